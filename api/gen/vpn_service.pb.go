@@ -283,6 +283,55 @@ func (DomainAction) EnumDescriptor() ([]byte, []int) {
 	return file_vpn_service_proto_rawDescGZIP(), []int{4}
 }
 
+type RateLimitScope int32
+
+const (
+	RateLimitScope_RATE_LIMIT_SCOPE_GLOBAL RateLimitScope = 0
+	RateLimitScope_RATE_LIMIT_SCOPE_TUNNEL RateLimitScope = 1 // target: tunnel ID
+	RateLimitScope_RATE_LIMIT_SCOPE_RULE   RateLimitScope = 2 // target: rule pattern
+)
+
+// Enum value maps for RateLimitScope.
+var (
+	RateLimitScope_name = map[int32]string{
+		0: "RATE_LIMIT_SCOPE_GLOBAL",
+		1: "RATE_LIMIT_SCOPE_TUNNEL",
+		2: "RATE_LIMIT_SCOPE_RULE",
+	}
+	RateLimitScope_value = map[string]int32{
+		"RATE_LIMIT_SCOPE_GLOBAL": 0,
+		"RATE_LIMIT_SCOPE_TUNNEL": 1,
+		"RATE_LIMIT_SCOPE_RULE":   2,
+	}
+)
+
+func (x RateLimitScope) Enum() *RateLimitScope {
+	p := new(RateLimitScope)
+	*p = x
+	return p
+}
+
+func (x RateLimitScope) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RateLimitScope) Descriptor() protoreflect.EnumDescriptor {
+	return file_vpn_service_proto_enumTypes[5].Descriptor()
+}
+
+func (RateLimitScope) Type() protoreflect.EnumType {
+	return &file_vpn_service_proto_enumTypes[5]
+}
+
+func (x RateLimitScope) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RateLimitScope.Descriptor instead.
+func (RateLimitScope) EnumDescriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{5}
+}
+
 type TunnelConfig struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
 	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -293,6 +342,9 @@ type TunnelConfig struct {
 	DisallowedIps  []string               `protobuf:"bytes,6,rep,name=disallowed_ips,json=disallowedIps,proto3" json:"disallowed_ips,omitempty"`
 	DisallowedApps []string               `protobuf:"bytes,7,rep,name=disallowed_apps,json=disallowedApps,proto3" json:"disallowed_apps,omitempty"`
 	SortIndex      int32                  `protobuf:"varint,8,opt,name=sort_index,json=sortIndex,proto3" json:"sort_index,omitempty"` // user-defined display order
+	RateLimit      *RateLimit             `protobuf:"bytes,9,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`  // unset = unlimited
+	Mtu            int32                  `protobuf:"varint,10,opt,name=mtu,proto3" json:"mtu,omitempty"`                             // fixed path MTU, 0 = probe
+	PmtuProbe      string                 `protobuf:"bytes,11,opt,name=pmtu_probe,json=pmtuProbe,proto3" json:"pmtu_probe,omitempty"` // probe target IPv4, "off" = no probing
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -383,6 +435,27 @@ func (x *TunnelConfig) GetSortIndex() int32 {
 	return 0
 }
 
+func (x *TunnelConfig) GetRateLimit() *RateLimit {
+	if x != nil {
+		return x.RateLimit
+	}
+	return nil
+}
+
+func (x *TunnelConfig) GetMtu() int32 {
+	if x != nil {
+		return x.Mtu
+	}
+	return 0
+}
+
+func (x *TunnelConfig) GetPmtuProbe() string {
+	if x != nil {
+		return x.PmtuProbe
+	}
+	return ""
+}
+
 type TunnelStatus struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -393,6 +466,7 @@ type TunnelStatus struct {
 	ExternalIp    string                 `protobuf:"bytes,6,opt,name=external_ip,json=externalIp,proto3" json:"external_ip,omitempty"`    // VPN server endpoint IP
 	CountryCode   string                 `protobuf:"bytes,7,opt,name=country_code,json=countryCode,proto3" json:"country_code,omitempty"` // 2-letter country code from geoip
 	SortIndex     int32                  `protobuf:"varint,8,opt,name=sort_index,json=sortIndex,proto3" json:"sort_index,omitempty"`      // user-defined display order
+	PathMtu       int32                  `protobuf:"varint,9,opt,name=path_mtu,json=pathMtu,proto3" json:"path_mtu,omitempty"`            // measured path MTU of raw-forwarded traffic, 0 = unknown
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -483,6 +557,13 @@ func (x *TunnelStatus) GetSortIndex() int32 {
 	return 0
 }
 
+func (x *TunnelStatus) GetPathMtu() int32 {
+	if x != nil {
+		return x.PathMtu
+	}
+	return 0
+}
+
 type DomainRule struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Pattern       string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`                   // "domain:vk.com", "full:example.com", "keyword:google", "geosite:ru"
@@ -564,9 +645,13 @@ type Rule struct {
 	Pattern       string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
 	TunnelId      string                 `protobuf:"bytes,2,opt,name=tunnel_id,json=tunnelId,proto3" json:"tunnel_id,omitempty"` // empty for drop-only rules
 	Fallback      FallbackPolicy         `protobuf:"varint,3,opt,name=fallback,proto3,enum=awg.vpn.v1.FallbackPolicy" json:"fallback,omitempty"`
-	Priority      string                 `protobuf:"bytes,4,opt,name=priority,proto3" json:"priority,omitempty"` // "auto", "realtime", "normal", "low"
-	Active        bool                   `protobuf:"varint,5,opt,name=active,proto3" json:"active,omitempty"`    // tunnel is connected, rule is active
-	Enabled       bool                   `protobuf:"varint,6,opt,name=enabled,proto3" json:"enabled,omitempty"`  // user can disable rule without deleting it
+	Priority      string                 `protobuf:"bytes,4,opt,name=priority,proto3" json:"priority,omitempty"`                           // "auto", "realtime", "normal", "low"
+	Active        bool                   `protobuf:"varint,5,opt,name=active,proto3" json:"active,omitempty"`                              // tunnel is connected, rule is active
+	Enabled       bool                   `protobuf:"varint,6,opt,name=enabled,proto3" json:"enabled,omitempty"`                            // user can disable rule without deleting it
+	RateLimit     *RateLimit             `protobuf:"bytes,7,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`        // unset = unlimited
+	UdpNat        string                 `protobuf:"bytes,8,opt,name=udp_nat,json=udpNat,proto3" json:"udp_nat,omitempty"`                 // "" / "symmetric" (default), "full_cone"
+	IdleTimeout   string                 `protobuf:"bytes,9,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`  // duration string, "" = 5m
+	MaxLifetime   string                 `protobuf:"bytes,10,opt,name=max_lifetime,json=maxLifetime,proto3" json:"max_lifetime,omitempty"` // duration string, "" = unlimited
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -643,6 +728,34 @@ func (x *Rule) GetEnabled() bool {
 	return false
 }
 
+func (x *Rule) GetRateLimit() *RateLimit {
+	if x != nil {
+		return x.RateLimit
+	}
+	return nil
+}
+
+func (x *Rule) GetUdpNat() string {
+	if x != nil {
+		return x.UdpNat
+	}
+	return ""
+}
+
+func (x *Rule) GetIdleTimeout() string {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return ""
+}
+
+func (x *Rule) GetMaxLifetime() string {
+	if x != nil {
+		return x.MaxLifetime
+	}
+	return ""
+}
+
 type DNSCacheConfig struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Enabled       bool                   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
@@ -853,6 +966,7 @@ type GlobalFilterConfig struct {
 	DisallowedIps  []string               `protobuf:"bytes,2,rep,name=disallowed_ips,json=disallowedIps,proto3" json:"disallowed_ips,omitempty"`
 	DisallowedApps []string               `protobuf:"bytes,3,rep,name=disallowed_apps,json=disallowedApps,proto3" json:"disallowed_apps,omitempty"`
 	DisableLocal   bool                   `protobuf:"varint,4,opt,name=disable_local,json=disableLocal,proto3" json:"disable_local,omitempty"`
+	RateLimit      *RateLimit             `protobuf:"bytes,5,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"` // unset = unlimited
 	unknownFields  protoimpl.UnknownFields
 	sizeCache      protoimpl.SizeCache
 }
@@ -915,6 +1029,13 @@ func (x *GlobalFilterConfig) GetDisableLocal() bool {
 	return false
 }
 
+func (x *GlobalFilterConfig) GetRateLimit() *RateLimit {
+	if x != nil {
+		return x.RateLimit
+	}
+	return nil
+}
+
 type LogConfig struct {
 	state              protoimpl.MessageState `protogen:"open.v1"`
 	Level              string                 `protobuf:"bytes,1,opt,name=level,proto3" json:"level,omitempty"`                                                                                     // "debug", "info", "warn", "error", "off"
@@ -2471,27 +2592,32 @@ func (x *SaveRulesResponse) GetError() string {
 	return ""
 }
 
-type DomainRuleListResponse struct {
+type ImportRulesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Rules         []*DomainRule          `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	Format        string                 `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`                                                                                                  // "proxifier", "clash", "v2rayn", "wiresock"; "" = detect
+	Content       []byte                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`                                                                                                // source file contents
+	FileName      string                 `protobuf:"bytes,3,opt,name=file_name,json=fileName,proto3" json:"file_name,omitempty"`                                                                              // used for format detection
+	TunnelMap     map[string]string      `protobuf:"bytes,4,rep,name=tunnel_map,json=tunnelMap,proto3" json:"tunnel_map,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"` // source proxy / outbound name → tunnel ID
+	DefaultTunnel string                 `protobuf:"bytes,5,opt,name=default_tunnel,json=defaultTunnel,proto3" json:"default_tunnel,omitempty"`                                                               // tunnel for unmapped proxies, WireSock AllowedApps
+	DryRun        bool                   `protobuf:"varint,6,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                                                                                   // convert only, do not change the config
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DomainRuleListResponse) Reset() {
-	*x = DomainRuleListResponse{}
+func (x *ImportRulesRequest) Reset() {
+	*x = ImportRulesRequest{}
 	mi := &file_vpn_service_proto_msgTypes[35]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DomainRuleListResponse) String() string {
+func (x *ImportRulesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DomainRuleListResponse) ProtoMessage() {}
+func (*ImportRulesRequest) ProtoMessage() {}
 
-func (x *DomainRuleListResponse) ProtoReflect() protoreflect.Message {
+func (x *ImportRulesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_vpn_service_proto_msgTypes[35]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2503,39 +2629,82 @@ func (x *DomainRuleListResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DomainRuleListResponse.ProtoReflect.Descriptor instead.
-func (*DomainRuleListResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ImportRulesRequest.ProtoReflect.Descriptor instead.
+func (*ImportRulesRequest) Descriptor() ([]byte, []int) {
 	return file_vpn_service_proto_rawDescGZIP(), []int{35}
 }
 
-func (x *DomainRuleListResponse) GetRules() []*DomainRule {
+func (x *ImportRulesRequest) GetFormat() string {
 	if x != nil {
-		return x.Rules
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ImportRulesRequest) GetContent() []byte {
+	if x != nil {
+		return x.Content
 	}
 	return nil
 }
 
-type SaveDomainRulesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Rules         []*DomainRule          `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+func (x *ImportRulesRequest) GetFileName() string {
+	if x != nil {
+		return x.FileName
+	}
+	return ""
 }
 
-func (x *SaveDomainRulesRequest) Reset() {
-	*x = SaveDomainRulesRequest{}
+func (x *ImportRulesRequest) GetTunnelMap() map[string]string {
+	if x != nil {
+		return x.TunnelMap
+	}
+	return nil
+}
+
+func (x *ImportRulesRequest) GetDefaultTunnel() string {
+	if x != nil {
+		return x.DefaultTunnel
+	}
+	return ""
+}
+
+func (x *ImportRulesRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ImportRulesResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Success        bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error          string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Format         string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"` // format actually used
+	Rules          []*Rule                `protobuf:"bytes,4,rep,name=rules,proto3" json:"rules,omitempty"`
+	DomainRules    []*DomainRule          `protobuf:"bytes,5,rep,name=domain_rules,json=domainRules,proto3" json:"domain_rules,omitempty"`
+	DisallowedApps []string               `protobuf:"bytes,6,rep,name=disallowed_apps,json=disallowedApps,proto3" json:"disallowed_apps,omitempty"`
+	DisallowedIps  []string               `protobuf:"bytes,7,rep,name=disallowed_ips,json=disallowedIps,proto3" json:"disallowed_ips,omitempty"`
+	Skipped        []*SkippedRule         `protobuf:"bytes,8,rep,name=skipped,proto3" json:"skipped,omitempty"`   // source rules that could not be represented
+	Warnings       []*SkippedRule         `protobuf:"bytes,9,rep,name=warnings,proto3" json:"warnings,omitempty"` // imported rules that override an earlier source rule
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ImportRulesResponse) Reset() {
+	*x = ImportRulesResponse{}
 	mi := &file_vpn_service_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SaveDomainRulesRequest) String() string {
+func (x *ImportRulesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SaveDomainRulesRequest) ProtoMessage() {}
+func (*ImportRulesResponse) ProtoMessage() {}
 
-func (x *SaveDomainRulesRequest) ProtoReflect() protoreflect.Message {
+func (x *ImportRulesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_vpn_service_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2547,40 +2716,96 @@ func (x *SaveDomainRulesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SaveDomainRulesRequest.ProtoReflect.Descriptor instead.
-func (*SaveDomainRulesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ImportRulesResponse.ProtoReflect.Descriptor instead.
+func (*ImportRulesResponse) Descriptor() ([]byte, []int) {
 	return file_vpn_service_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *SaveDomainRulesRequest) GetRules() []*DomainRule {
+func (x *ImportRulesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ImportRulesResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ImportRulesResponse) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *ImportRulesResponse) GetRules() []*Rule {
 	if x != nil {
 		return x.Rules
 	}
 	return nil
 }
 
-type SaveDomainRulesResponse struct {
+func (x *ImportRulesResponse) GetDomainRules() []*DomainRule {
+	if x != nil {
+		return x.DomainRules
+	}
+	return nil
+}
+
+func (x *ImportRulesResponse) GetDisallowedApps() []string {
+	if x != nil {
+		return x.DisallowedApps
+	}
+	return nil
+}
+
+func (x *ImportRulesResponse) GetDisallowedIps() []string {
+	if x != nil {
+		return x.DisallowedIps
+	}
+	return nil
+}
+
+func (x *ImportRulesResponse) GetSkipped() []*SkippedRule {
+	if x != nil {
+		return x.Skipped
+	}
+	return nil
+}
+
+func (x *ImportRulesResponse) GetWarnings() []*SkippedRule {
+	if x != nil {
+		return x.Warnings
+	}
+	return nil
+}
+
+type SkippedRule struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SaveDomainRulesResponse) Reset() {
-	*x = SaveDomainRulesResponse{}
+func (x *SkippedRule) Reset() {
+	*x = SkippedRule{}
 	mi := &file_vpn_service_proto_msgTypes[37]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SaveDomainRulesResponse) String() string {
+func (x *SkippedRule) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SaveDomainRulesResponse) ProtoMessage() {}
+func (*SkippedRule) ProtoMessage() {}
 
-func (x *SaveDomainRulesResponse) ProtoReflect() protoreflect.Message {
+func (x *SkippedRule) ProtoReflect() protoreflect.Message {
 	mi := &file_vpn_service_proto_msgTypes[37]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2592,46 +2817,46 @@ func (x *SaveDomainRulesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SaveDomainRulesResponse.ProtoReflect.Descriptor instead.
-func (*SaveDomainRulesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SkippedRule.ProtoReflect.Descriptor instead.
+func (*SkippedRule) Descriptor() ([]byte, []int) {
 	return file_vpn_service_proto_rawDescGZIP(), []int{37}
 }
 
-func (x *SaveDomainRulesResponse) GetSuccess() bool {
+func (x *SkippedRule) GetSource() string {
 	if x != nil {
-		return x.Success
+		return x.Source
 	}
-	return false
+	return ""
 }
 
-func (x *SaveDomainRulesResponse) GetError() string {
+func (x *SkippedRule) GetReason() string {
 	if x != nil {
-		return x.Error
+		return x.Reason
 	}
 	return ""
 }
 
-type GeositeCategoriesResponse struct {
+type DomainRuleListResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Categories    []string               `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"`
+	Rules         []*DomainRule          `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *GeositeCategoriesResponse) Reset() {
-	*x = GeositeCategoriesResponse{}
+func (x *DomainRuleListResponse) Reset() {
+	*x = DomainRuleListResponse{}
 	mi := &file_vpn_service_proto_msgTypes[38]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *GeositeCategoriesResponse) String() string {
+func (x *DomainRuleListResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*GeositeCategoriesResponse) ProtoMessage() {}
+func (*DomainRuleListResponse) ProtoMessage() {}
 
-func (x *GeositeCategoriesResponse) ProtoReflect() protoreflect.Message {
+func (x *DomainRuleListResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_vpn_service_proto_msgTypes[38]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2643,40 +2868,39 @@ func (x *GeositeCategoriesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use GeositeCategoriesResponse.ProtoReflect.Descriptor instead.
-func (*GeositeCategoriesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use DomainRuleListResponse.ProtoReflect.Descriptor instead.
+func (*DomainRuleListResponse) Descriptor() ([]byte, []int) {
 	return file_vpn_service_proto_rawDescGZIP(), []int{38}
 }
 
-func (x *GeositeCategoriesResponse) GetCategories() []string {
+func (x *DomainRuleListResponse) GetRules() []*DomainRule {
 	if x != nil {
-		return x.Categories
+		return x.Rules
 	}
 	return nil
 }
 
-type UpdateGeositeResponse struct {
+type SaveDomainRulesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Rules         []*DomainRule          `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateGeositeResponse) Reset() {
-	*x = UpdateGeositeResponse{}
+func (x *SaveDomainRulesRequest) Reset() {
+	*x = SaveDomainRulesRequest{}
 	mi := &file_vpn_service_proto_msgTypes[39]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateGeositeResponse) String() string {
+func (x *SaveDomainRulesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateGeositeResponse) ProtoMessage() {}
+func (*SaveDomainRulesRequest) ProtoMessage() {}
 
-func (x *UpdateGeositeResponse) ProtoReflect() protoreflect.Message {
+func (x *SaveDomainRulesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_vpn_service_proto_msgTypes[39]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -2688,48 +2912,189 @@ func (x *UpdateGeositeResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateGeositeResponse.ProtoReflect.Descriptor instead.
-func (*UpdateGeositeResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use SaveDomainRulesRequest.ProtoReflect.Descriptor instead.
+func (*SaveDomainRulesRequest) Descriptor() ([]byte, []int) {
 	return file_vpn_service_proto_rawDescGZIP(), []int{39}
 }
 
-func (x *UpdateGeositeResponse) GetSuccess() bool {
-	if x != nil {
-		return x.Success
-	}
-	return false
-}
-
-func (x *UpdateGeositeResponse) GetError() string {
+func (x *SaveDomainRulesRequest) GetRules() []*DomainRule {
 	if x != nil {
-		return x.Error
+		return x.Rules
 	}
-	return ""
+	return nil
 }
 
-type SaveConfigRequest struct {
-	state              protoimpl.MessageState `protogen:"open.v1"`
-	Config             *AppConfig             `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
-	RestartIfConnected bool                   `protobuf:"varint,2,opt,name=restart_if_connected,json=restartIfConnected,proto3" json:"restart_if_connected,omitempty"`
-	unknownFields      protoimpl.UnknownFields
-	sizeCache          protoimpl.SizeCache
+type SaveDomainRulesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SaveConfigRequest) Reset() {
-	*x = SaveConfigRequest{}
+func (x *SaveDomainRulesResponse) Reset() {
+	*x = SaveDomainRulesResponse{}
 	mi := &file_vpn_service_proto_msgTypes[40]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SaveConfigRequest) String() string {
+func (x *SaveDomainRulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SaveDomainRulesResponse) ProtoMessage() {}
+
+func (x *SaveDomainRulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SaveDomainRulesResponse.ProtoReflect.Descriptor instead.
+func (*SaveDomainRulesResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *SaveDomainRulesResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SaveDomainRulesResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type GeositeCategoriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categories    []string               `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeositeCategoriesResponse) Reset() {
+	*x = GeositeCategoriesResponse{}
+	mi := &file_vpn_service_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeositeCategoriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeositeCategoriesResponse) ProtoMessage() {}
+
+func (x *GeositeCategoriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeositeCategoriesResponse.ProtoReflect.Descriptor instead.
+func (*GeositeCategoriesResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *GeositeCategoriesResponse) GetCategories() []string {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type UpdateGeositeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateGeositeResponse) Reset() {
+	*x = UpdateGeositeResponse{}
+	mi := &file_vpn_service_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGeositeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGeositeResponse) ProtoMessage() {}
+
+func (x *UpdateGeositeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGeositeResponse.ProtoReflect.Descriptor instead.
+func (*UpdateGeositeResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *UpdateGeositeResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *UpdateGeositeResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type SaveConfigRequest struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Config             *AppConfig             `protobuf:"bytes,1,opt,name=config,proto3" json:"config,omitempty"`
+	RestartIfConnected bool                   `protobuf:"varint,2,opt,name=restart_if_connected,json=restartIfConnected,proto3" json:"restart_if_connected,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *SaveConfigRequest) Reset() {
+	*x = SaveConfigRequest{}
+	mi := &file_vpn_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SaveConfigRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
 func (*SaveConfigRequest) ProtoMessage() {}
 
 func (x *SaveConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[40]
+	mi := &file_vpn_service_proto_msgTypes[43]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2742,7 +3107,7 @@ func (x *SaveConfigRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SaveConfigRequest.ProtoReflect.Descriptor instead.
 func (*SaveConfigRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{40}
+	return file_vpn_service_proto_rawDescGZIP(), []int{43}
 }
 
 func (x *SaveConfigRequest) GetConfig() *AppConfig {
@@ -2770,7 +3135,7 @@ type SaveConfigResponse struct {
 
 func (x *SaveConfigResponse) Reset() {
 	*x = SaveConfigResponse{}
-	mi := &file_vpn_service_proto_msgTypes[41]
+	mi := &file_vpn_service_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2782,7 +3147,7 @@ func (x *SaveConfigResponse) String() string {
 func (*SaveConfigResponse) ProtoMessage() {}
 
 func (x *SaveConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[41]
+	mi := &file_vpn_service_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2795,7 +3160,7 @@ func (x *SaveConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SaveConfigResponse.ProtoReflect.Descriptor instead.
 func (*SaveConfigResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{41}
+	return file_vpn_service_proto_rawDescGZIP(), []int{44}
 }
 
 func (x *SaveConfigResponse) GetSuccess() bool {
@@ -2828,7 +3193,7 @@ type ExportConfigResponse struct {
 
 func (x *ExportConfigResponse) Reset() {
 	*x = ExportConfigResponse{}
-	mi := &file_vpn_service_proto_msgTypes[42]
+	mi := &file_vpn_service_proto_msgTypes[45]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2840,7 +3205,7 @@ func (x *ExportConfigResponse) String() string {
 func (*ExportConfigResponse) ProtoMessage() {}
 
 func (x *ExportConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[42]
+	mi := &file_vpn_service_proto_msgTypes[45]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2853,7 +3218,7 @@ func (x *ExportConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ExportConfigResponse.ProtoReflect.Descriptor instead.
 func (*ExportConfigResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{42}
+	return file_vpn_service_proto_rawDescGZIP(), []int{45}
 }
 
 func (x *ExportConfigResponse) GetZipData() []byte {
@@ -2872,7 +3237,7 @@ type ImportConfigRequest struct {
 
 func (x *ImportConfigRequest) Reset() {
 	*x = ImportConfigRequest{}
-	mi := &file_vpn_service_proto_msgTypes[43]
+	mi := &file_vpn_service_proto_msgTypes[46]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2884,7 +3249,7 @@ func (x *ImportConfigRequest) String() string {
 func (*ImportConfigRequest) ProtoMessage() {}
 
 func (x *ImportConfigRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[43]
+	mi := &file_vpn_service_proto_msgTypes[46]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2897,7 +3262,7 @@ func (x *ImportConfigRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ImportConfigRequest.ProtoReflect.Descriptor instead.
 func (*ImportConfigRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{43}
+	return file_vpn_service_proto_rawDescGZIP(), []int{46}
 }
 
 func (x *ImportConfigRequest) GetZipData() []byte {
@@ -2917,7 +3282,7 @@ type ImportConfigResponse struct {
 
 func (x *ImportConfigResponse) Reset() {
 	*x = ImportConfigResponse{}
-	mi := &file_vpn_service_proto_msgTypes[44]
+	mi := &file_vpn_service_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2929,7 +3294,7 @@ func (x *ImportConfigResponse) String() string {
 func (*ImportConfigResponse) ProtoMessage() {}
 
 func (x *ImportConfigResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[44]
+	mi := &file_vpn_service_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2942,7 +3307,7 @@ func (x *ImportConfigResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ImportConfigResponse.ProtoReflect.Descriptor instead.
 func (*ImportConfigResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{44}
+	return file_vpn_service_proto_rawDescGZIP(), []int{47}
 }
 
 func (x *ImportConfigResponse) GetSuccess() bool {
@@ -2970,7 +3335,7 @@ type LogStreamRequest struct {
 
 func (x *LogStreamRequest) Reset() {
 	*x = LogStreamRequest{}
-	mi := &file_vpn_service_proto_msgTypes[45]
+	mi := &file_vpn_service_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2982,7 +3347,7 @@ func (x *LogStreamRequest) String() string {
 func (*LogStreamRequest) ProtoMessage() {}
 
 func (x *LogStreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[45]
+	mi := &file_vpn_service_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2995,7 +3360,7 @@ func (x *LogStreamRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogStreamRequest.ProtoReflect.Descriptor instead.
 func (*LogStreamRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{45}
+	return file_vpn_service_proto_rawDescGZIP(), []int{48}
 }
 
 func (x *LogStreamRequest) GetMinLevel() LogLevel {
@@ -3028,7 +3393,7 @@ type StatsStreamRequest struct {
 
 func (x *StatsStreamRequest) Reset() {
 	*x = StatsStreamRequest{}
-	mi := &file_vpn_service_proto_msgTypes[46]
+	mi := &file_vpn_service_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3040,7 +3405,7 @@ func (x *StatsStreamRequest) String() string {
 func (*StatsStreamRequest) ProtoMessage() {}
 
 func (x *StatsStreamRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[46]
+	mi := &file_vpn_service_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3053,7 +3418,7 @@ func (x *StatsStreamRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StatsStreamRequest.ProtoReflect.Descriptor instead.
 func (*StatsStreamRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{46}
+	return file_vpn_service_proto_rawDescGZIP(), []int{49}
 }
 
 func (x *StatsStreamRequest) GetIntervalMs() int32 {
@@ -3072,7 +3437,7 @@ type ProcessListRequest struct {
 
 func (x *ProcessListRequest) Reset() {
 	*x = ProcessListRequest{}
-	mi := &file_vpn_service_proto_msgTypes[47]
+	mi := &file_vpn_service_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3084,7 +3449,7 @@ func (x *ProcessListRequest) String() string {
 func (*ProcessListRequest) ProtoMessage() {}
 
 func (x *ProcessListRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[47]
+	mi := &file_vpn_service_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3097,7 +3462,7 @@ func (x *ProcessListRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessListRequest.ProtoReflect.Descriptor instead.
 func (*ProcessListRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{47}
+	return file_vpn_service_proto_rawDescGZIP(), []int{50}
 }
 
 func (x *ProcessListRequest) GetNameFilter() string {
@@ -3116,7 +3481,7 @@ type ProcessListResponse struct {
 
 func (x *ProcessListResponse) Reset() {
 	*x = ProcessListResponse{}
-	mi := &file_vpn_service_proto_msgTypes[48]
+	mi := &file_vpn_service_proto_msgTypes[51]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3128,7 +3493,7 @@ func (x *ProcessListResponse) String() string {
 func (*ProcessListResponse) ProtoMessage() {}
 
 func (x *ProcessListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[48]
+	mi := &file_vpn_service_proto_msgTypes[51]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3141,7 +3506,7 @@ func (x *ProcessListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ProcessListResponse.ProtoReflect.Descriptor instead.
 func (*ProcessListResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{48}
+	return file_vpn_service_proto_rawDescGZIP(), []int{51}
 }
 
 func (x *ProcessListResponse) GetProcesses() []*ProcessInfo {
@@ -3160,7 +3525,7 @@ type SubscriptionListResponse struct {
 
 func (x *SubscriptionListResponse) Reset() {
 	*x = SubscriptionListResponse{}
-	mi := &file_vpn_service_proto_msgTypes[49]
+	mi := &file_vpn_service_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3172,7 +3537,7 @@ func (x *SubscriptionListResponse) String() string {
 func (*SubscriptionListResponse) ProtoMessage() {}
 
 func (x *SubscriptionListResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[49]
+	mi := &file_vpn_service_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3185,7 +3550,7 @@ func (x *SubscriptionListResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SubscriptionListResponse.ProtoReflect.Descriptor instead.
 func (*SubscriptionListResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{49}
+	return file_vpn_service_proto_rawDescGZIP(), []int{52}
 }
 
 func (x *SubscriptionListResponse) GetSubscriptions() []*SubscriptionStatus {
@@ -3204,7 +3569,7 @@ type AddSubscriptionRequest struct {
 
 func (x *AddSubscriptionRequest) Reset() {
 	*x = AddSubscriptionRequest{}
-	mi := &file_vpn_service_proto_msgTypes[50]
+	mi := &file_vpn_service_proto_msgTypes[53]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3216,7 +3581,7 @@ func (x *AddSubscriptionRequest) String() string {
 func (*AddSubscriptionRequest) ProtoMessage() {}
 
 func (x *AddSubscriptionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[50]
+	mi := &file_vpn_service_proto_msgTypes[53]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3229,7 +3594,7 @@ func (x *AddSubscriptionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddSubscriptionRequest.ProtoReflect.Descriptor instead.
 func (*AddSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{50}
+	return file_vpn_service_proto_rawDescGZIP(), []int{53}
 }
 
 func (x *AddSubscriptionRequest) GetConfig() *SubscriptionConfig {
@@ -3250,7 +3615,7 @@ type AddSubscriptionResponse struct {
 
 func (x *AddSubscriptionResponse) Reset() {
 	*x = AddSubscriptionResponse{}
-	mi := &file_vpn_service_proto_msgTypes[51]
+	mi := &file_vpn_service_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3262,7 +3627,7 @@ func (x *AddSubscriptionResponse) String() string {
 func (*AddSubscriptionResponse) ProtoMessage() {}
 
 func (x *AddSubscriptionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[51]
+	mi := &file_vpn_service_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3275,7 +3640,7 @@ func (x *AddSubscriptionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AddSubscriptionResponse.ProtoReflect.Descriptor instead.
 func (*AddSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{51}
+	return file_vpn_service_proto_rawDescGZIP(), []int{54}
 }
 
 func (x *AddSubscriptionResponse) GetSuccess() bool {
@@ -3308,7 +3673,7 @@ type RemoveSubscriptionRequest struct {
 
 func (x *RemoveSubscriptionRequest) Reset() {
 	*x = RemoveSubscriptionRequest{}
-	mi := &file_vpn_service_proto_msgTypes[52]
+	mi := &file_vpn_service_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3320,7 +3685,7 @@ func (x *RemoveSubscriptionRequest) String() string {
 func (*RemoveSubscriptionRequest) ProtoMessage() {}
 
 func (x *RemoveSubscriptionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[52]
+	mi := &file_vpn_service_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3333,7 +3698,7 @@ func (x *RemoveSubscriptionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveSubscriptionRequest.ProtoReflect.Descriptor instead.
 func (*RemoveSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{52}
+	return file_vpn_service_proto_rawDescGZIP(), []int{55}
 }
 
 func (x *RemoveSubscriptionRequest) GetName() string {
@@ -3353,7 +3718,7 @@ type RemoveSubscriptionResponse struct {
 
 func (x *RemoveSubscriptionResponse) Reset() {
 	*x = RemoveSubscriptionResponse{}
-	mi := &file_vpn_service_proto_msgTypes[53]
+	mi := &file_vpn_service_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3365,7 +3730,7 @@ func (x *RemoveSubscriptionResponse) String() string {
 func (*RemoveSubscriptionResponse) ProtoMessage() {}
 
 func (x *RemoveSubscriptionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[53]
+	mi := &file_vpn_service_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3378,7 +3743,7 @@ func (x *RemoveSubscriptionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RemoveSubscriptionResponse.ProtoReflect.Descriptor instead.
 func (*RemoveSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{53}
+	return file_vpn_service_proto_rawDescGZIP(), []int{56}
 }
 
 func (x *RemoveSubscriptionResponse) GetSuccess() bool {
@@ -3404,7 +3769,7 @@ type RefreshSubscriptionRequest struct {
 
 func (x *RefreshSubscriptionRequest) Reset() {
 	*x = RefreshSubscriptionRequest{}
-	mi := &file_vpn_service_proto_msgTypes[54]
+	mi := &file_vpn_service_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3416,7 +3781,7 @@ func (x *RefreshSubscriptionRequest) String() string {
 func (*RefreshSubscriptionRequest) ProtoMessage() {}
 
 func (x *RefreshSubscriptionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[54]
+	mi := &file_vpn_service_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3429,7 +3794,7 @@ func (x *RefreshSubscriptionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RefreshSubscriptionRequest.ProtoReflect.Descriptor instead.
 func (*RefreshSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{54}
+	return file_vpn_service_proto_rawDescGZIP(), []int{57}
 }
 
 func (x *RefreshSubscriptionRequest) GetName() string {
@@ -3450,7 +3815,7 @@ type RefreshSubscriptionResponse struct {
 
 func (x *RefreshSubscriptionResponse) Reset() {
 	*x = RefreshSubscriptionResponse{}
-	mi := &file_vpn_service_proto_msgTypes[55]
+	mi := &file_vpn_service_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3462,7 +3827,7 @@ func (x *RefreshSubscriptionResponse) String() string {
 func (*RefreshSubscriptionResponse) ProtoMessage() {}
 
 func (x *RefreshSubscriptionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[55]
+	mi := &file_vpn_service_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3475,7 +3840,7 @@ func (x *RefreshSubscriptionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RefreshSubscriptionResponse.ProtoReflect.Descriptor instead.
 func (*RefreshSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{55}
+	return file_vpn_service_proto_rawDescGZIP(), []int{58}
 }
 
 func (x *RefreshSubscriptionResponse) GetSuccess() bool {
@@ -3508,7 +3873,7 @@ type UpdateSubscriptionRequest struct {
 
 func (x *UpdateSubscriptionRequest) Reset() {
 	*x = UpdateSubscriptionRequest{}
-	mi := &file_vpn_service_proto_msgTypes[56]
+	mi := &file_vpn_service_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3520,7 +3885,7 @@ func (x *UpdateSubscriptionRequest) String() string {
 func (*UpdateSubscriptionRequest) ProtoMessage() {}
 
 func (x *UpdateSubscriptionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[56]
+	mi := &file_vpn_service_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3533,7 +3898,7 @@ func (x *UpdateSubscriptionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateSubscriptionRequest.ProtoReflect.Descriptor instead.
 func (*UpdateSubscriptionRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{56}
+	return file_vpn_service_proto_rawDescGZIP(), []int{59}
 }
 
 func (x *UpdateSubscriptionRequest) GetConfig() *SubscriptionConfig {
@@ -3553,7 +3918,7 @@ type UpdateSubscriptionResponse struct {
 
 func (x *UpdateSubscriptionResponse) Reset() {
 	*x = UpdateSubscriptionResponse{}
-	mi := &file_vpn_service_proto_msgTypes[57]
+	mi := &file_vpn_service_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3565,7 +3930,7 @@ func (x *UpdateSubscriptionResponse) String() string {
 func (*UpdateSubscriptionResponse) ProtoMessage() {}
 
 func (x *UpdateSubscriptionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[57]
+	mi := &file_vpn_service_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3578,7 +3943,7 @@ func (x *UpdateSubscriptionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateSubscriptionResponse.ProtoReflect.Descriptor instead.
 func (*UpdateSubscriptionResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{57}
+	return file_vpn_service_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *UpdateSubscriptionResponse) GetSuccess() bool {
@@ -3605,7 +3970,7 @@ type RenameTunnelRequest struct {
 
 func (x *RenameTunnelRequest) Reset() {
 	*x = RenameTunnelRequest{}
-	mi := &file_vpn_service_proto_msgTypes[58]
+	mi := &file_vpn_service_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3617,7 +3982,7 @@ func (x *RenameTunnelRequest) String() string {
 func (*RenameTunnelRequest) ProtoMessage() {}
 
 func (x *RenameTunnelRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[58]
+	mi := &file_vpn_service_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3630,7 +3995,7 @@ func (x *RenameTunnelRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RenameTunnelRequest.ProtoReflect.Descriptor instead.
 func (*RenameTunnelRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{58}
+	return file_vpn_service_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *RenameTunnelRequest) GetTunnelId() string {
@@ -3657,7 +4022,7 @@ type RenameTunnelResponse struct {
 
 func (x *RenameTunnelResponse) Reset() {
 	*x = RenameTunnelResponse{}
-	mi := &file_vpn_service_proto_msgTypes[59]
+	mi := &file_vpn_service_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3669,7 +4034,7 @@ func (x *RenameTunnelResponse) String() string {
 func (*RenameTunnelResponse) ProtoMessage() {}
 
 func (x *RenameTunnelResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[59]
+	mi := &file_vpn_service_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3682,7 +4047,7 @@ func (x *RenameTunnelResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RenameTunnelResponse.ProtoReflect.Descriptor instead.
 func (*RenameTunnelResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{59}
+	return file_vpn_service_proto_rawDescGZIP(), []int{62}
 }
 
 func (x *RenameTunnelResponse) GetSuccess() bool {
@@ -3713,7 +4078,7 @@ type ServiceStatus struct {
 
 func (x *ServiceStatus) Reset() {
 	*x = ServiceStatus{}
-	mi := &file_vpn_service_proto_msgTypes[60]
+	mi := &file_vpn_service_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3725,7 +4090,7 @@ func (x *ServiceStatus) String() string {
 func (*ServiceStatus) ProtoMessage() {}
 
 func (x *ServiceStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[60]
+	mi := &file_vpn_service_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3738,7 +4103,7 @@ func (x *ServiceStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ServiceStatus.ProtoReflect.Descriptor instead.
 func (*ServiceStatus) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{60}
+	return file_vpn_service_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *ServiceStatus) GetRunning() bool {
@@ -3791,7 +4156,7 @@ type ActivateRequest struct {
 
 func (x *ActivateRequest) Reset() {
 	*x = ActivateRequest{}
-	mi := &file_vpn_service_proto_msgTypes[61]
+	mi := &file_vpn_service_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3803,7 +4168,7 @@ func (x *ActivateRequest) String() string {
 func (*ActivateRequest) ProtoMessage() {}
 
 func (x *ActivateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[61]
+	mi := &file_vpn_service_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3816,7 +4181,7 @@ func (x *ActivateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ActivateRequest.ProtoReflect.Descriptor instead.
 func (*ActivateRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{61}
+	return file_vpn_service_proto_rawDescGZIP(), []int{64}
 }
 
 type ActivateResponse struct {
@@ -3829,7 +4194,7 @@ type ActivateResponse struct {
 
 func (x *ActivateResponse) Reset() {
 	*x = ActivateResponse{}
-	mi := &file_vpn_service_proto_msgTypes[62]
+	mi := &file_vpn_service_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3841,7 +4206,7 @@ func (x *ActivateResponse) String() string {
 func (*ActivateResponse) ProtoMessage() {}
 
 func (x *ActivateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[62]
+	mi := &file_vpn_service_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3854,7 +4219,7 @@ func (x *ActivateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ActivateResponse.ProtoReflect.Descriptor instead.
 func (*ActivateResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{62}
+	return file_vpn_service_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *ActivateResponse) GetSuccess() bool {
@@ -3879,7 +4244,7 @@ type DeactivateRequest struct {
 
 func (x *DeactivateRequest) Reset() {
 	*x = DeactivateRequest{}
-	mi := &file_vpn_service_proto_msgTypes[63]
+	mi := &file_vpn_service_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3891,7 +4256,7 @@ func (x *DeactivateRequest) String() string {
 func (*DeactivateRequest) ProtoMessage() {}
 
 func (x *DeactivateRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[63]
+	mi := &file_vpn_service_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3904,7 +4269,7 @@ func (x *DeactivateRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeactivateRequest.ProtoReflect.Descriptor instead.
 func (*DeactivateRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{63}
+	return file_vpn_service_proto_rawDescGZIP(), []int{66}
 }
 
 type DeactivateResponse struct {
@@ -3917,7 +4282,7 @@ type DeactivateResponse struct {
 
 func (x *DeactivateResponse) Reset() {
 	*x = DeactivateResponse{}
-	mi := &file_vpn_service_proto_msgTypes[64]
+	mi := &file_vpn_service_proto_msgTypes[67]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3929,7 +4294,7 @@ func (x *DeactivateResponse) String() string {
 func (*DeactivateResponse) ProtoMessage() {}
 
 func (x *DeactivateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[64]
+	mi := &file_vpn_service_proto_msgTypes[67]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3942,7 +4307,7 @@ func (x *DeactivateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeactivateResponse.ProtoReflect.Descriptor instead.
 func (*DeactivateResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{64}
+	return file_vpn_service_proto_rawDescGZIP(), []int{67}
 }
 
 func (x *DeactivateResponse) GetSuccess() bool {
@@ -3971,7 +4336,7 @@ type UpdateInfo struct {
 
 func (x *UpdateInfo) Reset() {
 	*x = UpdateInfo{}
-	mi := &file_vpn_service_proto_msgTypes[65]
+	mi := &file_vpn_service_proto_msgTypes[68]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -3983,7 +4348,7 @@ func (x *UpdateInfo) String() string {
 func (*UpdateInfo) ProtoMessage() {}
 
 func (x *UpdateInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[65]
+	mi := &file_vpn_service_proto_msgTypes[68]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -3996,7 +4361,7 @@ func (x *UpdateInfo) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateInfo.ProtoReflect.Descriptor instead.
 func (*UpdateInfo) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{65}
+	return file_vpn_service_proto_rawDescGZIP(), []int{68}
 }
 
 func (x *UpdateInfo) GetVersion() string {
@@ -4037,7 +4402,7 @@ type CheckUpdateResponse struct {
 
 func (x *CheckUpdateResponse) Reset() {
 	*x = CheckUpdateResponse{}
-	mi := &file_vpn_service_proto_msgTypes[66]
+	mi := &file_vpn_service_proto_msgTypes[69]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4049,7 +4414,7 @@ func (x *CheckUpdateResponse) String() string {
 func (*CheckUpdateResponse) ProtoMessage() {}
 
 func (x *CheckUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[66]
+	mi := &file_vpn_service_proto_msgTypes[69]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4062,7 +4427,7 @@ func (x *CheckUpdateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckUpdateResponse.ProtoReflect.Descriptor instead.
 func (*CheckUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{66}
+	return file_vpn_service_proto_rawDescGZIP(), []int{69}
 }
 
 func (x *CheckUpdateResponse) GetAvailable() bool {
@@ -4089,7 +4454,7 @@ type ApplyUpdateResponse struct {
 
 func (x *ApplyUpdateResponse) Reset() {
 	*x = ApplyUpdateResponse{}
-	mi := &file_vpn_service_proto_msgTypes[67]
+	mi := &file_vpn_service_proto_msgTypes[70]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4101,7 +4466,7 @@ func (x *ApplyUpdateResponse) String() string {
 func (*ApplyUpdateResponse) ProtoMessage() {}
 
 func (x *ApplyUpdateResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[67]
+	mi := &file_vpn_service_proto_msgTypes[70]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4114,7 +4479,7 @@ func (x *ApplyUpdateResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ApplyUpdateResponse.ProtoReflect.Descriptor instead.
 func (*ApplyUpdateResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{67}
+	return file_vpn_service_proto_rawDescGZIP(), []int{70}
 }
 
 func (x *ApplyUpdateResponse) GetSuccess() bool {
@@ -4145,7 +4510,7 @@ type UpdateProgress struct {
 
 func (x *UpdateProgress) Reset() {
 	*x = UpdateProgress{}
-	mi := &file_vpn_service_proto_msgTypes[68]
+	mi := &file_vpn_service_proto_msgTypes[71]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4157,7 +4522,7 @@ func (x *UpdateProgress) String() string {
 func (*UpdateProgress) ProtoMessage() {}
 
 func (x *UpdateProgress) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[68]
+	mi := &file_vpn_service_proto_msgTypes[71]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4170,7 +4535,7 @@ func (x *UpdateProgress) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateProgress.ProtoReflect.Descriptor instead.
 func (*UpdateProgress) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{68}
+	return file_vpn_service_proto_rawDescGZIP(), []int{71}
 }
 
 func (x *UpdateProgress) GetStage() string {
@@ -4226,7 +4591,7 @@ type AutostartConfig struct {
 
 func (x *AutostartConfig) Reset() {
 	*x = AutostartConfig{}
-	mi := &file_vpn_service_proto_msgTypes[69]
+	mi := &file_vpn_service_proto_msgTypes[72]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4238,7 +4603,7 @@ func (x *AutostartConfig) String() string {
 func (*AutostartConfig) ProtoMessage() {}
 
 func (x *AutostartConfig) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[69]
+	mi := &file_vpn_service_proto_msgTypes[72]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4251,7 +4616,7 @@ func (x *AutostartConfig) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AutostartConfig.ProtoReflect.Descriptor instead.
 func (*AutostartConfig) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{69}
+	return file_vpn_service_proto_rawDescGZIP(), []int{72}
 }
 
 func (x *AutostartConfig) GetEnabled() bool {
@@ -4284,7 +4649,7 @@ type SetAutostartRequest struct {
 
 func (x *SetAutostartRequest) Reset() {
 	*x = SetAutostartRequest{}
-	mi := &file_vpn_service_proto_msgTypes[70]
+	mi := &file_vpn_service_proto_msgTypes[73]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4296,7 +4661,7 @@ func (x *SetAutostartRequest) String() string {
 func (*SetAutostartRequest) ProtoMessage() {}
 
 func (x *SetAutostartRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[70]
+	mi := &file_vpn_service_proto_msgTypes[73]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4309,7 +4674,7 @@ func (x *SetAutostartRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetAutostartRequest.ProtoReflect.Descriptor instead.
 func (*SetAutostartRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{70}
+	return file_vpn_service_proto_rawDescGZIP(), []int{73}
 }
 
 func (x *SetAutostartRequest) GetConfig() *AutostartConfig {
@@ -4329,7 +4694,7 @@ type SetAutostartResponse struct {
 
 func (x *SetAutostartResponse) Reset() {
 	*x = SetAutostartResponse{}
-	mi := &file_vpn_service_proto_msgTypes[71]
+	mi := &file_vpn_service_proto_msgTypes[74]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4341,7 +4706,7 @@ func (x *SetAutostartResponse) String() string {
 func (*SetAutostartResponse) ProtoMessage() {}
 
 func (x *SetAutostartResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[71]
+	mi := &file_vpn_service_proto_msgTypes[74]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4354,7 +4719,7 @@ func (x *SetAutostartResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetAutostartResponse.ProtoReflect.Descriptor instead.
 func (*SetAutostartResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{71}
+	return file_vpn_service_proto_rawDescGZIP(), []int{74}
 }
 
 func (x *SetAutostartResponse) GetSuccess() bool {
@@ -4384,7 +4749,7 @@ type ConflictingService struct {
 
 func (x *ConflictingService) Reset() {
 	*x = ConflictingService{}
-	mi := &file_vpn_service_proto_msgTypes[72]
+	mi := &file_vpn_service_proto_msgTypes[75]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4396,7 +4761,7 @@ func (x *ConflictingService) String() string {
 func (*ConflictingService) ProtoMessage() {}
 
 func (x *ConflictingService) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[72]
+	mi := &file_vpn_service_proto_msgTypes[75]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4409,7 +4774,7 @@ func (x *ConflictingService) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConflictingService.ProtoReflect.Descriptor instead.
 func (*ConflictingService) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{72}
+	return file_vpn_service_proto_rawDescGZIP(), []int{75}
 }
 
 func (x *ConflictingService) GetName() string {
@@ -4456,7 +4821,7 @@ type ConflictingServicesResponse struct {
 
 func (x *ConflictingServicesResponse) Reset() {
 	*x = ConflictingServicesResponse{}
-	mi := &file_vpn_service_proto_msgTypes[73]
+	mi := &file_vpn_service_proto_msgTypes[76]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4468,7 +4833,7 @@ func (x *ConflictingServicesResponse) String() string {
 func (*ConflictingServicesResponse) ProtoMessage() {}
 
 func (x *ConflictingServicesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[73]
+	mi := &file_vpn_service_proto_msgTypes[76]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4481,7 +4846,7 @@ func (x *ConflictingServicesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConflictingServicesResponse.ProtoReflect.Descriptor instead.
 func (*ConflictingServicesResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{73}
+	return file_vpn_service_proto_rawDescGZIP(), []int{76}
 }
 
 func (x *ConflictingServicesResponse) GetServices() []*ConflictingService {
@@ -4500,7 +4865,7 @@ type StopConflictingServicesRequest struct {
 
 func (x *StopConflictingServicesRequest) Reset() {
 	*x = StopConflictingServicesRequest{}
-	mi := &file_vpn_service_proto_msgTypes[74]
+	mi := &file_vpn_service_proto_msgTypes[77]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4512,7 +4877,7 @@ func (x *StopConflictingServicesRequest) String() string {
 func (*StopConflictingServicesRequest) ProtoMessage() {}
 
 func (x *StopConflictingServicesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[74]
+	mi := &file_vpn_service_proto_msgTypes[77]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4525,7 +4890,7 @@ func (x *StopConflictingServicesRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopConflictingServicesRequest.ProtoReflect.Descriptor instead.
 func (*StopConflictingServicesRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{74}
+	return file_vpn_service_proto_rawDescGZIP(), []int{77}
 }
 
 func (x *StopConflictingServicesRequest) GetNames() []string {
@@ -4547,7 +4912,7 @@ type StopConflictingServicesResponse struct {
 
 func (x *StopConflictingServicesResponse) Reset() {
 	*x = StopConflictingServicesResponse{}
-	mi := &file_vpn_service_proto_msgTypes[75]
+	mi := &file_vpn_service_proto_msgTypes[78]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4559,7 +4924,7 @@ func (x *StopConflictingServicesResponse) String() string {
 func (*StopConflictingServicesResponse) ProtoMessage() {}
 
 func (x *StopConflictingServicesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[75]
+	mi := &file_vpn_service_proto_msgTypes[78]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4572,7 +4937,7 @@ func (x *StopConflictingServicesResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StopConflictingServicesResponse.ProtoReflect.Descriptor instead.
 func (*StopConflictingServicesResponse) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{75}
+	return file_vpn_service_proto_rawDescGZIP(), []int{78}
 }
 
 func (x *StopConflictingServicesResponse) GetSuccess() bool {
@@ -4622,7 +4987,7 @@ type ConnectionEntry struct {
 
 func (x *ConnectionEntry) Reset() {
 	*x = ConnectionEntry{}
-	mi := &file_vpn_service_proto_msgTypes[76]
+	mi := &file_vpn_service_proto_msgTypes[79]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4634,7 +4999,7 @@ func (x *ConnectionEntry) String() string {
 func (*ConnectionEntry) ProtoMessage() {}
 
 func (x *ConnectionEntry) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[76]
+	mi := &file_vpn_service_proto_msgTypes[79]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4647,7 +5012,7 @@ func (x *ConnectionEntry) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionEntry.ProtoReflect.Descriptor instead.
 func (*ConnectionEntry) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{76}
+	return file_vpn_service_proto_rawDescGZIP(), []int{79}
 }
 
 func (x *ConnectionEntry) GetProcessName() string {
@@ -4730,7 +5095,7 @@ type ConnectionMonitorRequest struct {
 
 func (x *ConnectionMonitorRequest) Reset() {
 	*x = ConnectionMonitorRequest{}
-	mi := &file_vpn_service_proto_msgTypes[77]
+	mi := &file_vpn_service_proto_msgTypes[80]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4742,7 +5107,7 @@ func (x *ConnectionMonitorRequest) String() string {
 func (*ConnectionMonitorRequest) ProtoMessage() {}
 
 func (x *ConnectionMonitorRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[77]
+	mi := &file_vpn_service_proto_msgTypes[80]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4755,7 +5120,7 @@ func (x *ConnectionMonitorRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionMonitorRequest.ProtoReflect.Descriptor instead.
 func (*ConnectionMonitorRequest) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{77}
+	return file_vpn_service_proto_rawDescGZIP(), []int{80}
 }
 
 func (x *ConnectionMonitorRequest) GetTunnelFilter() string {
@@ -4781,7 +5146,7 @@ type ConnectionSnapshot struct {
 
 func (x *ConnectionSnapshot) Reset() {
 	*x = ConnectionSnapshot{}
-	mi := &file_vpn_service_proto_msgTypes[78]
+	mi := &file_vpn_service_proto_msgTypes[81]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -4793,7 +5158,7 @@ func (x *ConnectionSnapshot) String() string {
 func (*ConnectionSnapshot) ProtoMessage() {}
 
 func (x *ConnectionSnapshot) ProtoReflect() protoreflect.Message {
-	mi := &file_vpn_service_proto_msgTypes[78]
+	mi := &file_vpn_service_proto_msgTypes[81]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -4806,7 +5171,7 @@ func (x *ConnectionSnapshot) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionSnapshot.ProtoReflect.Descriptor instead.
 func (*ConnectionSnapshot) Descriptor() ([]byte, []int) {
-	return file_vpn_service_proto_rawDescGZIP(), []int{78}
+	return file_vpn_service_proto_rawDescGZIP(), []int{81}
 }
 
 func (x *ConnectionSnapshot) GetConnections() []*ConnectionEntry {
@@ -4816,152 +5181,1959 @@ func (x *ConnectionSnapshot) GetConnections() []*ConnectionEntry {
 	return nil
 }
 
-var File_vpn_service_proto protoreflect.FileDescriptor
+type DNSQueryStreamRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tail          int32                  `protobuf:"varint,1,opt,name=tail,proto3" json:"tail,omitempty"`                                       // send last N records from the ring first
+	DomainFilter  string                 `protobuf:"bytes,2,opt,name=domain_filter,json=domainFilter,proto3" json:"domain_filter,omitempty"`    // substring match on query name
+	ProcessFilter string                 `protobuf:"bytes,3,opt,name=process_filter,json=processFilter,proto3" json:"process_filter,omitempty"` // substring match on process name
+	BlockedOnly   bool                   `protobuf:"varint,4,opt,name=blocked_only,json=blockedOnly,proto3" json:"blocked_only,omitempty"`      // only queries answered by a block rule
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_vpn_service_proto_rawDesc = "" +
-	"\n" +
-	"\x11vpn_service.proto\x12\n" +
-	"awg.vpn.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xdf\x02\n" +
-	"\fTunnelConfig\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
-	"\bprotocol\x18\x02 \x01(\tR\bprotocol\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\x12B\n" +
-	"\bsettings\x18\x04 \x03(\v2&.awg.vpn.v1.TunnelConfig.SettingsEntryR\bsettings\x12\x1f\n" +
-	"\vallowed_ips\x18\x05 \x03(\tR\n" +
-	"allowedIps\x12%\n" +
-	"\x0edisallowed_ips\x18\x06 \x03(\tR\rdisallowedIps\x12'\n" +
-	"\x0fdisallowed_apps\x18\a \x03(\tR\x0edisallowedApps\x12\x1d\n" +
-	"\n" +
-	"sort_index\x18\b \x01(\x05R\tsortIndex\x1a;\n" +
-	"\rSettingsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x97\x02\n" +
-	"\fTunnelStatus\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
-	"\x06config\x18\x02 \x01(\v2\x18.awg.vpn.v1.TunnelConfigR\x06config\x12-\n" +
-	"\x05state\x18\x03 \x01(\x0e2\x17.awg.vpn.v1.TunnelStateR\x05state\x12\x14\n" +
-	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1d\n" +
-	"\n" +
-	"adapter_ip\x18\x05 \x01(\tR\tadapterIp\x12\x1f\n" +
-	"\vexternal_ip\x18\x06 \x01(\tR\n" +
-	"externalIp\x12!\n" +
-	"\fcountry_code\x18\a \x01(\tR\vcountryCode\x12\x1d\n" +
-	"\n" +
-	"sort_index\x18\b \x01(\x05R\tsortIndex\"\xa7\x01\n" +
-	"\n" +
-	"DomainRule\x12\x18\n" +
-	"\apattern\x18\x01 \x01(\tR\apattern\x12\x1b\n" +
-	"\ttunnel_id\x18\x02 \x01(\tR\btunnelId\x120\n" +
-	"\x06action\x18\x03 \x01(\x0e2\x18.awg.vpn.v1.DomainActionR\x06action\x12\x16\n" +
-	"\x06active\x18\x04 \x01(\bR\x06active\x12\x18\n" +
-	"\aenabled\x18\x05 \x01(\bR\aenabled\"\xc3\x01\n" +
-	"\x04Rule\x12\x18\n" +
-	"\apattern\x18\x01 \x01(\tR\apattern\x12\x1b\n" +
-	"\ttunnel_id\x18\x02 \x01(\tR\btunnelId\x126\n" +
-	"\bfallback\x18\x03 \x01(\x0e2\x1a.awg.vpn.v1.FallbackPolicyR\bfallback\x12\x1a\n" +
-	"\bpriority\x18\x04 \x01(\tR\bpriority\x12\x16\n" +
-	"\x06active\x18\x05 \x01(\bR\x06active\x12\x18\n" +
-	"\aenabled\x18\x06 \x01(\bR\aenabled\"\x90\x01\n" +
-	"\x0eDNSCacheConfig\x12\x18\n" +
-	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x19\n" +
-	"\bmax_size\x18\x02 \x01(\x05R\amaxSize\x12\x17\n" +
-	"\amin_ttl\x18\x03 \x01(\tR\x06minTtl\x12\x17\n" +
-	"\amax_ttl\x18\x04 \x01(\tR\x06maxTtl\x12\x17\n" +
-	"\aneg_ttl\x18\x05 \x01(\tR\x06negTtl\"<\n" +
-	"\fFakeIPConfig\x12\x18\n" +
-	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x12\n" +
-	"\x04cidr\x18\x02 \x01(\tR\x04cidr\"\xc5\x01\n" +
-	"\tDNSConfig\x12\x1b\n" +
-	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\x12\x18\n" +
-	"\aservers\x18\x02 \x03(\tR\aservers\x120\n" +
-	"\x05cache\x18\x03 \x01(\v2\x1a.awg.vpn.v1.DNSCacheConfigR\x05cache\x12\x1d\n" +
-	"\n" +
-	"tunnel_ids\x18\x04 \x03(\tR\ttunnelIds\x120\n" +
-	"\x06fakeip\x18\x05 \x01(\v2\x18.awg.vpn.v1.FakeIPConfigR\x06fakeip\"\xaa\x01\n" +
-	"\x12GlobalFilterConfig\x12\x1f\n" +
-	"\vallowed_ips\x18\x01 \x03(\tR\n" +
-	"allowedIps\x12%\n" +
-	"\x0edisallowed_ips\x18\x02 \x03(\tR\rdisallowedIps\x12'\n" +
-	"\x0fdisallowed_apps\x18\x03 \x03(\tR\x0edisallowedApps\x12#\n" +
-	"\rdisable_local\x18\x04 \x01(\bR\fdisableLocal\"\xd9\x01\n" +
-	"\tLogConfig\x12\x14\n" +
-	"\x05level\x18\x01 \x01(\tR\x05level\x12E\n" +
-	"\n" +
-	"components\x18\x02 \x03(\v2%.awg.vpn.v1.LogConfig.ComponentsEntryR\n" +
-	"components\x120\n" +
-	"\x14file_logging_enabled\x18\x03 \x01(\bR\x12fileLoggingEnabled\x1a=\n" +
-	"\x0fComponentsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9c\x01\n" +
-	"\x12SubscriptionConfig\x12\x12\n" +
-	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
-	"\x03url\x18\x02 \x01(\tR\x03url\x12)\n" +
-	"\x10refresh_interval\x18\x03 \x01(\tR\x0frefreshInterval\x12\x1d\n" +
-	"\n" +
-	"user_agent\x18\x04 \x01(\tR\tuserAgent\x12\x16\n" +
-	"\x06prefix\x18\x05 \x01(\tR\x06prefix\"\x8e\x01\n" +
-	"\x12SubscriptionStatus\x126\n" +
-	"\x06config\x18\x01 \x01(\v2\x1e.awg.vpn.v1.SubscriptionConfigR\x06config\x12!\n" +
-	"\ftunnel_count\x18\x02 \x01(\x05R\vtunnelCount\x12\x1d\n" +
-	"\n" +
-	"last_error\x18\x03 \x01(\tR\tlastError\"h\n" +
-	"\x0fReconnectConfig\x12\x18\n" +
-	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x1a\n" +
-	"\binterval\x18\x02 \x01(\tR\binterval\x12\x1f\n" +
-	"\vmax_retries\x18\x03 \x01(\x05R\n" +
-	"maxRetries\"\x99\x01\n" +
-	"\x10AutoBypassConfig\x12\x18\n" +
-	"\aenabled\x18\x01 \x01(\bR\aenabled\x12%\n" +
-	"\x0eextra_patterns\x18\x02 \x03(\tR\rextraPatterns\x12!\n" +
-	"\fextra_bypass\x18\x03 \x03(\tR\vextraBypass\x12!\n" +
-	"\fnever_bypass\x18\x04 \x03(\tR\vneverBypass\"\xf4\x03\n" +
-	"\tAppConfig\x126\n" +
-	"\x06global\x18\x01 \x01(\v2\x1e.awg.vpn.v1.GlobalFilterConfigR\x06global\x122\n" +
-	"\atunnels\x18\x02 \x03(\v2\x18.awg.vpn.v1.TunnelConfigR\atunnels\x12&\n" +
-	"\x05rules\x18\x03 \x03(\v2\x10.awg.vpn.v1.RuleR\x05rules\x12'\n" +
-	"\x03dns\x18\x04 \x01(\v2\x15.awg.vpn.v1.DNSConfigR\x03dns\x12/\n" +
-	"\alogging\x18\x05 \x01(\v2\x15.awg.vpn.v1.LogConfigR\alogging\x129\n" +
-	"\fdomain_rules\x18\x06 \x03(\v2\x16.awg.vpn.v1.DomainRuleR\vdomainRules\x12D\n" +
-	"\rsubscriptions\x18\a \x03(\v2\x1e.awg.vpn.v1.SubscriptionConfigR\rsubscriptions\x129\n" +
-	"\treconnect\x18\b \x01(\v2\x1b.awg.vpn.v1.ReconnectConfigR\treconnect\x12=\n" +
-	"\vauto_bypass\x18\t \x01(\v2\x1c.awg.vpn.v1.AutoBypassConfigR\n" +
-	"autoBypass\"\xfd\x02\n" +
-	"\vTunnelStats\x12\x1b\n" +
-	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\x12-\n" +
-	"\x05state\x18\x02 \x01(\x0e2\x17.awg.vpn.v1.TunnelStateR\x05state\x12\x19\n" +
-	"\bbytes_tx\x18\x03 \x01(\x03R\abytesTx\x12\x19\n" +
-	"\bbytes_rx\x18\x04 \x01(\x03R\abytesRx\x12\x19\n" +
-	"\bspeed_tx\x18\x05 \x01(\x03R\aspeedTx\x12\x19\n" +
-	"\bspeed_rx\x18\x06 \x01(\x03R\aspeedRx\x12\x1f\n" +
-	"\vpacket_loss\x18\a \x01(\x01R\n" +
-	"packetLoss\x12\x1d\n" +
-	"\n" +
-	"latency_ms\x18\b \x01(\x03R\tlatencyMs\x12A\n" +
-	"\x0elast_handshake\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\rlastHandshake\x12\x1b\n" +
-	"\tjitter_ms\x18\n" +
-	" \x01(\x03R\bjitterMs\x12\x16\n" +
-	"\x06banner\x18\v \x01(\tR\x06banner\"|\n" +
-	"\rStatsSnapshot\x121\n" +
-	"\atunnels\x18\x01 \x03(\v2\x17.awg.vpn.v1.TunnelStatsR\atunnels\x128\n" +
-	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\x9c\x01\n" +
-	"\bLogEntry\x128\n" +
-	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12*\n" +
-	"\x05level\x18\x02 \x01(\x0e2\x14.awg.vpn.v1.LogLevelR\x05level\x12\x10\n" +
-	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x18\n" +
-	"\amessage\x18\x04 \x01(\tR\amessage\"G\n" +
-	"\vProcessInfo\x12\x10\n" +
-	"\x03pid\x18\x01 \x01(\rR\x03pid\x12\x12\n" +
-	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
-	"\x04path\x18\x03 \x01(\tR\x04path\"\xb9\x01\n" +
-	"\x0eConnectRequest\x12\x1b\n" +
-	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\x12K\n" +
-	"\vauth_params\x18\x02 \x03(\v2*.awg.vpn.v1.ConnectRequest.AuthParamsEntryR\n" +
-	"authParams\x1a=\n" +
-	"\x0fAuthParamsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"A\n" +
-	"\x0fConnectResponse\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+func (x *DNSQueryStreamRequest) Reset() {
+	*x = DNSQueryStreamRequest{}
+	mi := &file_vpn_service_proto_msgTypes[82]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSQueryStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSQueryStreamRequest) ProtoMessage() {}
+
+func (x *DNSQueryStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[82]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSQueryStreamRequest.ProtoReflect.Descriptor instead.
+func (*DNSQueryStreamRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{82}
+}
+
+func (x *DNSQueryStreamRequest) GetTail() int32 {
+	if x != nil {
+		return x.Tail
+	}
+	return 0
+}
+
+func (x *DNSQueryStreamRequest) GetDomainFilter() string {
+	if x != nil {
+		return x.DomainFilter
+	}
+	return ""
+}
+
+func (x *DNSQueryStreamRequest) GetProcessFilter() string {
+	if x != nil {
+		return x.ProcessFilter
+	}
+	return ""
+}
+
+func (x *DNSQueryStreamRequest) GetBlockedOnly() bool {
+	if x != nil {
+		return x.BlockedOnly
+	}
+	return false
+}
+
+type DNSQueryEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	ProcessName   string                 `protobuf:"bytes,2,opt,name=process_name,json=processName,proto3" json:"process_name,omitempty"` // client exe name, empty if unknown
+	Qname         string                 `protobuf:"bytes,3,opt,name=qname,proto3" json:"qname,omitempty"`
+	Qtype         uint32                 `protobuf:"varint,4,opt,name=qtype,proto3" json:"qtype,omitempty"`                                // 1 = A, 28 = AAAA, ...
+	Rule          string                 `protobuf:"bytes,5,opt,name=rule,proto3" json:"rule,omitempty"`                                   // matched domain rule pattern, empty if none
+	Action        DomainAction           `protobuf:"varint,6,opt,name=action,proto3,enum=awg.vpn.v1.DomainAction" json:"action,omitempty"` // valid only when rule is set
+	TunnelId      string                 `protobuf:"bytes,7,opt,name=tunnel_id,json=tunnelId,proto3" json:"tunnel_id,omitempty"`           // tunnel the query was forwarded through
+	Upstream      string                 `protobuf:"bytes,8,opt,name=upstream,proto3" json:"upstream,omitempty"`                           // upstream DNS server
+	LatencyUs     int64                  `protobuf:"varint,9,opt,name=latency_us,json=latencyUs,proto3" json:"latency_us,omitempty"`
+	Rcode         uint32                 `protobuf:"varint,10,opt,name=rcode,proto3" json:"rcode,omitempty"`                // 0 = NOERROR, 2 = SERVFAIL, 3 = NXDOMAIN
+	FakeIp        string                 `protobuf:"bytes,11,opt,name=fake_ip,json=fakeIp,proto3" json:"fake_ip,omitempty"` // assigned FakeIP, empty if none
+	Transport     string                 `protobuf:"bytes,12,opt,name=transport,proto3" json:"transport,omitempty"`         // "udp" or "tcp"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSQueryEntry) Reset() {
+	*x = DNSQueryEntry{}
+	mi := &file_vpn_service_proto_msgTypes[83]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSQueryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSQueryEntry) ProtoMessage() {}
+
+func (x *DNSQueryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[83]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSQueryEntry.ProtoReflect.Descriptor instead.
+func (*DNSQueryEntry) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{83}
+}
+
+func (x *DNSQueryEntry) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *DNSQueryEntry) GetProcessName() string {
+	if x != nil {
+		return x.ProcessName
+	}
+	return ""
+}
+
+func (x *DNSQueryEntry) GetQname() string {
+	if x != nil {
+		return x.Qname
+	}
+	return ""
+}
+
+func (x *DNSQueryEntry) GetQtype() uint32 {
+	if x != nil {
+		return x.Qtype
+	}
+	return 0
+}
+
+func (x *DNSQueryEntry) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *DNSQueryEntry) GetAction() DomainAction {
+	if x != nil {
+		return x.Action
+	}
+	return DomainAction_DOMAIN_ACTION_ROUTE
+}
+
+func (x *DNSQueryEntry) GetTunnelId() string {
+	if x != nil {
+		return x.TunnelId
+	}
+	return ""
+}
+
+func (x *DNSQueryEntry) GetUpstream() string {
+	if x != nil {
+		return x.Upstream
+	}
+	return ""
+}
+
+func (x *DNSQueryEntry) GetLatencyUs() int64 {
+	if x != nil {
+		return x.LatencyUs
+	}
+	return 0
+}
+
+func (x *DNSQueryEntry) GetRcode() uint32 {
+	if x != nil {
+		return x.Rcode
+	}
+	return 0
+}
+
+func (x *DNSQueryEntry) GetFakeIp() string {
+	if x != nil {
+		return x.FakeIp
+	}
+	return ""
+}
+
+func (x *DNSQueryEntry) GetTransport() string {
+	if x != nil {
+		return x.Transport
+	}
+	return ""
+}
+
+type DNSDomainStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Domain        string                 `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	Queries       uint64                 `protobuf:"varint,2,opt,name=queries,proto3" json:"queries,omitempty"`
+	Blocked       uint64                 `protobuf:"varint,3,opt,name=blocked,proto3" json:"blocked,omitempty"`
+	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	LastProcess   string                 `protobuf:"bytes,5,opt,name=last_process,json=lastProcess,proto3" json:"last_process,omitempty"`
+	LastTunnel    string                 `protobuf:"bytes,6,opt,name=last_tunnel,json=lastTunnel,proto3" json:"last_tunnel,omitempty"`
+	Rule          string                 `protobuf:"bytes,7,opt,name=rule,proto3" json:"rule,omitempty"` // last matched rule, empty if none
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSDomainStats) Reset() {
+	*x = DNSDomainStats{}
+	mi := &file_vpn_service_proto_msgTypes[84]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSDomainStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSDomainStats) ProtoMessage() {}
+
+func (x *DNSDomainStats) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[84]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSDomainStats.ProtoReflect.Descriptor instead.
+func (*DNSDomainStats) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{84}
+}
+
+func (x *DNSDomainStats) GetDomain() string {
+	if x != nil {
+		return x.Domain
+	}
+	return ""
+}
+
+func (x *DNSDomainStats) GetQueries() uint64 {
+	if x != nil {
+		return x.Queries
+	}
+	return 0
+}
+
+func (x *DNSDomainStats) GetBlocked() uint64 {
+	if x != nil {
+		return x.Blocked
+	}
+	return 0
+}
+
+func (x *DNSDomainStats) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *DNSDomainStats) GetLastProcess() string {
+	if x != nil {
+		return x.LastProcess
+	}
+	return ""
+}
+
+func (x *DNSDomainStats) GetLastTunnel() string {
+	if x != nil {
+		return x.LastTunnel
+	}
+	return ""
+}
+
+func (x *DNSDomainStats) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+type DNSQueryStatsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`                             // max entries per list (default 50)
+	ResetStats    bool                   `protobuf:"varint,2,opt,name=reset_stats,json=resetStats,proto3" json:"reset_stats,omitempty"` // clear aggregates after reading
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DNSQueryStatsRequest) Reset() {
+	*x = DNSQueryStatsRequest{}
+	mi := &file_vpn_service_proto_msgTypes[85]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSQueryStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSQueryStatsRequest) ProtoMessage() {}
+
+func (x *DNSQueryStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[85]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSQueryStatsRequest.ProtoReflect.Descriptor instead.
+func (*DNSQueryStatsRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{85}
+}
+
+func (x *DNSQueryStatsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *DNSQueryStatsRequest) GetResetStats() bool {
+	if x != nil {
+		return x.ResetStats
+	}
+	return false
+}
+
+type DNSQueryStatsResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	TopDomains     []*DNSDomainStats      `protobuf:"bytes,1,rep,name=top_domains,json=topDomains,proto3" json:"top_domains,omitempty"`
+	BlockedDomains []*DNSDomainStats      `protobuf:"bytes,2,rep,name=blocked_domains,json=blockedDomains,proto3" json:"blocked_domains,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *DNSQueryStatsResponse) Reset() {
+	*x = DNSQueryStatsResponse{}
+	mi := &file_vpn_service_proto_msgTypes[86]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DNSQueryStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DNSQueryStatsResponse) ProtoMessage() {}
+
+func (x *DNSQueryStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[86]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DNSQueryStatsResponse.ProtoReflect.Descriptor instead.
+func (*DNSQueryStatsResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{86}
+}
+
+func (x *DNSQueryStatsResponse) GetTopDomains() []*DNSDomainStats {
+	if x != nil {
+		return x.TopDomains
+	}
+	return nil
+}
+
+func (x *DNSQueryStatsResponse) GetBlockedDomains() []*DNSDomainStats {
+	if x != nil {
+		return x.BlockedDomains
+	}
+	return nil
+}
+
+type WireGuardKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PrivateKey    string                 `protobuf:"bytes,1,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"` // derive the public key of this key; empty = generate a new pair
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WireGuardKeyRequest) Reset() {
+	*x = WireGuardKeyRequest{}
+	mi := &file_vpn_service_proto_msgTypes[87]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WireGuardKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WireGuardKeyRequest) ProtoMessage() {}
+
+func (x *WireGuardKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[87]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WireGuardKeyRequest.ProtoReflect.Descriptor instead.
+func (*WireGuardKeyRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{87}
+}
+
+func (x *WireGuardKeyRequest) GetPrivateKey() string {
+	if x != nil {
+		return x.PrivateKey
+	}
+	return ""
+}
+
+type WireGuardKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	PrivateKey    string                 `protobuf:"bytes,3,opt,name=private_key,json=privateKey,proto3" json:"private_key,omitempty"` // base64
+	PublicKey     string                 `protobuf:"bytes,4,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`    // base64
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WireGuardKeyResponse) Reset() {
+	*x = WireGuardKeyResponse{}
+	mi := &file_vpn_service_proto_msgTypes[88]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WireGuardKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WireGuardKeyResponse) ProtoMessage() {}
+
+func (x *WireGuardKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[88]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WireGuardKeyResponse.ProtoReflect.Descriptor instead.
+func (*WireGuardKeyResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{88}
+}
+
+func (x *WireGuardKeyResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WireGuardKeyResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WireGuardKeyResponse) GetPrivateKey() string {
+	if x != nil {
+		return x.PrivateKey
+	}
+	return ""
+}
+
+func (x *WireGuardKeyResponse) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+type ImportWireGuardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Payload       string                 `protobuf:"bytes,1,opt,name=payload,proto3" json:"payload,omitempty"`                   // .conf text, wg:// / wireguard:// link, vpn:// string or QR code text
+	TunnelId      string                 `protobuf:"bytes,2,opt,name=tunnel_id,json=tunnelId,proto3" json:"tunnel_id,omitempty"` // optional; generated from the name if empty
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`                         // optional; overrides the name carried by the payload
+	DryRun        bool                   `protobuf:"varint,4,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`      // only decode and return the config, do not add the tunnel
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportWireGuardRequest) Reset() {
+	*x = ImportWireGuardRequest{}
+	mi := &file_vpn_service_proto_msgTypes[89]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportWireGuardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportWireGuardRequest) ProtoMessage() {}
+
+func (x *ImportWireGuardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[89]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportWireGuardRequest.ProtoReflect.Descriptor instead.
+func (*ImportWireGuardRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{89}
+}
+
+func (x *ImportWireGuardRequest) GetPayload() string {
+	if x != nil {
+		return x.Payload
+	}
+	return ""
+}
+
+func (x *ImportWireGuardRequest) GetTunnelId() string {
+	if x != nil {
+		return x.TunnelId
+	}
+	return ""
+}
+
+func (x *ImportWireGuardRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ImportWireGuardRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ImportWireGuardResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Config        *TunnelConfig          `protobuf:"bytes,3,opt,name=config,proto3" json:"config,omitempty"` // decoded tunnel (inline "config" setting)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportWireGuardResponse) Reset() {
+	*x = ImportWireGuardResponse{}
+	mi := &file_vpn_service_proto_msgTypes[90]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportWireGuardResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportWireGuardResponse) ProtoMessage() {}
+
+func (x *ImportWireGuardResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[90]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportWireGuardResponse.ProtoReflect.Descriptor instead.
+func (*ImportWireGuardResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{90}
+}
+
+func (x *ImportWireGuardResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ImportWireGuardResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ImportWireGuardResponse) GetConfig() *TunnelConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+type ExportWireGuardRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TunnelId      string                 `protobuf:"bytes,1,opt,name=tunnel_id,json=tunnelId,proto3" json:"tunnel_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportWireGuardRequest) Reset() {
+	*x = ExportWireGuardRequest{}
+	mi := &file_vpn_service_proto_msgTypes[91]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportWireGuardRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportWireGuardRequest) ProtoMessage() {}
+
+func (x *ExportWireGuardRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[91]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportWireGuardRequest.ProtoReflect.Descriptor instead.
+func (*ExportWireGuardRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{91}
+}
+
+func (x *ExportWireGuardRequest) GetTunnelId() string {
+	if x != nil {
+		return x.TunnelId
+	}
+	return ""
+}
+
+type ExportWireGuardResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Conf          string                 `protobuf:"bytes,3,opt,name=conf,proto3" json:"conf,omitempty"` // standard .conf text
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportWireGuardResponse) Reset() {
+	*x = ExportWireGuardResponse{}
+	mi := &file_vpn_service_proto_msgTypes[92]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportWireGuardResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportWireGuardResponse) ProtoMessage() {}
+
+func (x *ExportWireGuardResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[92]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportWireGuardResponse.ProtoReflect.Descriptor instead.
+func (*ExportWireGuardResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{92}
+}
+
+func (x *ExportWireGuardResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ExportWireGuardResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *ExportWireGuardResponse) GetConf() string {
+	if x != nil {
+		return x.Conf
+	}
+	return ""
+}
+
+type TunnelPeersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TunnelId      string                 `protobuf:"bytes,1,opt,name=tunnel_id,json=tunnelId,proto3" json:"tunnel_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TunnelPeersRequest) Reset() {
+	*x = TunnelPeersRequest{}
+	mi := &file_vpn_service_proto_msgTypes[93]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TunnelPeersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TunnelPeersRequest) ProtoMessage() {}
+
+func (x *TunnelPeersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[93]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TunnelPeersRequest.ProtoReflect.Descriptor instead.
+func (*TunnelPeersRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{93}
+}
+
+func (x *TunnelPeersRequest) GetTunnelId() string {
+	if x != nil {
+		return x.TunnelId
+	}
+	return ""
+}
+
+// TunnelPeer is one peer (sub-exit) of a WireGuard/AmneziaWG tunnel.
+type TunnelPeer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"` // rule selector: "tunnel_id/<id>"
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Endpoint      string                 `protobuf:"bytes,3,opt,name=endpoint,proto3" json:"endpoint,omitempty"`
+	AllowedIps    []string               `protobuf:"bytes,4,rep,name=allowed_ips,json=allowedIps,proto3" json:"allowed_ips,omitempty"`
+	LastHandshake *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=last_handshake,json=lastHandshake,proto3" json:"last_handshake,omitempty"`
+	RxBytes       uint64                 `protobuf:"varint,6,opt,name=rx_bytes,json=rxBytes,proto3" json:"rx_bytes,omitempty"`
+	TxBytes       uint64                 `protobuf:"varint,7,opt,name=tx_bytes,json=txBytes,proto3" json:"tx_bytes,omitempty"`
+	Stale         bool                   `protobuf:"varint,8,opt,name=stale,proto3" json:"stale,omitempty"`   // no recent handshake; routes failed over
+	Active        bool                   `protobuf:"varint,9,opt,name=active,proto3" json:"active,omitempty"` // currently carries at least one route
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TunnelPeer) Reset() {
+	*x = TunnelPeer{}
+	mi := &file_vpn_service_proto_msgTypes[94]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TunnelPeer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TunnelPeer) ProtoMessage() {}
+
+func (x *TunnelPeer) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[94]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TunnelPeer.ProtoReflect.Descriptor instead.
+func (*TunnelPeer) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{94}
+}
+
+func (x *TunnelPeer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TunnelPeer) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *TunnelPeer) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *TunnelPeer) GetAllowedIps() []string {
+	if x != nil {
+		return x.AllowedIps
+	}
+	return nil
+}
+
+func (x *TunnelPeer) GetLastHandshake() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHandshake
+	}
+	return nil
+}
+
+func (x *TunnelPeer) GetRxBytes() uint64 {
+	if x != nil {
+		return x.RxBytes
+	}
+	return 0
+}
+
+func (x *TunnelPeer) GetTxBytes() uint64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+func (x *TunnelPeer) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+func (x *TunnelPeer) GetActive() bool {
+	if x != nil {
+		return x.Active
+	}
+	return false
+}
+
+type TunnelPeersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Peers         []*TunnelPeer          `protobuf:"bytes,3,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TunnelPeersResponse) Reset() {
+	*x = TunnelPeersResponse{}
+	mi := &file_vpn_service_proto_msgTypes[95]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TunnelPeersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TunnelPeersResponse) ProtoMessage() {}
+
+func (x *TunnelPeersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[95]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TunnelPeersResponse.ProtoReflect.Descriptor instead.
+func (*TunnelPeersResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{95}
+}
+
+func (x *TunnelPeersResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *TunnelPeersResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *TunnelPeersResponse) GetPeers() []*TunnelPeer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+// WGServerPeer is a device connecting to the WireGuard server (wg_server).
+type WGServerPeer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"` // rule selector: "peer:<name>"
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"` // tunnel IP
+	Enabled       bool                   `protobuf:"varint,4,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	HasConfig     bool                   `protobuf:"varint,5,opt,name=has_config,json=hasConfig,proto3" json:"has_config,omitempty"` // private key stored: client config can be exported
+	Endpoint      string                 `protobuf:"bytes,6,opt,name=endpoint,proto3" json:"endpoint,omitempty"`                     // last seen public address, empty if never connected
+	LastHandshake *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=last_handshake,json=lastHandshake,proto3" json:"last_handshake,omitempty"`
+	RxBytes       uint64                 `protobuf:"varint,8,opt,name=rx_bytes,json=rxBytes,proto3" json:"rx_bytes,omitempty"`
+	TxBytes       uint64                 `protobuf:"varint,9,opt,name=tx_bytes,json=txBytes,proto3" json:"tx_bytes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WGServerPeer) Reset() {
+	*x = WGServerPeer{}
+	mi := &file_vpn_service_proto_msgTypes[96]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WGServerPeer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WGServerPeer) ProtoMessage() {}
+
+func (x *WGServerPeer) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[96]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WGServerPeer.ProtoReflect.Descriptor instead.
+func (*WGServerPeer) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{96}
+}
+
+func (x *WGServerPeer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WGServerPeer) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *WGServerPeer) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WGServerPeer) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *WGServerPeer) GetHasConfig() bool {
+	if x != nil {
+		return x.HasConfig
+	}
+	return false
+}
+
+func (x *WGServerPeer) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *WGServerPeer) GetLastHandshake() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastHandshake
+	}
+	return nil
+}
+
+func (x *WGServerPeer) GetRxBytes() uint64 {
+	if x != nil {
+		return x.RxBytes
+	}
+	return 0
+}
+
+func (x *WGServerPeer) GetTxBytes() uint64 {
+	if x != nil {
+		return x.TxBytes
+	}
+	return 0
+}
+
+type WGServerStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Enabled       bool                   `protobuf:"varint,3,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	Running       bool                   `protobuf:"varint,4,opt,name=running,proto3" json:"running,omitempty"`
+	ListenPort    int32                  `protobuf:"varint,5,opt,name=listen_port,json=listenPort,proto3" json:"listen_port,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,6,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`
+	Address       string                 `protobuf:"bytes,7,opt,name=address,proto3" json:"address,omitempty"`   // server IP with the peer subnet
+	Endpoint      string                 `protobuf:"bytes,8,opt,name=endpoint,proto3" json:"endpoint,omitempty"` // public host:port written into client configs
+	Peers         []*WGServerPeer        `protobuf:"bytes,9,rep,name=peers,proto3" json:"peers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WGServerStatusResponse) Reset() {
+	*x = WGServerStatusResponse{}
+	mi := &file_vpn_service_proto_msgTypes[97]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WGServerStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WGServerStatusResponse) ProtoMessage() {}
+
+func (x *WGServerStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[97]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WGServerStatusResponse.ProtoReflect.Descriptor instead.
+func (*WGServerStatusResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{97}
+}
+
+func (x *WGServerStatusResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WGServerStatusResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WGServerStatusResponse) GetEnabled() bool {
+	if x != nil {
+		return x.Enabled
+	}
+	return false
+}
+
+func (x *WGServerStatusResponse) GetRunning() bool {
+	if x != nil {
+		return x.Running
+	}
+	return false
+}
+
+func (x *WGServerStatusResponse) GetListenPort() int32 {
+	if x != nil {
+		return x.ListenPort
+	}
+	return 0
+}
+
+func (x *WGServerStatusResponse) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *WGServerStatusResponse) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WGServerStatusResponse) GetEndpoint() string {
+	if x != nil {
+		return x.Endpoint
+	}
+	return ""
+}
+
+func (x *WGServerStatusResponse) GetPeers() []*WGServerPeer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+type AddWGServerPeerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	PublicKey     string                 `protobuf:"bytes,2,opt,name=public_key,json=publicKey,proto3" json:"public_key,omitempty"`          // optional; a key pair is generated if empty
+	PresharedKey  string                 `protobuf:"bytes,3,opt,name=preshared_key,json=presharedKey,proto3" json:"preshared_key,omitempty"` // optional; generated with the key pair
+	Address       string                 `protobuf:"bytes,4,opt,name=address,proto3" json:"address,omitempty"`                               // optional; next free address if empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddWGServerPeerRequest) Reset() {
+	*x = AddWGServerPeerRequest{}
+	mi := &file_vpn_service_proto_msgTypes[98]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddWGServerPeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddWGServerPeerRequest) ProtoMessage() {}
+
+func (x *AddWGServerPeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[98]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddWGServerPeerRequest.ProtoReflect.Descriptor instead.
+func (*AddWGServerPeerRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{98}
+}
+
+func (x *AddWGServerPeerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddWGServerPeerRequest) GetPublicKey() string {
+	if x != nil {
+		return x.PublicKey
+	}
+	return ""
+}
+
+func (x *AddWGServerPeerRequest) GetPresharedKey() string {
+	if x != nil {
+		return x.PresharedKey
+	}
+	return ""
+}
+
+func (x *AddWGServerPeerRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+type AddWGServerPeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Peer          *WGServerPeer          `protobuf:"bytes,3,opt,name=peer,proto3" json:"peer,omitempty"`
+	ClientConfig  string                 `protobuf:"bytes,4,opt,name=client_config,json=clientConfig,proto3" json:"client_config,omitempty"` // .conf for the device, when keys were generated
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddWGServerPeerResponse) Reset() {
+	*x = AddWGServerPeerResponse{}
+	mi := &file_vpn_service_proto_msgTypes[99]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddWGServerPeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddWGServerPeerResponse) ProtoMessage() {}
+
+func (x *AddWGServerPeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[99]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddWGServerPeerResponse.ProtoReflect.Descriptor instead.
+func (*AddWGServerPeerResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{99}
+}
+
+func (x *AddWGServerPeerResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AddWGServerPeerResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *AddWGServerPeerResponse) GetPeer() *WGServerPeer {
+	if x != nil {
+		return x.Peer
+	}
+	return nil
+}
+
+func (x *AddWGServerPeerResponse) GetClientConfig() string {
+	if x != nil {
+		return x.ClientConfig
+	}
+	return ""
+}
+
+type RemoveWGServerPeerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveWGServerPeerRequest) Reset() {
+	*x = RemoveWGServerPeerRequest{}
+	mi := &file_vpn_service_proto_msgTypes[100]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveWGServerPeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveWGServerPeerRequest) ProtoMessage() {}
+
+func (x *RemoveWGServerPeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[100]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveWGServerPeerRequest.ProtoReflect.Descriptor instead.
+func (*RemoveWGServerPeerRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{100}
+}
+
+func (x *RemoveWGServerPeerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type RemoveWGServerPeerResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveWGServerPeerResponse) Reset() {
+	*x = RemoveWGServerPeerResponse{}
+	mi := &file_vpn_service_proto_msgTypes[101]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveWGServerPeerResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveWGServerPeerResponse) ProtoMessage() {}
+
+func (x *RemoveWGServerPeerResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[101]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveWGServerPeerResponse.ProtoReflect.Descriptor instead.
+func (*RemoveWGServerPeerResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{101}
+}
+
+func (x *RemoveWGServerPeerResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *RemoveWGServerPeerResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type WGServerClientConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WGServerClientConfigRequest) Reset() {
+	*x = WGServerClientConfigRequest{}
+	mi := &file_vpn_service_proto_msgTypes[102]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WGServerClientConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WGServerClientConfigRequest) ProtoMessage() {}
+
+func (x *WGServerClientConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[102]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WGServerClientConfigRequest.ProtoReflect.Descriptor instead.
+func (*WGServerClientConfigRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{102}
+}
+
+func (x *WGServerClientConfigRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type WGServerClientConfigResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Conf          string                 `protobuf:"bytes,3,opt,name=conf,proto3" json:"conf,omitempty"` // standard .conf text
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WGServerClientConfigResponse) Reset() {
+	*x = WGServerClientConfigResponse{}
+	mi := &file_vpn_service_proto_msgTypes[103]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WGServerClientConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WGServerClientConfigResponse) ProtoMessage() {}
+
+func (x *WGServerClientConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[103]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WGServerClientConfigResponse.ProtoReflect.Descriptor instead.
+func (*WGServerClientConfigResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{103}
+}
+
+func (x *WGServerClientConfigResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *WGServerClientConfigResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *WGServerClientConfigResponse) GetConf() string {
+	if x != nil {
+		return x.Conf
+	}
+	return ""
+}
+
+// RateLimit is a token-bucket limit. Zero rates are unlimited; zero bursts
+// default to 250 ms worth of the rate.
+type RateLimit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UpKbps        int64                  `protobuf:"varint,1,opt,name=up_kbps,json=upKbps,proto3" json:"up_kbps,omitempty"`                  // kilobits per second, client → server
+	DownKbps      int64                  `protobuf:"varint,2,opt,name=down_kbps,json=downKbps,proto3" json:"down_kbps,omitempty"`            // kilobits per second, server → client
+	UpBurstKb     int64                  `protobuf:"varint,3,opt,name=up_burst_kb,json=upBurstKb,proto3" json:"up_burst_kb,omitempty"`       // kilobytes
+	DownBurstKb   int64                  `protobuf:"varint,4,opt,name=down_burst_kb,json=downBurstKb,proto3" json:"down_burst_kb,omitempty"` // kilobytes
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimit) Reset() {
+	*x = RateLimit{}
+	mi := &file_vpn_service_proto_msgTypes[104]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimit) ProtoMessage() {}
+
+func (x *RateLimit) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[104]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimit.ProtoReflect.Descriptor instead.
+func (*RateLimit) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{104}
+}
+
+func (x *RateLimit) GetUpKbps() int64 {
+	if x != nil {
+		return x.UpKbps
+	}
+	return 0
+}
+
+func (x *RateLimit) GetDownKbps() int64 {
+	if x != nil {
+		return x.DownKbps
+	}
+	return 0
+}
+
+func (x *RateLimit) GetUpBurstKb() int64 {
+	if x != nil {
+		return x.UpBurstKb
+	}
+	return 0
+}
+
+func (x *RateLimit) GetDownBurstKb() int64 {
+	if x != nil {
+		return x.DownBurstKb
+	}
+	return 0
+}
+
+type RateLimitEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         RateLimitScope         `protobuf:"varint,1,opt,name=scope,proto3,enum=awg.vpn.v1.RateLimitScope" json:"scope,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Limit         *RateLimit             `protobuf:"bytes,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitEntry) Reset() {
+	*x = RateLimitEntry{}
+	mi := &file_vpn_service_proto_msgTypes[105]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitEntry) ProtoMessage() {}
+
+func (x *RateLimitEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[105]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitEntry.ProtoReflect.Descriptor instead.
+func (*RateLimitEntry) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{105}
+}
+
+func (x *RateLimitEntry) GetScope() RateLimitScope {
+	if x != nil {
+		return x.Scope
+	}
+	return RateLimitScope_RATE_LIMIT_SCOPE_GLOBAL
+}
+
+func (x *RateLimitEntry) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *RateLimitEntry) GetLimit() *RateLimit {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
+type RateLimitsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limits        []*RateLimitEntry      `protobuf:"bytes,1,rep,name=limits,proto3" json:"limits,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RateLimitsResponse) Reset() {
+	*x = RateLimitsResponse{}
+	mi := &file_vpn_service_proto_msgTypes[106]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RateLimitsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RateLimitsResponse) ProtoMessage() {}
+
+func (x *RateLimitsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[106]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RateLimitsResponse.ProtoReflect.Descriptor instead.
+func (*RateLimitsResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{106}
+}
+
+func (x *RateLimitsResponse) GetLimits() []*RateLimitEntry {
+	if x != nil {
+		return x.Limits
+	}
+	return nil
+}
+
+type SetRateLimitRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Scope         RateLimitScope         `protobuf:"varint,1,opt,name=scope,proto3,enum=awg.vpn.v1.RateLimitScope" json:"scope,omitempty"`
+	Target        string                 `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Limit         *RateLimit             `protobuf:"bytes,3,opt,name=limit,proto3" json:"limit,omitempty"` // unset or zero rates remove the limit
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRateLimitRequest) Reset() {
+	*x = SetRateLimitRequest{}
+	mi := &file_vpn_service_proto_msgTypes[107]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRateLimitRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRateLimitRequest) ProtoMessage() {}
+
+func (x *SetRateLimitRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[107]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRateLimitRequest.ProtoReflect.Descriptor instead.
+func (*SetRateLimitRequest) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{107}
+}
+
+func (x *SetRateLimitRequest) GetScope() RateLimitScope {
+	if x != nil {
+		return x.Scope
+	}
+	return RateLimitScope_RATE_LIMIT_SCOPE_GLOBAL
+}
+
+func (x *SetRateLimitRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *SetRateLimitRequest) GetLimit() *RateLimit {
+	if x != nil {
+		return x.Limit
+	}
+	return nil
+}
+
+type SetRateLimitResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRateLimitResponse) Reset() {
+	*x = SetRateLimitResponse{}
+	mi := &file_vpn_service_proto_msgTypes[108]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRateLimitResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRateLimitResponse) ProtoMessage() {}
+
+func (x *SetRateLimitResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_vpn_service_proto_msgTypes[108]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRateLimitResponse.ProtoReflect.Descriptor instead.
+func (*SetRateLimitResponse) Descriptor() ([]byte, []int) {
+	return file_vpn_service_proto_rawDescGZIP(), []int{108}
+}
+
+func (x *SetRateLimitResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *SetRateLimitResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_vpn_service_proto protoreflect.FileDescriptor
+
+const file_vpn_service_proto_rawDesc = "" +
+	"\n" +
+	"\x11vpn_service.proto\x12\n" +
+	"awg.vpn.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xc6\x03\n" +
+	"\fTunnelConfig\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bprotocol\x18\x02 \x01(\tR\bprotocol\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12B\n" +
+	"\bsettings\x18\x04 \x03(\v2&.awg.vpn.v1.TunnelConfig.SettingsEntryR\bsettings\x12\x1f\n" +
+	"\vallowed_ips\x18\x05 \x03(\tR\n" +
+	"allowedIps\x12%\n" +
+	"\x0edisallowed_ips\x18\x06 \x03(\tR\rdisallowedIps\x12'\n" +
+	"\x0fdisallowed_apps\x18\a \x03(\tR\x0edisallowedApps\x12\x1d\n" +
+	"\n" +
+	"sort_index\x18\b \x01(\x05R\tsortIndex\x124\n" +
+	"\n" +
+	"rate_limit\x18\t \x01(\v2\x15.awg.vpn.v1.RateLimitR\trateLimit\x12\x10\n" +
+	"\x03mtu\x18\n" +
+	" \x01(\x05R\x03mtu\x12\x1d\n" +
+	"\n" +
+	"pmtu_probe\x18\v \x01(\tR\tpmtuProbe\x1a;\n" +
+	"\rSettingsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x02\n" +
+	"\fTunnelStatus\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x120\n" +
+	"\x06config\x18\x02 \x01(\v2\x18.awg.vpn.v1.TunnelConfigR\x06config\x12-\n" +
+	"\x05state\x18\x03 \x01(\x0e2\x17.awg.vpn.v1.TunnelStateR\x05state\x12\x14\n" +
+	"\x05error\x18\x04 \x01(\tR\x05error\x12\x1d\n" +
+	"\n" +
+	"adapter_ip\x18\x05 \x01(\tR\tadapterIp\x12\x1f\n" +
+	"\vexternal_ip\x18\x06 \x01(\tR\n" +
+	"externalIp\x12!\n" +
+	"\fcountry_code\x18\a \x01(\tR\vcountryCode\x12\x1d\n" +
+	"\n" +
+	"sort_index\x18\b \x01(\x05R\tsortIndex\x12\x19\n" +
+	"\bpath_mtu\x18\t \x01(\x05R\apathMtu\"\xa7\x01\n" +
+	"\n" +
+	"DomainRule\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x1b\n" +
+	"\ttunnel_id\x18\x02 \x01(\tR\btunnelId\x120\n" +
+	"\x06action\x18\x03 \x01(\x0e2\x18.awg.vpn.v1.DomainActionR\x06action\x12\x16\n" +
+	"\x06active\x18\x04 \x01(\bR\x06active\x12\x18\n" +
+	"\aenabled\x18\x05 \x01(\bR\aenabled\"\xd8\x02\n" +
+	"\x04Rule\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x1b\n" +
+	"\ttunnel_id\x18\x02 \x01(\tR\btunnelId\x126\n" +
+	"\bfallback\x18\x03 \x01(\x0e2\x1a.awg.vpn.v1.FallbackPolicyR\bfallback\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\tR\bpriority\x12\x16\n" +
+	"\x06active\x18\x05 \x01(\bR\x06active\x12\x18\n" +
+	"\aenabled\x18\x06 \x01(\bR\aenabled\x124\n" +
+	"\n" +
+	"rate_limit\x18\a \x01(\v2\x15.awg.vpn.v1.RateLimitR\trateLimit\x12\x17\n" +
+	"\audp_nat\x18\b \x01(\tR\x06udpNat\x12!\n" +
+	"\fidle_timeout\x18\t \x01(\tR\vidleTimeout\x12!\n" +
+	"\fmax_lifetime\x18\n" +
+	" \x01(\tR\vmaxLifetime\"\x90\x01\n" +
+	"\x0eDNSCacheConfig\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x19\n" +
+	"\bmax_size\x18\x02 \x01(\x05R\amaxSize\x12\x17\n" +
+	"\amin_ttl\x18\x03 \x01(\tR\x06minTtl\x12\x17\n" +
+	"\amax_ttl\x18\x04 \x01(\tR\x06maxTtl\x12\x17\n" +
+	"\aneg_ttl\x18\x05 \x01(\tR\x06negTtl\"<\n" +
+	"\fFakeIPConfig\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x12\n" +
+	"\x04cidr\x18\x02 \x01(\tR\x04cidr\"\xc5\x01\n" +
+	"\tDNSConfig\x12\x1b\n" +
+	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\x12\x18\n" +
+	"\aservers\x18\x02 \x03(\tR\aservers\x120\n" +
+	"\x05cache\x18\x03 \x01(\v2\x1a.awg.vpn.v1.DNSCacheConfigR\x05cache\x12\x1d\n" +
+	"\n" +
+	"tunnel_ids\x18\x04 \x03(\tR\ttunnelIds\x120\n" +
+	"\x06fakeip\x18\x05 \x01(\v2\x18.awg.vpn.v1.FakeIPConfigR\x06fakeip\"\xe0\x01\n" +
+	"\x12GlobalFilterConfig\x12\x1f\n" +
+	"\vallowed_ips\x18\x01 \x03(\tR\n" +
+	"allowedIps\x12%\n" +
+	"\x0edisallowed_ips\x18\x02 \x03(\tR\rdisallowedIps\x12'\n" +
+	"\x0fdisallowed_apps\x18\x03 \x03(\tR\x0edisallowedApps\x12#\n" +
+	"\rdisable_local\x18\x04 \x01(\bR\fdisableLocal\x124\n" +
+	"\n" +
+	"rate_limit\x18\x05 \x01(\v2\x15.awg.vpn.v1.RateLimitR\trateLimit\"\xd9\x01\n" +
+	"\tLogConfig\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\tR\x05level\x12E\n" +
+	"\n" +
+	"components\x18\x02 \x03(\v2%.awg.vpn.v1.LogConfig.ComponentsEntryR\n" +
+	"components\x120\n" +
+	"\x14file_logging_enabled\x18\x03 \x01(\bR\x12fileLoggingEnabled\x1a=\n" +
+	"\x0fComponentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x9c\x01\n" +
+	"\x12SubscriptionConfig\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12)\n" +
+	"\x10refresh_interval\x18\x03 \x01(\tR\x0frefreshInterval\x12\x1d\n" +
+	"\n" +
+	"user_agent\x18\x04 \x01(\tR\tuserAgent\x12\x16\n" +
+	"\x06prefix\x18\x05 \x01(\tR\x06prefix\"\x8e\x01\n" +
+	"\x12SubscriptionStatus\x126\n" +
+	"\x06config\x18\x01 \x01(\v2\x1e.awg.vpn.v1.SubscriptionConfigR\x06config\x12!\n" +
+	"\ftunnel_count\x18\x02 \x01(\x05R\vtunnelCount\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\x03 \x01(\tR\tlastError\"h\n" +
+	"\x0fReconnectConfig\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12\x1a\n" +
+	"\binterval\x18\x02 \x01(\tR\binterval\x12\x1f\n" +
+	"\vmax_retries\x18\x03 \x01(\x05R\n" +
+	"maxRetries\"\x99\x01\n" +
+	"\x10AutoBypassConfig\x12\x18\n" +
+	"\aenabled\x18\x01 \x01(\bR\aenabled\x12%\n" +
+	"\x0eextra_patterns\x18\x02 \x03(\tR\rextraPatterns\x12!\n" +
+	"\fextra_bypass\x18\x03 \x03(\tR\vextraBypass\x12!\n" +
+	"\fnever_bypass\x18\x04 \x03(\tR\vneverBypass\"\xf4\x03\n" +
+	"\tAppConfig\x126\n" +
+	"\x06global\x18\x01 \x01(\v2\x1e.awg.vpn.v1.GlobalFilterConfigR\x06global\x122\n" +
+	"\atunnels\x18\x02 \x03(\v2\x18.awg.vpn.v1.TunnelConfigR\atunnels\x12&\n" +
+	"\x05rules\x18\x03 \x03(\v2\x10.awg.vpn.v1.RuleR\x05rules\x12'\n" +
+	"\x03dns\x18\x04 \x01(\v2\x15.awg.vpn.v1.DNSConfigR\x03dns\x12/\n" +
+	"\alogging\x18\x05 \x01(\v2\x15.awg.vpn.v1.LogConfigR\alogging\x129\n" +
+	"\fdomain_rules\x18\x06 \x03(\v2\x16.awg.vpn.v1.DomainRuleR\vdomainRules\x12D\n" +
+	"\rsubscriptions\x18\a \x03(\v2\x1e.awg.vpn.v1.SubscriptionConfigR\rsubscriptions\x129\n" +
+	"\treconnect\x18\b \x01(\v2\x1b.awg.vpn.v1.ReconnectConfigR\treconnect\x12=\n" +
+	"\vauto_bypass\x18\t \x01(\v2\x1c.awg.vpn.v1.AutoBypassConfigR\n" +
+	"autoBypass\"\xfd\x02\n" +
+	"\vTunnelStats\x12\x1b\n" +
+	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\x12-\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x17.awg.vpn.v1.TunnelStateR\x05state\x12\x19\n" +
+	"\bbytes_tx\x18\x03 \x01(\x03R\abytesTx\x12\x19\n" +
+	"\bbytes_rx\x18\x04 \x01(\x03R\abytesRx\x12\x19\n" +
+	"\bspeed_tx\x18\x05 \x01(\x03R\aspeedTx\x12\x19\n" +
+	"\bspeed_rx\x18\x06 \x01(\x03R\aspeedRx\x12\x1f\n" +
+	"\vpacket_loss\x18\a \x01(\x01R\n" +
+	"packetLoss\x12\x1d\n" +
+	"\n" +
+	"latency_ms\x18\b \x01(\x03R\tlatencyMs\x12A\n" +
+	"\x0elast_handshake\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\rlastHandshake\x12\x1b\n" +
+	"\tjitter_ms\x18\n" +
+	" \x01(\x03R\bjitterMs\x12\x16\n" +
+	"\x06banner\x18\v \x01(\tR\x06banner\"|\n" +
+	"\rStatsSnapshot\x121\n" +
+	"\atunnels\x18\x01 \x03(\v2\x17.awg.vpn.v1.TunnelStatsR\atunnels\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\x9c\x01\n" +
+	"\bLogEntry\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12*\n" +
+	"\x05level\x18\x02 \x01(\x0e2\x14.awg.vpn.v1.LogLevelR\x05level\x12\x10\n" +
+	"\x03tag\x18\x03 \x01(\tR\x03tag\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"G\n" +
+	"\vProcessInfo\x12\x10\n" +
+	"\x03pid\x18\x01 \x01(\rR\x03pid\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\"\xb9\x01\n" +
+	"\x0eConnectRequest\x12\x1b\n" +
+	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\x12K\n" +
+	"\vauth_params\x18\x02 \x03(\v2*.awg.vpn.v1.ConnectRequest.AuthParamsEntryR\n" +
+	"authParams\x1a=\n" +
+	"\x0fAuthParamsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"A\n" +
+	"\x0fConnectResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\"0\n" +
 	"\x11DisconnectRequest\x12\x1b\n" +
 	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\"D\n" +
@@ -5000,7 +7172,31 @@ const file_vpn_service_proto_rawDesc = "" +
 	"\x05rules\x18\x01 \x03(\v2\x10.awg.vpn.v1.RuleR\x05rules\"C\n" +
 	"\x11SaveRulesResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
-	"\x05error\x18\x02 \x01(\tR\x05error\"F\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xaf\x02\n" +
+	"\x12ImportRulesRequest\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\fR\acontent\x12\x1b\n" +
+	"\tfile_name\x18\x03 \x01(\tR\bfileName\x12L\n" +
+	"\n" +
+	"tunnel_map\x18\x04 \x03(\v2-.awg.vpn.v1.ImportRulesRequest.TunnelMapEntryR\ttunnelMap\x12%\n" +
+	"\x0edefault_tunnel\x18\x05 \x01(\tR\rdefaultTunnel\x12\x17\n" +
+	"\adry_run\x18\x06 \x01(\bR\x06dryRun\x1a<\n" +
+	"\x0eTunnelMapEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf8\x02\n" +
+	"\x13ImportRulesResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12&\n" +
+	"\x05rules\x18\x04 \x03(\v2\x10.awg.vpn.v1.RuleR\x05rules\x129\n" +
+	"\fdomain_rules\x18\x05 \x03(\v2\x16.awg.vpn.v1.DomainRuleR\vdomainRules\x12'\n" +
+	"\x0fdisallowed_apps\x18\x06 \x03(\tR\x0edisallowedApps\x12%\n" +
+	"\x0edisallowed_ips\x18\a \x03(\tR\rdisallowedIps\x121\n" +
+	"\askipped\x18\b \x03(\v2\x17.awg.vpn.v1.SkippedRuleR\askipped\x123\n" +
+	"\bwarnings\x18\t \x03(\v2\x17.awg.vpn.v1.SkippedRuleR\bwarnings\"=\n" +
+	"\vSkippedRule\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"F\n" +
 	"\x16DomainRuleListResponse\x12,\n" +
 	"\x05rules\x18\x01 \x03(\v2\x16.awg.vpn.v1.DomainRuleR\x05rules\"F\n" +
 	"\x16SaveDomainRulesRequest\x12,\n" +
@@ -5150,7 +7346,152 @@ const file_vpn_service_proto_rawDesc = "" +
 	"\rtunnel_filter\x18\x01 \x01(\tR\ftunnelFilter\x12%\n" +
 	"\x0eprocess_filter\x18\x02 \x01(\tR\rprocessFilter\"S\n" +
 	"\x12ConnectionSnapshot\x12=\n" +
-	"\vconnections\x18\x01 \x03(\v2\x1b.awg.vpn.v1.ConnectionEntryR\vconnections*n\n" +
+	"\vconnections\x18\x01 \x03(\v2\x1b.awg.vpn.v1.ConnectionEntryR\vconnections\"\x9a\x01\n" +
+	"\x15DNSQueryStreamRequest\x12\x12\n" +
+	"\x04tail\x18\x01 \x01(\x05R\x04tail\x12#\n" +
+	"\rdomain_filter\x18\x02 \x01(\tR\fdomainFilter\x12%\n" +
+	"\x0eprocess_filter\x18\x03 \x01(\tR\rprocessFilter\x12!\n" +
+	"\fblocked_only\x18\x04 \x01(\bR\vblockedOnly\"\x83\x03\n" +
+	"\rDNSQueryEntry\x128\n" +
+	"\ttimestamp\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12!\n" +
+	"\fprocess_name\x18\x02 \x01(\tR\vprocessName\x12\x14\n" +
+	"\x05qname\x18\x03 \x01(\tR\x05qname\x12\x14\n" +
+	"\x05qtype\x18\x04 \x01(\rR\x05qtype\x12\x12\n" +
+	"\x04rule\x18\x05 \x01(\tR\x04rule\x120\n" +
+	"\x06action\x18\x06 \x01(\x0e2\x18.awg.vpn.v1.DomainActionR\x06action\x12\x1b\n" +
+	"\ttunnel_id\x18\a \x01(\tR\btunnelId\x12\x1a\n" +
+	"\bupstream\x18\b \x01(\tR\bupstream\x12\x1d\n" +
+	"\n" +
+	"latency_us\x18\t \x01(\x03R\tlatencyUs\x12\x14\n" +
+	"\x05rcode\x18\n" +
+	" \x01(\rR\x05rcode\x12\x17\n" +
+	"\afake_ip\x18\v \x01(\tR\x06fakeIp\x12\x1c\n" +
+	"\ttransport\x18\f \x01(\tR\ttransport\"\xed\x01\n" +
+	"\x0eDNSDomainStats\x12\x16\n" +
+	"\x06domain\x18\x01 \x01(\tR\x06domain\x12\x18\n" +
+	"\aqueries\x18\x02 \x01(\x04R\aqueries\x12\x18\n" +
+	"\ablocked\x18\x03 \x01(\x04R\ablocked\x127\n" +
+	"\tlast_seen\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x12!\n" +
+	"\flast_process\x18\x05 \x01(\tR\vlastProcess\x12\x1f\n" +
+	"\vlast_tunnel\x18\x06 \x01(\tR\n" +
+	"lastTunnel\x12\x12\n" +
+	"\x04rule\x18\a \x01(\tR\x04rule\"M\n" +
+	"\x14DNSQueryStatsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x1f\n" +
+	"\vreset_stats\x18\x02 \x01(\bR\n" +
+	"resetStats\"\x99\x01\n" +
+	"\x15DNSQueryStatsResponse\x12;\n" +
+	"\vtop_domains\x18\x01 \x03(\v2\x1a.awg.vpn.v1.DNSDomainStatsR\n" +
+	"topDomains\x12C\n" +
+	"\x0fblocked_domains\x18\x02 \x03(\v2\x1a.awg.vpn.v1.DNSDomainStatsR\x0eblockedDomains\"6\n" +
+	"\x13WireGuardKeyRequest\x12\x1f\n" +
+	"\vprivate_key\x18\x01 \x01(\tR\n" +
+	"privateKey\"\x86\x01\n" +
+	"\x14WireGuardKeyResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x1f\n" +
+	"\vprivate_key\x18\x03 \x01(\tR\n" +
+	"privateKey\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x04 \x01(\tR\tpublicKey\"|\n" +
+	"\x16ImportWireGuardRequest\x12\x18\n" +
+	"\apayload\x18\x01 \x01(\tR\apayload\x12\x1b\n" +
+	"\ttunnel_id\x18\x02 \x01(\tR\btunnelId\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12\x17\n" +
+	"\adry_run\x18\x04 \x01(\bR\x06dryRun\"{\n" +
+	"\x17ImportWireGuardResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x120\n" +
+	"\x06config\x18\x03 \x01(\v2\x18.awg.vpn.v1.TunnelConfigR\x06config\"5\n" +
+	"\x16ExportWireGuardRequest\x12\x1b\n" +
+	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\"]\n" +
+	"\x17ExportWireGuardResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x12\n" +
+	"\x04conf\x18\x03 \x01(\tR\x04conf\"1\n" +
+	"\x12TunnelPeersRequest\x12\x1b\n" +
+	"\ttunnel_id\x18\x01 \x01(\tR\btunnelId\"\x9f\x02\n" +
+	"\n" +
+	"TunnelPeer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x12\x1a\n" +
+	"\bendpoint\x18\x03 \x01(\tR\bendpoint\x12\x1f\n" +
+	"\vallowed_ips\x18\x04 \x03(\tR\n" +
+	"allowedIps\x12A\n" +
+	"\x0elast_handshake\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\rlastHandshake\x12\x19\n" +
+	"\brx_bytes\x18\x06 \x01(\x04R\arxBytes\x12\x19\n" +
+	"\btx_bytes\x18\a \x01(\x04R\atxBytes\x12\x14\n" +
+	"\x05stale\x18\b \x01(\bR\x05stale\x12\x16\n" +
+	"\x06active\x18\t \x01(\bR\x06active\"s\n" +
+	"\x13TunnelPeersResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12,\n" +
+	"\x05peers\x18\x03 \x03(\v2\x16.awg.vpn.v1.TunnelPeerR\x05peers\"\xa9\x02\n" +
+	"\fWGServerPeer\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12\x18\n" +
+	"\aenabled\x18\x04 \x01(\bR\aenabled\x12\x1d\n" +
+	"\n" +
+	"has_config\x18\x05 \x01(\bR\thasConfig\x12\x1a\n" +
+	"\bendpoint\x18\x06 \x01(\tR\bendpoint\x12A\n" +
+	"\x0elast_handshake\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\rlastHandshake\x12\x19\n" +
+	"\brx_bytes\x18\b \x01(\x04R\arxBytes\x12\x19\n" +
+	"\btx_bytes\x18\t \x01(\x04R\atxBytes\"\xa2\x02\n" +
+	"\x16WGServerStatusResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x18\n" +
+	"\aenabled\x18\x03 \x01(\bR\aenabled\x12\x18\n" +
+	"\arunning\x18\x04 \x01(\bR\arunning\x12\x1f\n" +
+	"\vlisten_port\x18\x05 \x01(\x05R\n" +
+	"listenPort\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x06 \x01(\tR\tpublicKey\x12\x18\n" +
+	"\aaddress\x18\a \x01(\tR\aaddress\x12\x1a\n" +
+	"\bendpoint\x18\b \x01(\tR\bendpoint\x12.\n" +
+	"\x05peers\x18\t \x03(\v2\x18.awg.vpn.v1.WGServerPeerR\x05peers\"\x8a\x01\n" +
+	"\x16AddWGServerPeerRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"public_key\x18\x02 \x01(\tR\tpublicKey\x12#\n" +
+	"\rpreshared_key\x18\x03 \x01(\tR\fpresharedKey\x12\x18\n" +
+	"\aaddress\x18\x04 \x01(\tR\aaddress\"\x9c\x01\n" +
+	"\x17AddWGServerPeerResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12,\n" +
+	"\x04peer\x18\x03 \x01(\v2\x18.awg.vpn.v1.WGServerPeerR\x04peer\x12#\n" +
+	"\rclient_config\x18\x04 \x01(\tR\fclientConfig\"/\n" +
+	"\x19RemoveWGServerPeerRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"L\n" +
+	"\x1aRemoveWGServerPeerResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"1\n" +
+	"\x1bWGServerClientConfigRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"b\n" +
+	"\x1cWGServerClientConfigResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x12\x12\n" +
+	"\x04conf\x18\x03 \x01(\tR\x04conf\"\x85\x01\n" +
+	"\tRateLimit\x12\x17\n" +
+	"\aup_kbps\x18\x01 \x01(\x03R\x06upKbps\x12\x1b\n" +
+	"\tdown_kbps\x18\x02 \x01(\x03R\bdownKbps\x12\x1e\n" +
+	"\vup_burst_kb\x18\x03 \x01(\x03R\tupBurstKb\x12\"\n" +
+	"\rdown_burst_kb\x18\x04 \x01(\x03R\vdownBurstKb\"\x87\x01\n" +
+	"\x0eRateLimitEntry\x120\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x1a.awg.vpn.v1.RateLimitScopeR\x05scope\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12+\n" +
+	"\x05limit\x18\x03 \x01(\v2\x15.awg.vpn.v1.RateLimitR\x05limit\"H\n" +
+	"\x12RateLimitsResponse\x122\n" +
+	"\x06limits\x18\x01 \x03(\v2\x1a.awg.vpn.v1.RateLimitEntryR\x06limits\"\x8c\x01\n" +
+	"\x13SetRateLimitRequest\x120\n" +
+	"\x05scope\x18\x01 \x01(\x0e2\x1a.awg.vpn.v1.RateLimitScopeR\x05scope\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12+\n" +
+	"\x05limit\x18\x03 \x01(\v2\x15.awg.vpn.v1.RateLimitR\x05limit\"F\n" +
+	"\x14SetRateLimitResponse\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error*n\n" +
 	"\vTunnelState\x12\x15\n" +
 	"\x11TUNNEL_STATE_DOWN\x10\x00\x12\x1b\n" +
 	"\x17TUNNEL_STATE_CONNECTING\x10\x01\x12\x13\n" +
@@ -5175,7 +7516,11 @@ const file_vpn_service_proto_rawDesc = "" +
 	"\fDomainAction\x12\x17\n" +
 	"\x13DOMAIN_ACTION_ROUTE\x10\x00\x12\x18\n" +
 	"\x14DOMAIN_ACTION_DIRECT\x10\x01\x12\x17\n" +
-	"\x13DOMAIN_ACTION_BLOCK\x10\x022\xea\x1a\n" +
+	"\x13DOMAIN_ACTION_BLOCK\x10\x02*e\n" +
+	"\x0eRateLimitScope\x12\x1b\n" +
+	"\x17RATE_LIMIT_SCOPE_GLOBAL\x10\x00\x12\x1b\n" +
+	"\x17RATE_LIMIT_SCOPE_TUNNEL\x10\x01\x12\x19\n" +
+	"\x15RATE_LIMIT_SCOPE_RULE\x10\x022\xf6#\n" +
 	"\n" +
 	"VPNService\x12>\n" +
 	"\tGetStatus\x12\x16.google.protobuf.Empty\x1a\x19.awg.vpn.v1.ServiceStatus\x12:\n" +
@@ -5193,9 +7538,20 @@ const file_vpn_service_proto_rawDesc = "" +
 	"Disconnect\x12\x1d.awg.vpn.v1.DisconnectRequest\x1a\x1e.awg.vpn.v1.DisconnectResponse\x12H\n" +
 	"\rRestartTunnel\x12\x1a.awg.vpn.v1.ConnectRequest\x1a\x1b.awg.vpn.v1.ConnectResponse\x12Z\n" +
 	"\x0fSaveTunnelOrder\x12\".awg.vpn.v1.SaveTunnelOrderRequest\x1a#.awg.vpn.v1.SaveTunnelOrderResponse\x12Q\n" +
-	"\fRenameTunnel\x12\x1f.awg.vpn.v1.RenameTunnelRequest\x1a .awg.vpn.v1.RenameTunnelResponse\x12A\n" +
+	"\fRenameTunnel\x12\x1f.awg.vpn.v1.RenameTunnelRequest\x1a .awg.vpn.v1.RenameTunnelResponse\x12Z\n" +
+	"\x15GenerateWireGuardKeys\x12\x1f.awg.vpn.v1.WireGuardKeyRequest\x1a .awg.vpn.v1.WireGuardKeyResponse\x12`\n" +
+	"\x15ImportWireGuardConfig\x12\".awg.vpn.v1.ImportWireGuardRequest\x1a#.awg.vpn.v1.ImportWireGuardResponse\x12`\n" +
+	"\x15ExportWireGuardConfig\x12\".awg.vpn.v1.ExportWireGuardRequest\x1a#.awg.vpn.v1.ExportWireGuardResponse\x12Q\n" +
+	"\x0eGetTunnelPeers\x12\x1e.awg.vpn.v1.TunnelPeersRequest\x1a\x1f.awg.vpn.v1.TunnelPeersResponse\x12O\n" +
+	"\x11GetWGServerStatus\x12\x16.google.protobuf.Empty\x1a\".awg.vpn.v1.WGServerStatusResponse\x12Z\n" +
+	"\x0fAddWGServerPeer\x12\".awg.vpn.v1.AddWGServerPeerRequest\x1a#.awg.vpn.v1.AddWGServerPeerResponse\x12c\n" +
+	"\x12RemoveWGServerPeer\x12%.awg.vpn.v1.RemoveWGServerPeerRequest\x1a&.awg.vpn.v1.RemoveWGServerPeerResponse\x12l\n" +
+	"\x17GetWGServerClientConfig\x12'.awg.vpn.v1.WGServerClientConfigRequest\x1a(.awg.vpn.v1.WGServerClientConfigResponse\x12G\n" +
+	"\rGetRateLimits\x12\x16.google.protobuf.Empty\x1a\x1e.awg.vpn.v1.RateLimitsResponse\x12Q\n" +
+	"\fSetRateLimit\x12\x1f.awg.vpn.v1.SetRateLimitRequest\x1a .awg.vpn.v1.SetRateLimitResponse\x12A\n" +
 	"\tListRules\x12\x16.google.protobuf.Empty\x1a\x1c.awg.vpn.v1.RuleListResponse\x12H\n" +
-	"\tSaveRules\x12\x1c.awg.vpn.v1.SaveRulesRequest\x1a\x1d.awg.vpn.v1.SaveRulesResponse\x12M\n" +
+	"\tSaveRules\x12\x1c.awg.vpn.v1.SaveRulesRequest\x1a\x1d.awg.vpn.v1.SaveRulesResponse\x12N\n" +
+	"\vImportRules\x12\x1e.awg.vpn.v1.ImportRulesRequest\x1a\x1f.awg.vpn.v1.ImportRulesResponse\x12M\n" +
 	"\x0fListDomainRules\x12\x16.google.protobuf.Empty\x1a\".awg.vpn.v1.DomainRuleListResponse\x12Z\n" +
 	"\x0fSaveDomainRules\x12\".awg.vpn.v1.SaveDomainRulesRequest\x1a#.awg.vpn.v1.SaveDomainRulesResponse\x12V\n" +
 	"\x15ListGeositeCategories\x12\x16.google.protobuf.Empty\x1a%.awg.vpn.v1.GeositeCategoriesResponse\x12T\n" +
@@ -5219,7 +7575,9 @@ const file_vpn_service_proto_rawDesc = "" +
 	"\x13RefreshSubscription\x12&.awg.vpn.v1.RefreshSubscriptionRequest\x1a'.awg.vpn.v1.RefreshSubscriptionResponse\x12c\n" +
 	"\x12UpdateSubscription\x12%.awg.vpn.v1.UpdateSubscriptionRequest\x1a&.awg.vpn.v1.UpdateSubscriptionResponse\x12I\n" +
 	"\x12RestoreConnections\x12\x16.google.protobuf.Empty\x1a\x1b.awg.vpn.v1.ConnectResponse\x12?\n" +
-	"\bFlushDNS\x12\x16.google.protobuf.Empty\x1a\x1b.awg.vpn.v1.ConnectResponse\x12F\n" +
+	"\bFlushDNS\x12\x16.google.protobuf.Empty\x1a\x1b.awg.vpn.v1.ConnectResponse\x12R\n" +
+	"\x10StreamDNSQueries\x12!.awg.vpn.v1.DNSQueryStreamRequest\x1a\x19.awg.vpn.v1.DNSQueryEntry0\x01\x12W\n" +
+	"\x10GetDNSQueryStats\x12 .awg.vpn.v1.DNSQueryStatsRequest\x1a!.awg.vpn.v1.DNSQueryStatsResponse\x12F\n" +
 	"\vCheckUpdate\x12\x16.google.protobuf.Empty\x1a\x1f.awg.vpn.v1.CheckUpdateResponse\x12F\n" +
 	"\vApplyUpdate\x12\x16.google.protobuf.Empty\x1a\x1f.awg.vpn.v1.ApplyUpdateResponse\x12I\n" +
 	"\x11ApplyUpdateStream\x12\x16.google.protobuf.Empty\x1a\x1a.awg.vpn.v1.UpdateProgress0\x01\x12[\n" +
@@ -5238,234 +7596,316 @@ func file_vpn_service_proto_rawDescGZIP() []byte {
 	return file_vpn_service_proto_rawDescData
 }
 
-var file_vpn_service_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
-var file_vpn_service_proto_msgTypes = make([]protoimpl.MessageInfo, 82)
+var file_vpn_service_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_vpn_service_proto_msgTypes = make([]protoimpl.MessageInfo, 113)
 var file_vpn_service_proto_goTypes = []any{
 	(TunnelState)(0),                        // 0: awg.vpn.v1.TunnelState
 	(FallbackPolicy)(0),                     // 1: awg.vpn.v1.FallbackPolicy
 	(LogLevel)(0),                           // 2: awg.vpn.v1.LogLevel
 	(DaemonState)(0),                        // 3: awg.vpn.v1.DaemonState
 	(DomainAction)(0),                       // 4: awg.vpn.v1.DomainAction
-	(*TunnelConfig)(nil),                    // 5: awg.vpn.v1.TunnelConfig
-	(*TunnelStatus)(nil),                    // 6: awg.vpn.v1.TunnelStatus
-	(*DomainRule)(nil),                      // 7: awg.vpn.v1.DomainRule
-	(*Rule)(nil),                            // 8: awg.vpn.v1.Rule
-	(*DNSCacheConfig)(nil),                  // 9: awg.vpn.v1.DNSCacheConfig
-	(*FakeIPConfig)(nil),                    // 10: awg.vpn.v1.FakeIPConfig
-	(*DNSConfig)(nil),                       // 11: awg.vpn.v1.DNSConfig
-	(*GlobalFilterConfig)(nil),              // 12: awg.vpn.v1.GlobalFilterConfig
-	(*LogConfig)(nil),                       // 13: awg.vpn.v1.LogConfig
-	(*SubscriptionConfig)(nil),              // 14: awg.vpn.v1.SubscriptionConfig
-	(*SubscriptionStatus)(nil),              // 15: awg.vpn.v1.SubscriptionStatus
-	(*ReconnectConfig)(nil),                 // 16: awg.vpn.v1.ReconnectConfig
-	(*AutoBypassConfig)(nil),                // 17: awg.vpn.v1.AutoBypassConfig
-	(*AppConfig)(nil),                       // 18: awg.vpn.v1.AppConfig
-	(*TunnelStats)(nil),                     // 19: awg.vpn.v1.TunnelStats
-	(*StatsSnapshot)(nil),                   // 20: awg.vpn.v1.StatsSnapshot
-	(*LogEntry)(nil),                        // 21: awg.vpn.v1.LogEntry
-	(*ProcessInfo)(nil),                     // 22: awg.vpn.v1.ProcessInfo
-	(*ConnectRequest)(nil),                  // 23: awg.vpn.v1.ConnectRequest
-	(*ConnectResponse)(nil),                 // 24: awg.vpn.v1.ConnectResponse
-	(*DisconnectRequest)(nil),               // 25: awg.vpn.v1.DisconnectRequest
-	(*DisconnectResponse)(nil),              // 26: awg.vpn.v1.DisconnectResponse
-	(*AddTunnelRequest)(nil),                // 27: awg.vpn.v1.AddTunnelRequest
-	(*AddTunnelResponse)(nil),               // 28: awg.vpn.v1.AddTunnelResponse
-	(*RemoveTunnelRequest)(nil),             // 29: awg.vpn.v1.RemoveTunnelRequest
-	(*RemoveTunnelResponse)(nil),            // 30: awg.vpn.v1.RemoveTunnelResponse
-	(*UpdateTunnelRequest)(nil),             // 31: awg.vpn.v1.UpdateTunnelRequest
-	(*UpdateTunnelResponse)(nil),            // 32: awg.vpn.v1.UpdateTunnelResponse
-	(*GetTunnelRequest)(nil),                // 33: awg.vpn.v1.GetTunnelRequest
-	(*TunnelListResponse)(nil),              // 34: awg.vpn.v1.TunnelListResponse
-	(*SaveTunnelOrderRequest)(nil),          // 35: awg.vpn.v1.SaveTunnelOrderRequest
-	(*SaveTunnelOrderResponse)(nil),         // 36: awg.vpn.v1.SaveTunnelOrderResponse
-	(*RuleListResponse)(nil),                // 37: awg.vpn.v1.RuleListResponse
-	(*SaveRulesRequest)(nil),                // 38: awg.vpn.v1.SaveRulesRequest
-	(*SaveRulesResponse)(nil),               // 39: awg.vpn.v1.SaveRulesResponse
-	(*DomainRuleListResponse)(nil),          // 40: awg.vpn.v1.DomainRuleListResponse
-	(*SaveDomainRulesRequest)(nil),          // 41: awg.vpn.v1.SaveDomainRulesRequest
-	(*SaveDomainRulesResponse)(nil),         // 42: awg.vpn.v1.SaveDomainRulesResponse
-	(*GeositeCategoriesResponse)(nil),       // 43: awg.vpn.v1.GeositeCategoriesResponse
-	(*UpdateGeositeResponse)(nil),           // 44: awg.vpn.v1.UpdateGeositeResponse
-	(*SaveConfigRequest)(nil),               // 45: awg.vpn.v1.SaveConfigRequest
-	(*SaveConfigResponse)(nil),              // 46: awg.vpn.v1.SaveConfigResponse
-	(*ExportConfigResponse)(nil),            // 47: awg.vpn.v1.ExportConfigResponse
-	(*ImportConfigRequest)(nil),             // 48: awg.vpn.v1.ImportConfigRequest
-	(*ImportConfigResponse)(nil),            // 49: awg.vpn.v1.ImportConfigResponse
-	(*LogStreamRequest)(nil),                // 50: awg.vpn.v1.LogStreamRequest
-	(*StatsStreamRequest)(nil),              // 51: awg.vpn.v1.StatsStreamRequest
-	(*ProcessListRequest)(nil),              // 52: awg.vpn.v1.ProcessListRequest
-	(*ProcessListResponse)(nil),             // 53: awg.vpn.v1.ProcessListResponse
-	(*SubscriptionListResponse)(nil),        // 54: awg.vpn.v1.SubscriptionListResponse
-	(*AddSubscriptionRequest)(nil),          // 55: awg.vpn.v1.AddSubscriptionRequest
-	(*AddSubscriptionResponse)(nil),         // 56: awg.vpn.v1.AddSubscriptionResponse
-	(*RemoveSubscriptionRequest)(nil),       // 57: awg.vpn.v1.RemoveSubscriptionRequest
-	(*RemoveSubscriptionResponse)(nil),      // 58: awg.vpn.v1.RemoveSubscriptionResponse
-	(*RefreshSubscriptionRequest)(nil),      // 59: awg.vpn.v1.RefreshSubscriptionRequest
-	(*RefreshSubscriptionResponse)(nil),     // 60: awg.vpn.v1.RefreshSubscriptionResponse
-	(*UpdateSubscriptionRequest)(nil),       // 61: awg.vpn.v1.UpdateSubscriptionRequest
-	(*UpdateSubscriptionResponse)(nil),      // 62: awg.vpn.v1.UpdateSubscriptionResponse
-	(*RenameTunnelRequest)(nil),             // 63: awg.vpn.v1.RenameTunnelRequest
-	(*RenameTunnelResponse)(nil),            // 64: awg.vpn.v1.RenameTunnelResponse
-	(*ServiceStatus)(nil),                   // 65: awg.vpn.v1.ServiceStatus
-	(*ActivateRequest)(nil),                 // 66: awg.vpn.v1.ActivateRequest
-	(*ActivateResponse)(nil),                // 67: awg.vpn.v1.ActivateResponse
-	(*DeactivateRequest)(nil),               // 68: awg.vpn.v1.DeactivateRequest
-	(*DeactivateResponse)(nil),              // 69: awg.vpn.v1.DeactivateResponse
-	(*UpdateInfo)(nil),                      // 70: awg.vpn.v1.UpdateInfo
-	(*CheckUpdateResponse)(nil),             // 71: awg.vpn.v1.CheckUpdateResponse
-	(*ApplyUpdateResponse)(nil),             // 72: awg.vpn.v1.ApplyUpdateResponse
-	(*UpdateProgress)(nil),                  // 73: awg.vpn.v1.UpdateProgress
-	(*AutostartConfig)(nil),                 // 74: awg.vpn.v1.AutostartConfig
-	(*SetAutostartRequest)(nil),             // 75: awg.vpn.v1.SetAutostartRequest
-	(*SetAutostartResponse)(nil),            // 76: awg.vpn.v1.SetAutostartResponse
-	(*ConflictingService)(nil),              // 77: awg.vpn.v1.ConflictingService
-	(*ConflictingServicesResponse)(nil),     // 78: awg.vpn.v1.ConflictingServicesResponse
-	(*StopConflictingServicesRequest)(nil),  // 79: awg.vpn.v1.StopConflictingServicesRequest
-	(*StopConflictingServicesResponse)(nil), // 80: awg.vpn.v1.StopConflictingServicesResponse
-	(*ConnectionEntry)(nil),                 // 81: awg.vpn.v1.ConnectionEntry
-	(*ConnectionMonitorRequest)(nil),        // 82: awg.vpn.v1.ConnectionMonitorRequest
-	(*ConnectionSnapshot)(nil),              // 83: awg.vpn.v1.ConnectionSnapshot
-	nil,                                     // 84: awg.vpn.v1.TunnelConfig.SettingsEntry
-	nil,                                     // 85: awg.vpn.v1.LogConfig.ComponentsEntry
-	nil,                                     // 86: awg.vpn.v1.ConnectRequest.AuthParamsEntry
-	(*timestamppb.Timestamp)(nil),           // 87: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),                   // 88: google.protobuf.Empty
+	(RateLimitScope)(0),                     // 5: awg.vpn.v1.RateLimitScope
+	(*TunnelConfig)(nil),                    // 6: awg.vpn.v1.TunnelConfig
+	(*TunnelStatus)(nil),                    // 7: awg.vpn.v1.TunnelStatus
+	(*DomainRule)(nil),                      // 8: awg.vpn.v1.DomainRule
+	(*Rule)(nil),                            // 9: awg.vpn.v1.Rule
+	(*DNSCacheConfig)(nil),                  // 10: awg.vpn.v1.DNSCacheConfig
+	(*FakeIPConfig)(nil),                    // 11: awg.vpn.v1.FakeIPConfig
+	(*DNSConfig)(nil),                       // 12: awg.vpn.v1.DNSConfig
+	(*GlobalFilterConfig)(nil),              // 13: awg.vpn.v1.GlobalFilterConfig
+	(*LogConfig)(nil),                       // 14: awg.vpn.v1.LogConfig
+	(*SubscriptionConfig)(nil),              // 15: awg.vpn.v1.SubscriptionConfig
+	(*SubscriptionStatus)(nil),              // 16: awg.vpn.v1.SubscriptionStatus
+	(*ReconnectConfig)(nil),                 // 17: awg.vpn.v1.ReconnectConfig
+	(*AutoBypassConfig)(nil),                // 18: awg.vpn.v1.AutoBypassConfig
+	(*AppConfig)(nil),                       // 19: awg.vpn.v1.AppConfig
+	(*TunnelStats)(nil),                     // 20: awg.vpn.v1.TunnelStats
+	(*StatsSnapshot)(nil),                   // 21: awg.vpn.v1.StatsSnapshot
+	(*LogEntry)(nil),                        // 22: awg.vpn.v1.LogEntry
+	(*ProcessInfo)(nil),                     // 23: awg.vpn.v1.ProcessInfo
+	(*ConnectRequest)(nil),                  // 24: awg.vpn.v1.ConnectRequest
+	(*ConnectResponse)(nil),                 // 25: awg.vpn.v1.ConnectResponse
+	(*DisconnectRequest)(nil),               // 26: awg.vpn.v1.DisconnectRequest
+	(*DisconnectResponse)(nil),              // 27: awg.vpn.v1.DisconnectResponse
+	(*AddTunnelRequest)(nil),                // 28: awg.vpn.v1.AddTunnelRequest
+	(*AddTunnelResponse)(nil),               // 29: awg.vpn.v1.AddTunnelResponse
+	(*RemoveTunnelRequest)(nil),             // 30: awg.vpn.v1.RemoveTunnelRequest
+	(*RemoveTunnelResponse)(nil),            // 31: awg.vpn.v1.RemoveTunnelResponse
+	(*UpdateTunnelRequest)(nil),             // 32: awg.vpn.v1.UpdateTunnelRequest
+	(*UpdateTunnelResponse)(nil),            // 33: awg.vpn.v1.UpdateTunnelResponse
+	(*GetTunnelRequest)(nil),                // 34: awg.vpn.v1.GetTunnelRequest
+	(*TunnelListResponse)(nil),              // 35: awg.vpn.v1.TunnelListResponse
+	(*SaveTunnelOrderRequest)(nil),          // 36: awg.vpn.v1.SaveTunnelOrderRequest
+	(*SaveTunnelOrderResponse)(nil),         // 37: awg.vpn.v1.SaveTunnelOrderResponse
+	(*RuleListResponse)(nil),                // 38: awg.vpn.v1.RuleListResponse
+	(*SaveRulesRequest)(nil),                // 39: awg.vpn.v1.SaveRulesRequest
+	(*SaveRulesResponse)(nil),               // 40: awg.vpn.v1.SaveRulesResponse
+	(*ImportRulesRequest)(nil),              // 41: awg.vpn.v1.ImportRulesRequest
+	(*ImportRulesResponse)(nil),             // 42: awg.vpn.v1.ImportRulesResponse
+	(*SkippedRule)(nil),                     // 43: awg.vpn.v1.SkippedRule
+	(*DomainRuleListResponse)(nil),          // 44: awg.vpn.v1.DomainRuleListResponse
+	(*SaveDomainRulesRequest)(nil),          // 45: awg.vpn.v1.SaveDomainRulesRequest
+	(*SaveDomainRulesResponse)(nil),         // 46: awg.vpn.v1.SaveDomainRulesResponse
+	(*GeositeCategoriesResponse)(nil),       // 47: awg.vpn.v1.GeositeCategoriesResponse
+	(*UpdateGeositeResponse)(nil),           // 48: awg.vpn.v1.UpdateGeositeResponse
+	(*SaveConfigRequest)(nil),               // 49: awg.vpn.v1.SaveConfigRequest
+	(*SaveConfigResponse)(nil),              // 50: awg.vpn.v1.SaveConfigResponse
+	(*ExportConfigResponse)(nil),            // 51: awg.vpn.v1.ExportConfigResponse
+	(*ImportConfigRequest)(nil),             // 52: awg.vpn.v1.ImportConfigRequest
+	(*ImportConfigResponse)(nil),            // 53: awg.vpn.v1.ImportConfigResponse
+	(*LogStreamRequest)(nil),                // 54: awg.vpn.v1.LogStreamRequest
+	(*StatsStreamRequest)(nil),              // 55: awg.vpn.v1.StatsStreamRequest
+	(*ProcessListRequest)(nil),              // 56: awg.vpn.v1.ProcessListRequest
+	(*ProcessListResponse)(nil),             // 57: awg.vpn.v1.ProcessListResponse
+	(*SubscriptionListResponse)(nil),        // 58: awg.vpn.v1.SubscriptionListResponse
+	(*AddSubscriptionRequest)(nil),          // 59: awg.vpn.v1.AddSubscriptionRequest
+	(*AddSubscriptionResponse)(nil),         // 60: awg.vpn.v1.AddSubscriptionResponse
+	(*RemoveSubscriptionRequest)(nil),       // 61: awg.vpn.v1.RemoveSubscriptionRequest
+	(*RemoveSubscriptionResponse)(nil),      // 62: awg.vpn.v1.RemoveSubscriptionResponse
+	(*RefreshSubscriptionRequest)(nil),      // 63: awg.vpn.v1.RefreshSubscriptionRequest
+	(*RefreshSubscriptionResponse)(nil),     // 64: awg.vpn.v1.RefreshSubscriptionResponse
+	(*UpdateSubscriptionRequest)(nil),       // 65: awg.vpn.v1.UpdateSubscriptionRequest
+	(*UpdateSubscriptionResponse)(nil),      // 66: awg.vpn.v1.UpdateSubscriptionResponse
+	(*RenameTunnelRequest)(nil),             // 67: awg.vpn.v1.RenameTunnelRequest
+	(*RenameTunnelResponse)(nil),            // 68: awg.vpn.v1.RenameTunnelResponse
+	(*ServiceStatus)(nil),                   // 69: awg.vpn.v1.ServiceStatus
+	(*ActivateRequest)(nil),                 // 70: awg.vpn.v1.ActivateRequest
+	(*ActivateResponse)(nil),                // 71: awg.vpn.v1.ActivateResponse
+	(*DeactivateRequest)(nil),               // 72: awg.vpn.v1.DeactivateRequest
+	(*DeactivateResponse)(nil),              // 73: awg.vpn.v1.DeactivateResponse
+	(*UpdateInfo)(nil),                      // 74: awg.vpn.v1.UpdateInfo
+	(*CheckUpdateResponse)(nil),             // 75: awg.vpn.v1.CheckUpdateResponse
+	(*ApplyUpdateResponse)(nil),             // 76: awg.vpn.v1.ApplyUpdateResponse
+	(*UpdateProgress)(nil),                  // 77: awg.vpn.v1.UpdateProgress
+	(*AutostartConfig)(nil),                 // 78: awg.vpn.v1.AutostartConfig
+	(*SetAutostartRequest)(nil),             // 79: awg.vpn.v1.SetAutostartRequest
+	(*SetAutostartResponse)(nil),            // 80: awg.vpn.v1.SetAutostartResponse
+	(*ConflictingService)(nil),              // 81: awg.vpn.v1.ConflictingService
+	(*ConflictingServicesResponse)(nil),     // 82: awg.vpn.v1.ConflictingServicesResponse
+	(*StopConflictingServicesRequest)(nil),  // 83: awg.vpn.v1.StopConflictingServicesRequest
+	(*StopConflictingServicesResponse)(nil), // 84: awg.vpn.v1.StopConflictingServicesResponse
+	(*ConnectionEntry)(nil),                 // 85: awg.vpn.v1.ConnectionEntry
+	(*ConnectionMonitorRequest)(nil),        // 86: awg.vpn.v1.ConnectionMonitorRequest
+	(*ConnectionSnapshot)(nil),              // 87: awg.vpn.v1.ConnectionSnapshot
+	(*DNSQueryStreamRequest)(nil),           // 88: awg.vpn.v1.DNSQueryStreamRequest
+	(*DNSQueryEntry)(nil),                   // 89: awg.vpn.v1.DNSQueryEntry
+	(*DNSDomainStats)(nil),                  // 90: awg.vpn.v1.DNSDomainStats
+	(*DNSQueryStatsRequest)(nil),            // 91: awg.vpn.v1.DNSQueryStatsRequest
+	(*DNSQueryStatsResponse)(nil),           // 92: awg.vpn.v1.DNSQueryStatsResponse
+	(*WireGuardKeyRequest)(nil),             // 93: awg.vpn.v1.WireGuardKeyRequest
+	(*WireGuardKeyResponse)(nil),            // 94: awg.vpn.v1.WireGuardKeyResponse
+	(*ImportWireGuardRequest)(nil),          // 95: awg.vpn.v1.ImportWireGuardRequest
+	(*ImportWireGuardResponse)(nil),         // 96: awg.vpn.v1.ImportWireGuardResponse
+	(*ExportWireGuardRequest)(nil),          // 97: awg.vpn.v1.ExportWireGuardRequest
+	(*ExportWireGuardResponse)(nil),         // 98: awg.vpn.v1.ExportWireGuardResponse
+	(*TunnelPeersRequest)(nil),              // 99: awg.vpn.v1.TunnelPeersRequest
+	(*TunnelPeer)(nil),                      // 100: awg.vpn.v1.TunnelPeer
+	(*TunnelPeersResponse)(nil),             // 101: awg.vpn.v1.TunnelPeersResponse
+	(*WGServerPeer)(nil),                    // 102: awg.vpn.v1.WGServerPeer
+	(*WGServerStatusResponse)(nil),          // 103: awg.vpn.v1.WGServerStatusResponse
+	(*AddWGServerPeerRequest)(nil),          // 104: awg.vpn.v1.AddWGServerPeerRequest
+	(*AddWGServerPeerResponse)(nil),         // 105: awg.vpn.v1.AddWGServerPeerResponse
+	(*RemoveWGServerPeerRequest)(nil),       // 106: awg.vpn.v1.RemoveWGServerPeerRequest
+	(*RemoveWGServerPeerResponse)(nil),      // 107: awg.vpn.v1.RemoveWGServerPeerResponse
+	(*WGServerClientConfigRequest)(nil),     // 108: awg.vpn.v1.WGServerClientConfigRequest
+	(*WGServerClientConfigResponse)(nil),    // 109: awg.vpn.v1.WGServerClientConfigResponse
+	(*RateLimit)(nil),                       // 110: awg.vpn.v1.RateLimit
+	(*RateLimitEntry)(nil),                  // 111: awg.vpn.v1.RateLimitEntry
+	(*RateLimitsResponse)(nil),              // 112: awg.vpn.v1.RateLimitsResponse
+	(*SetRateLimitRequest)(nil),             // 113: awg.vpn.v1.SetRateLimitRequest
+	(*SetRateLimitResponse)(nil),            // 114: awg.vpn.v1.SetRateLimitResponse
+	nil,                                     // 115: awg.vpn.v1.TunnelConfig.SettingsEntry
+	nil,                                     // 116: awg.vpn.v1.LogConfig.ComponentsEntry
+	nil,                                     // 117: awg.vpn.v1.ConnectRequest.AuthParamsEntry
+	nil,                                     // 118: awg.vpn.v1.ImportRulesRequest.TunnelMapEntry
+	(*timestamppb.Timestamp)(nil),           // 119: google.protobuf.Timestamp
+	(*emptypb.Empty)(nil),                   // 120: google.protobuf.Empty
 }
 var file_vpn_service_proto_depIdxs = []int32{
-	84, // 0: awg.vpn.v1.TunnelConfig.settings:type_name -> awg.vpn.v1.TunnelConfig.SettingsEntry
-	5,  // 1: awg.vpn.v1.TunnelStatus.config:type_name -> awg.vpn.v1.TunnelConfig
-	0,  // 2: awg.vpn.v1.TunnelStatus.state:type_name -> awg.vpn.v1.TunnelState
-	4,  // 3: awg.vpn.v1.DomainRule.action:type_name -> awg.vpn.v1.DomainAction
-	1,  // 4: awg.vpn.v1.Rule.fallback:type_name -> awg.vpn.v1.FallbackPolicy
-	9,  // 5: awg.vpn.v1.DNSConfig.cache:type_name -> awg.vpn.v1.DNSCacheConfig
-	10, // 6: awg.vpn.v1.DNSConfig.fakeip:type_name -> awg.vpn.v1.FakeIPConfig
-	85, // 7: awg.vpn.v1.LogConfig.components:type_name -> awg.vpn.v1.LogConfig.ComponentsEntry
-	14, // 8: awg.vpn.v1.SubscriptionStatus.config:type_name -> awg.vpn.v1.SubscriptionConfig
-	12, // 9: awg.vpn.v1.AppConfig.global:type_name -> awg.vpn.v1.GlobalFilterConfig
-	5,  // 10: awg.vpn.v1.AppConfig.tunnels:type_name -> awg.vpn.v1.TunnelConfig
-	8,  // 11: awg.vpn.v1.AppConfig.rules:type_name -> awg.vpn.v1.Rule
-	11, // 12: awg.vpn.v1.AppConfig.dns:type_name -> awg.vpn.v1.DNSConfig
-	13, // 13: awg.vpn.v1.AppConfig.logging:type_name -> awg.vpn.v1.LogConfig
-	7,  // 14: awg.vpn.v1.AppConfig.domain_rules:type_name -> awg.vpn.v1.DomainRule
-	14, // 15: awg.vpn.v1.AppConfig.subscriptions:type_name -> awg.vpn.v1.SubscriptionConfig
-	16, // 16: awg.vpn.v1.AppConfig.reconnect:type_name -> awg.vpn.v1.ReconnectConfig
-	17, // 17: awg.vpn.v1.AppConfig.auto_bypass:type_name -> awg.vpn.v1.AutoBypassConfig
-	0,  // 18: awg.vpn.v1.TunnelStats.state:type_name -> awg.vpn.v1.TunnelState
-	87, // 19: awg.vpn.v1.TunnelStats.last_handshake:type_name -> google.protobuf.Timestamp
-	19, // 20: awg.vpn.v1.StatsSnapshot.tunnels:type_name -> awg.vpn.v1.TunnelStats
-	87, // 21: awg.vpn.v1.StatsSnapshot.timestamp:type_name -> google.protobuf.Timestamp
-	87, // 22: awg.vpn.v1.LogEntry.timestamp:type_name -> google.protobuf.Timestamp
-	2,  // 23: awg.vpn.v1.LogEntry.level:type_name -> awg.vpn.v1.LogLevel
-	86, // 24: awg.vpn.v1.ConnectRequest.auth_params:type_name -> awg.vpn.v1.ConnectRequest.AuthParamsEntry
-	5,  // 25: awg.vpn.v1.AddTunnelRequest.config:type_name -> awg.vpn.v1.TunnelConfig
-	5,  // 26: awg.vpn.v1.UpdateTunnelRequest.config:type_name -> awg.vpn.v1.TunnelConfig
-	6,  // 27: awg.vpn.v1.TunnelListResponse.tunnels:type_name -> awg.vpn.v1.TunnelStatus
-	8,  // 28: awg.vpn.v1.RuleListResponse.rules:type_name -> awg.vpn.v1.Rule
-	8,  // 29: awg.vpn.v1.SaveRulesRequest.rules:type_name -> awg.vpn.v1.Rule
-	7,  // 30: awg.vpn.v1.DomainRuleListResponse.rules:type_name -> awg.vpn.v1.DomainRule
-	7,  // 31: awg.vpn.v1.SaveDomainRulesRequest.rules:type_name -> awg.vpn.v1.DomainRule
-	18, // 32: awg.vpn.v1.SaveConfigRequest.config:type_name -> awg.vpn.v1.AppConfig
-	2,  // 33: awg.vpn.v1.LogStreamRequest.min_level:type_name -> awg.vpn.v1.LogLevel
-	22, // 34: awg.vpn.v1.ProcessListResponse.processes:type_name -> awg.vpn.v1.ProcessInfo
-	15, // 35: awg.vpn.v1.SubscriptionListResponse.subscriptions:type_name -> awg.vpn.v1.SubscriptionStatus
-	14, // 36: awg.vpn.v1.AddSubscriptionRequest.config:type_name -> awg.vpn.v1.SubscriptionConfig
-	14, // 37: awg.vpn.v1.UpdateSubscriptionRequest.config:type_name -> awg.vpn.v1.SubscriptionConfig
-	3,  // 38: awg.vpn.v1.ServiceStatus.daemon_state:type_name -> awg.vpn.v1.DaemonState
-	70, // 39: awg.vpn.v1.CheckUpdateResponse.info:type_name -> awg.vpn.v1.UpdateInfo
-	74, // 40: awg.vpn.v1.SetAutostartRequest.config:type_name -> awg.vpn.v1.AutostartConfig
-	77, // 41: awg.vpn.v1.ConflictingServicesResponse.services:type_name -> awg.vpn.v1.ConflictingService
-	81, // 42: awg.vpn.v1.ConnectionSnapshot.connections:type_name -> awg.vpn.v1.ConnectionEntry
-	88, // 43: awg.vpn.v1.VPNService.GetStatus:input_type -> google.protobuf.Empty
-	88, // 44: awg.vpn.v1.VPNService.Shutdown:input_type -> google.protobuf.Empty
-	66, // 45: awg.vpn.v1.VPNService.Activate:input_type -> awg.vpn.v1.ActivateRequest
-	68, // 46: awg.vpn.v1.VPNService.Deactivate:input_type -> awg.vpn.v1.DeactivateRequest
-	88, // 47: awg.vpn.v1.VPNService.ListTunnels:input_type -> google.protobuf.Empty
-	33, // 48: awg.vpn.v1.VPNService.GetTunnel:input_type -> awg.vpn.v1.GetTunnelRequest
-	27, // 49: awg.vpn.v1.VPNService.AddTunnel:input_type -> awg.vpn.v1.AddTunnelRequest
-	29, // 50: awg.vpn.v1.VPNService.RemoveTunnel:input_type -> awg.vpn.v1.RemoveTunnelRequest
-	31, // 51: awg.vpn.v1.VPNService.UpdateTunnel:input_type -> awg.vpn.v1.UpdateTunnelRequest
-	23, // 52: awg.vpn.v1.VPNService.Connect:input_type -> awg.vpn.v1.ConnectRequest
-	25, // 53: awg.vpn.v1.VPNService.Disconnect:input_type -> awg.vpn.v1.DisconnectRequest
-	23, // 54: awg.vpn.v1.VPNService.RestartTunnel:input_type -> awg.vpn.v1.ConnectRequest
-	35, // 55: awg.vpn.v1.VPNService.SaveTunnelOrder:input_type -> awg.vpn.v1.SaveTunnelOrderRequest
-	63, // 56: awg.vpn.v1.VPNService.RenameTunnel:input_type -> awg.vpn.v1.RenameTunnelRequest
-	88, // 57: awg.vpn.v1.VPNService.ListRules:input_type -> google.protobuf.Empty
-	38, // 58: awg.vpn.v1.VPNService.SaveRules:input_type -> awg.vpn.v1.SaveRulesRequest
-	88, // 59: awg.vpn.v1.VPNService.ListDomainRules:input_type -> google.protobuf.Empty
-	41, // 60: awg.vpn.v1.VPNService.SaveDomainRules:input_type -> awg.vpn.v1.SaveDomainRulesRequest
-	88, // 61: awg.vpn.v1.VPNService.ListGeositeCategories:input_type -> google.protobuf.Empty
-	88, // 62: awg.vpn.v1.VPNService.ListGeoIPCategories:input_type -> google.protobuf.Empty
-	88, // 63: awg.vpn.v1.VPNService.UpdateGeosite:input_type -> google.protobuf.Empty
-	88, // 64: awg.vpn.v1.VPNService.GetConfig:input_type -> google.protobuf.Empty
-	45, // 65: awg.vpn.v1.VPNService.SaveConfig:input_type -> awg.vpn.v1.SaveConfigRequest
-	88, // 66: awg.vpn.v1.VPNService.ExportConfig:input_type -> google.protobuf.Empty
-	48, // 67: awg.vpn.v1.VPNService.ImportConfig:input_type -> awg.vpn.v1.ImportConfigRequest
-	50, // 68: awg.vpn.v1.VPNService.StreamLogs:input_type -> awg.vpn.v1.LogStreamRequest
-	51, // 69: awg.vpn.v1.VPNService.StreamStats:input_type -> awg.vpn.v1.StatsStreamRequest
-	82, // 70: awg.vpn.v1.VPNService.StreamConnections:input_type -> awg.vpn.v1.ConnectionMonitorRequest
-	52, // 71: awg.vpn.v1.VPNService.ListProcesses:input_type -> awg.vpn.v1.ProcessListRequest
-	88, // 72: awg.vpn.v1.VPNService.GetAutostart:input_type -> google.protobuf.Empty
-	75, // 73: awg.vpn.v1.VPNService.SetAutostart:input_type -> awg.vpn.v1.SetAutostartRequest
-	88, // 74: awg.vpn.v1.VPNService.ListSubscriptions:input_type -> google.protobuf.Empty
-	55, // 75: awg.vpn.v1.VPNService.AddSubscription:input_type -> awg.vpn.v1.AddSubscriptionRequest
-	57, // 76: awg.vpn.v1.VPNService.RemoveSubscription:input_type -> awg.vpn.v1.RemoveSubscriptionRequest
-	59, // 77: awg.vpn.v1.VPNService.RefreshSubscription:input_type -> awg.vpn.v1.RefreshSubscriptionRequest
-	61, // 78: awg.vpn.v1.VPNService.UpdateSubscription:input_type -> awg.vpn.v1.UpdateSubscriptionRequest
-	88, // 79: awg.vpn.v1.VPNService.RestoreConnections:input_type -> google.protobuf.Empty
-	88, // 80: awg.vpn.v1.VPNService.FlushDNS:input_type -> google.protobuf.Empty
-	88, // 81: awg.vpn.v1.VPNService.CheckUpdate:input_type -> google.protobuf.Empty
-	88, // 82: awg.vpn.v1.VPNService.ApplyUpdate:input_type -> google.protobuf.Empty
-	88, // 83: awg.vpn.v1.VPNService.ApplyUpdateStream:input_type -> google.protobuf.Empty
-	88, // 84: awg.vpn.v1.VPNService.CheckConflictingServices:input_type -> google.protobuf.Empty
-	79, // 85: awg.vpn.v1.VPNService.StopConflictingServices:input_type -> awg.vpn.v1.StopConflictingServicesRequest
-	65, // 86: awg.vpn.v1.VPNService.GetStatus:output_type -> awg.vpn.v1.ServiceStatus
-	88, // 87: awg.vpn.v1.VPNService.Shutdown:output_type -> google.protobuf.Empty
-	67, // 88: awg.vpn.v1.VPNService.Activate:output_type -> awg.vpn.v1.ActivateResponse
-	69, // 89: awg.vpn.v1.VPNService.Deactivate:output_type -> awg.vpn.v1.DeactivateResponse
-	34, // 90: awg.vpn.v1.VPNService.ListTunnels:output_type -> awg.vpn.v1.TunnelListResponse
-	6,  // 91: awg.vpn.v1.VPNService.GetTunnel:output_type -> awg.vpn.v1.TunnelStatus
-	28, // 92: awg.vpn.v1.VPNService.AddTunnel:output_type -> awg.vpn.v1.AddTunnelResponse
-	30, // 93: awg.vpn.v1.VPNService.RemoveTunnel:output_type -> awg.vpn.v1.RemoveTunnelResponse
-	32, // 94: awg.vpn.v1.VPNService.UpdateTunnel:output_type -> awg.vpn.v1.UpdateTunnelResponse
-	24, // 95: awg.vpn.v1.VPNService.Connect:output_type -> awg.vpn.v1.ConnectResponse
-	26, // 96: awg.vpn.v1.VPNService.Disconnect:output_type -> awg.vpn.v1.DisconnectResponse
-	24, // 97: awg.vpn.v1.VPNService.RestartTunnel:output_type -> awg.vpn.v1.ConnectResponse
-	36, // 98: awg.vpn.v1.VPNService.SaveTunnelOrder:output_type -> awg.vpn.v1.SaveTunnelOrderResponse
-	64, // 99: awg.vpn.v1.VPNService.RenameTunnel:output_type -> awg.vpn.v1.RenameTunnelResponse
-	37, // 100: awg.vpn.v1.VPNService.ListRules:output_type -> awg.vpn.v1.RuleListResponse
-	39, // 101: awg.vpn.v1.VPNService.SaveRules:output_type -> awg.vpn.v1.SaveRulesResponse
-	40, // 102: awg.vpn.v1.VPNService.ListDomainRules:output_type -> awg.vpn.v1.DomainRuleListResponse
-	42, // 103: awg.vpn.v1.VPNService.SaveDomainRules:output_type -> awg.vpn.v1.SaveDomainRulesResponse
-	43, // 104: awg.vpn.v1.VPNService.ListGeositeCategories:output_type -> awg.vpn.v1.GeositeCategoriesResponse
-	43, // 105: awg.vpn.v1.VPNService.ListGeoIPCategories:output_type -> awg.vpn.v1.GeositeCategoriesResponse
-	44, // 106: awg.vpn.v1.VPNService.UpdateGeosite:output_type -> awg.vpn.v1.UpdateGeositeResponse
-	18, // 107: awg.vpn.v1.VPNService.GetConfig:output_type -> awg.vpn.v1.AppConfig
-	46, // 108: awg.vpn.v1.VPNService.SaveConfig:output_type -> awg.vpn.v1.SaveConfigResponse
-	47, // 109: awg.vpn.v1.VPNService.ExportConfig:output_type -> awg.vpn.v1.ExportConfigResponse
-	49, // 110: awg.vpn.v1.VPNService.ImportConfig:output_type -> awg.vpn.v1.ImportConfigResponse
-	21, // 111: awg.vpn.v1.VPNService.StreamLogs:output_type -> awg.vpn.v1.LogEntry
-	20, // 112: awg.vpn.v1.VPNService.StreamStats:output_type -> awg.vpn.v1.StatsSnapshot
-	83, // 113: awg.vpn.v1.VPNService.StreamConnections:output_type -> awg.vpn.v1.ConnectionSnapshot
-	53, // 114: awg.vpn.v1.VPNService.ListProcesses:output_type -> awg.vpn.v1.ProcessListResponse
-	74, // 115: awg.vpn.v1.VPNService.GetAutostart:output_type -> awg.vpn.v1.AutostartConfig
-	76, // 116: awg.vpn.v1.VPNService.SetAutostart:output_type -> awg.vpn.v1.SetAutostartResponse
-	54, // 117: awg.vpn.v1.VPNService.ListSubscriptions:output_type -> awg.vpn.v1.SubscriptionListResponse
-	56, // 118: awg.vpn.v1.VPNService.AddSubscription:output_type -> awg.vpn.v1.AddSubscriptionResponse
-	58, // 119: awg.vpn.v1.VPNService.RemoveSubscription:output_type -> awg.vpn.v1.RemoveSubscriptionResponse
-	60, // 120: awg.vpn.v1.VPNService.RefreshSubscription:output_type -> awg.vpn.v1.RefreshSubscriptionResponse
-	62, // 121: awg.vpn.v1.VPNService.UpdateSubscription:output_type -> awg.vpn.v1.UpdateSubscriptionResponse
-	24, // 122: awg.vpn.v1.VPNService.RestoreConnections:output_type -> awg.vpn.v1.ConnectResponse
-	24, // 123: awg.vpn.v1.VPNService.FlushDNS:output_type -> awg.vpn.v1.ConnectResponse
-	71, // 124: awg.vpn.v1.VPNService.CheckUpdate:output_type -> awg.vpn.v1.CheckUpdateResponse
-	72, // 125: awg.vpn.v1.VPNService.ApplyUpdate:output_type -> awg.vpn.v1.ApplyUpdateResponse
-	73, // 126: awg.vpn.v1.VPNService.ApplyUpdateStream:output_type -> awg.vpn.v1.UpdateProgress
-	78, // 127: awg.vpn.v1.VPNService.CheckConflictingServices:output_type -> awg.vpn.v1.ConflictingServicesResponse
-	80, // 128: awg.vpn.v1.VPNService.StopConflictingServices:output_type -> awg.vpn.v1.StopConflictingServicesResponse
-	86, // [86:129] is the sub-list for method output_type
-	43, // [43:86] is the sub-list for method input_type
-	43, // [43:43] is the sub-list for extension type_name
-	43, // [43:43] is the sub-list for extension extendee
-	0,  // [0:43] is the sub-list for field type_name
+	115, // 0: awg.vpn.v1.TunnelConfig.settings:type_name -> awg.vpn.v1.TunnelConfig.SettingsEntry
+	110, // 1: awg.vpn.v1.TunnelConfig.rate_limit:type_name -> awg.vpn.v1.RateLimit
+	6,   // 2: awg.vpn.v1.TunnelStatus.config:type_name -> awg.vpn.v1.TunnelConfig
+	0,   // 3: awg.vpn.v1.TunnelStatus.state:type_name -> awg.vpn.v1.TunnelState
+	4,   // 4: awg.vpn.v1.DomainRule.action:type_name -> awg.vpn.v1.DomainAction
+	1,   // 5: awg.vpn.v1.Rule.fallback:type_name -> awg.vpn.v1.FallbackPolicy
+	110, // 6: awg.vpn.v1.Rule.rate_limit:type_name -> awg.vpn.v1.RateLimit
+	10,  // 7: awg.vpn.v1.DNSConfig.cache:type_name -> awg.vpn.v1.DNSCacheConfig
+	11,  // 8: awg.vpn.v1.DNSConfig.fakeip:type_name -> awg.vpn.v1.FakeIPConfig
+	110, // 9: awg.vpn.v1.GlobalFilterConfig.rate_limit:type_name -> awg.vpn.v1.RateLimit
+	116, // 10: awg.vpn.v1.LogConfig.components:type_name -> awg.vpn.v1.LogConfig.ComponentsEntry
+	15,  // 11: awg.vpn.v1.SubscriptionStatus.config:type_name -> awg.vpn.v1.SubscriptionConfig
+	13,  // 12: awg.vpn.v1.AppConfig.global:type_name -> awg.vpn.v1.GlobalFilterConfig
+	6,   // 13: awg.vpn.v1.AppConfig.tunnels:type_name -> awg.vpn.v1.TunnelConfig
+	9,   // 14: awg.vpn.v1.AppConfig.rules:type_name -> awg.vpn.v1.Rule
+	12,  // 15: awg.vpn.v1.AppConfig.dns:type_name -> awg.vpn.v1.DNSConfig
+	14,  // 16: awg.vpn.v1.AppConfig.logging:type_name -> awg.vpn.v1.LogConfig
+	8,   // 17: awg.vpn.v1.AppConfig.domain_rules:type_name -> awg.vpn.v1.DomainRule
+	15,  // 18: awg.vpn.v1.AppConfig.subscriptions:type_name -> awg.vpn.v1.SubscriptionConfig
+	17,  // 19: awg.vpn.v1.AppConfig.reconnect:type_name -> awg.vpn.v1.ReconnectConfig
+	18,  // 20: awg.vpn.v1.AppConfig.auto_bypass:type_name -> awg.vpn.v1.AutoBypassConfig
+	0,   // 21: awg.vpn.v1.TunnelStats.state:type_name -> awg.vpn.v1.TunnelState
+	119, // 22: awg.vpn.v1.TunnelStats.last_handshake:type_name -> google.protobuf.Timestamp
+	20,  // 23: awg.vpn.v1.StatsSnapshot.tunnels:type_name -> awg.vpn.v1.TunnelStats
+	119, // 24: awg.vpn.v1.StatsSnapshot.timestamp:type_name -> google.protobuf.Timestamp
+	119, // 25: awg.vpn.v1.LogEntry.timestamp:type_name -> google.protobuf.Timestamp
+	2,   // 26: awg.vpn.v1.LogEntry.level:type_name -> awg.vpn.v1.LogLevel
+	117, // 27: awg.vpn.v1.ConnectRequest.auth_params:type_name -> awg.vpn.v1.ConnectRequest.AuthParamsEntry
+	6,   // 28: awg.vpn.v1.AddTunnelRequest.config:type_name -> awg.vpn.v1.TunnelConfig
+	6,   // 29: awg.vpn.v1.UpdateTunnelRequest.config:type_name -> awg.vpn.v1.TunnelConfig
+	7,   // 30: awg.vpn.v1.TunnelListResponse.tunnels:type_name -> awg.vpn.v1.TunnelStatus
+	9,   // 31: awg.vpn.v1.RuleListResponse.rules:type_name -> awg.vpn.v1.Rule
+	9,   // 32: awg.vpn.v1.SaveRulesRequest.rules:type_name -> awg.vpn.v1.Rule
+	118, // 33: awg.vpn.v1.ImportRulesRequest.tunnel_map:type_name -> awg.vpn.v1.ImportRulesRequest.TunnelMapEntry
+	9,   // 34: awg.vpn.v1.ImportRulesResponse.rules:type_name -> awg.vpn.v1.Rule
+	8,   // 35: awg.vpn.v1.ImportRulesResponse.domain_rules:type_name -> awg.vpn.v1.DomainRule
+	43,  // 36: awg.vpn.v1.ImportRulesResponse.skipped:type_name -> awg.vpn.v1.SkippedRule
+	43,  // 37: awg.vpn.v1.ImportRulesResponse.warnings:type_name -> awg.vpn.v1.SkippedRule
+	8,   // 38: awg.vpn.v1.DomainRuleListResponse.rules:type_name -> awg.vpn.v1.DomainRule
+	8,   // 39: awg.vpn.v1.SaveDomainRulesRequest.rules:type_name -> awg.vpn.v1.DomainRule
+	19,  // 40: awg.vpn.v1.SaveConfigRequest.config:type_name -> awg.vpn.v1.AppConfig
+	2,   // 41: awg.vpn.v1.LogStreamRequest.min_level:type_name -> awg.vpn.v1.LogLevel
+	23,  // 42: awg.vpn.v1.ProcessListResponse.processes:type_name -> awg.vpn.v1.ProcessInfo
+	16,  // 43: awg.vpn.v1.SubscriptionListResponse.subscriptions:type_name -> awg.vpn.v1.SubscriptionStatus
+	15,  // 44: awg.vpn.v1.AddSubscriptionRequest.config:type_name -> awg.vpn.v1.SubscriptionConfig
+	15,  // 45: awg.vpn.v1.UpdateSubscriptionRequest.config:type_name -> awg.vpn.v1.SubscriptionConfig
+	3,   // 46: awg.vpn.v1.ServiceStatus.daemon_state:type_name -> awg.vpn.v1.DaemonState
+	74,  // 47: awg.vpn.v1.CheckUpdateResponse.info:type_name -> awg.vpn.v1.UpdateInfo
+	78,  // 48: awg.vpn.v1.SetAutostartRequest.config:type_name -> awg.vpn.v1.AutostartConfig
+	81,  // 49: awg.vpn.v1.ConflictingServicesResponse.services:type_name -> awg.vpn.v1.ConflictingService
+	85,  // 50: awg.vpn.v1.ConnectionSnapshot.connections:type_name -> awg.vpn.v1.ConnectionEntry
+	119, // 51: awg.vpn.v1.DNSQueryEntry.timestamp:type_name -> google.protobuf.Timestamp
+	4,   // 52: awg.vpn.v1.DNSQueryEntry.action:type_name -> awg.vpn.v1.DomainAction
+	119, // 53: awg.vpn.v1.DNSDomainStats.last_seen:type_name -> google.protobuf.Timestamp
+	90,  // 54: awg.vpn.v1.DNSQueryStatsResponse.top_domains:type_name -> awg.vpn.v1.DNSDomainStats
+	90,  // 55: awg.vpn.v1.DNSQueryStatsResponse.blocked_domains:type_name -> awg.vpn.v1.DNSDomainStats
+	6,   // 56: awg.vpn.v1.ImportWireGuardResponse.config:type_name -> awg.vpn.v1.TunnelConfig
+	119, // 57: awg.vpn.v1.TunnelPeer.last_handshake:type_name -> google.protobuf.Timestamp
+	100, // 58: awg.vpn.v1.TunnelPeersResponse.peers:type_name -> awg.vpn.v1.TunnelPeer
+	119, // 59: awg.vpn.v1.WGServerPeer.last_handshake:type_name -> google.protobuf.Timestamp
+	102, // 60: awg.vpn.v1.WGServerStatusResponse.peers:type_name -> awg.vpn.v1.WGServerPeer
+	102, // 61: awg.vpn.v1.AddWGServerPeerResponse.peer:type_name -> awg.vpn.v1.WGServerPeer
+	5,   // 62: awg.vpn.v1.RateLimitEntry.scope:type_name -> awg.vpn.v1.RateLimitScope
+	110, // 63: awg.vpn.v1.RateLimitEntry.limit:type_name -> awg.vpn.v1.RateLimit
+	111, // 64: awg.vpn.v1.RateLimitsResponse.limits:type_name -> awg.vpn.v1.RateLimitEntry
+	5,   // 65: awg.vpn.v1.SetRateLimitRequest.scope:type_name -> awg.vpn.v1.RateLimitScope
+	110, // 66: awg.vpn.v1.SetRateLimitRequest.limit:type_name -> awg.vpn.v1.RateLimit
+	120, // 67: awg.vpn.v1.VPNService.GetStatus:input_type -> google.protobuf.Empty
+	120, // 68: awg.vpn.v1.VPNService.Shutdown:input_type -> google.protobuf.Empty
+	70,  // 69: awg.vpn.v1.VPNService.Activate:input_type -> awg.vpn.v1.ActivateRequest
+	72,  // 70: awg.vpn.v1.VPNService.Deactivate:input_type -> awg.vpn.v1.DeactivateRequest
+	120, // 71: awg.vpn.v1.VPNService.ListTunnels:input_type -> google.protobuf.Empty
+	34,  // 72: awg.vpn.v1.VPNService.GetTunnel:input_type -> awg.vpn.v1.GetTunnelRequest
+	28,  // 73: awg.vpn.v1.VPNService.AddTunnel:input_type -> awg.vpn.v1.AddTunnelRequest
+	30,  // 74: awg.vpn.v1.VPNService.RemoveTunnel:input_type -> awg.vpn.v1.RemoveTunnelRequest
+	32,  // 75: awg.vpn.v1.VPNService.UpdateTunnel:input_type -> awg.vpn.v1.UpdateTunnelRequest
+	24,  // 76: awg.vpn.v1.VPNService.Connect:input_type -> awg.vpn.v1.ConnectRequest
+	26,  // 77: awg.vpn.v1.VPNService.Disconnect:input_type -> awg.vpn.v1.DisconnectRequest
+	24,  // 78: awg.vpn.v1.VPNService.RestartTunnel:input_type -> awg.vpn.v1.ConnectRequest
+	36,  // 79: awg.vpn.v1.VPNService.SaveTunnelOrder:input_type -> awg.vpn.v1.SaveTunnelOrderRequest
+	67,  // 80: awg.vpn.v1.VPNService.RenameTunnel:input_type -> awg.vpn.v1.RenameTunnelRequest
+	93,  // 81: awg.vpn.v1.VPNService.GenerateWireGuardKeys:input_type -> awg.vpn.v1.WireGuardKeyRequest
+	95,  // 82: awg.vpn.v1.VPNService.ImportWireGuardConfig:input_type -> awg.vpn.v1.ImportWireGuardRequest
+	97,  // 83: awg.vpn.v1.VPNService.ExportWireGuardConfig:input_type -> awg.vpn.v1.ExportWireGuardRequest
+	99,  // 84: awg.vpn.v1.VPNService.GetTunnelPeers:input_type -> awg.vpn.v1.TunnelPeersRequest
+	120, // 85: awg.vpn.v1.VPNService.GetWGServerStatus:input_type -> google.protobuf.Empty
+	104, // 86: awg.vpn.v1.VPNService.AddWGServerPeer:input_type -> awg.vpn.v1.AddWGServerPeerRequest
+	106, // 87: awg.vpn.v1.VPNService.RemoveWGServerPeer:input_type -> awg.vpn.v1.RemoveWGServerPeerRequest
+	108, // 88: awg.vpn.v1.VPNService.GetWGServerClientConfig:input_type -> awg.vpn.v1.WGServerClientConfigRequest
+	120, // 89: awg.vpn.v1.VPNService.GetRateLimits:input_type -> google.protobuf.Empty
+	113, // 90: awg.vpn.v1.VPNService.SetRateLimit:input_type -> awg.vpn.v1.SetRateLimitRequest
+	120, // 91: awg.vpn.v1.VPNService.ListRules:input_type -> google.protobuf.Empty
+	39,  // 92: awg.vpn.v1.VPNService.SaveRules:input_type -> awg.vpn.v1.SaveRulesRequest
+	41,  // 93: awg.vpn.v1.VPNService.ImportRules:input_type -> awg.vpn.v1.ImportRulesRequest
+	120, // 94: awg.vpn.v1.VPNService.ListDomainRules:input_type -> google.protobuf.Empty
+	45,  // 95: awg.vpn.v1.VPNService.SaveDomainRules:input_type -> awg.vpn.v1.SaveDomainRulesRequest
+	120, // 96: awg.vpn.v1.VPNService.ListGeositeCategories:input_type -> google.protobuf.Empty
+	120, // 97: awg.vpn.v1.VPNService.ListGeoIPCategories:input_type -> google.protobuf.Empty
+	120, // 98: awg.vpn.v1.VPNService.UpdateGeosite:input_type -> google.protobuf.Empty
+	120, // 99: awg.vpn.v1.VPNService.GetConfig:input_type -> google.protobuf.Empty
+	49,  // 100: awg.vpn.v1.VPNService.SaveConfig:input_type -> awg.vpn.v1.SaveConfigRequest
+	120, // 101: awg.vpn.v1.VPNService.ExportConfig:input_type -> google.protobuf.Empty
+	52,  // 102: awg.vpn.v1.VPNService.ImportConfig:input_type -> awg.vpn.v1.ImportConfigRequest
+	54,  // 103: awg.vpn.v1.VPNService.StreamLogs:input_type -> awg.vpn.v1.LogStreamRequest
+	55,  // 104: awg.vpn.v1.VPNService.StreamStats:input_type -> awg.vpn.v1.StatsStreamRequest
+	86,  // 105: awg.vpn.v1.VPNService.StreamConnections:input_type -> awg.vpn.v1.ConnectionMonitorRequest
+	56,  // 106: awg.vpn.v1.VPNService.ListProcesses:input_type -> awg.vpn.v1.ProcessListRequest
+	120, // 107: awg.vpn.v1.VPNService.GetAutostart:input_type -> google.protobuf.Empty
+	79,  // 108: awg.vpn.v1.VPNService.SetAutostart:input_type -> awg.vpn.v1.SetAutostartRequest
+	120, // 109: awg.vpn.v1.VPNService.ListSubscriptions:input_type -> google.protobuf.Empty
+	59,  // 110: awg.vpn.v1.VPNService.AddSubscription:input_type -> awg.vpn.v1.AddSubscriptionRequest
+	61,  // 111: awg.vpn.v1.VPNService.RemoveSubscription:input_type -> awg.vpn.v1.RemoveSubscriptionRequest
+	63,  // 112: awg.vpn.v1.VPNService.RefreshSubscription:input_type -> awg.vpn.v1.RefreshSubscriptionRequest
+	65,  // 113: awg.vpn.v1.VPNService.UpdateSubscription:input_type -> awg.vpn.v1.UpdateSubscriptionRequest
+	120, // 114: awg.vpn.v1.VPNService.RestoreConnections:input_type -> google.protobuf.Empty
+	120, // 115: awg.vpn.v1.VPNService.FlushDNS:input_type -> google.protobuf.Empty
+	88,  // 116: awg.vpn.v1.VPNService.StreamDNSQueries:input_type -> awg.vpn.v1.DNSQueryStreamRequest
+	91,  // 117: awg.vpn.v1.VPNService.GetDNSQueryStats:input_type -> awg.vpn.v1.DNSQueryStatsRequest
+	120, // 118: awg.vpn.v1.VPNService.CheckUpdate:input_type -> google.protobuf.Empty
+	120, // 119: awg.vpn.v1.VPNService.ApplyUpdate:input_type -> google.protobuf.Empty
+	120, // 120: awg.vpn.v1.VPNService.ApplyUpdateStream:input_type -> google.protobuf.Empty
+	120, // 121: awg.vpn.v1.VPNService.CheckConflictingServices:input_type -> google.protobuf.Empty
+	83,  // 122: awg.vpn.v1.VPNService.StopConflictingServices:input_type -> awg.vpn.v1.StopConflictingServicesRequest
+	69,  // 123: awg.vpn.v1.VPNService.GetStatus:output_type -> awg.vpn.v1.ServiceStatus
+	120, // 124: awg.vpn.v1.VPNService.Shutdown:output_type -> google.protobuf.Empty
+	71,  // 125: awg.vpn.v1.VPNService.Activate:output_type -> awg.vpn.v1.ActivateResponse
+	73,  // 126: awg.vpn.v1.VPNService.Deactivate:output_type -> awg.vpn.v1.DeactivateResponse
+	35,  // 127: awg.vpn.v1.VPNService.ListTunnels:output_type -> awg.vpn.v1.TunnelListResponse
+	7,   // 128: awg.vpn.v1.VPNService.GetTunnel:output_type -> awg.vpn.v1.TunnelStatus
+	29,  // 129: awg.vpn.v1.VPNService.AddTunnel:output_type -> awg.vpn.v1.AddTunnelResponse
+	31,  // 130: awg.vpn.v1.VPNService.RemoveTunnel:output_type -> awg.vpn.v1.RemoveTunnelResponse
+	33,  // 131: awg.vpn.v1.VPNService.UpdateTunnel:output_type -> awg.vpn.v1.UpdateTunnelResponse
+	25,  // 132: awg.vpn.v1.VPNService.Connect:output_type -> awg.vpn.v1.ConnectResponse
+	27,  // 133: awg.vpn.v1.VPNService.Disconnect:output_type -> awg.vpn.v1.DisconnectResponse
+	25,  // 134: awg.vpn.v1.VPNService.RestartTunnel:output_type -> awg.vpn.v1.ConnectResponse
+	37,  // 135: awg.vpn.v1.VPNService.SaveTunnelOrder:output_type -> awg.vpn.v1.SaveTunnelOrderResponse
+	68,  // 136: awg.vpn.v1.VPNService.RenameTunnel:output_type -> awg.vpn.v1.RenameTunnelResponse
+	94,  // 137: awg.vpn.v1.VPNService.GenerateWireGuardKeys:output_type -> awg.vpn.v1.WireGuardKeyResponse
+	96,  // 138: awg.vpn.v1.VPNService.ImportWireGuardConfig:output_type -> awg.vpn.v1.ImportWireGuardResponse
+	98,  // 139: awg.vpn.v1.VPNService.ExportWireGuardConfig:output_type -> awg.vpn.v1.ExportWireGuardResponse
+	101, // 140: awg.vpn.v1.VPNService.GetTunnelPeers:output_type -> awg.vpn.v1.TunnelPeersResponse
+	103, // 141: awg.vpn.v1.VPNService.GetWGServerStatus:output_type -> awg.vpn.v1.WGServerStatusResponse
+	105, // 142: awg.vpn.v1.VPNService.AddWGServerPeer:output_type -> awg.vpn.v1.AddWGServerPeerResponse
+	107, // 143: awg.vpn.v1.VPNService.RemoveWGServerPeer:output_type -> awg.vpn.v1.RemoveWGServerPeerResponse
+	109, // 144: awg.vpn.v1.VPNService.GetWGServerClientConfig:output_type -> awg.vpn.v1.WGServerClientConfigResponse
+	112, // 145: awg.vpn.v1.VPNService.GetRateLimits:output_type -> awg.vpn.v1.RateLimitsResponse
+	114, // 146: awg.vpn.v1.VPNService.SetRateLimit:output_type -> awg.vpn.v1.SetRateLimitResponse
+	38,  // 147: awg.vpn.v1.VPNService.ListRules:output_type -> awg.vpn.v1.RuleListResponse
+	40,  // 148: awg.vpn.v1.VPNService.SaveRules:output_type -> awg.vpn.v1.SaveRulesResponse
+	42,  // 149: awg.vpn.v1.VPNService.ImportRules:output_type -> awg.vpn.v1.ImportRulesResponse
+	44,  // 150: awg.vpn.v1.VPNService.ListDomainRules:output_type -> awg.vpn.v1.DomainRuleListResponse
+	46,  // 151: awg.vpn.v1.VPNService.SaveDomainRules:output_type -> awg.vpn.v1.SaveDomainRulesResponse
+	47,  // 152: awg.vpn.v1.VPNService.ListGeositeCategories:output_type -> awg.vpn.v1.GeositeCategoriesResponse
+	47,  // 153: awg.vpn.v1.VPNService.ListGeoIPCategories:output_type -> awg.vpn.v1.GeositeCategoriesResponse
+	48,  // 154: awg.vpn.v1.VPNService.UpdateGeosite:output_type -> awg.vpn.v1.UpdateGeositeResponse
+	19,  // 155: awg.vpn.v1.VPNService.GetConfig:output_type -> awg.vpn.v1.AppConfig
+	50,  // 156: awg.vpn.v1.VPNService.SaveConfig:output_type -> awg.vpn.v1.SaveConfigResponse
+	51,  // 157: awg.vpn.v1.VPNService.ExportConfig:output_type -> awg.vpn.v1.ExportConfigResponse
+	53,  // 158: awg.vpn.v1.VPNService.ImportConfig:output_type -> awg.vpn.v1.ImportConfigResponse
+	22,  // 159: awg.vpn.v1.VPNService.StreamLogs:output_type -> awg.vpn.v1.LogEntry
+	21,  // 160: awg.vpn.v1.VPNService.StreamStats:output_type -> awg.vpn.v1.StatsSnapshot
+	87,  // 161: awg.vpn.v1.VPNService.StreamConnections:output_type -> awg.vpn.v1.ConnectionSnapshot
+	57,  // 162: awg.vpn.v1.VPNService.ListProcesses:output_type -> awg.vpn.v1.ProcessListResponse
+	78,  // 163: awg.vpn.v1.VPNService.GetAutostart:output_type -> awg.vpn.v1.AutostartConfig
+	80,  // 164: awg.vpn.v1.VPNService.SetAutostart:output_type -> awg.vpn.v1.SetAutostartResponse
+	58,  // 165: awg.vpn.v1.VPNService.ListSubscriptions:output_type -> awg.vpn.v1.SubscriptionListResponse
+	60,  // 166: awg.vpn.v1.VPNService.AddSubscription:output_type -> awg.vpn.v1.AddSubscriptionResponse
+	62,  // 167: awg.vpn.v1.VPNService.RemoveSubscription:output_type -> awg.vpn.v1.RemoveSubscriptionResponse
+	64,  // 168: awg.vpn.v1.VPNService.RefreshSubscription:output_type -> awg.vpn.v1.RefreshSubscriptionResponse
+	66,  // 169: awg.vpn.v1.VPNService.UpdateSubscription:output_type -> awg.vpn.v1.UpdateSubscriptionResponse
+	25,  // 170: awg.vpn.v1.VPNService.RestoreConnections:output_type -> awg.vpn.v1.ConnectResponse
+	25,  // 171: awg.vpn.v1.VPNService.FlushDNS:output_type -> awg.vpn.v1.ConnectResponse
+	89,  // 172: awg.vpn.v1.VPNService.StreamDNSQueries:output_type -> awg.vpn.v1.DNSQueryEntry
+	92,  // 173: awg.vpn.v1.VPNService.GetDNSQueryStats:output_type -> awg.vpn.v1.DNSQueryStatsResponse
+	75,  // 174: awg.vpn.v1.VPNService.CheckUpdate:output_type -> awg.vpn.v1.CheckUpdateResponse
+	76,  // 175: awg.vpn.v1.VPNService.ApplyUpdate:output_type -> awg.vpn.v1.ApplyUpdateResponse
+	77,  // 176: awg.vpn.v1.VPNService.ApplyUpdateStream:output_type -> awg.vpn.v1.UpdateProgress
+	82,  // 177: awg.vpn.v1.VPNService.CheckConflictingServices:output_type -> awg.vpn.v1.ConflictingServicesResponse
+	84,  // 178: awg.vpn.v1.VPNService.StopConflictingServices:output_type -> awg.vpn.v1.StopConflictingServicesResponse
+	123, // [123:179] is the sub-list for method output_type
+	67,  // [67:123] is the sub-list for method input_type
+	67,  // [67:67] is the sub-list for extension type_name
+	67,  // [67:67] is the sub-list for extension extendee
+	0,   // [0:67] is the sub-list for field type_name
 }
 
 func init() { file_vpn_service_proto_init() }
@@ -5478,8 +7918,8 @@ func file_vpn_service_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_vpn_service_proto_rawDesc), len(file_vpn_service_proto_rawDesc)),
-			NumEnums:      5,
-			NumMessages:   82,
+			NumEnums:      6,
+			NumMessages:   113,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -34,8 +34,19 @@ const (
 	VPNService_RestartTunnel_FullMethodName            = "/awg.vpn.v1.VPNService/RestartTunnel"
 	VPNService_SaveTunnelOrder_FullMethodName          = "/awg.vpn.v1.VPNService/SaveTunnelOrder"
 	VPNService_RenameTunnel_FullMethodName             = "/awg.vpn.v1.VPNService/RenameTunnel"
+	VPNService_GenerateWireGuardKeys_FullMethodName    = "/awg.vpn.v1.VPNService/GenerateWireGuardKeys"
+	VPNService_ImportWireGuardConfig_FullMethodName    = "/awg.vpn.v1.VPNService/ImportWireGuardConfig"
+	VPNService_ExportWireGuardConfig_FullMethodName    = "/awg.vpn.v1.VPNService/ExportWireGuardConfig"
+	VPNService_GetTunnelPeers_FullMethodName           = "/awg.vpn.v1.VPNService/GetTunnelPeers"
+	VPNService_GetWGServerStatus_FullMethodName        = "/awg.vpn.v1.VPNService/GetWGServerStatus"
+	VPNService_AddWGServerPeer_FullMethodName          = "/awg.vpn.v1.VPNService/AddWGServerPeer"
+	VPNService_RemoveWGServerPeer_FullMethodName       = "/awg.vpn.v1.VPNService/RemoveWGServerPeer"
+	VPNService_GetWGServerClientConfig_FullMethodName  = "/awg.vpn.v1.VPNService/GetWGServerClientConfig"
+	VPNService_GetRateLimits_FullMethodName            = "/awg.vpn.v1.VPNService/GetRateLimits"
+	VPNService_SetRateLimit_FullMethodName             = "/awg.vpn.v1.VPNService/SetRateLimit"
 	VPNService_ListRules_FullMethodName                = "/awg.vpn.v1.VPNService/ListRules"
 	VPNService_SaveRules_FullMethodName                = "/awg.vpn.v1.VPNService/SaveRules"
+	VPNService_ImportRules_FullMethodName              = "/awg.vpn.v1.VPNService/ImportRules"
 	VPNService_ListDomainRules_FullMethodName          = "/awg.vpn.v1.VPNService/ListDomainRules"
 	VPNService_SaveDomainRules_FullMethodName          = "/awg.vpn.v1.VPNService/SaveDomainRules"
 	VPNService_ListGeositeCategories_FullMethodName    = "/awg.vpn.v1.VPNService/ListGeositeCategories"
@@ -58,6 +69,8 @@ const (
 	VPNService_UpdateSubscription_FullMethodName       = "/awg.vpn.v1.VPNService/UpdateSubscription"
 	VPNService_RestoreConnections_FullMethodName       = "/awg.vpn.v1.VPNService/RestoreConnections"
 	VPNService_FlushDNS_FullMethodName                 = "/awg.vpn.v1.VPNService/FlushDNS"
+	VPNService_StreamDNSQueries_FullMethodName         = "/awg.vpn.v1.VPNService/StreamDNSQueries"
+	VPNService_GetDNSQueryStats_FullMethodName         = "/awg.vpn.v1.VPNService/GetDNSQueryStats"
 	VPNService_CheckUpdate_FullMethodName              = "/awg.vpn.v1.VPNService/CheckUpdate"
 	VPNService_ApplyUpdate_FullMethodName              = "/awg.vpn.v1.VPNService/ApplyUpdate"
 	VPNService_ApplyUpdateStream_FullMethodName        = "/awg.vpn.v1.VPNService/ApplyUpdateStream"
@@ -86,9 +99,23 @@ type VPNServiceClient interface {
 	RestartTunnel(ctx context.Context, in *ConnectRequest, opts ...grpc.CallOption) (*ConnectResponse, error)
 	SaveTunnelOrder(ctx context.Context, in *SaveTunnelOrderRequest, opts ...grpc.CallOption) (*SaveTunnelOrderResponse, error)
 	RenameTunnel(ctx context.Context, in *RenameTunnelRequest, opts ...grpc.CallOption) (*RenameTunnelResponse, error)
+	// -- WireGuard configs and keys --
+	GenerateWireGuardKeys(ctx context.Context, in *WireGuardKeyRequest, opts ...grpc.CallOption) (*WireGuardKeyResponse, error)
+	ImportWireGuardConfig(ctx context.Context, in *ImportWireGuardRequest, opts ...grpc.CallOption) (*ImportWireGuardResponse, error)
+	ExportWireGuardConfig(ctx context.Context, in *ExportWireGuardRequest, opts ...grpc.CallOption) (*ExportWireGuardResponse, error)
+	GetTunnelPeers(ctx context.Context, in *TunnelPeersRequest, opts ...grpc.CallOption) (*TunnelPeersResponse, error)
+	// -- WireGuard server --
+	GetWGServerStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*WGServerStatusResponse, error)
+	AddWGServerPeer(ctx context.Context, in *AddWGServerPeerRequest, opts ...grpc.CallOption) (*AddWGServerPeerResponse, error)
+	RemoveWGServerPeer(ctx context.Context, in *RemoveWGServerPeerRequest, opts ...grpc.CallOption) (*RemoveWGServerPeerResponse, error)
+	GetWGServerClientConfig(ctx context.Context, in *WGServerClientConfigRequest, opts ...grpc.CallOption) (*WGServerClientConfigResponse, error)
+	// -- Bandwidth shaping --
+	GetRateLimits(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RateLimitsResponse, error)
+	SetRateLimit(ctx context.Context, in *SetRateLimitRequest, opts ...grpc.CallOption) (*SetRateLimitResponse, error)
 	// -- Rules --
 	ListRules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RuleListResponse, error)
 	SaveRules(ctx context.Context, in *SaveRulesRequest, opts ...grpc.CallOption) (*SaveRulesResponse, error)
+	ImportRules(ctx context.Context, in *ImportRulesRequest, opts ...grpc.CallOption) (*ImportRulesResponse, error)
 	// -- Domain rules --
 	ListDomainRules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DomainRuleListResponse, error)
 	SaveDomainRules(ctx context.Context, in *SaveDomainRulesRequest, opts ...grpc.CallOption) (*SaveDomainRulesResponse, error)
@@ -119,6 +146,8 @@ type VPNServiceClient interface {
 	RestoreConnections(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ConnectResponse, error)
 	// -- DNS --
 	FlushDNS(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ConnectResponse, error)
+	StreamDNSQueries(ctx context.Context, in *DNSQueryStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DNSQueryEntry], error)
+	GetDNSQueryStats(ctx context.Context, in *DNSQueryStatsRequest, opts ...grpc.CallOption) (*DNSQueryStatsResponse, error)
 	// -- Updates --
 	CheckUpdate(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CheckUpdateResponse, error)
 	ApplyUpdate(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*ApplyUpdateResponse, error)
@@ -276,6 +305,106 @@ func (c *vPNServiceClient) RenameTunnel(ctx context.Context, in *RenameTunnelReq
 	return out, nil
 }
 
+func (c *vPNServiceClient) GenerateWireGuardKeys(ctx context.Context, in *WireGuardKeyRequest, opts ...grpc.CallOption) (*WireGuardKeyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WireGuardKeyResponse)
+	err := c.cc.Invoke(ctx, VPNService_GenerateWireGuardKeys_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) ImportWireGuardConfig(ctx context.Context, in *ImportWireGuardRequest, opts ...grpc.CallOption) (*ImportWireGuardResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportWireGuardResponse)
+	err := c.cc.Invoke(ctx, VPNService_ImportWireGuardConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) ExportWireGuardConfig(ctx context.Context, in *ExportWireGuardRequest, opts ...grpc.CallOption) (*ExportWireGuardResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportWireGuardResponse)
+	err := c.cc.Invoke(ctx, VPNService_ExportWireGuardConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) GetTunnelPeers(ctx context.Context, in *TunnelPeersRequest, opts ...grpc.CallOption) (*TunnelPeersResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TunnelPeersResponse)
+	err := c.cc.Invoke(ctx, VPNService_GetTunnelPeers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) GetWGServerStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*WGServerStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WGServerStatusResponse)
+	err := c.cc.Invoke(ctx, VPNService_GetWGServerStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) AddWGServerPeer(ctx context.Context, in *AddWGServerPeerRequest, opts ...grpc.CallOption) (*AddWGServerPeerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddWGServerPeerResponse)
+	err := c.cc.Invoke(ctx, VPNService_AddWGServerPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) RemoveWGServerPeer(ctx context.Context, in *RemoveWGServerPeerRequest, opts ...grpc.CallOption) (*RemoveWGServerPeerResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveWGServerPeerResponse)
+	err := c.cc.Invoke(ctx, VPNService_RemoveWGServerPeer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) GetWGServerClientConfig(ctx context.Context, in *WGServerClientConfigRequest, opts ...grpc.CallOption) (*WGServerClientConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WGServerClientConfigResponse)
+	err := c.cc.Invoke(ctx, VPNService_GetWGServerClientConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) GetRateLimits(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RateLimitsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RateLimitsResponse)
+	err := c.cc.Invoke(ctx, VPNService_GetRateLimits_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *vPNServiceClient) SetRateLimit(ctx context.Context, in *SetRateLimitRequest, opts ...grpc.CallOption) (*SetRateLimitResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRateLimitResponse)
+	err := c.cc.Invoke(ctx, VPNService_SetRateLimit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *vPNServiceClient) ListRules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*RuleListResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(RuleListResponse)
@@ -296,6 +425,16 @@ func (c *vPNServiceClient) SaveRules(ctx context.Context, in *SaveRulesRequest,
 	return out, nil
 }
 
+func (c *vPNServiceClient) ImportRules(ctx context.Context, in *ImportRulesRequest, opts ...grpc.CallOption) (*ImportRulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ImportRulesResponse)
+	err := c.cc.Invoke(ctx, VPNService_ImportRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *vPNServiceClient) ListDomainRules(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*DomainRuleListResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(DomainRuleListResponse)
@@ -543,6 +682,35 @@ func (c *vPNServiceClient) FlushDNS(ctx context.Context, in *emptypb.Empty, opts
 	return out, nil
 }
 
+func (c *vPNServiceClient) StreamDNSQueries(ctx context.Context, in *DNSQueryStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DNSQueryEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VPNService_ServiceDesc.Streams[3], VPNService_StreamDNSQueries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[DNSQueryStreamRequest, DNSQueryEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VPNService_StreamDNSQueriesClient = grpc.ServerStreamingClient[DNSQueryEntry]
+
+func (c *vPNServiceClient) GetDNSQueryStats(ctx context.Context, in *DNSQueryStatsRequest, opts ...grpc.CallOption) (*DNSQueryStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DNSQueryStatsResponse)
+	err := c.cc.Invoke(ctx, VPNService_GetDNSQueryStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *vPNServiceClient) CheckUpdate(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*CheckUpdateResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CheckUpdateResponse)
@@ -565,7 +733,7 @@ func (c *vPNServiceClient) ApplyUpdate(ctx context.Context, in *emptypb.Empty, o
 
 func (c *vPNServiceClient) ApplyUpdateStream(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (grpc.ServerStreamingClient[UpdateProgress], error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
-	stream, err := c.cc.NewStream(ctx, &VPNService_ServiceDesc.Streams[3], VPNService_ApplyUpdateStream_FullMethodName, cOpts...)
+	stream, err := c.cc.NewStream(ctx, &VPNService_ServiceDesc.Streams[4], VPNService_ApplyUpdateStream_FullMethodName, cOpts...)
 	if err != nil {
 		return nil, err
 	}
@@ -623,9 +791,23 @@ type VPNServiceServer interface {
 	RestartTunnel(context.Context, *ConnectRequest) (*ConnectResponse, error)
 	SaveTunnelOrder(context.Context, *SaveTunnelOrderRequest) (*SaveTunnelOrderResponse, error)
 	RenameTunnel(context.Context, *RenameTunnelRequest) (*RenameTunnelResponse, error)
+	// -- WireGuard configs and keys --
+	GenerateWireGuardKeys(context.Context, *WireGuardKeyRequest) (*WireGuardKeyResponse, error)
+	ImportWireGuardConfig(context.Context, *ImportWireGuardRequest) (*ImportWireGuardResponse, error)
+	ExportWireGuardConfig(context.Context, *ExportWireGuardRequest) (*ExportWireGuardResponse, error)
+	GetTunnelPeers(context.Context, *TunnelPeersRequest) (*TunnelPeersResponse, error)
+	// -- WireGuard server --
+	GetWGServerStatus(context.Context, *emptypb.Empty) (*WGServerStatusResponse, error)
+	AddWGServerPeer(context.Context, *AddWGServerPeerRequest) (*AddWGServerPeerResponse, error)
+	RemoveWGServerPeer(context.Context, *RemoveWGServerPeerRequest) (*RemoveWGServerPeerResponse, error)
+	GetWGServerClientConfig(context.Context, *WGServerClientConfigRequest) (*WGServerClientConfigResponse, error)
+	// -- Bandwidth shaping --
+	GetRateLimits(context.Context, *emptypb.Empty) (*RateLimitsResponse, error)
+	SetRateLimit(context.Context, *SetRateLimitRequest) (*SetRateLimitResponse, error)
 	// -- Rules --
 	ListRules(context.Context, *emptypb.Empty) (*RuleListResponse, error)
 	SaveRules(context.Context, *SaveRulesRequest) (*SaveRulesResponse, error)
+	ImportRules(context.Context, *ImportRulesRequest) (*ImportRulesResponse, error)
 	// -- Domain rules --
 	ListDomainRules(context.Context, *emptypb.Empty) (*DomainRuleListResponse, error)
 	SaveDomainRules(context.Context, *SaveDomainRulesRequest) (*SaveDomainRulesResponse, error)
@@ -656,6 +838,8 @@ type VPNServiceServer interface {
 	RestoreConnections(context.Context, *emptypb.Empty) (*ConnectResponse, error)
 	// -- DNS --
 	FlushDNS(context.Context, *emptypb.Empty) (*ConnectResponse, error)
+	StreamDNSQueries(*DNSQueryStreamRequest, grpc.ServerStreamingServer[DNSQueryEntry]) error
+	GetDNSQueryStats(context.Context, *DNSQueryStatsRequest) (*DNSQueryStatsResponse, error)
 	// -- Updates --
 	CheckUpdate(context.Context, *emptypb.Empty) (*CheckUpdateResponse, error)
 	ApplyUpdate(context.Context, *emptypb.Empty) (*ApplyUpdateResponse, error)
@@ -715,12 +899,45 @@ func (UnimplementedVPNServiceServer) SaveTunnelOrder(context.Context, *SaveTunne
 func (UnimplementedVPNServiceServer) RenameTunnel(context.Context, *RenameTunnelRequest) (*RenameTunnelResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method RenameTunnel not implemented")
 }
+func (UnimplementedVPNServiceServer) GenerateWireGuardKeys(context.Context, *WireGuardKeyRequest) (*WireGuardKeyResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GenerateWireGuardKeys not implemented")
+}
+func (UnimplementedVPNServiceServer) ImportWireGuardConfig(context.Context, *ImportWireGuardRequest) (*ImportWireGuardResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ImportWireGuardConfig not implemented")
+}
+func (UnimplementedVPNServiceServer) ExportWireGuardConfig(context.Context, *ExportWireGuardRequest) (*ExportWireGuardResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ExportWireGuardConfig not implemented")
+}
+func (UnimplementedVPNServiceServer) GetTunnelPeers(context.Context, *TunnelPeersRequest) (*TunnelPeersResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetTunnelPeers not implemented")
+}
+func (UnimplementedVPNServiceServer) GetWGServerStatus(context.Context, *emptypb.Empty) (*WGServerStatusResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetWGServerStatus not implemented")
+}
+func (UnimplementedVPNServiceServer) AddWGServerPeer(context.Context, *AddWGServerPeerRequest) (*AddWGServerPeerResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddWGServerPeer not implemented")
+}
+func (UnimplementedVPNServiceServer) RemoveWGServerPeer(context.Context, *RemoveWGServerPeerRequest) (*RemoveWGServerPeerResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveWGServerPeer not implemented")
+}
+func (UnimplementedVPNServiceServer) GetWGServerClientConfig(context.Context, *WGServerClientConfigRequest) (*WGServerClientConfigResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetWGServerClientConfig not implemented")
+}
+func (UnimplementedVPNServiceServer) GetRateLimits(context.Context, *emptypb.Empty) (*RateLimitsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetRateLimits not implemented")
+}
+func (UnimplementedVPNServiceServer) SetRateLimit(context.Context, *SetRateLimitRequest) (*SetRateLimitResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetRateLimit not implemented")
+}
 func (UnimplementedVPNServiceServer) ListRules(context.Context, *emptypb.Empty) (*RuleListResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListRules not implemented")
 }
 func (UnimplementedVPNServiceServer) SaveRules(context.Context, *SaveRulesRequest) (*SaveRulesResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method SaveRules not implemented")
 }
+func (UnimplementedVPNServiceServer) ImportRules(context.Context, *ImportRulesRequest) (*ImportRulesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ImportRules not implemented")
+}
 func (UnimplementedVPNServiceServer) ListDomainRules(context.Context, *emptypb.Empty) (*DomainRuleListResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListDomainRules not implemented")
 }
@@ -787,6 +1004,12 @@ func (UnimplementedVPNServiceServer) RestoreConnections(context.Context, *emptyp
 func (UnimplementedVPNServiceServer) FlushDNS(context.Context, *emptypb.Empty) (*ConnectResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method FlushDNS not implemented")
 }
+func (UnimplementedVPNServiceServer) StreamDNSQueries(*DNSQueryStreamRequest, grpc.ServerStreamingServer[DNSQueryEntry]) error {
+	return status.Error(codes.Unimplemented, "method StreamDNSQueries not implemented")
+}
+func (UnimplementedVPNServiceServer) GetDNSQueryStats(context.Context, *DNSQueryStatsRequest) (*DNSQueryStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDNSQueryStats not implemented")
+}
 func (UnimplementedVPNServiceServer) CheckUpdate(context.Context, *emptypb.Empty) (*CheckUpdateResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method CheckUpdate not implemented")
 }
@@ -1075,6 +1298,186 @@ func _VPNService_RenameTunnel_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VPNService_GenerateWireGuardKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WireGuardKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).GenerateWireGuardKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_GenerateWireGuardKeys_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).GenerateWireGuardKeys(ctx, req.(*WireGuardKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_ImportWireGuardConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportWireGuardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).ImportWireGuardConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_ImportWireGuardConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).ImportWireGuardConfig(ctx, req.(*ImportWireGuardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_ExportWireGuardConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportWireGuardRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).ExportWireGuardConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_ExportWireGuardConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).ExportWireGuardConfig(ctx, req.(*ExportWireGuardRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_GetTunnelPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TunnelPeersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).GetTunnelPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_GetTunnelPeers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).GetTunnelPeers(ctx, req.(*TunnelPeersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_GetWGServerStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).GetWGServerStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_GetWGServerStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).GetWGServerStatus(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_AddWGServerPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddWGServerPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).AddWGServerPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_AddWGServerPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).AddWGServerPeer(ctx, req.(*AddWGServerPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_RemoveWGServerPeer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveWGServerPeerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).RemoveWGServerPeer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_RemoveWGServerPeer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).RemoveWGServerPeer(ctx, req.(*RemoveWGServerPeerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_GetWGServerClientConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WGServerClientConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).GetWGServerClientConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_GetWGServerClientConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).GetWGServerClientConfig(ctx, req.(*WGServerClientConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_GetRateLimits_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).GetRateLimits(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_GetRateLimits_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).GetRateLimits(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VPNService_SetRateLimit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRateLimitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).SetRateLimit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_SetRateLimit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).SetRateLimit(ctx, req.(*SetRateLimitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VPNService_ListRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -1111,6 +1514,24 @@ func _VPNService_SaveRules_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VPNService_ImportRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ImportRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).ImportRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_ImportRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).ImportRules(ctx, req.(*ImportRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VPNService_ListDomainRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -1486,6 +1907,35 @@ func _VPNService_FlushDNS_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VPNService_StreamDNSQueries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(DNSQueryStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VPNServiceServer).StreamDNSQueries(m, &grpc.GenericServerStream[DNSQueryStreamRequest, DNSQueryEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VPNService_StreamDNSQueriesServer = grpc.ServerStreamingServer[DNSQueryEntry]
+
+func _VPNService_GetDNSQueryStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DNSQueryStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VPNServiceServer).GetDNSQueryStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VPNService_GetDNSQueryStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VPNServiceServer).GetDNSQueryStats(ctx, req.(*DNSQueryStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VPNService_CheckUpdate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(emptypb.Empty)
 	if err := dec(in); err != nil {
@@ -1632,6 +2082,46 @@ var VPNService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "RenameTunnel",
 			Handler:    _VPNService_RenameTunnel_Handler,
 		},
+		{
+			MethodName: "GenerateWireGuardKeys",
+			Handler:    _VPNService_GenerateWireGuardKeys_Handler,
+		},
+		{
+			MethodName: "ImportWireGuardConfig",
+			Handler:    _VPNService_ImportWireGuardConfig_Handler,
+		},
+		{
+			MethodName: "ExportWireGuardConfig",
+			Handler:    _VPNService_ExportWireGuardConfig_Handler,
+		},
+		{
+			MethodName: "GetTunnelPeers",
+			Handler:    _VPNService_GetTunnelPeers_Handler,
+		},
+		{
+			MethodName: "GetWGServerStatus",
+			Handler:    _VPNService_GetWGServerStatus_Handler,
+		},
+		{
+			MethodName: "AddWGServerPeer",
+			Handler:    _VPNService_AddWGServerPeer_Handler,
+		},
+		{
+			MethodName: "RemoveWGServerPeer",
+			Handler:    _VPNService_RemoveWGServerPeer_Handler,
+		},
+		{
+			MethodName: "GetWGServerClientConfig",
+			Handler:    _VPNService_GetWGServerClientConfig_Handler,
+		},
+		{
+			MethodName: "GetRateLimits",
+			Handler:    _VPNService_GetRateLimits_Handler,
+		},
+		{
+			MethodName: "SetRateLimit",
+			Handler:    _VPNService_SetRateLimit_Handler,
+		},
 		{
 			MethodName: "ListRules",
 			Handler:    _VPNService_ListRules_Handler,
@@ -1640,6 +2130,10 @@ var VPNService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "SaveRules",
 			Handler:    _VPNService_SaveRules_Handler,
 		},
+		{
+			MethodName: "ImportRules",
+			Handler:    _VPNService_ImportRules_Handler,
+		},
 		{
 			MethodName: "ListDomainRules",
 			Handler:    _VPNService_ListDomainRules_Handler,
@@ -1716,6 +2210,10 @@ var VPNService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "FlushDNS",
 			Handler:    _VPNService_FlushDNS_Handler,
 		},
+		{
+			MethodName: "GetDNSQueryStats",
+			Handler:    _VPNService_GetDNSQueryStats_Handler,
+		},
 		{
 			MethodName: "CheckUpdate",
 			Handler:    _VPNService_CheckUpdate_Handler,
@@ -1749,6 +2247,11 @@ var VPNService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _VPNService_StreamConnections_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "StreamDNSQueries",
+			Handler:       _VPNService_StreamDNSQueries_Handler,
+			ServerStreams: true,
+		},
 		{
 			StreamName:    "ApplyUpdateStream",
 			Handler:       _VPNService_ApplyUpdateStream_Handler,
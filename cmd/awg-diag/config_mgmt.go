@@ -11,6 +11,7 @@ import (
 
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/ipc"
+	"awg-split-tunnel/internal/ruleimport"
 
 	"google.golang.org/protobuf/types/known/emptypb"
 	"gopkg.in/yaml.v3"
@@ -181,6 +182,118 @@ func runConfigShowRules() {
 	}
 }
 
+// runConfigImportRules converts rules of another client (Proxifier, Clash,
+// v2rayN, WireSock) and appends them to the config.
+func runConfigImportRules(args []string) {
+	var file, formatStr, defaultTunnel string
+	var dryRun bool
+	tunnels := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--file":
+			if i+1 < len(args) {
+				file = args[i+1]
+				i++
+			}
+		case "--format":
+			if i+1 < len(args) {
+				formatStr = args[i+1]
+				i++
+			}
+		case "--tunnel":
+			if i+1 < len(args) {
+				defaultTunnel = args[i+1]
+				i++
+			}
+		case "--map":
+			if i+1 < len(args) {
+				name, id, ok := strings.Cut(args[i+1], "=")
+				if !ok {
+					fatal("invalid --map %q, expected <source name>=<tunnel_id>", args[i+1])
+				}
+				tunnels[name] = id
+				i++
+			}
+		case "--dry-run":
+			dryRun = true
+		}
+	}
+
+	if file == "" {
+		fatal("usage: config import-rules --file <path> [--format <proxifier|clash|v2rayn|wiresock>] [--tunnel <tunnel_id>] [--map <name>=<tunnel_id>]... [--dry-run]")
+	}
+	format, err := ruleimport.ParseFormat(formatStr)
+	if err != nil {
+		fatal("%v", err)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fatal("read %s: %v", file, err)
+	}
+	if format == "" {
+		format = ruleimport.Detect(file, data)
+	}
+
+	res, err := ruleimport.Parse(format, data, ruleimport.Options{Tunnels: tunnels, DefaultTunnel: defaultTunnel})
+	if err != nil {
+		fatal("import: %v", err)
+	}
+	skipped := res.Skipped
+
+	if !dryRun {
+		cfg, err := loadConfig()
+		if err != nil {
+			fatal("load config: %v", err)
+		}
+		skipped = append(skipped, res.MergeInto(cfg)...)
+		if err := saveConfig(cfg); err != nil {
+			fatal("save config: %v", err)
+		}
+	}
+
+	if jsonOutput {
+		outputJSON(map[string]any{
+			"format":       format,
+			"rules":        res.Rules,
+			"domain_rules": res.DomainRules,
+			"filter":       res.Filter,
+			"skipped":      skipped,
+			"warnings":     res.Warnings,
+		})
+		return
+	}
+
+	for _, r := range res.Rules {
+		if r.TunnelID == "" {
+			diagLog.Printf("rule         %-40s %s", r.Pattern, r.Fallback.String())
+		} else {
+			diagLog.Printf("rule         %-40s -> %s", r.Pattern, r.TunnelID)
+		}
+	}
+	for _, r := range res.DomainRules {
+		diagLog.Printf("domain rule  %-40s %s %s", r.Pattern, r.Action.String(), r.TunnelID)
+	}
+	for _, app := range res.Filter.DisallowedApps {
+		diagLog.Printf("direct app   %s", app)
+	}
+	for _, ip := range res.Filter.DisallowedIPs {
+		diagLog.Printf("direct IP    %s", ip)
+	}
+	for _, sk := range skipped {
+		diagLog.Printf("SKIPPED      %s", sk)
+	}
+	for _, w := range res.Warnings {
+		diagLog.Printf("WARNING      %s", w)
+	}
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+	diagLog.Printf("%s %s: %d rules, %d domain rules, %d filter entries; %d skipped, %d warnings",
+		verb, format, len(res.Rules), len(res.DomainRules),
+		len(res.Filter.DisallowedApps)+len(res.Filter.DisallowedIPs), len(skipped), len(res.Warnings))
+}
+
 // runConfigListTunnels shows tunnels from config + optional live status via IPC.
 func runConfigListTunnels() {
 	cfg, err := loadConfig()
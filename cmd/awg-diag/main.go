@@ -87,7 +87,7 @@ func main() {
 	// Config management.
 	case "config":
 		if len(cmdArgs) == 0 {
-			fatal("usage: awg-diag config <add-rule|remove-rule|show-rules|list-tunnels>")
+			fatal("usage: awg-diag config <add-rule|remove-rule|show-rules|import-rules|list-tunnels>")
 		}
 		switch cmdArgs[0] {
 		case "add-rule":
@@ -96,6 +96,8 @@ func main() {
 			runConfigRemoveRule(cmdArgs[1:])
 		case "show-rules":
 			runConfigShowRules()
+		case "import-rules":
+			runConfigImportRules(cmdArgs[1:])
 		case "list-tunnels":
 			runConfigListTunnels()
 		default:
@@ -271,6 +273,7 @@ Config Management:
   config add-rule --pattern P --tunnel T [--fallback F] [--priority P]
   config remove-rule --pattern P
   config show-rules
+  config import-rules --file F [--format X] [--tunnel T] [--map NAME=T]... [--dry-run]
   config list-tunnels
 
 Tunnel Management:
@@ -23,10 +23,16 @@ import (
 	"awg-split-tunnel/internal/platform"
 	"awg-split-tunnel/internal/process"
 	"awg-split-tunnel/internal/provider"
-	"awg-split-tunnel/internal/provider/anyconnect"
+	"awg-split-tunnel/internal/provider/hysteria2"
+	"awg-split-tunnel/internal/provider/tuic"
 	"awg-split-tunnel/internal/provider/vless"
+	"awg-split-tunnel/internal/provider/wgconf"
+	"awg-split-tunnel/internal/proxy"
+	"awg-split-tunnel/internal/secret"
 	"awg-split-tunnel/internal/service"
+	"awg-split-tunnel/internal/shaping"
 	"awg-split-tunnel/internal/update"
+	"awg-split-tunnel/internal/wgserver"
 )
 
 // Build info — injected via ldflags at compile time.
@@ -55,6 +61,10 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	}
 	cfg := cfgManager.Get()
 
+	// Sealed secrets (TOTP seeds) use a key kept next to the config where
+	// the OS has no data-protection API.
+	secret.SetKeyFile(filepath.Join(filepath.Dir(configPath), "secret.key"))
+
 	// Initialize logger from config.
 	core.Log = core.NewLogger(cfg.Logging)
 
@@ -113,6 +123,7 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 
 	registry := core.NewTunnelRegistry(bus)
 	matcher := process.NewMatcher()
+	matcher.SetPathQuery(plat.QueryProcessPath)
 	ruleEngine := core.NewRuleEngine(cfg.Rules, bus, matcher)
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -231,6 +242,13 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 			len(cfg.Global.DisallowedIPs), len(cfg.Global.AllowedIPs), len(cfg.Global.DisallowedApps))
 	}
 
+	// === 7a-ter. Bandwidth shaping (rate_limit: global, per tunnel, per rule) ===
+	shaper := shaping.New()
+	shaper.Configure(cfg)
+	tunRouter.SetShaper(shaper)
+	connLimits := proxy.NewConnLimits()
+	connLimits.Configure(cfg.Rules)
+
 	// === 7b. Process filter bypass permits for local/disallowed CIDRs ===
 	bypassPrefixes := gateway.GetBypassPrefixes(cfg.Global)
 	if len(bypassPrefixes) > 0 {
@@ -249,7 +267,17 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	nicHTTPClient := gateway.NewNICBoundHTTPClient(realNIC.Index, realNIC.LocalIP, ifBinder)
 
 	// === 8a. Subscriptions: fetch and merge into tunnel list ===
-	subMgr := core.NewSubscriptionManager(cfgManager, bus, nicHTTPClient, vless.ParseURIToTunnelConfig)
+	subMgr := core.NewSubscriptionManager(cfgManager, bus, nicHTTPClient, func(uri string) (core.TunnelConfig, error) {
+		switch {
+		case vless.IsURI(uri):
+			return vless.ParseURIToTunnelConfig(uri)
+		case hysteria2.IsURI(uri):
+			return hysteria2.ParseURIToTunnelConfig(uri)
+		case tuic.IsURI(uri):
+			return tuic.ParseURIToTunnelConfig(uri)
+		}
+		return wgconf.ParseURIToTunnelConfig(uri)
+	})
 	if len(cfg.Subscriptions) > 0 {
 		subTunnels, err := subMgr.RefreshAll(ctx)
 		if err != nil {
@@ -280,7 +308,15 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		Providers:       providers,
 		Rules:           ruleEngine,
 		Cfg:             cfgManager,
+		Shaper:          shaper,
+		ConnLimits:      connLimits,
+		ListenTCP:       plat.ListenTCP,
+		ListenUDP:       plat.ListenUDP,
+		NewProvider:     plat.NewTunnelProvider,
 	}, nextProxyPort)
+	// Rules targeting "tunnel_id/peer" pin destinations to one WG/AWG peer.
+	tunRouter.SetPeerPinner(tunnelCtrl.PinPeer)
+	tunnelCtrl.SetFailover(cfg.Global.Failover)
 
 	for _, tcfg := range cfg.Tunnels {
 		if err := tunnelCtrl.AddTunnel(ctx, tcfg, nil); err != nil {
@@ -296,6 +332,7 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	// === 11. DNS Resolver (local DNS forwarder — created and started now, ===
 	// === but routes and DNS interception are activated only when VPN tunnels connect) ===
 	var dnsResolver *gateway.DNSResolver
+	var dnsQueryLog *gateway.DNSQueryLog
 	hasDNSResolver := false
 	if len(dnsConfig.TunnelIDs) > 0 && len(dnsConfig.FallbackServers) > 0 {
 		resolverCfg := gateway.DNSResolverConfig{
@@ -303,6 +340,8 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 			Servers:        dnsConfig.FallbackServers,
 			TunnelIDs:      dnsConfig.TunnelIDs,
 			FallbackDirect: true,
+			ListenUDP:      plat.ListenUDP,
+			ListenTCP:      plat.ListenTCP,
 		}
 		dnsResolver = gateway.NewDNSResolver(resolverCfg, registry, providers)
 		dnsResolver.SetDirectIPCallback(func(ips []netip.Addr) {
@@ -310,6 +349,10 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 				core.Log.Warnf("WFP", "Failed to add direct IP permits: %v", err)
 			}
 		})
+		// Structured query log for the DNS query stream and per-domain stats.
+		dnsQueryLog = gateway.NewDNSQueryLog()
+		dnsResolver.SetQueryLog(dnsQueryLog)
+		dnsResolver.SetProcessLookup(tunRouter.LookupProcess)
 		if err := dnsResolver.Start(ctx); err != nil {
 			core.Log.Warnf("DNS", "Failed to start DNS resolver: %v", err)
 		} else {
@@ -654,17 +697,20 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 				}
 			}
 
-			// Trigger network roaming for AnyConnect tunnels.
+			// Trigger network roaming for SSL-VPN tunnels.
 		for _, entry := range registry.All() {
-			if entry.State == core.TunnelStateUp && entry.Config.Protocol == "anyconnect" {
+			if entry.State == core.TunnelStateUp && core.IsSSLVPNProtocol(entry.Config.Protocol) {
 				if prov := tunnelCtrl.GetProvider(entry.ID); prov != nil {
-					if ap, ok := prov.(*anyconnect.Provider); ok {
-						ap.HandleNetworkChange()
+					if rp, ok := prov.(interface{ HandleNetworkChange() }); ok {
+						rp.HandleNetworkChange()
 					}
 				}
 			}
 		}
 
+		// The new uplink may carry less: measure tunnel path MTUs again.
+		tunnelCtrl.ReprobePathMTU()
+
 		core.Log.Infof("Core", "Network change handled (new gateway: %s)", newNIC.Gateway)
 		}
 
@@ -687,10 +733,38 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		procFilter.RemoveDirectIPs(ips)
 	})
 	domainTable.StartCleanup(ctx)
-	domainMatcher := buildDomainMatcher(cfg.DomainRules, geositeFilePath, nicHTTPClient)
+	// Ad/tracker blocklists and rule sets: cached on disk, refreshed in the background.
+	blocklistMgr := gateway.NewBlocklistManager(resolveRelativeToExe("blocklists"), nicHTTPClient)
+	ruleSetMgr := gateway.NewRuleSetManager(resolveRelativeToExe("rulesets"), nicHTTPClient)
+	// A background list refresh only swaps the matchers: FakeIP mappings
+	// and the domain table stay valid for domains that are still routed.
+	swapDomainMatcher := func() {
+		m := buildDomainMatcher(cfgManager.Get().DomainRules, geositeFilePath, nicHTTPClient, blocklistMgr, ruleSetMgr)
+		if dnsResolver != nil {
+			dnsResolver.SetDomainMatcher(m)
+		}
+		if m != nil && !m.IsEmpty() {
+			fn := domainMatchFuncFrom(m)
+			tunnelCtrl.SetDomainMatchFunc(&fn)
+		} else {
+			tunnelCtrl.SetDomainMatchFunc(nil)
+		}
+	}
+	blocklistMgr.SetOnUpdate(swapDomainMatcher)
+	ruleSetMgr.SetOnUpdate(func() {
+		ruleEngine.SetRuleSets(ruleSetMgr.ProcessSets())
+		swapDomainMatcher()
+		tunRouter.SetGeoIPMatcher(buildGeoIPMatcher(cfgManager.Get().DomainRules, geoipFilePath, nicHTTPClient, ruleSetMgr))
+	})
+	blocklistMgr.Configure(ctx, cfg.DNS.Blocklists, cfg.DNS.Allowlist)
+	ruleSetMgr.Configure(ctx, cfg.RuleSets)
+	ruleEngine.SetRuleSets(ruleSetMgr.ProcessSets())
+	domainMatcher := buildDomainMatcher(cfg.DomainRules, geositeFilePath, nicHTTPClient, blocklistMgr, ruleSetMgr)
 	if dnsResolver != nil {
 		dnsResolver.SetDomainMatcher(domainMatcher)
 		dnsResolver.SetDomainTable(domainTable)
+		dnsResolver.SetLocalRecords(gateway.NewLocalRecords(cfg.DNS.Records))
+		dnsResolver.SetSplitDNS(gateway.NewSplitDNS(cfg.DNS.Split, cfg.DNS.SearchDomains))
 	}
 	tunRouter.SetDomainTable(domainTable)
 
@@ -718,7 +792,7 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	}
 
 	// GeoIP routing.
-	geoipMatcher := buildGeoIPMatcher(cfg.DomainRules, geoipFilePath, nicHTTPClient)
+	geoipMatcher := buildGeoIPMatcher(cfg.DomainRules, geoipFilePath, nicHTTPClient, ruleSetMgr)
 	if geoipMatcher != nil && !geoipMatcher.IsEmpty() {
 		tunRouter.SetGeoIPMatcher(geoipMatcher)
 		core.Log.Infof("DNS", "GeoIP matcher active")
@@ -749,7 +823,7 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	}
 
 	domainReloader := func(rules []core.DomainRule) error {
-		m := buildDomainMatcher(rules, geositeFilePath, nicHTTPClient)
+		m := buildDomainMatcher(rules, geositeFilePath, nicHTTPClient, blocklistMgr, ruleSetMgr)
 		if dnsResolver != nil {
 			dnsResolver.SetDomainMatcher(m)
 		}
@@ -759,7 +833,7 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		domainTable.Flush()
 
 		// Rebuild GeoIP matcher.
-		gm := buildGeoIPMatcher(rules, geoipFilePath, nicHTTPClient)
+		gm := buildGeoIPMatcher(rules, geoipFilePath, nicHTTPClient, ruleSetMgr)
 		tunRouter.SetGeoIPMatcher(gm)
 
 		// Update SNI-based routing on all proxies.
@@ -788,6 +862,15 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	connMon := service.NewConnectionMonitor(flows, domainTable, geoResolver)
 	go connMon.Start(ctx)
 
+	// === 11f. Connection audit log (finished flows → NDJSON / IPFIX) ===
+	flowExporter := gateway.NewFlowExporter(resolveRelativeToExe("."), adapter.IP())
+	flowExporter.SetDomainTable(domainTable)
+	if err := flowExporter.Configure(cfg.FlowLog); err != nil {
+		core.Log.Warnf("Core", "Flow log disabled: %v", err)
+	}
+	flows.SetFlowExportHook(flowExporter.Enqueue)
+	flowExporter.Start(ctx)
+
 	// === 12. Start TUN Router ===
 	if err := tunRouter.Start(ctx); err != nil {
 		return fmt.Errorf("failed to start TUN router: %w", err)
@@ -795,6 +878,36 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 	// TUN router started — the main shutdown handler takes over cleanup.
 	initCleanup = nil
 
+	// === 12a. WireGuard server (inbound peers routed by the same rules) ===
+	wgSrv := wgserver.New(wgserver.Options{
+		Router:        tunRouter,
+		Providers:     tunnelCtrl.ProviderLookup(),
+		BytesReporter: statsCollector.AddBytes,
+		OnPeerEndpoint: func(ip netip.Addr) {
+			// Replies to peers must leave through the real NIC, not the TUN.
+			if err := routeMgr.AddBypassRoute(ip); err != nil {
+				core.Log.Warnf("WGServer", "Failed to add bypass route for %s: %v", ip, err)
+			}
+		},
+	})
+	configureWGServer := func(c core.Config) {
+		changed, err := wgserver.EnsureKey(&c.WGServer)
+		if err != nil {
+			core.Log.Warnf("WGServer", "Failed to generate server key: %v", err)
+			return
+		}
+		if changed && c.WGServer.Enabled {
+			cfgManager.SetQuiet(c)
+			if err := cfgManager.Save(); err != nil {
+				core.Log.Warnf("WGServer", "Failed to save server key: %v", err)
+			}
+		}
+		if err := wgSrv.Configure(c.WGServer); err != nil {
+			core.Log.Warnf("WGServer", "Server disabled: %v", err)
+		}
+	}
+	configureWGServer(cfg)
+
 	rules := ruleEngine.GetRules()
 	core.Log.Infof("Core", "Active rules: %d", len(rules))
 	for _, r := range rules {
@@ -831,6 +944,15 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		if rcfg.Enabled && cfg.GUI.RestoreConnections {
 			reconnectMgr.LoadIntents(cfg.GUI.ActiveTunnels)
 		}
+
+		// Re-resolve hostname WG/AWG endpoints (dynamic DNS) via the same
+		// NIC-bound resolver so a moved server is followed without reconnect.
+		refresh := 5 * time.Minute
+		if d, err := time.ParseDuration(cfg.GUI.HealthCheck.EndpointRefresh); err == nil && d > 0 {
+			refresh = d
+		}
+		tunnelCtrl.SetEndpointResolver(nicResolver)
+		tunnelCtrl.StartEndpointRefresh(refresh)
 	}
 
 	// === 12c. Health Monitor ===
@@ -844,7 +966,7 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		}
 		if enabled {
 			healthMon = service.NewHealthMonitor(hcfg, registry,
-				tunnelCtrl.ProviderLookup(), tunnelCtrl.MarkTunnelUnhealthy)
+				tunnelCtrl.ProviderLookup(), tunnelCtrl.MarkTunnelUnhealthy, tunnelCtrl.RefreshEndpoints)
 		}
 	}
 
@@ -881,16 +1003,27 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		ReconnectManager:    reconnectMgr,
 		HealthMonitor:       healthMon,
 		ConnMonitor:         connMon,
+		DNSQueryLog:         dnsQueryLog,
+		WGServer:            wgSrv,
+		Shaper:              shaper,
+		ConnLimits:          connLimits,
 	})
 	svc.Start(ctx)
 
 	// Register service via controller callback (daemon mode) or create own IPC server.
 	var ipcServer *ipc.Server
 	var deregisterSvc func()
+	var ipcAuthz *ipc.Authorizer
+	if len(opts) > 0 && opts[0].Authorizer != nil {
+		ipcAuthz = opts[0].Authorizer
+		ipcAuthz.SetPolicy(ipc.NewPolicy(cfg.IPCAccess))
+	} else {
+		ipcAuthz = ipc.NewAuthorizer(ipc.NewPolicy(cfg.IPCAccess))
+	}
 	if len(opts) > 0 && opts[0].RegisterService != nil {
 		deregisterSvc = opts[0].RegisterService(svc)
 	} else {
-		ipcServer = ipc.NewServer(svc)
+		ipcServer = ipc.NewServer(svc, ipcAuthz.ServerOptions()...)
 		core.SafeGo("ipc.server", func() {
 			ln, err := plat.IPC.Listener()
 			if err != nil {
@@ -917,6 +1050,12 @@ func runVPN(configPath string, plat *platform.Platform, stopCh <-chan struct{},
 		})
 	}
 
+	// Remote management API (opt-in TCP listener with mTLS).
+	remoteAPI := ipc.NewRemoteServer(svc, filepath.Dir(configPath))
+	if err := remoteAPI.Configure(cfg.RemoteAPI); err != nil {
+		core.Log.Warnf("RemoteAPI", "Remote API disabled: %v", err)
+	}
+
 	// --- Wait for shutdown signal ---
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
@@ -955,13 +1094,29 @@ mainLoop:
 			newCfg := cfgManager.Get()
 			ipFilter = gateway.NewIPFilter(newCfg.Global, newCfg.Tunnels)
 			tunRouter.SetIPFilter(ipFilter)
+			ruleSetMgr.Configure(ctx, newCfg.RuleSets)
+			ruleEngine.SetRuleSets(ruleSetMgr.ProcessSets())
 			ruleEngine.SetRules(newCfg.Rules)
+			shaper.Configure(newCfg)
+			connLimits.Configure(newCfg.Rules)
+			tunnelCtrl.SetFailover(newCfg.Global.Failover)
 			// Reload auto-bypass (revokes old WFP permits, rebuilds with new config).
 			tunRouter.SetAutoBypass(core.NewAutoBypass(newCfg.AutoBypass))
 			// Rebuild domain matcher if rules changed
 			if dnsResolver != nil {
+				blocklistMgr.Configure(ctx, newCfg.DNS.Blocklists, newCfg.DNS.Allowlist)
+				dnsResolver.SetLocalRecords(gateway.NewLocalRecords(newCfg.DNS.Records))
+				dnsResolver.SetSplitDNS(gateway.NewSplitDNS(newCfg.DNS.Split, newCfg.DNS.SearchDomains))
 				domainReloader(newCfg.DomainRules)
 			}
+			if err := flowExporter.Configure(newCfg.FlowLog); err != nil {
+				core.Log.Warnf("Core", "Flow log disabled: %v", err)
+			}
+			configureWGServer(newCfg)
+			ipcAuthz.SetPolicy(ipc.NewPolicy(newCfg.IPCAccess))
+			if err := remoteAPI.Configure(newCfg.RemoteAPI); err != nil {
+				core.Log.Warnf("RemoteAPI", "Remote API disabled: %v", err)
+			}
 			// Check if kill switch setting changed.
 			gwMu.Lock()
 			active := gwActive
@@ -1006,12 +1161,17 @@ mainLoop:
 		if ipcServer != nil {
 			ipcServer.Stop()
 		}
+		remoteAPI.Stop()
 		svc.Stop()
 
 		if subMgr != nil {
 			subMgr.Stop()
 		}
 
+		wgSrv.Stop()
+		blocklistMgr.Stop()
+		ruleSetMgr.Stop()
+		flowExporter.Wait()
 		if dnsResolver != nil {
 			dnsResolver.Stop()
 		}
@@ -1071,12 +1231,18 @@ func getStringSetting(settings map[string]any, key, defaultVal string) string {
 	return defaultVal
 }
 
-func buildDomainMatcher(rules []core.DomainRule, geositeFilePath string, httpClient *http.Client) *gateway.DomainMatcher {
-	if len(rules) == 0 {
+func buildDomainMatcher(rules []core.DomainRule, geositeFilePath string, httpClient *http.Client, lists *gateway.BlocklistManager, sets *gateway.RuleSetManager) *gateway.DomainMatcher {
+	var listEntries []gateway.GeositeExpanded
+	var allow []string
+	if lists != nil {
+		listEntries, allow = lists.Expanded()
+	}
+	if len(rules) == 0 && len(listEntries) == 0 {
 		return nil
 	}
 
 	var regularRules []core.DomainRule
+	var ruleSetEntries []gateway.GeositeExpanded
 	geositeCategories := make(map[string]core.DomainRule)
 
 	for _, r := range rules {
@@ -1092,6 +1258,12 @@ func buildDomainMatcher(rules []core.DomainRule, geositeFilePath string, httpCli
 		case "geoip":
 			// GeoIP rules are handled separately via buildGeoIPMatcher; skip here.
 			continue
+		case "ruleset":
+			if rs := sets.Get(value); rs != nil {
+				for _, d := range rs.Domains {
+					ruleSetEntries = append(ruleSetEntries, gateway.GeositeExpanded{Type: d.Type, Value: d.Value, Rule: r})
+				}
+			}
 		default:
 			regularRules = append(regularRules, r)
 		}
@@ -1111,33 +1283,49 @@ func buildDomainMatcher(rules []core.DomainRule, geositeFilePath string, httpCli
 		}
 	}
 
-	return gateway.NewDomainMatcher(regularRules, geositeEntries)
+	geositeEntries = append(geositeEntries, ruleSetEntries...)
+	return gateway.NewDomainMatcherWithLists(regularRules, geositeEntries, listEntries, allow)
 }
 
-func buildGeoIPMatcher(rules []core.DomainRule, geoipFilePath string, httpClient *http.Client) *gateway.GeoIPMatcher {
+func buildGeoIPMatcher(rules []core.DomainRule, geoipFilePath string, httpClient *http.Client, sets *gateway.RuleSetManager) *gateway.GeoIPMatcher {
 	geoipCategories := make(map[string]core.DomainRule)
+	var ruleSetRules []core.DomainRule
 	for _, r := range rules {
 		if !r.IsEnabled() {
 			continue // skip disabled rule
 		}
 		prefix, value := splitDomainPattern(r.Pattern)
-		if prefix == "geoip" && value != "" {
+		switch {
+		case prefix == "geoip" && value != "":
 			geoipCategories[value] = r
+		case prefix == "ruleset" && value != "":
+			ruleSetRules = append(ruleSetRules, r)
 		}
 	}
 
-	if len(geoipCategories) == 0 {
+	if len(geoipCategories) == 0 && len(ruleSetRules) == 0 {
 		return nil
 	}
 
-	if err := gateway.EnsureGeoIPFile(geoipFilePath, httpClient); err != nil {
-		core.Log.Warnf("DNS", "Failed to ensure geoip.dat: %v", err)
-		return nil
+	matcher := &gateway.GeoIPMatcher{}
+	if len(geoipCategories) > 0 {
+		if err := gateway.EnsureGeoIPFile(geoipFilePath, httpClient); err != nil {
+			core.Log.Warnf("DNS", "Failed to ensure geoip.dat: %v", err)
+		} else if m, err := gateway.NewGeoIPMatcher(geoipFilePath, geoipCategories); err != nil {
+			core.Log.Warnf("DNS", "Failed to build GeoIP matcher: %v", err)
+		} else {
+			matcher = m
+		}
 	}
 
-	matcher, err := gateway.NewGeoIPMatcher(geoipFilePath, geoipCategories)
-	if err != nil {
-		core.Log.Warnf("DNS", "Failed to build GeoIP matcher: %v", err)
+	// Rule set CIDRs are matched after geoip categories.
+	for _, r := range ruleSetRules {
+		_, name := splitDomainPattern(r.Pattern)
+		if rs := sets.Get(name); rs != nil {
+			matcher.AddPrefixes(rs.CIDRs, r)
+		}
+	}
+	if matcher.IsEmpty() {
 		return nil
 	}
 	return matcher
@@ -1148,7 +1336,7 @@ func splitDomainPattern(pattern string) (string, string) {
 		if pattern[i] == ':' {
 			prefix := pattern[:i]
 			switch prefix {
-			case "domain", "full", "keyword", "geosite", "geoip":
+			case "domain", "full", "keyword", "geosite", "geoip", "ruleset":
 				return prefix, pattern[i+1:]
 			}
 			break
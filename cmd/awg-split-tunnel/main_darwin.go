@@ -10,6 +10,7 @@ import (
 
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/daemon"
+	"awg-split-tunnel/internal/ipc"
 	platformDarwin "awg-split-tunnel/internal/platform/darwin"
 	"awg-split-tunnel/internal/service"
 )
@@ -85,6 +86,7 @@ func main() {
 			RunVPN:      runVPN,
 			KeepAlive:   cfg.GUI.KeepAliveOnDisconnect,
 			Listener:    ln,
+			Authorizer:  ipc.NewAuthorizer(ipc.NewPolicy(cfg.IPCAccess)),
 		})
 		if err := ctrl.Run(); err != nil {
 			log.Fatalf("[Daemon] Fatal: %v", err)
@@ -0,0 +1,142 @@
+//go:build linux
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/daemon"
+	"awg-split-tunnel/internal/ipc"
+	"awg-split-tunnel/internal/platform/sim"
+)
+
+// stopCh is used to signal shutdown once a scenario has finished.
+var stopCh = make(chan struct{}, 1)
+
+// On Linux the service runs on the simulated platform: the TUN adapter,
+// process table, filters and tunnels are all in memory, so it needs no
+// privileges and never touches the host's network. Rules and configs are
+// validated by running a scenario against it, or interactively over IPC.
+func main() {
+	configPath := flag.String("config", "config.yaml", "Path to configuration file")
+	scenarioPath := flag.String("scenario", "", "Run the flows in this scenario file, print a report and exit")
+	replayPath := flag.String("replay", "", "Replay this pcap through the TUN adapter (overrides the scenario's replay)")
+	capturePath := flag.String("capture", "", "Write a pcap of all packets crossing the TUN adapter")
+	socketPath := flag.String("socket", sim.DefaultSocketPath, "IPC socket path")
+	showVersion := flag.Bool("version", false, "Print version and exit")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Printf("awg-split-tunnel %s (commit=%s, built=%s, simulated platform)\n", version, commit, buildDate)
+		os.Exit(0)
+	}
+
+	var sc *sim.Scenario
+	if *scenarioPath != "" {
+		var err error
+		if sc, err = sim.LoadScenario(*scenarioPath); err != nil {
+			log.Fatalf("[Sim] %v", err)
+		}
+	}
+	if *replayPath != "" {
+		if sc == nil {
+			sc = &sim.Scenario{}
+		}
+		sc.Replay = *replayPath
+	}
+
+	opts := sim.Options{SocketPath: *socketPath}
+	if *capturePath != "" {
+		f, err := os.Create(*capturePath)
+		if err != nil {
+			log.Fatalf("[Sim] Capture: %v", err)
+		}
+		defer f.Close()
+		opts.Capture = f
+	}
+	s, err := sim.New(opts)
+	if err != nil {
+		log.Fatalf("[Sim] %v", err)
+	}
+	defer s.Close()
+
+	// runVPN hands the service over instead of starting its own IPC server,
+	// so the scenario can drive it in-process.
+	authz := ipc.NewAuthorizer(ipc.NewPolicy(core.IPCAccessConfig{}))
+	svcCh := make(chan vpnapi.VPNServiceServer, 1)
+	runCfg := daemon.RunConfig{
+		Authorizer: authz,
+		RegisterService: func(svc vpnapi.VPNServiceServer) func() {
+			srv := ipc.NewServer(svc, authz.ServerOptions()...)
+			core.SafeGo("ipc.server", func() {
+				ln, err := s.Platform.IPC.Listener()
+				if err != nil {
+					core.Log.Errorf("Core", "IPC listen error: %v", err)
+					return
+				}
+				core.Log.Infof("Core", "IPC server starting on %s", ln.Addr())
+				if err := srv.Start(ln); err != nil {
+					core.Log.Errorf("Core", "IPC server error: %v", err)
+				}
+			})
+			svcCh <- svc
+			return srv.Stop
+		},
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- runVPN(resolveRelativeToExe(*configPath), s.Platform, stopCh, runCfg) }()
+
+	if sc == nil {
+		if err := <-errCh; err != nil {
+			log.Fatalf("[Core] Fatal: %v", err)
+		}
+		return
+	}
+
+	var svc vpnapi.VPNServiceServer
+	select {
+	case svc = <-svcCh:
+	case err := <-errCh:
+		log.Fatalf("[Core] Fatal: %v", err)
+	}
+	failed := runScenario(s, svc, sc)
+	stopCh <- struct{}{}
+	if err := <-errCh; err != nil {
+		log.Printf("[Core] %v", err)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// runScenario connects every tunnel, runs the scenario and prints the
+// report. It returns the number of failed flows.
+func runScenario(s *sim.Sim, svc vpnapi.VPNServiceServer, sc *sim.Scenario) int {
+	ctx := context.Background()
+	if resp, err := svc.Connect(ctx, &vpnapi.ConnectRequest{}); err != nil {
+		log.Printf("[Sim] Connect: %v", err)
+	} else if !resp.Success {
+		log.Printf("[Sim] Connect: %s", resp.Error)
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	if err := s.WaitActive(waitCtx); err != nil {
+		log.Printf("[Sim] Gateway not activated (no VPN tunnel up?); running flows anyway")
+	}
+	cancel()
+
+	results, err := s.Run(ctx, sc)
+	failed := sim.WriteReport(os.Stdout, results)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Scenario aborted: %v\n", err)
+		failed++
+	}
+	return failed
+}
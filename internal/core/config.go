@@ -1,11 +1,17 @@
 package core
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net"
+	"net/netip"
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -45,8 +51,23 @@ const (
 	ProtocolAnyConnect  = "anyconnect"
 	ProtocolHysteria2   = "hysteria2"
 	ProtocolSSH         = "ssh"
+	ProtocolGlobalProtect = "globalprotect"
+	ProtocolFortinet      = "fortinet"
+	ProtocolOpenVPN       = "openvpn"
+	ProtocolTUIC          = "tuic"
 )
 
+// IsSSLVPNProtocol reports whether protocol is a corporate SSL-VPN
+// (AnyConnect, GlobalProtect, Fortinet): cookie-based sessions that may need
+// interactive authentication (OTP/SAML) and can be resumed after roaming.
+func IsSSLVPNProtocol(protocol string) bool {
+	switch protocol {
+	case ProtocolAnyConnect, ProtocolGlobalProtect, ProtocolFortinet:
+		return true
+	}
+	return false
+}
+
 // FallbackPolicy defines what happens when a tunnel is unavailable.
 type FallbackPolicy int
 
@@ -87,6 +108,8 @@ type NATInfo struct {
 	ExeLower    string  // pre-lowered exe path for failover re-matching
 	BaseLower   string  // pre-lowered exe basename for failover re-matching
 	RuleIdx     int     // index of matched rule in RuleEngine, for failover chain
+	Rule        string  // pattern of the matched process rule, "" if none
+	FullCone    bool    // rule asks for endpoint-independent UDP NAT (udp_nat: full_cone)
 }
 
 // DialDst returns the destination address to use for dialing.
@@ -200,7 +223,8 @@ func ParseDomainAction(s string) (DomainAction, error) {
 
 // DomainRule maps a domain pattern to a routing action.
 type DomainRule struct {
-	// Pattern is the matching expression: "domain:vk.com", "full:example.com", "keyword:google", "geosite:ru"
+	// Pattern is the matching expression: "domain:vk.com", "full:example.com", "keyword:google", "geosite:ru",
+	// "geoip:ru", "ruleset:name" (domains and CIDRs of a rule set)
 	Pattern string `yaml:"pattern"`
 	// TunnelID identifies which tunnel to route through (only for DomainRoute).
 	TunnelID string `yaml:"tunnel_id,omitempty"`
@@ -222,9 +246,13 @@ type DomainMatchFunc func(domain string) (tunnelID string, action DomainAction,
 
 // Rule maps a process pattern to a tunnel with a fallback policy.
 type Rule struct {
-	// Pattern is the matching expression: "firefox.exe", "chrome", "C:\Games\*"
+	// Pattern is the matching expression: "firefox.exe", "chrome", "C:\Games\*",
+	// "ruleset:name" (process entries of a rule set), "peer:name" (a
+	// wg_server peer by name or public key; "peer:*" matches every peer)
 	Pattern string `yaml:"pattern"`
 	// TunnelID identifies which tunnel to route through. Empty for drop-only rules.
+	// "tunnel_id/peer" targets one peer of a multi-peer WireGuard/AWG tunnel
+	// (peer name, 1-based index or public key).
 	TunnelID string `yaml:"tunnel_id,omitempty"`
 	// Fallback defines behavior when the tunnel is unavailable.
 	Fallback FallbackPolicy `yaml:"fallback"`
@@ -232,13 +260,41 @@ type Rule struct {
 	Priority RulePriority `yaml:"priority,omitempty"`
 	// Enabled controls whether this rule is active. nil or true = enabled.
 	Enabled *bool `yaml:"enabled,omitempty"`
+	// RateLimit caps the combined throughput of all flows matching this rule.
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+	// UDPNAT selects the UDP NAT behaviour: "symmetric" (default) or
+	// "full_cone" for games and WebRTC that need peers to reach the port
+	// a STUN server saw.
+	UDPNAT string `yaml:"udp_nat,omitempty"`
+	// IdleTimeout closes a proxied TCP connection when no data has moved in
+	// either direction for this long (e.g. "30m"). Default: 5m.
+	IdleTimeout string `yaml:"idle_timeout,omitempty"`
+	// MaxLifetime closes a proxied TCP connection this long after it was
+	// opened, busy or not (e.g. "12h"). Default: unlimited.
+	MaxLifetime string `yaml:"max_lifetime,omitempty"`
 }
 
+// UDP NAT modes (Rule.UDPNAT).
+const (
+	UDPNATSymmetric = "symmetric" // one tunnel socket per destination (default)
+	UDPNATFullCone  = "full_cone" // one tunnel socket per client port, any remote may reply
+)
+
 // IsEnabled returns true if the rule is enabled (nil defaults to true).
 func (r Rule) IsEnabled() bool {
 	return r.Enabled == nil || *r.Enabled
 }
 
+// PeerRulePrefix marks rules that match wg_server peers instead of processes.
+const PeerRulePrefix = "peer:"
+
+// SplitPeerTarget splits a rule target of the form "tunnel_id/peer" into
+// the tunnel ID and the peer selector (empty if the rule targets the tunnel).
+func SplitPeerTarget(target string) (tunnelID, peer string) {
+	tunnelID, peer, _ = strings.Cut(target, "/")
+	return tunnelID, peer
+}
+
 // TunnelConfig holds the configuration for a single VPN tunnel.
 type TunnelConfig struct {
 	ID        string `yaml:"id"`
@@ -254,8 +310,22 @@ type TunnelConfig struct {
 	AllowedIPs     []string `yaml:"allowed_ips,omitempty"`
 	DisallowedIPs  []string `yaml:"disallowed_ips,omitempty"`
 	DisallowedApps []string `yaml:"disallowed_apps,omitempty"`
+
+	// RateLimit caps the combined throughput of all flows through this tunnel.
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+
+	// MTU caps the path MTU of raw-forwarded traffic (576-1500). When set,
+	// the path is not probed.
+	MTU int `yaml:"mtu,omitempty"`
+	// PMTUProbe is the IPv4 address probed through the tunnel to measure the
+	// path MTU. Default: the tunnel's first DNS server, else 1.1.1.1.
+	// "off" disables probing.
+	PMTUProbe string `yaml:"pmtu_probe,omitempty"`
 }
 
+// PMTUProbeOff disables path MTU probing in TunnelConfig.PMTUProbe.
+const PMTUProbeOff = "off"
+
 // DNSRouteConfig configures per-process DNS routing.
 type DNSRouteConfig struct {
 	// TunnelIDs are the tunnels used for DNS resolution.
@@ -266,6 +336,100 @@ type DNSRouteConfig struct {
 	Servers []string `yaml:"servers,omitempty"`
 	// FakeIP configures synthetic IP allocation for domain-matched DNS responses.
 	FakeIP FakeIPConfig `yaml:"fakeip,omitempty"`
+	// Blocklists are subscribable ad/tracker domain lists. Their entries are
+	// compiled into the domain matcher as block rules, after user rules.
+	Blocklists []BlocklistConfig `yaml:"blocklists,omitempty"`
+	// Allowlist holds domain patterns ("domain:", "full:" or bare) that are
+	// never blocked by blocklists. Explicit domain rules are not affected.
+	Allowlist []string `yaml:"allowlist,omitempty"`
+	// Records are static local DNS records answered without querying upstream.
+	Records []DNSLocalRecord `yaml:"records,omitempty"`
+	// Split routes send queries for specific domain suffixes to dedicated
+	// upstreams instead of the default tunnel fan-out.
+	Split []DNSSplitRoute `yaml:"split,omitempty"`
+	// SearchDomains are tried for single-label names ("intranet" →
+	// "intranet.corp.example.com"), in order.
+	SearchDomains []string `yaml:"search_domains,omitempty"`
+}
+
+// DNSSplitRoute resolves a set of domain suffixes through dedicated upstreams.
+type DNSSplitRoute struct {
+	// Domains are suffixes matched together with their subdomains
+	// ("corp.example.com", "*.lan"). "." matches every name not covered by
+	// a more specific route.
+	Domains []string `yaml:"domains"`
+	// Servers are upstream IPs ("10.0.0.53") or DNS-over-HTTPS URLs
+	// ("https://1.1.1.1/dns-query"). Empty uses the tunnel's own DNS servers.
+	Servers []string `yaml:"servers,omitempty"`
+	// TunnelID is the tunnel used to reach the servers. Empty means direct
+	// via the real NIC.
+	TunnelID string `yaml:"tunnel_id,omitempty"`
+}
+
+// Blocklist formats.
+const (
+	BlocklistFormatAuto    = "auto"    // detect per line
+	BlocklistFormatHosts   = "hosts"   // "0.0.0.0 ads.example.com"
+	BlocklistFormatAdblock = "adblock" // AdGuard/ABP "||ads.example.com^", "@@||ok.example.com^"
+	BlocklistFormatPlain   = "plain"   // one domain per line
+)
+
+// BlocklistConfig describes a domain blocklist loaded from a URL or local file.
+type BlocklistConfig struct {
+	Name string `yaml:"name"`
+	// URL is fetched through the NIC-bound HTTP client and cached on disk.
+	URL string `yaml:"url,omitempty"`
+	// Path is a local list file, used instead of URL when set.
+	Path string `yaml:"path,omitempty"`
+	// Format is one of "auto" (default), "hosts", "adblock", "plain".
+	Format string `yaml:"format,omitempty"`
+	// RefreshInterval is how often to re-fetch (e.g. "24h"). Default 24h.
+	RefreshInterval string `yaml:"refresh_interval,omitempty"`
+	Enabled         *bool  `yaml:"enabled,omitempty"`
+}
+
+// IsEnabled returns true if the blocklist is enabled (nil defaults to true).
+func (b BlocklistConfig) IsEnabled() bool {
+	return b.Enabled == nil || *b.Enabled
+}
+
+// DNSLocalRecord is a static DNS record answered by the local resolver.
+// Domain may start with "*." to also match all subdomains.
+type DNSLocalRecord struct {
+	Domain string   `yaml:"domain"`
+	IPs    []string `yaml:"ips,omitempty"`   // A records
+	CNAME  string   `yaml:"cname,omitempty"` // alias target (used when IPs is empty)
+	TTL    int      `yaml:"ttl,omitempty"`   // seconds, default 300
+}
+
+// Rule set formats.
+const (
+	RuleSetFormatAuto    = "auto"    // detect from content
+	RuleSetFormatDomain  = "domain"  // one domain per line, matching subdomains too
+	RuleSetFormatIPCIDR  = "ipcidr"  // one CIDR per line
+	RuleSetFormatClash   = "clash"   // Clash rule-provider YAML or classical text ("DOMAIN-SUFFIX,example.com")
+	RuleSetFormatSingBox = "singbox" // sing-box source rule set (JSON)
+	RuleSetFormatSRS     = "srs"     // sing-box binary rule set
+)
+
+// RuleSetConfig describes a named set of domains, CIDRs and processes loaded
+// from a URL or local file. Domain and process rules reference it as "ruleset:<name>".
+type RuleSetConfig struct {
+	Name string `yaml:"name"`
+	// URL is fetched through the NIC-bound HTTP client and cached on disk.
+	URL string `yaml:"url,omitempty"`
+	// Path is a local rule set file, used instead of URL when set.
+	Path string `yaml:"path,omitempty"`
+	// Format is one of "auto" (default), "domain", "ipcidr", "clash", "singbox", "srs".
+	Format string `yaml:"format,omitempty"`
+	// RefreshInterval is how often to re-fetch (e.g. "12h"). Default 24h.
+	RefreshInterval string `yaml:"refresh_interval,omitempty"`
+	Enabled         *bool  `yaml:"enabled,omitempty"`
+}
+
+// IsEnabled returns true if the rule set is enabled (nil defaults to true).
+func (r RuleSetConfig) IsEnabled() bool {
+	return r.Enabled == nil || *r.Enabled
 }
 
 // FakeIPConfig configures FakeIP allocation for domain-based routing.
@@ -285,6 +449,71 @@ type GlobalFilterConfig struct {
 	DisallowedApps []string `yaml:"disallowed_apps,omitempty"`
 	DisableLocal   bool     `yaml:"disable_local,omitempty"`
 	KillSwitch     bool     `yaml:"kill_switch,omitempty"`
+
+	// RateLimit caps the combined throughput of all tunneled and direct flows.
+	RateLimit *RateLimit `yaml:"rate_limit,omitempty"`
+
+	// Failover tunes rules with fallback: failover.
+	Failover *FailoverConfig `yaml:"failover,omitempty"`
+}
+
+// FailoverConfig controls how rules with fallback: failover pick a tunnel.
+type FailoverConfig struct {
+	// Race dials the next candidate tunnel after RaceDelay instead of
+	// waiting for the previous dial to fail (RFC 8305 style). The first
+	// tunnel to connect wins.
+	Race bool `yaml:"race,omitempty"`
+	// RaceDelay is the head start of each candidate. Default "250ms".
+	RaceDelay string `yaml:"race_delay,omitempty"`
+	// FailureTTL is how long a tunnel that failed to reach a destination,
+	// or lost the race to a less preferred one, is tried last for it.
+	// Default "30s".
+	FailureTTL string `yaml:"failure_ttl,omitempty"`
+}
+
+// Validate checks the duration strings.
+func (f *FailoverConfig) Validate() error {
+	if f == nil {
+		return nil
+	}
+	for _, v := range [...]struct{ name, value string }{
+		{"race_delay", f.RaceDelay},
+		{"failure_ttl", f.FailureTTL},
+	} {
+		if v.value == "" {
+			continue
+		}
+		if d, err := time.ParseDuration(v.value); err != nil || d < 0 {
+			return fmt.Errorf("failover: invalid %s %q", v.name, v.value)
+		}
+	}
+	return nil
+}
+
+// RateLimit is a token-bucket bandwidth limit. Rates are in kilobits per
+// second, bursts in kilobytes; zero means unlimited (rates) or automatic
+// (bursts, 250 ms worth of the rate).
+type RateLimit struct {
+	UpKbps      int64 `yaml:"up_kbps,omitempty"`
+	DownKbps    int64 `yaml:"down_kbps,omitempty"`
+	UpBurstKB   int64 `yaml:"up_burst_kb,omitempty"`
+	DownBurstKB int64 `yaml:"down_burst_kb,omitempty"`
+}
+
+// IsZero reports whether l is nil or limits nothing.
+func (l *RateLimit) IsZero() bool {
+	return l == nil || (l.UpKbps == 0 && l.DownKbps == 0)
+}
+
+// Validate checks that no value is negative.
+func (l *RateLimit) Validate() error {
+	if l == nil {
+		return nil
+	}
+	if l.UpKbps < 0 || l.DownKbps < 0 || l.UpBurstKB < 0 || l.DownBurstKB < 0 {
+		return fmt.Errorf("rate_limit: values must not be negative")
+	}
+	return nil
 }
 
 // UpdateConfig holds auto-update settings.
@@ -312,9 +541,110 @@ type ReconnectConfig struct {
 
 // HealthCheckConfig controls periodic peer liveness monitoring for WG/AWG tunnels.
 type HealthCheckConfig struct {
-	Enabled        *bool  `yaml:"enabled,omitempty"`          // nil = follow reconnect.enabled
-	Interval       string `yaml:"interval,omitempty"`         // check interval, default "30s"
-	StaleThreshold string `yaml:"stale_threshold,omitempty"`  // max age of last handshake, default "3m"
+	Enabled         *bool  `yaml:"enabled,omitempty"`          // nil = follow reconnect.enabled
+	Interval        string `yaml:"interval,omitempty"`         // check interval, default "30s"
+	StaleThreshold  string `yaml:"stale_threshold,omitempty"`  // max age of last handshake, default "3m"
+	EndpointRefresh string `yaml:"endpoint_refresh,omitempty"` // hostname endpoint re-resolve interval, default "5m"
+}
+
+// IPC caller roles, from least to most privileged.
+const (
+	IPCRoleMonitor  = "monitor"  // read-only: status, lists, stats and log streams
+	IPCRoleOperator = "operator" // monitor + connect/disconnect tunnels
+	IPCRoleAdmin    = "admin"    // everything, including config changes and shutdown
+)
+
+// IPCAccessConfig maps local IPC callers to roles. root (macOS/Linux),
+// LocalSystem and elevated Administrators (Windows) are always admin.
+type IPCAccessConfig struct {
+	// DefaultRole applies to callers matched by neither Users nor Groups.
+	// Default "monitor".
+	DefaultRole string `yaml:"default_role,omitempty"`
+	// Users maps a user name, numeric uid or Windows SID to a role.
+	Users map[string]string `yaml:"users,omitempty"`
+	// Groups maps a group name, numeric gid or Windows group SID to a role.
+	// A caller in several groups gets the highest role among them.
+	// On macOS the "admin" group is admin unless overridden here.
+	Groups map[string]string `yaml:"groups,omitempty"`
+}
+
+// Validate checks that every role name is known.
+func (a IPCAccessConfig) Validate() error {
+	check := func(where, role string) error {
+		switch role {
+		case "", IPCRoleMonitor, IPCRoleOperator, IPCRoleAdmin:
+			return nil
+		}
+		return fmt.Errorf("ipc_access: %s: unknown role %q", where, role)
+	}
+	if err := check("default_role", a.DefaultRole); err != nil {
+		return err
+	}
+	for name, role := range a.Users {
+		if err := check("users."+name, role); err != nil {
+			return err
+		}
+	}
+	for name, role := range a.Groups {
+		if err := check("groups."+name, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoteAPIConfig exposes the VPN service over TCP for remote management.
+// Every connection must present a client certificate pinned in Clients.
+type RemoteAPIConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Listen is the gRPC address. Default ":7443".
+	Listen string `yaml:"listen,omitempty"`
+	// RESTListen enables the HTTPS JSON facade on this address (same mTLS).
+	RESTListen string `yaml:"rest_listen,omitempty"`
+	// CertFile/KeyFile hold the server certificate (PEM), relative to the
+	// config directory. A self-signed pair is generated when both are
+	// missing. Default "remote_api.crt" / "remote_api.key".
+	CertFile string         `yaml:"cert_file,omitempty"`
+	KeyFile  string         `yaml:"key_file,omitempty"`
+	Clients  []RemoteClient `yaml:"clients,omitempty"`
+}
+
+// RemoteClient pins one client certificate and assigns its role.
+type RemoteClient struct {
+	Name string `yaml:"name"`
+	// Fingerprint is the SHA-256 of the client certificate (DER), in hex;
+	// colons are allowed.
+	Fingerprint string `yaml:"fingerprint"`
+	Role        string `yaml:"role,omitempty"` // default "monitor"
+}
+
+// FingerprintHex returns the fingerprint as lowercase hex without colons.
+func (c RemoteClient) FingerprintHex() string {
+	return strings.ToLower(strings.ReplaceAll(c.Fingerprint, ":", ""))
+}
+
+// Validate checks client pins and roles.
+func (r RemoteAPIConfig) Validate() error {
+	seen := make(map[string]bool, len(r.Clients))
+	for i, c := range r.Clients {
+		if c.Name == "" {
+			return fmt.Errorf("remote_api.clients[%d]: empty name", i)
+		}
+		fp := c.FingerprintHex()
+		if b, err := hex.DecodeString(fp); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("remote_api client %q: fingerprint must be a hex SHA-256", c.Name)
+		}
+		if seen[fp] {
+			return fmt.Errorf("remote_api client %q: duplicate fingerprint", c.Name)
+		}
+		seen[fp] = true
+		switch c.Role {
+		case "", IPCRoleMonitor, IPCRoleOperator, IPCRoleAdmin:
+		default:
+			return fmt.Errorf("remote_api client %q: unknown role %q", c.Name, c.Role)
+		}
+	}
+	return nil
 }
 
 // GUIConfig holds GUI-specific settings.
@@ -328,6 +658,96 @@ type GUIConfig struct {
 	KeepAliveOnDisconnect bool              `yaml:"keep_alive_on_disconnect,omitempty"` // keep VPN when GUI disconnects (macOS)
 }
 
+// Flow export formats for FlowLogConfig.CollectorFormat.
+const (
+	FlowFormatIPFIX    = "ipfix"    // IPFIX (RFC 7011)
+	FlowFormatNetFlow9 = "netflow9" // NetFlow v9 (RFC 3954)
+)
+
+// FlowLogConfig controls the connection audit log: one record per finished
+// flow, written to a rotating NDJSON file and optionally exported to a
+// flow collector.
+type FlowLogConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Path is the NDJSON file. Relative paths are resolved next to the
+	// executable; empty means "logs/flows.ndjson".
+	Path string `yaml:"path,omitempty"`
+	// MaxSizeMB rotates the file once it grows past this size (default 50).
+	MaxSizeMB int `yaml:"max_size_mb,omitempty"`
+	// MaxFiles is the number of rotated files kept (default 5).
+	MaxFiles int `yaml:"max_files,omitempty"`
+	// Collector is a UDP "host:port" flow collector, e.g. "127.0.0.1:4739".
+	// Empty disables flow export.
+	Collector string `yaml:"collector,omitempty"`
+	// CollectorFormat is "ipfix" (default) or "netflow9".
+	CollectorFormat string `yaml:"collector_format,omitempty"`
+}
+
+// WGServerConfig runs an inbound WireGuard/AmneziaWG listener so other
+// devices can use the split tunnel as peers. Peer traffic is routed by
+// domain rules, geoip and "peer:" rules through the configured tunnels.
+type WGServerConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// ListenPort is the UDP port to listen on (default 51820).
+	ListenPort int `yaml:"listen_port,omitempty"`
+	// PrivateKey is the server's base64 key; generated on first start if empty.
+	PrivateKey string `yaml:"private_key,omitempty"`
+	// Address is the server's tunnel IP with the peer subnet, e.g. "10.66.0.1/24".
+	Address string `yaml:"address,omitempty"`
+	// MTU of the tunnel (default 1420).
+	MTU int `yaml:"mtu,omitempty"`
+	// Endpoint is the public "host:port" written into generated client configs.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Obfuscation holds AmneziaWG parameters (jc, jmin, jmax, s1-s4, h1-h4,
+	// i1-i5). Empty runs plain WireGuard.
+	Obfuscation map[string]string `yaml:"obfuscation,omitempty"`
+	// AllowLAN lets peers reach the host's local networks (RFC 1918,
+	// link-local, multicast) and the host's own interface addresses. Off by
+	// default; peers can override it. The host's loopback is never reachable.
+	AllowLAN bool           `yaml:"allow_lan,omitempty"`
+	Peers    []WGServerPeer `yaml:"peers,omitempty"`
+}
+
+// WGServerPeer is a device allowed to connect to the WireGuard server.
+type WGServerPeer struct {
+	// Name identifies the peer in "peer:<name>" rules and the UI.
+	Name         string `yaml:"name"`
+	PublicKey    string `yaml:"public_key"`
+	PresharedKey string `yaml:"preshared_key,omitempty"`
+	// Address is the peer's tunnel IP; allocated from the server subnet if empty.
+	Address string `yaml:"address,omitempty"`
+	// PrivateKey is kept for peers whose keys were generated by the server,
+	// so their client config can be exported again.
+	PrivateKey string `yaml:"private_key,omitempty"`
+	// Enabled controls whether the peer may connect. nil or true = enabled.
+	Enabled *bool `yaml:"enabled,omitempty"`
+	// AllowLAN overrides WGServerConfig.AllowLAN for this peer. nil = inherit.
+	AllowLAN *bool `yaml:"allow_lan,omitempty"`
+}
+
+// IsEnabled returns true if the peer is enabled (nil defaults to true).
+func (p WGServerPeer) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// PeerAllowsLAN reports whether peer p may reach the host's local networks.
+func (s WGServerConfig) PeerAllowsLAN(p WGServerPeer) bool {
+	if p.AllowLAN != nil {
+		return *p.AllowLAN
+	}
+	return s.AllowLAN
+}
+
+// hasPeer reports whether sel names a peer by name or public key.
+func (s WGServerConfig) hasPeer(sel string) bool {
+	for _, p := range s.Peers {
+		if strings.EqualFold(p.Name, sel) || p.PublicKey == sel {
+			return true
+		}
+	}
+	return false
+}
+
 // SubscriptionConfig holds configuration for a VLESS subscription URL.
 type SubscriptionConfig struct {
 	// URL is the subscription endpoint that returns base64-encoded proxy URIs.
@@ -348,11 +768,16 @@ type Config struct {
 	Subscriptions map[string]SubscriptionConfig `yaml:"subscriptions,omitempty"`
 	Rules         []Rule                        `yaml:"rules"`
 	DomainRules   []DomainRule                  `yaml:"domain_rules,omitempty"`
+	RuleSets      []RuleSetConfig               `yaml:"rule_sets,omitempty"`
 	DNS           DNSRouteConfig                `yaml:"dns,omitempty"`
 	Logging       LogConfig                     `yaml:"logging,omitempty"`
+	FlowLog       FlowLogConfig                 `yaml:"flow_log,omitempty"`
+	WGServer      WGServerConfig                `yaml:"wg_server,omitempty"`
 	GUI           GUIConfig                     `yaml:"gui,omitempty"`
 	Update        UpdateConfig                  `yaml:"update,omitempty"`
 	AutoBypass    AutoBypassConfig              `yaml:"auto_bypass,omitempty"`
+	IPCAccess     IPCAccessConfig               `yaml:"ipc_access,omitempty"`
+	RemoteAPI     RemoteAPIConfig               `yaml:"remote_api,omitempty"`
 }
 
 // validProtocols is the set of recognized tunnel protocol identifiers.
@@ -365,6 +790,10 @@ var validProtocols = map[string]bool{
 	ProtocolAnyConnect: true,
 	ProtocolHysteria2:  true,
 	ProtocolSSH:        true,
+	ProtocolGlobalProtect: true,
+	ProtocolFortinet:      true,
+	ProtocolOpenVPN:       true,
+	ProtocolTUIC:          true,
 }
 
 // Validate performs basic sanity checks on the configuration.
@@ -386,6 +815,44 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("tunnel %q: duplicate ID", t.ID)
 		}
 		seen[t.ID] = true
+		if err := t.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("tunnel %q: %w", t.ID, err)
+		}
+		if t.MTU != 0 && (t.MTU < 576 || t.MTU > 1500) {
+			return fmt.Errorf("tunnel %q: mtu %d out of range 576-1500", t.ID, t.MTU)
+		}
+		if t.PMTUProbe != "" && t.PMTUProbe != PMTUProbeOff {
+			if a, err := netip.ParseAddr(t.PMTUProbe); err != nil || !a.Is4() {
+				return fmt.Errorf("tunnel %q: pmtu_probe %q is not an IPv4 address", t.ID, t.PMTUProbe)
+			}
+		}
+	}
+	if err := c.Global.RateLimit.Validate(); err != nil {
+		return fmt.Errorf("global: %w", err)
+	}
+	if err := c.Global.Failover.Validate(); err != nil {
+		return fmt.Errorf("global: %w", err)
+	}
+
+	// Validate rule sets.
+	ruleSets := make(map[string]bool, len(c.RuleSets))
+	for i, rs := range c.RuleSets {
+		if rs.Name == "" {
+			return fmt.Errorf("rule_sets[%d]: empty name", i)
+		}
+		if ruleSets[rs.Name] {
+			return fmt.Errorf("rule set %q: duplicate name", rs.Name)
+		}
+		ruleSets[rs.Name] = true
+		if rs.URL == "" && rs.Path == "" {
+			return fmt.Errorf("rule set %q: url or path required", rs.Name)
+		}
+		switch rs.Format {
+		case "", RuleSetFormatAuto, RuleSetFormatDomain, RuleSetFormatIPCIDR,
+			RuleSetFormatClash, RuleSetFormatSingBox, RuleSetFormatSRS:
+		default:
+			return fmt.Errorf("rule set %q: unknown format %q", rs.Name, rs.Format)
+		}
 	}
 
 	// Validate rules reference existing tunnels or are drop-only.
@@ -393,9 +860,38 @@ func (c *Config) Validate() error {
 		if r.Pattern == "" {
 			return fmt.Errorf("rule[%d]: empty pattern", i)
 		}
-		if r.TunnelID != "" && !seen[r.TunnelID] {
+		if err := r.RateLimit.Validate(); err != nil {
+			return fmt.Errorf("rule[%d]: %w", i, err)
+		}
+		switch r.UDPNAT {
+		case "", UDPNATSymmetric, UDPNATFullCone:
+		default:
+			return fmt.Errorf("rule[%d]: unknown udp_nat %q", i, r.UDPNAT)
+		}
+		for _, f := range [...]struct{ name, v string }{{"idle_timeout", r.IdleTimeout}, {"max_lifetime", r.MaxLifetime}} {
+			if f.v == "" {
+				continue
+			}
+			if d, err := time.ParseDuration(f.v); err != nil || d <= 0 {
+				return fmt.Errorf("rule[%d]: invalid %s %q", i, f.name, f.v)
+			}
+		}
+		if tid, _ := SplitPeerTarget(r.TunnelID); tid != "" && !seen[tid] {
 			Log.Warnf("Core", "rule[%d] pattern=%q references unknown tunnel %q", i, r.Pattern, r.TunnelID)
 		}
+		if name, ok := strings.CutPrefix(r.Pattern, "ruleset:"); ok && !ruleSets[name] {
+			Log.Warnf("Core", "rule[%d] references unknown rule set %q", i, name)
+		}
+		if sel, ok := strings.CutPrefix(r.Pattern, PeerRulePrefix); ok && sel != "*" && !c.WGServer.hasPeer(sel) {
+			Log.Warnf("Core", "rule[%d] references unknown wg_server peer %q", i, sel)
+		}
+	}
+
+	if err := c.IPCAccess.Validate(); err != nil {
+		return err
+	}
+	if err := c.RemoteAPI.Validate(); err != nil {
+		return err
 	}
 
 	// Validate domain rules.
@@ -403,8 +899,137 @@ func (c *Config) Validate() error {
 		if dr.Pattern == "" {
 			return fmt.Errorf("domain_rule[%d]: empty pattern", i)
 		}
+		if name, ok := strings.CutPrefix(dr.Pattern, "ruleset:"); ok && !ruleSets[name] {
+			Log.Warnf("Core", "domain_rule[%d] references unknown rule set %q", i, name)
+		}
+	}
+
+	// Validate blocklists.
+	lists := make(map[string]bool, len(c.DNS.Blocklists))
+	for i, bl := range c.DNS.Blocklists {
+		if bl.Name == "" {
+			return fmt.Errorf("dns.blocklists[%d]: empty name", i)
+		}
+		if lists[bl.Name] {
+			return fmt.Errorf("blocklist %q: duplicate name", bl.Name)
+		}
+		lists[bl.Name] = true
+		if bl.URL == "" && bl.Path == "" {
+			return fmt.Errorf("blocklist %q: url or path required", bl.Name)
+		}
+		switch bl.Format {
+		case "", BlocklistFormatAuto, BlocklistFormatHosts, BlocklistFormatAdblock, BlocklistFormatPlain:
+		default:
+			return fmt.Errorf("blocklist %q: unknown format %q", bl.Name, bl.Format)
+		}
+	}
+
+	// Validate split DNS routes.
+	for i, sr := range c.DNS.Split {
+		if len(sr.Domains) == 0 {
+			return fmt.Errorf("dns.split[%d]: no domains", i)
+		}
+		if len(sr.Servers) == 0 && sr.TunnelID == "" {
+			return fmt.Errorf("dns.split[%d]: servers or tunnel_id required", i)
+		}
+		if sr.TunnelID != "" && !seen[sr.TunnelID] {
+			Log.Warnf("Core", "dns.split[%d] references unknown tunnel %q", i, sr.TunnelID)
+		}
+		for _, srv := range sr.Servers {
+			if strings.HasPrefix(srv, "https://") {
+				continue
+			}
+			if _, err := netip.ParseAddr(srv); err != nil {
+				return fmt.Errorf("dns.split[%d]: invalid server %q", i, srv)
+			}
+		}
 	}
 
+	// Validate local DNS records.
+	for i, rec := range c.DNS.Records {
+		if rec.Domain == "" {
+			return fmt.Errorf("dns.records[%d]: empty domain", i)
+		}
+		if len(rec.IPs) == 0 && rec.CNAME == "" {
+			return fmt.Errorf("dns record %q: ips or cname required", rec.Domain)
+		}
+		for _, ip := range rec.IPs {
+			if addr, err := netip.ParseAddr(ip); err != nil || !addr.Is4() {
+				return fmt.Errorf("dns record %q: invalid IPv4 address %q", rec.Domain, ip)
+			}
+		}
+	}
+
+	// Validate flow log export settings.
+	if fl := c.FlowLog; fl.Collector != "" {
+		if _, _, err := net.SplitHostPort(fl.Collector); err != nil {
+			return fmt.Errorf("flow_log.collector: invalid address %q: %w", fl.Collector, err)
+		}
+		switch fl.CollectorFormat {
+		case "", FlowFormatIPFIX, FlowFormatNetFlow9:
+		default:
+			return fmt.Errorf("flow_log.collector_format: unknown format %q", fl.CollectorFormat)
+		}
+	}
+	if c.FlowLog.MaxSizeMB < 0 || c.FlowLog.MaxFiles < 0 {
+		return fmt.Errorf("flow_log: max_size_mb and max_files must not be negative")
+	}
+
+	return c.WGServer.Validate()
+}
+
+// Validate checks the WireGuard server settings and its peer list.
+func (s WGServerConfig) Validate() error {
+	if s.ListenPort < 0 || s.ListenPort > 65535 {
+		return fmt.Errorf("wg_server.listen_port: invalid port %d", s.ListenPort)
+	}
+	var subnet netip.Prefix
+	if s.Address != "" {
+		pfx, err := netip.ParsePrefix(s.Address)
+		if err != nil || !pfx.Addr().Is4() {
+			return fmt.Errorf("wg_server.address: invalid IPv4 prefix %q", s.Address)
+		}
+		subnet = pfx.Masked()
+	}
+	if s.Endpoint != "" {
+		if _, _, err := net.SplitHostPort(s.Endpoint); err != nil {
+			return fmt.Errorf("wg_server.endpoint: invalid address %q: %w", s.Endpoint, err)
+		}
+	}
+
+	names := make(map[string]bool, len(s.Peers))
+	keys := make(map[string]bool, len(s.Peers))
+	addrs := make(map[netip.Addr]bool, len(s.Peers))
+	for i, p := range s.Peers {
+		if p.Name == "" {
+			return fmt.Errorf("wg_server.peers[%d]: empty name", i)
+		}
+		if names[strings.ToLower(p.Name)] {
+			return fmt.Errorf("wg_server peer %q: duplicate name", p.Name)
+		}
+		names[strings.ToLower(p.Name)] = true
+		if p.PublicKey == "" {
+			return fmt.Errorf("wg_server peer %q: empty public_key", p.Name)
+		}
+		if keys[p.PublicKey] {
+			return fmt.Errorf("wg_server peer %q: duplicate public_key", p.Name)
+		}
+		keys[p.PublicKey] = true
+		if p.Address == "" {
+			continue
+		}
+		ip, err := netip.ParseAddr(p.Address)
+		if err != nil || !ip.Is4() {
+			return fmt.Errorf("wg_server peer %q: invalid IPv4 address %q", p.Name, p.Address)
+		}
+		if subnet.IsValid() && !subnet.Contains(ip) {
+			return fmt.Errorf("wg_server peer %q: address %s is outside %s", p.Name, ip, subnet)
+		}
+		if addrs[ip] {
+			return fmt.Errorf("wg_server peer %q: duplicate address %s", p.Name, ip)
+		}
+		addrs[ip] = true
+	}
 	return nil
 }
 
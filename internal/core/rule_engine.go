@@ -12,18 +12,22 @@ import (
 // MatchResult holds the routing decision for a process.
 type MatchResult struct {
 	Matched  bool
+	Pattern  string // pattern of the matched rule
 	TunnelID string
+	Peer     string // peer selector for "tunnel_id/peer" targets, else empty
 	Fallback FallbackPolicy
 	Priority RulePriority
+	FullCone bool // udp_nat: full_cone
 }
 
 // RuleEngine evaluates process paths against configured rules.
 type RuleEngine struct {
 	mu            sync.RWMutex
 	rules         []Rule
-	rulesLower    []string         // pre-lowercased patterns, parallel to rules
-	regexCache    []*regexp.Regexp // compiled regex patterns, parallel to rules (nil for non-regex)
-	activeTunnels map[string]bool  // set of connected tunnel IDs
+	rulesLower    []string            // pre-lowercased patterns, parallel to rules
+	regexCache    []*regexp.Regexp    // compiled regex patterns, parallel to rules (nil for non-regex)
+	ruleSets      map[string][]string // rule set name → lowercased process patterns
+	activeTunnels map[string]bool     // set of connected tunnel IDs
 	bus           *EventBus
 	matcher       *process.Matcher
 }
@@ -44,6 +48,20 @@ func compileRegexPatterns(rules []Rule) []*regexp.Regexp {
 	return result
 }
 
+// matchResult builds the routing decision for a matched rule.
+func matchResult(rule Rule) MatchResult {
+	tunnelID, peer := SplitPeerTarget(rule.TunnelID)
+	return MatchResult{
+		Matched:  true,
+		Pattern:  rule.Pattern,
+		TunnelID: tunnelID,
+		Peer:     peer,
+		Fallback: rule.Fallback,
+		Priority: rule.Priority,
+		FullCone: rule.UDPNAT == UDPNATFullCone,
+	}
+}
+
 // NewRuleEngine creates a rule engine with the given initial rules.
 func NewRuleEngine(rules []Rule, bus *EventBus, matcher *process.Matcher) *RuleEngine {
 	lower := make([]string, len(rules))
@@ -74,19 +92,8 @@ func (re *RuleEngine) Match(exePath string) MatchResult {
 		if !rule.IsEnabled() {
 			continue // skip disabled rule
 		}
-		var matched bool
-		if re.regexCache[i] != nil {
-			matched = re.regexCache[i].MatchString(exeLower)
-		} else {
-			matched = process.MatchPreprocessed(exeLower, baseLower, rule.Pattern, re.rulesLower[i])
-		}
-		if matched {
-			return MatchResult{
-				Matched:  true,
-				TunnelID: rule.TunnelID,
-				Fallback: rule.Fallback,
-				Priority: rule.Priority,
-			}
+		if re.matchAt(i, exeLower, baseLower) {
+			return matchResult(rule)
 		}
 	}
 
@@ -104,19 +111,8 @@ func (re *RuleEngine) MatchPreLowered(exeLower, baseLower string) MatchResult {
 		if !rule.IsEnabled() {
 			continue // skip disabled rule
 		}
-		var matched bool
-		if re.regexCache[i] != nil {
-			matched = re.regexCache[i].MatchString(exeLower)
-		} else {
-			matched = process.MatchPreprocessed(exeLower, baseLower, rule.Pattern, re.rulesLower[i])
-		}
-		if matched {
-			return MatchResult{
-				Matched:  true,
-				TunnelID: rule.TunnelID,
-				Fallback: rule.Fallback,
-				Priority: rule.Priority,
-			}
+		if re.matchAt(i, exeLower, baseLower) {
+			return matchResult(rule)
 		}
 	}
 
@@ -135,25 +131,54 @@ func (re *RuleEngine) MatchPreLoweredFrom(exeLower, baseLower string, startIdx i
 		if !re.rules[i].IsEnabled() {
 			continue // skip disabled rule
 		}
-		var matched bool
-		if re.regexCache[i] != nil {
-			matched = re.regexCache[i].MatchString(exeLower)
-		} else {
-			matched = process.MatchPreprocessed(exeLower, baseLower, re.rules[i].Pattern, re.rulesLower[i])
+		if re.matchAt(i, exeLower, baseLower) {
+			return matchResult(re.rules[i]), i
 		}
-		if matched {
-			return MatchResult{
-				Matched:  true,
-				TunnelID: re.rules[i].TunnelID,
-				Fallback: re.rules[i].Fallback,
-				Priority: re.rules[i].Priority,
-			}, i
+	}
+
+	return MatchResult{Matched: false}, -1
+}
+
+// MatchPeerFrom finds the first "peer:" rule matching a wg_server peer,
+// starting from startIdx. The selector is compared case-insensitively with
+// the peer name, or exactly with its public key; "peer:*" matches any peer.
+// Returns the match result and the index where the match was found.
+func (re *RuleEngine) MatchPeerFrom(name, publicKey string, startIdx int) (MatchResult, int) {
+	re.mu.RLock()
+	defer re.mu.RUnlock()
+
+	for i := startIdx; i < len(re.rules); i++ {
+		if !re.rules[i].IsEnabled() || !strings.HasPrefix(re.rulesLower[i], PeerRulePrefix) {
+			continue
+		}
+		sel := re.rules[i].Pattern[len(PeerRulePrefix):]
+		if sel == "*" || strings.EqualFold(sel, name) || sel == publicKey {
+			return matchResult(re.rules[i]), i
 		}
 	}
 
 	return MatchResult{Matched: false}, -1
 }
 
+// matchAt reports whether rule i matches. Must be called with re.mu held.
+func (re *RuleEngine) matchAt(i int, exeLower, baseLower string) bool {
+	if re.regexCache[i] != nil {
+		return re.regexCache[i].MatchString(exeLower)
+	}
+	if strings.HasPrefix(re.rulesLower[i], PeerRulePrefix) {
+		return false // matches wg_server peers only, see MatchPeerFrom
+	}
+	if strings.HasPrefix(re.rulesLower[i], "ruleset:") {
+		for _, p := range re.ruleSets[re.rules[i].Pattern[8:]] {
+			if process.MatchPreprocessed(exeLower, baseLower, p, p) {
+				return true
+			}
+		}
+		return false
+	}
+	return process.MatchPreprocessed(exeLower, baseLower, re.rules[i].Pattern, re.rulesLower[i])
+}
+
 // MatchByPID resolves PID to exe path and then matches.
 func (re *RuleEngine) MatchByPID(pid uint32) MatchResult {
 	exePath, ok := re.matcher.GetExePath(pid)
@@ -181,6 +206,22 @@ func (re *RuleEngine) SetRules(rules []Rule) {
 	Log.Infof("Rule", "Updated %d rules", len(rules))
 }
 
+// SetRuleSets replaces the process patterns referenced by "ruleset:<name>" rules.
+func (re *RuleEngine) SetRuleSets(sets map[string][]string) {
+	lowered := make(map[string][]string, len(sets))
+	for name, patterns := range sets {
+		lp := make([]string, len(patterns))
+		for i, p := range patterns {
+			lp[i] = strings.ToLower(p)
+		}
+		lowered[name] = lp
+	}
+
+	re.mu.Lock()
+	re.ruleSets = lowered
+	re.mu.Unlock()
+}
+
 // AddRule appends a rule and notifies subscribers.
 func (re *RuleEngine) AddRule(rule Rule) {
 	var compiled *regexp.Regexp
@@ -247,7 +288,9 @@ func (re *RuleEngine) SetTunnelActive(tunnelID string, active bool) {
 }
 
 // IsTunnelActive returns true if the tunnel is marked as connected.
+// A "tunnel_id/peer" target is active when its tunnel is.
 func (re *RuleEngine) IsTunnelActive(tunnelID string) bool {
+	tunnelID, _ = SplitPeerTarget(tunnelID)
 	re.mu.RLock()
 	defer re.mu.RUnlock()
 	return re.activeTunnels[tunnelID]
@@ -11,7 +11,8 @@ import (
 	"time"
 )
 
-// SubscriptionManager fetches and parses VLESS subscription URLs,
+// SubscriptionManager fetches and parses subscription URLs (VLESS and
+// WireGuard/AmneziaWG share links),
 // converting them into TunnelConfig entries.
 type SubscriptionManager struct {
 	mu         sync.RWMutex
@@ -21,13 +22,13 @@ type SubscriptionManager struct {
 	stopFns    map[string]context.CancelFunc
 	// cache stores the last fetched tunnels per subscription name.
 	cache map[string][]TunnelConfig
-	// parseURI is the function used to parse vless:// URIs into TunnelConfig.
-	// Injected to avoid circular dependency with the vless package.
+	// parseURI is the function used to parse share URIs into TunnelConfig.
+	// Injected to avoid circular dependency with the provider packages.
 	parseURI func(uri string) (TunnelConfig, error)
 }
 
 // NewSubscriptionManager creates a new subscription manager.
-// parseURI converts a single vless:// URI string into a TunnelConfig.
+// parseURI converts a single share URI (see subscriptionSchemes) into a TunnelConfig.
 func NewSubscriptionManager(
 	cfgMgr *ConfigManager,
 	bus *EventBus,
@@ -158,6 +159,18 @@ func (sm *SubscriptionManager) fetch(ctx context.Context, sub SubscriptionConfig
 	return body, nil
 }
 
+// subscriptionSchemes are the share URI prefixes accepted in subscriptions.
+var subscriptionSchemes = []string{"vless://", "vmess://", "wg://", "wireguard://", "vpn://", "hysteria2://", "hy2://", "tuic://"}
+
+func isSubscriptionURI(line string) bool {
+	for _, p := range subscriptionSchemes {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
 // parse decodes subscription content and converts URIs to TunnelConfigs.
 func (sm *SubscriptionManager) parse(name string, sub SubscriptionConfig, data []byte) ([]TunnelConfig, error) {
 	// Try base64 decode (standard, then URL-safe).
@@ -182,9 +195,8 @@ func (sm *SubscriptionManager) parse(name string, sub SubscriptionConfig, data [
 		if line == "" {
 			continue
 		}
-		// Only support vless:// URIs for now.
-		if !strings.HasPrefix(line, "vless://") {
-			Log.Debugf("Sub", "Skipping non-vless URI in subscription %q: %.40s...", name, line)
+		if !isSubscriptionURI(line) {
+			Log.Debugf("Sub", "Skipping unsupported URI in subscription %q: %.40s...", name, line)
 			continue
 		}
 
@@ -210,7 +222,7 @@ func (sm *SubscriptionManager) parse(name string, sub SubscriptionConfig, data [
 	}
 
 	if len(tunnels) == 0 {
-		return nil, fmt.Errorf("no valid vless:// URIs found in subscription %q", name)
+		return nil, fmt.Errorf("no valid URIs found in subscription %q", name)
 	}
 
 	return tunnels, nil
@@ -2,6 +2,7 @@ package daemon
 
 import (
 	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/ipc"
 )
 
 // RunConfig provides optional hooks for the daemon controller mode (macOS).
@@ -11,4 +12,8 @@ type RunConfig struct {
 	// RegisterService is called after the Service is created.
 	// Returns a deregister function to call during shutdown.
 	RegisterService func(svc vpnapi.VPNServiceServer) func()
+
+	// Authorizer guards the controller's gRPC server. runVPN refreshes its
+	// policy when the config is reloaded.
+	Authorizer *ipc.Authorizer
 }
@@ -12,6 +12,7 @@ import (
 	"google.golang.org/grpc"
 
 	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/ipc"
 	"awg-split-tunnel/internal/platform"
 )
@@ -63,6 +64,7 @@ type Controller struct {
 	listener    net.Listener
 	grpcServer  *grpc.Server
 	connTracker *ipc.ConnTracker
+	authz       *ipc.Authorizer
 	delegator   *ServiceDelegator
 	idleSvc     *IdleService
 
@@ -80,6 +82,9 @@ type ControllerConfig struct {
 	KeepAlive   bool          // from config: gui.keep_alive_on_disconnect
 	GracePeriod time.Duration // 0 = 30s default
 	Listener    net.Listener  // inherited from launchd (or created manually)
+
+	// Authorizer enforces IPC roles; nil builds one from the default policy.
+	Authorizer *ipc.Authorizer
 }
 
 // NewController creates a new daemon controller.
@@ -101,6 +106,11 @@ func NewController(cfg ControllerConfig) *Controller {
 		shutdownCh:  make(chan struct{}),
 	}
 
+	ctrl.authz = cfg.Authorizer
+	if ctrl.authz == nil {
+		ctrl.authz = ipc.NewAuthorizer(ipc.NewPolicy(core.IPCAccessConfig{}))
+	}
+
 	ctrl.idleSvc = NewIdleService(ctrl, cfg.Version)
 	ctrl.delegator = NewServiceDelegator(ctrl.idleSvc)
 
@@ -116,8 +126,9 @@ func NewController(cfg ControllerConfig) *Controller {
 // Returns nil on clean exit.
 func (c *Controller) Run() error {
 	c.grpcServer = grpc.NewServer(
-		grpc.ChainUnaryInterceptor(c.connTracker.UnaryInterceptor()),
-		grpc.ChainStreamInterceptor(c.connTracker.StreamInterceptor()),
+		grpc.Creds(ipc.PeerCredentials()),
+		grpc.ChainUnaryInterceptor(c.authz.UnaryInterceptor(), c.connTracker.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(c.authz.StreamInterceptor(), c.connTracker.StreamInterceptor()),
 	)
 	vpnapi.RegisterVPNServiceServer(c.grpcServer, c.delegator)
 
@@ -183,6 +194,7 @@ func (c *Controller) Activate() error {
 					log.Printf("[Daemon] VPN service deregistered")
 				}
 			},
+			Authorizer: c.authz,
 		})
 		c.vpnDoneCh <- err
 
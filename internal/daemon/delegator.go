@@ -129,6 +129,50 @@ func (d *ServiceDelegator) RenameTunnel(ctx context.Context, req *vpnapi.RenameT
 	return d.current().RenameTunnel(ctx, req)
 }
 
+func (d *ServiceDelegator) GenerateWireGuardKeys(ctx context.Context, req *vpnapi.WireGuardKeyRequest) (*vpnapi.WireGuardKeyResponse, error) {
+	return d.current().GenerateWireGuardKeys(ctx, req)
+}
+
+func (d *ServiceDelegator) ImportWireGuardConfig(ctx context.Context, req *vpnapi.ImportWireGuardRequest) (*vpnapi.ImportWireGuardResponse, error) {
+	return d.current().ImportWireGuardConfig(ctx, req)
+}
+
+func (d *ServiceDelegator) ExportWireGuardConfig(ctx context.Context, req *vpnapi.ExportWireGuardRequest) (*vpnapi.ExportWireGuardResponse, error) {
+	return d.current().ExportWireGuardConfig(ctx, req)
+}
+
+func (d *ServiceDelegator) GetTunnelPeers(ctx context.Context, req *vpnapi.TunnelPeersRequest) (*vpnapi.TunnelPeersResponse, error) {
+	return d.current().GetTunnelPeers(ctx, req)
+}
+
+// --- WireGuard server ---
+
+func (d *ServiceDelegator) GetWGServerStatus(ctx context.Context, req *emptypb.Empty) (*vpnapi.WGServerStatusResponse, error) {
+	return d.current().GetWGServerStatus(ctx, req)
+}
+
+func (d *ServiceDelegator) AddWGServerPeer(ctx context.Context, req *vpnapi.AddWGServerPeerRequest) (*vpnapi.AddWGServerPeerResponse, error) {
+	return d.current().AddWGServerPeer(ctx, req)
+}
+
+func (d *ServiceDelegator) RemoveWGServerPeer(ctx context.Context, req *vpnapi.RemoveWGServerPeerRequest) (*vpnapi.RemoveWGServerPeerResponse, error) {
+	return d.current().RemoveWGServerPeer(ctx, req)
+}
+
+func (d *ServiceDelegator) GetWGServerClientConfig(ctx context.Context, req *vpnapi.WGServerClientConfigRequest) (*vpnapi.WGServerClientConfigResponse, error) {
+	return d.current().GetWGServerClientConfig(ctx, req)
+}
+
+// --- Bandwidth shaping ---
+
+func (d *ServiceDelegator) GetRateLimits(ctx context.Context, req *emptypb.Empty) (*vpnapi.RateLimitsResponse, error) {
+	return d.current().GetRateLimits(ctx, req)
+}
+
+func (d *ServiceDelegator) SetRateLimit(ctx context.Context, req *vpnapi.SetRateLimitRequest) (*vpnapi.SetRateLimitResponse, error) {
+	return d.current().SetRateLimit(ctx, req)
+}
+
 // --- Rules ---
 
 func (d *ServiceDelegator) ListRules(ctx context.Context, req *emptypb.Empty) (*vpnapi.RuleListResponse, error) {
@@ -139,6 +183,10 @@ func (d *ServiceDelegator) SaveRules(ctx context.Context, req *vpnapi.SaveRulesR
 	return d.current().SaveRules(ctx, req)
 }
 
+func (d *ServiceDelegator) ImportRules(ctx context.Context, req *vpnapi.ImportRulesRequest) (*vpnapi.ImportRulesResponse, error) {
+	return d.current().ImportRules(ctx, req)
+}
+
 // --- Domain rules ---
 
 func (d *ServiceDelegator) ListDomainRules(ctx context.Context, req *emptypb.Empty) (*vpnapi.DomainRuleListResponse, error) {
@@ -231,6 +279,14 @@ func (d *ServiceDelegator) FlushDNS(ctx context.Context, req *emptypb.Empty) (*v
 	return d.current().FlushDNS(ctx, req)
 }
 
+func (d *ServiceDelegator) StreamDNSQueries(req *vpnapi.DNSQueryStreamRequest, stream vpnapi.VPNService_StreamDNSQueriesServer) error {
+	return d.current().StreamDNSQueries(req, stream)
+}
+
+func (d *ServiceDelegator) GetDNSQueryStats(ctx context.Context, req *vpnapi.DNSQueryStatsRequest) (*vpnapi.DNSQueryStatsResponse, error) {
+	return d.current().GetDNSQueryStats(ctx, req)
+}
+
 // --- Updates ---
 
 func (d *ServiceDelegator) CheckUpdate(ctx context.Context, req *emptypb.Empty) (*vpnapi.CheckUpdateResponse, error) {
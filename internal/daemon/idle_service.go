@@ -114,6 +114,46 @@ func (s *IdleService) RenameTunnel(_ context.Context, _ *vpnapi.RenameTunnelRequ
 	return nil, errIdle
 }
 
+func (s *IdleService) GenerateWireGuardKeys(_ context.Context, _ *vpnapi.WireGuardKeyRequest) (*vpnapi.WireGuardKeyResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) ImportWireGuardConfig(_ context.Context, _ *vpnapi.ImportWireGuardRequest) (*vpnapi.ImportWireGuardResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) ExportWireGuardConfig(_ context.Context, _ *vpnapi.ExportWireGuardRequest) (*vpnapi.ExportWireGuardResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) GetTunnelPeers(_ context.Context, _ *vpnapi.TunnelPeersRequest) (*vpnapi.TunnelPeersResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) GetWGServerStatus(_ context.Context, _ *emptypb.Empty) (*vpnapi.WGServerStatusResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) AddWGServerPeer(_ context.Context, _ *vpnapi.AddWGServerPeerRequest) (*vpnapi.AddWGServerPeerResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) RemoveWGServerPeer(_ context.Context, _ *vpnapi.RemoveWGServerPeerRequest) (*vpnapi.RemoveWGServerPeerResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) GetWGServerClientConfig(_ context.Context, _ *vpnapi.WGServerClientConfigRequest) (*vpnapi.WGServerClientConfigResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) GetRateLimits(_ context.Context, _ *emptypb.Empty) (*vpnapi.RateLimitsResponse, error) {
+	return nil, errIdle
+}
+
+func (s *IdleService) SetRateLimit(_ context.Context, _ *vpnapi.SetRateLimitRequest) (*vpnapi.SetRateLimitResponse, error) {
+	return nil, errIdle
+}
+
 func (s *IdleService) ListRules(_ context.Context, _ *emptypb.Empty) (*vpnapi.RuleListResponse, error) {
 	return nil, errIdle
 }
@@ -122,6 +162,10 @@ func (s *IdleService) SaveRules(_ context.Context, _ *vpnapi.SaveRulesRequest) (
 	return nil, errIdle
 }
 
+func (s *IdleService) ImportRules(_ context.Context, _ *vpnapi.ImportRulesRequest) (*vpnapi.ImportRulesResponse, error) {
+	return nil, errIdle
+}
+
 func (s *IdleService) ListDomainRules(_ context.Context, _ *emptypb.Empty) (*vpnapi.DomainRuleListResponse, error) {
 	return nil, errIdle
 }
@@ -190,6 +234,14 @@ func (s *IdleService) FlushDNS(_ context.Context, _ *emptypb.Empty) (*vpnapi.Con
 	return nil, errIdle
 }
 
+func (s *IdleService) StreamDNSQueries(_ *vpnapi.DNSQueryStreamRequest, _ vpnapi.VPNService_StreamDNSQueriesServer) error {
+	return errIdle
+}
+
+func (s *IdleService) GetDNSQueryStats(_ context.Context, _ *vpnapi.DNSQueryStatsRequest) (*vpnapi.DNSQueryStatsResponse, error) {
+	return nil, errIdle
+}
+
 func (s *IdleService) CheckUpdate(_ context.Context, _ *emptypb.Empty) (*vpnapi.CheckUpdateResponse, error) {
 	return nil, errIdle
 }
@@ -0,0 +1,307 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// blocklistDefaultRefresh is used when a blocklist has no refresh_interval.
+	blocklistDefaultRefresh = 24 * time.Hour
+	// blocklistMaxSize limits the downloaded list body.
+	blocklistMaxSize = 32 << 20
+)
+
+// BlocklistEntry is a single parsed blocklist line.
+type BlocklistEntry struct {
+	Type  string // "domain" (with subdomains) or "full" (exact)
+	Value string
+	Allow bool // exception (e.g. "@@||example.com^")
+}
+
+// ParseBlocklist parses a blocklist in the given format ("auto", "hosts",
+// "adblock", "plain"). Lines that cannot be represented as domain rules
+// (URL paths, cosmetic filters, regexes) are skipped.
+func ParseBlocklist(data []byte, format string) []BlocklistEntry {
+	if format == "" {
+		format = core.BlocklistFormatAuto
+	}
+
+	var entries []BlocklistEntry
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == '!' || line[0] == '[' {
+			continue
+		}
+
+		lineFormat := format
+		if lineFormat == core.BlocklistFormatAuto {
+			lineFormat = detectBlocklistLine(line)
+		}
+
+		switch lineFormat {
+		case core.BlocklistFormatHosts:
+			entries = appendHostsLine(entries, line)
+		case core.BlocklistFormatAdblock:
+			if e, ok := parseAdblockLine(line); ok {
+				entries = append(entries, e)
+			}
+		case core.BlocklistFormatPlain:
+			if e, ok := parsePlainLine(line); ok {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries
+}
+
+// detectBlocklistLine guesses the format of a single line.
+func detectBlocklistLine(line string) string {
+	if strings.HasPrefix(line, "||") || strings.HasPrefix(line, "@@") || strings.HasPrefix(line, "|") {
+		return core.BlocklistFormatAdblock
+	}
+	if i := strings.IndexAny(line, " \t"); i > 0 {
+		if _, err := netip.ParseAddr(line[:i]); err == nil {
+			return core.BlocklistFormatHosts
+		}
+	}
+	return core.BlocklistFormatPlain
+}
+
+// hostsIgnored lists hostnames commonly present in hosts files that must never be blocked.
+var hostsIgnored = map[string]bool{
+	"localhost":             true,
+	"localhost.localdomain": true,
+	"local":                 true,
+	"broadcasthost":         true,
+	"ip6-localhost":         true,
+	"ip6-loopback":          true,
+	"0.0.0.0":               true,
+}
+
+// appendHostsLine parses "IP host1 host2 # comment" into exact-match entries.
+func appendHostsLine(entries []BlocklistEntry, line string) []BlocklistEntry {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return entries
+	}
+	if _, err := netip.ParseAddr(fields[0]); err != nil {
+		return entries
+	}
+	for _, host := range fields[1:] {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+		if hostsIgnored[host] || !isValidListDomain(host) {
+			continue
+		}
+		entries = append(entries, BlocklistEntry{Type: "full", Value: host})
+	}
+	return entries
+}
+
+// parseAdblockLine parses AdGuard/ABP network rules of the form
+// "||example.com^", "||example.com^$important" and "@@||example.com^".
+func parseAdblockLine(line string) (BlocklistEntry, bool) {
+	var e BlocklistEntry
+	if strings.HasPrefix(line, "@@") {
+		e.Allow = true
+		line = line[2:]
+	}
+
+	// Only modifiers that do not narrow the rule are accepted.
+	if i := strings.IndexByte(line, '$'); i >= 0 {
+		for _, opt := range strings.Split(line[i+1:], ",") {
+			if opt != "important" && opt != "all" {
+				return e, false
+			}
+		}
+		line = line[:i]
+	}
+
+	switch {
+	case strings.HasPrefix(line, "||"):
+		e.Type = "domain"
+		line = line[2:]
+	case strings.HasPrefix(line, "|") && strings.HasSuffix(line, "|"):
+		e.Type = "full"
+		line = strings.Trim(line, "|")
+	default:
+		return e, false
+	}
+	line = strings.TrimSuffix(line, "^")
+	line = strings.ToLower(strings.TrimSuffix(line, "."))
+	if !isValidListDomain(line) {
+		return e, false
+	}
+	e.Value = line
+	return e, true
+}
+
+// parsePlainLine parses a bare domain. "*.example.com" and ".example.com"
+// match subdomains; bare names match exactly.
+func parsePlainLine(line string) (BlocklistEntry, bool) {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	e := BlocklistEntry{Type: "full"}
+	switch {
+	case strings.HasPrefix(line, "*."):
+		e.Type = "domain"
+		line = line[2:]
+	case strings.HasPrefix(line, "."):
+		e.Type = "domain"
+		line = line[1:]
+	}
+	line = strings.ToLower(strings.TrimSuffix(line, "."))
+	if !isValidListDomain(line) {
+		return e, false
+	}
+	e.Value = line
+	return e, true
+}
+
+// isValidListDomain reports whether s looks like a multi-label hostname.
+func isValidListDomain(s string) bool {
+	if len(s) == 0 || len(s) > 253 || !strings.Contains(s, ".") {
+		return false
+	}
+	if _, err := netip.ParseAddr(s); err == nil {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '-' || c == '.' || c == '_' {
+			continue
+		}
+		return false
+	}
+	return s[0] != '.' && s[len(s)-1] != '.'
+}
+
+// ---------------------------------------------------------------------------
+// BlocklistManager
+// ---------------------------------------------------------------------------
+
+// BlocklistManager loads configured blocklists, keeps a cached copy of each
+// remote list on disk and refreshes them on their interval. Whenever a list
+// changes, the onUpdate callback is invoked so the domain matcher can be rebuilt.
+type BlocklistManager struct {
+	remote *remoteLists
+
+	mu        sync.RWMutex
+	configs   []core.BlocklistConfig
+	allowlist []string
+	lists     map[string][]BlocklistEntry
+}
+
+// NewBlocklistManager creates a manager that caches downloaded lists in cacheDir.
+// httpClient may be nil — falls back to http.DefaultClient.
+func NewBlocklistManager(cacheDir string, httpClient *http.Client) *BlocklistManager {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	m := &BlocklistManager{lists: make(map[string][]BlocklistEntry)}
+	m.remote = &remoteLists{
+		kind:           "blocklist",
+		logTag:         "DNS",
+		goName:         "dns.blocklist",
+		cacheExt:       ".txt",
+		maxSize:        blocklistMaxSize,
+		defaultRefresh: blocklistDefaultRefresh,
+		httpClient:     httpClient,
+		cacheDir:       cacheDir,
+		store:          m.store,
+		stopFns:        make(map[string]context.CancelFunc),
+	}
+	return m
+}
+
+// SetOnUpdate sets the callback invoked after a list is refreshed in the background.
+func (m *BlocklistManager) SetOnUpdate(fn func()) {
+	m.remote.setOnUpdate(fn)
+}
+
+// Configure applies a new blocklist configuration. Lists are loaded from the
+// on-disk cache (or local path) synchronously; lists without a cached copy are
+// downloaded in the background. Refresh loops are restarted.
+func (m *BlocklistManager) Configure(ctx context.Context, configs []core.BlocklistConfig, allowlist []string) {
+	sources := make([]listSource, len(configs))
+	m.mu.Lock()
+	m.configs = configs
+	m.allowlist = allowlist
+	lists := make(map[string][]BlocklistEntry, len(configs))
+	for i, bl := range configs {
+		if prev, ok := m.lists[bl.Name]; ok {
+			lists[bl.Name] = prev
+		}
+		sources[i] = listSource{
+			Name:            bl.Name,
+			URL:             bl.URL,
+			Path:            bl.Path,
+			Format:          bl.Format,
+			RefreshInterval: bl.RefreshInterval,
+			Enabled:         bl.IsEnabled(),
+		}
+	}
+	m.lists = lists
+	m.mu.Unlock()
+
+	m.remote.configure(ctx, sources)
+}
+
+// Stop halts all refresh loops.
+func (m *BlocklistManager) Stop() {
+	m.remote.stop()
+}
+
+// Refresh re-reads (local) or re-downloads (remote) a single list and
+// invokes the update callback.
+func (m *BlocklistManager) Refresh(ctx context.Context, name string) error {
+	return m.remote.refresh(ctx, name)
+}
+
+// Expanded returns all loaded block entries as matcher entries (tagged with
+// a "blocklist:<name>" pattern) and the combined allowlist patterns
+// (config allowlist plus list exceptions).
+func (m *BlocklistManager) Expanded() ([]GeositeExpanded, []string) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var entries []GeositeExpanded
+	allow := append([]string(nil), m.allowlist...)
+	for _, bl := range m.configs {
+		if !bl.IsEnabled() {
+			continue
+		}
+		rule := core.DomainRule{Pattern: "blocklist:" + bl.Name, Action: core.DomainBlock}
+		for _, e := range m.lists[bl.Name] {
+			if e.Allow {
+				allow = append(allow, e.Type+":"+e.Value)
+				continue
+			}
+			entries = append(entries, GeositeExpanded{Type: e.Type, Value: e.Value, Rule: rule})
+		}
+	}
+	return entries, allow
+}
+
+// store parses data and replaces the list. Returns the number of entries.
+func (m *BlocklistManager) store(src listSource, data []byte) (int, error) {
+	entries := ParseBlocklist(data, src.Format)
+	m.mu.Lock()
+	m.lists[src.Name] = entries
+	m.mu.Unlock()
+	return len(entries), nil
+}
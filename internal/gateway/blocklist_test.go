@@ -0,0 +1,108 @@
+package gateway
+
+import (
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestParseBlocklist_Auto(t *testing.T) {
+	data := []byte(`! AdGuard comment
+# hosts comment
+0.0.0.0 ads.example.com tracker.example.com # trailing
+127.0.0.1 localhost
+||doubleclick.net^
+||metrics.example.org^$important
+||example.net^$third-party
+@@||ok.doubleclick.net^
+*.telemetry.example.com
+plain.example.com
+/banner/*
+`)
+	entries := ParseBlocklist(data, core.BlocklistFormatAuto)
+
+	want := []BlocklistEntry{
+		{Type: "full", Value: "ads.example.com"},
+		{Type: "full", Value: "tracker.example.com"},
+		{Type: "domain", Value: "doubleclick.net"},
+		{Type: "domain", Value: "metrics.example.org"},
+		{Type: "domain", Value: "ok.doubleclick.net", Allow: true},
+		{Type: "domain", Value: "telemetry.example.com"},
+		{Type: "full", Value: "plain.example.com"},
+	}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries %v, want %d", len(entries), entries, len(want))
+	}
+	for i := range want {
+		if entries[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, entries[i], want[i])
+		}
+	}
+}
+
+func TestDomainMatcher_AllowlistOverridesLists(t *testing.T) {
+	rule := core.DomainRule{Pattern: "blocklist:ads", Action: core.DomainBlock}
+	lists := []GeositeExpanded{
+		{Type: "domain", Value: "doubleclick.net", Rule: rule},
+		{Type: "domain", Value: "example.com", Rule: rule},
+	}
+	userRules := []core.DomainRule{
+		{Pattern: "domain:cdn.example.com", TunnelID: "vpn", Action: core.DomainRoute},
+	}
+	m := NewDomainMatcherWithLists(userRules, nil, lists, []string{"domain:ok.doubleclick.net", "full:example.com"})
+
+	if r := m.Match("ads.doubleclick.net"); !r.Matched || r.Action != core.DomainBlock {
+		t.Errorf("ads.doubleclick.net: expected block, got %+v", r)
+	}
+	if r := m.Match("x.ok.doubleclick.net"); r.Matched {
+		t.Errorf("x.ok.doubleclick.net: expected allowlisted, got %+v", r)
+	}
+	if r := m.Match("example.com"); r.Matched {
+		t.Errorf("example.com: expected allowlisted, got %+v", r)
+	}
+	if r := m.Match("www.example.com"); !r.Matched || r.Action != core.DomainBlock {
+		t.Errorf("www.example.com: expected block, got %+v", r)
+	}
+	if r := m.Match("a.cdn.example.com"); r.Action != core.DomainRoute || r.TunnelID != "vpn" {
+		t.Errorf("a.cdn.example.com: expected user rule, got %+v", r)
+	}
+}
+
+func TestDomainMatcher_ListsHaveLowestPrecedence(t *testing.T) {
+	rule := core.DomainRule{Pattern: "blocklist:hosts", Action: core.DomainBlock}
+	lists := []GeositeExpanded{
+		{Type: "full", Value: "foo.example.com", Rule: rule},
+		{Type: "domain", Value: "deep.sub.example.org", Rule: rule},
+		{Type: "full", Value: "tracker.net", Rule: rule},
+	}
+	userRules := []core.DomainRule{
+		{Pattern: "domain:example.com", TunnelID: "vpn", Action: core.DomainRoute},
+		{Pattern: "domain:example.org", Action: core.DomainDirect},
+	}
+	m := NewDomainMatcherWithLists(userRules, nil, lists, nil)
+
+	// A more specific list entry must not override a broader user rule.
+	if r := m.Match("foo.example.com"); r.Action != core.DomainRoute || r.TunnelID != "vpn" {
+		t.Errorf("foo.example.com: expected user route, got %+v", r)
+	}
+	if r := m.Match("x.deep.sub.example.org"); r.Action != core.DomainDirect {
+		t.Errorf("x.deep.sub.example.org: expected user direct, got %+v", r)
+	}
+	// Without a rule, the list applies.
+	if r := m.Match("tracker.net"); !r.Matched || r.Action != core.DomainBlock {
+		t.Errorf("tracker.net: expected block, got %+v", r)
+	}
+}
+
+func TestBuildLocalResponse_CNAME(t *testing.T) {
+	q := buildNameQuery(0x1234, "nas.home.lan", 1)
+	resp := buildLocalResponse(q, []string{"storage.home.lan"}, [][4]byte{{192, 168, 1, 10}}, 60)
+
+	if got := dnsRcode(resp); got != DNSRcodeNoError {
+		t.Fatalf("rcode = %d, want 0", got)
+	}
+	ips := extractARecords(resp)
+	if len(ips) != 1 || ips[0] != [4]byte{192, 168, 1, 10} {
+		t.Fatalf("A records = %v", ips)
+	}
+}
@@ -0,0 +1,286 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"net/netip"
+	"strings"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// localRecordDefaultTTL is used for local records without an explicit TTL.
+	localRecordDefaultTTL = 300
+	// localCNAMEMaxHops limits CNAME chains between local records.
+	localCNAMEMaxHops = 8
+)
+
+// localRecord is a compiled static DNS record.
+type localRecord struct {
+	domain string // as configured, e.g. "*.home.lan"
+	ips    [][4]byte
+	cname  string
+	ttl    uint32
+}
+
+// LocalRecords holds static DNS records answered without querying upstream.
+// Immutable after creation — swap atomically via DNSResolver.SetLocalRecords.
+type LocalRecords struct {
+	exact  map[string]*localRecord
+	suffix map[string]*localRecord // "*.example.com" stored as "example.com"
+}
+
+// NewLocalRecords compiles configured local records. Invalid entries are skipped.
+func NewLocalRecords(records []core.DNSLocalRecord) *LocalRecords {
+	lr := &LocalRecords{
+		exact:  make(map[string]*localRecord),
+		suffix: make(map[string]*localRecord),
+	}
+	for _, rec := range records {
+		name := strings.ToLower(strings.TrimSuffix(strings.TrimSpace(rec.Domain), "."))
+		if name == "" {
+			continue
+		}
+		compiled := &localRecord{
+			domain: name,
+			cname:  strings.ToLower(strings.TrimSuffix(rec.CNAME, ".")),
+			ttl:    localRecordDefaultTTL,
+		}
+		if rec.TTL > 0 {
+			compiled.ttl = uint32(rec.TTL)
+		}
+		for _, s := range rec.IPs {
+			if addr, err := netip.ParseAddr(s); err == nil && addr.Is4() {
+				compiled.ips = append(compiled.ips, addr.As4())
+			}
+		}
+		if len(compiled.ips) == 0 && compiled.cname == "" {
+			continue
+		}
+		if rest, ok := strings.CutPrefix(name, "*."); ok {
+			lr.suffix[rest] = compiled
+		} else {
+			lr.exact[name] = compiled
+		}
+	}
+	return lr
+}
+
+// IsEmpty returns true if there are no records.
+func (lr *LocalRecords) IsEmpty() bool {
+	return lr == nil || (len(lr.exact) == 0 && len(lr.suffix) == 0)
+}
+
+// lookup finds the record for name. Exact records win over wildcards;
+// the most specific wildcard wins.
+func (lr *LocalRecords) lookup(name string) *localRecord {
+	if lr.IsEmpty() {
+		return nil
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if rec, ok := lr.exact[name]; ok {
+		return rec
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] == '.' {
+			if rec, ok := lr.suffix[name[i+1:]]; ok {
+				return rec
+			}
+		}
+	}
+	return nil
+}
+
+// answerLocal returns a response built from local records and the matched
+// record domain, or nil if the name has no local record. CNAME targets are
+// followed through local records; a non-local final target is resolved
+// through the normal resolver path.
+func (r *DNSResolver) answerLocal(ctx context.Context, query []byte, name string) ([]byte, string) {
+	lr := r.localRecords.Load()
+	rec := lr.lookup(name)
+	if rec == nil {
+		return nil, ""
+	}
+
+	qtype := dnsQueryType(query)
+	if qtype != 1 && qtype != 5 { // only A and CNAME are answered
+		return makeEmptyResponse(query), rec.domain
+	}
+
+	// Follow the CNAME chain through local records.
+	var cnames []string
+	ips := rec.ips
+	ttl := rec.ttl
+	for cur := rec; cur.cname != "" && len(cnames) < localCNAMEMaxHops; {
+		cnames = append(cnames, cur.cname)
+		next := lr.lookup(cur.cname)
+		if next == nil {
+			ips = nil
+			break
+		}
+		cur = next
+		ips = cur.ips
+	}
+
+	// Resolve a non-local CNAME target upstream (A queries only).
+	if qtype == 1 && len(cnames) > 0 && len(ips) == 0 && ctx.Value(dnsLocalKey{}) == nil {
+		target := cnames[len(cnames)-1]
+		if q := buildNameQuery(binary.BigEndian.Uint16(query[0:2]), target, 1); q != nil {
+			resp := r.Resolve(context.WithValue(ctx, dnsLocalKey{}, true), q)
+			ips = extractARecords(resp)
+		}
+	}
+	if qtype == 5 {
+		ips = nil
+	}
+
+	core.Log.Debugf("DNS", "%s answered from local record %q", name, rec.domain)
+	return buildLocalResponse(query, cnames, ips, ttl), rec.domain
+}
+
+// localMatchResult describes a local record answer for the query log.
+func localMatchResult(domain string) DomainMatchResult {
+	return DomainMatchResult{Matched: true, Action: core.DomainDirect, Pattern: "local:" + domain}
+}
+
+// dnsLocalKey marks a resolution started by answerLocal to prevent re-entry.
+type dnsLocalKey struct{}
+
+// buildNameQuery builds a recursive query for a single name.
+func buildNameQuery(id uint16, name string, qtype uint16) []byte {
+	qname := encodeDNSName(name)
+	if qname == nil {
+		return nil
+	}
+	q := make([]byte, 12, 12+len(qname)+4)
+	binary.BigEndian.PutUint16(q[0:2], id)
+	q[2] = 0x01 // RD=1
+	binary.BigEndian.PutUint16(q[4:6], 1)
+	q = append(q, qname...)
+	q = binary.BigEndian.AppendUint16(q, qtype)
+	q = binary.BigEndian.AppendUint16(q, 1) // IN
+	return q
+}
+
+// encodeDNSName encodes a dotted name as uncompressed DNS labels.
+func encodeDNSName(name string) []byte {
+	name = strings.TrimSuffix(name, ".")
+	var b []byte
+	for _, label := range strings.Split(name, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return nil
+		}
+		b = append(b, byte(len(label)))
+		b = append(b, label...)
+	}
+	return append(b, 0)
+}
+
+// questionEnd returns the offset just past the first question, or -1.
+func questionEnd(msg []byte) int {
+	pos := 12
+	for pos < len(msg) {
+		labelLen := int(msg[pos])
+		if labelLen == 0 {
+			pos++
+			break
+		}
+		if labelLen >= 64 {
+			return -1
+		}
+		pos += 1 + labelLen
+	}
+	pos += 4 // QTYPE + QCLASS
+	if pos > len(msg) {
+		return -1
+	}
+	return pos
+}
+
+// buildLocalResponse builds an authoritative NOERROR response with an optional
+// CNAME chain followed by A records for the final name.
+func buildLocalResponse(query []byte, cnames []string, ips [][4]byte, ttl uint32) []byte {
+	qEnd := questionEnd(query)
+	if qEnd < 0 {
+		return makeServFail(query)
+	}
+
+	resp := make([]byte, qEnd, qEnd+64*len(cnames)+16*len(ips))
+	copy(resp, query[:qEnd])
+	resp[2] = (query[2] & 0x79) | 0x84 // QR=1, AA=1, keep opcode and RD
+	resp[3] = 0x80                     // RA=1, RCODE=NOERROR
+	binary.BigEndian.PutUint16(resp[4:6], 1)
+	binary.BigEndian.PutUint16(resp[6:8], uint16(len(cnames)+len(ips)))
+	resp[8], resp[9] = 0, 0
+	resp[10], resp[11] = 0, 0
+
+	owner := uint16(12) // offset of the current owner name (QNAME first)
+	appendRR := func(rrType uint16, rdata []byte) {
+		resp = binary.BigEndian.AppendUint16(resp, 0xC000|owner)
+		resp = binary.BigEndian.AppendUint16(resp, rrType)
+		resp = binary.BigEndian.AppendUint16(resp, 1) // IN
+		resp = binary.BigEndian.AppendUint32(resp, ttl)
+		resp = binary.BigEndian.AppendUint16(resp, uint16(len(rdata)))
+		resp = append(resp, rdata...)
+	}
+
+	for _, target := range cnames {
+		rdata := encodeDNSName(target)
+		if rdata == nil {
+			return makeServFail(query)
+		}
+		rdataOff := len(resp) + 12 // after name ptr, type, class, ttl, rdlength
+		appendRR(5, rdata)
+		owner = uint16(rdataOff)
+	}
+	for _, ip := range ips {
+		appendRR(1, ip[:])
+	}
+	return resp
+}
+
+// extractARecords returns all A record addresses from the answer section.
+func extractARecords(resp []byte) [][4]byte {
+	if len(resp) < 12 {
+		return nil
+	}
+	ancount := int(binary.BigEndian.Uint16(resp[6:8]))
+	pos := questionEnd(resp)
+	if pos < 0 {
+		return nil
+	}
+
+	var ips [][4]byte
+	for i := 0; i < ancount && pos < len(resp); i++ {
+		// Skip owner name (labels or compression pointer).
+		for pos < len(resp) {
+			l := int(resp[pos])
+			if l == 0 {
+				pos++
+				break
+			}
+			if l >= 0xC0 {
+				pos += 2
+				break
+			}
+			pos += 1 + l
+		}
+		if pos+10 > len(resp) {
+			break
+		}
+		rrType := binary.BigEndian.Uint16(resp[pos : pos+2])
+		rdLength := int(binary.BigEndian.Uint16(resp[pos+8 : pos+10]))
+		pos += 10
+		if pos+rdLength > len(resp) {
+			break
+		}
+		if rrType == 1 && rdLength == 4 {
+			var ip [4]byte
+			copy(ip[:], resp[pos:pos+4])
+			ips = append(ips, ip)
+		}
+		pos += rdLength
+	}
+	return ips
+}
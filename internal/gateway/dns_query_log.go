@@ -0,0 +1,357 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// dnsQueryRingSize is the max number of query records kept in the ring buffer.
+	dnsQueryRingSize = 2000
+	// dnsQueryChannelSize is the buffer size for each subscriber channel.
+	dnsQueryChannelSize = 256
+	// dnsQueryMaxDomains caps the per-domain statistics map. When exceeded,
+	// the least recently seen half is evicted.
+	dnsQueryMaxDomains = 10000
+)
+
+// DNS response codes used in query records.
+const (
+	DNSRcodeNoError  uint8 = 0
+	DNSRcodeServFail uint8 = 2
+	DNSRcodeNXDomain uint8 = 3
+)
+
+// DNSQueryEntry is a structured record of a single resolver decision.
+type DNSQueryEntry struct {
+	Time      time.Time
+	Process   string // lowercase exe base name of the client, "" if unknown or unwatched (see Watched)
+	Name      string
+	QType     uint16
+	Rule      string            // matched domain rule pattern, "" if no rule matched
+	Action    core.DomainAction // valid only when Rule != ""
+	TunnelID  string            // tunnel the query was forwarded through
+	Upstream  string            // upstream server address, "" if answered locally
+	Latency   time.Duration
+	Rcode     uint8
+	FakeIP    netip.Addr // assigned FakeIP, invalid if none
+	Transport string     // "udp" or "tcp"
+}
+
+// Blocked reports whether the query was answered with NXDOMAIN by a block rule.
+func (e *DNSQueryEntry) Blocked() bool {
+	return e.Rule != "" && e.Action == core.DomainBlock
+}
+
+// DNSDomainStats aggregates query counters for a single domain.
+type DNSDomainStats struct {
+	Name        string
+	Queries     uint64
+	Blocked     uint64
+	LastSeen    time.Time
+	LastProcess string
+	LastTunnel  string
+	Rule        string
+}
+
+// DNSQuerySubscriber receives query records via a channel.
+type DNSQuerySubscriber struct {
+	C  <-chan DNSQueryEntry
+	ch chan DNSQueryEntry
+	id uint64
+}
+
+// DNSQueryLog keeps a ring of recent DNS query records, per-domain aggregates
+// and distributes new records to subscribers.
+type DNSQueryLog struct {
+	mu          sync.Mutex
+	ring        []DNSQueryEntry
+	ringPos     int
+	ringFull    bool
+	domains     map[string]*DNSDomainStats
+	subscribers map[uint64]*DNSQuerySubscriber
+	nextID      uint64
+	watchers    atomic.Int32 // len(subscribers), readable without mu
+}
+
+// NewDNSQueryLog creates an empty query log.
+func NewDNSQueryLog() *DNSQueryLog {
+	return &DNSQueryLog{
+		ring:        make([]DNSQueryEntry, dnsQueryRingSize),
+		domains:     make(map[string]*DNSDomainStats),
+		subscribers: make(map[uint64]*DNSQuerySubscriber),
+	}
+}
+
+// Record appends a query record, updates aggregates and notifies subscribers.
+func (l *DNSQueryLog) Record(e DNSQueryEntry) {
+	l.mu.Lock()
+	l.ring[l.ringPos] = e
+	l.ringPos++
+	if l.ringPos >= dnsQueryRingSize {
+		l.ringPos = 0
+		l.ringFull = true
+	}
+
+	if e.Name != "" {
+		key := strings.ToLower(strings.TrimSuffix(e.Name, "."))
+		ds, ok := l.domains[key]
+		if !ok {
+			if len(l.domains) >= dnsQueryMaxDomains {
+				l.evictDomainsLocked()
+			}
+			ds = &DNSDomainStats{Name: key}
+			l.domains[key] = ds
+		}
+		ds.Queries++
+		if e.Blocked() {
+			ds.Blocked++
+		}
+		ds.LastSeen = e.Time
+		if e.Process != "" {
+			ds.LastProcess = e.Process
+		}
+		if e.TunnelID != "" {
+			ds.LastTunnel = e.TunnelID
+		}
+		ds.Rule = e.Rule
+	}
+
+	// Send under l.mu: Unsubscribe closes the channel under the same lock,
+	// so a send can never hit a closed channel. Sends never block.
+	for _, sub := range l.subscribers {
+		select {
+		case sub.ch <- e:
+		default:
+			// Drop record if subscriber is slow.
+		}
+	}
+	l.mu.Unlock()
+}
+
+// Subscribe creates a new subscriber. tail sends the last N records from the
+// ring buffer first.
+func (l *DNSQueryLog) Subscribe(tail int) *DNSQuerySubscriber {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	ch := make(chan DNSQueryEntry, dnsQueryChannelSize)
+	sub := &DNSQuerySubscriber{C: ch, ch: ch, id: l.nextID}
+	l.nextID++
+	l.subscribers[sub.id] = sub
+	l.watchers.Add(1)
+
+	for _, e := range l.tailLocked(tail) {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+	return sub
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (l *DNSQueryLog) Unsubscribe(sub *DNSQuerySubscriber) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.subscribers[sub.id]; ok {
+		close(sub.ch)
+		delete(l.subscribers, sub.id)
+		l.watchers.Add(-1)
+	}
+}
+
+// Watched reports whether anyone is subscribed to the live record stream.
+func (l *DNSQueryLog) Watched() bool {
+	return l.watchers.Load() > 0
+}
+
+// Recent returns up to n most recent records, oldest first.
+func (l *DNSQueryLog) Recent(n int) []DNSQueryEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.tailLocked(n)
+}
+
+// TopDomains returns up to n domains ordered by query count (descending).
+// If blockedOnly is set, only domains with at least one blocked query are
+// returned, ordered by blocked count.
+func (l *DNSQueryLog) TopDomains(n int, blockedOnly bool) []DNSDomainStats {
+	l.mu.Lock()
+	result := make([]DNSDomainStats, 0, len(l.domains))
+	for _, ds := range l.domains {
+		if blockedOnly && ds.Blocked == 0 {
+			continue
+		}
+		result = append(result, *ds)
+	}
+	l.mu.Unlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		ci, cj := result[i].Queries, result[j].Queries
+		if blockedOnly {
+			ci, cj = result[i].Blocked, result[j].Blocked
+		}
+		if ci != cj {
+			return ci > cj
+		}
+		return result[i].Name < result[j].Name
+	})
+	if n > 0 && len(result) > n {
+		result = result[:n]
+	}
+	return result
+}
+
+// ResetStats clears per-domain aggregates. The record ring is kept.
+func (l *DNSQueryLog) ResetStats() {
+	l.mu.Lock()
+	l.domains = make(map[string]*DNSDomainStats)
+	l.mu.Unlock()
+}
+
+// tailLocked returns the last n records. Must be called with l.mu held.
+func (l *DNSQueryLog) tailLocked(n int) []DNSQueryEntry {
+	total := l.ringPos
+	if l.ringFull {
+		total = dnsQueryRingSize
+	}
+	if n > total {
+		n = total
+	}
+	if n <= 0 {
+		return nil
+	}
+	result := make([]DNSQueryEntry, n)
+	start := l.ringPos - n
+	if start < 0 {
+		start += dnsQueryRingSize
+	}
+	for i := range n {
+		result[i] = l.ring[(start+i)%dnsQueryRingSize]
+	}
+	return result
+}
+
+// evictDomainsLocked drops the least recently seen half of the domain stats.
+// Must be called with l.mu held.
+func (l *DNSQueryLog) evictDomainsLocked() {
+	seen := make([]time.Time, 0, len(l.domains))
+	for _, ds := range l.domains {
+		seen = append(seen, ds.LastSeen)
+	}
+	sort.Slice(seen, func(i, j int) bool { return seen[i].Before(seen[j]) })
+	cutoff := seen[len(seen)/2]
+	for k, ds := range l.domains {
+		if !ds.LastSeen.After(cutoff) {
+			delete(l.domains, k)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Resolver integration
+// ---------------------------------------------------------------------------
+
+// dnsClientKey is the context key carrying the client's source port so the
+// query log can attribute a query to a process.
+type dnsClientKey struct{}
+
+type dnsClientInfo struct {
+	port  uint16
+	isUDP bool
+	peer  string // wg_server peer name; set instead of port for peer queries
+}
+
+// withDNSClient returns a context annotated with the querying client's source port.
+func withDNSClient(ctx context.Context, port uint16, isUDP bool) context.Context {
+	return context.WithValue(ctx, dnsClientKey{}, dnsClientInfo{port: port, isUDP: isUDP})
+}
+
+// withDNSPeer returns a context annotated with the querying wg_server peer.
+func withDNSPeer(ctx context.Context, peer string) context.Context {
+	return context.WithValue(ctx, dnsClientKey{}, dnsClientInfo{peer: peer})
+}
+
+// dnsQueryType returns the QTYPE of the first question, or 0 on parse failure.
+func dnsQueryType(query []byte) uint16 {
+	if len(query) < 12 {
+		return 0
+	}
+	pos := 12
+	for pos < len(query) {
+		labelLen := int(query[pos])
+		if labelLen == 0 {
+			pos++
+			break
+		}
+		if labelLen >= 64 {
+			return 0
+		}
+		pos += 1 + labelLen
+	}
+	if pos+2 > len(query) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(query[pos : pos+2])
+}
+
+// dnsRcode returns the RCODE of a DNS response.
+func dnsRcode(resp []byte) uint8 {
+	if len(resp) < 4 {
+		return DNSRcodeServFail
+	}
+	return resp[3] & 0x0F
+}
+
+// logQuery records a resolver decision in the query log, if one is attached.
+func (r *DNSResolver) logQuery(ctx context.Context, start time.Time, transport, name string, query, resp []byte,
+	dr DomainMatchResult, tunnelID string, upstream netip.Addr) {
+	ql := r.queryLog.Load()
+	if ql == nil {
+		return
+	}
+
+	e := DNSQueryEntry{
+		Time:      start,
+		Name:      name,
+		QType:     dnsQueryType(query),
+		TunnelID:  tunnelID,
+		Latency:   time.Since(start),
+		Rcode:     dnsRcode(resp),
+		Transport: transport,
+	}
+	if dr.Matched {
+		e.Rule = dr.Pattern
+		e.Action = dr.Action
+	}
+	if upstream.IsValid() {
+		e.Upstream = upstream.String()
+	}
+	if dr.Matched && dr.Action != core.DomainBlock {
+		if pool := r.fakeIPPool.Load(); pool != nil {
+			if fip, _, ok := pool.LookupByDomain(name); ok {
+				e.FakeIP = netip.AddrFrom4(fip)
+			}
+		}
+	}
+	// The port-to-process lookup queries the OS socket table, too costly
+	// for every query; it only runs while the live stream is watched.
+	if ci, ok := ctx.Value(dnsClientKey{}).(dnsClientInfo); ok {
+		if ci.peer != "" {
+			e.Process = core.PeerRulePrefix + ci.peer
+		} else if lookup := r.processLookup; lookup != nil && ql.Watched() {
+			e.Process = lookup(ci.port, ci.isUDP)
+		}
+	}
+
+	ql.Record(e)
+}
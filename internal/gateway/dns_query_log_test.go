@@ -0,0 +1,159 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestDNSQueryLogRingWrap(t *testing.T) {
+	l := NewDNSQueryLog()
+	for i := range dnsQueryRingSize + 10 {
+		l.Record(DNSQueryEntry{Name: fmt.Sprintf("d%d.example", i)})
+	}
+
+	recent := l.Recent(dnsQueryRingSize * 2)
+	if len(recent) != dnsQueryRingSize {
+		t.Fatalf("Recent returned %d records, want %d", len(recent), dnsQueryRingSize)
+	}
+	// Oldest first: the first 10 records were overwritten.
+	if recent[0].Name != "d10.example" || recent[len(recent)-1].Name != fmt.Sprintf("d%d.example", dnsQueryRingSize+9) {
+		t.Errorf("ring order: first %s, last %s", recent[0].Name, recent[len(recent)-1].Name)
+	}
+	if got := l.Recent(3); len(got) != 3 || got[2].Name != recent[len(recent)-1].Name {
+		t.Errorf("Recent(3) = %v", got)
+	}
+}
+
+func TestDNSQueryLogTopDomains(t *testing.T) {
+	l := NewDNSQueryLog()
+	base := time.Now()
+	for i := range 3 {
+		l.Record(DNSQueryEntry{Name: "A.example.", Time: base})
+		if i < 2 {
+			l.Record(DNSQueryEntry{Name: "b.example", Time: base, Rule: "domain:b.example", Action: core.DomainBlock})
+		}
+	}
+
+	top := l.TopDomains(0, false)
+	if len(top) != 2 || top[0].Name != "a.example" || top[0].Queries != 3 {
+		t.Errorf("TopDomains = %+v", top)
+	}
+	blocked := l.TopDomains(0, true)
+	if len(blocked) != 1 || blocked[0].Name != "b.example" || blocked[0].Blocked != 2 {
+		t.Errorf("TopDomains(blocked) = %+v", blocked)
+	}
+
+	// Filling the map past its cap evicts the least recently seen half,
+	// which includes the two domains above.
+	for i := range dnsQueryMaxDomains {
+		l.Record(DNSQueryEntry{Name: fmt.Sprintf("n%d.example", i), Time: base.Add(time.Duration(i+1) * time.Millisecond)})
+	}
+	top = l.TopDomains(0, false)
+	if len(top) > dnsQueryMaxDomains {
+		t.Errorf("%d domains kept, cap is %d", len(top), dnsQueryMaxDomains)
+	}
+	for _, ds := range top {
+		if ds.Name == "a.example" || ds.Name == "b.example" {
+			t.Errorf("oldest domain %s not evicted", ds.Name)
+		}
+	}
+	if last := fmt.Sprintf("n%d.example", dnsQueryMaxDomains-1); !hasDomain(top, last) {
+		t.Errorf("newest domain %s evicted", last)
+	}
+}
+
+func hasDomain(stats []DNSDomainStats, name string) bool {
+	for _, ds := range stats {
+		if ds.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDNSQueryLogSubscribeTail(t *testing.T) {
+	l := NewDNSQueryLog()
+	for i := range 5 {
+		l.Record(DNSQueryEntry{Name: fmt.Sprintf("old%d", i)})
+	}
+	sub := l.Subscribe(2)
+	defer l.Unsubscribe(sub)
+	l.Record(DNSQueryEntry{Name: "new"})
+
+	for _, want := range []string{"old3", "old4", "new"} {
+		select {
+		case e := <-sub.C:
+			if e.Name != want {
+				t.Errorf("got %s, want %s", e.Name, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s", want)
+		}
+	}
+}
+
+// TestDNSQueryLogConcurrentUnsubscribe runs Record against subscribers
+// leaving mid-stream; with -race it also checks the locking.
+func TestDNSQueryLogConcurrentUnsubscribe(t *testing.T) {
+	l := NewDNSQueryLog()
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					l.Record(DNSQueryEntry{Name: "load.example"})
+				}
+			}
+		}()
+	}
+	for range 200 {
+		sub := l.Subscribe(0)
+		l.Unsubscribe(sub)
+		for range sub.C {
+			// Drain until closed.
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestLogQueryLooksUpProcessOnlyWhenWatched(t *testing.T) {
+	ql := NewDNSQueryLog()
+	r := &DNSResolver{}
+	r.SetQueryLog(ql)
+	lookups := 0
+	r.SetProcessLookup(func(port uint16, isUDP bool) string {
+		lookups++
+		return "app.exe"
+	})
+	ctx := withDNSClient(context.Background(), 50000, true)
+	query := buildNameQuery(1, "example.com", 1)
+
+	r.logQuery(ctx, time.Now(), "udp", "example.com", query, nil, DomainMatchResult{}, "", netip.Addr{})
+	if lookups != 0 {
+		t.Errorf("process looked up %d times without subscribers", lookups)
+	}
+
+	sub := ql.Subscribe(0)
+	r.logQuery(ctx, time.Now(), "udp", "example.com", query, nil, DomainMatchResult{}, "", netip.Addr{})
+	if e := <-sub.C; e.Process != "app.exe" || lookups != 1 {
+		t.Errorf("watched: process = %q after %d lookups", e.Process, lookups)
+	}
+
+	ql.Unsubscribe(sub)
+	if ql.Watched() {
+		t.Error("log still watched after the last unsubscribe")
+	}
+}
@@ -180,6 +180,10 @@ type DNSResolverConfig struct {
 	// FallbackDirect enables direct (non-VPN) fallback when all VPN servers fail.
 	FallbackDirect bool
 
+	// ListenUDP and ListenTCP open the listeners on ListenAddr
+	// (nil = host network stack).
+	ListenUDP func(addr string) (net.PacketConn, error)
+	ListenTCP func(addr string) (net.Listener, error)
 }
 
 // DNSResolver is a local DNS forwarder that listens on the TUN adapter IP
@@ -197,7 +201,7 @@ type DNSResolver struct {
 	// providers is a reference to the main providers map; read-only after startup.
 	providers map[string]provider.TunnelProvider
 
-	udpConn *net.UDPConn
+	udpConn net.PacketConn
 	tcpLn   net.Listener
 
 	// Domain-based routing.
@@ -229,6 +233,22 @@ type DNSResolver struct {
 	// dnsFanoutSem limits total concurrent fan-out goroutines across all queries.
 	dnsFanoutSem chan struct{}
 
+	// localRecords holds static records answered without upstream (nil if none).
+	localRecords atomic.Pointer[LocalRecords]
+
+	// splitDNS routes domain suffixes to dedicated upstreams (nil if none).
+	splitDNS atomic.Pointer[SplitDNS]
+
+	// dohClients caches DNS-over-HTTPS clients (tunnelID → *http.Client).
+	dohClients sync.Map
+
+	// queryLog records structured per-query decisions (nil if disabled).
+	queryLog atomic.Pointer[DNSQueryLog]
+
+	// processLookup maps a client source port to a lowercase exe base name.
+	// Used only to annotate query log records.
+	processLookup func(port uint16, isUDP bool) string
+
 	started atomic.Bool
 	cancel  context.CancelFunc
 	wg      sync.WaitGroup
@@ -280,6 +300,27 @@ func (r *DNSResolver) SetFakeIPPool(pool *FakeIPPool) {
 	r.fakeIPPool.Store(pool)
 }
 
+// SetLocalRecords atomically replaces the static local DNS records.
+func (r *DNSResolver) SetLocalRecords(lr *LocalRecords) {
+	r.localRecords.Store(lr)
+}
+
+// SetSplitDNS atomically replaces the split DNS routes and search domains.
+func (r *DNSResolver) SetSplitDNS(s *SplitDNS) {
+	r.splitDNS.Store(s)
+}
+
+// SetQueryLog attaches a query log. Pass nil to disable query recording.
+func (r *DNSResolver) SetQueryLog(ql *DNSQueryLog) {
+	r.queryLog.Store(ql)
+}
+
+// SetProcessLookup sets the function used to attribute queries to client
+// processes by source port. Must be called before Start.
+func (r *DNSResolver) SetProcessLookup(fn func(port uint16, isUDP bool) string) {
+	r.processLookup = fn
+}
+
 // SetTunnelDNS registers per-tunnel DNS servers. When a domain rule routes
 // a query through this tunnel, these servers are used instead of global ones.
 func (r *DNSResolver) SetTunnelDNS(tunnelID string, servers []netip.Addr) {
@@ -305,13 +346,21 @@ func (r *DNSResolver) Start(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("[DNS] resolve addr: %w", err)
 	}
-	r.udpConn, err = net.ListenUDP("udp4", udpAddr)
+	if r.config.ListenUDP != nil {
+		r.udpConn, err = r.config.ListenUDP(r.config.ListenAddr)
+	} else {
+		r.udpConn, err = net.ListenUDP("udp4", udpAddr)
+	}
 	if err != nil {
 		return fmt.Errorf("[DNS] listen UDP %s: %w", r.config.ListenAddr, err)
 	}
 
 	// TCP listener.
-	r.tcpLn, err = net.Listen("tcp4", r.config.ListenAddr)
+	if r.config.ListenTCP != nil {
+		r.tcpLn, err = r.config.ListenTCP(r.config.ListenAddr)
+	} else {
+		r.tcpLn, err = net.Listen("tcp4", r.config.ListenAddr)
+	}
 	if err != nil {
 		r.udpConn.Close()
 		return fmt.Errorf("[DNS] listen TCP %s: %w", r.config.ListenAddr, err)
@@ -341,6 +390,7 @@ func (r *DNSResolver) Stop() {
 		r.tcpLn.Close()
 	}
 	r.udpConnCache.CloseAll()
+	r.closeDoHClients()
 	r.wg.Wait()
 	core.Log.Infof("DNS", "Resolver stopped")
 }
@@ -360,7 +410,7 @@ func (r *DNSResolver) FlushCache() {
 func (r *DNSResolver) udpLoop(ctx context.Context) {
 	buf := make([]byte, 4096)
 	for {
-		n, clientAddr, err := r.udpConn.ReadFromUDP(buf)
+		n, from, err := r.udpConn.ReadFrom(buf)
 		if err != nil {
 			select {
 			case <-ctx.Done():
@@ -371,7 +421,8 @@ func (r *DNSResolver) udpLoop(ctx context.Context) {
 			}
 		}
 
-		if n < 12 {
+		clientAddr, ok := from.(*net.UDPAddr)
+		if !ok || n < 12 {
 			continue // too small for DNS header
 		}
 
@@ -393,10 +444,10 @@ func (r *DNSResolver) udpLoop(ctx context.Context) {
 }
 
 func (r *DNSResolver) handleUDPQuery(ctx context.Context, query []byte, clientAddr *net.UDPAddr) {
-	resp := r.Resolve(ctx, query)
+	resp := r.Resolve(withDNSClient(ctx, uint16(clientAddr.Port), true), query)
 	if resp != nil {
-		if _, err := r.udpConn.WriteToUDP(resp, clientAddr); err != nil {
-			core.Log.Warnf("DNS", "WriteToUDP to %s: %v", clientAddr, err)
+		if _, err := r.udpConn.WriteTo(resp, clientAddr); err != nil {
+			core.Log.Warnf("DNS", "WriteTo %s: %v", clientAddr, err)
 		}
 	}
 }
@@ -411,7 +462,21 @@ func (r *DNSResolver) Resolve(ctx context.Context, query []byte) []byte {
 	// Block AAAA (IPv6) queries — return empty NOERROR response.
 	// IPv6 is disabled in the VPN stack; forwarding AAAA would leak IPv6 addresses.
 	if isAAAAQuery(query) {
-		return makeEmptyResponse(query)
+		resp := makeEmptyResponse(query)
+		r.logQuery(ctx, start, "udp", name, query, resp, DomainMatchResult{}, "", netip.Addr{})
+		return resp
+	}
+
+	// Static local records: answered without querying upstream.
+	if resp, rec := r.answerLocal(ctx, query, name); resp != nil {
+		r.logQuery(ctx, start, "udp", name, query, resp, localMatchResult(rec), "", netip.Addr{})
+		return resp
+	}
+
+	// Single-label names: try the search domains.
+	if resp := r.answerSearch(ctx, query, name); resp != nil {
+		r.logQuery(ctx, start, "udp", name, query, resp, DomainMatchResult{}, "", netip.Addr{})
+		return resp
 	}
 
 	// Domain-based routing: intercept before cache/forwarding.
@@ -423,13 +488,15 @@ func (r *DNSResolver) Resolve(ctx context.Context, query []byte) []byte {
 		routeTunnelID = tunnelIDs[0]
 	}
 	var domainResult DomainMatchResult
-	if dm := r.domainMatcher.Load(); dm != nil && name != "" {
+	if dm := r.domainMatcher.Load(); dm != nil && name != "" && ctx.Value(dnsBootstrapKey{}) == nil {
 		domainResult = dm.Match(name)
 		if domainResult.Matched {
 			switch domainResult.Action {
 			case core.DomainBlock:
 				core.Log.Debugf("DNS", "%s blocked by domain rule (UDP)", name)
-				return makeNXDomain(query)
+				resp := makeNXDomain(query)
+				r.logQuery(ctx, start, "udp", name, query, resp, domainResult, "", netip.Addr{})
+				return resp
 			case core.DomainDirect:
 				routeTunnelID = DirectTunnelID
 				// Route DNS through direct path to prevent DNS/IP mismatch detection:
@@ -445,8 +512,19 @@ func (r *DNSResolver) Resolve(ctx context.Context, query []byte) []byte {
 		}
 	}
 
-	// Forward DNS query through all configured VPN tunnels in parallel.
-	resp, server, usedTunnel, err := r.forwardUDPAll(ctx, tunnelIDs, query)
+	// Split DNS routes take precedence over the default fan-out through all
+	// configured VPN tunnels.
+	var resp []byte
+	var server netip.Addr
+	var usedTunnel string
+	var err error
+	route := r.splitRouteFor(ctx, name)
+	if route != nil {
+		resp, server, err = r.forwardSplit(ctx, route, query, false)
+		usedTunnel = route.tunnelID
+	} else {
+		resp, server, usedTunnel, err = r.forwardUDPAll(ctx, tunnelIDs, query)
+	}
 	if err == nil {
 		// FakeIP rewriting: for domain-matched queries, rewrite A records to FakeIP
 		// BEFORE caching so cache hits also return FakeIPs.
@@ -459,6 +537,16 @@ func (r *DNSResolver) Resolve(ctx context.Context, query []byte) []byte {
 		if domainResult.Matched {
 			r.recordDomainIPs(resp, name, routeTunnelID, domainResult.Action)
 		}
+		r.logQuery(ctx, start, "udp", name, query, resp, splitMatchResult(domainResult, route), usedTunnel, server)
+		return resp
+	}
+
+	// Split routes never fall back to other upstreams: names under a private
+	// suffix must not leak to public resolvers.
+	if route != nil {
+		core.Log.Warnf("DNS", "Split DNS failed for %s (UDP): %v [%s]", name, err, time.Since(start))
+		resp = makeServFail(query)
+		r.logQuery(ctx, start, "udp", name, query, resp, splitMatchResult(domainResult, route), usedTunnel, netip.Addr{})
 		return resp
 	}
 
@@ -469,6 +557,7 @@ func (r *DNSResolver) Resolve(ctx context.Context, query []byte) []byte {
 			if name != "" {
 				core.Log.Debugf("DNS", "%s → %s via direct/fallback (UDP) [%s]", name, server, time.Since(start))
 			}
+			r.logQuery(ctx, start, "udp", name, query, resp, domainResult, DirectTunnelID, server)
 			return resp
 		}
 	}
@@ -480,11 +569,14 @@ func (r *DNSResolver) Resolve(ctx context.Context, query []byte) []byte {
 		if name != "" {
 			core.Log.Debugf("DNS", "%s → raw fallback (UDP) [%s]", name, time.Since(start))
 		}
+		r.logQuery(ctx, start, "udp", name, query, resp, domainResult, "raw", netip.Addr{})
 		return resp
 	}
 
 	core.Log.Warnf("DNS", "All tunnels/servers failed for %s (UDP): %v [%s]", name, err, time.Since(start))
-	return makeServFail(query)
+	resp = makeServFail(query)
+	r.logQuery(ctx, start, "udp", name, query, resp, domainResult, "", netip.Addr{})
+	return resp
 }
 
 func (r *DNSResolver) forwardUDP(ctx context.Context, tunnelID string, query []byte) ([]byte, netip.Addr, error) {
@@ -814,12 +906,17 @@ func (r *DNSResolver) handleTCPQuery(ctx context.Context, clientConn net.Conn) {
 		return
 	}
 
+	if ap, err := netip.ParseAddrPort(clientConn.RemoteAddr().String()); err == nil {
+		ctx = withDNSClient(ctx, ap.Port(), false)
+	}
+
 	start := time.Now()
 	name := extractDNSName(query)
 
 	// Block AAAA (IPv6) queries — return empty NOERROR response.
 	if isAAAAQuery(query) {
 		if resp := makeEmptyResponse(query); resp != nil {
+			r.logQuery(ctx, start, "tcp", name, query, resp, DomainMatchResult{}, "", netip.Addr{})
 			if err := writeTCPDNSResponse(clientConn, resp); err != nil {
 				core.Log.Warnf("DNS", "TCP write AAAA block response: %v", err)
 			}
@@ -827,6 +924,24 @@ func (r *DNSResolver) handleTCPQuery(ctx context.Context, clientConn net.Conn) {
 		return
 	}
 
+	// Static local records: answered without querying upstream.
+	if resp, rec := r.answerLocal(ctx, query, name); resp != nil {
+		r.logQuery(ctx, start, "tcp", name, query, resp, localMatchResult(rec), "", netip.Addr{})
+		if err := writeTCPDNSResponse(clientConn, resp); err != nil {
+			core.Log.Warnf("DNS", "TCP write local response: %v", err)
+		}
+		return
+	}
+
+	// Single-label names: try the search domains.
+	if resp := r.answerSearch(ctx, query, name); resp != nil {
+		r.logQuery(ctx, start, "tcp", name, query, resp, DomainMatchResult{}, "", netip.Addr{})
+		if err := writeTCPDNSResponse(clientConn, resp); err != nil {
+			core.Log.Warnf("DNS", "TCP write search response: %v", err)
+		}
+		return
+	}
+
 	// Domain-based routing: intercept before cache/forwarding.
 	// DNS forwarding goes through all configured tunnels in parallel.
 	// Only the routeTunnelID (for DomainTable) reflects the domain rule's target.
@@ -843,6 +958,7 @@ func (r *DNSResolver) handleTCPQuery(ctx context.Context, clientConn net.Conn) {
 			case core.DomainBlock:
 				core.Log.Debugf("DNS", "%s blocked by domain rule (TCP)", name)
 				if nxd := makeNXDomain(query); nxd != nil {
+					r.logQuery(ctx, start, "tcp", name, query, nxd, domainResult, "", netip.Addr{})
 					if err := writeTCPDNSResponse(clientConn, nxd); err != nil {
 						core.Log.Warnf("DNS", "TCP write NXDomain response: %v", err)
 					}
@@ -863,15 +979,26 @@ func (r *DNSResolver) handleTCPQuery(ctx context.Context, clientConn net.Conn) {
 		}
 	}
 
-	// Forward DNS query through all configured VPN tunnels in parallel.
-	resp, server, usedTunnel, err := r.forwardTCPAll(ctx, tunnelIDs, query)
-	if err != nil && r.config.FallbackDirect {
+	// Split DNS routes take precedence over the default fan-out through all
+	// configured VPN tunnels. They never fall back to other upstreams.
+	var resp []byte
+	var server netip.Addr
+	var usedTunnel string
+	var err error
+	route := r.splitRouteFor(ctx, name)
+	if route != nil {
+		resp, server, err = r.forwardSplit(ctx, route, query, true)
+		usedTunnel = route.tunnelID
+	} else {
+		resp, server, usedTunnel, err = r.forwardTCPAll(ctx, tunnelIDs, query)
+	}
+	if err != nil && route == nil && r.config.FallbackDirect {
 		resp, server, err = r.forwardTCP(ctx, DirectTunnelID, query)
 		usedTunnel = DirectTunnelID
 	}
 
 	// Last resort: raw DNS via OS network stack.
-	if err != nil {
+	if err != nil && route == nil {
 		rawResp, rawErr := r.forwardRawUDP(ctx, query)
 		if rawErr == nil {
 			resp = rawResp
@@ -899,6 +1026,7 @@ func (r *DNSResolver) handleTCPQuery(ctx context.Context, clientConn net.Conn) {
 			r.recordDomainIPs(resp, name, routeTunnelID, domainResult.Action)
 		}
 	}
+	r.logQuery(ctx, start, "tcp", name, query, resp, splitMatchResult(domainResult, route), usedTunnel, server)
 
 	// Write response with length prefix.
 	if err := writeTCPDNSResponse(clientConn, resp); err != nil {
@@ -0,0 +1,327 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// searchDomainTTL is the TTL of answers synthesized for search-domain expansion.
+	searchDomainTTL = 60
+	// dohMaxResponse limits the DNS-over-HTTPS response body.
+	dohMaxResponse = 65535
+)
+
+// searchSkipNames are single-label names that are never expanded with the
+// search domains: OS discovery probes ask for them constantly, and an
+// expanded "wpad" answer would hand out a proxy configuration.
+var searchSkipNames = map[string]bool{
+	"wpad":      true,
+	"isatap":    true,
+	"localhost": true,
+}
+
+// splitRoute is a compiled split DNS route.
+type splitRoute struct {
+	name     string // first configured domain, used in logs and query records
+	tunnelID string
+	servers  []netip.Addr // plain DNS upstreams (port 53)
+	dohURLs  []string     // DNS-over-HTTPS upstreams
+}
+
+// SplitDNS routes queries for domain suffixes to dedicated upstreams and
+// holds the search domains for single-label names.
+// Immutable after creation — swap atomically via DNSResolver.SetSplitDNS.
+type SplitDNS struct {
+	routes   map[string]*splitRoute // suffix → route
+	catchAll *splitRoute            // "." route, nil if none
+	search   []string
+}
+
+// NewSplitDNS compiles split DNS routes and search domains. Invalid servers are skipped.
+func NewSplitDNS(routes []core.DNSSplitRoute, searchDomains []string) *SplitDNS {
+	s := &SplitDNS{routes: make(map[string]*splitRoute)}
+	for _, cfg := range routes {
+		route := &splitRoute{tunnelID: cfg.TunnelID}
+		if route.tunnelID == "" {
+			route.tunnelID = DirectTunnelID
+		}
+		for _, srv := range cfg.Servers {
+			if strings.HasPrefix(srv, "https://") {
+				route.dohURLs = append(route.dohURLs, srv)
+			} else if addr, err := netip.ParseAddr(srv); err == nil {
+				route.servers = append(route.servers, addr)
+			}
+		}
+		for _, d := range cfg.Domains {
+			d = normalizeSplitDomain(d)
+			if route.name == "" {
+				route.name = d
+			}
+			if d == "." {
+				s.catchAll = route
+			} else if d != "" {
+				s.routes[d] = route
+			}
+		}
+	}
+	for _, sd := range searchDomains {
+		if sd = normalizeSplitDomain(sd); sd != "" && sd != "." {
+			s.search = append(s.search, sd)
+		}
+	}
+	return s
+}
+
+// normalizeSplitDomain lowercases a suffix and strips "*." / "." prefixes.
+// "." and "*" are returned as ".".
+func normalizeSplitDomain(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	if d == "." || d == "*" {
+		return "."
+	}
+	d = strings.TrimPrefix(d, "*.")
+	d = strings.TrimPrefix(d, ".")
+	return strings.TrimSuffix(d, ".")
+}
+
+// IsEmpty returns true if there are no routes and no search domains.
+func (s *SplitDNS) IsEmpty() bool {
+	return s == nil || (len(s.routes) == 0 && s.catchAll == nil && len(s.search) == 0)
+}
+
+// lookup returns the most specific route for name, or nil.
+func (s *SplitDNS) lookup(name string) *splitRoute {
+	if s == nil {
+		return nil
+	}
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	if name == "" {
+		return nil
+	}
+	if route, ok := s.routes[name]; ok {
+		return route
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] == '.' {
+			if route, ok := s.routes[name[i+1:]]; ok {
+				return route
+			}
+		}
+	}
+	return s.catchAll
+}
+
+// dnsBootstrapKey marks a resolution of a DoH server host name. Such queries
+// skip split routes and domain rules to avoid loops and FakeIP answers.
+type dnsBootstrapKey struct{}
+
+// dnsSearchKey marks a resolution started by search-domain expansion.
+type dnsSearchKey struct{}
+
+// splitRouteFor returns the split route for name, or nil if the default
+// upstreams should be used.
+func (r *DNSResolver) splitRouteFor(ctx context.Context, name string) *splitRoute {
+	if ctx.Value(dnsBootstrapKey{}) != nil {
+		return nil
+	}
+	return r.splitDNS.Load().lookup(name)
+}
+
+// splitMatchResult describes a split route for the query log when no domain rule matched.
+func splitMatchResult(dr DomainMatchResult, route *splitRoute) DomainMatchResult {
+	if dr.Matched || route == nil {
+		return dr
+	}
+	return DomainMatchResult{Matched: true, Action: core.DomainRoute, TunnelID: route.tunnelID, Pattern: "split:" + route.name}
+}
+
+// forwardSplit sends a query to the route's upstreams, trying them in order.
+// Routes without servers use the tunnel's own (or the global) DNS servers.
+func (r *DNSResolver) forwardSplit(ctx context.Context, route *splitRoute, query []byte, tcp bool) ([]byte, netip.Addr, error) {
+	if len(route.servers) == 0 && len(route.dohURLs) == 0 {
+		if tcp {
+			return r.forwardTCP(ctx, route.tunnelID, query)
+		}
+		return r.forwardUDP(ctx, route.tunnelID, query)
+	}
+
+	prov, ok := r.providers[route.tunnelID]
+	if !ok {
+		return nil, netip.Addr{}, fmt.Errorf("tunnel %q not found", route.tunnelID)
+	}
+	entry, ok := r.registry.Get(route.tunnelID)
+	if !ok || entry.State != core.TunnelStateUp {
+		return nil, netip.Addr{}, fmt.Errorf("tunnel %q not up", route.tunnelID)
+	}
+
+	var lastErr error
+	for _, server := range route.servers {
+		qctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+		var resp []byte
+		var err error
+		if tcp {
+			resp, _, err = r.forwardTCPSingle(qctx, prov, route.tunnelID, server, query)
+		} else {
+			resp, _, err = r.forwardUDPSingle(qctx, prov, route.tunnelID, server, query)
+		}
+		cancel()
+		if err == nil {
+			return resp, server, nil
+		}
+		lastErr = err
+	}
+	for _, u := range route.dohURLs {
+		resp, err := r.forwardDoH(ctx, route.tunnelID, u, query)
+		if err == nil {
+			return resp, netip.Addr{}, nil
+		}
+		lastErr = err
+	}
+	return nil, netip.Addr{}, fmt.Errorf("split route %q via %s: %w", route.name, route.tunnelID, lastErr)
+}
+
+// answerSearch resolves a single-label A query against the search domains.
+// Returns a response with a CNAME to the expanded name, or nil if no search
+// domain produced an answer. Well-known probe names and names that have their
+// own split route or domain rule are not expanded.
+func (r *DNSResolver) answerSearch(ctx context.Context, query []byte, name string) []byte {
+	s := r.splitDNS.Load()
+	label := strings.ToLower(strings.TrimSuffix(name, "."))
+	if s == nil || len(s.search) == 0 || label == "" || strings.Contains(label, ".") {
+		return nil
+	}
+	if dnsQueryType(query) != 1 || ctx.Value(dnsSearchKey{}) != nil {
+		return nil
+	}
+	if searchSkipNames[label] || s.routes[label] != nil {
+		return nil
+	}
+	if dm := r.domainMatcher.Load(); dm != nil && dm.Match(label).Matched {
+		return nil
+	}
+
+	id := binary.BigEndian.Uint16(query[0:2])
+	sctx := context.WithValue(ctx, dnsSearchKey{}, true)
+	for _, sd := range s.search {
+		expanded := label + "." + sd
+		q := buildNameQuery(id, expanded, 1)
+		if q == nil {
+			continue
+		}
+		resp := r.Resolve(sctx, q)
+		if ips := extractARecords(resp); len(ips) > 0 {
+			core.Log.Debugf("DNS", "%s expanded to %s via search domain", name, expanded)
+			return buildLocalResponse(query, []string{expanded}, ips, searchDomainTTL)
+		}
+	}
+	return nil
+}
+
+// ---------------------------------------------------------------------------
+// DNS-over-HTTPS
+// ---------------------------------------------------------------------------
+
+// forwardDoH sends a query to a DNS-over-HTTPS server (RFC 8484) through the tunnel.
+func (r *DNSResolver) forwardDoH(ctx context.Context, tunnelID, url string, query []byte) ([]byte, error) {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+
+	// The message ID is zeroed for cache friendliness and restored in the response.
+	body := make([]byte, len(query))
+	copy(body, query)
+	body[0], body[1] = 0, 0
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create DoH request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+
+	client := r.dohClient(tunnelID)
+	resp, err := client.Do(req)
+	if err != nil {
+		client.CloseIdleConnections()
+		return nil, fmt.Errorf("DoH %s via %s: %w", url, tunnelID, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("DoH %s: HTTP %d", url, resp.StatusCode)
+	}
+	msg, err := io.ReadAll(io.LimitReader(resp.Body, dohMaxResponse))
+	if err != nil {
+		return nil, fmt.Errorf("DoH %s: read body: %w", url, err)
+	}
+	if len(msg) < 12 {
+		return nil, fmt.Errorf("DoH %s: response too short (%d bytes)", url, len(msg))
+	}
+	msg[0], msg[1] = query[0], query[1]
+
+	r.latencyTracker.Record(tunnelID, time.Since(start).Microseconds())
+	return msg, nil
+}
+
+// dohClient returns the HTTP client that dials DoH servers through the tunnel.
+func (r *DNSResolver) dohClient(tunnelID string) *http.Client {
+	if c, ok := r.dohClients.Load(tunnelID); ok {
+		return c.(*http.Client)
+	}
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return r.dialDoH(ctx, tunnelID, addr)
+		},
+		ForceAttemptHTTP2:   true,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: r.config.Timeout,
+	}
+	c, _ := r.dohClients.LoadOrStore(tunnelID, &http.Client{Transport: transport})
+	return c.(*http.Client)
+}
+
+// dialDoH dials a DoH server through the tunnel. Host names are resolved
+// through the default upstreams first.
+func (r *DNSResolver) dialDoH(ctx context.Context, tunnelID, addr string) (net.Conn, error) {
+	prov, ok := r.providers[tunnelID]
+	if !ok {
+		return nil, fmt.Errorf("tunnel %q not found", tunnelID)
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		q := buildNameQuery(uint16(time.Now().UnixNano()), host, 1)
+		if q == nil {
+			return nil, fmt.Errorf("invalid DoH host %q", host)
+		}
+		ips := extractARecords(r.Resolve(context.WithValue(ctx, dnsBootstrapKey{}, true), q))
+		if len(ips) == 0 {
+			return nil, fmt.Errorf("resolve DoH host %q: no A records", host)
+		}
+		ip = netip.AddrFrom4(ips[0])
+	}
+	return prov.DialTCP(ctx, net.JoinHostPort(ip.String(), port))
+}
+
+// closeDoHClients drops idle DoH connections of all tunnels.
+func (r *DNSResolver) closeDoHClients() {
+	r.dohClients.Range(func(_, v any) bool {
+		v.(*http.Client).CloseIdleConnections()
+		return true
+	})
+}
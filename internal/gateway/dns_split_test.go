@@ -0,0 +1,122 @@
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestNormalizeSplitDomain(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"corp.example.com", "corp.example.com"},
+		{" Corp.Example.COM. ", "corp.example.com"},
+		{"*.lan", "lan"},
+		{".lan", "lan"},
+		{"*.lan.", "lan"},
+		{".", "."},
+		{"*", "."},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeSplitDomain(tt.in); got != tt.want {
+			t.Errorf("normalizeSplitDomain(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSplitDNSLookup(t *testing.T) {
+	s := NewSplitDNS([]core.DNSSplitRoute{
+		{Domains: []string{"example.com"}, Servers: []string{"10.0.0.1"}, TunnelID: "wide"},
+		{Domains: []string{"*.corp.example.com", "corp.example.net"}, Servers: []string{"10.0.0.2", "https://dns.example/dns-query", "bogus"}, TunnelID: "corp"},
+		{Domains: []string{"."}},
+	}, nil)
+
+	tests := []struct {
+		name string
+		want string // tunnel ID of the expected route
+	}{
+		{"host.corp.example.com", "corp"},
+		{"CORP.example.com.", "corp"},
+		{"corp.example.net", "corp"},
+		{"www.example.com", "wide"},
+		{"example.com", "wide"},
+		{"notexample.com", DirectTunnelID},
+		{"example.org", DirectTunnelID},
+	}
+	for _, tt := range tests {
+		route := s.lookup(tt.name)
+		if route == nil {
+			t.Errorf("lookup(%q) = nil, want %s", tt.name, tt.want)
+			continue
+		}
+		if route.tunnelID != tt.want {
+			t.Errorf("lookup(%q) = %s, want %s", tt.name, route.tunnelID, tt.want)
+		}
+	}
+
+	corp := s.lookup("corp.example.net")
+	if corp.name != "corp.example.com" || len(corp.servers) != 1 || len(corp.dohURLs) != 1 {
+		t.Errorf("corp route = %+v", corp)
+	}
+
+	noCatchAll := NewSplitDNS([]core.DNSSplitRoute{{Domains: []string{"lan"}}}, nil)
+	if route := noCatchAll.lookup("example.org"); route != nil {
+		t.Errorf("lookup without catch-all = %+v, want nil", route)
+	}
+	if route := noCatchAll.lookup(""); route != nil {
+		t.Errorf("lookup(\"\") = %+v, want nil", route)
+	}
+}
+
+func TestAnswerSearch(t *testing.T) {
+	r := NewDNSResolver(DNSResolverConfig{}, core.NewTunnelRegistry(nil), nil)
+	// Every name under home.lan resolves locally, so expansion never goes upstream.
+	r.SetLocalRecords(NewLocalRecords([]core.DNSLocalRecord{
+		{Domain: "*.home.lan", IPs: []string{"192.168.1.10"}},
+	}))
+	r.SetSplitDNS(NewSplitDNS([]core.DNSSplitRoute{
+		{Domains: []string{"router"}, Servers: []string{"192.168.1.1"}},
+	}, []string{"home.lan"}))
+	r.SetDomainMatcher(NewDomainMatcher([]core.DomainRule{
+		{Pattern: "full:tracker", Action: core.DomainBlock},
+	}, nil))
+
+	tests := []struct {
+		name  string
+		qtype uint16
+		want  bool // expanded answer expected
+	}{
+		{"nas", 1, true},
+		{"NAS.", 1, true},
+		{"nas", 28, false},            // only A queries are expanded
+		{"nas.example.com", 1, false}, // not a single label
+		{"wpad", 1, false},            // well-known probe names
+		{"isatap", 1, false},
+		{"localhost", 1, false},
+		{"router", 1, false},  // has its own split route
+		{"tracker", 1, false}, // answered by a domain rule
+	}
+	for _, tt := range tests {
+		query := buildNameQuery(0x1234, tt.name, tt.qtype)
+		resp := r.answerSearch(context.Background(), query, tt.name)
+		if !tt.want {
+			if resp != nil {
+				t.Errorf("answerSearch(%q, %d) expanded, want nil", tt.name, tt.qtype)
+			}
+			continue
+		}
+		ips := extractARecords(resp)
+		if len(ips) != 1 || ips[0] != [4]byte{192, 168, 1, 10} {
+			t.Errorf("answerSearch(%q) A = %v, want [192.168.1.10]", tt.name, ips)
+		}
+	}
+
+	// Expansion is not recursive.
+	ctx := context.WithValue(context.Background(), dnsSearchKey{}, true)
+	if resp := r.answerSearch(ctx, buildNameQuery(1, "nas", 1), "nas"); resp != nil {
+		t.Error("answerSearch expanded inside a search expansion")
+	}
+}
@@ -11,6 +11,7 @@ type DomainMatchResult struct {
 	Matched  bool
 	TunnelID string
 	Action   core.DomainAction
+	Pattern  string // originating rule pattern (e.g. "domain:example.com", "geosite:ads")
 }
 
 // keywordEntry stores a keyword pattern and its associated result.
@@ -32,6 +33,14 @@ type DomainMatcher struct {
 	trie     *domainTrieNode
 	fullOnly map[string]DomainMatchResult
 	keywords []keywordEntry
+
+	// Blocklist entries, consulted only when no rule or geosite entry matches.
+	listTrie *domainTrieNode
+	listFull map[string]DomainMatchResult
+
+	// Allowlist: exceptions that override blocklist entries only.
+	allowTrie *domainTrieNode
+	allowFull map[string]struct{}
 }
 
 // GeositeExpanded represents an expanded geosite entry with our pattern syntax.
@@ -43,9 +52,20 @@ type GeositeExpanded struct {
 
 // NewDomainMatcher builds a matcher from domain rules and expanded geosite entries.
 func NewDomainMatcher(rules []core.DomainRule, geositeEntries []GeositeExpanded) *DomainMatcher {
+	return NewDomainMatcherWithLists(rules, geositeEntries, nil, nil)
+}
+
+// NewDomainMatcherWithLists builds a matcher from domain rules, expanded geosite
+// entries and blocklist entries. Blocklist entries have the lowest precedence and
+// are ignored for domains matching an allow pattern ("domain:", "full:" or bare).
+func NewDomainMatcherWithLists(rules []core.DomainRule, geositeEntries, listEntries []GeositeExpanded, allow []string) *DomainMatcher {
 	m := &DomainMatcher{
-		trie:     &domainTrieNode{},
-		fullOnly: make(map[string]DomainMatchResult),
+		trie:      &domainTrieNode{},
+		fullOnly:  make(map[string]DomainMatchResult),
+		listTrie:  &domainTrieNode{},
+		listFull:  make(map[string]DomainMatchResult),
+		allowTrie: &domainTrieNode{},
+		allowFull: make(map[string]struct{}),
 	}
 
 	// Process regular rules.
@@ -54,6 +74,7 @@ func NewDomainMatcher(rules []core.DomainRule, geositeEntries []GeositeExpanded)
 			Matched:  true,
 			TunnelID: r.TunnelID,
 			Action:   r.Action,
+			Pattern:  r.Pattern,
 		}
 
 		prefix, value := splitPattern(r.Pattern)
@@ -70,7 +91,7 @@ func NewDomainMatcher(rules []core.DomainRule, geositeEntries []GeositeExpanded)
 		case "keyword":
 			m.keywords = append(m.keywords, keywordEntry{keyword: value, result: result})
 		}
-		// geosite: patterns are handled via geositeEntries
+		// geosite: and ruleset: patterns are handled via geositeEntries
 	}
 
 	// Process geosite entries.
@@ -79,6 +100,7 @@ func NewDomainMatcher(rules []core.DomainRule, geositeEntries []GeositeExpanded)
 			Matched:  true,
 			TunnelID: ge.Rule.TunnelID,
 			Action:   ge.Rule.Action,
+			Pattern:  ge.Rule.Pattern,
 		}
 		value := strings.ToLower(ge.Value)
 
@@ -94,11 +116,48 @@ func NewDomainMatcher(rules []core.DomainRule, geositeEntries []GeositeExpanded)
 		}
 	}
 
+	// Process blocklist entries. They are kept apart from rules so that any
+	// rule or geosite match, however broad, takes precedence.
+	for _, le := range listEntries {
+		result := DomainMatchResult{
+			Matched:  true,
+			TunnelID: le.Rule.TunnelID,
+			Action:   le.Rule.Action,
+			Pattern:  le.Rule.Pattern,
+		}
+		value := strings.ToLower(le.Value)
+
+		switch le.Type {
+		case "full":
+			if _, exists := m.listFull[value]; !exists {
+				m.listFull[value] = result
+			}
+		case "domain":
+			insertTrieNode(m.listTrie, value, result)
+		}
+	}
+
+	// Process allowlist.
+	for _, pattern := range allow {
+		prefix, value := splitPattern(pattern)
+		value = strings.ToLower(strings.TrimSuffix(value, "."))
+		if value == "" {
+			continue
+		}
+		switch prefix {
+		case "full":
+			m.allowFull[value] = struct{}{}
+		case "domain":
+			insertTrieNode(m.allowTrie, value, DomainMatchResult{Matched: true})
+		}
+	}
+
 	return m
 }
 
 // Match looks up a domain name and returns the routing decision.
-// Priority: full: (exact) > domain: (suffix trie) > keyword: (linear scan).
+// Priority: full: (exact) > domain: (suffix trie) > keyword: (linear scan)
+// > blocklists (exact, then suffix), unless the domain is allowlisted.
 func (m *DomainMatcher) Match(domain string) DomainMatchResult {
 	if m == nil {
 		return DomainMatchResult{}
@@ -115,7 +174,7 @@ func (m *DomainMatcher) Match(domain string) DomainMatchResult {
 	}
 
 	// 2. Suffix trie (domain:) — matches domain and all subdomains.
-	if result := m.lookupTrie(domain); result != nil {
+	if result := lookupTrieNode(m.trie, domain); result != nil {
 		return *result
 	}
 
@@ -126,22 +185,51 @@ func (m *DomainMatcher) Match(domain string) DomainMatchResult {
 		}
 	}
 
+	// 4. Blocklists, skipped for allowlisted domains.
+	if m.isAllowlisted(domain) {
+		return DomainMatchResult{}
+	}
+	if result, ok := m.listFull[domain]; ok {
+		return result
+	}
+	if result := lookupTrieNode(m.listTrie, domain); result != nil {
+		return *result
+	}
+
 	return DomainMatchResult{}
 }
 
+// isAllowlisted reports whether blocklist entries must be ignored for domain.
+// Results from user rules and geosite are never overridden.
+func (m *DomainMatcher) isAllowlisted(domain string) bool {
+	if len(m.allowFull) == 0 && len(m.allowTrie.children) == 0 {
+		return false
+	}
+	if _, ok := m.allowFull[domain]; ok {
+		return true
+	}
+	return lookupTrieNode(m.allowTrie, domain) != nil
+}
+
 // IsEmpty returns true if the matcher has no rules.
 func (m *DomainMatcher) IsEmpty() bool {
 	if m == nil {
 		return true
 	}
-	return len(m.fullOnly) == 0 && len(m.keywords) == 0 && len(m.trie.children) == 0
+	return len(m.fullOnly) == 0 && len(m.keywords) == 0 && len(m.trie.children) == 0 &&
+		len(m.listFull) == 0 && len(m.listTrie.children) == 0
 }
 
 // insertTrie inserts a domain into the reversed-label suffix trie.
 // For "vk.com", labels are reversed to ["com", "vk"] and inserted as trie path.
 func (m *DomainMatcher) insertTrie(domain string, result DomainMatchResult) {
+	insertTrieNode(m.trie, domain, result)
+}
+
+// insertTrieNode inserts a domain under root. The first inserted result wins.
+func insertTrieNode(root *domainTrieNode, domain string, result DomainMatchResult) {
 	labels := strings.Split(domain, ".")
-	node := m.trie
+	node := root
 	// Walk labels in reverse order.
 	for i := len(labels) - 1; i >= 0; i-- {
 		label := labels[i]
@@ -164,11 +252,11 @@ func (m *DomainMatcher) insertTrie(domain string, result DomainMatchResult) {
 	}
 }
 
-// lookupTrie walks the reversed-label trie for the given domain.
+// lookupTrieNode walks the reversed-label trie under root for the given domain.
 // Returns the deepest (most specific) match, or nil if no match.
-func (m *DomainMatcher) lookupTrie(domain string) *DomainMatchResult {
+func lookupTrieNode(root *domainTrieNode, domain string) *DomainMatchResult {
 	labels := strings.Split(domain, ".")
-	node := m.trie
+	node := root
 	var lastMatch *DomainMatchResult
 
 	// Walk labels in reverse order (TLD first).
@@ -196,7 +284,7 @@ func splitPattern(pattern string) (string, string) {
 	if idx := strings.Index(pattern, ":"); idx > 0 {
 		prefix := pattern[:idx]
 		switch prefix {
-		case "domain", "full", "keyword", "geosite", "geoip":
+		case "domain", "full", "keyword", "geosite", "geoip", "ruleset":
 			return prefix, pattern[idx+1:]
 		}
 	}
@@ -0,0 +1,352 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// flowExportQueueSize buffers records between the FlowTable hook (which
+	// runs under a shard lock) and the writer goroutine.
+	flowExportQueueSize = 4096
+	// flowExportFlushInterval bounds how long a record may sit in write buffers.
+	flowExportFlushInterval = time.Second
+
+	flowLogDefaultPath      = "logs/flows.ndjson"
+	flowLogDefaultMaxSizeMB = 50
+	flowLogDefaultMaxFiles  = 5
+)
+
+// flowLogLine is the NDJSON representation of a FlowRecord.
+type flowLogLine struct {
+	Start      string `json:"start"`
+	End        string `json:"end"`
+	Proto      string `json:"proto"`
+	SrcIP      string `json:"src_ip"`
+	SrcPort    uint16 `json:"src_port"`
+	DstIP      string `json:"dst_ip"`
+	DstPort    uint16 `json:"dst_port"`
+	ResolvedIP string `json:"resolved_ip,omitempty"`
+	Domain     string `json:"domain,omitempty"`
+	Process    string `json:"process,omitempty"`
+	Tunnel     string `json:"tunnel"`
+	Rule       string `json:"rule,omitempty"`
+	Path       string `json:"path"` // "proxy" or "raw"
+	BytesOut   int64  `json:"bytes_out"`
+	BytesIn    int64  `json:"bytes_in"`
+}
+
+// FlowExporter writes one audit record per finished flow to a rotating NDJSON
+// file and, optionally, to an IPFIX/NetFlow v9 collector. Records are fed by
+// the FlowTable export hook via Enqueue.
+type FlowExporter struct {
+	baseDir string
+	localIP netip.Addr // client side of every flow (the TUN address)
+
+	domainTable atomic.Pointer[DomainTable]
+	enabled     atomic.Bool
+	queue       chan FlowRecord
+	dropped     atomic.Uint64
+
+	mu        sync.Mutex // guards sinks
+	file      *rotatingFile
+	collector *flowCollector
+
+	wg sync.WaitGroup
+}
+
+// NewFlowExporter creates a disabled exporter. Relative log paths are
+// resolved against baseDir; localIP is reported as the flow source address.
+func NewFlowExporter(baseDir string, localIP netip.Addr) *FlowExporter {
+	return &FlowExporter{
+		baseDir: baseDir,
+		localIP: localIP,
+		queue:   make(chan FlowRecord, flowExportQueueSize),
+	}
+}
+
+// SetDomainTable sets the table used to attach domain names to records.
+func (x *FlowExporter) SetDomainTable(dt *DomainTable) {
+	x.domainTable.Store(dt)
+}
+
+// Configure applies a flow log configuration, reopening the file and the
+// collector socket. A disabled config closes both.
+func (x *FlowExporter) Configure(cfg core.FlowLogConfig) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	x.enabled.Store(false)
+	x.closeSinksLocked()
+	if !cfg.Enabled {
+		return nil
+	}
+
+	path := cfg.Path
+	if path == "" {
+		path = flowLogDefaultPath
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(x.baseDir, path)
+	}
+	maxSize := cfg.MaxSizeMB
+	if maxSize == 0 {
+		maxSize = flowLogDefaultMaxSizeMB
+	}
+	maxFiles := cfg.MaxFiles
+	if maxFiles == 0 {
+		maxFiles = flowLogDefaultMaxFiles
+	}
+	f, err := openRotatingFile(path, int64(maxSize)<<20, maxFiles)
+	if err != nil {
+		return fmt.Errorf("flow log: %w", err)
+	}
+	x.file = f
+
+	if cfg.Collector != "" {
+		c, err := dialFlowCollector(cfg.Collector, cfg.CollectorFormat)
+		if err != nil {
+			core.Log.Warnf("Gateway", "Flow collector %s: %v", cfg.Collector, err)
+		} else {
+			x.collector = c
+		}
+	}
+
+	x.enabled.Store(true)
+	core.Log.Infof("Gateway", "Flow log enabled: %s", path)
+	return nil
+}
+
+// Enqueue queues a record for export. Never blocks: records are dropped when
+// the writer falls behind. Safe to use as the FlowTable export hook.
+func (x *FlowExporter) Enqueue(rec FlowRecord) {
+	if !x.enabled.Load() {
+		return
+	}
+	select {
+	case x.queue <- rec:
+	default:
+		x.dropped.Add(1)
+	}
+}
+
+// Start launches the writer goroutine. It drains the queue and closes the
+// sinks when ctx is cancelled.
+func (x *FlowExporter) Start(ctx context.Context) {
+	x.wg.Add(1)
+	core.SafeGoWG(&x.wg, "flow.export", func() {
+		ticker := time.NewTicker(flowExportFlushInterval)
+		defer ticker.Stop()
+		var lastDropped uint64
+		for {
+			select {
+			case <-ctx.Done():
+				x.drain()
+				x.mu.Lock()
+				x.closeSinksLocked()
+				x.mu.Unlock()
+				return
+			case rec := <-x.queue:
+				x.mu.Lock()
+				x.writeLocked(rec)
+				x.mu.Unlock()
+			case <-ticker.C:
+				x.mu.Lock()
+				x.flushLocked()
+				x.mu.Unlock()
+				if d := x.dropped.Load(); d != lastDropped {
+					core.Log.Warnf("Gateway", "Flow log: %d records dropped (queue full)", d-lastDropped)
+					lastDropped = d
+				}
+			}
+		}
+	})
+}
+
+// Wait blocks until the writer goroutine exits.
+// The caller must cancel the context passed to Start first.
+func (x *FlowExporter) Wait() { x.wg.Wait() }
+
+// drain writes all records still queued.
+func (x *FlowExporter) drain() {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	for {
+		select {
+		case rec := <-x.queue:
+			x.writeLocked(rec)
+		default:
+			return
+		}
+	}
+}
+
+func (x *FlowExporter) writeLocked(rec FlowRecord) {
+	if x.file != nil {
+		line := x.logLine(rec)
+		data, err := json.Marshal(&line)
+		if err == nil {
+			data = append(data, '\n')
+			if _, err = x.file.Write(data); err != nil {
+				core.Log.Warnf("Gateway", "Flow log write: %v", err)
+			}
+		}
+	}
+	if x.collector != nil && x.localIP.Is4() {
+		if err := x.collector.Add(rec, x.localIP); err != nil {
+			core.Log.Debugf("Gateway", "Flow collector send: %v", err)
+		}
+	}
+}
+
+func (x *FlowExporter) flushLocked() {
+	if x.file != nil {
+		if err := x.file.Flush(); err != nil {
+			core.Log.Warnf("Gateway", "Flow log flush: %v", err)
+		}
+	}
+	if x.collector != nil {
+		if err := x.collector.Flush(); err != nil {
+			core.Log.Debugf("Gateway", "Flow collector send: %v", err)
+		}
+	}
+}
+
+func (x *FlowExporter) closeSinksLocked() {
+	x.flushLocked()
+	if x.file != nil {
+		x.file.Close()
+		x.file = nil
+	}
+	if x.collector != nil {
+		x.collector.Close()
+		x.collector = nil
+	}
+}
+
+// logLine converts a record to its NDJSON form, resolving the domain name.
+func (x *FlowExporter) logLine(rec FlowRecord) flowLogLine {
+	line := flowLogLine{
+		Start:    time.Unix(rec.Start, 0).UTC().Format(time.RFC3339),
+		End:      time.Unix(rec.End, 0).UTC().Format(time.RFC3339),
+		Proto:    flowProtoName(rec.Protocol),
+		SrcPort:  rec.SrcPort,
+		DstIP:    rec.DstIP.String(),
+		DstPort:  rec.DstPort,
+		Process:  rec.ExeLower,
+		Tunnel:   rec.TunnelID,
+		Rule:     rec.Rule,
+		Path:     "proxy",
+		BytesOut: rec.TxBytes,
+		BytesIn:  rec.RxBytes,
+	}
+	if x.localIP.IsValid() {
+		line.SrcIP = x.localIP.String()
+	}
+	if rec.ResolvedDstIP.IsValid() {
+		line.ResolvedIP = rec.ResolvedDstIP.String()
+	}
+	if rec.Raw {
+		line.Path = "raw"
+	}
+	if dt := x.domainTable.Load(); dt != nil {
+		line.Domain = dt.ReverseLookup(rec.DstIP)
+	}
+	return line
+}
+
+func flowProtoName(proto uint8) string {
+	switch proto {
+	case protoTCP:
+		return "tcp"
+	case protoUDP:
+		return "udp"
+	case protoICMP:
+		return "icmp"
+	default:
+		return fmt.Sprintf("ip-%d", proto)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// rotatingFile — size-based rotation: flows.ndjson → flows.ndjson.1 → …
+// ---------------------------------------------------------------------------
+
+type rotatingFile struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	f    *os.File
+	w    *bufio.Writer
+	size int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxFiles int) (*rotatingFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	rf := &rotatingFile{path: path, maxSize: maxSize, maxFiles: maxFiles}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *rotatingFile) open() error {
+	f, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rf.f = f
+	rf.w = bufio.NewWriterSize(f, 64*1024)
+	rf.size = st.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it would push the file past maxSize.
+func (rf *rotatingFile) Write(p []byte) (int, error) {
+	if rf.size > 0 && rf.size+int64(len(p)) > rf.maxSize {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := rf.w.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+func (rf *rotatingFile) rotate() error {
+	rf.w.Flush()
+	rf.f.Close()
+	os.Remove(fmt.Sprintf("%s.%d", rf.path, rf.maxFiles))
+	for i := rf.maxFiles - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+	}
+	if err := os.Rename(rf.path, rf.path+".1"); err != nil {
+		core.Log.Warnf("Gateway", "Flow log rotate: %v", err)
+	}
+	return rf.open()
+}
+
+func (rf *rotatingFile) Flush() error { return rf.w.Flush() }
+
+func (rf *rotatingFile) Close() error {
+	rf.w.Flush()
+	return rf.f.Close()
+}
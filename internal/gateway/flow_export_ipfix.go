@@ -0,0 +1,222 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// flowTemplateID identifies our single data template (IDs < 256 are reserved).
+	flowTemplateID = 256
+	// flowTemplateRefresh is how often the template is resent over UDP so
+	// collectors that (re)start later can decode data sets.
+	flowTemplateRefresh = time.Minute
+	// flowCollectorBatch caps data records per message (~1.2 KB, below any MTU).
+	flowCollectorBatch = 40
+
+	ipfixVersion       = 10
+	ipfixHeaderLen     = 16
+	ipfixTemplateSetID = 2
+	nf9Version         = 9
+	nf9HeaderLen       = 20
+	nf9TemplateSetID   = 0
+)
+
+// flowTemplateField is one template field: information element IDs for IPFIX
+// and NetFlow v9 (identical except for timestamps) and the encoded length.
+type flowTemplateField struct {
+	ipfixID uint16
+	nf9ID   uint16
+	length  uint16
+}
+
+// flowTemplate is the fixed record layout: 5-tuple, byte count and timestamps.
+// IPFIX timestamps are flowStartSeconds/flowEndSeconds; NetFlow v9 uses
+// FIRST_SWITCHED/LAST_SWITCHED in milliseconds of exporter uptime.
+var flowTemplate = [...]flowTemplateField{
+	{8, 8, 4},    // sourceIPv4Address / IPV4_SRC_ADDR
+	{12, 12, 4},  // destinationIPv4Address / IPV4_DST_ADDR
+	{7, 7, 2},    // sourceTransportPort / L4_SRC_PORT
+	{11, 11, 2},  // destinationTransportPort / L4_DST_PORT
+	{4, 4, 1},    // protocolIdentifier / PROTOCOL
+	{1, 1, 8},    // octetDeltaCount / IN_BYTES
+	{150, 22, 4}, // flowStartSeconds / FIRST_SWITCHED
+	{151, 21, 4}, // flowEndSeconds / LAST_SWITCHED
+}
+
+// flowRecordLen is the encoded size of one data record.
+const flowRecordLen = 4 + 4 + 2 + 2 + 1 + 8 + 4 + 4
+
+// flowCollector batches flow records and sends them to a UDP collector as
+// IPFIX or NetFlow v9 messages. Each FlowRecord becomes two unidirectional
+// records (client → server and, if any bytes came back, server → client).
+// Not safe for concurrent use.
+type flowCollector struct {
+	conn     net.Conn
+	nf9      bool
+	boot     time.Time // NetFlow v9 sysUptime reference
+	sourceID uint32
+
+	seq          uint32 // IPFIX: data records sent; NetFlow v9: packets sent
+	records      []byte
+	count        int
+	lastTemplate time.Time
+}
+
+func dialFlowCollector(addr, format string) (*flowCollector, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return newFlowCollector(conn, format), nil
+}
+
+func newFlowCollector(conn net.Conn, format string) *flowCollector {
+	return &flowCollector{
+		conn:     conn,
+		nf9:      format == core.FlowFormatNetFlow9,
+		boot:     time.Now(),
+		sourceID: 1,
+		records:  make([]byte, 0, flowCollectorBatch*flowRecordLen),
+	}
+}
+
+// Add encodes a record and sends a message once the batch is full.
+func (c *flowCollector) Add(rec FlowRecord, localIP netip.Addr) error {
+	if !rec.DstIP.Is4() {
+		return nil
+	}
+	// Report the address actually contacted for FakeIP flows.
+	dst := rec.DstIP
+	if rec.ResolvedDstIP.Is4() {
+		dst = rec.ResolvedDstIP
+	}
+	src4, dst4 := localIP.As4(), dst.As4()
+
+	if err := c.appendRecord(src4, dst4, rec.SrcPort, rec.DstPort, rec.Protocol, rec.TxBytes, rec.Start, rec.End); err != nil {
+		return err
+	}
+	if rec.RxBytes > 0 {
+		return c.appendRecord(dst4, src4, rec.DstPort, rec.SrcPort, rec.Protocol, rec.RxBytes, rec.Start, rec.End)
+	}
+	return nil
+}
+
+func (c *flowCollector) appendRecord(src, dst [4]byte, srcPort, dstPort uint16, proto uint8, bytes, start, end int64) error {
+	b := c.records
+	b = append(b, src[:]...)
+	b = append(b, dst[:]...)
+	b = binary.BigEndian.AppendUint16(b, srcPort)
+	b = binary.BigEndian.AppendUint16(b, dstPort)
+	b = append(b, proto)
+	b = binary.BigEndian.AppendUint64(b, uint64(bytes))
+	b = binary.BigEndian.AppendUint32(b, c.timestamp(start))
+	b = binary.BigEndian.AppendUint32(b, c.timestamp(end))
+	c.records = b
+	c.count++
+	if c.count >= flowCollectorBatch {
+		return c.Flush()
+	}
+	return nil
+}
+
+// timestamp converts Unix seconds to the wire format: seconds for IPFIX,
+// milliseconds since exporter start for NetFlow v9 (clamped at zero).
+func (c *flowCollector) timestamp(unix int64) uint32 {
+	if !c.nf9 {
+		return uint32(unix)
+	}
+	ms := (unix - c.boot.Unix()) * 1000
+	if ms < 0 {
+		return 0
+	}
+	return uint32(ms)
+}
+
+// Flush sends pending records, prefixed with the template when it is due.
+func (c *flowCollector) Flush() error {
+	if c.count == 0 {
+		return nil
+	}
+	now := time.Now()
+	withTemplate := now.Sub(c.lastTemplate) >= flowTemplateRefresh
+	msg := c.message(now, withTemplate)
+	c.records = c.records[:0]
+	c.count = 0
+	if _, err := c.conn.Write(msg); err != nil {
+		return err
+	}
+	if withTemplate {
+		c.lastTemplate = now
+	}
+	return nil
+}
+
+// message builds one export packet from the pending records and advances
+// the sequence number.
+func (c *flowCollector) message(now time.Time, withTemplate bool) []byte {
+	hdrLen := ipfixHeaderLen
+	if c.nf9 {
+		hdrLen = nf9HeaderLen
+	}
+	msg := make([]byte, hdrLen, hdrLen+4+4+4*len(flowTemplate)+4+len(c.records)+3)
+
+	templateSetID := uint16(ipfixTemplateSetID)
+	if c.nf9 {
+		templateSetID = nf9TemplateSetID
+	}
+	if withTemplate {
+		setLen := 4 + 4 + 4*len(flowTemplate)
+		msg = binary.BigEndian.AppendUint16(msg, templateSetID)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(setLen))
+		msg = binary.BigEndian.AppendUint16(msg, flowTemplateID)
+		msg = binary.BigEndian.AppendUint16(msg, uint16(len(flowTemplate)))
+		for _, f := range flowTemplate {
+			id := f.ipfixID
+			if c.nf9 {
+				id = f.nf9ID
+			}
+			msg = binary.BigEndian.AppendUint16(msg, id)
+			msg = binary.BigEndian.AppendUint16(msg, f.length)
+		}
+	}
+
+	// Data set. NetFlow v9 flowsets are padded to a 4-byte boundary.
+	setLen := 4 + len(c.records)
+	pad := 0
+	if c.nf9 {
+		pad = (4 - setLen%4) % 4
+	}
+	msg = binary.BigEndian.AppendUint16(msg, flowTemplateID)
+	msg = binary.BigEndian.AppendUint16(msg, uint16(setLen+pad))
+	msg = append(msg, c.records...)
+	msg = append(msg, make([]byte, pad)...)
+
+	if c.nf9 {
+		count := c.count
+		if withTemplate {
+			count++
+		}
+		binary.BigEndian.PutUint16(msg[0:], nf9Version)
+		binary.BigEndian.PutUint16(msg[2:], uint16(count))
+		binary.BigEndian.PutUint32(msg[4:], uint32(now.Sub(c.boot).Milliseconds()))
+		binary.BigEndian.PutUint32(msg[8:], uint32(now.Unix()))
+		binary.BigEndian.PutUint32(msg[12:], c.seq)
+		binary.BigEndian.PutUint32(msg[16:], c.sourceID)
+		c.seq++
+	} else {
+		binary.BigEndian.PutUint16(msg[0:], ipfixVersion)
+		binary.BigEndian.PutUint16(msg[2:], uint16(len(msg)))
+		binary.BigEndian.PutUint32(msg[4:], uint32(now.Unix()))
+		binary.BigEndian.PutUint32(msg[8:], c.seq)
+		binary.BigEndian.PutUint32(msg[12:], c.sourceID)
+		c.seq += uint32(c.count)
+	}
+	return msg
+}
+
+func (c *flowCollector) Close() error { return c.conn.Close() }
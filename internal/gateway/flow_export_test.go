@@ -0,0 +1,162 @@
+package gateway
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestFlowTable_ExportHookOnDelete(t *testing.T) {
+	ft := NewFlowTable()
+	var got []FlowRecord
+	ft.SetFlowExportHook(func(rec FlowRecord) { got = append(got, rec) })
+
+	dst := netip.MustParseAddr("93.184.216.34")
+	ft.InsertTCP(dst, 50000, NATEntry{
+		LastActivity:    ft.NowSec(),
+		OriginalDstIP:   dst,
+		OriginalDstPort: 443,
+		TunnelID:        "vpn",
+		ExeLower:        `c:\apps\firefox.exe`,
+		Rule:            "firefox.exe",
+		TxBytes:         60,
+	})
+	ft.GetAndTouchTCP(dst, 50000, 100, 0)
+	ft.GetAndTouchTCP(dst, 50000, 0, 1500)
+	ft.DeleteTCP(dst, 50000)
+
+	if len(got) != 1 {
+		t.Fatalf("got %d records, want 1", len(got))
+	}
+	rec := got[0]
+	if rec.Protocol != protoTCP || rec.SrcPort != 50000 || rec.DstIP != dst || rec.DstPort != 443 {
+		t.Errorf("5-tuple = %d %d %s %d", rec.Protocol, rec.SrcPort, rec.DstIP, rec.DstPort)
+	}
+	if rec.TxBytes != 160 || rec.RxBytes != 1500 {
+		t.Errorf("bytes = %d/%d, want 160/1500", rec.TxBytes, rec.RxBytes)
+	}
+	if rec.Rule != "firefox.exe" || rec.TunnelID != "vpn" || rec.Start == 0 || rec.End < rec.Start {
+		t.Errorf("record = %+v", rec)
+	}
+}
+
+func TestFlowTable_RawRecordReportsFakeIP(t *testing.T) {
+	ft := NewFlowTable()
+	var got []FlowRecord
+	ft.SetFlowExportHook(func(rec FlowRecord) { got = append(got, rec) })
+
+	real4 := [4]byte{1, 1, 1, 1}
+	ft.InsertRawFlow(protoUDP, real4, 40000, RawFlowEntry{
+		TunnelID:  "vpn",
+		FakeIP:    [4]byte{198, 18, 0, 5},
+		RealDstIP: real4,
+		DstPort:   443,
+	})
+	ft.DeleteRawFlow(protoUDP, real4, 40000)
+
+	if len(got) != 1 {
+		t.Fatalf("got %d records, want 1", len(got))
+	}
+	if got[0].DstIP != netip.MustParseAddr("198.18.0.5") || got[0].ResolvedDstIP != netip.MustParseAddr("1.1.1.1") || !got[0].Raw {
+		t.Errorf("record = %+v", got[0])
+	}
+}
+
+func TestRotatingFile_Rotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flows.ndjson")
+	rf, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := rf.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rf.Close()
+
+	for name, want := range map[string]string{
+		path:        "dddddddd\n",
+		path + ".1": "cccccccc\n",
+		path + ".2": "bbbbbbbb\n",
+	} {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", filepath.Base(name), data, want)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected only 2 rotated files")
+	}
+}
+
+func TestFlowCollector_Messages(t *testing.T) {
+	rec := FlowRecord{
+		Protocol: protoTCP,
+		SrcPort:  50000,
+		DstIP:    netip.MustParseAddr("93.184.216.34"),
+		DstPort:  443,
+		Start:    time.Now().Unix(),
+		End:      time.Now().Unix(),
+		TxBytes:  100,
+		RxBytes:  2000,
+	}
+	local := netip.MustParseAddr("10.255.0.1")
+
+	for _, tc := range []struct {
+		format  string
+		version uint16
+		hdrLen  int
+	}{
+		{core.FlowFormatIPFIX, ipfixVersion, ipfixHeaderLen},
+		{core.FlowFormatNetFlow9, nf9Version, nf9HeaderLen},
+	} {
+		client, server := net.Pipe()
+		c := newFlowCollector(client, tc.format)
+		c.Add(rec, local)
+		if c.count != 2 {
+			t.Fatalf("%s: got %d data records, want 2 (one per direction)", tc.format, c.count)
+		}
+		msg := c.message(time.Now(), true)
+		client.Close()
+		server.Close()
+
+		if v := binary.BigEndian.Uint16(msg); v != tc.version {
+			t.Errorf("%s: version = %d", tc.format, v)
+		}
+		// Template set, then data set.
+		off := tc.hdrLen
+		tmplLen := int(binary.BigEndian.Uint16(msg[off+2:]))
+		if tmplLen != 8+4*len(flowTemplate) {
+			t.Errorf("%s: template set length = %d", tc.format, tmplLen)
+		}
+		off += tmplLen
+		if id := binary.BigEndian.Uint16(msg[off:]); id != flowTemplateID {
+			t.Errorf("%s: data set id = %d", tc.format, id)
+		}
+		dataLen := int(binary.BigEndian.Uint16(msg[off+2:]))
+		if off+dataLen != len(msg) || (tc.format == core.FlowFormatNetFlow9 && dataLen%4 != 0) {
+			t.Errorf("%s: data set length %d at %d, message %d", tc.format, dataLen, off, len(msg))
+		}
+		if tc.format == core.FlowFormatIPFIX && int(binary.BigEndian.Uint16(msg[2:])) != len(msg) {
+			t.Errorf("ipfix: header length mismatch")
+		}
+		// Reverse record: server → client with the received byte count.
+		second := msg[off+4+flowRecordLen:]
+		if src := netip.AddrFrom4([4]byte(second[0:4])); src != rec.DstIP {
+			t.Errorf("%s: reverse src = %s", tc.format, src)
+		}
+		if n := binary.BigEndian.Uint64(second[13:]); n != 2000 {
+			t.Errorf("%s: reverse bytes = %d", tc.format, n)
+		}
+	}
+}
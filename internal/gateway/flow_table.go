@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/shaping"
 )
 
 // stringIntern deduplicates frequently repeated strings (tunnelID, exeLower,
@@ -61,9 +62,15 @@ type NATEntry struct {
 	ExeLower  string // pre-lowered exe path for failover re-matching
 	BaseLower string // pre-lowered exe basename
 	RuleIdx   int    // index of matched rule in RuleEngine
+	Rule      string // pattern of the matched process rule, "" if none
 
 	// FakeIP: real IP for dial when OriginalDstIP is a FakeIP.
 	ResolvedDstIP netip.Addr
+
+	// Audit accounting (see FlowRecord).
+	StartTime int64 // Unix seconds; set on insert
+	TxBytes   int64 // atomic; client → server
+	RxBytes   int64 // atomic; server → client
 }
 
 // UDPNATEntry maps a redirected UDP flow back to its original destination.
@@ -80,9 +87,16 @@ type UDPNATEntry struct {
 	ExeLower  string
 	BaseLower string
 	RuleIdx   int
+	Rule      string
+	FullCone  bool // udp_nat: full_cone; the UDP proxy shares one socket per client port
 
 	// FakeIP: real IP for dial when OriginalDstIP is a FakeIP.
 	ResolvedDstIP netip.Addr
+
+	// Audit accounting (see FlowRecord).
+	StartTime int64 // Unix seconds; set on insert
+	TxBytes   int64 // atomic; client → server
+	RxBytes   int64 // atomic; server → client
 }
 
 // ---------------------------------------------------------------------------
@@ -101,6 +115,20 @@ type RawFlowEntry struct {
 	RealDstIP    [4]byte // real IP destination (for FakeIP rewriting)
 	ExeLower     string  // cached lowercase exe path (for monitoring)
 	BaseLower    string  // cached lowercase base name (for monitoring)
+	Rule         string  // pattern of the matched process rule, "" if none
+	DstPort      uint16  // original destination port (0 for ICMP)
+
+	// Rate limiters (global, tunnel, rule); see shaping.Shaper.Flow.
+	Shape shaping.Flow
+
+	// FullCone accepts inbound UDP from any remote to this client port
+	// (udp_nat: full_cone); see AddRawCone.
+	FullCone bool
+
+	// Audit accounting (see FlowRecord).
+	StartTime int64 // Unix seconds; set on insert
+	TxBytes   int64 // atomic; client → server
+	RxBytes   int64 // atomic; server → client
 }
 
 // NATSnapshotEntry is a lightweight copy of a TCP NAT entry for monitoring.
@@ -236,6 +264,13 @@ type FlowTable struct {
 	// Hook called before removing stale raw flows (e.g. for FakeIP flow counting).
 	rawFlowCleanupHook atomic.Pointer[func(*RawFlowEntry)]
 
+	// Hook receiving a FlowRecord for every flow removed from any table.
+	flowExportHook atomic.Pointer[func(FlowRecord)]
+
+	// Full-cone UDP mappings of raw flows (see full_cone.go).
+	conesMu sync.RWMutex
+	cones   map[coneKey]*coneEntry
+
 	// wg tracks background goroutines (cleanup loops, timestamp updater).
 	wg sync.WaitGroup
 }
@@ -303,8 +338,13 @@ func (ft *FlowTable) InsertTCP(dstIP netip.Addr, srcPort uint16, entry NATEntry)
 	entry.TunnelID = internStr(entry.TunnelID)
 	entry.ExeLower = internStr(entry.ExeLower)
 	entry.BaseLower = internStr(entry.BaseLower)
+	entry.Rule = internStr(entry.Rule)
+	if entry.StartTime == 0 {
+		entry.StartTime = ft.nowSec.Load()
+	}
 	nk := makeNATKey(dstIP, srcPort)
 	shard := &ft.tcp[natShardIndex(nk)]
+	export := ft.flowExportHook.Load()
 	shard.mu.Lock()
 	if len(shard.index) >= maxEntriesPerShard {
 		// LRU eviction: find entry with oldest LastActivity.
@@ -319,6 +359,9 @@ func (ft *FlowTable) InsertTCP(dstIP netip.Addr, srcPort uint16, entry NATEntry)
 				oldestIdx = idx
 			}
 		}
+		if export != nil {
+			(*export)(tcpFlowRecord(oldestKey, &shard.store[oldestIdx]))
+		}
 		shard.store[oldestIdx] = NATEntry{}
 		shard.free = append(shard.free, oldestIdx)
 		delete(shard.index, oldestKey)
@@ -369,10 +412,11 @@ func (ft *FlowTable) TouchTCP(dstIP netip.Addr, srcPort uint16) {
 	shard.mu.RUnlock()
 }
 
-// GetAndTouchTCP returns a copy of a TCP NAT entry and updates its LastActivity
-// timestamp in a single RLock acquisition, eliminating the double-lock overhead
-// of a separate Get + Touch call pair on the hot path.
-func (ft *FlowTable) GetAndTouchTCP(dstIP netip.Addr, srcPort uint16) (NATEntry, bool) {
+// GetAndTouchTCP returns a copy of a TCP NAT entry, updates its LastActivity
+// timestamp and adds tx/rx to its byte counters in a single RLock acquisition,
+// eliminating the double-lock overhead of a separate Get + Touch call pair on
+// the hot path.
+func (ft *FlowTable) GetAndTouchTCP(dstIP netip.Addr, srcPort uint16, tx, rx int64) (NATEntry, bool) {
 	nk := makeNATKey(dstIP, srcPort)
 	shard := &ft.tcp[natShardIndex(nk)]
 	shard.mu.RLock()
@@ -386,6 +430,7 @@ func (ft *FlowTable) GetAndTouchTCP(dstIP netip.Addr, srcPort uint16) (NATEntry,
 		return NATEntry{}, false
 	}
 	atomic.StoreInt64(&shard.store[idx].LastActivity, ft.nowSec.Load())
+	addFlowBytes(&shard.store[idx].TxBytes, &shard.store[idx].RxBytes, tx, rx)
 	entry := shard.store[idx]
 	shard.mu.RUnlock()
 	return entry, true
@@ -411,8 +456,12 @@ func (ft *FlowTable) SetFinTCP(dstIP netip.Addr, srcPort uint16, finBit int32) {
 func (ft *FlowTable) DeleteTCP(dstIP netip.Addr, srcPort uint16) {
 	nk := makeNATKey(dstIP, srcPort)
 	shard := &ft.tcp[natShardIndex(nk)]
+	export := ft.flowExportHook.Load()
 	shard.mu.Lock()
 	if idx, ok := shard.index[nk]; ok {
+		if export != nil {
+			(*export)(tcpFlowRecord(nk, &shard.store[idx]))
+		}
 		shard.store[idx] = NATEntry{} // zero to release strings
 		shard.free = append(shard.free, idx)
 		delete(shard.index, nk)
@@ -447,6 +496,7 @@ func (ft *FlowTable) LookupNAT(addrKey string) (core.NATInfo, bool) {
 		ExeLower:    entry.ExeLower,
 		BaseLower:   entry.BaseLower,
 		RuleIdx:     entry.RuleIdx,
+		Rule:        entry.Rule,
 	}
 
 	// If FakeIP resolved a real destination, provide it for dial.
@@ -458,6 +508,24 @@ func (ft *FlowTable) LookupNAT(addrKey string) (core.NATInfo, bool) {
 	return info, true
 }
 
+// SetTunnelTCP records the tunnel that actually carries a proxied TCP
+// connection, so stats and the connection monitor follow fallback.
+// Compatible with proxy.TunnelReporter callback signature.
+func (ft *FlowTable) SetTunnelTCP(addrKey, tunnelID string) {
+	ap, err := netip.ParseAddrPort(addrKey)
+	if err != nil {
+		return
+	}
+	nk := makeNATKey(ap.Addr(), ap.Port())
+	shard := &ft.tcp[natShardIndex(nk)]
+	tunnelID = internStr(tunnelID)
+	shard.mu.Lock()
+	if idx, ok := shard.index[nk]; ok {
+		shard.store[idx].TunnelID = tunnelID
+	}
+	shard.mu.Unlock()
+}
+
 // ---------------------------------------------------------------------------
 // UDP NAT operations
 // ---------------------------------------------------------------------------
@@ -468,8 +536,13 @@ func (ft *FlowTable) InsertUDP(dstIP netip.Addr, srcPort uint16, entry UDPNATEnt
 	entry.TunnelID = internStr(entry.TunnelID)
 	entry.ExeLower = internStr(entry.ExeLower)
 	entry.BaseLower = internStr(entry.BaseLower)
+	entry.Rule = internStr(entry.Rule)
+	if entry.StartTime == 0 {
+		entry.StartTime = ft.nowSec.Load()
+	}
 	nk := makeNATKey(dstIP, srcPort)
 	shard := &ft.udp[natShardIndex(nk)]
+	export := ft.flowExportHook.Load()
 	shard.mu.Lock()
 	if len(shard.index) >= maxEntriesPerShard {
 		var oldestKey natKey
@@ -483,6 +556,9 @@ func (ft *FlowTable) InsertUDP(dstIP netip.Addr, srcPort uint16, entry UDPNATEnt
 				oldestIdx = idx
 			}
 		}
+		if export != nil {
+			(*export)(udpFlowRecord(oldestKey, &shard.store[oldestIdx]))
+		}
 		shard.store[oldestIdx] = UDPNATEntry{}
 		shard.free = append(shard.free, oldestIdx)
 		delete(shard.index, oldestKey)
@@ -533,10 +609,11 @@ func (ft *FlowTable) TouchUDP(dstIP netip.Addr, srcPort uint16) {
 	shard.mu.RUnlock()
 }
 
-// GetAndTouchUDP returns a copy of a UDP NAT entry and updates its LastActivity
-// timestamp in a single RLock acquisition, eliminating the double-lock overhead
-// of a separate Get + Touch call pair on the hot path.
-func (ft *FlowTable) GetAndTouchUDP(dstIP netip.Addr, srcPort uint16) (UDPNATEntry, bool) {
+// GetAndTouchUDP returns a copy of a UDP NAT entry, updates its LastActivity
+// timestamp and adds tx/rx to its byte counters in a single RLock acquisition,
+// eliminating the double-lock overhead of a separate Get + Touch call pair on
+// the hot path.
+func (ft *FlowTable) GetAndTouchUDP(dstIP netip.Addr, srcPort uint16, tx, rx int64) (UDPNATEntry, bool) {
 	nk := makeNATKey(dstIP, srcPort)
 	shard := &ft.udp[natShardIndex(nk)]
 	shard.mu.RLock()
@@ -550,6 +627,7 @@ func (ft *FlowTable) GetAndTouchUDP(dstIP netip.Addr, srcPort uint16) (UDPNATEnt
 		return UDPNATEntry{}, false
 	}
 	atomic.StoreInt64(&shard.store[idx].LastActivity, ft.nowSec.Load())
+	addFlowBytes(&shard.store[idx].TxBytes, &shard.store[idx].RxBytes, tx, rx)
 	entry := shard.store[idx]
 	shard.mu.RUnlock()
 	return entry, true
@@ -582,6 +660,8 @@ func (ft *FlowTable) LookupUDPNAT(addrKey string) (core.NATInfo, bool) {
 		ExeLower:    entry.ExeLower,
 		BaseLower:   entry.BaseLower,
 		RuleIdx:     entry.RuleIdx,
+		Rule:        entry.Rule,
+		FullCone:    entry.FullCone,
 	}
 
 	// If FakeIP resolved a real destination, provide it for dial.
@@ -593,6 +673,22 @@ func (ft *FlowTable) LookupUDPNAT(addrKey string) (core.NATInfo, bool) {
 	return info, true
 }
 
+// SetTunnelUDP is the UDP equivalent of SetTunnelTCP.
+func (ft *FlowTable) SetTunnelUDP(addrKey, tunnelID string) {
+	ap, err := netip.ParseAddrPort(addrKey)
+	if err != nil {
+		return
+	}
+	nk := makeNATKey(ap.Addr(), ap.Port())
+	shard := &ft.udp[natShardIndex(nk)]
+	tunnelID = internStr(tunnelID)
+	shard.mu.Lock()
+	if idx, ok := shard.index[nk]; ok {
+		shard.store[idx].TunnelID = tunnelID
+	}
+	shard.mu.Unlock()
+}
+
 // ---------------------------------------------------------------------------
 // Raw flow operations — for raw IP forwarding (bypass TCP proxy + gVisor)
 // ---------------------------------------------------------------------------
@@ -601,8 +697,13 @@ func (ft *FlowTable) LookupUDPNAT(addrKey string) (core.NATInfo, bool) {
 // Evicts a random entry if the shard is at capacity.
 func (ft *FlowTable) InsertRawFlow(proto byte, dstIP [4]byte, srcPort uint16, entry RawFlowEntry) {
 	entry.TunnelID = internStr(entry.TunnelID)
+	entry.Rule = internStr(entry.Rule)
+	if entry.StartTime == 0 {
+		entry.StartTime = ft.nowSec.Load()
+	}
 	k := makeRawFlowKey(proto, dstIP, srcPort)
 	shard := &ft.raw[rawFlowShardIndex(k)]
+	export := ft.flowExportHook.Load()
 	shard.mu.Lock()
 	if len(shard.index) >= maxEntriesPerShard {
 		var oldestKey rawFlowKey
@@ -616,6 +717,9 @@ func (ft *FlowTable) InsertRawFlow(proto byte, dstIP [4]byte, srcPort uint16, en
 				oldestIdx = idx
 			}
 		}
+		if export != nil {
+			(*export)(rawFlowRecord(oldestKey, &shard.store[oldestIdx]))
+		}
 		shard.store[oldestIdx] = RawFlowEntry{}
 		shard.free = append(shard.free, oldestIdx)
 		delete(shard.index, oldestKey)
@@ -666,10 +770,11 @@ func (ft *FlowTable) TouchRawFlow(proto byte, dstIP [4]byte, srcPort uint16) {
 	shard.mu.RUnlock()
 }
 
-// GetAndTouchRawFlow returns a copy of a raw flow entry and updates its
-// LastActivity timestamp in a single RLock acquisition, eliminating the
-// double-lock overhead of a separate Get + Touch call pair on the hot path.
-func (ft *FlowTable) GetAndTouchRawFlow(proto byte, dstIP [4]byte, srcPort uint16) (RawFlowEntry, bool) {
+// GetAndTouchRawFlow returns a copy of a raw flow entry, updates its
+// LastActivity timestamp and adds tx/rx to its byte counters in a single RLock
+// acquisition, eliminating the double-lock overhead of a separate Get + Touch
+// call pair on the hot path.
+func (ft *FlowTable) GetAndTouchRawFlow(proto byte, dstIP [4]byte, srcPort uint16, tx, rx int64) (RawFlowEntry, bool) {
 	k := makeRawFlowKey(proto, dstIP, srcPort)
 	shard := &ft.raw[rawFlowShardIndex(k)]
 	shard.mu.RLock()
@@ -683,6 +788,7 @@ func (ft *FlowTable) GetAndTouchRawFlow(proto byte, dstIP [4]byte, srcPort uint1
 		return RawFlowEntry{}, false
 	}
 	atomic.StoreInt64(&shard.store[idx].LastActivity, ft.nowSec.Load())
+	addFlowBytes(&shard.store[idx].TxBytes, &shard.store[idx].RxBytes, tx, rx)
 	entry := shard.store[idx]
 	shard.mu.RUnlock()
 	return entry, true
@@ -692,8 +798,12 @@ func (ft *FlowTable) GetAndTouchRawFlow(proto byte, dstIP [4]byte, srcPort uint1
 func (ft *FlowTable) DeleteRawFlow(proto byte, dstIP [4]byte, srcPort uint16) {
 	k := makeRawFlowKey(proto, dstIP, srcPort)
 	shard := &ft.raw[rawFlowShardIndex(k)]
+	export := ft.flowExportHook.Load()
 	shard.mu.Lock()
 	if idx, ok := shard.index[k]; ok {
+		if export != nil {
+			(*export)(rawFlowRecord(k, &shard.store[idx]))
+		}
 		shard.store[idx] = RawFlowEntry{}
 		shard.free = append(shard.free, idx)
 		delete(shard.index, k)
@@ -759,21 +869,30 @@ func (ft *FlowTable) StartRawFlowCleanup(ctx context.Context, initialDelay time.
 				now := ft.nowSec.Load()
 				const timeout int64 = 300
 				var marked int
+				cones := ft.coneActivity()
 
 				for i := range ft.raw {
 					shard := &ft.raw[i]
 					shard.mu.RLock()
-					for _, idx := range shard.index {
+					for k, idx := range shard.index {
 						if atomic.LoadInt32(&shard.store[idx].Dead) != 0 {
 							continue
 						}
-						if now-atomic.LoadInt64(&shard.store[idx].LastActivity) > timeout {
+						last := atomic.LoadInt64(&shard.store[idx].LastActivity)
+						if now-last > timeout {
 							atomic.StoreInt32(&shard.store[idx].Dead, 1)
 							marked++
+						} else if cones != nil && shard.store[idx].FullCone {
+							ck := coneKey{vpnIP: shard.store[idx].VpnIP, port: rawFlowKeySrcPort(k)}
+							u := cones[ck]
+							u.last = max(u.last, last)
+							u.flows++
+							cones[ck] = u
 						}
 					}
 					shard.mu.RUnlock()
 				}
+				ft.expireRawCones(cones, now-timeout)
 
 				if marked > 0 {
 					core.Log.Debugf("Gateway", "Raw flow cleanup: marked %d entries dead", marked)
@@ -789,6 +908,17 @@ func (ft *FlowTable) SetRawFlowCleanupHook(hook func(*RawFlowEntry)) {
 	ft.rawFlowCleanupHook.Store(&hook)
 }
 
+// SetFlowExportHook sets a callback that receives a FlowRecord for every flow
+// leaving the table (compaction, explicit delete, LRU eviction). The hook runs
+// under a shard write lock and must not block. Pass nil to remove it.
+func (ft *FlowTable) SetFlowExportHook(hook func(FlowRecord)) {
+	if hook == nil {
+		ft.flowExportHook.Store(nil)
+		return
+	}
+	ft.flowExportHook.Store(&hook)
+}
+
 // ---------------------------------------------------------------------------
 // Proxy port management — lock-free reads via atomic copy-on-write
 // ---------------------------------------------------------------------------
@@ -1127,12 +1257,13 @@ func (ft *FlowTable) StartCompaction(ctx context.Context) {
 			}
 
 			var totalTCP, totalUDP, totalRaw int
+			export := ft.flowExportHook.Load()
 
 			for i := range ft.tcp {
 				if ctx.Err() != nil {
 					return
 				}
-				totalTCP += ft.compactTCPShard(&ft.tcp[i])
+				totalTCP += ft.compactTCPShard(&ft.tcp[i], export)
 				runtime.Gosched()
 			}
 
@@ -1140,7 +1271,7 @@ func (ft *FlowTable) StartCompaction(ctx context.Context) {
 				if ctx.Err() != nil {
 					return
 				}
-				totalUDP += ft.compactUDPShard(&ft.udp[i])
+				totalUDP += ft.compactUDPShard(&ft.udp[i], export)
 				runtime.Gosched()
 			}
 
@@ -1149,7 +1280,7 @@ func (ft *FlowTable) StartCompaction(ctx context.Context) {
 				if ctx.Err() != nil {
 					return
 				}
-				totalRaw += ft.compactRawShard(&ft.raw[i], hookPtr)
+				totalRaw += ft.compactRawShard(&ft.raw[i], hookPtr, export)
 				runtime.Gosched()
 			}
 
@@ -1162,7 +1293,7 @@ func (ft *FlowTable) StartCompaction(ctx context.Context) {
 	})
 }
 
-func (ft *FlowTable) compactTCPShard(shard *tcpNATShard) int {
+func (ft *FlowTable) compactTCPShard(shard *tcpNATShard, export *func(FlowRecord)) int {
 	// Quick RLock scan: skip shard entirely if no dead entries.
 	shard.mu.RLock()
 	hasDead := false
@@ -1183,6 +1314,9 @@ func (ft *FlowTable) compactTCPShard(shard *tcpNATShard) int {
 	var removed int
 	for k, idx := range shard.index {
 		if atomic.LoadInt32(&shard.store[idx].Dead) != 0 {
+			if export != nil {
+				(*export)(tcpFlowRecord(k, &shard.store[idx]))
+			}
 			shard.store[idx] = NATEntry{}
 			shard.free = append(shard.free, idx)
 			delete(shard.index, k)
@@ -1199,7 +1333,7 @@ func (ft *FlowTable) compactTCPShard(shard *tcpNATShard) int {
 	return removed
 }
 
-func (ft *FlowTable) compactUDPShard(shard *udpNATShard) int {
+func (ft *FlowTable) compactUDPShard(shard *udpNATShard, export *func(FlowRecord)) int {
 	shard.mu.RLock()
 	hasDead := false
 	for _, idx := range shard.index {
@@ -1219,6 +1353,9 @@ func (ft *FlowTable) compactUDPShard(shard *udpNATShard) int {
 	var removed int
 	for k, idx := range shard.index {
 		if atomic.LoadInt32(&shard.store[idx].Dead) != 0 {
+			if export != nil {
+				(*export)(udpFlowRecord(k, &shard.store[idx]))
+			}
 			shard.store[idx] = UDPNATEntry{}
 			shard.free = append(shard.free, idx)
 			delete(shard.index, k)
@@ -1235,7 +1372,7 @@ func (ft *FlowTable) compactUDPShard(shard *udpNATShard) int {
 	return removed
 }
 
-func (ft *FlowTable) compactRawShard(shard *rawFlowShard, hookPtr *func(*RawFlowEntry)) int {
+func (ft *FlowTable) compactRawShard(shard *rawFlowShard, hookPtr *func(*RawFlowEntry), export *func(FlowRecord)) int {
 	shard.mu.RLock()
 	hasDead := false
 	for _, idx := range shard.index {
@@ -1258,6 +1395,9 @@ func (ft *FlowTable) compactRawShard(shard *rawFlowShard, hookPtr *func(*RawFlow
 			if hookPtr != nil {
 				(*hookPtr)(&shard.store[idx])
 			}
+			if export != nil {
+				(*export)(rawFlowRecord(k, &shard.store[idx]))
+			}
 			shard.store[idx] = RawFlowEntry{}
 			shard.free = append(shard.free, idx)
 			delete(shard.index, k)
@@ -1317,3 +1457,117 @@ func (ft *FlowTable) MarkDeadRawFlow(proto byte, dstIP [4]byte, srcPort uint16)
 	shard.mu.RUnlock()
 	return ok
 }
+
+// ---------------------------------------------------------------------------
+// Flow records — audit export of finished flows
+// ---------------------------------------------------------------------------
+
+// FlowRecord describes a flow that has left the FlowTable.
+type FlowRecord struct {
+	Protocol      uint8      // protoTCP, protoUDP or protoICMP
+	SrcPort       uint16     // client source port (ICMP: echo identifier)
+	DstIP         netip.Addr // destination as seen by the client (may be a FakeIP)
+	ResolvedDstIP netip.Addr // real destination of a FakeIP flow, invalid otherwise
+	DstPort       uint16
+	TunnelID      string
+	ExeLower      string
+	Rule          string // matched process rule pattern, "" if none
+	Raw           bool   // forwarded as raw IP rather than via the tunnel proxy
+	Start         int64  // Unix seconds
+	End           int64  // Unix seconds of last activity
+	TxBytes       int64  // client → server
+	RxBytes       int64  // server → client
+}
+
+// tcpFinGrace is how far SetFinTCP backdates LastActivity to accelerate
+// cleanup; flow records undo it to report the real end time.
+const tcpFinGrace = 298
+
+// addFlowBytes adds packet lengths to an entry's atomic byte counters.
+func addFlowBytes(txCounter, rxCounter *int64, tx, rx int64) {
+	if tx != 0 {
+		atomic.AddInt64(txCounter, tx)
+	}
+	if rx != 0 {
+		atomic.AddInt64(rxCounter, rx)
+	}
+}
+
+// flowEnd clamps a record end time so it never precedes the start time.
+func flowEnd(start, lastActivity int64) int64 {
+	if lastActivity < start {
+		return start
+	}
+	return lastActivity
+}
+
+func natKeySrcPort(k natKey) uint16 { return uint16(k[4])<<8 | uint16(k[5]) }
+
+func rawFlowKeySrcPort(k rawFlowKey) uint16 { return uint16(k[5])<<8 | uint16(k[6]) }
+
+// tcpFlowRecord builds a record for a TCP NAT entry. Caller holds the shard lock.
+func tcpFlowRecord(k natKey, e *NATEntry) FlowRecord {
+	last := atomic.LoadInt64(&e.LastActivity)
+	if atomic.LoadInt32(&e.FinSeen) == 0x3 {
+		last += tcpFinGrace
+	}
+	return FlowRecord{
+		Protocol:      protoTCP,
+		SrcPort:       natKeySrcPort(k),
+		DstIP:         e.OriginalDstIP,
+		ResolvedDstIP: e.ResolvedDstIP,
+		DstPort:       e.OriginalDstPort,
+		TunnelID:      e.TunnelID,
+		ExeLower:      e.ExeLower,
+		Rule:          e.Rule,
+		Start:         e.StartTime,
+		End:           flowEnd(e.StartTime, last),
+		TxBytes:       atomic.LoadInt64(&e.TxBytes),
+		RxBytes:       atomic.LoadInt64(&e.RxBytes),
+	}
+}
+
+// udpFlowRecord builds a record for a UDP NAT entry. Caller holds the shard lock.
+func udpFlowRecord(k natKey, e *UDPNATEntry) FlowRecord {
+	return FlowRecord{
+		Protocol:      protoUDP,
+		SrcPort:       natKeySrcPort(k),
+		DstIP:         e.OriginalDstIP,
+		ResolvedDstIP: e.ResolvedDstIP,
+		DstPort:       e.OriginalDstPort,
+		TunnelID:      e.TunnelID,
+		ExeLower:      e.ExeLower,
+		Rule:          e.Rule,
+		Start:         e.StartTime,
+		End:           flowEnd(e.StartTime, atomic.LoadInt64(&e.LastActivity)),
+		TxBytes:       atomic.LoadInt64(&e.TxBytes),
+		RxBytes:       atomic.LoadInt64(&e.RxBytes),
+	}
+}
+
+// rawFlowRecord builds a record for a raw flow entry. Caller holds the shard lock.
+func rawFlowRecord(k rawFlowKey, e *RawFlowEntry) FlowRecord {
+	var dst4 [4]byte
+	copy(dst4[:], k[1:5])
+	rec := FlowRecord{
+		Protocol: k[0],
+		SrcPort:  rawFlowKeySrcPort(k),
+		DstIP:    netip.AddrFrom4(dst4),
+		DstPort:  e.DstPort,
+		TunnelID: e.TunnelID,
+		ExeLower: e.ExeLower,
+		Rule:     e.Rule,
+		Raw:      true,
+		Start:    e.StartTime,
+		End:      flowEnd(e.StartTime, atomic.LoadInt64(&e.LastActivity)),
+		TxBytes:  atomic.LoadInt64(&e.TxBytes),
+		RxBytes:  atomic.LoadInt64(&e.RxBytes),
+	}
+	// Raw flows are keyed by the real destination; report the FakeIP the
+	// client actually connected to, like the proxy tables do.
+	if e.FakeIP != [4]byte{} {
+		rec.DstIP = netip.AddrFrom4(e.FakeIP)
+		rec.ResolvedDstIP = netip.AddrFrom4(dst4)
+	}
+	return rec
+}
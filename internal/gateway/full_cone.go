@@ -0,0 +1,169 @@
+package gateway
+
+import (
+	"net/netip"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// ---------------------------------------------------------------------------
+// Full-cone UDP NAT (udp_nat: full_cone)
+//
+// Raw flows and proxy NAT entries are keyed by remote address, so only the
+// remote a client sent to can answer (symmetric NAT). Games and WebRTC learn
+// their public port from a STUN server and expect other peers to reach it.
+// For rules with udp_nat: full_cone the client port stays reachable from any
+// remote for as long as one of its flows is alive:
+//
+//   - raw flows: the first outbound flow records a cone for (VPN IP, client
+//     port); an inbound datagram from an unknown remote to that port creates
+//     a raw flow copied from it (AcceptRawCone).
+//   - UDP proxy: the proxy sends through one unconnected tunnel socket per
+//     client port and registers a NAT entry for each new remote
+//     (RegisterUDPPeer) so the reply is hairpinned with the right source.
+//
+// The tunnel server's own NAT still decides what reaches the tunnel.
+// ---------------------------------------------------------------------------
+
+// maxConeRemotes bounds the live raw flows of one full-cone mapping, so a
+// flood of senders to an open client port cannot grow the flow table without
+// limit. Mirrors maxConePeers of the UDP proxy.
+const maxConeRemotes = 1024
+
+// coneKey identifies a full-cone mapping of a raw flow: the tunnel VPN IP
+// and the client source port.
+type coneKey struct {
+	vpnIP [4]byte
+	port  uint16
+}
+
+// coneEntry is a live full-cone mapping. tmpl is copied into the raw flows
+// created for datagrams from new remotes.
+type coneEntry struct {
+	lastActivity int64 // Unix seconds; updated by the raw flow cleanup
+	flows        int   // live raw flows; recounted by the raw flow cleanup
+	tmpl         RawFlowEntry
+}
+
+// coneUsage is what the raw flow cleanup found for one mapping.
+type coneUsage struct {
+	last  int64 // latest activity of its live flows
+	flows int
+}
+
+// AddRawCone records a full-cone mapping for a new outbound raw UDP flow
+// from srcPort through the tunnel with VPN IP vpnIP.
+func (ft *FlowTable) AddRawCone(vpnIP [4]byte, srcPort uint16, entry RawFlowEntry) {
+	entry.FakeIP = [4]byte{}
+	entry.RealDstIP = [4]byte{}
+	entry.StartTime = 0
+	entry.TxBytes = 0
+	entry.RxBytes = 0
+
+	ck := coneKey{vpnIP: vpnIP, port: srcPort}
+	ft.conesMu.Lock()
+	if ft.cones == nil {
+		ft.cones = make(map[coneKey]*coneEntry)
+	}
+	if c, ok := ft.cones[ck]; ok {
+		c.lastActivity = ft.nowSec.Load()
+		c.flows++
+		c.tmpl = entry
+	} else {
+		ft.cones[ck] = &coneEntry{lastActivity: ft.nowSec.Load(), flows: 1, tmpl: entry}
+	}
+	ft.conesMu.Unlock()
+}
+
+// AcceptRawCone handles an inbound UDP datagram from remoteIP:remotePort to
+// vpnIP:dstPort that matches no raw flow. If dstPort has a full-cone
+// mapping, a raw flow for the remote is created from it and returned.
+// Datagrams are refused once the mapping has maxConeRemotes live flows.
+func (ft *FlowTable) AcceptRawCone(vpnIP, remoteIP [4]byte, dstPort, remotePort uint16, rx int64) (RawFlowEntry, bool) {
+	ft.conesMu.RLock()
+	empty := len(ft.cones) == 0
+	ft.conesMu.RUnlock()
+	if empty {
+		return RawFlowEntry{}, false
+	}
+
+	ft.conesMu.Lock()
+	c, ok := ft.cones[coneKey{vpnIP: vpnIP, port: dstPort}]
+	var entry RawFlowEntry
+	if ok && c.flows < maxConeRemotes {
+		c.flows++
+		entry = c.tmpl
+	} else {
+		ok = false
+	}
+	ft.conesMu.Unlock()
+	if !ok {
+		return RawFlowEntry{}, false
+	}
+
+	entry.LastActivity = ft.nowSec.Load()
+	entry.DstPort = remotePort
+	entry.RxBytes = rx
+	ft.InsertRawFlow(protoUDP, remoteIP, dstPort, entry)
+	core.Log.Debugf("Gateway", "Full-cone: accepted %s → port %d (tunnel %s)",
+		netip.AddrPortFrom(netip.AddrFrom4(remoteIP), remotePort), dstPort, entry.TunnelID)
+	return entry, true
+}
+
+// coneActivity returns an empty usage map for the raw flow cleanup to
+// fill, or nil when there are no full-cone mappings.
+func (ft *FlowTable) coneActivity() map[coneKey]coneUsage {
+	ft.conesMu.RLock()
+	defer ft.conesMu.RUnlock()
+	if len(ft.cones) == 0 {
+		return nil
+	}
+	return make(map[coneKey]coneUsage, len(ft.cones))
+}
+
+// expireRawCones updates the mappings from the activity and count of their
+// live flows and removes those idle since before cutoff.
+func (ft *FlowTable) expireRawCones(activity map[coneKey]coneUsage, cutoff int64) {
+	if activity == nil {
+		return
+	}
+	ft.conesMu.Lock()
+	defer ft.conesMu.Unlock()
+	for ck, c := range ft.cones {
+		u := activity[ck]
+		if u.last > c.lastActivity {
+			c.lastActivity = u.last
+		}
+		c.flows = u.flows
+		if c.lastActivity < cutoff {
+			delete(ft.cones, ck)
+		}
+	}
+}
+
+// RegisterUDPPeer adds a UDP NAT entry for a datagram that reached a
+// full-cone UDP proxy socket from a remote the client has not sent to, so
+// that the proxy's reply to remote.Addr():clientPort is hairpinned with
+// remote as its source. It reports false if the client port already talks
+// to another port of the same remote IP: NAT entries are keyed by remote IP
+// and client port, so the two cannot be told apart.
+// Compatible with proxy.UDPPeerRegistrar.
+func (ft *FlowTable) RegisterUDPPeer(remote netip.AddrPort, clientPort, proxyPort uint16, info core.NATInfo) bool {
+	if e, ok := ft.GetUDP(remote.Addr(), clientPort); ok {
+		return e.OriginalDstPort == remote.Port()
+	}
+	ft.InsertUDP(remote.Addr(), clientPort, UDPNATEntry{
+		LastActivity:    ft.nowSec.Load(),
+		OriginalDstIP:   remote.Addr(),
+		OriginalDstPort: remote.Port(),
+		TunnelID:        info.TunnelID,
+		UDPProxyPort:    proxyPort,
+		Fallback:        info.Fallback,
+		ExeLower:        info.ExeLower,
+		BaseLower:       info.BaseLower,
+		RuleIdx:         info.RuleIdx,
+		Rule:            info.Rule,
+		FullCone:        true,
+	})
+	return true
+}
@@ -0,0 +1,43 @@
+package gateway
+
+import "testing"
+
+func TestAcceptRawCone_RemoteLimit(t *testing.T) {
+	ft := NewFlowTable()
+	vpnIP := [4]byte{10, 8, 1, 2}
+	remote := func(i int) [4]byte { return [4]byte{203, 0, byte(i >> 8), byte(i)} }
+
+	if _, ok := ft.AcceptRawCone(vpnIP, remote(0), 40000, 3478, 0); ok {
+		t.Fatal("accepted without a full-cone mapping")
+	}
+
+	ft.AddRawCone(vpnIP, 40000, RawFlowEntry{TunnelID: "vpn", VpnIP: vpnIP, FullCone: true})
+	if _, ok := ft.AcceptRawCone(vpnIP, remote(0), 40001, 3478, 0); ok {
+		t.Error("accepted on a port without a mapping")
+	}
+
+	// The outbound flow that created the mapping counts as one.
+	for i := 1; i < maxConeRemotes; i++ {
+		e, ok := ft.AcceptRawCone(vpnIP, remote(i), 40000, 3478, 10)
+		if !ok {
+			t.Fatalf("remote %d refused below the limit", i)
+		}
+		if e.TunnelID != "vpn" || e.DstPort != 3478 || e.RxBytes != 10 {
+			t.Fatalf("entry = %+v", e)
+		}
+	}
+	if _, ok := ft.AcceptRawCone(vpnIP, remote(maxConeRemotes), 40000, 3478, 0); ok {
+		t.Fatal("remote accepted over the limit")
+	}
+	if _, ok := ft.GetRawFlow(protoUDP, remote(maxConeRemotes), 40000); ok {
+		t.Error("raw flow created for a refused remote")
+	}
+
+	// The cleanup recounts live flows; expired ones free their slots.
+	now := ft.NowSec()
+	ck := coneKey{vpnIP: vpnIP, port: 40000}
+	ft.expireRawCones(map[coneKey]coneUsage{ck: {last: now, flows: 10}}, now-300)
+	if _, ok := ft.AcceptRawCone(vpnIP, remote(maxConeRemotes), 40000, 3478, 0); !ok {
+		t.Error("remote refused after flows expired")
+	}
+}
@@ -54,6 +54,20 @@ func (m *GeoIPMatcher) IsEmpty() bool {
 	return len(m.entries) == 0
 }
 
+// AddPrefixes appends an entry matching the given IPv4 prefixes (e.g. the
+// CIDRs of a rule set). Must be called before the matcher is published.
+func (m *GeoIPMatcher) AddPrefixes(prefixes []netip.Prefix, rule core.DomainRule) {
+	trie := buildTrieFromPrefixes(prefixes)
+	if trie.IsEmpty() {
+		return
+	}
+	m.entries = append(m.entries, geoipMatchEntry{
+		trie:     trie,
+		tunnelID: rule.TunnelID,
+		action:   rule.Action,
+	})
+}
+
 // NewGeoIPMatcher builds a matcher from geoip.dat for the requested categories.
 // categories maps uppercase country code → DomainRule template.
 func NewGeoIPMatcher(geoipFilePath string, categories map[string]core.DomainRule) (*GeoIPMatcher, error) {
@@ -189,12 +189,12 @@ func tunSetUDPPort(pkt []byte, portOff int, newPort uint16, udpCkOff int) {
 const rawMSSLimit = tunInterfaceMTU - 40 // 1360
 
 // clampTCPMSS reduces the MSS option in a TCP SYN/SYN-ACK packet if it
-// exceeds rawMSSLimit. This prevents the remote side from sending segments
+// exceeds limit (rawMSSLimit or the tunnel's path MTU minus 40). This prevents the remote side from sending segments
 // larger than the VPN tunnel can carry, avoiding silent drops due to MTU
 // mismatch between the TUN adapter and the encrypted tunnel.
 //
 // tpOff is the offset of the TCP header within pkt.
-func clampTCPMSS(pkt []byte, tpOff int) {
+func clampTCPMSS(pkt []byte, tpOff int, limit uint16) {
 	// Bounds check: need at least 14 bytes of TCP header (up to flags byte).
 	if tpOff+minTCPHdr > len(pkt) {
 		return
@@ -233,12 +233,12 @@ func clampTCPMSS(pkt []byte, tpOff int) {
 
 		if kind == 2 && optLen == 4 { // MSS option
 			currentMSS := binary.BigEndian.Uint16(pkt[pos+2:])
-			if currentMSS > rawMSSLimit {
-				binary.BigEndian.PutUint16(pkt[pos+2:], rawMSSLimit)
+			if currentMSS > limit {
+				binary.BigEndian.PutUint16(pkt[pos+2:], limit)
 				// Incrementally update TCP checksum.
 				tcpCkOff := tpOff + 16
 				ck := binary.BigEndian.Uint16(pkt[tcpCkOff:])
-				ck = checksumUpdate16(ck, currentMSS, rawMSSLimit)
+				ck = checksumUpdate16(ck, currentMSS, limit)
 				binary.BigEndian.PutUint16(pkt[tcpCkOff:], ck)
 			}
 			return
@@ -0,0 +1,206 @@
+package gateway
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// ---------------------------------------------------------------------------
+// wg_server peers: flows that enter through the WireGuard server instead of
+// the TUN adapter. They have no local PID, so "peer:" rules take the place
+// of process rules; the destination-based layers are shared with resolveFlow.
+// ---------------------------------------------------------------------------
+
+// PeerRoute is the routing decision for a flow from a wg_server peer.
+type PeerRoute struct {
+	TunnelID string     // tunnel to dial through; DirectTunnelID for the real NIC
+	Dst      netip.Addr // address to dial (a FakeIP is replaced by its real IP)
+	Rule     string     // matched "peer:" rule pattern, "" if none
+}
+
+// peerHostOnly are destinations on the host itself. A peer must never reach
+// services bound to the host's loopback, whatever the rules say.
+var peerHostOnly = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("127.0.0.0/8"),
+}
+
+// RoutePeer resolves a new flow from a wg_server peer, identified by its name
+// and base64 public key. ok is false when the flow must be dropped.
+// allowLAN lets the peer reach the host's local networks directly; without
+// it, direct flows to RFC 1918, link-local, multicast and broadcast
+// destinations, and to any address of the host's own interfaces (a public
+// or CGNAT address), are dropped.
+func (r *TUNRouter) RoutePeer(name, publicKey string, dst netip.Addr, isUDP, allowLAN bool) (PeerRoute, bool) {
+	route, ok := r.routePeer(name, publicKey, dst, isUDP)
+	if !ok {
+		return PeerRoute{}, false
+	}
+	for _, p := range peerHostOnly {
+		if p.Contains(route.Dst) {
+			return PeerRoute{}, false
+		}
+	}
+	if route.TunnelID == DirectTunnelID && !allowLAN && (isLocalNetwork(route.Dst) || isHostAddr(route.Dst)) {
+		return PeerRoute{}, false
+	}
+	return route, true
+}
+
+// isLocalNetwork reports whether ip is in one of localBypassCIDRs.
+func isLocalNetwork(ip netip.Addr) bool {
+	for _, c := range localBypassCIDRs {
+		if netip.PrefixFrom(netip.AddrFrom4(c.ip), c.prefLen).Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostAddrTTL bounds how long a changed interface address (new DHCP lease,
+// PPPoE reconnect) goes unnoticed.
+const hostAddrTTL = 5 * time.Second
+
+// interfaceAddrs lists the addresses of the local interfaces; replaced in tests.
+var interfaceAddrs = net.InterfaceAddrs
+
+// hostAddrs caches the result of interfaceAddrs for hostAddrTTL, so new
+// peer flows do not each query the OS.
+var hostAddrs struct {
+	mu      sync.Mutex
+	addrs   map[netip.Addr]bool
+	fetched time.Time
+}
+
+// isHostAddr reports whether ip is assigned to one of the host's interfaces.
+func isHostAddr(ip netip.Addr) bool {
+	hostAddrs.mu.Lock()
+	defer hostAddrs.mu.Unlock()
+	if now := time.Now(); hostAddrs.addrs == nil || now.Sub(hostAddrs.fetched) > hostAddrTTL {
+		addrs, err := interfaceAddrs()
+		if err != nil {
+			core.Log.Warnf("Gateway", "Failed to list interface addresses: %v", err)
+		}
+		set := make(map[netip.Addr]bool, len(addrs))
+		for _, a := range addrs {
+			if pfx, err := netip.ParsePrefix(a.String()); err == nil {
+				set[pfx.Addr().Unmap()] = true
+			}
+		}
+		hostAddrs.addrs = set
+		hostAddrs.fetched = now
+	}
+	return hostAddrs.addrs[ip]
+}
+
+func (r *TUNRouter) routePeer(name, publicKey string, dst netip.Addr, isUDP bool) (route PeerRoute, ok bool) {
+	if !dst.Is4() {
+		return PeerRoute{}, false
+	}
+	dstIP := dst.As4()
+	route = PeerRoute{TunnelID: DirectTunnelID, Dst: dst}
+
+	// FakeIP answers from the resolver are only meaningful to us: dial the
+	// real address whatever tunnel is chosen.
+	if fp := r.fakeIPPool.Load(); fp != nil && fp.IsFakeIP(dstIP) {
+		entry, found := fp.Lookup(dstIP)
+		if !found || len(entry.RealIPs) == 0 {
+			return PeerRoute{}, false
+		}
+		route.Dst = netip.AddrFrom4(entry.RealIPs[0])
+	}
+
+	f := r.ipFilter.Load() // may be nil
+
+	// Per-tunnel AllowedIPs (split-include) routes, as for local processes.
+	if f != nil {
+		if tid, found := f.FindTunnelByAllowedIP(dstIP, func(id string) bool {
+			entry, ok := r.registry.Get(id)
+			return ok && entry.State == core.TunnelStateUp
+		}); found {
+			route.TunnelID = tid
+			return route, true
+		}
+	}
+
+	// Unlike TUN traffic, a LAN destination is reachable from a peer: the
+	// direct provider dials it on the real NIC (if the peer has allow_lan).
+	if f != nil && f.IsLocalBypassIP(dstIP) {
+		return route, true
+	}
+
+	if tid, _, action, decided := r.resolveByDestination(dstIP, isUDP); decided {
+		switch action {
+		case flowDrop:
+			return PeerRoute{}, false
+		case flowRoute:
+			route.TunnelID = tid
+		}
+		return route, true
+	}
+
+	return r.matchPeerRules(name, publicKey, dstIP, route)
+}
+
+// matchPeerRules applies "peer:" rules with the same fallback policies as
+// process rules. Peers without a matching rule go direct.
+func (r *TUNRouter) matchPeerRules(name, publicKey string, dstIP [4]byte, route PeerRoute) (PeerRoute, bool) {
+	f := r.ipFilter.Load()
+
+	startIdx := 0
+	for range len(r.rules.GetRules()) {
+		result, idx := r.rules.MatchPeerFrom(name, publicKey, startIdx)
+		if !result.Matched {
+			if startIdx > 0 {
+				// Failover exhausted — VPN-or-nothing: drop.
+				return PeerRoute{}, false
+			}
+			return route, true
+		}
+		startIdx = idx + 1
+
+		if result.Fallback == core.PolicyDrop {
+			return PeerRoute{}, false
+		}
+
+		entry, ok := r.registry.Get(result.TunnelID)
+		if !ok || entry.State != core.TunnelStateUp {
+			switch result.Fallback {
+			case core.PolicyFailover:
+				continue
+			case core.PolicyBlock:
+				return PeerRoute{}, false
+			default:
+				return route, true
+			}
+		}
+
+		route.Rule = result.Pattern
+		if f != nil && f.ShouldBypassIP(result.TunnelID, dstIP) {
+			return route, true
+		}
+		if result.Peer != "" && r.peerPinner != nil {
+			r.peerPinner(result.TunnelID, result.Peer, route.Dst)
+		}
+		route.TunnelID = result.TunnelID
+		return route, true
+	}
+
+	// Safety: loop bound reached — drop to be safe.
+	return PeerRoute{}, false
+}
+
+// ResolvePeerDNS answers a DNS query from a wg_server peer through the local
+// resolver, so domain rules and FakeIP apply to peers as to local apps.
+// Returns nil if no resolver is configured or the query fails.
+func (r *TUNRouter) ResolvePeerDNS(ctx context.Context, peer string, query []byte) []byte {
+	if r.dnsResolver == nil {
+		return nil
+	}
+	return r.dnsResolver.Resolve(withDNSPeer(ctx, peer), query)
+}
@@ -0,0 +1,74 @@
+package gateway
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func newPeerRouter(rules []core.Rule) *TUNRouter {
+	r := &TUNRouter{
+		rules:    core.NewRuleEngine(rules, nil, nil),
+		registry: core.NewTunnelRegistry(nil),
+	}
+	r.SetIPFilter(NewIPFilter(core.GlobalFilterConfig{}, nil))
+	return r
+}
+
+// setHostAddrs makes isHostAddr see addrs as the host's interface addresses.
+func setHostAddrs(t *testing.T, addrs ...string) {
+	t.Helper()
+	var list []net.Addr
+	for _, a := range addrs {
+		ip, ipnet, err := net.ParseCIDR(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list = append(list, &net.IPNet{IP: ip, Mask: ipnet.Mask})
+	}
+	prev := interfaceAddrs
+	interfaceAddrs = func() ([]net.Addr, error) { return list, nil }
+	hostAddrs.addrs = nil
+	t.Cleanup(func() {
+		interfaceAddrs = prev
+		hostAddrs.addrs = nil
+	})
+}
+
+func TestRoutePeerLocalDestinations(t *testing.T) {
+	setHostAddrs(t, "127.0.0.1/8", "192.168.1.2/24", "203.0.113.7/24", "100.72.1.9/10")
+	r := newPeerRouter([]core.Rule{{Pattern: "peer:*", TunnelID: "awg1"}})
+
+	tests := []struct {
+		dst      string
+		allowLAN bool
+		ok       bool
+	}{
+		{"127.0.0.1", false, false},
+		{"127.0.0.1", true, false}, // loopback never, even with allow_lan
+		{"0.0.0.0", true, false},
+		{"192.168.1.10", false, false},
+		{"192.168.1.10", true, true},
+		{"10.0.0.5", false, false},
+		{"224.0.0.251", false, false},
+		{"1.1.1.1", false, true}, // awg1 is down: allow_direct
+		// The host's own public and CGNAT addresses count as local.
+		{"203.0.113.7", false, false},
+		{"203.0.113.7", true, true},
+		{"203.0.113.8", false, true},
+		{"100.72.1.9", false, false},
+		{"100.72.1.10", false, true},
+	}
+	for _, tt := range tests {
+		route, ok := r.RoutePeer("phone", "key", netip.MustParseAddr(tt.dst), false, tt.allowLAN)
+		if ok != tt.ok {
+			t.Errorf("%s allowLAN=%v: ok=%v, want %v", tt.dst, tt.allowLAN, ok, tt.ok)
+			continue
+		}
+		if ok && route.TunnelID != DirectTunnelID {
+			t.Errorf("%s: routed via %s, want direct", tt.dst, route.TunnelID)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// ---------------------------------------------------------------------------
+// Path MTU — per-tunnel MTU for raw-forwarded traffic
+// ---------------------------------------------------------------------------
+
+const (
+	// minTunnelMTU is the smallest MTU every IPv4 path must carry.
+	minTunnelMTU = 576
+
+	// pmtuProbeTimeout is how long one probe waits for its echo reply.
+	pmtuProbeTimeout = time.Second
+
+	// pmtuProbeTries is how many times a size is tried before it counts as lost.
+	pmtuProbeTries = 2
+
+	// pmtuGranularity stops the search once the bounds are this close.
+	pmtuGranularity = 8
+
+	// pmtuProbeIDBase is the first ICMP identifier used by probes.
+	pmtuProbeIDBase = 0xa700
+)
+
+// ErrPMTUNoReply is returned by ProbePathMTU when the target does not answer
+// even minimum-size probes (ICMP filtered, target down).
+var ErrPMTUNoReply = errors.New("probe target did not answer")
+
+// errPMTUInject aborts a probe when the tunnel refuses packets (closing).
+var errPMTUInject = errors.New("tunnel dropped the probe")
+
+// pmtuProbe is one probe waiting for its reply.
+type pmtuProbe struct {
+	target [4]byte
+	// reply receives 0 for an echo reply, or the next-hop MTU of an ICMP
+	// "fragmentation needed" error.
+	reply chan int
+}
+
+// TunnelMTU returns the MTU of raw-forwarded traffic through the tunnel, or
+// 0 if it is not known.
+func (r *TUNRouter) TunnelMTU(tunnelID string) int {
+	if m := r.tunnelMTUs.Load(); m != nil {
+		return (*m)[tunnelID]
+	}
+	return 0
+}
+
+// SetTunnelMTU sets the MTU of raw-forwarded traffic through the tunnel.
+// TCP MSS is clamped to it and larger packets with DF set are answered with
+// ICMP "fragmentation needed". mtu <= 0 removes the limit.
+func (r *TUNRouter) SetTunnelMTU(tunnelID string, mtu int) {
+	r.mtuMu.Lock()
+	defer r.mtuMu.Unlock()
+	next := make(map[string]int)
+	if m := r.tunnelMTUs.Load(); m != nil {
+		for k, v := range *m {
+			next[k] = v
+		}
+	}
+	if mtu > 0 {
+		next[tunnelID] = max(mtu, minTunnelMTU)
+	} else {
+		delete(next, tunnelID)
+	}
+	r.tunnelMTUs.Store(&next)
+}
+
+// tunnelMSS returns the MSS limit for TCP through the tunnel.
+func (r *TUNRouter) tunnelMSS(tunnelID string) uint16 {
+	if mtu := r.TunnelMTU(tunnelID); mtu > 0 && mtu-40 < rawMSSLimit {
+		return uint16(mtu - 40)
+	}
+	return rawMSSLimit
+}
+
+// ProbePathMTU measures the path MTU through a raw-forwarding tunnel by
+// sending ICMP echo requests with DF set to target and binary-searching the
+// largest size that is answered, up to maxMTU. ICMP "fragmentation needed"
+// replies from inside the tunnel shortcut the search.
+func (r *TUNRouter) ProbePathMTU(ctx context.Context, tunnelID string, target netip.Addr, maxMTU int) (int, error) {
+	rf, vpnIP, ok := r.getRawForwarder(tunnelID)
+	if !ok {
+		return 0, fmt.Errorf("tunnel %q has no raw forwarder", tunnelID)
+	}
+	if !target.Is4() {
+		return 0, fmt.Errorf("probe target %s is not IPv4", target)
+	}
+	maxMTU = min(maxMTU, tunInterfaceMTU)
+	dst := target.As4()
+
+	id := uint16(pmtuProbeIDBase + r.pmtuSeq.Add(1)%0x100)
+	probe := &pmtuProbe{target: dst, reply: make(chan int, 4)}
+	r.pmtuProbes.Store(id, probe)
+	defer r.pmtuProbes.Delete(id)
+
+	var seq uint16
+	// try reports whether size is answered, and the next-hop MTU if an ICMP
+	// error said it is too big.
+	try := func(size int) (bool, int, error) {
+		for range pmtuProbeTries {
+			seq++
+			if !rf.InjectOutboundPriority(buildICMPEcho(vpnIP, dst, id, seq, size), PrioNormal) {
+				return false, 0, errPMTUInject
+			}
+			timer := time.NewTimer(pmtuProbeTimeout)
+		wait:
+			for {
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					return false, 0, ctx.Err()
+				case <-timer.C:
+					break wait
+				case hop := <-probe.reply:
+					if hop == 0 {
+						timer.Stop()
+						return true, 0, nil
+					}
+					if hop < size {
+						timer.Stop()
+						return false, hop, nil
+					}
+				}
+			}
+		}
+		return false, 0, nil
+	}
+
+	ok, _, err := try(minTunnelMTU)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, ErrPMTUNoReply
+	}
+
+	lo, hi := minTunnelMTU, maxMTU+1 // lo is answered, hi is not
+	next := maxMTU
+	for hi-lo > pmtuGranularity {
+		ok, hop, err := try(next)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			lo = next
+		} else {
+			hi = next
+			if hop > lo && hop < hi {
+				// The path told us its MTU: confirm it next.
+				next = hop
+				continue
+			}
+		}
+		next = (lo + hi) / 2
+	}
+	if _, _, ok := r.getRawForwarder(tunnelID); !ok {
+		return 0, fmt.Errorf("tunnel %q went down during the probe", tunnelID)
+	}
+	core.Log.Debugf("Gateway", "PMTU %q via %s: %d", tunnelID, target, lo)
+	return lo, nil
+}
+
+// deliverPMTUReply hands an inbound ICMP packet to the probe it answers.
+// Returns true if the packet was a probe reply.
+func (r *TUNRouter) deliverPMTUReply(pkt []byte, ihl int) bool {
+	var id uint16
+	var from [4]byte
+	hop := 0
+	switch pkt[ihl] {
+	case icmpEchoReply:
+		id = binary.BigEndian.Uint16(pkt[ihl+4:])
+		copy(from[:], pkt[12:16])
+	case icmpDestUnreach:
+		// Code 4: fragmentation needed; next-hop MTU in bytes 6-7.
+		embOff := ihl + minICMPHdr
+		if pkt[ihl+1] != 4 || len(pkt) < embOff+minIPv4Hdr+minICMPHdr {
+			return false
+		}
+		embIHL := int(pkt[embOff]&0x0f) * 4
+		if embIHL < minIPv4Hdr || len(pkt) < embOff+embIHL+minICMPHdr || pkt[embOff+9] != protoICMP {
+			return false
+		}
+		id = binary.BigEndian.Uint16(pkt[embOff+embIHL+4:])
+		copy(from[:], pkt[embOff+16:embOff+20])
+		hop = int(binary.BigEndian.Uint16(pkt[ihl+6:]))
+		if hop == 0 {
+			hop = minTunnelMTU // pre-RFC 1191 router: no MTU given
+		}
+	default:
+		return false
+	}
+	if id < pmtuProbeIDBase || id > pmtuProbeIDBase+0xff {
+		return false
+	}
+	v, ok := r.pmtuProbes.Load(id)
+	if !ok {
+		return false
+	}
+	probe := v.(*pmtuProbe)
+	if probe.target != from {
+		return false
+	}
+	select {
+	case probe.reply <- hop:
+	default:
+	}
+	return true
+}
+
+// buildICMPEcho builds an IPv4 ICMP echo request of size bytes with DF set.
+func buildICMPEcho(src, dst [4]byte, id, seq uint16, size int) []byte {
+	pkt := make([]byte, max(size, minIPv4Hdr+minICMPHdr))
+	pkt[0] = 0x45
+	binary.BigEndian.PutUint16(pkt[2:], uint16(len(pkt)))
+	binary.BigEndian.PutUint16(pkt[4:], seq)
+	pkt[6] = 0x40 // DF
+	pkt[8] = 64
+	pkt[9] = protoICMP
+	copy(pkt[12:16], src[:])
+	copy(pkt[16:20], dst[:])
+	recalcIPChecksum(pkt[:minIPv4Hdr])
+
+	pkt[minIPv4Hdr] = icmpEchoRequest
+	binary.BigEndian.PutUint16(pkt[minIPv4Hdr+4:], id)
+	binary.BigEndian.PutUint16(pkt[minIPv4Hdr+6:], seq)
+	recalcICMPChecksum(pkt, minIPv4Hdr)
+	return pkt
+}
+
+// buildICMPFragNeeded builds the ICMP "fragmentation needed" error telling
+// the sender of pkt that the path MTU is mtu (RFC 1191). It appears to come
+// from pkt's destination and quotes pkt's IP header and first 8 payload bytes.
+func buildICMPFragNeeded(pkt []byte, mtu int) []byte {
+	ihl := int(pkt[0]&0x0f) * 4
+	quote := pkt[:min(len(pkt), ihl+8)]
+	out := make([]byte, minIPv4Hdr+minICMPHdr+len(quote))
+	out[0] = 0x45
+	binary.BigEndian.PutUint16(out[2:], uint16(len(out)))
+	out[8] = 64
+	out[9] = protoICMP
+	copy(out[12:16], pkt[16:20])
+	copy(out[16:20], pkt[12:16])
+	recalcIPChecksum(out[:minIPv4Hdr])
+
+	out[minIPv4Hdr] = icmpDestUnreach
+	out[minIPv4Hdr+1] = 4
+	binary.BigEndian.PutUint16(out[minIPv4Hdr+6:], uint16(mtu))
+	copy(out[minIPv4Hdr+minICMPHdr:], quote)
+	recalcICMPChecksum(out, minIPv4Hdr)
+	return out
+}
@@ -0,0 +1,125 @@
+package gateway
+
+import (
+	"context"
+	"encoding/binary"
+	"net/netip"
+	"testing"
+
+	"awg-split-tunnel/internal/provider"
+)
+
+// fakePath is a raw forwarder whose path carries packets up to mtu bytes.
+// Echo requests that fit are answered; larger ones get ICMP "fragmentation
+// needed" from the path.
+type fakePath struct {
+	r   *TUNRouter
+	mtu int
+	max int // largest probe seen
+}
+
+func (f *fakePath) InjectOutbound(pkt []byte) bool { return f.InjectOutboundPriority(pkt, PrioNormal) }
+
+func (f *fakePath) InjectOutboundPriority(pkt []byte, _ byte) bool {
+	f.max = max(f.max, len(pkt))
+	if len(pkt) > f.mtu {
+		f.r.deliverPMTUReply(buildICMPFragNeeded(pkt, f.mtu), minIPv4Hdr)
+		return true
+	}
+	reply := append([]byte(nil), pkt...)
+	copy(reply[12:16], pkt[16:20])
+	copy(reply[16:20], pkt[12:16])
+	reply[minIPv4Hdr] = icmpEchoReply
+	f.r.deliverPMTUReply(reply, minIPv4Hdr)
+	return true
+}
+
+func (f *fakePath) SetInboundHandler(func(pkt []byte) bool) {}
+
+func newPMTURouter(tunnelID string, rf provider.RawForwarder) *TUNRouter {
+	return &TUNRouter{
+		rawFwders: map[string]provider.RawForwarder{tunnelID: rf},
+		vpnIPs:    map[string][4]byte{tunnelID: {10, 8, 0, 2}},
+	}
+}
+
+func TestProbePathMTU(t *testing.T) {
+	for _, pathMTU := range []int{1400, 1280, 1000} {
+		f := &fakePath{mtu: pathMTU}
+		r := newPMTURouter("vpn", f)
+		f.r = r
+
+		got, err := r.ProbePathMTU(context.Background(), "vpn", netip.MustParseAddr("1.1.1.1"), 1420)
+		if err != nil {
+			t.Fatalf("path %d: %v", pathMTU, err)
+		}
+		if got != pathMTU {
+			t.Errorf("path %d: probed %d", pathMTU, got)
+		}
+		if f.max > tunInterfaceMTU {
+			t.Errorf("path %d: probe of %d bytes exceeds the TUN MTU", pathMTU, f.max)
+		}
+	}
+}
+
+func TestProbePathMTUIgnoresOtherTargets(t *testing.T) {
+	r := newPMTURouter("vpn", nil)
+	probe := &pmtuProbe{target: [4]byte{1, 1, 1, 1}, reply: make(chan int, 1)}
+	r.pmtuProbes.Store(uint16(pmtuProbeIDBase), probe)
+
+	reply := buildICMPEcho([4]byte{9, 9, 9, 9}, [4]byte{10, 8, 0, 2}, pmtuProbeIDBase, 1, 64)
+	reply[minIPv4Hdr] = icmpEchoReply
+	if r.deliverPMTUReply(reply, minIPv4Hdr) {
+		t.Error("reply from another host was taken as a probe reply")
+	}
+}
+
+func TestFragNeededPacket(t *testing.T) {
+	orig := buildICMPEcho([4]byte{10, 255, 0, 1}, [4]byte{93, 184, 216, 34}, 7, 1, 1400)
+	out := buildICMPFragNeeded(orig, 1280)
+
+	if out[minIPv4Hdr] != icmpDestUnreach || out[minIPv4Hdr+1] != 4 {
+		t.Fatalf("type/code = %d/%d, want 3/4", out[minIPv4Hdr], out[minIPv4Hdr+1])
+	}
+	if mtu := binary.BigEndian.Uint16(out[minIPv4Hdr+6:]); mtu != 1280 {
+		t.Errorf("next-hop MTU = %d, want 1280", mtu)
+	}
+	if [4]byte(out[12:16]) != [4]byte(orig[16:20]) || [4]byte(out[16:20]) != [4]byte(orig[12:16]) {
+		t.Error("addresses not swapped")
+	}
+	if want := minIPv4Hdr + minICMPHdr + minIPv4Hdr + 8; len(out) != want {
+		t.Errorf("length = %d, want %d", len(out), want)
+	}
+	if checksumFold(sumWords(out[:minIPv4Hdr])) != 0xffff {
+		t.Error("bad IP checksum")
+	}
+	if checksumFold(sumWords(out[minIPv4Hdr:])) != 0xffff {
+		t.Error("bad ICMP checksum")
+	}
+}
+
+func TestTunnelMSS(t *testing.T) {
+	r := &TUNRouter{}
+	if got := r.tunnelMSS("vpn"); got != rawMSSLimit {
+		t.Errorf("unknown MTU: MSS %d, want %d", got, rawMSSLimit)
+	}
+	r.SetTunnelMTU("vpn", 1280)
+	if got := r.tunnelMSS("vpn"); got != 1240 {
+		t.Errorf("MTU 1280: MSS %d, want 1240", got)
+	}
+	r.SetTunnelMTU("vpn", 0)
+	if got := r.TunnelMTU("vpn"); got != 0 {
+		t.Errorf("cleared MTU = %d", got)
+	}
+}
+
+func sumWords(b []byte) uint32 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(b[i:]))
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	return sum
+}
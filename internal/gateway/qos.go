@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 
 	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/shaping"
 )
 
 // ---------------------------------------------------------------------------
@@ -129,3 +130,13 @@ func markDSCP(pkt []byte, proto byte, tpOff int) {
 	newCk := checksumUpdate16(oldCk, oldWord, newWord)
 	binary.BigEndian.PutUint16(pkt[10:12], newCk)
 }
+
+// allowShaped charges a packet of n bytes against lim and reports whether it
+// may be sent. High-priority packets (voice, DNS, TCP control) may borrow
+// ahead of the limit, so bulk traffic sharing it absorbs the cut.
+func allowShaped(lim shaping.Limiter, n int, prio byte) bool {
+	if prio == PrioHigh {
+		return lim.AllowPriority(n)
+	}
+	return lim.Allow(n)
+}
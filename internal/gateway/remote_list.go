@@ -0,0 +1,250 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// listSource is one configured list as remoteLists sees it: where its
+// content comes from and how often it is refreshed.
+type listSource struct {
+	Name            string
+	URL             string
+	Path            string // local file; URL is ignored when set
+	Format          string
+	RefreshInterval string
+	Enabled         bool
+}
+
+// remoteLists loads, caches and refreshes the lists of one manager
+// (blocklists, rule sets). Local lists are read from their path; remote
+// lists are downloaded, kept in cacheDir and re-downloaded on their
+// interval. Parsing is left to the manager's store callback.
+type remoteLists struct {
+	kind           string // "blocklist", "rule set"; used in messages
+	logTag         string
+	goName         string // prefix of the background goroutine names
+	cacheExt       string
+	maxSize        int64
+	defaultRefresh time.Duration
+	httpClient     *http.Client
+	cacheDir       string
+
+	// store parses the content of src and replaces the list. It returns
+	// the number of entries, or an error to keep the previous content.
+	store func(src listSource, data []byte) (int, error)
+
+	mu       sync.RWMutex
+	sources  []listSource
+	stopFns  map[string]context.CancelFunc
+	onUpdate func()
+}
+
+// title is kind with a capital first letter, for the start of log lines.
+func (r *remoteLists) title() string {
+	return strings.ToUpper(r.kind[:1]) + r.kind[1:]
+}
+
+func (r *remoteLists) setOnUpdate(fn func()) {
+	r.mu.Lock()
+	r.onUpdate = fn
+	r.mu.Unlock()
+}
+
+// configure replaces the sources. Enabled lists are loaded from the local
+// path or the download cache synchronously; remote lists without a usable
+// cached copy are downloaded in the background. Refresh loops are restarted.
+func (r *remoteLists) configure(ctx context.Context, sources []listSource) {
+	r.mu.Lock()
+	r.stopLocked()
+	r.sources = sources
+	r.mu.Unlock()
+
+	for _, src := range sources {
+		if !src.Enabled {
+			continue
+		}
+		data, err := r.readLocal(src)
+		if err == nil {
+			_, err = r.store(src, data)
+		}
+		if err != nil && src.Path == "" {
+			core.SafeGo(r.goName+"-fetch", func() {
+				if err := r.refresh(ctx, src.Name); err != nil {
+					core.Log.Warnf(r.logTag, "%s %q: %v", r.title(), src.Name, err)
+				}
+			})
+		} else if err != nil {
+			core.Log.Warnf(r.logTag, "%s %q: %v", r.title(), src.Name, err)
+		}
+		r.startRefreshLoop(ctx, src)
+	}
+}
+
+// stop halts all refresh loops.
+func (r *remoteLists) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopLocked()
+}
+
+func (r *remoteLists) stopLocked() {
+	for name, cancel := range r.stopFns {
+		cancel()
+		delete(r.stopFns, name)
+	}
+}
+
+// refresh re-reads (local) or re-downloads (remote) a single list and
+// invokes the update callback. A remote list is cached only once it parses.
+func (r *remoteLists) refresh(ctx context.Context, name string) error {
+	src, ok := r.source(name)
+	if !ok {
+		return fmt.Errorf("unknown %s %q", r.kind, name)
+	}
+
+	var data []byte
+	var err error
+	if src.Path != "" {
+		data, err = os.ReadFile(src.Path)
+	} else {
+		data, err = downloadList(ctx, r.httpClient, src.URL, r.maxSize)
+	}
+	if err != nil {
+		return err
+	}
+	n, err := r.store(src, data)
+	if err != nil {
+		return err
+	}
+	if src.Path == "" {
+		if err := writeListCache(r.cacheDir, r.cachePath(src.Name), data); err != nil {
+			core.Log.Warnf(r.logTag, "%s %q: failed to cache: %v", r.title(), src.Name, err)
+		}
+	}
+	core.Log.Infof(r.logTag, "%s %q refreshed: %d entries", r.title(), name, n)
+
+	r.mu.RLock()
+	fn := r.onUpdate
+	r.mu.RUnlock()
+	if fn != nil {
+		fn()
+	}
+	return nil
+}
+
+func (r *remoteLists) source(name string) (listSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, src := range r.sources {
+		if src.Name == name {
+			return src, true
+		}
+	}
+	return listSource{}, false
+}
+
+// readLocal reads the list from its local path or from the download cache.
+func (r *remoteLists) readLocal(src listSource) ([]byte, error) {
+	if src.Path != "" {
+		return os.ReadFile(src.Path)
+	}
+	return os.ReadFile(r.cachePath(src.Name))
+}
+
+func (r *remoteLists) cachePath(name string) string {
+	return filepath.Join(r.cacheDir, sanitizeFileName(name)+r.cacheExt)
+}
+
+func (r *remoteLists) startRefreshLoop(ctx context.Context, src listSource) {
+	interval := r.defaultRefresh
+	if src.RefreshInterval != "" {
+		d, err := time.ParseDuration(src.RefreshInterval)
+		if err != nil || d <= 0 {
+			core.Log.Warnf(r.logTag, "Invalid refresh_interval %q for %s %q", src.RefreshInterval, r.kind, src.Name)
+			return
+		}
+		interval = d
+	}
+
+	loopCtx, loopCancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.stopFns[src.Name] = loopCancel
+	r.mu.Unlock()
+
+	core.SafeGo(r.goName+"-refresh", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-loopCtx.Done():
+				return
+			case <-ticker.C:
+				if err := r.refresh(loopCtx, src.Name); err != nil {
+					core.Log.Warnf(r.logTag, "%s %q auto-refresh failed: %v", r.title(), src.Name, err)
+				}
+			}
+		}
+	})
+}
+
+// downloadList fetches a list body of at most maxSize bytes; larger lists
+// are an error rather than silently cut short.
+func downloadList(ctx context.Context, httpClient *http.Client, url string, maxSize int64) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("User-Agent", "AWGSplitTunnel/1.0")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d from %s", resp.StatusCode, url)
+	}
+
+	// Read one byte past the limit: a truncated list would end in half a line.
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read body: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("%s: list exceeds %d bytes", url, maxSize)
+	}
+	return data, nil
+}
+
+// writeListCache stores a downloaded list in the cache directory.
+func writeListCache(cacheDir, path string, data []byte) error {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// sanitizeFileName replaces characters that are unsafe in file names.
+func sanitizeFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '\\', ':', '*', '?', '"', '<', '>', '|':
+			return '_'
+		}
+		return r
+	}, name)
+}
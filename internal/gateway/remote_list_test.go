@@ -0,0 +1,71 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestDownloadList_RejectsOversized(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("0.0.0.0 a.example\n0.0.0.0 b.example\n"))
+	}))
+	defer srv.Close()
+
+	if _, err := downloadList(context.Background(), srv.Client(), srv.URL, 20); err == nil {
+		t.Error("list over the size limit accepted")
+	}
+	if data, err := downloadList(context.Background(), srv.Client(), srv.URL, 1024); err != nil || len(data) != 36 {
+		t.Errorf("list within the limit: %d bytes, %v", len(data), err)
+	}
+}
+
+func TestRuleSetManager_CachesRemoteSets(t *testing.T) {
+	var body atomic.Value
+	body.Store("example.com\n")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(body.Load().(string)))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cfg := []core.RuleSetConfig{{Name: "ads", URL: srv.URL, Format: core.RuleSetFormatDomain}}
+	m := NewRuleSetManager(dir, srv.Client())
+	m.Configure(context.Background(), cfg)
+	defer m.Stop()
+	if err := m.Refresh(context.Background(), "ads"); err != nil {
+		t.Fatal(err)
+	}
+	if rs := m.Get("ads"); rs == nil || rs.Len() != 1 {
+		t.Fatalf("set after refresh = %+v", rs)
+	}
+
+	// A set that fails to parse keeps the previous content and cache.
+	body.Store("{not json")
+	bad := []core.RuleSetConfig{{Name: "ads", URL: srv.URL, Format: core.RuleSetFormatSingBox}}
+	m.Configure(context.Background(), bad)
+	if err := m.Refresh(context.Background(), "ads"); err == nil {
+		t.Error("unparsable set accepted")
+	}
+	if data, err := os.ReadFile(m.remote.cachePath("ads")); err != nil || string(data) != "example.com\n" {
+		t.Errorf("cache = %q, %v", data, err)
+	}
+
+	// A new manager loads the cached copy without downloading.
+	srv.Close()
+	m2 := NewRuleSetManager(dir, nil)
+	m2.Configure(context.Background(), cfg)
+	defer m2.Stop()
+	if rs := m2.Get("ads"); rs == nil || rs.Len() != 1 {
+		t.Errorf("set from cache = %+v", rs)
+	}
+
+	if err := m2.Refresh(context.Background(), "missing"); err == nil || err.Error() != `unknown rule set "missing"` {
+		t.Errorf("Refresh(missing) = %v", err)
+	}
+}
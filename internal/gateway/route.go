@@ -153,6 +153,30 @@ func (rm *RouteManager) AddBypassRoute(dst netip.Addr) error {
 	return nil
 }
 
+// RemoveBypassRoute removes the host route for dst added by AddBypassRoute.
+// Used when a VPN server endpoint moves to a new IP.
+func (rm *RouteManager) RemoveBypassRoute(dst netip.Addr) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	ip4 := dst.As4()
+	for i := range rm.routes {
+		row := &rm.routes[i]
+		if row.data[fwdDestPrefixLen] != 32 || [4]byte(row.data[fwdDestAddr:fwdDestAddr+4]) != ip4 ||
+			*(*uint64)(unsafe.Pointer(&row.data[fwdInterfaceLUID])) != rm.realNIC.LUID {
+			continue
+		}
+		r, _, _ := procDeleteIpForwardEntry2.Call(uintptr(unsafe.Pointer(row)))
+		rm.routes = append(rm.routes[:i], rm.routes[i+1:]...)
+		if r != 0 && r != 0x80070490 && r != 0x490 { // ERROR_NOT_FOUND: already gone
+			return fmt.Errorf("[Route] remove bypass %s: DeleteIpForwardEntry2 failed: 0x%x", dst, r)
+		}
+		core.Log.Infof("Route", "Removed bypass route: %s", dst)
+		return nil
+	}
+	return nil
+}
+
 // ClearBypassRoutes removes all bypass routes. Used before re-adding them
 // after a network change (new gateway).
 func (rm *RouteManager) ClearBypassRoutes() {
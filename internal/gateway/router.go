@@ -16,6 +16,7 @@ import (
 	"awg-split-tunnel/internal/platform"
 	"awg-split-tunnel/internal/process"
 	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/shaping"
 )
 
 // DirectTunnelID is the special tunnel ID for unmatched traffic routed via real NIC.
@@ -67,9 +68,23 @@ type TUNRouter struct {
 	rawFwders map[string]provider.RawForwarder // tunnelID → forwarder
 	vpnIPs    map[string][4]byte               // tunnelID → VPN IP
 
+	// Path MTU per raw-forwarding tunnel (copy-on-write, see pmtu.go) and
+	// in-flight PMTU probes keyed by ICMP identifier.
+	mtuMu      sync.Mutex
+	tunnelMTUs atomic.Pointer[map[string]int]
+	pmtuProbes sync.Map // uint16 → *pmtuProbe
+	pmtuSeq    atomic.Uint32
+
 	// External byte reporting callback (for StatsCollector).
 	bytesReporter func(tunnelID string, tx, rx int64)
 
+	// Pins a destination to one peer of a multi-peer tunnel for rules
+	// targeting "tunnel_id/peer".
+	peerPinner func(tunnelID, peer string, dst netip.Addr)
+
+	// Bandwidth limits for raw-forwarded flows (nil = unlimited).
+	shaper *shaping.Shaper
+
 	// Per-process new-flow counter for burst diagnostics (baseLower → *atomic.Int64).
 	// Reset every 10s in packetLoop; logged when burst threshold is exceeded.
 	procFlowCounts sync.Map
@@ -174,6 +189,19 @@ func (r *TUNRouter) SetBytesReporter(fn func(tunnelID string, tx, rx int64)) {
 	r.bytesReporter = fn
 }
 
+// SetPeerPinner sets the callback that routes a new flow's destination
+// through the peer selected by a "tunnel_id/peer" rule. Must be called
+// before Start.
+func (r *TUNRouter) SetPeerPinner(fn func(tunnelID, peer string, dst netip.Addr)) {
+	r.peerPinner = fn
+}
+
+// SetShaper sets the rate limiter for raw-forwarded flows. Must be called
+// before Start.
+func (r *TUNRouter) SetShaper(s *shaping.Shaper) {
+	r.shaper = s
+}
+
 // SetICMPUnreachableCallback registers a callback invoked when an ICMP
 // Destination Unreachable packet arrives. Used to kill matching UDP sessions.
 func (r *TUNRouter) SetICMPUnreachableCallback(cb func(dstIP [4]byte, srcPort, dstPort uint16)) {
@@ -580,6 +608,7 @@ case flowPass:
 		if rf, vpnIP, ok := r.getRawForwarder(tunnelID); ok {
 			basePrio, isAuto := mapRulePriority(rulePrio)
 			prio := resolvePriority(basePrio, isAuto, pkt, protoTCP, m.tpOff, m.srcP, m.dstP)
+			shape := r.shaper.Flow(tunnelID, fb.rule)
 			// Key raw flow by effective (real) dst IP so inbound lookups match.
 			r.flows.InsertRawFlow(protoTCP, effectiveDstIP, m.srcP, RawFlowEntry{
 				LastActivity: r.flows.NowSec(),
@@ -591,6 +620,10 @@ case flowPass:
 				RealDstIP:    realDstIP,
 				ExeLower:     fb.exeLower,
 				BaseLower:    fb.baseLower,
+				Rule:         fb.rule,
+				DstPort:      m.dstP,
+				Shape:        shape,
+				TxBytes:      int64(len(pkt)),
 			})
 			// FakeIP: rewrite packet dst from FakeIP to real IP before forwarding.
 			if fakeIP != [4]byte{} {
@@ -599,7 +632,7 @@ case flowPass:
 					fp.IncrementFlows(fakeIP)
 				}
 			}
-			r.handleRawOutbound(pkt, m, tunnelID, vpnIP, rf, protoTCP, prio)
+			r.handleRawOutbound(pkt, m, tunnelID, vpnIP, rf, protoTCP, prio, shape.Up)
 			return
 		}
 	}
@@ -622,6 +655,8 @@ case flowPass:
 		ExeLower:        fb.exeLower,
 		BaseLower:       fb.baseLower,
 		RuleIdx:         fb.ruleIdx,
+		Rule:            fb.rule,
+		TxBytes:         int64(len(pkt)),
 	}
 	// FakeIP: set resolved real IP for proxy dial.
 	if fakeIP != [4]byte{} {
@@ -641,7 +676,7 @@ case flowPass:
 
 func (r *TUNRouter) handleTCPProxyResponse(pkt []byte, m pktMeta) {
 	dstIP := netip.AddrFrom4(m.dstIP)
-	entry, ok := r.flows.GetAndTouchTCP(dstIP, m.dstP)
+	entry, ok := r.flows.GetAndTouchTCP(dstIP, m.dstP, 0, int64(len(pkt)))
 	if !ok {
 		return
 	}
@@ -681,7 +716,7 @@ func (r *TUNRouter) handleTCPExisting(pkt []byte, m pktMeta) {
 	}
 
 	// Check raw flow table first.
-	if rawEntry, ok := r.flows.GetAndTouchRawFlow(protoTCP, effectiveDstIP, m.srcP); ok {
+	if rawEntry, ok := r.flows.GetAndTouchRawFlow(protoTCP, effectiveDstIP, m.srcP, int64(len(pkt)), 0); ok {
 
 		// RST: clean up raw flow.
 		if m.flags&tcpRST != 0 {
@@ -695,7 +730,7 @@ func (r *TUNRouter) handleTCPExisting(pkt []byte, m pktMeta) {
 			}
 			// Per-packet TCP control boost (SYN/FIN/RST bypass bulk queue).
 			prio := boostTCPControl(pkt, m.tpOff, rawEntry.Priority)
-			r.handleRawOutbound(pkt, m, rawEntry.TunnelID, vpnIP, rf, protoTCP, prio)
+			r.handleRawOutbound(pkt, m, rawEntry.TunnelID, vpnIP, rf, protoTCP, prio, rawEntry.Shape.Up)
 			return
 		}
 		// Forwarder gone — delete stale raw flow and fall through.
@@ -704,7 +739,7 @@ func (r *TUNRouter) handleTCPExisting(pkt []byte, m pktMeta) {
 
 	// Check proxy NAT table.
 	dstIP := netip.AddrFrom4(m.dstIP)
-	entry, ok := r.flows.GetAndTouchTCP(dstIP, m.srcP)
+	entry, ok := r.flows.GetAndTouchTCP(dstIP, m.srcP, int64(len(pkt)), 0)
 	if !ok {
 		// No NAT entry — try to create one (might be a retransmit or late SYN).
 		r.handleTCPSYN(pkt, m)
@@ -764,13 +799,13 @@ func (r *TUNRouter) handleUDP(pkt []byte, m pktMeta) {
 	}
 
 	// Fast path: existing raw flow.
-	if rawEntry, ok := r.flows.GetAndTouchRawFlow(protoUDP, effectiveDstIP, m.srcP); ok {
+	if rawEntry, ok := r.flows.GetAndTouchRawFlow(protoUDP, effectiveDstIP, m.srcP, int64(len(pkt)), 0); ok {
 		if rf, vpnIP, ok := r.getRawForwarder(rawEntry.TunnelID); ok {
 			// FakeIP: rewrite dst from FakeIP to real IP for existing flows.
 			if rawEntry.FakeIP != [4]byte{} {
 				tunOverwriteDstIP(pkt, rawEntry.RealDstIP, m.tpOff+6)
 			}
-			r.handleRawOutbound(pkt, m, rawEntry.TunnelID, vpnIP, rf, protoUDP, rawEntry.Priority)
+			r.handleRawOutbound(pkt, m, rawEntry.TunnelID, vpnIP, rf, protoUDP, rawEntry.Priority, rawEntry.Shape.Up)
 			return
 		}
 		// Forwarder gone — delete stale raw flow and fall through.
@@ -778,7 +813,7 @@ func (r *TUNRouter) handleUDP(pkt []byte, m pktMeta) {
 	}
 
 	// Fast path: existing proxy NAT entry.
-	entry, exists := r.flows.GetAndTouchUDP(dstIP, m.srcP)
+	entry, exists := r.flows.GetAndTouchUDP(dstIP, m.srcP, int64(len(pkt)), 0)
 	if exists {
 		tunSwapIPs(pkt)
 		tunSetUDPPort(pkt, m.tpOff+2, entry.UDPProxyPort, m.tpOff+6)
@@ -841,8 +876,8 @@ case flowPass:
 		if rf, vpnIP, ok := r.getRawForwarder(tunnelID); ok {
 			basePrio, isAuto := mapRulePriority(rulePrio)
 			prio := resolvePriority(basePrio, isAuto, pkt, protoUDP, m.tpOff, m.srcP, m.dstP)
-			// Key raw flow by effective (real) dst IP so inbound lookups match.
-			r.flows.InsertRawFlow(protoUDP, effectiveDstIP, m.srcP, RawFlowEntry{
+			shape := r.shaper.Flow(tunnelID, fb.rule)
+			rawEntry := RawFlowEntry{
 				LastActivity: r.flows.NowSec(),
 				TunnelID:     tunnelID,
 				VpnIP:        vpnIP,
@@ -852,7 +887,17 @@ case flowPass:
 				RealDstIP:    realDstIP,
 				ExeLower:     fb.exeLower,
 				BaseLower:    fb.baseLower,
-			})
+				Rule:         fb.rule,
+				DstPort:      m.dstP,
+				Shape:        shape,
+				FullCone:     fb.fullCone,
+				TxBytes:      int64(len(pkt)),
+			}
+			// Key raw flow by effective (real) dst IP so inbound lookups match.
+			r.flows.InsertRawFlow(protoUDP, effectiveDstIP, m.srcP, rawEntry)
+			if fb.fullCone {
+				r.flows.AddRawCone(vpnIP, m.srcP, rawEntry)
+			}
 			// FakeIP: rewrite packet dst from FakeIP to real IP before forwarding.
 			if fakeIP != [4]byte{} {
 				tunOverwriteDstIP(pkt, realDstIP, m.tpOff+6)
@@ -860,7 +905,7 @@ case flowPass:
 					fp.IncrementFlows(fakeIP)
 				}
 			}
-			r.handleRawOutbound(pkt, m, tunnelID, vpnIP, rf, protoUDP, prio)
+			r.handleRawOutbound(pkt, m, tunnelID, vpnIP, rf, protoUDP, prio, shape.Up)
 			return
 		}
 	}
@@ -881,6 +926,9 @@ case flowPass:
 		ExeLower:        fb.exeLower,
 		BaseLower:       fb.baseLower,
 		RuleIdx:         fb.ruleIdx,
+		Rule:            fb.rule,
+		FullCone:        fb.fullCone,
+		TxBytes:         int64(len(pkt)),
 	}
 	// FakeIP: set resolved real IP for proxy dial.
 	if fakeIP != [4]byte{} {
@@ -929,7 +977,7 @@ func (r *TUNRouter) hijackDNS(pkt []byte, m pktMeta) {
 			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 			defer cancel()
 
-			resp := r.dnsResolver.Resolve(ctx, query)
+			resp := r.dnsResolver.Resolve(withDNSClient(ctx, srcPort, true), query)
 			if resp == nil {
 				return
 			}
@@ -948,9 +996,23 @@ func (r *TUNRouter) hijackDNS(pkt []byte, m pktMeta) {
 	}
 }
 
+// LookupProcess returns the lowercase exe base name of the local process
+// owning the given source port, or "" if it cannot be determined.
+// Used by the DNS query log to attribute queries to applications.
+func (r *TUNRouter) LookupProcess(srcPort uint16, isUDP bool) string {
+	pid, err := r.procID.FindPIDByPort(srcPort, isUDP)
+	if err != nil || pid == r.selfPID {
+		return ""
+	}
+	if _, _, baseL, ok := r.matcher.GetExePathLower(pid); ok {
+		return baseL
+	}
+	return ""
+}
+
 func (r *TUNRouter) handleUDPProxyResponse(pkt []byte, m pktMeta) {
 	dstIP := netip.AddrFrom4(m.dstIP)
-	entry, ok := r.flows.GetUDP(dstIP, m.dstP)
+	entry, ok := r.flows.GetAndTouchUDP(dstIP, m.dstP, 0, int64(len(pkt)))
 	if !ok {
 		return
 	}
@@ -1049,13 +1111,13 @@ func (r *TUNRouter) handleICMP(pkt []byte, m pktMeta) {
 	}
 
 	// Fast path: existing raw flow (keyed by effective/real IP).
-	if rawEntry, ok := r.flows.GetAndTouchRawFlow(protoICMP, effectiveDstIP, icmpID); ok {
+	if rawEntry, ok := r.flows.GetAndTouchRawFlow(protoICMP, effectiveDstIP, icmpID, int64(len(pkt)), 0); ok {
 		if rf, vpnIP, ok := r.getRawForwarder(rawEntry.TunnelID); ok {
 			// FakeIP: rewrite dst from FakeIP to real IP before forwarding.
 			if rawEntry.FakeIP != [4]byte{} {
 				tunOverwriteDstIP(pkt, rawEntry.RealDstIP, 0)
 			}
-			r.handleRawOutbound(pkt, m, rawEntry.TunnelID, vpnIP, rf, protoICMP, rawEntry.Priority)
+			r.handleRawOutbound(pkt, m, rawEntry.TunnelID, vpnIP, rf, protoICMP, rawEntry.Priority, rawEntry.Shape.Up)
 			return
 		}
 		// Forwarder gone — delete stale flow and fall through.
@@ -1079,6 +1141,7 @@ case flowPass:
 		return
 	}
 
+	shape := r.shaper.Flow(tunnelID, "")
 	r.flows.InsertRawFlow(protoICMP, effectiveDstIP, icmpID, RawFlowEntry{
 		LastActivity: r.flows.NowSec(),
 		TunnelID:     tunnelID,
@@ -1086,6 +1149,8 @@ case flowPass:
 		Priority:     PrioNormal,
 		FakeIP:       fakeIP,
 		RealDstIP:    realDstIP,
+		Shape:        shape,
+		TxBytes:      int64(len(pkt)),
 	})
 	// FakeIP: rewrite dst from FakeIP to real IP before forwarding.
 	if fakeIP != [4]byte{} {
@@ -1094,7 +1159,7 @@ case flowPass:
 			fp.IncrementFlows(fakeIP)
 		}
 	}
-	r.handleRawOutbound(pkt, m, tunnelID, vpnIP, rf, protoICMP, PrioNormal)
+	r.handleRawOutbound(pkt, m, tunnelID, vpnIP, rf, protoICMP, PrioNormal, shape.Up)
 }
 
 // resolveICMPFlow determines which tunnel should handle an ICMP packet.
@@ -1203,57 +1268,31 @@ type flowFallbackInfo struct {
 	exeLower  string
 	baseLower string
 	ruleIdx   int
+	rule      string // matched rule pattern, for flow records
+	fullCone  bool   // matched rule uses udp_nat: full_cone
 }
 
-func (r *TUNRouter) resolveFlow(srcPort uint16, isUDP bool, dstIP [4]byte) (tunnelID string, proxyPort uint16, action flowAction, rulePrio core.RulePriority, fb flowFallbackInfo) {
-	f := r.ipFilter.Load() // may be nil
-
-	// Per-tunnel AllowedIPs routing: if the dst IP is in a tunnel's split-include
-	// routes (e.g. AnyConnect corporate subnets), route through that tunnel.
-	// This takes priority over local bypass — corporate 10.x.x.x subnets overlap
-	// with RFC 1918 ranges but must be routed through the VPN tunnel.
-	// Uses longest-prefix match among UP tunnels to handle overlapping subnets.
-	if f != nil {
-		if tid, ok := f.FindTunnelByAllowedIP(dstIP, func(id string) bool {
-			entry, ok := r.registry.Get(id)
-			return ok && entry.State == core.TunnelStateUp
-		}); ok {
-			if regEntry, ok := r.registry.Get(tid); ok {
-				if isUDP {
-					if port, ok := r.registry.GetUDPProxyPort(tid); ok {
-						return tid, port, flowRoute, core.PriorityAuto, fb
-					}
-				}
-				return tid, regEntry.ProxyPort, flowRoute, core.PriorityAuto, fb
-			}
-		}
-	}
-
-	// Early drop: if a local/private IP reached TUN, there is no direct route
-	// through any local interface (Docker/WSL/Hyper-V vNIC is down or absent).
-	// The __direct__ proxy (bound to the physical NIC via IP_UNICAST_IF) cannot
-	// deliver these packets either — drop them to avoid futile proxy timeouts.
-	if f != nil && f.IsLocalBypassIP(dstIP) {
-		return "", 0, flowDrop, 0, fb
-	}
-
+// resolveByDestination applies the destination-based routing layers shared
+// by local processes and wg_server peers: FakeIP, the DNS domain table and
+// GeoIP. ok is false when none of them decides the flow.
+func (r *TUNRouter) resolveByDestination(dstIP [4]byte, isUDP bool) (tunnelID string, proxyPort uint16, action flowAction, ok bool) {
 	// FakeIP routing: highest priority for domain-based routing.
 	// IsFakeIP is lock-free arithmetic — safe for hot path.
 	if fp := r.fakeIPPool.Load(); fp != nil && fp.IsFakeIP(dstIP) {
 		if entry, ok := fp.Lookup(dstIP); ok {
 			switch entry.Action {
 			case core.DomainBlock:
-				return "", 0, flowDrop, 0, fb
+				return "", 0, flowDrop, true
 			case core.DomainDirect:
-				return "", 0, flowPass, 0, fb
+				return "", 0, flowPass, true
 			case core.DomainRoute:
 				if regEntry, ok := r.registry.Get(entry.TunnelID); ok && regEntry.State == core.TunnelStateUp {
 					if isUDP {
 						if port, ok := r.registry.GetUDPProxyPort(entry.TunnelID); ok {
-							return entry.TunnelID, port, flowRoute, core.PriorityAuto, fb
+							return entry.TunnelID, port, flowRoute, true
 						}
 					}
-					return entry.TunnelID, regEntry.ProxyPort, flowRoute, core.PriorityAuto, fb
+					return entry.TunnelID, regEntry.ProxyPort, flowRoute, true
 				}
 				// Tunnel down — fall through to process rules.
 			}
@@ -1266,17 +1305,17 @@ func (r *TUNRouter) resolveFlow(srcPort uint16, isUDP bool, dstIP [4]byte) (tunn
 		if dEntry, ok := dt.Lookup(dstIP); ok {
 			switch dEntry.Action {
 			case core.DomainBlock:
-				return "", 0, flowDrop, 0, fb
+				return "", 0, flowDrop, true
 			case core.DomainDirect:
-				return "", 0, flowPass, 0, fb
+				return "", 0, flowPass, true
 			case core.DomainRoute:
 				if entry, ok := r.registry.Get(dEntry.TunnelID); ok && entry.State == core.TunnelStateUp {
 					if isUDP {
 						if port, ok := r.registry.GetUDPProxyPort(dEntry.TunnelID); ok {
-							return dEntry.TunnelID, port, flowRoute, core.PriorityAuto, fb
+							return dEntry.TunnelID, port, flowRoute, true
 						}
 					}
-					return dEntry.TunnelID, entry.ProxyPort, flowRoute, core.PriorityAuto, fb
+					return dEntry.TunnelID, entry.ProxyPort, flowRoute, true
 				}
 				// Tunnel down — fall through to process rules.
 			}
@@ -1289,23 +1328,63 @@ func (r *TUNRouter) resolveFlow(srcPort uint16, isUDP bool, dstIP [4]byte) (tunn
 		if geoTunnelID, geoAction, geoOK := gm.Match(dstIP); geoOK {
 			switch geoAction {
 			case core.DomainBlock:
-				return "", 0, flowDrop, 0, fb
+				return "", 0, flowDrop, true
 			case core.DomainDirect:
-				return "", 0, flowPass, 0, fb
+				return "", 0, flowPass, true
 			case core.DomainRoute:
 				if entry, ok := r.registry.Get(geoTunnelID); ok && entry.State == core.TunnelStateUp {
 					if isUDP {
 						if port, ok := r.registry.GetUDPProxyPort(geoTunnelID); ok {
-							return geoTunnelID, port, flowRoute, core.PriorityAuto, fb
+							return geoTunnelID, port, flowRoute, true
 						}
 					}
-					return geoTunnelID, entry.ProxyPort, flowRoute, core.PriorityAuto, fb
+					return geoTunnelID, entry.ProxyPort, flowRoute, true
 				}
 				// Tunnel down — fall through to process rules.
 			}
 		}
 	}
 
+	return "", 0, flowPass, false
+}
+
+func (r *TUNRouter) resolveFlow(srcPort uint16, isUDP bool, dstIP [4]byte) (tunnelID string, proxyPort uint16, action flowAction, rulePrio core.RulePriority, fb flowFallbackInfo) {
+	f := r.ipFilter.Load() // may be nil
+
+	// Per-tunnel AllowedIPs routing: if the dst IP is in a tunnel's split-include
+	// routes (e.g. AnyConnect corporate subnets), route through that tunnel.
+	// This takes priority over local bypass — corporate 10.x.x.x subnets overlap
+	// with RFC 1918 ranges but must be routed through the VPN tunnel.
+	// Uses longest-prefix match among UP tunnels to handle overlapping subnets.
+	if f != nil {
+		if tid, ok := f.FindTunnelByAllowedIP(dstIP, func(id string) bool {
+			entry, ok := r.registry.Get(id)
+			return ok && entry.State == core.TunnelStateUp
+		}); ok {
+			if regEntry, ok := r.registry.Get(tid); ok {
+				if isUDP {
+					if port, ok := r.registry.GetUDPProxyPort(tid); ok {
+						return tid, port, flowRoute, core.PriorityAuto, fb
+					}
+				}
+				return tid, regEntry.ProxyPort, flowRoute, core.PriorityAuto, fb
+			}
+		}
+	}
+
+	// Early drop: if a local/private IP reached TUN, there is no direct route
+	// through any local interface (Docker/WSL/Hyper-V vNIC is down or absent).
+	// The __direct__ proxy (bound to the physical NIC via IP_UNICAST_IF) cannot
+	// deliver these packets either — drop them to avoid futile proxy timeouts.
+	if f != nil && f.IsLocalBypassIP(dstIP) {
+		return "", 0, flowDrop, 0, fb
+	}
+
+	// FakeIP, domain and GeoIP routing take priority over process rules.
+	if tid, port, action, ok := r.resolveByDestination(dstIP, isUDP); ok {
+		return tid, port, action, core.PriorityAuto, fb
+	}
+
 	// Look up PID by source port.
 	pid, err := r.procID.FindPIDByPort(srcPort, isUDP)
 	if err != nil {
@@ -1458,6 +1537,14 @@ func (r *TUNRouter) resolveFlow(srcPort uint16, isUDP bool, dstIP [4]byte) (tunn
 			exeLower:  exeLower,
 			baseLower: baseLower,
 			ruleIdx:   currentRuleIdx,
+			rule:      result.Pattern,
+			fullCone:  result.FullCone,
+		}
+
+		// Peer-targeted rule: steer this destination to the peer before the
+		// first packet reaches the tunnel.
+		if result.Peer != "" && r.peerPinner != nil {
+			r.peerPinner(result.TunnelID, result.Peer, netip.AddrFrom4(dstIP))
 		}
 
 		if isUDP {
@@ -1514,6 +1601,7 @@ func (r *TUNRouter) UnregisterRawForwarder(tunnelID string) {
 	if vpnIP != [4]byte{} {
 		r.flows.UnregisterVpnIP(vpnIP)
 	}
+	r.SetTunnelMTU(tunnelID, 0)
 
 	marked := r.flows.MarkDeadByTunnelID(tunnelID)
 	core.Log.Infof("Gateway", "Raw forwarder unregistered for tunnel %q (marked %d flows dead)", tunnelID, marked)
@@ -1530,8 +1618,13 @@ func (r *TUNRouter) getRawForwarder(tunnelID string) (provider.RawForwarder, [4]
 
 // handleRawOutbound rewrites the source IP, applies DSCP marking for high-priority
 // traffic, and injects the packet into the tunnel at the given priority level.
-// Returns true if the packet was handled via raw forwarding.
-func (r *TUNRouter) handleRawOutbound(pkt []byte, m pktMeta, tunnelID string, vpnIP [4]byte, rf provider.RawForwarder, proto byte, prio byte) bool {
+// Packets over the flow's rate limits are dropped; high-priority packets are
+// only charged. Returns true if the packet was handled via raw forwarding.
+func (r *TUNRouter) handleRawOutbound(pkt []byte, m pktMeta, tunnelID string, vpnIP [4]byte, rf provider.RawForwarder, proto byte, prio byte, lim shaping.Limiter) bool {
+	if !allowShaped(lim, len(pkt), prio) {
+		return false
+	}
+
 	// Determine transport checksum offset.
 	var transportCkOff int
 	switch proto {
@@ -1543,7 +1636,14 @@ func (r *TUNRouter) handleRawOutbound(pkt []byte, m pktMeta, tunnelID string, vp
 
 	// Clamp TCP MSS on SYN packets to prevent oversized segments in tunnel.
 	if proto == protoTCP {
-		clampTCPMSS(pkt, m.tpOff)
+		clampTCPMSS(pkt, m.tpOff, r.tunnelMSS(tunnelID))
+	}
+
+	// Packets too big for the tunnel path with DF set: tell the app
+	// (ICMP "fragmentation needed") so it lowers its path MTU.
+	if mtu := r.TunnelMTU(tunnelID); mtu > 0 && len(pkt) > mtu && pkt[6]&0x40 != 0 {
+		r.writePacket(buildICMPFragNeeded(pkt, mtu))
+		return true
 	}
 
 	// Rewrite src IP from TUN IP (10.255.0.1) to tunnel's VPN IP.
@@ -1622,6 +1722,10 @@ func (r *TUNRouter) handleInboundRaw(pkt []byte) bool {
 		if len(pkt) < ihl+minICMPHdr {
 			return false
 		}
+		// Replies to our own path MTU probes never reach the TUN.
+		if r.deliverPMTUReply(pkt, ihl) {
+			return true
+		}
 		icmpType := pkt[ihl]
 		switch icmpType {
 		case icmpEchoReply:
@@ -1661,14 +1765,31 @@ func (r *TUNRouter) handleInboundRaw(pkt []byte) bool {
 	// Check if this response matches a raw flow entry.
 	// For inbound: srcIP = original destination, dstPort = original source port.
 	inboundStart := time.Now()
-	rawEntry, ok := r.flows.GetAndTouchRawFlow(proto, srcIP, dstPort)
+	rawEntry, ok := r.flows.GetAndTouchRawFlow(proto, srcIP, dstPort, 0, int64(len(pkt)))
+	if !ok && proto == protoUDP {
+		// Full-cone: a new remote reaching the client port of a
+		// udp_nat: full_cone flow.
+		remotePort := binary.BigEndian.Uint16(pkt[ihl:])
+		rawEntry, ok = r.flows.AcceptRawCone(dstIP, srcIP, dstPort, remotePort, int64(len(pkt)))
+	}
 	if !ok {
 		return false // no raw flow — let gVisor handle (proxy/DNS resolver traffic)
 	}
 
+	// Rate limits: the packet is consumed (dropped) when over the limit.
+	if len(rawEntry.Shape.Down) > 0 {
+		prio := rawEntry.Priority
+		if proto == protoTCP {
+			prio = boostTCPControl(pkt, ihl, prio)
+		}
+		if !allowShaped(rawEntry.Shape.Down, len(pkt), prio) {
+			return true
+		}
+	}
+
 	// Clamp TCP MSS on inbound SYN-ACK to prevent client sending oversized segments.
 	if proto == protoTCP {
-		clampTCPMSS(pkt, ihl)
+		clampTCPMSS(pkt, ihl, r.tunnelMSS(rawEntry.TunnelID))
 	}
 
 	// Rewrite dst IP from VPN IP to TUN IP (10.255.0.1).
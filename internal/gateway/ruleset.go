@@ -0,0 +1,675 @@
+package gateway
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sagernet/sing/common/domain"
+	"gopkg.in/yaml.v3"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// ruleSetDefaultRefresh is used when a rule set has no refresh_interval.
+	ruleSetDefaultRefresh = 24 * time.Hour
+	// ruleSetMaxSize limits the downloaded rule set body.
+	ruleSetMaxSize = 32 << 20
+)
+
+// RuleSetDomain is a single domain entry of a rule set.
+type RuleSetDomain struct {
+	Type  string // "domain" (with subdomains), "full" (exact) or "keyword"
+	Value string
+}
+
+// RuleSet is the parsed content of a rule set. A set may mix entry kinds;
+// domain rules use Domains and CIDRs, process rules use Processes.
+type RuleSet struct {
+	Domains   []RuleSetDomain
+	CIDRs     []netip.Prefix // IPv4 only (the TUN stack is IPv4-only)
+	Processes []string       // process names or full paths
+}
+
+// Len returns the total number of entries.
+func (rs *RuleSet) Len() int {
+	return len(rs.Domains) + len(rs.CIDRs) + len(rs.Processes)
+}
+
+// ParseRuleSet parses a rule set in the given format ("auto", "domain",
+// "ipcidr", "clash", "singbox", "srs"). Entries that cannot be represented
+// (regexes, ports, logical or inverted rules) are skipped.
+func ParseRuleSet(data []byte, format string) (*RuleSet, error) {
+	if format == "" || format == core.RuleSetFormatAuto {
+		format = detectRuleSetFormat(data)
+	}
+
+	switch format {
+	case core.RuleSetFormatSRS:
+		return parseSRS(data)
+	case core.RuleSetFormatSingBox:
+		return parseSingBoxRuleSet(data)
+	}
+
+	rs := &RuleSet{}
+	if format == core.RuleSetFormatClash && bytes.Contains(data, []byte("payload:")) {
+		var provider struct {
+			Payload []string `yaml:"payload"`
+		}
+		if err := yaml.Unmarshal(data, &provider); err != nil {
+			return nil, fmt.Errorf("parse clash rule provider: %w", err)
+		}
+		for _, line := range provider.Payload {
+			rs.addClashEntry(strings.TrimSpace(line))
+		}
+		return rs, nil
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || strings.HasPrefix(line, "//") {
+			continue
+		}
+		rs.addTextLine(line, format)
+	}
+	return rs, nil
+}
+
+// detectRuleSetFormat guesses the format of a rule set file.
+func detectRuleSetFormat(data []byte) string {
+	if bytes.HasPrefix(data, []byte("SRS")) {
+		return core.RuleSetFormatSRS
+	}
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return core.RuleSetFormatSingBox
+	}
+	if bytes.HasPrefix(trimmed, []byte("payload:")) || bytes.Contains(data, []byte("\npayload:")) {
+		return core.RuleSetFormatClash
+	}
+	return core.RuleSetFormatAuto
+}
+
+// addTextLine parses one line of a text list. Lines are classified
+// individually: classical Clash rules ("DOMAIN-SUFFIX,example.com"),
+// CIDRs/addresses, prefixed domain patterns ("full:", "domain:", "keyword:")
+// and bare domains, which match subdomains too. Clash text lists follow
+// the rule-provider semantics instead (see addClashEntry).
+func (rs *RuleSet) addTextLine(line, format string) {
+	if i := strings.Index(line, " #"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+
+	if format == core.RuleSetFormatClash {
+		rs.addClashEntry(line)
+		return
+	}
+	if format != core.RuleSetFormatDomain && format != core.RuleSetFormatIPCIDR && strings.Contains(line, ",") {
+		rs.addClashEntry(line)
+		return
+	}
+	if format != core.RuleSetFormatDomain && rs.addCIDR(line) {
+		return
+	}
+	if format == core.RuleSetFormatIPCIDR {
+		return
+	}
+
+	typ := "domain"
+	if prefix, value, ok := strings.Cut(line, ":"); ok {
+		switch prefix {
+		case "full", "domain", "keyword":
+			typ, line = prefix, value
+		}
+	}
+	line = strings.TrimPrefix(strings.TrimPrefix(line, "+."), "*.")
+	rs.addDomain(typ, strings.TrimPrefix(line, "."))
+}
+
+// addClashEntry parses a Clash rule-provider entry. Classical entries carry
+// a rule type; bare entries follow the "domain" behavior ("+.example.com"
+// matches subdomains, "example.com" is exact) or are CIDRs.
+func (rs *RuleSet) addClashEntry(line string) {
+	if line == "" || line[0] == '#' {
+		return
+	}
+	typ, rest, ok := strings.Cut(line, ",")
+	if !ok {
+		if rs.addCIDR(line) {
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "+."):
+			rs.addDomain("domain", line[2:])
+		case strings.HasPrefix(line, "*."):
+			rs.addDomain("domain", line[2:])
+		case strings.HasPrefix(line, "."):
+			rs.addDomain("domain", line[1:])
+		default:
+			rs.addDomain("full", line)
+		}
+		return
+	}
+
+	value, _, _ := strings.Cut(rest, ",") // drop options like "no-resolve"
+	value = strings.TrimSpace(value)
+	switch strings.ToUpper(strings.TrimSpace(typ)) {
+	case "DOMAIN":
+		rs.addDomain("full", value)
+	case "DOMAIN-SUFFIX":
+		rs.addDomain("domain", strings.TrimPrefix(value, "."))
+	case "DOMAIN-KEYWORD":
+		rs.addDomain("keyword", value)
+	case "IP-CIDR", "IP-CIDR6":
+		rs.addCIDR(value)
+	case "PROCESS-NAME", "PROCESS-PATH":
+		rs.addProcess(value)
+	}
+}
+
+// addDomain appends a domain entry after normalization.
+func (rs *RuleSet) addDomain(typ, value string) {
+	value = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(value), "."))
+	if typ == "keyword" {
+		if value != "" {
+			rs.Domains = append(rs.Domains, RuleSetDomain{Type: typ, Value: value})
+		}
+		return
+	}
+	if !isValidListDomain(value) {
+		return
+	}
+	rs.Domains = append(rs.Domains, RuleSetDomain{Type: typ, Value: value})
+}
+
+// addCIDR appends a CIDR or single address. Returns false if s is neither.
+// IPv6 entries are recognized but dropped.
+func (rs *RuleSet) addCIDR(s string) bool {
+	pfx, err := netip.ParsePrefix(s)
+	if err != nil {
+		addr, aerr := netip.ParseAddr(s)
+		if aerr != nil {
+			return false
+		}
+		pfx = netip.PrefixFrom(addr, addr.BitLen())
+	}
+	if pfx.Addr().Is4() {
+		rs.CIDRs = append(rs.CIDRs, pfx.Masked())
+	}
+	return true
+}
+
+// addProcess appends a process name or path.
+func (rs *RuleSet) addProcess(value string) {
+	if value = strings.TrimSpace(value); value != "" {
+		rs.Processes = append(rs.Processes, value)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// sing-box source (JSON) rule sets
+// ---------------------------------------------------------------------------
+
+// singBoxList accepts both a single string and a list of strings.
+type singBoxList []string
+
+func (l *singBoxList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*l = []string{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+type singBoxHeadlessRule struct {
+	Type          string      `json:"type"`
+	Domain        singBoxList `json:"domain"`
+	DomainSuffix  singBoxList `json:"domain_suffix"`
+	DomainKeyword singBoxList `json:"domain_keyword"`
+	IPCIDR        singBoxList `json:"ip_cidr"`
+	ProcessName   singBoxList `json:"process_name"`
+	ProcessPath   singBoxList `json:"process_path"`
+	Invert        bool        `json:"invert"`
+}
+
+func parseSingBoxRuleSet(data []byte) (*RuleSet, error) {
+	var src struct {
+		Version int                   `json:"version"`
+		Rules   []singBoxHeadlessRule `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &src); err != nil {
+		return nil, fmt.Errorf("parse sing-box rule set: %w", err)
+	}
+
+	rs := &RuleSet{}
+	for _, r := range src.Rules {
+		if (r.Type != "" && r.Type != "default") || r.Invert {
+			continue
+		}
+		rs.addSingBoxItems(r.Domain, r.DomainSuffix, r.DomainKeyword, r.IPCIDR, r.ProcessName, r.ProcessPath)
+	}
+	return rs, nil
+}
+
+// addSingBoxItems appends the items of a sing-box default rule.
+func (rs *RuleSet) addSingBoxItems(domains, suffixes, keywords, cidrs, processNames, processPaths []string) {
+	for _, d := range domains {
+		rs.addDomain("full", d)
+	}
+	for _, d := range suffixes {
+		// ".example.com" matches subdomains only; approximated as a domain rule.
+		rs.addDomain("domain", strings.TrimPrefix(d, "."))
+	}
+	for _, k := range keywords {
+		rs.addDomain("keyword", k)
+	}
+	for _, c := range cidrs {
+		rs.addCIDR(c)
+	}
+	for _, p := range processNames {
+		rs.addProcess(p)
+	}
+	for _, p := range processPaths {
+		rs.addProcess(p)
+	}
+}
+
+// ---------------------------------------------------------------------------
+// sing-box binary (.srs) rule sets
+// ---------------------------------------------------------------------------
+
+// srs rule item types.
+const (
+	srsItemQueryType uint8 = iota
+	srsItemNetwork
+	srsItemDomain
+	srsItemDomainKeyword
+	srsItemDomainRegex
+	srsItemSourceIPCIDR
+	srsItemIPCIDR
+	srsItemSourcePort
+	srsItemSourcePortRange
+	srsItemPort
+	srsItemPortRange
+	srsItemProcessName
+	srsItemProcessPath
+	srsItemPackageName
+	srsItemWIFISSID
+	srsItemWIFIBSSID
+	srsItemAdGuardDomain
+	srsItemProcessPathRegex
+	srsItemNetworkType
+	srsItemNetworkIsExpensive
+	srsItemNetworkIsConstrained
+	srsItemFinal uint8 = 0xFF
+)
+
+// parseSRS decodes a sing-box binary rule set: "SRS" magic, version byte and
+// a zlib stream of rules.
+func parseSRS(data []byte) (*RuleSet, error) {
+	if len(data) < 4 || !bytes.HasPrefix(data, []byte("SRS")) {
+		return nil, fmt.Errorf("not a sing-box binary rule set")
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data[4:]))
+	if err != nil {
+		return nil, fmt.Errorf("srs: %w", err)
+	}
+	defer zr.Close()
+	r := bufio.NewReader(zr)
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, fmt.Errorf("srs: rule count: %w", err)
+	}
+	rs := &RuleSet{}
+	for i := uint64(0); i < count; i++ {
+		if err := readSRSRule(r, rs); err != nil {
+			return nil, fmt.Errorf("srs: rule %d: %w", i, err)
+		}
+	}
+	return rs, nil
+}
+
+// readSRSRule reads one rule. Default rules are merged into rs unless
+// inverted; logical rules are consumed and dropped.
+func readSRSRule(r *bufio.Reader, rs *RuleSet) error {
+	ruleType, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	switch ruleType {
+	case 0:
+		item := &RuleSet{}
+		if err := readSRSDefaultRule(r, item); err != nil {
+			return err
+		}
+		invert, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if invert == 0 {
+			rs.Domains = append(rs.Domains, item.Domains...)
+			rs.CIDRs = append(rs.CIDRs, item.CIDRs...)
+			rs.Processes = append(rs.Processes, item.Processes...)
+		}
+		return nil
+	case 1:
+		if _, err := r.ReadByte(); err != nil { // mode
+			return err
+		}
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return err
+		}
+		discard := &RuleSet{}
+		for i := uint64(0); i < n; i++ {
+			if err := readSRSRule(r, discard); err != nil {
+				return err
+			}
+		}
+		_, err = r.ReadByte() // invert
+		return err
+	default:
+		return fmt.Errorf("unknown rule type %d", ruleType)
+	}
+}
+
+// readSRSDefaultRule reads rule items up to the final marker.
+func readSRSDefaultRule(r *bufio.Reader, rs *RuleSet) error {
+	for {
+		itemType, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch itemType {
+		case srsItemFinal:
+			return nil
+		case srsItemDomain:
+			matcher, err := domain.ReadMatcher(r)
+			if err != nil {
+				return fmt.Errorf("domain matcher: %w", err)
+			}
+			domains, suffixes := matcher.Dump()
+			rs.addSingBoxItems(domains, suffixes, nil, nil, nil, nil)
+		case srsItemAdGuardDomain:
+			if _, err := domain.ReadAdGuardMatcher(r); err != nil {
+				return fmt.Errorf("adguard matcher: %w", err)
+			}
+		case srsItemDomainKeyword, srsItemProcessName, srsItemProcessPath:
+			values, err := readSRSStrings(r)
+			if err != nil {
+				return err
+			}
+			switch itemType {
+			case srsItemDomainKeyword:
+				rs.addSingBoxItems(nil, nil, values, nil, nil, nil)
+			default:
+				rs.addSingBoxItems(nil, nil, nil, nil, values, nil)
+			}
+		case srsItemIPCIDR, srsItemSourceIPCIDR:
+			prefixes, err := readSRSIPSet(r)
+			if err != nil {
+				return err
+			}
+			if itemType == srsItemIPCIDR {
+				rs.CIDRs = append(rs.CIDRs, prefixes...)
+			}
+		case srsItemNetwork, srsItemDomainRegex, srsItemSourcePortRange, srsItemPortRange,
+			srsItemPackageName, srsItemWIFISSID, srsItemWIFIBSSID, srsItemProcessPathRegex:
+			if _, err := readSRSStrings(r); err != nil {
+				return err
+			}
+		case srsItemQueryType, srsItemSourcePort, srsItemPort:
+			if err := skipSRSSlice(r, 2); err != nil {
+				return err
+			}
+		case srsItemNetworkType:
+			if err := skipSRSSlice(r, 1); err != nil {
+				return err
+			}
+		case srsItemNetworkIsExpensive, srsItemNetworkIsConstrained:
+			// No payload.
+		default:
+			return fmt.Errorf("unknown rule item %d", itemType)
+		}
+	}
+}
+
+// readSRSStrings reads a uvarint-prefixed list of uvarint-prefixed strings.
+func readSRSStrings(r *bufio.Reader) ([]string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, 0, min(n, 4096))
+	for i := uint64(0); i < n; i++ {
+		l, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if l > ruleSetMaxSize {
+			return nil, fmt.Errorf("string too long")
+		}
+		buf := make([]byte, l)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		values = append(values, string(buf))
+	}
+	return values, nil
+}
+
+// skipSRSSlice skips a uvarint-prefixed list of fixed-size values.
+func skipSRSSlice(r *bufio.Reader, itemSize int) error {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if n > ruleSetMaxSize {
+		return fmt.Errorf("list too long")
+	}
+	_, err = r.Discard(int(n) * itemSize)
+	return err
+}
+
+// readSRSIPSet reads an IP set stored as address ranges and converts the
+// IPv4 ranges to prefixes.
+func readSRSIPSet(r *bufio.Reader) ([]netip.Prefix, error) {
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != 1 {
+		return nil, fmt.Errorf("unsupported ip set version %d", version)
+	}
+	var n uint64
+	if err := binary.Read(r, binary.BigEndian, &n); err != nil {
+		return nil, err
+	}
+	if n > ruleSetMaxSize {
+		return nil, fmt.Errorf("ip set too large")
+	}
+
+	readAddr := func() (netip.Addr, error) {
+		l, err := binary.ReadUvarint(r)
+		if err != nil {
+			return netip.Addr{}, err
+		}
+		if l != 4 && l != 16 {
+			return netip.Addr{}, fmt.Errorf("invalid address length %d", l)
+		}
+		buf := make([]byte, l)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return netip.Addr{}, err
+		}
+		addr, _ := netip.AddrFromSlice(buf)
+		return addr.Unmap(), nil
+	}
+
+	var prefixes []netip.Prefix
+	for i := uint64(0); i < n; i++ {
+		from, err := readAddr()
+		if err != nil {
+			return nil, err
+		}
+		to, err := readAddr()
+		if err != nil {
+			return nil, err
+		}
+		if from.Is4() && to.Is4() {
+			prefixes = appendRangePrefixes(prefixes, from.As4(), to.As4())
+		}
+	}
+	return prefixes, nil
+}
+
+// appendRangePrefixes appends the minimal set of prefixes covering from..to.
+func appendRangePrefixes(prefixes []netip.Prefix, from, to [4]byte) []netip.Prefix {
+	lo := uint64(binary.BigEndian.Uint32(from[:]))
+	hi := uint64(binary.BigEndian.Uint32(to[:]))
+	for lo <= hi {
+		bits := 32
+		for bits > 0 {
+			size := uint64(1) << (33 - bits)
+			if lo%size != 0 || lo+size-1 > hi {
+				break
+			}
+			bits--
+		}
+		var ip [4]byte
+		binary.BigEndian.PutUint32(ip[:], uint32(lo))
+		prefixes = append(prefixes, netip.PrefixFrom(netip.AddrFrom4(ip), bits))
+		lo += uint64(1) << (32 - bits)
+	}
+	return prefixes
+}
+
+// ---------------------------------------------------------------------------
+// RuleSetManager
+// ---------------------------------------------------------------------------
+
+// RuleSetManager loads configured rule sets, keeps a cached copy of each
+// remote set on disk and refreshes them on their interval. Whenever a set
+// changes, the onUpdate callback is invoked so matchers can be rebuilt.
+type RuleSetManager struct {
+	remote *remoteLists
+
+	mu   sync.RWMutex
+	sets map[string]*RuleSet
+}
+
+// NewRuleSetManager creates a manager that caches downloaded sets in cacheDir.
+// httpClient may be nil — falls back to http.DefaultClient.
+func NewRuleSetManager(cacheDir string, httpClient *http.Client) *RuleSetManager {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	m := &RuleSetManager{sets: make(map[string]*RuleSet)}
+	m.remote = &remoteLists{
+		kind:           "rule set",
+		logTag:         "Rule",
+		goName:         "rule.ruleset",
+		cacheExt:       ".ruleset",
+		maxSize:        ruleSetMaxSize,
+		defaultRefresh: ruleSetDefaultRefresh,
+		httpClient:     httpClient,
+		cacheDir:       cacheDir,
+		store:          m.store,
+		stopFns:        make(map[string]context.CancelFunc),
+	}
+	return m
+}
+
+// SetOnUpdate sets the callback invoked after a set is refreshed in the background.
+func (m *RuleSetManager) SetOnUpdate(fn func()) {
+	m.remote.setOnUpdate(fn)
+}
+
+// Configure applies a new rule set configuration. Sets are loaded from the
+// on-disk cache (or local path) synchronously; sets without a cached copy are
+// downloaded in the background. Refresh loops are restarted.
+func (m *RuleSetManager) Configure(ctx context.Context, configs []core.RuleSetConfig) {
+	sources := make([]listSource, len(configs))
+	m.mu.Lock()
+	sets := make(map[string]*RuleSet, len(configs))
+	for i, rc := range configs {
+		if prev, ok := m.sets[rc.Name]; ok && rc.IsEnabled() {
+			sets[rc.Name] = prev
+		}
+		sources[i] = listSource{
+			Name:            rc.Name,
+			URL:             rc.URL,
+			Path:            rc.Path,
+			Format:          rc.Format,
+			RefreshInterval: rc.RefreshInterval,
+			Enabled:         rc.IsEnabled(),
+		}
+	}
+	m.sets = sets
+	m.mu.Unlock()
+
+	m.remote.configure(ctx, sources)
+}
+
+// Stop halts all refresh loops.
+func (m *RuleSetManager) Stop() {
+	m.remote.stop()
+}
+
+// Refresh re-reads (local) or re-downloads (remote) a single set and
+// invokes the update callback.
+func (m *RuleSetManager) Refresh(ctx context.Context, name string) error {
+	return m.remote.refresh(ctx, name)
+}
+
+// Get returns the loaded set, or nil if it is unknown, disabled or not loaded yet.
+func (m *RuleSetManager) Get(name string) *RuleSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.sets[name]
+}
+
+// ProcessSets returns the process entries of every loaded set, keyed by name.
+func (m *RuleSetManager) ProcessSets() map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	result := make(map[string][]string, len(m.sets))
+	for name, rs := range m.sets {
+		if len(rs.Processes) > 0 {
+			result[name] = rs.Processes
+		}
+	}
+	return result
+}
+
+// store parses data and replaces the set. Returns the number of entries.
+// On a parse error the previous content is kept.
+func (m *RuleSetManager) store(src listSource, data []byte) (int, error) {
+	rs, err := ParseRuleSet(data, src.Format)
+	if err != nil {
+		return 0, err
+	}
+	m.mu.Lock()
+	m.sets[src.Name] = rs
+	m.mu.Unlock()
+	return rs.Len(), nil
+}
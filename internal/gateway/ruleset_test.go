@@ -0,0 +1,131 @@
+package gateway
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/sagernet/sing/common/domain"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestParseRuleSet_Formats(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		format    string
+		domains   []RuleSetDomain
+		cidrs     []string
+		processes []string
+	}{
+		{
+			name: "plain",
+			data: "# comment\nexample.com\nfull:api.example.org\n10.0.0.0/8\n2001:db8::/32\n",
+			domains: []RuleSetDomain{
+				{Type: "domain", Value: "example.com"},
+				{Type: "full", Value: "api.example.org"},
+			},
+			cidrs: []string{"10.0.0.0/8"},
+		},
+		{
+			name:   "clash yaml",
+			format: core.RuleSetFormatClash,
+			data: `payload:
+  - '+.google.com'
+  - 'youtube.com'
+  - DOMAIN-KEYWORD,telegram
+  - IP-CIDR,91.108.4.0/22,no-resolve
+  - PROCESS-NAME,Telegram.exe
+`,
+			domains: []RuleSetDomain{
+				{Type: "domain", Value: "google.com"},
+				{Type: "full", Value: "youtube.com"},
+				{Type: "keyword", Value: "telegram"},
+			},
+			cidrs:     []string{"91.108.4.0/22"},
+			processes: []string{"Telegram.exe"},
+		},
+		{
+			name: "sing-box json",
+			data: `{"version": 2, "rules": [
+				{"domain_suffix": [".discord.gg", "discord.com"], "ip_cidr": "162.159.128.0/19"},
+				{"process_name": ["Discord.exe"]},
+				{"domain": "skip.example.com", "invert": true}
+			]}`,
+			domains: []RuleSetDomain{
+				{Type: "domain", Value: "discord.gg"},
+				{Type: "domain", Value: "discord.com"},
+			},
+			cidrs:     []string{"162.159.128.0/19"},
+			processes: []string{"Discord.exe"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := ParseRuleSet([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("ParseRuleSet: %v", err)
+			}
+			if !slices.Equal(rs.Domains, tt.domains) {
+				t.Errorf("domains = %v, want %v", rs.Domains, tt.domains)
+			}
+			var cidrs []string
+			for _, p := range rs.CIDRs {
+				cidrs = append(cidrs, p.String())
+			}
+			if !slices.Equal(cidrs, tt.cidrs) {
+				t.Errorf("cidrs = %v, want %v", cidrs, tt.cidrs)
+			}
+			if !slices.Equal(rs.Processes, tt.processes) {
+				t.Errorf("processes = %v, want %v", rs.Processes, tt.processes)
+			}
+		})
+	}
+}
+
+func TestParseRuleSet_SRS(t *testing.T) {
+	var body bytes.Buffer
+	body.WriteByte(1) // rule count
+	body.WriteByte(0) // default rule
+	body.WriteByte(srsItemDomain)
+	if err := domain.NewMatcher([]string{"exact.example.com"}, []string{"example.org"}, false).Write(&body); err != nil {
+		t.Fatal(err)
+	}
+	body.WriteByte(srsItemIPCIDR)
+	body.WriteByte(1) // ip set version
+	binary.Write(&body, binary.BigEndian, uint64(1))
+	for _, ip := range []string{"10.0.0.0", "10.0.1.255"} {
+		b := netip.MustParseAddr(ip).As4()
+		body.WriteByte(4)
+		body.Write(b[:])
+	}
+	body.WriteByte(srsItemFinal)
+	body.WriteByte(0) // invert
+
+	var data bytes.Buffer
+	data.WriteString("SRS")
+	data.WriteByte(1)
+	zw := zlib.NewWriter(&data)
+	zw.Write(body.Bytes())
+	zw.Close()
+
+	rs, err := ParseRuleSet(data.Bytes(), core.RuleSetFormatAuto)
+	if err != nil {
+		t.Fatalf("ParseRuleSet: %v", err)
+	}
+	wantDomains := []RuleSetDomain{
+		{Type: "full", Value: "exact.example.com"},
+		{Type: "domain", Value: "example.org"},
+	}
+	if !slices.Equal(rs.Domains, wantDomains) {
+		t.Errorf("domains = %v, want %v", rs.Domains, wantDomains)
+	}
+	if len(rs.CIDRs) != 1 || rs.CIDRs[0] != netip.MustParsePrefix("10.0.0.0/23") {
+		t.Errorf("cidrs = %v, want [10.0.0.0/23]", rs.CIDRs)
+	}
+}
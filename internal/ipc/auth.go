@@ -0,0 +1,308 @@
+package ipc
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+)
+
+// Role is the access level granted to an IPC caller. Roles are ordered:
+// each one includes everything allowed to the roles below it.
+type Role int
+
+const (
+	RoleNone     Role = iota // no access
+	RoleMonitor              // read-only status, lists, stats and streams
+	RoleOperator             // monitor + connect/disconnect tunnels
+	RoleAdmin                // everything
+)
+
+func (r Role) String() string {
+	switch r {
+	case RoleMonitor:
+		return core.IPCRoleMonitor
+	case RoleOperator:
+		return core.IPCRoleOperator
+	case RoleAdmin:
+		return core.IPCRoleAdmin
+	default:
+		return "none"
+	}
+}
+
+// parseRole converts a config role name; empty or unknown names yield def.
+// Unknown names are rejected earlier by IPCAccessConfig.Validate.
+func parseRole(s string, def Role) Role {
+	switch s {
+	case core.IPCRoleMonitor:
+		return RoleMonitor
+	case core.IPCRoleOperator:
+		return RoleOperator
+	case core.IPCRoleAdmin:
+		return RoleAdmin
+	default:
+		return def
+	}
+}
+
+// methodRoles lists the RPCs open to non-admin callers. Anything missing
+// (including RPCs added later) requires admin.
+var methodRoles = map[string]Role{
+	vpnapi.VPNService_GetStatus_FullMethodName:                RoleMonitor,
+	vpnapi.VPNService_ListTunnels_FullMethodName:              RoleMonitor,
+	vpnapi.VPNService_GetTunnel_FullMethodName:                RoleMonitor,
+	vpnapi.VPNService_GetTunnelPeers_FullMethodName:           RoleMonitor,
+	vpnapi.VPNService_GetWGServerStatus_FullMethodName:        RoleMonitor,
+	vpnapi.VPNService_GetRateLimits_FullMethodName:            RoleMonitor,
+	vpnapi.VPNService_ListRules_FullMethodName:                RoleMonitor,
+	vpnapi.VPNService_ListDomainRules_FullMethodName:          RoleMonitor,
+	vpnapi.VPNService_ListGeositeCategories_FullMethodName:    RoleMonitor,
+	vpnapi.VPNService_ListGeoIPCategories_FullMethodName:      RoleMonitor,
+	vpnapi.VPNService_StreamLogs_FullMethodName:               RoleMonitor,
+	vpnapi.VPNService_StreamStats_FullMethodName:              RoleMonitor,
+	vpnapi.VPNService_StreamConnections_FullMethodName:        RoleMonitor,
+	vpnapi.VPNService_ListProcesses_FullMethodName:            RoleMonitor,
+	vpnapi.VPNService_GetAutostart_FullMethodName:             RoleMonitor,
+	vpnapi.VPNService_ListSubscriptions_FullMethodName:        RoleMonitor,
+	vpnapi.VPNService_StreamDNSQueries_FullMethodName:         RoleMonitor,
+	vpnapi.VPNService_GetDNSQueryStats_FullMethodName:         RoleMonitor,
+	vpnapi.VPNService_CheckUpdate_FullMethodName:              RoleMonitor,
+	vpnapi.VPNService_CheckConflictingServices_FullMethodName: RoleMonitor,
+
+	vpnapi.VPNService_Activate_FullMethodName:           RoleOperator,
+	vpnapi.VPNService_Deactivate_FullMethodName:         RoleOperator,
+	vpnapi.VPNService_Connect_FullMethodName:            RoleOperator,
+	vpnapi.VPNService_Disconnect_FullMethodName:         RoleOperator,
+	vpnapi.VPNService_RestartTunnel_FullMethodName:      RoleOperator,
+	vpnapi.VPNService_RestoreConnections_FullMethodName: RoleOperator,
+	vpnapi.VPNService_FlushDNS_FullMethodName:           RoleOperator,
+}
+
+// requiredRole returns the minimum role allowed to call fullMethod.
+func requiredRole(fullMethod string) Role {
+	if r, ok := methodRoles[fullMethod]; ok {
+		return r
+	}
+	return RoleAdmin
+}
+
+// Policy maps callers to roles. It is immutable once built; use
+// Authorizer.SetPolicy to swap in a new one after a config reload.
+type Policy struct {
+	defaultRole Role
+	users       map[string]Role
+	groups      map[string]Role
+}
+
+// NewPolicy builds a Policy from the ipc_access config section.
+func NewPolicy(cfg core.IPCAccessConfig) *Policy {
+	p := &Policy{
+		defaultRole: parseRole(cfg.DefaultRole, RoleMonitor),
+		users:       make(map[string]Role, len(cfg.Users)),
+		groups:      make(map[string]Role, len(builtinGroupRoles)+len(cfg.Groups)),
+	}
+	for name, role := range builtinGroupRoles {
+		p.groups[name] = role
+	}
+	for name, role := range cfg.Users {
+		p.users[strings.ToLower(name)] = parseRole(role, p.defaultRole)
+	}
+	for name, role := range cfg.Groups {
+		p.groups[strings.ToLower(name)] = parseRole(role, p.defaultRole)
+	}
+	return p
+}
+
+// RoleFor resolves the role of c. Privileged callers are always admin;
+// otherwise an explicit user entry wins, then the highest matching group,
+// then the default role.
+func (p *Policy) RoleFor(c Caller) Role {
+	if c.Privileged {
+		return RoleAdmin
+	}
+	for _, id := range []string{c.User, c.UID} {
+		if r, ok := p.users[strings.ToLower(id)]; ok && id != "" {
+			return r
+		}
+	}
+	best, matched := RoleNone, false
+	for _, ids := range [][]string{c.Groups, c.GroupNames} {
+		for _, id := range ids {
+			if r, ok := p.groups[strings.ToLower(id)]; ok {
+				best, matched = max(best, r), true
+			}
+		}
+	}
+	if matched {
+		return best
+	}
+	return p.defaultRole
+}
+
+// Caller identifies the process on the other end of an IPC connection,
+// or the pinned client certificate of a remote API connection.
+type Caller struct {
+	PID        int
+	UID        string   // numeric uid, Windows user SID or certificate fingerprint
+	User       string   // account name ("alice", `HOST\alice`) or remote client name
+	Groups     []string // numeric gids or Windows group SIDs
+	GroupNames []string // group names, where the platform resolves them
+	Privileged bool     // root, LocalSystem or elevated administrator
+	Addr       string   // remote address of a remote API caller
+}
+
+func (c Caller) String() string {
+	name := c.User
+	if name == "" {
+		name = c.UID
+	}
+	if c.Addr != "" {
+		return name + " (" + c.Addr + ")"
+	}
+	return name + " (uid=" + c.UID + ", pid=" + strconv.Itoa(c.PID) + ")"
+}
+
+// CallerFromContext returns the IPC caller attached to ctx by the peer
+// credentials handshake.
+func CallerFromContext(ctx context.Context) (Caller, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return Caller{}, false
+	}
+	info, ok := p.AuthInfo.(callerInfo)
+	if !ok {
+		return Caller{}, false
+	}
+	return info.caller, true
+}
+
+// Authorizer enforces per-RPC roles and audit-logs every call above
+// monitor level. It relies on PeerCredentials being installed on the
+// server so each connection carries its caller.
+type Authorizer struct {
+	policy atomic.Pointer[Policy]
+}
+
+// NewAuthorizer creates an Authorizer with the given initial policy.
+func NewAuthorizer(p *Policy) *Authorizer {
+	a := &Authorizer{}
+	a.policy.Store(p)
+	return a
+}
+
+// SetPolicy atomically replaces the policy; in-flight calls keep the old one.
+func (a *Authorizer) SetPolicy(p *Policy) {
+	a.policy.Store(p)
+}
+
+// ServerOptions returns the credentials and interceptors needed to enforce
+// the policy on a gRPC server.
+func (a *Authorizer) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.Creds(PeerCredentials()),
+		grpc.ChainUnaryInterceptor(a.UnaryInterceptor()),
+		grpc.ChainStreamInterceptor(a.StreamInterceptor()),
+	}
+}
+
+// authorize checks the caller in ctx against fullMethod and returns its role.
+func (a *Authorizer) authorize(ctx context.Context, fullMethod string) (Role, error) {
+	need := requiredRole(fullMethod)
+	c, ok := CallerFromContext(ctx)
+	if !ok {
+		core.Log.Warnf("IPC", "Denied %s: caller credentials unavailable", fullMethod)
+		return RoleNone, status.Error(codes.Unauthenticated, "caller credentials unavailable")
+	}
+	role := a.policy.Load().RoleFor(c)
+	if role < need {
+		core.Log.Warnf("IPC", "Denied %s for %s: role %s, requires %s", fullMethod, c, role, need)
+		return role, status.Errorf(codes.PermissionDenied, "%s requires the %s role (caller is %s)",
+			fullMethod[strings.LastIndexByte(fullMethod, '/')+1:], need, role)
+	}
+	if need > RoleMonitor {
+		core.Log.Infof("IPC", "Audit: %s by %s as %s", fullMethod, c, role)
+	}
+	return role, nil
+}
+
+// UnaryInterceptor returns a gRPC unary server interceptor enforcing the policy.
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req any,
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (any, error) {
+		role, err := a.authorize(ctx, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if role < RoleAdmin {
+			redactSecrets(resp)
+		}
+		return resp, err
+	}
+}
+
+// StreamInterceptor returns a gRPC stream server interceptor enforcing the policy.
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(
+		srv any,
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if _, err := a.authorize(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// redactSecrets strips tunnel settings (private keys, passwords) and
+// subscription URLs (access tokens) from read-only responses before they
+// reach a non-admin caller. Responses are built per call, so they are
+// modified in place.
+func redactSecrets(resp any) {
+	switch r := resp.(type) {
+	case *vpnapi.TunnelListResponse:
+		for _, t := range r.GetTunnels() {
+			redactTunnel(t)
+		}
+	case *vpnapi.TunnelStatus:
+		redactTunnel(r)
+	case *vpnapi.SubscriptionListResponse:
+		for _, s := range r.GetSubscriptions() {
+			if s.GetConfig() != nil {
+				s.Config.Url = redactURL(s.Config.Url)
+			}
+		}
+	}
+}
+
+// redactURL keeps only the scheme and host of a URL: subscription tokens
+// may sit in the user info, the path or the query.
+func redactURL(s string) string {
+	u, err := url.Parse(s)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: u.Scheme, Host: u.Host}).String()
+}
+
+func redactTunnel(t *vpnapi.TunnelStatus) {
+	if t.GetConfig() != nil {
+		t.Config.Settings = nil
+	}
+}
@@ -25,6 +25,7 @@ type ConnTracker struct {
 // NewConnTracker creates a ConnTracker with the given grace period.
 // onIdle is called (in a separate goroutine) when all clients have
 // disconnected and the grace period has elapsed without reconnection.
+// With a nil onIdle the tracker only counts active RPCs.
 func NewConnTracker(gracePeriod time.Duration, onIdle func()) *ConnTracker {
 	return &ConnTracker{
 		gracePeriod: gracePeriod,
@@ -64,7 +65,7 @@ func (ct *ConnTracker) inc() {
 
 func (ct *ConnTracker) dec() {
 	n := ct.active.Add(-1)
-	if n == 0 {
+	if n == 0 && ct.onIdle != nil {
 		// All clients gone — start grace timer.
 		ct.mu.Lock()
 		if ct.graceTimer != nil {
@@ -75,9 +76,7 @@ func (ct *ConnTracker) dec() {
 			ct.mu.Lock()
 			ct.graceTimer = nil
 			ct.mu.Unlock()
-			if ct.onIdle != nil {
-				ct.onIdle()
-			}
+			ct.onIdle()
 		})
 		ct.mu.Unlock()
 	}
@@ -0,0 +1,36 @@
+//go:build darwin
+
+package ipc
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// builtinGroupRoles grants roles by group before ipc_access is applied.
+// Members of the macOS "admin" group can already administer the machine,
+// and the GUI runs as the console user, so they keep full access.
+var builtinGroupRoles = map[string]Role{"admin": RoleAdmin}
+
+// peerCaller identifies the process behind a Unix socket via
+// LOCAL_PEERCRED (uid and groups) and LOCAL_PEERPID.
+func peerCaller(conn net.Conn) (Caller, error) {
+	var (
+		cred *unix.Xucred
+		pid  int
+	)
+	err := peerFD(conn, func(fd int) (err error) {
+		cred, err = unix.GetsockoptXucred(fd, unix.SOL_LOCAL, unix.LOCAL_PEERCRED)
+		if err != nil {
+			return err
+		}
+		pid, _ = unix.GetsockoptInt(fd, unix.SOL_LOCAL, unix.LOCAL_PEERPID)
+		return nil
+	})
+	if err != nil {
+		return Caller{}, err
+	}
+	n := min(int(cred.Ngroups), len(cred.Groups))
+	return unixCaller(pid, cred.Uid, cred.Groups[:n]), nil
+}
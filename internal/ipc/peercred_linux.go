@@ -0,0 +1,39 @@
+//go:build linux
+
+package ipc
+
+import (
+	"net"
+	"os/user"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// builtinGroupRoles grants roles by group before ipc_access is applied.
+var builtinGroupRoles map[string]Role
+
+// peerCaller identifies the process behind a Unix socket via SO_PEERCRED.
+// The kernel reports only the primary gid, so supplementary groups come
+// from the user database.
+func peerCaller(conn net.Conn) (Caller, error) {
+	var cred *unix.Ucred
+	err := peerFD(conn, func(fd int) (err error) {
+		cred, err = unix.GetsockoptUcred(fd, unix.SOL_SOCKET, unix.SO_PEERCRED)
+		return err
+	})
+	if err != nil {
+		return Caller{}, err
+	}
+	gids := []uint32{cred.Gid}
+	if u, err := user.LookupId(strconv.FormatUint(uint64(cred.Uid), 10)); err == nil {
+		if ids, err := u.GroupIds(); err == nil {
+			for _, id := range ids {
+				if gid, err := strconv.ParseUint(id, 10, 32); err == nil {
+					gids = append(gids, uint32(gid))
+				}
+			}
+		}
+	}
+	return unixCaller(int(cred.Pid), cred.Uid, gids), nil
+}
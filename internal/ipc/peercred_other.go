@@ -0,0 +1,14 @@
+//go:build !linux && !darwin && !windows
+
+package ipc
+
+import (
+	"errors"
+	"net"
+)
+
+var builtinGroupRoles map[string]Role
+
+func peerCaller(net.Conn) (Caller, error) {
+	return Caller{}, errors.New("not supported on this platform")
+}
@@ -0,0 +1,54 @@
+//go:build linux || darwin
+
+package ipc
+
+import (
+	"errors"
+	"net"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// peerFD runs fn with the file descriptor of a Unix socket connection.
+func peerFD(conn net.Conn, fn func(fd int) error) error {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return errors.New("connection has no file descriptor")
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var opErr error
+	if err := raw.Control(func(fd uintptr) { opErr = fn(int(fd)) }); err != nil {
+		return err
+	}
+	return opErr
+}
+
+// unixCaller builds a Caller from kernel-reported ids, resolving names
+// through the user database where possible.
+func unixCaller(pid int, uid uint32, gids []uint32) Caller {
+	c := Caller{
+		PID:        pid,
+		UID:        strconv.FormatUint(uint64(uid), 10),
+		Privileged: uid == 0,
+	}
+	if u, err := user.LookupId(c.UID); err == nil {
+		c.User = u.Username
+	}
+	seen := make(map[uint32]bool, len(gids))
+	for _, gid := range gids {
+		if seen[gid] {
+			continue
+		}
+		seen[gid] = true
+		id := strconv.FormatUint(uint64(gid), 10)
+		c.Groups = append(c.Groups, id)
+		if g, err := user.LookupGroupId(id); err == nil {
+			c.GroupNames = append(c.GroupNames, g.Name)
+		}
+	}
+	return c
+}
@@ -0,0 +1,59 @@
+//go:build windows
+
+package ipc
+
+import (
+	"errors"
+	"net"
+
+	"golang.org/x/sys/windows"
+)
+
+// builtinGroupRoles grants roles by group before ipc_access is applied.
+var builtinGroupRoles map[string]Role
+
+// peerCaller identifies the process behind a named pipe connection from
+// its client process token: user SID, enabled group SIDs and elevation.
+func peerCaller(conn net.Conn) (Caller, error) {
+	f, ok := conn.(interface{ Fd() uintptr })
+	if !ok {
+		return Caller{}, errors.New("connection is not a named pipe")
+	}
+	var pid uint32
+	if err := windows.GetNamedPipeClientProcessId(windows.Handle(f.Fd()), &pid); err != nil {
+		return Caller{}, err
+	}
+	proc, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, pid)
+	if err != nil {
+		return Caller{}, err
+	}
+	defer windows.CloseHandle(proc)
+
+	var token windows.Token
+	if err := windows.OpenProcessToken(proc, windows.TOKEN_QUERY, &token); err != nil {
+		return Caller{}, err
+	}
+	defer token.Close()
+
+	tu, err := token.GetTokenUser()
+	if err != nil {
+		return Caller{}, err
+	}
+	sid := tu.User.Sid
+	c := Caller{
+		PID:        int(pid),
+		UID:        sid.String(),
+		Privileged: sid.IsWellKnown(windows.WinLocalSystemSid) || token.IsElevated(),
+	}
+	if account, domain, _, err := sid.LookupAccount(""); err == nil {
+		c.User = domain + `\` + account
+	}
+	if groups, err := token.GetTokenGroups(); err == nil {
+		for _, g := range groups.AllGroups() {
+			if g.Attributes&windows.SE_GROUP_ENABLED != 0 {
+				c.Groups = append(c.Groups, g.Sid.String())
+			}
+		}
+	}
+	return c, nil
+}
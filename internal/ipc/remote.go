@@ -0,0 +1,354 @@
+package ipc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	defaultRemoteListen = ":7443"
+	defaultRemoteCert   = "remote_api.crt"
+	defaultRemoteKey    = "remote_api.key"
+)
+
+// RemoteServer exposes the VPN service over TCP for remote management:
+// gRPC with mutual TLS and, optionally, a REST/JSON facade on a second
+// port. Clients are pinned by certificate fingerprint and mapped to roles
+// through the same Authorizer as local IPC; calls are counted by a
+// ConnTracker.
+type RemoteServer struct {
+	svc     vpnapi.VPNServiceServer
+	baseDir string // relative cert/key paths are resolved against it
+
+	authz   *Authorizer
+	tracker *ConnTracker
+	pins    atomic.Pointer[map[string]string] // fingerprint → client name
+
+	mu     sync.Mutex
+	cfg    core.RemoteAPIConfig
+	server *Server
+	rest   *http.Server
+}
+
+// NewRemoteServer creates a stopped remote API server; call Configure to
+// start it.
+func NewRemoteServer(svc vpnapi.VPNServiceServer, baseDir string) *RemoteServer {
+	s := &RemoteServer{
+		svc:     svc,
+		baseDir: baseDir,
+		authz:   NewAuthorizer(remotePolicy(nil)),
+		tracker: NewConnTracker(0, nil),
+	}
+	s.pins.Store(&map[string]string{})
+	return s
+}
+
+// Configure applies cfg: starts, stops or restarts the listeners as needed.
+// Changes that only touch the pinned clients take effect without a restart.
+func (s *RemoteServer) Configure(cfg core.RemoteAPIConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pins := make(map[string]string, len(cfg.Clients))
+	for _, c := range cfg.Clients {
+		pins[c.FingerprintHex()] = c.Name
+	}
+	s.pins.Store(&pins)
+	s.authz.SetPolicy(remotePolicy(cfg.Clients))
+
+	if !cfg.Enabled {
+		s.stopLocked()
+		s.cfg = cfg
+		return nil
+	}
+	if s.server != nil && sameRemoteListeners(s.cfg, cfg) {
+		s.cfg = cfg
+		core.Log.Infof("RemoteAPI", "Clients updated (%d pinned)", len(pins))
+		return nil
+	}
+	s.stopLocked()
+	s.cfg = cfg
+	return s.startLocked(cfg)
+}
+
+// Stop shuts down both listeners.
+func (s *RemoteServer) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+}
+
+// ActiveCalls returns the number of remote RPCs in progress.
+func (s *RemoteServer) ActiveCalls() int64 {
+	return s.tracker.ActiveCount()
+}
+
+func sameRemoteListeners(a, b core.RemoteAPIConfig) bool {
+	return a.Listen == b.Listen && a.RESTListen == b.RESTListen &&
+		a.CertFile == b.CertFile && a.KeyFile == b.KeyFile
+}
+
+// remotePolicy maps pinned certificate fingerprints to roles. Anything
+// else gets no access.
+func remotePolicy(clients []core.RemoteClient) *Policy {
+	p := &Policy{
+		defaultRole: RoleNone,
+		users:       make(map[string]Role, len(clients)),
+		groups:      map[string]Role{},
+	}
+	for _, c := range clients {
+		p.users[c.FingerprintHex()] = parseRole(c.Role, RoleMonitor)
+	}
+	return p
+}
+
+func (s *RemoteServer) startLocked(cfg core.RemoteAPIConfig) error {
+	cert, err := loadOrCreateCert(s.path(cfg.CertFile, defaultRemoteCert), s.path(cfg.KeyFile, defaultRemoteKey))
+	if err != nil {
+		return fmt.Errorf("[RemoteAPI] server certificate: %w", err)
+	}
+	tlsConf := &tls.Config{
+		Certificates:          []tls.Certificate{cert},
+		ClientAuth:            tls.RequireAnyClientCert,
+		MinVersion:            tls.VersionTLS13,
+		VerifyPeerCertificate: s.verifyClient,
+	}
+
+	addr := cfg.Listen
+	if addr == "" {
+		addr = defaultRemoteListen
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("[RemoteAPI] listen %s: %w", addr, err)
+	}
+	var rln net.Listener
+	if cfg.RESTListen != "" {
+		if rln, err = net.Listen("tcp", cfg.RESTListen); err != nil {
+			ln.Close()
+			return fmt.Errorf("[RemoteAPI] listen %s: %w", cfg.RESTListen, err)
+		}
+	}
+
+	unary := []grpc.UnaryServerInterceptor{s.authz.UnaryInterceptor(), s.tracker.UnaryInterceptor()}
+	stream := []grpc.StreamServerInterceptor{s.authz.StreamInterceptor(), s.tracker.StreamInterceptor()}
+	srv := NewServer(s.svc,
+		grpc.Creds(remoteCreds{TransportCredentials: credentials.NewTLS(tlsConf), s: s}),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	)
+	s.server = srv
+	core.SafeGo("remoteapi.grpc", func() {
+		if err := srv.Start(ln); err != nil {
+			core.Log.Warnf("RemoteAPI", "gRPC server error: %v", err)
+		}
+	})
+	core.Log.Infof("RemoteAPI", "gRPC listening on %s (%d pinned clients)", ln.Addr(), len(*s.pins.Load()))
+
+	if rln != nil {
+		hs := &http.Server{
+			Handler: newRESTHandler(s.svc, chainUnary(unary...), chainStream(stream...), func(r *http.Request) Caller {
+				return s.caller(*r.TLS, r.RemoteAddr)
+			}),
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+		s.rest = hs
+		core.SafeGo("remoteapi.rest", func() {
+			if err := hs.Serve(tls.NewListener(rln, tlsConf.Clone())); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				core.Log.Warnf("RemoteAPI", "REST server error: %v", err)
+			}
+		})
+		core.Log.Infof("RemoteAPI", "REST listening on %s", rln.Addr())
+	}
+	return nil
+}
+
+func (s *RemoteServer) stopLocked() {
+	if s.server == nil {
+		return
+	}
+	if n := s.tracker.ActiveCount(); n > 0 {
+		core.Log.Infof("RemoteAPI", "Stopping with %d active calls", n)
+	}
+	if s.rest != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		if err := s.rest.Shutdown(ctx); err != nil {
+			s.rest.Close()
+		}
+		cancel()
+		s.rest = nil
+	}
+	s.server.Stop()
+	s.server = nil
+	core.Log.Infof("RemoteAPI", "Stopped")
+}
+
+func (s *RemoteServer) path(p, def string) string {
+	if p == "" {
+		p = def
+	}
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.baseDir, p)
+}
+
+// verifyClient accepts only pinned client certificates. Chains are not
+// verified: the pin is the trust anchor.
+func (s *RemoteServer) verifyClient(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("client certificate required")
+	}
+	fp := certFingerprint(rawCerts[0])
+	if _, ok := (*s.pins.Load())[fp]; !ok {
+		core.Log.Warnf("RemoteAPI", "Rejected unpinned client certificate %s", fp)
+		return errors.New("client certificate not pinned")
+	}
+	return nil
+}
+
+// caller identifies a remote client by its (already verified) certificate.
+func (s *RemoteServer) caller(state tls.ConnectionState, addr string) Caller {
+	c := Caller{Addr: addr}
+	if len(state.PeerCertificates) > 0 {
+		c.UID = certFingerprint(state.PeerCertificates[0].Raw)
+		c.User = (*s.pins.Load())[c.UID]
+	}
+	return c
+}
+
+// remoteCreds runs the TLS handshake and attaches the pinned client as
+// the connection's Caller.
+type remoteCreds struct {
+	credentials.TransportCredentials
+	s *RemoteServer
+}
+
+func (c remoteCreds) ServerHandshake(raw net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ServerHandshake(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	tlsInfo := info.(credentials.TLSInfo)
+	return conn, callerInfo{
+		CommonAuthInfo: tlsInfo.CommonAuthInfo,
+		caller:         c.s.caller(tlsInfo.State, raw.RemoteAddr().String()),
+	}, nil
+}
+
+func (c remoteCreds) Clone() credentials.TransportCredentials {
+	return remoteCreds{TransportCredentials: c.TransportCredentials.Clone(), s: c.s}
+}
+
+// chainUnary composes interceptors in order, like grpc.ChainUnaryInterceptor.
+func chainUnary(ints ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		for i := len(ints) - 1; i >= 0; i-- {
+			next, icpt := handler, ints[i]
+			handler = func(ctx context.Context, req any) (any, error) {
+				return icpt(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// chainStream composes interceptors in order, like grpc.ChainStreamInterceptor.
+func chainStream(ints ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(ints) - 1; i >= 0; i-- {
+			next, icpt := handler, ints[i]
+			handler = func(srv any, ss grpc.ServerStream) error {
+				return icpt(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+func certFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadOrCreateCert loads the server key pair, generating a self-signed
+// ECDSA certificate when neither file exists yet.
+func loadOrCreateCert(certFile, keyFile string) (tls.Certificate, error) {
+	_, certErr := os.Stat(certFile)
+	_, keyErr := os.Stat(keyFile)
+	if os.IsNotExist(certErr) && os.IsNotExist(keyErr) {
+		if err := writeSelfSigned(certFile, keyFile); err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	core.Log.Infof("RemoteAPI", "Server certificate SHA-256: %s", certFingerprint(cert.Certificate[0]))
+	return cert, nil
+}
+
+func writeSelfSigned(certFile, keyFile string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return err
+	}
+	names := []string{"localhost"}
+	if host, err := os.Hostname(); err == nil && host != "" {
+		names = append(names, host)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "awg-split-tunnel"},
+		DNSNames:     names,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		return err
+	}
+	core.Log.Infof("RemoteAPI", "Generated self-signed server certificate %s", certFile)
+	return nil
+}
@@ -0,0 +1,126 @@
+package ipc
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+)
+
+type statusOnlyService struct {
+	vpnapi.UnimplementedVPNServiceServer
+}
+
+func (statusOnlyService) GetStatus(context.Context, *emptypb.Empty) (*vpnapi.ServiceStatus, error) {
+	return &vpnapi.ServiceStatus{Running: true, Version: "test"}, nil
+}
+
+func testClientCert(t *testing.T, dir, name string) (tls.Certificate, string) {
+	t.Helper()
+	certFile, keyFile := filepath.Join(dir, name+".crt"), filepath.Join(dir, name+".key")
+	if err := writeSelfSigned(certFile, keyFile); err != nil {
+		t.Fatal(err)
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, certFingerprint(cert.Certificate[0])
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestRemoteServer(t *testing.T) {
+	dir := t.TempDir()
+	monitor, monitorFP := testClientCert(t, dir, "monitor")
+	stranger, _ := testClientCert(t, dir, "stranger")
+
+	grpcAddr, restAddr := freeAddr(t), freeAddr(t)
+	s := NewRemoteServer(statusOnlyService{}, dir)
+	err := s.Configure(core.RemoteAPIConfig{
+		Enabled:    true,
+		Listen:     grpcAddr,
+		RESTListen: restAddr,
+		Clients:    []core.RemoteClient{{Name: "dashboard", Fingerprint: monitorFP}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	dial := func(cert tls.Certificate) vpnapi.VPNServiceClient {
+		creds := credentials.NewTLS(&tls.Config{Certificates: []tls.Certificate{cert}, InsecureSkipVerify: true})
+		conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(creds))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return vpnapi.NewVPNServiceClient(conn)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mon := dial(monitor)
+	if st, err := mon.GetStatus(ctx, &emptypb.Empty{}); err != nil || st.Version != "test" {
+		t.Fatalf("monitor GetStatus = %v, %v", st, err)
+	}
+	if _, err := mon.Shutdown(ctx, &emptypb.Empty{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("monitor Shutdown: got %v, want PermissionDenied", err)
+	}
+	if _, err := dial(stranger).GetStatus(ctx, &emptypb.Empty{}); err == nil {
+		t.Fatal("unpinned client was accepted")
+	}
+
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		Certificates:       []tls.Certificate{monitor},
+		InsecureSkipVerify: true,
+	}}}
+	defer hc.CloseIdleConnections()
+	resp, err := hc.Get("https://" + restAddr + "/v1/GetStatus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), `"version":"test"`) {
+		t.Fatalf("REST GetStatus = %d %s", resp.StatusCode, body)
+	}
+	resp, err = hc.Post("https://"+restAddr+"/v1/Shutdown", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("REST Shutdown status = %d, want 403", resp.StatusCode)
+	}
+
+	// Dropping the pin revokes access without a restart.
+	if err := s.Configure(core.RemoteAPIConfig{Enabled: true, Listen: grpcAddr, RESTListen: restAddr}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mon.GetStatus(ctx, &emptypb.Empty{}); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("revoked GetStatus: got %v, want PermissionDenied", err)
+	}
+}
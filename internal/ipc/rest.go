@@ -0,0 +1,208 @@
+package ipc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	vpnapi "awg-split-tunnel/api/gen"
+)
+
+// restHandler serves VPNService as JSON over HTTP in the style of
+// grpc-gateway: POST /v1/<Method> with the request message as a JSON body
+// (GET or an empty body for requests without fields). Server-streaming
+// methods reply with newline-delimited JSON, one message per line.
+//
+// Requests go through the generated method handlers with the same
+// interceptors as the gRPC listener, so roles, audit logging and
+// redaction apply unchanged.
+type restHandler struct {
+	svc      vpnapi.VPNServiceServer
+	unary    map[string]grpc.MethodDesc
+	streams  map[string]grpc.StreamDesc
+	unaryIC  grpc.UnaryServerInterceptor
+	streamIC grpc.StreamServerInterceptor
+	caller   func(*http.Request) Caller
+}
+
+func newRESTHandler(svc vpnapi.VPNServiceServer, unaryIC grpc.UnaryServerInterceptor, streamIC grpc.StreamServerInterceptor, caller func(*http.Request) Caller) *restHandler {
+	desc := vpnapi.VPNService_ServiceDesc
+	h := &restHandler{
+		svc:      svc,
+		unary:    make(map[string]grpc.MethodDesc, len(desc.Methods)),
+		streams:  make(map[string]grpc.StreamDesc, len(desc.Streams)),
+		unaryIC:  unaryIC,
+		streamIC: streamIC,
+		caller:   caller,
+	}
+	for _, m := range desc.Methods {
+		h.unary[m.MethodName] = m
+	}
+	for _, s := range desc.Streams {
+		if s.ServerStreams && !s.ClientStreams {
+			h.streams[s.StreamName] = s
+		}
+	}
+	return h
+}
+
+var restJSON = protojson.MarshalOptions{EmitUnpopulated: true}
+
+func (h *restHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutPrefix(r.URL.Path, "/v1/")
+	if !ok {
+		writeRESTError(w, status.Error(codes.NotFound, "unknown path"))
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		w.Header().Set("Allow", "GET, POST")
+		writeRESTError(w, status.Error(codes.Unimplemented, "method not allowed"))
+		return
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxMsgSize))
+	if err != nil {
+		writeRESTError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	dec := func(m any) error {
+		if len(bytes.TrimSpace(body)) == 0 {
+			return nil
+		}
+		if err := protojson.Unmarshal(body, m.(proto.Message)); err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil
+	}
+	ctx := peer.NewContext(r.Context(), &peer.Peer{AuthInfo: callerInfo{caller: h.caller(r)}})
+
+	if md, ok := h.unary[name]; ok {
+		resp, err := md.Handler(h.svc, ctx, dec, h.unaryIC)
+		if err != nil {
+			writeRESTError(w, err)
+			return
+		}
+		out, err := restJSON.Marshal(resp.(proto.Message))
+		if err != nil {
+			writeRESTError(w, status.Error(codes.Internal, err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(out)
+		return
+	}
+	if sd, ok := h.streams[name]; ok {
+		ss := &restStream{ctx: ctx, w: w, dec: dec}
+		info := &grpc.StreamServerInfo{
+			FullMethod:     "/" + vpnapi.VPNService_ServiceDesc.ServiceName + "/" + name,
+			IsServerStream: true,
+		}
+		if err := h.streamIC(h.svc, ss, info, sd.Handler); err != nil {
+			if !ss.started {
+				writeRESTError(w, err)
+				return
+			}
+			// Headers are gone; report the error as the final line.
+			st := status.Convert(err)
+			line, _ := json.Marshal(map[string]any{"error": restError{Code: int(st.Code()), Message: st.Message()}})
+			w.Write(append(line, '\n'))
+		}
+		return
+	}
+	writeRESTError(w, status.Errorf(codes.NotFound, "unknown method %q", name))
+}
+
+// restStream adapts an HTTP response to grpc.ServerStream for
+// server-streaming handlers.
+type restStream struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	dec     func(any) error
+	recvd   bool
+	started bool
+}
+
+func (s *restStream) Context() context.Context     { return s.ctx }
+func (s *restStream) SetHeader(metadata.MD) error  { return nil }
+func (s *restStream) SendHeader(metadata.MD) error { return nil }
+func (s *restStream) SetTrailer(metadata.MD)       {}
+
+func (s *restStream) RecvMsg(m any) error {
+	if s.recvd {
+		return io.EOF
+	}
+	s.recvd = true
+	return s.dec(m)
+}
+
+func (s *restStream) SendMsg(m any) error {
+	out, err := restJSON.Marshal(m.(proto.Message))
+	if err != nil {
+		return err
+	}
+	if !s.started {
+		s.w.Header().Set("Content-Type", "application/x-ndjson")
+		s.started = true
+	}
+	if _, err := s.w.Write(append(out, '\n')); err != nil {
+		return err
+	}
+	if f, ok := s.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// restError is the JSON error body, matching grpc-gateway's shape.
+type restError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func writeRESTError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	body, _ := json.Marshal(restError{Code: int(st.Code()), Message: st.Message()})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatusFromCode(st.Code()))
+	w.Write(body)
+}
+
+// httpStatusFromCode maps gRPC codes to HTTP statuses as grpc-gateway does.
+func httpStatusFromCode(c codes.Code) int {
+	switch c {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -215,6 +215,27 @@ func (rm *RouteManager) AddBypassRoute(dst netip.Addr) error {
 	return nil
 }
 
+// RemoveBypassRoute removes the host route for dst added by AddBypassRoute.
+// Used when a VPN server endpoint moves to a new IP.
+func (rm *RouteManager) RemoveBypassRoute(dst netip.Addr) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	host := dst.String()
+	for i, delArgs := range rm.bypassRoutes {
+		if delArgs[len(delArgs)-1] != host {
+			continue
+		}
+		rm.bypassRoutes = append(rm.bypassRoutes[:i], rm.bypassRoutes[i+1:]...)
+		if err := routeExec(delArgs, false); err != nil {
+			return fmt.Errorf("[Route] remove bypass %s: %w", dst, err)
+		}
+		core.Log.Infof("Route", "Removed bypass route: %s", dst)
+		return nil
+	}
+	return nil
+}
+
 // ClearBypassRoutes removes all bypass routes. Used before re-adding them
 // after a network change (new gateway).
 func (rm *RouteManager) ClearBypassRoutes() {
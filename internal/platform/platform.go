@@ -1,7 +1,15 @@
 package platform
 
+import (
+	"net"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+)
+
 // Platform aggregates all platform-specific implementations.
-// Populated by platform-specific factory (NewPlatform) in platform/windows/ or platform/darwin/.
+// Populated by platform-specific factory (NewPlatform) in platform/windows/,
+// platform/darwin/ or platform/sim/.
 type Platform struct {
 	NewTUNAdapter      func() (TUNAdapter, error)
 	NewProcessFilter   func(tunLUID uint64) (ProcessFilter, error)
@@ -28,4 +36,19 @@ type Platform struct {
 	// executables so that hairpin NAT packets on the TUN adapter are not
 	// blocked by the host firewall. May be nil on platforms that don't need it.
 	EnsureFirewallRules func()
+
+	// QueryProcessPath resolves a PID to its executable path. nil uses the
+	// OS lookup built into the process matcher.
+	QueryProcessPath func(pid uint32) (string, error)
+
+	// ListenTCP and ListenUDP open the local proxy and DNS listeners that
+	// receive hairpinned traffic from the TUN adapter. nil uses the host
+	// network stack; the simulated platform serves them from its own
+	// in-memory stack behind the TUN adapter.
+	ListenTCP func(addr string) (net.Listener, error)
+	ListenUDP func(addr string) (net.PacketConn, error)
+
+	// NewTunnelProvider replaces the protocol providers for every tunnel,
+	// including direct. nil uses the real providers.
+	NewTunnelProvider func(cfg core.TunnelConfig) (provider.TunnelProvider, error)
 }
@@ -0,0 +1,26 @@
+package sim
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultSocketPath is where the simulated service listens for IPC clients.
+// It lives in the temp directory so the simulation runs unprivileged.
+var DefaultSocketPath = filepath.Join(os.TempDir(), "awg-split-tunnel-sim.sock")
+
+// ipcTransport implements platform.IPCTransport over a Unix domain socket.
+type ipcTransport struct {
+	path string
+}
+
+func (t ipcTransport) Listener() (net.Listener, error) {
+	os.Remove(t.path)
+	return net.Listen("unix", t.path)
+}
+
+func (t ipcTransport) Dial(timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", t.path, timeout)
+}
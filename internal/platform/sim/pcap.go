@@ -0,0 +1,168 @@
+package sim
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// pcap link types understood by the replay reader.
+const (
+	linkTypeNull     = 0   // BSD loopback: 4-byte host-order address family
+	linkTypeEthernet = 1   // Ethernet II, optionally 802.1Q tagged
+	linkTypeRaw      = 101 // raw IP
+	linkTypeLoop     = 108 // OpenBSD loopback: 4-byte big-endian address family
+	linkTypeLinuxSLL = 113 // Linux "any" cooked capture
+	linkTypeIPv4     = 228 // raw IPv4
+)
+
+const (
+	pcapMagicMicro = 0xa1b2c3d4
+	pcapMagicNano  = 0xa1b23c4d
+	pcapMaxPacket  = 262144
+)
+
+// pcapPacket is one captured packet reduced to its IPv4 payload.
+type pcapPacket struct {
+	ts   time.Time
+	data []byte
+}
+
+// pcapReader reads classic libpcap files (not pcapng) in either byte order
+// and with microsecond or nanosecond timestamps.
+type pcapReader struct {
+	r        io.Reader
+	order    binary.ByteOrder
+	nano     bool
+	linkType uint32
+}
+
+func newPCAPReader(r io.Reader) (*pcapReader, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("pcap header: %w", err)
+	}
+	pr := &pcapReader{r: r}
+	switch {
+	case binary.LittleEndian.Uint32(hdr[0:]) == pcapMagicMicro:
+		pr.order = binary.LittleEndian
+	case binary.BigEndian.Uint32(hdr[0:]) == pcapMagicMicro:
+		pr.order = binary.BigEndian
+	case binary.LittleEndian.Uint32(hdr[0:]) == pcapMagicNano:
+		pr.order, pr.nano = binary.LittleEndian, true
+	case binary.BigEndian.Uint32(hdr[0:]) == pcapMagicNano:
+		pr.order, pr.nano = binary.BigEndian, true
+	default:
+		return nil, errors.New("not a pcap file (pcapng is not supported)")
+	}
+	pr.linkType = pr.order.Uint32(hdr[20:]) & 0x0fffffff
+	switch pr.linkType {
+	case linkTypeNull, linkTypeEthernet, linkTypeRaw, linkTypeLoop, linkTypeLinuxSLL, linkTypeIPv4:
+	default:
+		return nil, fmt.Errorf("unsupported pcap link type %d", pr.linkType)
+	}
+	return pr, nil
+}
+
+// next returns the next IPv4 packet, skipping frames that carry anything
+// else. It returns io.EOF at the end of the file.
+func (pr *pcapReader) next() (pcapPacket, error) {
+	for {
+		var rec [16]byte
+		if _, err := io.ReadFull(pr.r, rec[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return pcapPacket{}, err
+		}
+		sec, frac := pr.order.Uint32(rec[0:]), pr.order.Uint32(rec[4:])
+		incl := pr.order.Uint32(rec[8:])
+		if incl > pcapMaxPacket {
+			return pcapPacket{}, fmt.Errorf("pcap record of %d bytes", incl)
+		}
+		frame := make([]byte, incl)
+		if _, err := io.ReadFull(pr.r, frame); err != nil {
+			return pcapPacket{}, fmt.Errorf("pcap record: %w", err)
+		}
+		if !pr.nano {
+			frac *= 1000
+		}
+		if pkt := pr.ipv4Payload(frame); pkt != nil {
+			return pcapPacket{ts: time.Unix(int64(sec), int64(frac)), data: pkt}, nil
+		}
+	}
+}
+
+// ipv4Payload strips the link-layer header, returning nil for non-IPv4 frames.
+func (pr *pcapReader) ipv4Payload(frame []byte) []byte {
+	var pkt []byte
+	switch pr.linkType {
+	case linkTypeRaw, linkTypeIPv4:
+		pkt = frame
+	case linkTypeNull, linkTypeLoop:
+		if len(frame) < 4 {
+			return nil
+		}
+		// AF_INET is 2 everywhere; the header is host-order for NULL.
+		if frame[0] != 2 && frame[3] != 2 {
+			return nil
+		}
+		pkt = frame[4:]
+	case linkTypeEthernet:
+		if len(frame) < 14 {
+			return nil
+		}
+		etherType, off := binary.BigEndian.Uint16(frame[12:]), 14
+		for etherType == 0x8100 && len(frame) >= off+4 {
+			etherType, off = binary.BigEndian.Uint16(frame[off+2:]), off+4
+		}
+		if etherType != 0x0800 {
+			return nil
+		}
+		pkt = frame[off:]
+	case linkTypeLinuxSLL:
+		if len(frame) < 16 || binary.BigEndian.Uint16(frame[14:]) != 0x0800 {
+			return nil
+		}
+		pkt = frame[16:]
+	}
+	if len(pkt) < 20 || pkt[0]>>4 != 4 {
+		return nil
+	}
+	return pkt
+}
+
+// pcapWriter records packets crossing the TUN adapter as raw IP.
+type pcapWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func newPCAPWriter(w io.Writer) (*pcapWriter, error) {
+	var hdr [24]byte
+	binary.LittleEndian.PutUint32(hdr[0:], pcapMagicMicro)
+	binary.LittleEndian.PutUint16(hdr[4:], 2)
+	binary.LittleEndian.PutUint16(hdr[6:], 4)
+	binary.LittleEndian.PutUint32(hdr[16:], 65535)
+	binary.LittleEndian.PutUint32(hdr[20:], linkTypeRaw)
+	if _, err := w.Write(hdr[:]); err != nil {
+		return nil, err
+	}
+	return &pcapWriter{w: w}, nil
+}
+
+func (pw *pcapWriter) write(pkt []byte) {
+	now := time.Now()
+	var rec [16]byte
+	binary.LittleEndian.PutUint32(rec[0:], uint32(now.Unix()))
+	binary.LittleEndian.PutUint32(rec[4:], uint32(now.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(rec[8:], uint32(len(pkt)))
+	binary.LittleEndian.PutUint32(rec[12:], uint32(len(pkt)))
+	pw.mu.Lock()
+	pw.w.Write(rec[:])
+	pw.w.Write(pkt)
+	pw.mu.Unlock()
+}
@@ -0,0 +1,92 @@
+package sim
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// firstPID is the first synthetic PID. It lies above Linux's pid_max so
+// simulated processes never collide with the service's own PID.
+const firstPID = 1 << 23
+
+// portKey identifies a local socket by transport and port.
+type portKey struct {
+	port  uint16
+	isUDP bool
+}
+
+// ProcessTable is a scriptable platform.ProcessIdentifier: it maps local
+// ports to fake executables. Each distinct executable path gets a stable
+// synthetic PID that QueryPath resolves back to the path.
+type ProcessTable struct {
+	mu    sync.RWMutex
+	ports map[portKey]uint32
+	pids  map[uint32]string
+	byExe map[string]uint32
+	next  uint32
+}
+
+// NewProcessTable creates an empty table.
+func NewProcessTable() *ProcessTable {
+	return &ProcessTable{
+		ports: make(map[portKey]uint32),
+		pids:  make(map[uint32]string),
+		byExe: make(map[string]uint32),
+		next:  firstPID,
+	}
+}
+
+// PID returns the synthetic PID for exePath, allocating one on first use.
+func (pt *ProcessTable) PID(exePath string) uint32 {
+	key := strings.ToLower(exePath)
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+	if pid, ok := pt.byExe[key]; ok {
+		return pid
+	}
+	pid := pt.next
+	pt.next++
+	pt.byExe[key] = pid
+	pt.pids[pid] = exePath
+	return pid
+}
+
+// Bind assigns a local port to exePath and returns its PID.
+func (pt *ProcessTable) Bind(exePath string, port uint16, isUDP bool) uint32 {
+	pid := pt.PID(exePath)
+	pt.mu.Lock()
+	pt.ports[portKey{port, isUDP}] = pid
+	pt.mu.Unlock()
+	return pid
+}
+
+// Unbind releases a local port.
+func (pt *ProcessTable) Unbind(port uint16, isUDP bool) {
+	pt.mu.Lock()
+	delete(pt.ports, portKey{port, isUDP})
+	pt.mu.Unlock()
+}
+
+// FindPIDByPort implements platform.ProcessIdentifier.
+func (pt *ProcessTable) FindPIDByPort(srcPort uint16, isUDP bool) (uint32, error) {
+	pt.mu.RLock()
+	pid, ok := pt.ports[portKey{srcPort, isUDP}]
+	pt.mu.RUnlock()
+	if !ok {
+		return 0, fmt.Errorf("no simulated process owns port %d", srcPort)
+	}
+	return pid, nil
+}
+
+// QueryPath resolves a synthetic PID to its executable path
+// (platform.Platform.QueryProcessPath).
+func (pt *ProcessTable) QueryPath(pid uint32) (string, error) {
+	pt.mu.RLock()
+	path, ok := pt.pids[pid]
+	pt.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("no simulated process with PID %d", pid)
+	}
+	return path, nil
+}
@@ -0,0 +1,228 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+)
+
+// BannerPrefix starts the line the fake Internet sends on every TCP
+// connection (before closing it) and in reply to every UDP datagram:
+//
+//	awg-sim tunnel=<tunnel id> dst=<host:port>
+const BannerPrefix = "awg-sim "
+
+// ParseBanner extracts the tunnel and destination from a banner line.
+func ParseBanner(line string) (tunnelID, dst string, ok bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(line), BannerPrefix)
+	if !ok {
+		return "", "", false
+	}
+	for _, f := range strings.Fields(rest) {
+		if v, found := strings.CutPrefix(f, "tunnel="); found {
+			tunnelID = v
+		} else if v, found := strings.CutPrefix(f, "dst="); found {
+			dst = v
+		}
+	}
+	return tunnelID, dst, tunnelID != ""
+}
+
+func banner(tunnelID, dst string) string {
+	return fmt.Sprintf("%stunnel=%s dst=%s\n", BannerPrefix, tunnelID, dst)
+}
+
+// Internet is the simulated network behind every stand-in provider. It
+// answers DNS queries on port 53 of any address and serves the banner
+// everywhere else.
+type Internet struct {
+	mu    sync.RWMutex
+	hosts map[string]netip.Addr // lowercased FQDN without trailing dot
+}
+
+// NewInternet creates an internet where unknown names resolve to addresses
+// derived from the name.
+func NewInternet() *Internet {
+	return &Internet{hosts: make(map[string]netip.Addr)}
+}
+
+// SetHost pins the A record for name.
+func (in *Internet) SetHost(name string, ip netip.Addr) {
+	in.mu.Lock()
+	in.hosts[strings.TrimSuffix(strings.ToLower(name), ".")] = ip
+	in.mu.Unlock()
+}
+
+// Lookup returns the A record for name: the pinned address, or a stable
+// address in 203.0.113.0/24 (TEST-NET-3) hashed from the name.
+func (in *Internet) Lookup(name string) netip.Addr {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	in.mu.RLock()
+	ip, ok := in.hosts[name]
+	in.mu.RUnlock()
+	if ok {
+		return ip
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return netip.AddrFrom4([4]byte{203, 0, 113, byte(1 + h.Sum32()%254)})
+}
+
+// answerDNS builds a response to a DNS query, or returns nil if the query
+// cannot be parsed.
+func (in *Internet) answerDNS(query []byte) []byte {
+	var p dnsmessage.Parser
+	hdr, err := p.Start(query)
+	if err != nil {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 hdr.ID,
+			Response:           true,
+			RecursionDesired:   hdr.RecursionDesired,
+			RecursionAvailable: true,
+		},
+		Questions: []dnsmessage.Question{q},
+	}
+	if q.Type == dnsmessage.TypeA && q.Class == dnsmessage.ClassINET {
+		resp.Answers = []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: q.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+			Body:   &dnsmessage.AResource{A: in.Lookup(q.Name.String()).As4()},
+		}}
+	}
+	out, err := resp.Pack()
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// serveTCP writes the banner and closes the connection. The pipe has no
+// half-close, so the server side closing is what ends the proxied flow.
+func (in *Internet) serveTCP(conn net.Conn, tunnelID, dst string) {
+	io.WriteString(conn, banner(tunnelID, dst))
+	conn.Close()
+}
+
+// serveUDP answers each datagram: DNS on port 53, the banner elsewhere.
+func (in *Internet) serveUDP(conn net.Conn, tunnelID, dst string) {
+	defer conn.Close()
+	isDNS := strings.HasSuffix(dst, ":53")
+	buf := make([]byte, 65535)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return
+		}
+		var reply []byte
+		if isDNS {
+			if reply = in.answerDNS(buf[:n]); reply == nil {
+				continue
+			}
+		} else {
+			reply = []byte(banner(tunnelID, dst))
+		}
+		if _, err := conn.Write(reply); err != nil {
+			return
+		}
+	}
+}
+
+// Provider is a stand-in provider.TunnelProvider that connects instantly
+// and dials into the simulated Internet. One is created for every
+// configured tunnel, including direct, whatever its protocol.
+type Provider struct {
+	mu       sync.RWMutex
+	cfg      core.TunnelConfig
+	internet *Internet
+	state    core.TunnelState
+}
+
+// NewProvider creates a stand-in provider for cfg.
+func NewProvider(cfg core.TunnelConfig, internet *Internet) *Provider {
+	state := core.TunnelStateDown
+	if cfg.Protocol == "direct" {
+		state = core.TunnelStateUp
+	}
+	return &Provider{cfg: cfg, internet: internet, state: state}
+}
+
+var _ provider.TunnelProvider = (*Provider)(nil)
+
+// Connect marks the tunnel up.
+func (p *Provider) Connect(context.Context) error {
+	p.mu.Lock()
+	p.state = core.TunnelStateUp
+	p.mu.Unlock()
+	core.Log.Infof("Sim", "[%s] Stand-in %s provider connected", p.cfg.ID, p.cfg.Protocol)
+	return nil
+}
+
+// Disconnect marks the tunnel down.
+func (p *Provider) Disconnect() error {
+	p.mu.Lock()
+	p.state = core.TunnelStateDown
+	p.mu.Unlock()
+	return nil
+}
+
+// State returns the current provider state.
+func (p *Provider) State() core.TunnelState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.state
+}
+
+// GetAdapterIP returns an invalid addr (no VPN adapter).
+func (p *Provider) GetAdapterIP() netip.Addr { return netip.Addr{} }
+
+// DialTCP returns a connection to the simulated Internet.
+func (p *Provider) DialTCP(ctx context.Context, addr string) (net.Conn, error) {
+	if err := p.checkUp(ctx); err != nil {
+		return nil, err
+	}
+	client, server := net.Pipe()
+	core.SafeGo("sim.internet-tcp", func() { p.internet.serveTCP(server, p.cfg.ID, addr) })
+	return client, nil
+}
+
+// DialUDP returns a datagram connection to the simulated Internet.
+func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
+	if err := p.checkUp(ctx); err != nil {
+		return nil, err
+	}
+	client, server := net.Pipe()
+	core.SafeGo("sim.internet-udp", func() { p.internet.serveUDP(server, p.cfg.ID, addr) })
+	return client, nil
+}
+
+func (p *Provider) checkUp(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if p.State() != core.TunnelStateUp {
+		return fmt.Errorf("tunnel %q is not connected", p.cfg.ID)
+	}
+	return nil
+}
+
+// Name returns the tunnel's display name.
+func (p *Provider) Name() string { return p.cfg.Name }
+
+// Protocol returns the configured protocol.
+func (p *Provider) Protocol() string { return p.cfg.Protocol }
@@ -0,0 +1,156 @@
+package sim
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/platform"
+)
+
+// Call is one recorded platform call.
+type Call struct {
+	Time   time.Time
+	Method string
+	Args   string
+}
+
+func (c Call) String() string {
+	if c.Args == "" {
+		return c.Method
+	}
+	return c.Method + "(" + c.Args + ")"
+}
+
+// Recorder collects platform calls in order.
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+func (r *Recorder) record(method string, args ...any) {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = fmt.Sprint(a)
+	}
+	c := Call{Time: time.Now(), Method: method, Args: strings.Join(parts, ", ")}
+	r.mu.Lock()
+	r.calls = append(r.calls, c)
+	r.mu.Unlock()
+	core.Log.Debugf("Sim", "%s", c)
+}
+
+// Calls returns a copy of the recorded calls.
+func (r *Recorder) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Call(nil), r.calls...)
+}
+
+// Called reports whether method has been recorded.
+func (r *Recorder) Called(method string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.calls {
+		if c.Method == method {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessFilter implements platform.ProcessFilter by recording calls.
+// Nothing is blocked: the simulated host has no other route to the network.
+type ProcessFilter struct{ Recorder }
+
+func (f *ProcessFilter) EnsureBlocked(exePath string) { f.record("EnsureBlocked", exePath) }
+func (f *ProcessFilter) BlockProcessOnRealNIC(exePath string) error {
+	f.record("BlockProcessOnRealNIC", exePath)
+	return nil
+}
+func (f *ProcessFilter) UnblockProcess(exePath string) { f.record("UnblockProcess", exePath) }
+func (f *ProcessFilter) UnblockAllProcesses()          { f.record("UnblockAllProcesses") }
+func (f *ProcessFilter) AddBypassPrefixes(prefixes []netip.Prefix) error {
+	f.record("AddBypassPrefixes", prefixes)
+	return nil
+}
+func (f *ProcessFilter) BlockDNSOnInterface(ifLUID uint64) error {
+	f.record("BlockDNSOnInterface", ifLUID)
+	return nil
+}
+func (f *ProcessFilter) UnblockDNSOnInterface() { f.record("UnblockDNSOnInterface") }
+func (f *ProcessFilter) BlockDoHDoTOnInterface(ifLUID uint64) error {
+	f.record("BlockDoHDoTOnInterface", ifLUID)
+	return nil
+}
+func (f *ProcessFilter) UnblockDoHDoTOnInterface() { f.record("UnblockDoHDoTOnInterface") }
+func (f *ProcessFilter) PermitDNSForSelf(ifLUID uint64) error {
+	f.record("PermitDNSForSelf", ifLUID)
+	return nil
+}
+func (f *ProcessFilter) RemoveDNSPermitForSelf() { f.record("RemoveDNSPermitForSelf") }
+func (f *ProcessFilter) PermitDHCP() error       { f.record("PermitDHCP"); return nil }
+func (f *ProcessFilter) BlockAllIPv6() error     { f.record("BlockAllIPv6"); return nil }
+func (f *ProcessFilter) EnableKillSwitch(tunIfName string, vpnEndpoints []netip.Addr) error {
+	f.record("EnableKillSwitch", tunIfName, vpnEndpoints)
+	return nil
+}
+func (f *ProcessFilter) DisableKillSwitch() error { f.record("DisableKillSwitch"); return nil }
+func (f *ProcessFilter) PermitDirectIPs(ips []netip.Addr) error {
+	f.record("PermitDirectIPs", ips)
+	return nil
+}
+func (f *ProcessFilter) RemoveDirectIPs(ips []netip.Addr) { f.record("RemoveDirectIPs", ips) }
+func (f *ProcessFilter) EnableDefaultBlock() error        { f.record("EnableDefaultBlock"); return nil }
+func (f *ProcessFilter) DisableDefaultBlock()             { f.record("DisableDefaultBlock") }
+func (f *ProcessFilter) PermitVirtualAdapters() error     { f.record("PermitVirtualAdapters"); return nil }
+func (f *ProcessFilter) RemoveVirtualAdapterPermits()     { f.record("RemoveVirtualAdapterPermits") }
+func (f *ProcessFilter) Close() error                     { f.record("Close"); return nil }
+
+// realNIC is the simulated physical interface. It has no local address, so
+// NIC-bound clients fall back to the host's default route.
+var realNIC = platform.RealNIC{
+	LUID:    2,
+	Index:   2,
+	Gateway: netip.MustParseAddr("192.0.2.1"),
+}
+
+// RouteManager implements platform.RouteManager by recording calls.
+type RouteManager struct{ Recorder }
+
+func (m *RouteManager) DiscoverRealNIC() (platform.RealNIC, error) {
+	m.record("DiscoverRealNIC")
+	return realNIC, nil
+}
+func (m *RouteManager) RealNICInfo() platform.RealNIC { return realNIC }
+func (m *RouteManager) SetDefaultRoute() error        { m.record("SetDefaultRoute"); return nil }
+func (m *RouteManager) RemoveDefaultRoute() error     { m.record("RemoveDefaultRoute"); return nil }
+func (m *RouteManager) AddBypassRoute(dst netip.Addr) error {
+	m.record("AddBypassRoute", dst)
+	return nil
+}
+func (m *RouteManager) RemoveBypassRoute(dst netip.Addr) error {
+	m.record("RemoveBypassRoute", dst)
+	return nil
+}
+func (m *RouteManager) ClearBypassRoutes() { m.record("ClearBypassRoutes") }
+func (m *RouteManager) Cleanup() error     { m.record("Cleanup"); return nil }
+
+// interfaceBinder implements platform.InterfaceBinder without binding.
+type interfaceBinder struct{}
+
+func (interfaceBinder) BindControl(uint32) func(network, address string, c syscall.RawConn) error {
+	return nil
+}
+
+// notifier implements platform.Notifier by logging.
+type notifier struct{}
+
+func (notifier) Show(title, message string) error {
+	core.Log.Infof("Sim", "Notification: %s: %s", title, message)
+	return nil
+}
@@ -0,0 +1,214 @@
+package sim
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/gateway"
+)
+
+// Scenario outcomes besides a tunnel ID.
+const (
+	ExpectDirect = "direct" // alias for the __direct__ tunnel
+	ExpectDrop   = "drop"   // no answer: blocked or dropped by the service
+)
+
+const defaultFlowTimeout = 3 * time.Second
+
+// Scenario is a scripted run: simulated applications open flows and each
+// flow's outcome is compared against the expected tunnel.
+type Scenario struct {
+	// Hosts pins names in the fake Internet (name → IPv4). Other names
+	// resolve to stable addresses in 203.0.113.0/24.
+	Hosts map[string]string `yaml:"hosts,omitempty"`
+	// Processes assigns local ports to executables for replayed traffic.
+	Processes []ProcessPorts `yaml:"processes,omitempty"`
+	// Replay is a pcap file injected before the flows run, relative to
+	// the scenario file.
+	Replay string `yaml:"replay,omitempty"`
+	// Timeout bounds each flow (duration string, default "3s").
+	Timeout string `yaml:"timeout,omitempty"`
+	Flows   []Flow `yaml:"flows"`
+}
+
+// ProcessPorts maps local ports to an executable.
+type ProcessPorts struct {
+	Exe string   `yaml:"exe"`
+	TCP []uint16 `yaml:"tcp,omitempty"`
+	UDP []uint16 `yaml:"udp,omitempty"`
+}
+
+// Flow is one connection opened by a simulated application.
+type Flow struct {
+	Name    string `yaml:"name,omitempty"`
+	Exe     string `yaml:"exe"`
+	Network string `yaml:"network,omitempty"` // "tcp" (default) or "udp"
+	Address string `yaml:"address"`           // host:port; names go through DNS
+	// Expect is the tunnel ID that should carry the flow, "direct" or
+	// "drop". Empty only reports the outcome.
+	Expect string `yaml:"expect,omitempty"`
+}
+
+// FlowResult is the outcome of one flow.
+type FlowResult struct {
+	Flow
+	Got  string // tunnel ID, or ExpectDrop
+	Dst  string // destination as seen by the tunnel
+	Err  error
+	Pass bool
+}
+
+// LoadScenario reads a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if sc.Replay != "" && !filepath.IsAbs(sc.Replay) {
+		sc.Replay = filepath.Join(filepath.Dir(path), sc.Replay)
+	}
+	for i, f := range sc.Flows {
+		if f.Exe == "" || f.Address == "" {
+			return nil, fmt.Errorf("flow %d: exe and address are required", i+1)
+		}
+	}
+	return &sc, nil
+}
+
+// Run applies the scenario's hosts and process ports, replays its capture
+// and runs its flows in order.
+func (s *Sim) Run(ctx context.Context, sc *Scenario) ([]FlowResult, error) {
+	for name, ipStr := range sc.Hosts {
+		ip, err := netip.ParseAddr(ipStr)
+		if err != nil || !ip.Is4() {
+			return nil, fmt.Errorf("hosts: invalid IPv4 address %q for %s", ipStr, name)
+		}
+		s.Internet.SetHost(name, ip)
+	}
+	for _, p := range sc.Processes {
+		for _, port := range p.TCP {
+			s.Processes.Bind(p.Exe, port, false)
+		}
+		for _, port := range p.UDP {
+			s.Processes.Bind(p.Exe, port, true)
+		}
+	}
+	timeout := defaultFlowTimeout
+	if sc.Timeout != "" {
+		d, err := time.ParseDuration(sc.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("timeout: %w", err)
+		}
+		timeout = d
+	}
+
+	if sc.Replay != "" {
+		f, err := os.Open(sc.Replay)
+		if err != nil {
+			return nil, fmt.Errorf("replay: %w", err)
+		}
+		n, err := s.TUN.Replay(ctx, f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("replay %s: %w", sc.Replay, err)
+		}
+		core.Log.Infof("Sim", "Replayed %d packets from %s", n, sc.Replay)
+	}
+
+	results := make([]FlowResult, 0, len(sc.Flows))
+	for _, f := range sc.Flows {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		results = append(results, s.runFlow(ctx, f, timeout))
+	}
+	return results, nil
+}
+
+func (s *Sim) runFlow(ctx context.Context, f Flow, timeout time.Duration) FlowResult {
+	if f.Network == "" {
+		f.Network = "tcp"
+	}
+	res := FlowResult{Flow: f, Got: ExpectDrop}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := s.Dial(ctx, f.Exe, f.Network, f.Address)
+	if err == nil {
+		defer conn.Close()
+		deadline, _ := ctx.Deadline()
+		conn.SetDeadline(deadline)
+		if strings.HasPrefix(f.Network, "udp") {
+			_, err = conn.Write([]byte("awg-sim probe\n"))
+		}
+		var line string
+		if err == nil {
+			line, err = bufio.NewReader(conn).ReadString('\n')
+		}
+		if err == nil {
+			if id, dst, ok := ParseBanner(line); ok {
+				res.Got, res.Dst = id, dst
+			} else {
+				err = fmt.Errorf("unexpected reply %q", line)
+			}
+		}
+	}
+	res.Err = err
+
+	want := f.Expect
+	if want == ExpectDirect {
+		want = gateway.DirectTunnelID
+	}
+	res.Pass = want == "" || want == res.Got
+	return res
+}
+
+// WriteReport prints results as a table and returns the number of failures.
+func WriteReport(w io.Writer, results []FlowResult) int {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLOW\tEXE\tDIAL\tEXPECT\tGOT\tRESULT")
+	var failed int
+	for i, r := range results {
+		name := r.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+		got := r.Got
+		if r.Got == ExpectDrop && r.Err != nil {
+			got += " (" + r.Err.Error() + ")"
+		}
+		result := "ok"
+		if !r.Pass {
+			result = "FAIL"
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s/%s\t%s\t%s\t%s\n",
+			name, exeBase(r.Exe), r.Network, r.Address, r.Expect, got, result)
+	}
+	tw.Flush()
+	fmt.Fprintf(w, "%d flows, %d failed\n", len(results), failed)
+	return failed
+}
+
+// exeBase returns the file name of a Windows or Unix executable path.
+func exeBase(path string) string {
+	if i := strings.LastIndexAny(path, `/\`); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
@@ -0,0 +1,269 @@
+// Package sim provides a simulated platform that runs the whole service in
+// userspace on any OS: an in-memory TUN adapter backed by a gVisor "host"
+// stack, a scriptable port → executable process table, a process filter and
+// route manager that only record their calls, and stand-in tunnel providers
+// that dial into a fake Internet. It is used to reproduce routing bugs and
+// validate rule changes headlessly, without touching the host's network.
+package sim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/platform"
+	"awg-split-tunnel/internal/provider"
+)
+
+const (
+	// Local ports handed to simulated application sockets. The range stays
+	// clear of the service's proxy ports (31000+).
+	firstAppPort = 49152
+	lastAppPort  = 65535
+)
+
+// DefaultSystemDNS is the resolver simulated applications use while the
+// service has not set DNS on the TUN adapter (as the real NIC's resolver).
+var DefaultSystemDNS = netip.MustParseAddr("192.0.2.53")
+
+// Options configures a simulation.
+type Options struct {
+	// SocketPath is the IPC socket (default DefaultSocketPath).
+	SocketPath string
+	// Capture, if set, receives a pcap (raw IP) of every packet crossing
+	// the TUN adapter in either direction.
+	Capture io.Writer
+	// Internet serves all stand-in providers (default NewInternet()).
+	Internet *Internet
+}
+
+// Sim is one simulated host.
+type Sim struct {
+	TUN       *TUNAdapter
+	Processes *ProcessTable
+	Filter    *ProcessFilter
+	Routes    *RouteManager
+	Internet  *Internet
+	Platform  *platform.Platform
+
+	host *hostStack
+
+	portMu   sync.Mutex
+	nextPort uint16
+}
+
+// New creates a simulated host and the platform.Platform that drives it.
+func New(opts Options) (*Sim, error) {
+	s := &Sim{
+		TUN:       newTUNAdapter(),
+		Processes: NewProcessTable(),
+		Filter:    &ProcessFilter{},
+		Routes:    &RouteManager{},
+		Internet:  opts.Internet,
+		nextPort:  firstAppPort,
+	}
+	if s.Internet == nil {
+		s.Internet = NewInternet()
+	}
+	if opts.Capture != nil {
+		pw, err := newPCAPWriter(opts.Capture)
+		if err != nil {
+			return nil, fmt.Errorf("capture: %w", err)
+		}
+		s.TUN.capture = pw
+	}
+	host, err := newHostStack(tunIP, tunMTU, func(pkt []byte) {
+		select {
+		case s.TUN.queue <- pkt:
+		case <-s.TUN.done:
+		}
+	})
+	if err != nil {
+		return nil, fmt.Errorf("host stack: %w", err)
+	}
+	s.host = host
+	s.TUN.host = host
+
+	socketPath := opts.SocketPath
+	if socketPath == "" {
+		socketPath = DefaultSocketPath
+	}
+	s.Platform = &platform.Platform{
+		NewTUNAdapter: func() (platform.TUNAdapter, error) {
+			return s.TUN, nil
+		},
+		NewProcessFilter: func(uint64) (platform.ProcessFilter, error) {
+			return s.Filter, nil
+		},
+		NewRouteManager: func(uint64) platform.RouteManager {
+			return s.Routes
+		},
+		NewProcessID: func() platform.ProcessIdentifier {
+			return s.Processes
+		},
+		IPC:                ipcTransport{path: socketPath},
+		NewInterfaceBinder: func() platform.InterfaceBinder { return interfaceBinder{} },
+		Notifier:           notifier{},
+		FlushSystemDNS: func() error {
+			s.Filter.record("FlushSystemDNS")
+			return nil
+		},
+		QueryProcessPath: s.Processes.QueryPath,
+		ListenTCP:        host.listenTCP,
+		ListenUDP:        host.listenUDP,
+		NewTunnelProvider: func(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
+			return NewProvider(cfg, s.Internet), nil
+		},
+	}
+	return s, nil
+}
+
+// Close tears down the host stack and the TUN adapter.
+func (s *Sim) Close() {
+	s.TUN.Close()
+	s.host.close()
+}
+
+// WaitActive waits until the service has activated the gateway (set the
+// default route through the TUN adapter) or ctx ends.
+func (s *Sim) WaitActive(ctx context.Context) error {
+	t := time.NewTicker(50 * time.Millisecond)
+	defer t.Stop()
+	for !s.Routes.Called("SetDefaultRoute") {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	return nil
+}
+
+// allocPort returns the next free application port for the given transport.
+func (s *Sim) allocPort(isUDP bool) (uint16, error) {
+	s.portMu.Lock()
+	defer s.portMu.Unlock()
+	for range lastAppPort - firstAppPort + 1 {
+		p := s.nextPort
+		if s.nextPort == lastAppPort {
+			s.nextPort = firstAppPort
+		} else {
+			s.nextPort++
+		}
+		if _, err := s.Processes.FindPIDByPort(p, isUDP); err != nil {
+			return p, nil
+		}
+	}
+	return 0, errors.New("sim: no free application ports")
+}
+
+// Dial opens a connection from the TUN address as the application exePath,
+// so the service attributes it to that executable. network is "tcp" or
+// "udp"; host names are resolved through the DNS servers set on the TUN
+// adapter (or DefaultSystemDNS), also as exePath.
+func (s *Sim) Dial(ctx context.Context, exePath, network, address string) (net.Conn, error) {
+	var isUDP bool
+	switch network {
+	case "tcp", "tcp4":
+	case "udp", "udp4":
+		isUDP = true
+	default:
+		return nil, fmt.Errorf("sim: unsupported network %q", network)
+	}
+	dst, err := s.resolve(ctx, exePath, address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := s.allocPort(isUDP)
+	if err != nil {
+		return nil, err
+	}
+	s.Processes.Bind(exePath, port, isUDP)
+
+	release := func() { s.Processes.Unbind(port, isUDP) }
+	if isUDP {
+		conn, err := s.host.dialUDP(port, dst)
+		if err != nil {
+			release()
+			return nil, err
+		}
+		return &appPacketConn{UDPConn: conn, release: release}, nil
+	}
+	conn, err := s.host.dialTCP(ctx, port, dst)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &appConn{Conn: conn, release: release}, nil
+}
+
+// resolve turns host:port into an IPv4 address and port.
+func (s *Sim) resolve(ctx context.Context, exePath, address string) (netip.AddrPort, error) {
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid port in %q", address)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		if !ip.Is4() {
+			return netip.AddrPort{}, fmt.Errorf("sim: %s is not IPv4", ip)
+		}
+		return netip.AddrPortFrom(ip, uint16(port)), nil
+	}
+	servers := s.TUN.DNS()
+	if len(servers) == 0 {
+		servers = []netip.Addr{DefaultSystemDNS}
+	}
+	r := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return s.Dial(ctx, exePath, "udp", netip.AddrPortFrom(servers[0], 53).String())
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	ips, err := r.LookupNetIP(ctx, "ip4", host)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(ips[0].Unmap(), uint16(port)), nil
+}
+
+// appConn releases the application's port when closed.
+type appConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+func (c *appConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// appPacketConn is appConn for UDP. It keeps the net.PacketConn methods so
+// the Go resolver uses datagram framing over it.
+type appPacketConn struct {
+	*gonet.UDPConn
+	once    sync.Once
+	release func()
+}
+
+func (c *appPacketConn) Close() error {
+	err := c.UDPConn.Close()
+	c.once.Do(c.release)
+	return err
+}
@@ -0,0 +1,129 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/gateway"
+	"awg-split-tunnel/internal/process"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/service"
+)
+
+// startService wires the TUN router and tunnel controller to a simulated
+// host the way runVPN does, with one tunnel "vpn1" connected.
+func startService(t *testing.T, rules []core.Rule) *Sim {
+	t.Helper()
+	var capture bytes.Buffer
+	s, err := New(Options{SocketPath: filepath.Join(t.TempDir(), "sim.sock"), Capture: &capture})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(func() {
+		cancel()
+		s.Close()
+	})
+	plat := s.Platform
+
+	bus := core.NewEventBus()
+	registry := core.NewTunnelRegistry(bus)
+	matcher := process.NewMatcher()
+	matcher.SetPathQuery(plat.QueryProcessPath)
+	ruleEngine := core.NewRuleEngine(rules, bus, matcher)
+	adapter, _ := plat.NewTUNAdapter()
+	procFilter, _ := plat.NewProcessFilter(adapter.LUID())
+	flows := gateway.NewFlowTable()
+	dnsRouter := gateway.NewDNSRouter(gateway.DNSConfig{}, registry)
+	router := gateway.NewTUNRouter(adapter, flows, plat.NewProcessID(), matcher, ruleEngine, registry, procFilter, dnsRouter)
+
+	ctrl := service.NewTunnelController(ctx, service.ControllerDeps{
+		Registry:        registry,
+		Bus:             bus,
+		Flows:           flows,
+		TUNRouter:       router,
+		RouteMgr:        plat.NewRouteManager(adapter.LUID()),
+		WFPMgr:          procFilter,
+		Adapter:         adapter,
+		DNSRouter:       dnsRouter,
+		InterfaceBinder: plat.NewInterfaceBinder(),
+		Providers:       make(map[string]provider.TunnelProvider),
+		Rules:           ruleEngine,
+		ListenTCP:       plat.ListenTCP,
+		ListenUDP:       plat.ListenUDP,
+		NewProvider:     plat.NewTunnelProvider,
+	}, 31000)
+	if err := ctrl.AddTunnel(ctx, core.TunnelConfig{ID: "vpn1", Protocol: "amneziawg", Name: "VPN 1"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := ctrl.ConnectTunnel(ctx, "vpn1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := router.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		// The packet loop only notices cancellation between reads.
+		s.TUN.Close()
+		router.Stop()
+	})
+	return s
+}
+
+func TestScenarioRoutesByProcess(t *testing.T) {
+	s := startService(t, []core.Rule{
+		{Pattern: "game.exe", TunnelID: "vpn1", Fallback: core.PolicyBlock},
+		{Pattern: "blocked.exe", Fallback: core.PolicyDrop},
+	})
+
+	results, err := s.Run(context.Background(), &Scenario{
+		Hosts:   map[string]string{"game.example": "203.0.113.7"},
+		Timeout: "2s",
+		Flows: []Flow{
+			{Exe: `C:\Games\game.exe`, Address: "198.51.100.10:443", Expect: "vpn1"},
+			{Exe: `C:\Games\game.exe`, Network: "udp", Address: "198.51.100.10:27015", Expect: "vpn1"},
+			{Exe: `C:\Program Files\Browser\browser.exe`, Address: "198.51.100.10:443", Expect: ExpectDirect},
+			{Exe: `C:\Program Files\Browser\browser.exe`, Address: "game.example:80", Expect: ExpectDirect},
+			{Exe: `C:\Tools\blocked.exe`, Address: "198.51.100.10:443", Expect: ExpectDrop},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report bytes.Buffer
+	if WriteReport(&report, results) != 0 {
+		t.Fatalf("scenario failed:\n%s", report.String())
+	}
+	if got := results[3].Dst; got != "203.0.113.7:80" {
+		t.Errorf("pinned host dialed as %q, want 203.0.113.7:80", got)
+	}
+}
+
+func TestPCAPRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	pw, err := newPCAPWriter(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkt := make([]byte, 28)
+	pkt[0] = 0x45
+	pkt[9] = 17
+	pw.write(pkt)
+	pw.write([]byte{0x60, 0, 0, 0}) // IPv6, skipped on replay
+
+	tun := newTUNAdapter()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	n, err := tun.Replay(ctx, &buf)
+	if err != nil || n != 1 {
+		t.Fatalf("Replay = %d, %v; want 1 packet", n, err)
+	}
+	out := make([]byte, 64)
+	if m, err := tun.ReadPacket(out); err != nil || !bytes.Equal(out[:m], pkt) {
+		t.Fatalf("ReadPacket = %x, %v", out[:m], err)
+	}
+}
@@ -0,0 +1,161 @@
+package sim
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strconv"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	nicID = 1
+	// channelSize is the outbound queue of the link endpoint, in packets.
+	channelSize = 1024
+)
+
+// hostStack is the simulated operating system: a userspace network stack
+// that owns the TUN address and whose only route points at the TUN
+// adapter. Simulated applications dial from it, and the service's proxy
+// and DNS listeners are served by it, so hairpinned packets written by the
+// TUN router reach them exactly as they would through a kernel.
+type hostStack struct {
+	stack  *stack.Stack
+	ep     *channel.Endpoint
+	addr   tcpip.Address
+	cancel context.CancelFunc
+}
+
+// newHostStack creates the stack with ip assigned. Packets it emits are
+// passed to output.
+func newHostStack(ip netip.Addr, mtu int, output func(pkt []byte)) (*hostStack, error) {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol, udp.NewProtocol},
+		// Queries to the resolver on the TUN address stay inside the
+		// stack, as they do on the loopback path of a real OS.
+		HandleLocal: true,
+	})
+	ep := channel.New(channelSize, uint32(mtu), "")
+	if err := s.CreateNIC(nicID, ep); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("create NIC: %s", err)
+	}
+	addr := tcpip.AddrFrom4(ip.As4())
+	if err := s.AddProtocolAddress(nicID, tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: addr.WithPrefix(),
+	}, stack.AddressProperties{}); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("add address: %s", err)
+	}
+	s.SetRouteTable([]tcpip.Route{{Destination: header.IPv4EmptySubnet, NIC: nicID}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h := &hostStack{stack: s, ep: ep, addr: addr, cancel: cancel}
+	core.SafeGo("sim.stack-output", func() { h.outputLoop(ctx, output) })
+	return h, nil
+}
+
+// deliver injects an IPv4 packet written to the TUN adapter into the stack.
+func (h *hostStack) deliver(pkt []byte) {
+	if len(pkt) == 0 || pkt[0]>>4 != 4 {
+		return
+	}
+	pb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(pkt)})
+	h.ep.InjectInbound(ipv4.ProtocolNumber, pb)
+	pb.DecRef()
+}
+
+// outputLoop hands packets emitted by the stack to the TUN adapter.
+func (h *hostStack) outputLoop(ctx context.Context, output func(pkt []byte)) {
+	for {
+		pb := h.ep.ReadContext(ctx)
+		if pb == nil {
+			return
+		}
+		view := pb.ToView()
+		pkt := make([]byte, view.Size())
+		copy(pkt, view.AsSlice())
+		view.Release()
+		pb.DecRef()
+		output(pkt)
+	}
+}
+
+// listenTCP opens a TCP listener on the stack ("host:port", empty or
+// unspecified host = any address).
+func (h *hostStack) listenTCP(addr string) (net.Listener, error) {
+	full, err := fullAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	return gonet.ListenTCP(h.stack, full, ipv4.ProtocolNumber)
+}
+
+// listenUDP opens an unconnected UDP socket on the stack.
+func (h *hostStack) listenUDP(addr string) (net.PacketConn, error) {
+	full, err := fullAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	return gonet.DialUDP(h.stack, &full, nil, ipv4.ProtocolNumber)
+}
+
+// dialTCP connects from the TUN address and the given local port.
+func (h *hostStack) dialTCP(ctx context.Context, port uint16, dst netip.AddrPort) (net.Conn, error) {
+	return gonet.DialTCPWithBind(ctx, h.stack,
+		tcpip.FullAddress{NIC: nicID, Addr: h.addr, Port: port},
+		tcpip.FullAddress{NIC: nicID, Addr: tcpip.AddrFrom4(dst.Addr().As4()), Port: dst.Port()},
+		ipv4.ProtocolNumber)
+}
+
+// dialUDP opens a UDP socket connected to dst from the given local port.
+func (h *hostStack) dialUDP(port uint16, dst netip.AddrPort) (*gonet.UDPConn, error) {
+	return gonet.DialUDP(h.stack,
+		&tcpip.FullAddress{NIC: nicID, Addr: h.addr, Port: port},
+		&tcpip.FullAddress{NIC: nicID, Addr: tcpip.AddrFrom4(dst.Addr().As4()), Port: dst.Port()},
+		ipv4.ProtocolNumber)
+}
+
+// close stops the output loop and tears down every socket.
+func (h *hostStack) close() {
+	h.cancel()
+	h.ep.Close()
+	h.stack.Close()
+	h.stack.Wait()
+}
+
+func fullAddr(addr string) (tcpip.FullAddress, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return tcpip.FullAddress{}, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return tcpip.FullAddress{}, fmt.Errorf("invalid port in %q", addr)
+	}
+	full := tcpip.FullAddress{Port: uint16(port)}
+	if host != "" {
+		ip, err := netip.ParseAddr(host)
+		if err != nil || !ip.Is4() {
+			return tcpip.FullAddress{}, fmt.Errorf("invalid IPv4 address in %q", addr)
+		}
+		if !ip.IsUnspecified() {
+			full.Addr = tcpip.AddrFrom4(ip.As4())
+		}
+	}
+	return full, nil
+}
@@ -0,0 +1,173 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/netip"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// TUN configuration (matches Windows and macOS: 10.255.0.1, MTU 1400).
+	tunName = "sim0"
+	tunMTU  = 1400
+
+	// tunQueueSize is the number of outbound packets buffered for the router.
+	tunQueueSize = 4096
+
+	// maxReplayGap caps the pause between replayed packets.
+	maxReplayGap = time.Second
+)
+
+var (
+	tunIP        = netip.MustParseAddr("10.255.0.1")
+	errTUNClosed = errors.New("sim: TUN adapter closed")
+)
+
+// TUNAdapter implements platform.TUNAdapter in memory. Packets sent by the
+// simulated host stack, replayed from a capture or injected by tests are
+// returned by ReadPacket; packets written by the TUN router are delivered
+// back to the host stack.
+type TUNAdapter struct {
+	queue     chan []byte
+	done      chan struct{}
+	closeOnce sync.Once
+
+	host    *hostStack
+	capture *pcapWriter
+
+	dnsMu sync.Mutex
+	dns   []netip.Addr
+}
+
+func newTUNAdapter() *TUNAdapter {
+	return &TUNAdapter{
+		queue: make(chan []byte, tunQueueSize),
+		done:  make(chan struct{}),
+	}
+}
+
+// Name returns "sim0".
+func (t *TUNAdapter) Name() string { return tunName }
+
+// LUID returns a fixed identifier for the simulated interface.
+func (t *TUNAdapter) LUID() uint64 { return 1 }
+
+// InterfaceIndex returns the simulated interface index.
+func (t *TUNAdapter) InterfaceIndex() uint32 { return nicID }
+
+// IP returns the adapter's address (10.255.0.1).
+func (t *TUNAdapter) IP() netip.Addr { return tunIP }
+
+// ReadPacket blocks until the host stack, a replay or Inject produces a packet.
+func (t *TUNAdapter) ReadPacket(buf []byte) (int, error) {
+	select {
+	case pkt := <-t.queue:
+		if t.capture != nil {
+			t.capture.write(pkt)
+		}
+		return copy(buf, pkt), nil
+	case <-t.done:
+		return 0, errTUNClosed
+	}
+}
+
+// WritePacket delivers a packet from the TUN router to the host stack.
+func (t *TUNAdapter) WritePacket(pkt []byte) error {
+	select {
+	case <-t.done:
+		return errTUNClosed
+	default:
+	}
+	if t.capture != nil {
+		t.capture.write(pkt)
+	}
+	if t.host != nil {
+		t.host.deliver(pkt)
+	}
+	return nil
+}
+
+// Inject queues an outbound IPv4 packet as if an application had sent it.
+// The packet is copied.
+func (t *TUNAdapter) Inject(pkt []byte) error {
+	cp := make([]byte, len(pkt))
+	copy(cp, pkt)
+	select {
+	case t.queue <- cp:
+		return nil
+	case <-t.done:
+		return errTUNClosed
+	}
+}
+
+// Replay injects the IPv4 packets of a pcap capture, keeping their relative
+// timing (gaps are capped at one second). Frames that are not IPv4 are
+// skipped. It returns the number of packets injected.
+func (t *TUNAdapter) Replay(ctx context.Context, r io.Reader) (int, error) {
+	pr, err := newPCAPReader(r)
+	if err != nil {
+		return 0, err
+	}
+	var n int
+	var last time.Time
+	for {
+		p, err := pr.next()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		if !last.IsZero() {
+			if gap := min(p.ts.Sub(last), maxReplayGap); gap > 0 {
+				select {
+				case <-time.After(gap):
+				case <-ctx.Done():
+					return n, ctx.Err()
+				}
+			}
+		}
+		last = p.ts
+		if err := t.Inject(p.data); err != nil {
+			return n, err
+		}
+		n++
+	}
+}
+
+// SetDNS records the DNS servers the service assigned to the adapter.
+// Simulated applications resolve names through them.
+func (t *TUNAdapter) SetDNS(servers []netip.Addr) error {
+	t.dnsMu.Lock()
+	t.dns = append([]netip.Addr(nil), servers...)
+	t.dnsMu.Unlock()
+	core.Log.Infof("Sim", "TUN DNS set to %v", servers)
+	return nil
+}
+
+// ClearDNS forgets the adapter's DNS servers.
+func (t *TUNAdapter) ClearDNS() error {
+	t.dnsMu.Lock()
+	t.dns = nil
+	t.dnsMu.Unlock()
+	core.Log.Infof("Sim", "TUN DNS cleared")
+	return nil
+}
+
+// DNS returns the DNS servers currently set on the adapter.
+func (t *TUNAdapter) DNS() []netip.Addr {
+	t.dnsMu.Lock()
+	defer t.dnsMu.Unlock()
+	return append([]netip.Addr(nil), t.dns...)
+}
+
+// Close unblocks readers; later reads and writes fail.
+func (t *TUNAdapter) Close() error {
+	t.closeOnce.Do(func() { close(t.done) })
+	return nil
+}
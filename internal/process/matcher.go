@@ -68,12 +68,23 @@ const maxCacheSize = 10000
 type Matcher struct {
 	mu    sync.RWMutex
 	cache map[uint32]*cachedPath // PID → cached path info
+	query func(pid uint32) (string, error)
 }
 
 // NewMatcher creates a process matcher with an empty cache.
 func NewMatcher() *Matcher {
 	return &Matcher{
 		cache: make(map[uint32]*cachedPath),
+		query: queryProcessPath,
+	}
+}
+
+// SetPathQuery replaces the OS lookup used to resolve a PID to its
+// executable path (e.g. with a scripted process table in simulation).
+// Must be called before the matcher is used.
+func (m *Matcher) SetPathQuery(fn func(pid uint32) (string, error)) {
+	if fn != nil {
+		m.query = fn
 	}
 }
 
@@ -86,7 +97,7 @@ func (m *Matcher) GetExePath(pid uint32) (string, bool) {
 	}
 
 	// Query OS for the process path (platform-specific).
-	path, err := queryProcessPath(pid)
+	path, err := m.query(pid)
 	if err != nil {
 		return "", false
 	}
@@ -134,7 +145,7 @@ func (m *Matcher) GetExePathLower(pid uint32) (exePath, exeLower, baseLower stri
 	}
 
 	// Query OS for the process path (platform-specific).
-	path, err := queryProcessPath(pid)
+	path, err := m.query(pid)
 	if err != nil {
 		return "", "", "", false
 	}
@@ -234,7 +245,7 @@ func (m *Matcher) revalidateCache() {
 	}
 	var stale []staleEntry
 	for i, pid := range pids {
-		currentPath, err := queryProcessPath(pid)
+		currentPath, err := m.query(pid)
 		if err != nil {
 			// Process no longer exists.
 			stale = append(stale, staleEntry{pid, paths[i]})
@@ -2,12 +2,16 @@ package amneziawg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"net/netip"
+	"strings"
 	"sync"
 
 	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/wgconf"
 
 	"github.com/amnezia-vpn/amneziawg-go/conn"
 	"github.com/amnezia-vpn/amneziawg-go/device"
@@ -18,6 +22,8 @@ import (
 type Config struct {
 	// ConfigFile is the path to the AmneziaWG .conf file.
 	ConfigFile string `yaml:"config_file"`
+	// ConfigText is an inline .conf definition; takes precedence over ConfigFile.
+	ConfigText string `yaml:"config"`
 	// AdapterIP is the local IP override (optional; taken from .conf Address if empty).
 	AdapterIP string `yaml:"adapter_ip"`
 }
@@ -33,10 +39,20 @@ type Provider struct {
 
 	adapterIP     netip.Addr
 	peerEndpoints []netip.AddrPort
+	hostEndpoints []HostEndpoint
+	peers         *wgconf.PeerRouter
 	dev           *device.Device // amneziawg-go device
 	tnet          *netstack.Net  // userspace network stack
+	mtu           int
 }
 
+var (
+	_ provider.EndpointRefresher = (*Provider)(nil)
+	_ provider.MultiPeerProvider = (*Provider)(nil)
+	_ provider.MTUProvider       = (*Provider)(nil)
+	_ provider.UDPListener       = (*Provider)(nil)
+)
+
 // New creates an AmneziaWG provider with the given configuration.
 // AdapterIP is optional — if empty, it will be resolved from the .conf Address on Connect.
 func New(name string, cfg Config) (*Provider, error) {
@@ -65,8 +81,14 @@ func (p *Provider) Connect(ctx context.Context) error {
 	p.state = core.TunnelStateConnecting
 	core.Log.Infof("AWG", "Connecting tunnel %q...", p.name)
 
-	// 1. Parse .conf file.
-	parsed, err := ParseConfigFile(p.config.ConfigFile)
+	// 1. Parse .conf (inline text or file).
+	var parsed *ParsedConfig
+	var err error
+	if p.config.ConfigText != "" {
+		parsed, err = ParseConfig(strings.NewReader(p.config.ConfigText))
+	} else {
+		parsed, err = ParseConfigFile(p.config.ConfigFile)
+	}
 	if err != nil {
 		p.state = core.TunnelStateError
 		return fmt.Errorf("[AWG] parse config: %w", err)
@@ -123,9 +145,20 @@ func (p *Provider) Connect(ctx context.Context) error {
 		return fmt.Errorf("[AWG] device up: %w", err)
 	}
 
+	// 7. Per-peer routing: prefixes shared by several peers go to the first.
+	peers := wgconf.NewPeerRouter(parsed.Peers, dev.IpcSet)
+	if err := peers.Apply(); err != nil {
+		dev.Close()
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[AWG] %w", err)
+	}
+
 	p.dev = dev
 	p.tnet = tnet
 	p.peerEndpoints = parsed.PeerEndpoints
+	p.hostEndpoints = parsed.HostEndpoints
+	p.peers = peers
+	p.mtu = mtu
 	p.state = core.TunnelStateUp
 	core.Log.Infof("AWG", "Tunnel %q is UP (ip=%s, mtu=%d)", p.name, p.adapterIP, mtu)
 	return nil
@@ -144,6 +177,7 @@ func (p *Provider) Disconnect() error {
 		p.dev.Close()
 		p.dev = nil
 		p.tnet = nil
+		p.peers = nil
 	}
 
 	p.state = core.TunnelStateDown
@@ -165,6 +199,13 @@ func (p *Provider) GetAdapterIP() netip.Addr {
 	return p.adapterIP
 }
 
+// MTU returns the tunnel MTU from the .conf (0 before Connect).
+func (p *Provider) MTU() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.mtu
+}
+
 // DialTCP creates a TCP connection through the AWG tunnel via netstack.
 // Calls DialContextTCPAddrPort directly, bypassing DialContext's regex parsing
 // and DNS lookup overhead (~1μs + allocations saved per connection).
@@ -204,6 +245,24 @@ func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
 	return tnet.DialUDPAddrPort(netip.AddrPort{}, ap)
 }
 
+// ListenUDP opens an unconnected UDP socket on an ephemeral port of the
+// tunnel address via netstack. Implements provider.UDPListener.
+func (p *Provider) ListenUDP(_ context.Context) (net.PacketConn, error) {
+	p.mu.RLock()
+	state := p.state
+	tnet := p.tnet
+	p.mu.RUnlock()
+
+	if state != core.TunnelStateUp {
+		return nil, fmt.Errorf("[AWG] tunnel %q is not up (state=%d)", p.name, state)
+	}
+	conn, err := tnet.ListenUDPAddrPort(netip.AddrPort{})
+	if err != nil {
+		return nil, fmt.Errorf("[AWG] listen UDP: %w", err)
+	}
+	return conn, nil
+}
+
 // Name returns the human-readable tunnel name.
 func (p *Provider) Name() string {
 	return p.name
@@ -222,6 +281,74 @@ func (p *Provider) GetServerEndpoints() []netip.AddrPort {
 	return p.peerEndpoints
 }
 
+// RefreshEndpoints re-resolves peer endpoints configured by hostname and
+// pushes changed addresses to the running device via UAPI (update_only), so
+// the tunnel follows dynamic DNS without a reconnect.
+// Implements provider.EndpointRefresher.
+func (p *Provider) RefreshEndpoints(ctx context.Context, resolver *net.Resolver) ([]provider.EndpointChange, error) {
+	p.mu.RLock()
+	dev := p.dev
+	hosts := append([]HostEndpoint(nil), p.hostEndpoints...)
+	p.mu.RUnlock()
+	if dev == nil || len(hosts) == 0 {
+		return nil, nil
+	}
+
+	uapi, changes, resolveErr := reresolveHosts(ctx, resolver, hosts)
+	if len(changes) == 0 {
+		return nil, resolveErr
+	}
+	if err := dev.IpcSet(uapi); err != nil {
+		return nil, fmt.Errorf("[AWG] update endpoints: %w", err)
+	}
+
+	p.mu.Lock()
+	if p.dev == dev {
+		p.hostEndpoints = hosts
+		// Copy-on-write: callers may hold the previous slice.
+		eps := append([]netip.AddrPort(nil), p.peerEndpoints...)
+		for _, c := range changes {
+			for j := range eps {
+				if eps[j] == c.Old {
+					eps[j] = c.New
+					break
+				}
+			}
+		}
+		p.peerEndpoints = eps
+	}
+	p.mu.Unlock()
+
+	for _, c := range changes {
+		core.Log.Infof("AWG", "Tunnel %q: endpoint moved %s -> %s", p.name, c.Old, c.New)
+	}
+	return changes, resolveErr
+}
+
+// reresolveHosts re-resolves hosts in place and returns the UAPI update for
+// the endpoints that moved. Lookup failures are joined into err; the other
+// hosts are still updated.
+func reresolveHosts(ctx context.Context, resolver ipResolver, hosts []HostEndpoint) (uapi string, changes []provider.EndpointChange, err error) {
+	var (
+		b    strings.Builder
+		errs []error
+	)
+	for i, he := range hosts {
+		addr, err := reresolveEndpoint(ctx, resolver, he.Endpoint, he.Addr)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if addr == he.Addr {
+			continue
+		}
+		fmt.Fprintf(&b, "public_key=%s\nupdate_only=true\nendpoint=%s\n", he.PublicKey, addr)
+		changes = append(changes, provider.EndpointChange{Old: he.Addr, New: addr})
+		hosts[i].Addr = addr
+	}
+	return b.String(), changes, errors.Join(errs...)
+}
+
 // ---------------------------------------------------------------------------
 // RawForwarder implementation — raw IP forwarding bypassing gVisor
 // ---------------------------------------------------------------------------
@@ -272,3 +399,45 @@ func (p *Provider) IpcGet() (string, error) {
 	}
 	return dev.IpcGet()
 }
+
+// ---------------------------------------------------------------------------
+// MultiPeerProvider implementation — per-peer routing and failover
+// ---------------------------------------------------------------------------
+
+// Peers returns per-peer routing state and statistics.
+func (p *Provider) Peers() ([]provider.PeerInfo, error) {
+	p.mu.RLock()
+	dev := p.dev
+	peers := p.peers
+	p.mu.RUnlock()
+	if dev == nil {
+		return nil, fmt.Errorf("[AWG] tunnel %q is not up", p.name)
+	}
+	ipc, err := dev.IpcGet()
+	if err != nil {
+		return nil, fmt.Errorf("[AWG] get peers: %w", err)
+	}
+	return peers.PeerInfo(ipc), nil
+}
+
+// PinPeer routes dst through the selected peer.
+func (p *Provider) PinPeer(sel string, dst netip.Addr) error {
+	p.mu.RLock()
+	peers := p.peers
+	p.mu.RUnlock()
+	if peers == nil {
+		return fmt.Errorf("[AWG] tunnel %q is not up", p.name)
+	}
+	return peers.Pin(sel, dst)
+}
+
+// SetStalePeers re-routes prefixes away from stale peers (hex public keys).
+func (p *Provider) SetStalePeers(publicKeys []string) (bool, error) {
+	p.mu.RLock()
+	peers := p.peers
+	p.mu.RUnlock()
+	if peers == nil {
+		return false, nil
+	}
+	return peers.SetStale(publicKeys)
+}
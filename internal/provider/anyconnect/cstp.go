@@ -168,24 +168,24 @@ func parseTunnelHeaders(h http.Header) (*tunnelParams, error) {
 
 	// IPv4 split routes.
 	for _, v := range h.Values("X-CSTP-Split-Include") {
-		if pfx, ok := parseRouteEntry(v); ok {
+		if pfx, ok := ParseRouteEntry(v); ok {
 			p.SplitInclude = append(p.SplitInclude, pfx)
 		}
 	}
 	for _, v := range h.Values("X-CSTP-Split-Exclude") {
-		if pfx, ok := parseRouteEntry(v); ok {
+		if pfx, ok := ParseRouteEntry(v); ok {
 			p.SplitExclude = append(p.SplitExclude, pfx)
 		}
 	}
 
 	// IPv6 split routes.
 	for _, v := range h.Values("X-CSTP-Split-Include-IP6") {
-		if pfx, ok := parseRouteEntry(v); ok {
+		if pfx, ok := ParseRouteEntry(v); ok {
 			p.SplitIncludeV6 = append(p.SplitIncludeV6, pfx)
 		}
 	}
 	for _, v := range h.Values("X-CSTP-Split-Exclude-IP6") {
-		if pfx, ok := parseRouteEntry(v); ok {
+		if pfx, ok := ParseRouteEntry(v); ok {
 			p.SplitExcludeV6 = append(p.SplitExcludeV6, pfx)
 		}
 	}
@@ -193,9 +193,9 @@ func parseTunnelHeaders(h http.Header) (*tunnelParams, error) {
 	return p, nil
 }
 
-// parseRouteEntry parses "ip/mask" where mask is dotted-decimal (e.g. "10.0.0.0/255.0.0.0")
+// ParseRouteEntry parses "ip/mask" where mask is dotted-decimal (e.g. "10.0.0.0/255.0.0.0")
 // or CIDR notation (e.g. "10.0.0.0/8").
-func parseRouteEntry(s string) (netip.Prefix, bool) {
+func ParseRouteEntry(s string) (netip.Prefix, bool) {
 	s = strings.TrimSpace(s)
 
 	// Try CIDR first.
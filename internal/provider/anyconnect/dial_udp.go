@@ -5,6 +5,7 @@ import (
 	"encoding/binary"
 	"fmt"
 	"net"
+	"net/netip"
 	"os"
 	"strconv"
 	"sync"
@@ -12,10 +13,27 @@ import (
 	"time"
 )
 
-// rawUDPConn implements net.Conn for UDP-over-raw-IP through the CSTP tunnel.
-// Used by the DNS resolver to forward queries through AnyConnect.
+// RawUDP provides DialUDP for providers that forward raw IP packets: datagrams
+// are wrapped in IPv4/UDP headers sourced from the tunnel address and sent with
+// send; replies are picked out of the inbound packet stream by Intercept.
+// Used by the DNS resolver to forward queries through the tunnel.
+// Shared with the GlobalProtect and Fortinet providers.
+type RawUDP struct {
+	send func(pkt []byte) bool
+
+	// pending tracks virtual UDP connections created by Dial.
+	// Key: local ephemeral port, Value: *rawUDPConn.
+	pending sync.Map
+}
+
+// NewRawUDP creates a RawUDP that sends packets into the tunnel with send.
+func NewRawUDP(send func(pkt []byte) bool) *RawUDP {
+	return &RawUDP{send: send}
+}
+
+// rawUDPConn implements net.Conn for UDP-over-raw-IP through the tunnel.
 type rawUDPConn struct {
-	p          *Provider
+	udp        *RawUDP
 	srcIP      [4]byte
 	dstIP      [4]byte
 	localPort  uint16
@@ -44,51 +62,65 @@ func allocEphemeralPort() uint16 {
 
 // DialUDP creates a virtual UDP connection tunneled through CSTP at the raw IP level.
 func (p *Provider) DialUDP(_ context.Context, addr string) (net.Conn, error) {
+	p.mu.RLock()
+	c := p.cstp
+	adapterIP := p.adapterIP
+	p.mu.RUnlock()
+
+	if c == nil {
+		return nil, fmt.Errorf("anyconnect: not connected")
+	}
+	conn, err := p.rawUDP.Dial(addr, adapterIP)
+	if err != nil {
+		return nil, fmt.Errorf("anyconnect: %w", err)
+	}
+	return conn, nil
+}
+
+// sendRaw sends a packet built by RawUDP over CSTP.
+func (p *Provider) sendRaw(pkt []byte) bool {
+	p.mu.RLock()
+	c := p.cstp
+	p.mu.RUnlock()
+	return c != nil && c.sendData(pkt)
+}
+
+// Dial creates a virtual UDP connection to addr (an IPv4 literal) from the
+// tunnel address src.
+func (u *RawUDP) Dial(addr string, src netip.Addr) (net.Conn, error) {
 	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
-		return nil, fmt.Errorf("anyconnect: parse addr %q: %w", addr, err)
+		return nil, fmt.Errorf("parse addr %q: %w", addr, err)
 	}
 	port, err := strconv.Atoi(portStr)
 	if err != nil || port <= 0 || port > 65535 {
-		return nil, fmt.Errorf("anyconnect: invalid port %q", portStr)
+		return nil, fmt.Errorf("invalid port %q", portStr)
 	}
 
 	ip := net.ParseIP(host)
 	if ip == nil {
-		return nil, fmt.Errorf("anyconnect: invalid IP %q", host)
+		return nil, fmt.Errorf("invalid IP %q", host)
 	}
 	ip4 := ip.To4()
 	if ip4 == nil {
 		// IPv6 DialUDP is not yet supported (raw IPv6 packet construction needed).
-		return nil, fmt.Errorf("anyconnect: IPv6 DialUDP not yet supported")
+		return nil, fmt.Errorf("IPv6 DialUDP not yet supported")
 	}
-
-	p.mu.RLock()
-	c := p.cstp
-	adapterIP := p.adapterIP
-	p.mu.RUnlock()
-
-	if c == nil {
-		return nil, fmt.Errorf("anyconnect: not connected")
-	}
-	if !adapterIP.IsValid() {
-		return nil, fmt.Errorf("anyconnect: no adapter IP")
+	if !src.Is4() {
+		return nil, fmt.Errorf("no adapter IP")
 	}
 
-	srcIP := adapterIP.As4()
-	dstIP := [4]byte{ip4[0], ip4[1], ip4[2], ip4[3]}
 	localPort := allocEphemeralPort()
-
 	conn := &rawUDPConn{
-		p:          p,
-		srcIP:      srcIP,
-		dstIP:      dstIP,
+		udp:        u,
+		srcIP:      src.As4(),
+		dstIP:      [4]byte{ip4[0], ip4[1], ip4[2], ip4[3]},
 		localPort:  localPort,
 		remotePort: uint16(port),
 		respCh:     make(chan []byte, 4),
 	}
 
-	p.pendingUDP.Store(localPort, conn)
+	u.pending.Store(localPort, conn)
 	return conn, nil
 }
 
@@ -98,16 +130,8 @@ func (c *rawUDPConn) Write(b []byte) (int, error) {
 	}
 
 	pkt := buildRawUDPPacket(c.srcIP, c.dstIP, c.localPort, c.remotePort, b)
-
-	c.p.mu.RLock()
-	cstp := c.p.cstp
-	c.p.mu.RUnlock()
-
-	if cstp == nil {
-		return 0, fmt.Errorf("anyconnect: not connected")
-	}
-	if !cstp.sendData(pkt) {
-		return 0, fmt.Errorf("anyconnect: send failed")
+	if !c.udp.send(pkt) {
+		return 0, fmt.Errorf("tunnel send failed")
 	}
 	return len(b), nil
 }
@@ -145,7 +169,7 @@ func (c *rawUDPConn) Read(b []byte) (int, error) {
 
 func (c *rawUDPConn) Close() error {
 	if c.closed.CompareAndSwap(false, true) {
-		c.p.pendingUDP.Delete(c.localPort)
+		c.udp.pending.Delete(c.localPort)
 	}
 	return nil
 }
@@ -172,9 +196,9 @@ func (c *rawUDPConn) SetWriteDeadline(_ time.Time) error {
 	return nil
 }
 
-// interceptInbound checks if an inbound IP packet is a UDP response matching
-// a pending DialUDP connection. Returns true if the packet was consumed.
-func (p *Provider) interceptInbound(pkt []byte) bool {
+// Intercept checks if an inbound IP packet is a UDP response matching
+// a pending Dial connection. Returns true if the packet was consumed.
+func (u *RawUDP) Intercept(pkt []byte) bool {
 	if len(pkt) < 28 { // min IP(20) + UDP(8)
 		return false
 	}
@@ -192,7 +216,7 @@ func (p *Provider) interceptInbound(pkt []byte) bool {
 	srcPort := binary.BigEndian.Uint16(pkt[ihl : ihl+2])
 	dstPort := binary.BigEndian.Uint16(pkt[ihl+2 : ihl+4])
 
-	val, ok := p.pendingUDP.Load(dstPort)
+	val, ok := u.pending.Load(dstPort)
 	if !ok {
 		return false
 	}
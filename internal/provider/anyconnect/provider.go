@@ -25,17 +25,24 @@ import (
 	"golang.org/x/crypto/pkcs12"
 )
 
-// loadClientCert loads a TLS client certificate from the configured path.
+// loadClientCert loads the TLS client certificate configured for the provider.
+func loadClientCert(cfg Config) ([]tls.Certificate, error) {
+	return LoadClientCert(cfg.ClientCert, cfg.ClientKey, cfg.ClientCertPassword)
+}
+
+// LoadClientCert loads a TLS client certificate from certPath ("auto" = system store).
 // Supported formats:
 //   - .p12/.pfx   — PKCS12 bundle (cert + private key, optionally password-protected)
 //   - .cer/.crt/.der — DER/PEM certificate only; private key is looked up in the system store
-//   - .pem        — if ClientKey is set, loads cert+key as separate PEM files;
-//                    if ClientKey is empty, expects both cert and key blocks in one file
-func loadClientCert(cfg Config) ([]tls.Certificate, error) {
-	if cfg.ClientCert == "" {
+//   - .pem        — if keyPath is set, loads cert+key as separate PEM files;
+//                    if keyPath is empty, expects both cert and key blocks in one file
+//
+// Shared with the GlobalProtect and Fortinet providers.
+func LoadClientCert(certPath, keyPath, password string) ([]tls.Certificate, error) {
+	if certPath == "" {
 		return nil, nil
 	}
-	if cfg.ClientCert == "auto" {
+	if certPath == "auto" {
 		// Pre-load certificates from the system store at initialization time.
 		// This ensures they are available in tlsCfg.Certificates for servers that
 		// expect client certs without sending TLS CertificateRequest (Cisco ASA).
@@ -52,31 +59,31 @@ func loadClientCert(cfg Config) ([]tls.Certificate, error) {
 		return certs, nil
 	}
 
-	ext := strings.ToLower(filepath.Ext(cfg.ClientCert))
+	ext := strings.ToLower(filepath.Ext(certPath))
 
 	switch ext {
 	case ".p12", ".pfx":
-		return loadPKCS12Cert(cfg.ClientCert, cfg.ClientCertPassword)
+		return loadPKCS12Cert(certPath, password)
 
 	case ".cer", ".crt", ".der":
-		return loadCertWithSystemKey(cfg.ClientCert)
+		return loadCertWithSystemKey(certPath)
 
 	default:
 		// PEM format: single file (cert+key) or two separate files.
-		keyFile := cfg.ClientKey
+		keyFile := keyPath
 		if keyFile == "" {
 			// Try single PEM file containing both CERTIFICATE and PRIVATE KEY blocks.
-			keyFile = cfg.ClientCert
+			keyFile = certPath
 		}
-		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, keyFile)
+		cert, err := tls.LoadX509KeyPair(certPath, keyFile)
 		if err != nil {
-			if cfg.ClientKey == "" {
+			if keyPath == "" {
 				return nil, fmt.Errorf("load client cert from %s: %w (hint: file must contain both CERTIFICATE and PRIVATE KEY PEM blocks, "+
-					"or set client_key to a separate key file)", cfg.ClientCert, err)
+					"or set client_key to a separate key file)", certPath, err)
 			}
 			return nil, fmt.Errorf("load client cert: %w", err)
 		}
-		core.Log.Infof("AnyConnect", "Client certificate loaded from PEM %s", cfg.ClientCert)
+		core.Log.Infof("AnyConnect", "Client certificate loaded from PEM %s", certPath)
 		return []tls.Certificate{cert}, nil
 	}
 }
@@ -179,6 +186,7 @@ type Provider struct {
 	splitInclude    []netip.Prefix
 	splitExclude    []netip.Prefix
 	dns             []string
+	mtu             int
 
 	// authParams holds ephemeral auth params (e.g. otp_code) set at connect time.
 	// Not persisted to config. Cleared after each Connect attempt.
@@ -188,9 +196,8 @@ type Provider struct {
 	realNICIndex uint32
 	binder       platform.InterfaceBinder
 
-	// pendingUDP tracks virtual UDP connections created by DialUDP.
-	// Key: local ephemeral port, Value: *rawUDPConn.
-	pendingUDP sync.Map
+	// rawUDP carries DialUDP connections as raw IP packets over CSTP.
+	rawUDP *RawUDP
 
 	// onSessionDrop is called when the CSTP session drops unexpectedly.
 	// Set by the tunnel controller to propagate state changes.
@@ -228,13 +235,15 @@ func New(name string, cfg Config) (*Provider, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &Provider{
+	p := &Provider{
 		name:     name,
 		config:   cfg,
 		state:    core.TunnelStateDown,
 		cid:      resolveClientID(cfg.UserAgent),
 		tlsCerts: certs,
-	}, nil
+	}
+	p.rawUDP = NewRawUDP(p.sendRaw)
+	return p, nil
 }
 
 func (p *Provider) Name() string     { return p.name }
@@ -316,6 +325,13 @@ func (p *Provider) GetDNS() []string {
 	return p.dns
 }
 
+// MTU returns the tunnel MTU negotiated via X-CSTP-MTU.
+func (p *Provider) MTU() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.mtu
+}
+
 // HasSavedSession returns true if a session cookie is available for resumption.
 func (p *Provider) HasSavedSession() bool {
 	p.mu.RLock()
@@ -473,15 +489,6 @@ func (p *Provider) connectWithCookie(ctx context.Context, server string, port in
 
 // dialTLS performs DNS resolution and TLS dial, with optional proxy support.
 func (p *Provider) dialTLS(ctx context.Context, server string, port int, controlFn func(string, string, syscall.RawConn) error) (*tls.Conn, *bufio.Reader, bool, error) {
-	serverIP, err := p.resolveViaRealNIC(ctx, server, p.realNICIndex, controlFn)
-	if err != nil {
-		return nil, nil, false, fmt.Errorf("[AnyConnect] resolve %s: %w", server, err)
-	}
-	serverAddr := fmt.Sprintf("%s:%d", serverIP, port)
-	if ip, ok := netip.AddrFromSlice(net.ParseIP(serverIP)); ok {
-		p.serverEndpoints = []netip.AddrPort{netip.AddrPortFrom(ip, uint16(port))}
-	}
-
 	tlsCfg := &tls.Config{
 		InsecureSkipVerify: p.config.TLSSkipVerify,
 		ServerName:         server,
@@ -512,30 +519,63 @@ func (p *Provider) dialTLS(ctx context.Context, server string, port int, control
 		}
 	}
 
+	proxy := Proxy{URL: p.config.ProxyURL, Username: p.config.ProxyUsername, Password: p.config.ProxyPassword}
+	tlsConn, endpoint, err := DialTLS(ctx, server, port, tlsCfg, controlFn, proxy)
+	if endpoint.IsValid() {
+		p.serverEndpoints = []netip.AddrPort{endpoint}
+	}
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("[AnyConnect] %w", err)
+	}
+
+	br := bufio.NewReader(tlsConn)
+	return tlsConn, br, certSent, nil
+}
+
+// Proxy is an optional HTTP CONNECT proxy used to reach the VPN gateway.
+type Proxy struct {
+	URL      string // HTTP proxy URL (empty = direct)
+	Username string
+	Password string
+}
+
+// DialTLS resolves server via the real NIC (controlFn) and opens a TLS
+// connection to it, directly or through proxy. The returned endpoint is the
+// resolved server address for bypass routes; it is set even if the dial fails.
+// Shared with the GlobalProtect and Fortinet providers.
+func DialTLS(ctx context.Context, server string, port int, tlsCfg *tls.Config, controlFn func(string, string, syscall.RawConn) error, proxy Proxy) (*tls.Conn, netip.AddrPort, error) {
+	serverIP, err := ResolveViaRealNIC(ctx, server, controlFn)
+	if err != nil {
+		return nil, netip.AddrPort{}, fmt.Errorf("resolve %s: %w", server, err)
+	}
+	serverAddr := net.JoinHostPort(serverIP, strconv.Itoa(port))
+	var endpoint netip.AddrPort
+	if ip, ok := netip.AddrFromSlice(net.ParseIP(serverIP)); ok {
+		endpoint = netip.AddrPortFrom(ip.Unmap(), uint16(port))
+	}
+
 	var tlsConn *tls.Conn
 
-	if p.config.ProxyURL != "" {
+	if proxy.URL != "" {
 		// Dial through HTTP CONNECT proxy.
-		rawConn, err := dialViaProxy(ctx, serverAddr, p.config.ProxyURL, p.config.ProxyUsername, p.config.ProxyPassword, nil)
+		rawConn, err := DialViaProxy(ctx, serverAddr, proxy.URL, proxy.Username, proxy.Password, nil)
 		if err != nil {
-			return nil, nil, false, fmt.Errorf("[AnyConnect] proxy dial: %w", err)
+			return nil, endpoint, fmt.Errorf("proxy dial: %w", err)
 		}
 		tlsConn = tls.Client(rawConn, tlsCfg)
 		if err := tlsConn.HandshakeContext(ctx); err != nil {
 			rawConn.Close()
-			return nil, nil, false, fmt.Errorf("[AnyConnect] TLS handshake via proxy: %w", err)
+			return nil, endpoint, fmt.Errorf("TLS handshake via proxy: %w", err)
 		}
 	} else {
 		// Direct TLS dial.
 		dialer := &net.Dialer{Timeout: 15 * time.Second, Control: controlFn}
 		tlsConn, err = tls.DialWithDialer(dialer, "tcp", serverAddr, tlsCfg)
 		if err != nil {
-			return nil, nil, false, fmt.Errorf("[AnyConnect] TLS dial: %w", err)
+			return nil, endpoint, fmt.Errorf("TLS dial: %w", err)
 		}
 	}
-
-	br := bufio.NewReader(tlsConn)
-	return tlsConn, br, certSent, nil
+	return tlsConn, endpoint, nil
 }
 
 // establishAndRun sends the CSTP CONNECT, parses tunnel params, and starts I/O loops.
@@ -586,6 +626,7 @@ func (p *Provider) finalizeTunnel(tlsConn *tls.Conn, br *bufio.Reader, params *t
 		p.splitExclude = append(p.splitExclude, params.SplitExcludeV6...)
 	}
 	p.dns = params.DNS
+	p.mtu = params.MTU
 
 	// Start CSTP connection (read/write loops).
 	cctx, ccancel := context.WithCancel(context.Background())
@@ -738,7 +779,7 @@ func (p *Provider) InjectOutboundPriority(pkt []byte, _ byte) bool {
 // Wraps the handler with a DNS response interceptor for DialUDP connections.
 func (p *Provider) SetInboundHandler(handler func(pkt []byte) bool) {
 	wrapped := func(pkt []byte) bool {
-		if p.interceptInbound(pkt) {
+		if p.rawUDP.Intercept(pkt) {
 			return true
 		}
 		return handler(pkt)
@@ -852,16 +893,17 @@ func (p *Provider) sessionTimeoutLoop(sessionSeconds int) {
 	}
 }
 
-// resolveViaRealNIC resolves a hostname using a DNS resolver bound to the real NIC,
-// bypassing the TUN DNS resolver (FakeIP).
-func (p *Provider) resolveViaRealNIC(ctx context.Context, host string, realNICIndex uint32, controlFn func(string, string, syscall.RawConn) error) (string, error) {
+// ResolveViaRealNIC resolves a hostname using a DNS resolver bound to the real NIC
+// (via controlFn), bypassing the TUN DNS resolver (FakeIP).
+// Shared with the GlobalProtect and Fortinet providers.
+func ResolveViaRealNIC(ctx context.Context, host string, controlFn func(string, string, syscall.RawConn) error) (string, error) {
 	// If it's already an IP, return as-is.
 	if ip := net.ParseIP(host); ip != nil {
 		return host, nil
 	}
 
-	if realNICIndex > 0 {
-		core.Log.Debugf("AnyConnect", "Resolving %q via real NIC (index=%d)", host, realNICIndex)
+	if controlFn != nil {
+		core.Log.Debugf("AnyConnect", "Resolving %q via real NIC", host)
 	}
 
 	resolver := &net.Resolver{
@@ -14,9 +14,10 @@ import (
 	"awg-split-tunnel/internal/core"
 )
 
-// dialViaProxy establishes a TCP connection to target through an HTTP CONNECT proxy.
+// DialViaProxy establishes a TCP connection to target through an HTTP CONNECT proxy.
 // Returns a raw net.Conn tunneled through the proxy, ready for TLS handshake.
-func dialViaProxy(ctx context.Context, target string, proxyURL string, proxyUser, proxyPass string, controlFn func(string, string, net.Conn) error) (net.Conn, error) {
+// Shared with the GlobalProtect and Fortinet providers.
+func DialViaProxy(ctx context.Context, target string, proxyURL string, proxyUser, proxyPass string, controlFn func(string, string, net.Conn) error) (net.Conn, error) {
 	u, err := url.Parse(proxyURL)
 	if err != nil {
 		return nil, fmt.Errorf("parse proxy URL %q: %w", proxyURL, err)
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -14,22 +15,42 @@ import (
 	"awg-split-tunnel/internal/core"
 )
 
-// samlResult contains the result of a SAML authentication flow.
-type samlResult struct {
-	Cookie string
-	Err    error
-}
+// samlCookieKeys are the callback parameters/cookies that carry the
+// AnyConnect session token after SAML login.
+var samlCookieKeys = []string{"webvpn", "token", "session_token", "SAMLResponse"}
 
 // handleSAMLAuth performs browser-based SAML authentication.
 // It starts a local HTTP server, opens the SAML URL in the system browser,
 // and waits for the IdP to redirect back with the session token.
 func handleSAMLAuth(ctx context.Context, samlURL string, timeout time.Duration) (*sessionInfo, error) {
+	values, err := BrowserLogin(ctx, samlURL, "", samlCookieKeys, timeout)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range samlCookieKeys {
+		if v := values[key]; v != "" {
+			return &sessionInfo{Cookie: v}, nil
+		}
+	}
+	return nil, fmt.Errorf("SAML callback carried no session token")
+}
+
+// BrowserLogin opens loginURL in the system browser and waits for the login
+// flow to redirect to a local callback listener (listenAddr, default a random
+// 127.0.0.1 port, passed to the server as the "callback" query parameter).
+// It returns the values of the given keys found in the callback's query,
+// form data or cookies; at least one must be present.
+// Shared with the GlobalProtect and Fortinet providers.
+func BrowserLogin(ctx context.Context, loginURL, listenAddr string, keys []string, timeout time.Duration) (map[string]string, error) {
 	if timeout == 0 {
 		timeout = 120 * time.Second
 	}
+	if listenAddr == "" {
+		listenAddr = "127.0.0.1:0"
+	}
 
-	// Start local listener on random port.
-	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	// Start local callback listener.
+	listener, err := net.Listen("tcp", listenAddr)
 	if err != nil {
 		return nil, fmt.Errorf("start SAML callback listener: %w", err)
 	}
@@ -38,39 +59,34 @@ func handleSAMLAuth(ctx context.Context, samlURL string, timeout time.Duration)
 	callbackPort := listener.Addr().(*net.TCPAddr).Port
 	core.Log.Infof("AnyConnect", "SAML callback listener on port %d", callbackPort)
 
-	resultCh := make(chan samlResult, 1)
+	resultCh := make(chan map[string]string, 1)
 	var once sync.Once
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// Look for session token in query params or form data.
+		// Look for the result in query params, form data and cookies.
 		r.ParseForm()
 
-		cookie := ""
-		// Check common SAML callback parameters.
-		for _, key := range []string{"webvpn", "token", "session_token", "SAMLResponse"} {
+		values := make(map[string]string)
+		for _, key := range keys {
 			if v := r.FormValue(key); v != "" {
-				cookie = v
-				break
+				values[key] = v
 			}
 		}
-
-		// Also check cookies sent by the redirect.
 		for _, c := range r.Cookies() {
-			if c.Name == "webvpn" && cookie == "" {
-				cookie = c.Value
+			if _, ok := values[c.Name]; !ok && slices.Contains(keys, c.Name) && c.Value != "" {
+				values[c.Name] = c.Value
 			}
 		}
 
-		if cookie != "" {
-			w.Header().Set("Content-Type", "text/html")
+		w.Header().Set("Content-Type", "text/html")
+		if len(values) > 0 {
 			w.WriteHeader(http.StatusOK)
 			fmt.Fprint(w, `<html><body><h2>Authentication successful</h2><p>You can close this window.</p><script>window.close()</script></body></html>`)
 			once.Do(func() {
-				resultCh <- samlResult{Cookie: cookie}
+				resultCh <- values
 			})
 		} else {
-			w.Header().Set("Content-Type", "text/html")
 			w.WriteHeader(http.StatusBadRequest)
 			fmt.Fprint(w, `<html><body><h2>Missing session token</h2></body></html>`)
 		}
@@ -80,9 +96,9 @@ func handleSAMLAuth(ctx context.Context, samlURL string, timeout time.Duration)
 	go server.Serve(listener)
 	defer server.Close()
 
-	// Build SAML URL with callback.
+	// Build login URL with callback.
 	callbackURL := fmt.Sprintf("http://127.0.0.1:%d/", callbackPort)
-	fullURL := samlURL
+	fullURL := loginURL
 	if !strings.Contains(fullURL, "?") {
 		fullURL += "?"
 	} else {
@@ -91,7 +107,7 @@ func handleSAMLAuth(ctx context.Context, samlURL string, timeout time.Duration)
 	fullURL += "callback=" + callbackURL
 
 	// Open browser.
-	core.Log.Infof("AnyConnect", "Opening SAML URL in browser: %s", samlURL)
+	core.Log.Infof("AnyConnect", "Opening SAML URL in browser: %s", loginURL)
 	if err := openBrowser(fullURL); err != nil {
 		core.Log.Warnf("AnyConnect", "Failed to open browser: %v", err)
 		return nil, fmt.Errorf("open browser for SAML: %w (URL: %s)", err, fullURL)
@@ -102,12 +118,9 @@ func handleSAMLAuth(ctx context.Context, samlURL string, timeout time.Duration)
 	defer cancel()
 
 	select {
-	case result := <-resultCh:
-		if result.Err != nil {
-			return nil, result.Err
-		}
+	case values := <-resultCh:
 		core.Log.Infof("AnyConnect", "SAML authentication completed")
-		return &sessionInfo{Cookie: result.Cookie}, nil
+		return values, nil
 	case <-timeoutCtx.Done():
 		return nil, fmt.Errorf("SAML authentication timed out after %s", timeout)
 	}
@@ -0,0 +1,272 @@
+package fortinet
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// samlCallbackAddr is where FortiGate SAML logins redirect the browser
+// (the FortiClient local listener).
+const samlCallbackAddr = "127.0.0.1:8020"
+
+// cookieName is the FortiGate session cookie.
+const cookieName = "SVPNCOOKIE"
+
+// ---- XML structures for /remote/fortisslvpn_xml ----
+
+type tunnelConfigXML struct {
+	XMLName xml.Name `xml:"sslvpn-tunnel"`
+	IPv4    struct {
+		DNS []struct {
+			IP     string `xml:"ip,attr"`
+			Domain string `xml:"domain,attr"`
+		} `xml:"dns"`
+		AssignedAddr struct {
+			IPv4 string `xml:"ipv4,attr"`
+		} `xml:"assigned-addr"`
+		SplitTunnel []splitTunnelInfo `xml:"split-tunnel-info"`
+	} `xml:"ipv4"`
+	IdleTimeout struct {
+		Val int `xml:"val,attr"`
+	} `xml:"idle-timeout"`
+	AuthTimeout struct {
+		Val int `xml:"val,attr"`
+	} `xml:"auth-timeout"`
+}
+
+type splitTunnelInfo struct {
+	Negate string `xml:"negate,attr"`
+	Addrs  []struct {
+		IP   string `xml:"ip,attr"`
+		Mask string `xml:"mask,attr"`
+	} `xml:"addr"`
+}
+
+// tunnelConfig is the parsed tunnel configuration.
+type tunnelConfig struct {
+	Address      netip.Addr // assigned address (may be invalid: learned via IPCP)
+	DNS          []string
+	DNSSuffix    []string
+	SplitInclude []netip.Prefix
+	SplitExclude []netip.Prefix
+	IdleTimeout  int // seconds
+	AuthTimeout  int // seconds
+}
+
+func parseTunnelConfig(body []byte) (*tunnelConfig, error) {
+	var x tunnelConfigXML
+	if err := xml.Unmarshal(body, &x); err != nil {
+		return nil, fmt.Errorf("parse tunnel config: %w", err)
+	}
+	tc := &tunnelConfig{
+		IdleTimeout: x.IdleTimeout.Val,
+		AuthTimeout: x.AuthTimeout.Val,
+	}
+	if addr, err := netip.ParseAddr(x.IPv4.AssignedAddr.IPv4); err == nil {
+		tc.Address = addr
+	}
+	for _, d := range x.IPv4.DNS {
+		if d.IP != "" {
+			tc.DNS = append(tc.DNS, d.IP)
+		}
+		if d.Domain != "" {
+			tc.DNSSuffix = append(tc.DNSSuffix, d.Domain)
+		}
+	}
+	for _, st := range x.IPv4.SplitTunnel {
+		for _, a := range st.Addrs {
+			pfx, ok := anyconnect.ParseRouteEntry(a.IP + "/" + a.Mask)
+			if !ok {
+				continue
+			}
+			if st.Negate == "1" {
+				tc.SplitExclude = append(tc.SplitExclude, pfx.Masked())
+			} else if pfx.Bits() > 0 {
+				tc.SplitInclude = append(tc.SplitInclude, pfx.Masked())
+			}
+		}
+	}
+	return tc, nil
+}
+
+// credentials holds the login form values.
+type credentials struct {
+	Username string
+	Password string
+	Realm    string
+	OTPCode  string // one-shot second factor
+}
+
+// client performs FortiGate web API requests.
+type client struct {
+	http *http.Client
+	base url.URL
+}
+
+func newClient(hc *http.Client, host string, port int) *client {
+	return &client{
+		http: hc,
+		base: url.URL{Scheme: "https", Host: net.JoinHostPort(host, strconv.Itoa(port))},
+	}
+}
+
+// do sends a request and returns the response with its body read.
+func (c *client) do(ctx context.Context, method, path string, form url.Values, cookie string) (*http.Response, []byte, error) {
+	u := c.base
+	if i := strings.IndexByte(path, '?'); i >= 0 {
+		u.Path, u.RawQuery = path[:i], path[i+1:]
+	} else {
+		u.Path = path
+	}
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 SV1")
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: cookieName, Value: cookie})
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp, nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return resp, data, nil
+}
+
+// sessionCookie returns the SVPNCOOKIE set by resp, if any.
+func sessionCookie(resp *http.Response) string {
+	for _, c := range resp.Cookies() {
+		if c.Name == cookieName && c.Value != "" {
+			return c.Value
+		}
+	}
+	return ""
+}
+
+// parseRet parses a logincheck reply: comma-separated key=value pairs
+// ("ret=2,reqid=...,tokeninfo=...").
+func parseRet(body []byte) map[string]string {
+	m := make(map[string]string)
+	for _, kv := range strings.Split(strings.TrimSpace(string(body)), ",") {
+		k, v, _ := strings.Cut(kv, "=")
+		if k != "" {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// login authenticates with username/password and, when the FortiGate asks
+// for it, the one-shot token code. Returns the session cookie.
+func (c *client) login(ctx context.Context, creds *credentials) (string, error) {
+	form := url.Values{}
+	form.Set("ajax", "1")
+	form.Set("username", creds.Username)
+	form.Set("realm", creds.Realm)
+	form.Set("credential", creds.Password)
+
+	for round := 0; round < 3; round++ {
+		resp, body, err := c.do(ctx, http.MethodPost, "/remote/logincheck", form, "")
+		if err != nil {
+			return "", fmt.Errorf("logincheck: %w", err)
+		}
+		if cookie := sessionCookie(resp); cookie != "" {
+			return cookie, nil
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("logincheck: HTTP %d", resp.StatusCode)
+		}
+		ret := parseRet(body)
+		if ret["ret"] != "2" {
+			return "", fmt.Errorf("login failed (ret=%s)", ret["ret"])
+		}
+
+		// Second factor (FortiToken, email/SMS code).
+		msg := ret["chal_msg"]
+		if msg == "" {
+			msg = "token code required"
+		}
+		if creds.OTPCode == "" {
+			return "", fmt.Errorf("%w: %s", provider.ErrAuthRequired, msg)
+		}
+		core.Log.Infof("Fortinet", "Answering token challenge (%s)", ret["tokeninfo"])
+		form = url.Values{}
+		form.Set("ajax", "1")
+		form.Set("username", creds.Username)
+		form.Set("realm", creds.Realm)
+		form.Set("code", creds.OTPCode)
+		form.Set("code2", "")
+		for _, k := range []string{"reqid", "polid", "grp", "portal", "magic"} {
+			if v, ok := ret[k]; ok {
+				form.Set(k, v)
+			}
+		}
+		creds.OTPCode = ""
+	}
+	return "", fmt.Errorf("login: too many challenge rounds")
+}
+
+// samlLogin runs the SAML login in the system browser and exchanges the
+// returned id for a session cookie.
+func (c *client) samlLogin(ctx context.Context, realm string) (string, error) {
+	start := c.base
+	start.Path = "/remote/saml/start"
+	q := url.Values{}
+	q.Set("redirect", "1")
+	if realm != "" {
+		q.Set("realm", realm)
+	}
+	start.RawQuery = q.Encode()
+
+	core.Log.Infof("Fortinet", "SAML authentication, opening browser")
+	values, err := anyconnect.BrowserLogin(ctx, start.String(), samlCallbackAddr, []string{"id"}, 2*time.Minute)
+	if err != nil {
+		return "", fmt.Errorf("SAML: %w", err)
+	}
+	resp, _, err := c.do(ctx, http.MethodGet, "/remote/saml/auth_id?id="+url.QueryEscape(values["id"]), nil, "")
+	if err != nil {
+		return "", fmt.Errorf("SAML auth_id: %w", err)
+	}
+	cookie := sessionCookie(resp)
+	if cookie == "" {
+		return "", fmt.Errorf("SAML auth_id: no session cookie (HTTP %d)", resp.StatusCode)
+	}
+	return cookie, nil
+}
+
+// tunnelConfig fetches the tunnel configuration for a session.
+func (c *client) tunnelConfig(ctx context.Context, cookie string) (*tunnelConfig, error) {
+	resp, body, err := c.do(ctx, http.MethodGet, "/remote/fortisslvpn_xml", nil, cookie)
+	if err != nil {
+		return nil, fmt.Errorf("fortisslvpn_xml: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fortisslvpn_xml: HTTP %d", resp.StatusCode)
+	}
+	return parseTunnelConfig(body)
+}
@@ -0,0 +1,27 @@
+package fortinet
+
+// Config holds Fortinet SSL-VPN provider configuration parsed from TunnelConfig.Settings.
+// OTP codes are NOT stored here — they are passed at connect time via SetAuthParams.
+type Config struct {
+	Server        string // FortiGate hostname or IP
+	Port          int    // Server port (default 443)
+	Username      string // Login username
+	Password      string // Login password
+	Realm         string // Authentication realm (optional, the /remote/login?realm= part)
+	TLSSkipVerify bool   // Skip TLS certificate verification
+
+	// SAML authenticates in the system browser instead of username/password.
+	// The FortiGate must redirect to the FortiClient callback (127.0.0.1:8020).
+	SAML bool
+
+	// Client certificate for mutual TLS authentication (same formats as AnyConnect:
+	// "auto", .p12/.pfx, .cer/.crt/.der or .pem).
+	ClientCert         string
+	ClientKey          string
+	ClientCertPassword string
+
+	// Proxy settings for connecting through HTTP CONNECT proxy.
+	ProxyURL      string
+	ProxyUsername string
+	ProxyPassword string
+}
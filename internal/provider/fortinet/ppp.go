@@ -0,0 +1,252 @@
+package fortinet
+
+import (
+	"encoding/binary"
+	"net/netip"
+)
+
+// PPP protocol numbers.
+const (
+	pppIPv4 uint16 = 0x0021
+	pppIPv6 uint16 = 0x0057
+	pppIPCP uint16 = 0x8021
+	pppLCP  uint16 = 0xc021
+)
+
+// LCP/IPCP packet codes (RFC 1661 §5).
+const (
+	codeConfigureRequest = 1
+	codeConfigureAck     = 2
+	codeConfigureNak     = 3
+	codeConfigureReject  = 4
+	codeTerminateRequest = 5
+	codeTerminateAck     = 6
+	codeProtocolReject   = 8
+	codeEchoRequest      = 9
+	codeEchoReply        = 10
+	codeDiscardRequest   = 11
+)
+
+// LCP options.
+const (
+	lcpOptMRU   = 1
+	lcpOptACCM  = 2
+	lcpOptMagic = 5
+)
+
+// IPCP options (RFC 1332, RFC 1877).
+const (
+	ipcpOptAddress = 3
+	ipcpOptDNS1    = 129
+	ipcpOptDNS2    = 131
+)
+
+// pppOption is one Type-Length-Value configuration option.
+type pppOption struct {
+	typ  byte
+	data []byte
+}
+
+// parseOptions splits configuration options; ok is false if they are malformed.
+func parseOptions(b []byte) (opts []pppOption, ok bool) {
+	for len(b) > 0 {
+		if len(b) < 2 || int(b[1]) < 2 || int(b[1]) > len(b) {
+			return nil, false
+		}
+		opts = append(opts, pppOption{typ: b[0], data: b[2:b[1]]})
+		b = b[b[1]:]
+	}
+	return opts, true
+}
+
+func encodeOptions(opts []pppOption) []byte {
+	var b []byte
+	for _, o := range opts {
+		b = append(b, o.typ, byte(2+len(o.data)))
+		b = append(b, o.data...)
+	}
+	return b
+}
+
+// controlPacket builds an LCP/IPCP packet: code, id, length, data.
+func controlPacket(code, id byte, data []byte) []byte {
+	b := make([]byte, 4+len(data))
+	b[0] = code
+	b[1] = id
+	binary.BigEndian.PutUint16(b[2:4], uint16(len(b)))
+	copy(b[4:], data)
+	return b
+}
+
+// parseControl splits an LCP/IPCP packet.
+func parseControl(b []byte) (code, id byte, data []byte, ok bool) {
+	if len(b) < 4 {
+		return 0, 0, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(b[2:4]))
+	if n < 4 || n > len(b) {
+		return 0, 0, nil, false
+	}
+	return b[0], b[1], b[4:n], true
+}
+
+// pppFrame prefixes info with the HDLC address/control bytes and protocol.
+func pppFrame(proto uint16, info []byte) []byte {
+	b := make([]byte, 4+len(info))
+	b[0], b[1] = 0xff, 0x03
+	binary.BigEndian.PutUint16(b[2:4], proto)
+	copy(b[4:], info)
+	return b
+}
+
+// parsePPPFrame returns the protocol and information field of a frame,
+// accepting compressed address/control and protocol fields.
+func parsePPPFrame(b []byte) (uint16, []byte, bool) {
+	if len(b) >= 2 && b[0] == 0xff && b[1] == 0x03 {
+		b = b[2:]
+	}
+	if len(b) == 0 {
+		return 0, nil, false
+	}
+	if b[0]&1 == 1 {
+		// Protocol-Field-Compression: single odd byte.
+		return uint16(b[0]), b[1:], true
+	}
+	if len(b) < 2 {
+		return 0, nil, false
+	}
+	return binary.BigEndian.Uint16(b[0:2]), b[2:], true
+}
+
+// pppState is the LCP and IPCP negotiation state of the client.
+type pppState struct {
+	id    byte
+	magic uint32
+	mru   uint16
+
+	lcpAckSent, lcpAckRcvd   bool
+	ipcpAckSent, ipcpAckRcvd bool
+	ipcpStarted              bool
+	lcpBare                  bool // peer rejected our LCP options
+
+	// Requested IPCP values; refined by Configure-Nak, dropped on Reject.
+	addr       netip.Addr
+	dns1, dns2 netip.Addr
+	rejectDNS  bool
+}
+
+func (s *pppState) nextID() byte {
+	s.id++
+	return s.id
+}
+
+func (s *pppState) lcpOpen() bool  { return s.lcpAckSent && s.lcpAckRcvd }
+func (s *pppState) ipcpOpen() bool { return s.ipcpAckSent && s.ipcpAckRcvd }
+
+// lcpRequest is our LCP Configure-Request.
+func (s *pppState) lcpRequest() []byte {
+	mru := make([]byte, 2)
+	binary.BigEndian.PutUint16(mru, s.mru)
+	magic := make([]byte, 4)
+	binary.BigEndian.PutUint32(magic, s.magic)
+	opts := []pppOption{{lcpOptMRU, mru}, {lcpOptMagic, magic}}
+	if s.lcpBare {
+		opts = nil
+	}
+	return controlPacket(codeConfigureRequest, s.nextID(), encodeOptions(opts))
+}
+
+// ipcpRequest is our IPCP Configure-Request.
+func (s *pppState) ipcpRequest() []byte {
+	addr4 := func(a netip.Addr) []byte {
+		if !a.Is4() {
+			return make([]byte, 4)
+		}
+		b := a.As4()
+		return b[:]
+	}
+	opts := []pppOption{{ipcpOptAddress, addr4(s.addr)}}
+	if !s.rejectDNS {
+		opts = append(opts, pppOption{ipcpOptDNS1, addr4(s.dns1)}, pppOption{ipcpOptDNS2, addr4(s.dns2)})
+	}
+	return controlPacket(codeConfigureRequest, s.nextID(), encodeOptions(opts))
+}
+
+// peerLCPRequest answers the peer's LCP Configure-Request: Ack if every
+// option is understood, otherwise Reject the rest.
+func peerLCPRequest(id byte, data []byte) []byte {
+	opts, ok := parseOptions(data)
+	if !ok {
+		return nil
+	}
+	var rejected []pppOption
+	for _, o := range opts {
+		switch o.typ {
+		case lcpOptMRU, lcpOptACCM, lcpOptMagic:
+		default:
+			rejected = append(rejected, o)
+		}
+	}
+	if len(rejected) > 0 {
+		return controlPacket(codeConfigureReject, id, encodeOptions(rejected))
+	}
+	return controlPacket(codeConfigureAck, id, data)
+}
+
+// peerIPCPRequest answers the peer's IPCP Configure-Request (its own address).
+func peerIPCPRequest(id byte, data []byte) []byte {
+	opts, ok := parseOptions(data)
+	if !ok {
+		return nil
+	}
+	var rejected []pppOption
+	for _, o := range opts {
+		if o.typ != ipcpOptAddress {
+			rejected = append(rejected, o)
+		}
+	}
+	if len(rejected) > 0 {
+		return controlPacket(codeConfigureReject, id, encodeOptions(rejected))
+	}
+	return controlPacket(codeConfigureAck, id, data)
+}
+
+// applyIPCPNak adopts the values suggested by the peer.
+func (s *pppState) applyIPCPNak(data []byte) {
+	opts, _ := parseOptions(data)
+	for _, o := range opts {
+		if len(o.data) != 4 {
+			continue
+		}
+		a := netip.AddrFrom4([4]byte(o.data))
+		switch o.typ {
+		case ipcpOptAddress:
+			s.addr = a
+		case ipcpOptDNS1:
+			s.dns1 = a
+		case ipcpOptDNS2:
+			s.dns2 = a
+		}
+	}
+}
+
+// applyIPCPReject stops requesting rejected options (DNS only; the address
+// is mandatory).
+func (s *pppState) applyIPCPReject(data []byte) {
+	opts, _ := parseOptions(data)
+	for _, o := range opts {
+		if o.typ == ipcpOptDNS1 || o.typ == ipcpOptDNS2 {
+			s.rejectDNS = true
+		}
+	}
+}
+
+// applyLCPNak adopts the MRU suggested by the peer.
+func (s *pppState) applyLCPNak(data []byte) {
+	opts, _ := parseOptions(data)
+	for _, o := range opts {
+		if o.typ == lcpOptMRU && len(o.data) == 2 {
+			s.mru = binary.BigEndian.Uint16(o.data)
+		}
+	}
+}
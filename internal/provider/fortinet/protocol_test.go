@@ -0,0 +1,114 @@
+package fortinet
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestParseTunnelConfig(t *testing.T) {
+	body := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<sslvpn-tunnel ver="2" dtls="1" patch="1">
+  <dtls-config heartbeat-interval="10" heartbeat-fail-count="10" heartbeat-idle-timeout="10" client-hello-timeout="10"/>
+  <tunnel-method value="ppp"/>
+  <auth-timeout val="28800"/>
+  <idle-timeout val="300"/>
+  <ipv4>
+    <dns ip="10.0.0.53"/>
+    <dns domain="corp.example.com"/>
+    <assigned-addr ipv4="10.212.134.200"/>
+    <split-tunnel-info>
+      <addr ip="10.0.0.0" mask="255.0.0.0"/>
+      <addr ip="0.0.0.0" mask="0.0.0.0"/>
+    </split-tunnel-info>
+    <split-tunnel-info negate="1">
+      <addr ip="10.9.0.0" mask="255.255.0.0"/>
+    </split-tunnel-info>
+  </ipv4>
+</sslvpn-tunnel>`)
+	tc, err := parseTunnelConfig(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if tc.Address != netip.MustParseAddr("10.212.134.200") {
+		t.Errorf("address = %v", tc.Address)
+	}
+	if len(tc.DNS) != 1 || tc.DNS[0] != "10.0.0.53" || len(tc.DNSSuffix) != 1 {
+		t.Errorf("dns = %v suffix = %v", tc.DNS, tc.DNSSuffix)
+	}
+	if len(tc.SplitInclude) != 1 || tc.SplitInclude[0] != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("include = %v", tc.SplitInclude)
+	}
+	if len(tc.SplitExclude) != 1 || tc.SplitExclude[0] != netip.MustParsePrefix("10.9.0.0/16") {
+		t.Errorf("exclude = %v", tc.SplitExclude)
+	}
+	if tc.IdleTimeout != 300 || tc.AuthTimeout != 28800 {
+		t.Errorf("timeouts = %d/%d", tc.IdleTimeout, tc.AuthTimeout)
+	}
+}
+
+func TestParseRet(t *testing.T) {
+	m := parseRet([]byte("ret=2,reqid=123,polid=1-1-456,grp=,portal=full,magic=1-789,tokeninfo=ftm_push,chal_msg=Please enter FortiToken code\n"))
+	if m["ret"] != "2" || m["reqid"] != "123" || m["magic"] != "1-789" || m["chal_msg"] != "Please enter FortiToken code" {
+		t.Errorf("parseRet = %v", m)
+	}
+	if _, ok := m["grp"]; !ok {
+		t.Error("empty grp dropped")
+	}
+}
+
+func TestPPPFraming(t *testing.T) {
+	info := []byte{0x45, 0, 0, 20}
+	proto, got, ok := parsePPPFrame(pppFrame(pppIPv4, info))
+	if !ok || proto != pppIPv4 || !bytes.Equal(got, info) {
+		t.Fatalf("full frame: proto=%#x info=%x ok=%v", proto, got, ok)
+	}
+	// Address/control and protocol field compression.
+	proto, got, ok = parsePPPFrame(append([]byte{0x21}, info...))
+	if !ok || proto != pppIPv4 || !bytes.Equal(got, info) {
+		t.Fatalf("compressed frame: proto=%#x info=%x ok=%v", proto, got, ok)
+	}
+
+	if _, ok := parseOptions([]byte{lcpOptMRU, 4, 0x05}); ok {
+		t.Error("truncated option accepted")
+	}
+}
+
+func TestPeerLCPRequest(t *testing.T) {
+	opts := encodeOptions([]pppOption{
+		{lcpOptMRU, []byte{0x05, 0xdc}},
+		{lcpOptMagic, []byte{1, 2, 3, 4}},
+		{3, []byte{0xc0, 0x23}}, // Authentication-Protocol: PAP
+	})
+	code, id, data, ok := parseControl(peerLCPRequest(9, opts))
+	if !ok || code != codeConfigureReject || id != 9 {
+		t.Fatalf("code=%d id=%d ok=%v", code, id, ok)
+	}
+	rej, _ := parseOptions(data)
+	if len(rej) != 1 || rej[0].typ != 3 {
+		t.Errorf("rejected = %+v", rej)
+	}
+
+	code, _, data, _ = parseControl(peerLCPRequest(10, opts[:10]))
+	if code != codeConfigureAck || !bytes.Equal(data, opts[:10]) {
+		t.Errorf("expected ack of MRU+magic, got code=%d data=%x", code, data)
+	}
+}
+
+func TestIPCPNak(t *testing.T) {
+	s := &pppState{}
+	nak := encodeOptions([]pppOption{
+		{ipcpOptAddress, []byte{10, 212, 134, 200}},
+		{ipcpOptDNS1, []byte{10, 0, 0, 53}},
+	})
+	s.applyIPCPNak(nak)
+	if s.addr != netip.MustParseAddr("10.212.134.200") || s.dns1 != netip.MustParseAddr("10.0.0.53") {
+		t.Errorf("addr=%v dns1=%v", s.addr, s.dns1)
+	}
+	s.applyIPCPReject(encodeOptions([]pppOption{{ipcpOptDNS2, make([]byte, 4)}}))
+	_, _, data, _ := parseControl(s.ipcpRequest())
+	opts, _ := parseOptions(data)
+	if !s.rejectDNS || len(opts) != 1 || opts[0].typ != ipcpOptAddress {
+		t.Errorf("request after DNS reject = %+v", opts)
+	}
+}
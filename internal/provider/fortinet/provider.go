@@ -0,0 +1,478 @@
+package fortinet
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/platform"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// Provider implements provider.TunnelProvider, provider.RawForwarder,
+// provider.EndpointProvider, provider.SplitRouteProvider, provider.DNSProvider
+// and provider.AuthParamSetter for the FortiGate SSL-VPN (PPP over TLS).
+type Provider struct {
+	mu     sync.RWMutex
+	config Config
+	state  core.TunnelState
+	name   string
+
+	tlsCerts []tls.Certificate // client certificate for mutual TLS (optional)
+
+	adapterIP       netip.Addr
+	serverEndpoints []netip.AddrPort
+	splitInclude    []netip.Prefix
+	splitExclude    []netip.Prefix
+	dns             []string
+
+	// authParams holds ephemeral auth params (otp_code, svpn_cookie) set at
+	// connect time. Cleared after each Connect attempt.
+	authParams map[string]string
+
+	// Real NIC info for bypassing TUN DNS and routing.
+	realNICIndex uint32
+	binder       platform.InterfaceBinder
+
+	// rawUDP carries DialUDP connections as raw IP packets over PPP.
+	rawUDP *anyconnect.RawUDP
+
+	// onSessionDrop is called when the tunnel drops unexpectedly.
+	onSessionDrop func(tunnelID string, err error)
+
+	eventBus *core.EventBus
+
+	// Session resumption: saved SVPNCOOKIE for reconnect.
+	savedCookie string
+
+	// Network roaming: debounce rapid network change events.
+	networkChangeMu   sync.Mutex
+	networkChangeTime time.Time
+
+	ppp            *pppConn
+	inboundHandler *func(pkt []byte) bool
+}
+
+// New creates a new Fortinet SSL-VPN provider.
+func New(name string, cfg Config) (*Provider, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("fortinet: server is required")
+	}
+	if cfg.Port <= 0 {
+		cfg.Port = 443
+	}
+	certs, err := anyconnect.LoadClientCert(cfg.ClientCert, cfg.ClientKey, cfg.ClientCertPassword)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		name:     name,
+		config:   cfg,
+		state:    core.TunnelStateDown,
+		tlsCerts: certs,
+	}
+	p.rawUDP = anyconnect.NewRawUDP(p.sendData)
+	return p, nil
+}
+
+func (p *Provider) Name() string     { return p.name }
+func (p *Provider) Protocol() string { return core.ProtocolFortinet }
+
+// SetAuthParams implements provider.AuthParamSetter.
+// Accepts otp_code, and svpn_cookie to reuse a session from a browser login.
+func (p *Provider) SetAuthParams(params map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.authParams = params
+}
+
+// SetRealNICIndex sets the real NIC interface index for DNS resolution bypass.
+func (p *Provider) SetRealNICIndex(index uint32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.realNICIndex = index
+}
+
+// SetInterfaceBinder sets the platform-specific interface binder.
+func (p *Provider) SetInterfaceBinder(binder platform.InterfaceBinder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.binder = binder
+}
+
+// SetOnSessionDrop sets a callback invoked when the tunnel drops unexpectedly.
+func (p *Provider) SetOnSessionDrop(fn func(tunnelID string, err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onSessionDrop = fn
+}
+
+// SetEventBus sets the event bus for publishing tunnel events.
+func (p *Provider) SetEventBus(bus *core.EventBus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.eventBus = bus
+}
+
+func (p *Provider) State() core.TunnelState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.state
+}
+
+func (p *Provider) GetAdapterIP() netip.Addr {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.adapterIP
+}
+
+// GetServerEndpoints implements provider.EndpointProvider.
+func (p *Provider) GetServerEndpoints() []netip.AddrPort {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.serverEndpoints
+}
+
+// GetSplitInclude returns the split-tunnel routes pushed by the FortiGate.
+func (p *Provider) GetSplitInclude() []netip.Prefix {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.splitInclude
+}
+
+// GetSplitExclude returns the negated split-tunnel routes.
+func (p *Provider) GetSplitExclude() []netip.Prefix {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.splitExclude
+}
+
+// GetDNS returns DNS servers assigned by the FortiGate.
+func (p *Provider) GetDNS() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.dns
+}
+
+// HasSavedSession returns true if a session cookie is available for resumption.
+func (p *Provider) HasSavedSession() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.savedCookie != ""
+}
+
+// ClearSession clears the saved session cookie, forcing full re-auth on next connect.
+func (p *Provider) ClearSession() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.savedCookie = ""
+}
+
+// Connect authenticates and establishes the Fortinet tunnel.
+func (p *Provider) Connect(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = core.TunnelStateConnecting
+	core.Log.Infof("Fortinet", "Connecting tunnel %q to %s:%d...", p.name, p.config.Server, p.config.Port)
+
+	var controlFn func(string, string, syscall.RawConn) error
+	if p.binder != nil && p.realNICIndex > 0 {
+		controlFn = p.binder.BindControl(p.realNICIndex)
+	}
+
+	// Consume one-shot auth params early so they are never reused.
+	params := p.authParams
+	p.authParams = nil
+	creds := credentials{
+		Username: p.config.Username,
+		Password: p.config.Password,
+		Realm:    p.config.Realm,
+		OTPCode:  params["otp_code"],
+	}
+
+	hc := p.newHTTPClient(controlFn)
+	defer hc.CloseIdleConnections()
+	c := newClient(hc, p.config.Server, p.config.Port)
+
+	// Try session resumption first if we have a saved cookie.
+	if p.savedCookie != "" {
+		core.Log.Infof("Fortinet", "Attempting session resumption for %q", p.name)
+		if p.eventBus != nil {
+			p.eventBus.PublishAsync(core.Event{
+				Type:    core.EventTunnelResuming,
+				Payload: core.TunnelStatePayload{TunnelID: p.name},
+			})
+		}
+		err := p.establish(ctx, c, p.savedCookie, controlFn)
+		if err == nil {
+			core.Log.Infof("Fortinet", "Session resumed successfully for %q", p.name)
+			return nil
+		}
+		core.Log.Warnf("Fortinet", "Session resumption failed: %v, falling back to full auth", err)
+		p.savedCookie = ""
+	}
+
+	var cookie string
+	var err error
+	switch {
+	case params["svpn_cookie"] != "":
+		cookie = params["svpn_cookie"]
+	case p.config.SAML:
+		cookie, err = c.samlLogin(ctx, p.config.Realm)
+	default:
+		core.Log.Infof("Fortinet", "Authenticating as %q on %s...", creds.Username, p.config.Server)
+		cookie, err = c.login(ctx, &creds)
+	}
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[Fortinet] auth: %w", err)
+	}
+	core.Log.Infof("Fortinet", "Authentication successful")
+	p.savedCookie = cookie
+
+	if err := p.establish(ctx, c, cookie, controlFn); err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[Fortinet] tunnel: %w", err)
+	}
+	return nil
+}
+
+// establish fetches the tunnel configuration, opens the PPP tunnel and
+// negotiates the link. Must be called under p.mu.
+func (p *Provider) establish(ctx context.Context, c *client, cookie string, controlFn func(string, string, syscall.RawConn) error) error {
+	tc, err := c.tunnelConfig(ctx, cookie)
+	if err != nil {
+		return err
+	}
+	if tc.IdleTimeout > 0 {
+		core.Log.Infof("Fortinet", "Idle timeout: %d seconds", tc.IdleTimeout)
+	}
+	if tc.AuthTimeout > 0 {
+		core.Log.Infof("Fortinet", "Session timeout: %d seconds", tc.AuthTimeout)
+	}
+
+	tlsConn, err := p.dialTLS(ctx, p.config.Server, p.config.Port, controlFn)
+	if err != nil {
+		return err
+	}
+	pc, err := startTunnel(tlsConn, p.config.Server, cookie, tc.Address)
+	if err != nil {
+		tlsConn.Close()
+		return err
+	}
+	addr, pppDNS, err := pc.negotiate(ctx)
+	if err != nil {
+		pc.cleanShutdown.Store(true)
+		tlsConn.Close()
+		<-pc.stopped
+		return err
+	}
+	if !pc.setOnDisconnect(p.onTunnelDrop) {
+		tlsConn.Close()
+		return fmt.Errorf("tunnel closed after PPP negotiation")
+	}
+
+	dns := tc.DNS
+	if len(dns) == 0 {
+		dns = pppDNS
+	}
+	core.Log.Infof("Fortinet", "Tunnel established: IP=%s DNS=%v routes=%d excludes=%d",
+		addr, dns, len(tc.SplitInclude), len(tc.SplitExclude))
+
+	p.adapterIP = addr
+	p.splitInclude = tc.SplitInclude
+	p.splitExclude = tc.SplitExclude
+	p.dns = dns
+	p.ppp = pc
+	if p.inboundHandler != nil {
+		pc.setInboundHandler(p.inboundHandler)
+	}
+	p.state = core.TunnelStateUp
+	core.Log.Infof("Fortinet", "Tunnel %q is UP", p.name)
+	return nil
+}
+
+// onTunnelDrop marks the tunnel failed and notifies the controller.
+func (p *Provider) onTunnelDrop(err error) {
+	core.Log.Warnf("Fortinet", "Session dropped for %q: %v", p.name, err)
+	p.mu.Lock()
+	p.state = core.TunnelStateError
+	p.ppp = nil
+	onDrop := p.onSessionDrop
+	p.mu.Unlock()
+	if onDrop != nil {
+		onDrop(p.name, err)
+	}
+}
+
+// newHTTPClient returns an HTTP client for the web API whose connections go
+// through dialTLS. Redirects are not followed so Set-Cookie on a 302 is seen.
+func (p *Provider) newHTTPClient(controlFn func(string, string, syscall.RawConn) error) *http.Client {
+	tr := &http.Transport{
+		DialTLSContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			host, portStr, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			port, _ := strconv.Atoi(portStr)
+			return p.dialTLS(ctx, host, port, controlFn)
+		},
+		MaxIdleConnsPerHost: 1,
+	}
+	return &http.Client{
+		Transport: tr,
+		Timeout:   30 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// dialTLS connects to the FortiGate and records its address for bypass routes.
+func (p *Provider) dialTLS(ctx context.Context, server string, port int, controlFn func(string, string, syscall.RawConn) error) (*tls.Conn, error) {
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: p.config.TLSSkipVerify,
+		ServerName:         server,
+		Certificates:       p.tlsCerts,
+	}
+	proxy := anyconnect.Proxy{URL: p.config.ProxyURL, Username: p.config.ProxyUsername, Password: p.config.ProxyPassword}
+	conn, endpoint, err := anyconnect.DialTLS(ctx, server, port, tlsCfg, controlFn, proxy)
+	if endpoint.IsValid() {
+		p.serverEndpoints = []netip.AddrPort{endpoint}
+	}
+	return conn, err
+}
+
+// Disconnect tears down the tunnel. The session cookie is kept for resumption.
+func (p *Provider) Disconnect() error {
+	// Release the lock before stop(): the readLoop's onDisconnect path
+	// also takes p.mu.
+	p.mu.Lock()
+	pc := p.ppp
+	p.ppp = nil
+	p.mu.Unlock()
+
+	if pc != nil {
+		pc.stop()
+	}
+
+	p.mu.Lock()
+	p.state = core.TunnelStateDown
+	p.adapterIP = netip.Addr{}
+	p.mu.Unlock()
+
+	core.Log.Infof("Fortinet", "Tunnel %q disconnected", p.name)
+	return nil
+}
+
+// ---- RawForwarder interface ----
+
+// InjectOutbound sends an IP packet through the PPP tunnel.
+func (p *Provider) InjectOutbound(pkt []byte) bool {
+	return p.sendData(pkt)
+}
+
+// InjectOutboundPriority sends an IP packet (priority is ignored).
+func (p *Provider) InjectOutboundPriority(pkt []byte, _ byte) bool {
+	return p.sendData(pkt)
+}
+
+// SetInboundHandler registers a callback for incoming IP packets from the tunnel.
+// Wraps the handler with the DialUDP response interceptor.
+func (p *Provider) SetInboundHandler(handler func(pkt []byte) bool) {
+	wrapped := func(pkt []byte) bool {
+		if p.rawUDP.Intercept(pkt) {
+			return true
+		}
+		return handler(pkt)
+	}
+
+	p.mu.Lock()
+	p.inboundHandler = &wrapped
+	pc := p.ppp
+	p.mu.Unlock()
+
+	if pc != nil {
+		pc.setInboundHandler(&wrapped)
+	}
+}
+
+func (p *Provider) sendData(pkt []byte) bool {
+	p.mu.RLock()
+	pc := p.ppp
+	p.mu.RUnlock()
+	return pc != nil && pc.sendData(pkt)
+}
+
+// ---- Network Roaming ----
+
+// HandleNetworkChange closes the tunnel after a network change so the
+// controller reconnects with the saved cookie. Debounced to 3 seconds.
+func (p *Provider) HandleNetworkChange() {
+	p.networkChangeMu.Lock()
+	if time.Since(p.networkChangeTime) < 3*time.Second {
+		p.networkChangeMu.Unlock()
+		return
+	}
+	p.networkChangeTime = time.Now()
+	p.networkChangeMu.Unlock()
+
+	p.mu.Lock()
+	pc := p.ppp
+	up := p.state == core.TunnelStateUp && p.savedCookie != "" && pc != nil
+	if up {
+		p.ppp = nil
+	}
+	p.mu.Unlock()
+
+	if !up {
+		return
+	}
+	core.Log.Infof("Fortinet", "Network change detected for %q, triggering session resume", p.name)
+	pc.stop()
+	p.onTunnelDrop(errors.New("network changed"))
+}
+
+// ---- DialTCP / DialUDP ----
+// Fortinet uses RawForwarder for IP-level forwarding.
+
+func (p *Provider) DialTCP(_ context.Context, _ string) (net.Conn, error) {
+	return nil, fmt.Errorf("fortinet: DialTCP not supported, use RawForwarder")
+}
+
+// DialUDP creates a virtual UDP connection tunneled at the raw IP level.
+func (p *Provider) DialUDP(_ context.Context, addr string) (net.Conn, error) {
+	p.mu.RLock()
+	pc := p.ppp
+	adapterIP := p.adapterIP
+	p.mu.RUnlock()
+
+	if pc == nil {
+		return nil, fmt.Errorf("fortinet: not connected")
+	}
+	conn, err := p.rawUDP.Dial(addr, adapterIP)
+	if err != nil {
+		return nil, fmt.Errorf("fortinet: %w", err)
+	}
+	return conn, nil
+}
+
+var (
+	_ provider.TunnelProvider     = (*Provider)(nil)
+	_ provider.RawForwarder       = (*Provider)(nil)
+	_ provider.EndpointProvider   = (*Provider)(nil)
+	_ provider.SplitRouteProvider = (*Provider)(nil)
+	_ provider.DNSProvider        = (*Provider)(nil)
+	_ provider.AuthParamSetter    = (*Provider)(nil)
+)
@@ -0,0 +1,356 @@
+package fortinet
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// Fortinet tunnel frame header (6 bytes): total length BE16 (header
+// included), magic 0x5050, payload length BE16. The payload is a PPP frame.
+const (
+	frameHeaderLen = 6
+	frameMagic     = 0x5050
+
+	echoInterval = 10 * time.Second
+	defaultMRU   = 1354
+)
+
+// pppConn is a PPP session over the FortiGate TLS tunnel.
+type pppConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+
+	mu     sync.Mutex // guards state, onDisconnect and exited
+	state  pppState
+	exited bool
+	up     chan struct{}
+	upOnce sync.Once
+
+	inboundHandler atomic.Pointer[func(pkt []byte) bool]
+
+	// onDisconnect is called when the readLoop exits (server terminate, I/O error).
+	// Installed with setOnDisconnect once the link is up.
+	onDisconnect func(error)
+
+	// cleanShutdown suppresses onDisconnect on intentional stop.
+	cleanShutdown atomic.Bool
+
+	stopped chan struct{}
+}
+
+// startTunnel requests the PPP tunnel on an established TLS connection.
+// The FortiGate starts PPP right away; an HTTP reply means it refused.
+func startTunnel(conn net.Conn, host, cookie string, addr netip.Addr) (*pppConn, error) {
+	req := fmt.Sprintf("GET /remote/sslvpn-tunnel HTTP/1.1\r\n"+
+		"Host: %s\r\n"+
+		"User-Agent: Mozilla/5.0 SV1\r\n"+
+		"Cookie: %s=%s\r\n"+
+		"\r\n", host, cookieName, cookie)
+	conn.SetWriteDeadline(time.Now().Add(15 * time.Second))
+	if _, err := io.WriteString(conn, req); err != nil {
+		return nil, fmt.Errorf("write tunnel request: %w", err)
+	}
+	conn.SetWriteDeadline(time.Time{})
+
+	var magic [4]byte
+	rand.Read(magic[:])
+	c := &pppConn{
+		conn: conn,
+		br:   bufio.NewReader(conn),
+		state: pppState{
+			magic: binary.BigEndian.Uint32(magic[:]),
+			mru:   defaultMRU,
+			addr:  addr,
+		},
+		up:      make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	return c, nil
+}
+
+// negotiate runs LCP and IPCP until the link is up, retransmitting our
+// Configure-Requests. Returns the negotiated address and DNS servers.
+func (c *pppConn) negotiate(ctx context.Context) (netip.Addr, []string, error) {
+	go c.readLoop()
+
+	c.mu.Lock()
+	c.sendControl(pppLCP, c.state.lcpRequest())
+	c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(ctx, 20*time.Second)
+	defer cancel()
+	ticker := time.NewTicker(3 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.up:
+			go c.echoLoop()
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			var dns []string
+			for _, a := range []netip.Addr{c.state.dns1, c.state.dns2} {
+				if a.IsValid() && !a.IsUnspecified() {
+					dns = append(dns, a.String())
+				}
+			}
+			return c.state.addr, dns, nil
+		case <-c.stopped:
+			return netip.Addr{}, nil, fmt.Errorf("tunnel closed during PPP negotiation")
+		case <-ctx.Done():
+			return netip.Addr{}, nil, fmt.Errorf("PPP negotiation: %w", ctx.Err())
+		case <-ticker.C:
+			c.mu.Lock()
+			if !c.state.lcpAckRcvd {
+				c.sendControl(pppLCP, c.state.lcpRequest())
+			} else if c.state.ipcpStarted && !c.state.ipcpAckRcvd {
+				c.sendControl(pppIPCP, c.state.ipcpRequest())
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+// setOnDisconnect installs the drop callback. Returns false if the link has
+// already closed.
+func (c *pppConn) setOnDisconnect(fn func(error)) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.exited {
+		return false
+	}
+	c.onDisconnect = fn
+	return true
+}
+
+func (c *pppConn) stop() {
+	c.cleanShutdown.Store(true)
+	c.mu.Lock()
+	c.sendControl(pppLCP, controlPacket(codeTerminateRequest, c.state.nextID(), nil))
+	c.mu.Unlock()
+	c.conn.Close()
+	<-c.stopped
+}
+
+func (c *pppConn) readLoop() {
+	defer close(c.stopped)
+
+	var exitErr error
+	defer func() {
+		c.mu.Lock()
+		c.exited = true
+		onDisconnect := c.onDisconnect
+		c.mu.Unlock()
+		if onDisconnect != nil && !c.cleanShutdown.Load() {
+			onDisconnect(exitErr)
+		}
+	}()
+
+	hdr := make([]byte, frameHeaderLen)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(3 * echoInterval))
+
+		if _, err := io.ReadFull(c.br, hdr); err != nil {
+			exitErr = fmt.Errorf("read header: %w", err)
+			return
+		}
+		if bytes.HasPrefix(hdr, []byte("HTTP/")) {
+			line, _ := c.br.ReadString('\n')
+			exitErr = fmt.Errorf("tunnel rejected: %s", bytes.TrimSpace(append(hdr, line...)))
+			return
+		}
+		total := int(binary.BigEndian.Uint16(hdr[0:2]))
+		payloadLen := int(binary.BigEndian.Uint16(hdr[4:6]))
+		if binary.BigEndian.Uint16(hdr[2:4]) != frameMagic || total != payloadLen+frameHeaderLen {
+			exitErr = fmt.Errorf("invalid frame header %x", hdr)
+			return
+		}
+		buf := make([]byte, payloadLen)
+		if _, err := io.ReadFull(c.br, buf); err != nil {
+			exitErr = fmt.Errorf("read data: %w", err)
+			return
+		}
+		proto, info, ok := parsePPPFrame(buf)
+		if !ok {
+			continue
+		}
+		switch proto {
+		case pppIPv4, pppIPv6:
+			if hp := c.inboundHandler.Load(); hp != nil {
+				(*hp)(info)
+			}
+		case pppLCP:
+			if err := c.handleLCP(info); err != nil {
+				exitErr = err
+				return
+			}
+		case pppIPCP:
+			c.handleIPCP(info)
+		default:
+			// IPv6CP, CCP, ...: not supported.
+			c.mu.Lock()
+			rej := make([]byte, 2+len(info))
+			binary.BigEndian.PutUint16(rej, proto)
+			copy(rej[2:], info)
+			c.sendControl(pppLCP, controlPacket(codeProtocolReject, c.state.nextID(), rej))
+			c.mu.Unlock()
+		}
+	}
+}
+
+func (c *pppConn) handleLCP(b []byte) error {
+	code, id, data, ok := parseControl(b)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &c.state
+
+	switch code {
+	case codeConfigureRequest:
+		reply := peerLCPRequest(id, data)
+		if reply == nil {
+			return nil
+		}
+		c.sendControl(pppLCP, reply)
+		if reply[0] == codeConfigureAck {
+			s.lcpAckSent = true
+		}
+	case codeConfigureAck:
+		s.lcpAckRcvd = true
+	case codeConfigureNak:
+		s.applyLCPNak(data)
+		c.sendControl(pppLCP, s.lcpRequest())
+	case codeConfigureReject:
+		s.lcpBare = true
+		c.sendControl(pppLCP, s.lcpRequest())
+	case codeEchoRequest:
+		reply := make([]byte, len(data))
+		copy(reply, data)
+		if len(reply) >= 4 {
+			binary.BigEndian.PutUint32(reply[0:4], s.magic)
+		}
+		c.sendControl(pppLCP, controlPacket(codeEchoReply, id, reply))
+	case codeEchoReply, codeDiscardRequest, codeTerminateAck:
+	case codeTerminateRequest:
+		c.sendControl(pppLCP, controlPacket(codeTerminateAck, id, nil))
+		return fmt.Errorf("session terminated by server")
+	case codeProtocolReject:
+		if len(data) >= 2 && binary.BigEndian.Uint16(data) == pppIPCP {
+			return fmt.Errorf("server rejected IPCP")
+		}
+	}
+
+	if s.lcpOpen() && !s.ipcpStarted {
+		s.ipcpStarted = true
+		c.sendControl(pppIPCP, s.ipcpRequest())
+	}
+	return nil
+}
+
+func (c *pppConn) handleIPCP(b []byte) {
+	code, id, data, ok := parseControl(b)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := &c.state
+
+	switch code {
+	case codeConfigureRequest:
+		reply := peerIPCPRequest(id, data)
+		if reply == nil {
+			return
+		}
+		c.sendControl(pppIPCP, reply)
+		if reply[0] == codeConfigureAck {
+			s.ipcpAckSent = true
+		}
+	case codeConfigureAck:
+		s.ipcpAckRcvd = true
+	case codeConfigureNak:
+		s.applyIPCPNak(data)
+		c.sendControl(pppIPCP, s.ipcpRequest())
+	case codeConfigureReject:
+		s.applyIPCPReject(data)
+		c.sendControl(pppIPCP, s.ipcpRequest())
+	case codeTerminateRequest:
+		c.sendControl(pppIPCP, controlPacket(codeTerminateAck, id, nil))
+	}
+
+	if s.ipcpOpen() {
+		c.upOnce.Do(func() {
+			core.Log.Debugf("Fortinet", "PPP up: address %s", s.addr)
+			close(c.up)
+		})
+	}
+}
+
+// echoLoop sends LCP Echo-Requests so the readLoop deadline detects a dead link.
+func (c *pppConn) echoLoop() {
+	ticker := time.NewTicker(echoInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.mu.Lock()
+			magic := make([]byte, 4)
+			binary.BigEndian.PutUint32(magic, c.state.magic)
+			c.sendControl(pppLCP, controlPacket(codeEchoRequest, c.state.nextID(), magic))
+			c.mu.Unlock()
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+func (c *pppConn) setInboundHandler(h *func(pkt []byte) bool) {
+	c.inboundHandler.Store(h)
+}
+
+// sendData sends an IP packet as a PPP data frame.
+func (c *pppConn) sendData(pkt []byte) bool {
+	if len(pkt) == 0 {
+		return false
+	}
+	proto := pppIPv4
+	if pkt[0]>>4 == 6 {
+		proto = pppIPv6
+	}
+	return c.write(pppFrame(proto, pkt))
+}
+
+func (c *pppConn) sendControl(proto uint16, pkt []byte) {
+	c.write(pppFrame(proto, pkt))
+}
+
+func (c *pppConn) write(ppp []byte) bool {
+	if len(ppp)+frameHeaderLen > 65535 {
+		return false
+	}
+	frame := make([]byte, frameHeaderLen+len(ppp))
+	binary.BigEndian.PutUint16(frame[0:2], uint16(len(frame)))
+	binary.BigEndian.PutUint16(frame[2:4], frameMagic)
+	binary.BigEndian.PutUint16(frame[4:6], uint16(len(ppp)))
+	copy(frame[frameHeaderLen:], ppp)
+
+	c.writeMu.Lock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(frame)
+	c.writeMu.Unlock()
+	return err == nil
+}
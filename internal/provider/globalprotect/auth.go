@@ -0,0 +1,477 @@
+package globalprotect
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// gpClientVersion is the GlobalProtect client version reported to the server.
+const gpClientVersion = "4100"
+
+// statusChallenge is the non-standard HTTP status GlobalProtect uses for
+// a second-factor challenge (some gateways send the same body with 200).
+const statusChallenge = 512
+
+// ---- XML structures for GlobalProtect authentication ----
+
+type preloginResponse struct {
+	XMLName     xml.Name `xml:"prelogin-response"`
+	Status      string   `xml:"status"`
+	Msg         string   `xml:"msg"`
+	SAMLMethod  string   `xml:"saml-auth-method"`
+	SAMLRequest string   `xml:"saml-request"`
+	AuthMessage string   `xml:"authentication-message"`
+}
+
+type portalConfig struct {
+	XMLName                xml.Name       `xml:"policy"`
+	Gateways               []gatewayEntry `xml:"gateways>external>list>entry"`
+	UserAuthCookie         string         `xml:"portal-userauthcookie"`
+	PrelogonUserAuthCookie string         `xml:"portal-prelogonuserauthcookie"`
+}
+
+type gatewayEntry struct {
+	Address     string `xml:"name,attr"`
+	Description string `xml:"description"`
+}
+
+type jnlpResponse struct {
+	XMLName   xml.Name `xml:"jnlp"`
+	Arguments []string `xml:"application-desc>argument"`
+}
+
+type errorResponse struct {
+	XMLName xml.Name `xml:"response"`
+	Status  string   `xml:"status,attr"`
+	Error   string   `xml:"error"`
+}
+
+// gatewayConfig is the subset of /ssl-vpn/getconfig.esp we use.
+type gatewayConfig struct {
+	XMLName             xml.Name   `xml:"response"`
+	Status              string     `xml:"status,attr"`
+	Error               string     `xml:"error"`
+	IPAddress           string     `xml:"ip-address"`
+	MTU                 int        `xml:"mtu"`
+	DNS                 []string   `xml:"dns>member"`
+	DNSSuffix           []string   `xml:"dns-suffix>member"`
+	GatewayAddress      string     `xml:"gw-address"`
+	AccessRoutes        []string   `xml:"access-routes>member"`
+	ExcludeAccessRoutes []string   `xml:"exclude-access-routes>member"`
+	Lifetime            int        `xml:"lifetime"`
+	IdleTimeout         int        `xml:"disconnect-on-idle"`
+	IPsec               *ipsecInfo `xml:"ipsec"`
+}
+
+type ipsecInfo struct {
+	UDPPort  int    `xml:"udp-port"`
+	EncAlgo  string `xml:"enc-algo"`
+	HMACAlgo string `xml:"hmac-algo"`
+	C2SSPI   string `xml:"c2s-spi"`
+	S2CSPI   string `xml:"s2c-spi"`
+	EKeyC2S  string `xml:"ekey-c2s>val"`
+	AKeyC2S  string `xml:"akey-c2s>val"`
+	EKeyS2C  string `xml:"ekey-s2c>val"`
+	AKeyS2C  string `xml:"akey-s2c>val"`
+}
+
+// credentials holds everything that can be submitted on a login form.
+type credentials struct {
+	Username string
+	Password string
+	OTPCode  string // one-shot second factor, consumed by the first challenge
+
+	// Alternatives to Password.
+	PreloginCookie       string // from SAML
+	PortalUserAuthCookie string // issued by the portal for the gateway login
+	PortalPrelogonCookie string
+}
+
+// session is a gateway login: the cookie fields that authorize getconfig
+// and the tunnel.
+type session struct {
+	AuthCookie  string
+	Portal      string
+	User        string
+	Domain      string
+	PreferredIP string
+	Computer    string
+}
+
+// values returns the session cookie as form fields.
+func (s *session) values() url.Values {
+	v := url.Values{}
+	v.Set("authcookie", s.AuthCookie)
+	v.Set("portal", s.Portal)
+	v.Set("user", s.User)
+	v.Set("domain", s.Domain)
+	v.Set("computer", s.Computer)
+	if s.PreferredIP != "" {
+		v.Set("preferred-ip", s.PreferredIP)
+	}
+	return v
+}
+
+// challenge is a second-factor prompt returned by login or portal getconfig.
+type challenge struct {
+	Message  string
+	InputStr string
+}
+
+var (
+	reRespStatus = regexp.MustCompile(`respStatus\s*=\s*"([^"]*)"`)
+	reRespMsg    = regexp.MustCompile(`respMsg\s*=\s*"([^"]*)"`)
+	reInputStr   = regexp.MustCompile(`inputStr\.value\s*=\s*"([^"]*)"`)
+)
+
+// parseChallenge extracts a GlobalProtect JavaScript challenge page.
+func parseChallenge(body []byte) (*challenge, bool) {
+	m := reRespStatus.FindSubmatch(body)
+	if m == nil || !strings.EqualFold(string(m[1]), "Challenge") {
+		return nil, false
+	}
+	c := &challenge{}
+	if m := reRespMsg.FindSubmatch(body); m != nil {
+		c.Message = string(m[1])
+	}
+	if m := reInputStr.FindSubmatch(body); m != nil {
+		c.InputStr = string(m[1])
+	}
+	return c, true
+}
+
+// client performs GlobalProtect XML API requests.
+type client struct {
+	http     *http.Client
+	clientOS string
+	computer string
+}
+
+func newClient(hc *http.Client, clientOS string) *client {
+	computer, _ := os.Hostname()
+	if computer == "" {
+		computer = "localhost"
+	}
+	if clientOS == "" {
+		clientOS = "Windows"
+	}
+	return &client{http: hc, clientOS: clientOS, computer: computer}
+}
+
+func (c *client) osVersion() string {
+	switch c.clientOS {
+	case "Mac":
+		return "Apple Mac OS X 13.0"
+	case "Linux":
+		return "Linux"
+	default:
+		return "Microsoft Windows 10 Pro , 64-bit"
+	}
+}
+
+// post submits a form to https://host:port/path and returns status and body.
+func (c *client) post(ctx context.Context, host string, port int, path string, form url.Values) (int, []byte, error) {
+	u := url.URL{Scheme: "https", Host: hostPort(host, port), Path: path}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "PAN GlobalProtect")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return resp.StatusCode, nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return resp.StatusCode, body, nil
+}
+
+// prelogin queries the portal (or gateway) for its authentication method.
+func (c *client) prelogin(ctx context.Context, host string, port int, portal bool) (*preloginResponse, error) {
+	path := "/ssl-vpn/prelogin.esp"
+	if portal {
+		path = "/global-protect/prelogin.esp"
+	}
+	form := url.Values{}
+	form.Set("tmp", "tmp")
+	form.Set("clientVer", gpClientVersion)
+	form.Set("clientos", c.clientOS)
+	form.Set("os-version", c.osVersion())
+	form.Set("default-browser", "1")
+	form.Set("cas-support", "yes")
+
+	status, body, err := c.post(ctx, host, port, path, form)
+	if err != nil {
+		return nil, fmt.Errorf("prelogin: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("prelogin: HTTP %d", status)
+	}
+	var pr preloginResponse
+	if err := xml.Unmarshal(body, &pr); err != nil {
+		return nil, fmt.Errorf("prelogin: parse response: %w", err)
+	}
+	if !strings.EqualFold(pr.Status, "Success") {
+		return nil, fmt.Errorf("prelogin: %s", pr.Msg)
+	}
+	return &pr, nil
+}
+
+// loginForm builds the form shared by portal getconfig and gateway login.
+func (c *client) loginForm(host string, creds credentials) url.Values {
+	form := url.Values{}
+	form.Set("prot", "https:")
+	form.Set("server", host)
+	form.Set("inputStr", "")
+	form.Set("jnlpReady", "jnlpReady")
+	form.Set("user", creds.Username)
+	form.Set("passwd", creds.Password)
+	form.Set("computer", c.computer)
+	form.Set("ok", "Login")
+	form.Set("direct", "yes")
+	form.Set("clientVer", gpClientVersion)
+	form.Set("os-version", c.osVersion())
+	form.Set("clientos", c.clientOS)
+	form.Set("ipv6-support", "no")
+	if creds.PreloginCookie != "" {
+		form.Set("prelogin-cookie", creds.PreloginCookie)
+	}
+	if creds.PortalUserAuthCookie != "" {
+		form.Set("portal-userauthcookie", creds.PortalUserAuthCookie)
+	}
+	if creds.PortalPrelogonCookie != "" {
+		form.Set("portal-prelogonuserauthcookie", creds.PortalPrelogonCookie)
+	}
+	return form
+}
+
+// submit posts a login form, answering a second-factor challenge with the
+// one-shot OTP code. Returns provider.ErrAuthRequired if a challenge arrives
+// and no OTP is available.
+func (c *client) submit(ctx context.Context, host string, port int, path string, creds *credentials) ([]byte, error) {
+	form := c.loginForm(host, *creds)
+	for round := 0; round < 3; round++ {
+		status, body, err := c.post(ctx, host, port, path, form)
+		if err != nil {
+			return nil, err
+		}
+		ch, ok := parseChallenge(body)
+		if !ok && status == statusChallenge {
+			ch, ok = &challenge{Message: "challenge"}, true
+		}
+		if ok {
+			if creds.OTPCode == "" {
+				return nil, fmt.Errorf("%w: %s", provider.ErrAuthRequired, ch.Message)
+			}
+			core.Log.Infof("GlobalProtect", "Answering challenge %q", ch.Message)
+			form.Set("passwd", creds.OTPCode)
+			form.Set("inputStr", ch.InputStr)
+			creds.OTPCode = ""
+			continue
+		}
+		if status != http.StatusOK {
+			return nil, fmt.Errorf("HTTP %d%s", status, errorDetail(body))
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("too many challenge rounds")
+}
+
+// errorDetail extracts the message of a <response status="error"> body.
+func errorDetail(body []byte) string {
+	var er errorResponse
+	if xml.Unmarshal(body, &er) == nil && er.Error != "" {
+		return ": " + er.Error
+	}
+	return ""
+}
+
+// portalConfig authenticates to the portal and returns its gateway list and
+// the cookies that replace the password on the gateway.
+func (c *client) portalConfig(ctx context.Context, host string, port int, creds *credentials) (*portalConfig, error) {
+	body, err := c.submit(ctx, host, port, "/global-protect/getconfig.esp", creds)
+	if err != nil {
+		return nil, fmt.Errorf("portal config: %w", err)
+	}
+	var pc portalConfig
+	if err := xml.Unmarshal(body, &pc); err != nil {
+		return nil, fmt.Errorf("portal config: parse response: %w%s", err, errorDetail(body))
+	}
+	// The portal sends the literal "empty" when it issues no cookie.
+	if pc.UserAuthCookie == "empty" {
+		pc.UserAuthCookie = ""
+	}
+	if pc.PrelogonUserAuthCookie == "empty" {
+		pc.PrelogonUserAuthCookie = ""
+	}
+	return &pc, nil
+}
+
+// login authenticates to the gateway and returns the session cookie.
+func (c *client) login(ctx context.Context, host string, port int, creds *credentials) (*session, error) {
+	body, err := c.submit(ctx, host, port, "/ssl-vpn/login.esp", creds)
+	if err != nil {
+		return nil, fmt.Errorf("login: %w", err)
+	}
+	return c.parseLogin(body)
+}
+
+// parseLogin extracts the session cookie from the JNLP login response.
+// Arguments (0-based): 1 authcookie, 3 portal, 4 user, 7 domain,
+// 12 connection-type, 15 preferred-ip.
+func (c *client) parseLogin(body []byte) (*session, error) {
+	var j jnlpResponse
+	if err := xml.Unmarshal(body, &j); err != nil {
+		return nil, fmt.Errorf("login: parse response: %w%s", err, errorDetail(body))
+	}
+	arg := func(i int) string {
+		if i >= len(j.Arguments) {
+			return ""
+		}
+		v := strings.TrimSpace(j.Arguments[i])
+		if v == "(null)" || v == "-1" {
+			return ""
+		}
+		return v
+	}
+	s := &session{
+		AuthCookie:  arg(1),
+		Portal:      arg(3),
+		User:        arg(4),
+		Domain:      arg(7),
+		PreferredIP: arg(15),
+		Computer:    c.computer,
+	}
+	if s.AuthCookie == "" {
+		return nil, fmt.Errorf("login: no authcookie in response")
+	}
+	if ct := arg(12); ct != "" && ct != "tunnel" {
+		return nil, fmt.Errorf("login: unsupported connection type %q", ct)
+	}
+	return s, nil
+}
+
+// getConfig fetches the tunnel configuration for an authenticated session.
+func (c *client) getConfig(ctx context.Context, host string, port int, s *session) (*gatewayConfig, error) {
+	form := s.values()
+	form.Set("client-type", "1")
+	form.Set("protocol-version", "p1")
+	form.Set("app-version", "4.1.0-98")
+	form.Set("ipv6-support", "no")
+	form.Set("clientos", c.clientOS)
+	form.Set("os-version", c.osVersion())
+	form.Set("enc-algo", "aes-256-cbc,aes-128-cbc")
+	form.Set("hmac-algo", "sha256,sha1,md5")
+
+	status, body, err := c.post(ctx, host, port, "/ssl-vpn/getconfig.esp", form)
+	if err != nil {
+		return nil, fmt.Errorf("getconfig: %w", err)
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("getconfig: HTTP %d%s", status, errorDetail(body))
+	}
+	return parseGatewayConfig(body)
+}
+
+func parseGatewayConfig(body []byte) (*gatewayConfig, error) {
+	var gc gatewayConfig
+	if err := xml.Unmarshal(body, &gc); err != nil {
+		return nil, fmt.Errorf("getconfig: parse response: %w", err)
+	}
+	if gc.Status == "error" {
+		return nil, fmt.Errorf("getconfig: %s", gc.Error)
+	}
+	if _, err := netip.ParseAddr(gc.IPAddress); err != nil {
+		return nil, fmt.Errorf("getconfig: invalid ip-address %q", gc.IPAddress)
+	}
+	return &gc, nil
+}
+
+// routes converts access-routes / exclude-access-routes to prefixes. The
+// default route is dropped from the include list: no split-include means
+// "everything" for the tunnel controller.
+func (gc *gatewayConfig) routes() (include, exclude []netip.Prefix) {
+	for _, r := range gc.AccessRoutes {
+		if pfx, ok := anyconnect.ParseRouteEntry(r); ok && pfx.Bits() > 0 {
+			include = append(include, pfx.Masked())
+		}
+	}
+	for _, r := range gc.ExcludeAccessRoutes {
+		if pfx, ok := anyconnect.ParseRouteEntry(r); ok {
+			exclude = append(exclude, pfx.Masked())
+		}
+	}
+	return include, exclude
+}
+
+// samlLogin obtains the SAML prelogin cookie: from the auth params if the
+// user pasted one, otherwise through the system browser.
+func samlLogin(ctx context.Context, pr *preloginResponse, creds *credentials, params map[string]string) error {
+	if v := params["prelogin_cookie"]; v != "" {
+		creds.PreloginCookie = v
+		if u := params["saml_username"]; u != "" {
+			creds.Username = u
+		}
+		return nil
+	}
+	if !strings.EqualFold(pr.SAMLMethod, "REDIRECT") {
+		return fmt.Errorf("%w: SAML %s binding requires a prelogin_cookie", provider.ErrAuthRequired, pr.SAMLMethod)
+	}
+	raw, err := base64.StdEncoding.DecodeString(pr.SAMLRequest)
+	if err != nil {
+		return fmt.Errorf("decode SAML request: %w", err)
+	}
+	core.Log.Infof("GlobalProtect", "SAML authentication required, opening browser")
+	values, err := anyconnect.BrowserLogin(ctx, string(raw), "",
+		[]string{"prelogin-cookie", "portal-userauthcookie", "saml-username"}, 2*time.Minute)
+	if err != nil {
+		return fmt.Errorf("SAML: %w", err)
+	}
+	creds.PreloginCookie = values["prelogin-cookie"]
+	creds.PortalUserAuthCookie = values["portal-userauthcookie"]
+	if u := values["saml-username"]; u != "" {
+		creds.Username = u
+	}
+	return nil
+}
+
+// selectGateway picks the configured gateway from the portal list, matching
+// its address or description, or the first entry when none is configured.
+func selectGateway(gateways []gatewayEntry, want string) (string, error) {
+	if len(gateways) == 0 {
+		return "", fmt.Errorf("portal returned no gateways")
+	}
+	if want == "" {
+		return gateways[0].Address, nil
+	}
+	for _, g := range gateways {
+		if strings.EqualFold(g.Address, want) || strings.EqualFold(g.Description, want) {
+			return g.Address, nil
+		}
+	}
+	return "", fmt.Errorf("gateway %q not offered by portal", want)
+}
+
+func hostPort(host string, port int) string {
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
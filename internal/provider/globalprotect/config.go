@@ -0,0 +1,32 @@
+package globalprotect
+
+// Config holds GlobalProtect provider configuration parsed from TunnelConfig.Settings.
+// OTP codes and SAML prelogin cookies are NOT stored here — they are passed at
+// connect time via SetAuthParams.
+type Config struct {
+	Server        string // Portal (or gateway, see Gateway) hostname or IP
+	Port          int    // Server port (default 443)
+	Username      string // Login username
+	Password      string // Login password
+	Gateway       string // Gateway to connect to: name/address from the portal list, or "" = first listed
+	TLSSkipVerify bool   // Skip TLS certificate verification
+	ClientOS      string // Reported client OS: "Windows" (default), "Mac" or "Linux"
+
+	// GatewayOnly skips the portal and treats Server as the gateway itself.
+	GatewayOnly bool
+
+	// Client certificate for mutual TLS authentication (same formats as AnyConnect:
+	// "auto", .p12/.pfx, .cer/.crt/.der or .pem).
+	ClientCert         string
+	ClientKey          string
+	ClientCertPassword string
+
+	// Proxy settings for connecting through HTTP CONNECT proxy.
+	ProxyURL      string
+	ProxyUsername string
+	ProxyPassword string
+
+	// ESP enables IPsec ESP-over-UDP transport when the gateway offers it.
+	// Falls back to the HTTPS tunnel if the ESP probe gets no answer.
+	ESP bool
+}
@@ -0,0 +1,405 @@
+package globalprotect
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ESP next-header values for tunnel-mode payloads.
+const (
+	nextHeaderIPv4 = 4
+	nextHeaderIPv6 = 41
+	nextHeaderNone = 59 // dummy packet
+)
+
+// espProbePayload is the ICMP echo payload GlobalProtect gateways answer
+// over ESP to confirm the UDP path works.
+var espProbePayload = []byte("monitor\x00\x00pan ha ")
+
+// espSA is one direction of the ESP security association.
+type espSA struct {
+	spi     uint32
+	block   cipher.Block
+	newHMAC func() hash.Hash
+	icvLen  int
+}
+
+func newESPSA(spiStr, encAlgo, hmacAlgo, ekey, akey string) (*espSA, error) {
+	spi, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(spiStr), "0x"), 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPI %q", spiStr)
+	}
+	ek, err := hex.DecodeString(ekey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	ak, err := hex.DecodeString(akey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth key: %w", err)
+	}
+
+	var keyLen int
+	switch encAlgo {
+	case "aes-128-cbc", "aes128":
+		keyLen = 16
+	case "aes-256-cbc", "aes256":
+		keyLen = 32
+	default:
+		return nil, fmt.Errorf("unsupported enc-algo %q", encAlgo)
+	}
+	if len(ek) < keyLen {
+		return nil, fmt.Errorf("encryption key too short for %s", encAlgo)
+	}
+	block, err := aes.NewCipher(ek[:keyLen])
+	if err != nil {
+		return nil, err
+	}
+
+	sa := &espSA{spi: uint32(spi), block: block}
+	switch hmacAlgo {
+	case "sha1":
+		sa.newHMAC = func() hash.Hash { return hmac.New(sha1.New, ak) }
+		sa.icvLen = 12
+	case "md5":
+		sa.newHMAC = func() hash.Hash { return hmac.New(md5.New, ak) }
+		sa.icvLen = 12
+	case "sha256":
+		sa.newHMAC = func() hash.Hash { return hmac.New(sha256.New, ak) }
+		sa.icvLen = 16
+	default:
+		return nil, fmt.Errorf("unsupported hmac-algo %q", hmacAlgo)
+	}
+	return sa, nil
+}
+
+// seal encrypts an IP packet into an ESP packet with sequence number seq.
+func (sa *espSA) seal(seq uint32, pkt []byte) ([]byte, error) {
+	nextHeader := byte(nextHeaderIPv4)
+	if len(pkt) > 0 && pkt[0]>>4 == 6 {
+		nextHeader = nextHeaderIPv6
+	}
+	padLen := (aes.BlockSize - (len(pkt)+2)%aes.BlockSize) % aes.BlockSize
+	ptLen := len(pkt) + padLen + 2
+
+	out := make([]byte, 8+aes.BlockSize+ptLen+sa.icvLen)
+	binary.BigEndian.PutUint32(out[0:4], sa.spi)
+	binary.BigEndian.PutUint32(out[4:8], seq)
+	iv := out[8 : 8+aes.BlockSize]
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	pt := out[8+aes.BlockSize : 8+aes.BlockSize+ptLen]
+	copy(pt, pkt)
+	for i := 0; i < padLen; i++ {
+		pt[len(pkt)+i] = byte(i + 1)
+	}
+	pt[ptLen-2] = byte(padLen)
+	pt[ptLen-1] = nextHeader
+	cipher.NewCBCEncrypter(sa.block, iv).CryptBlocks(pt, pt)
+
+	mac := sa.newHMAC()
+	mac.Write(out[:len(out)-sa.icvLen])
+	copy(out[len(out)-sa.icvLen:], mac.Sum(nil))
+	return out, nil
+}
+
+// open authenticates and decrypts an ESP packet, returning the sequence
+// number, next header and inner packet.
+func (sa *espSA) open(b []byte) (uint32, byte, []byte, error) {
+	minLen := 8 + aes.BlockSize + aes.BlockSize + sa.icvLen
+	if len(b) < minLen || (len(b)-8-aes.BlockSize-sa.icvLen)%aes.BlockSize != 0 {
+		return 0, 0, nil, errors.New("bad ESP length")
+	}
+	if binary.BigEndian.Uint32(b[0:4]) != sa.spi {
+		return 0, 0, nil, errors.New("unknown SPI")
+	}
+	mac := sa.newHMAC()
+	mac.Write(b[:len(b)-sa.icvLen])
+	if subtle.ConstantTimeCompare(mac.Sum(nil)[:sa.icvLen], b[len(b)-sa.icvLen:]) != 1 {
+		return 0, 0, nil, errors.New("ESP authentication failed")
+	}
+	seq := binary.BigEndian.Uint32(b[4:8])
+	iv := b[8 : 8+aes.BlockSize]
+	pt := make([]byte, len(b)-8-aes.BlockSize-sa.icvLen)
+	cipher.NewCBCDecrypter(sa.block, iv).CryptBlocks(pt, b[8+aes.BlockSize:len(b)-sa.icvLen])
+
+	padLen := int(pt[len(pt)-2])
+	nextHeader := pt[len(pt)-1]
+	if padLen+2 > len(pt) {
+		return 0, 0, nil, errors.New("bad ESP padding")
+	}
+	return seq, nextHeader, pt[:len(pt)-2-padLen], nil
+}
+
+// replayWindow is a 64-packet anti-replay window (RFC 4303 §3.4.3).
+type replayWindow struct {
+	top    uint32
+	bitmap uint64
+}
+
+// check reports whether seq is new and records it.
+func (w *replayWindow) check(seq uint32) bool {
+	switch {
+	case seq == 0:
+		return false
+	case seq > w.top:
+		shift := seq - w.top
+		if shift >= 64 {
+			w.bitmap = 0
+		} else {
+			w.bitmap <<= shift
+		}
+		w.bitmap |= 1
+		w.top = seq
+		return true
+	case w.top-seq >= 64:
+		return false
+	default:
+		bit := uint64(1) << (w.top - seq)
+		if w.bitmap&bit != 0 {
+			return false
+		}
+		w.bitmap |= bit
+		return true
+	}
+}
+
+// espConn carries IP packets as ESP in UDP to the gateway.
+type espConn struct {
+	conn  net.Conn
+	out   *espSA
+	in    *espSA
+	local netip.Addr // tunnel address, source of probes
+	gw    netip.Addr // gw-address, destination of probes
+
+	seq      atomic.Uint32
+	replayMu sync.Mutex
+	replay   replayWindow
+	lastRx   atomic.Int64 // UnixNano of last authenticated packet
+
+	inboundHandler atomic.Pointer[func(pkt []byte) bool]
+
+	// onDisconnect is called when the readLoop exits or the gateway stops
+	// answering probes.
+	onDisconnect  func(error)
+	cleanShutdown atomic.Bool
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// dialESP opens the ESP transport and confirms with ICMP probes that the
+// gateway answers over UDP. The gateway disables ESP once the HTTPS tunnel is
+// requested, so this must run first.
+func dialESP(ctx context.Context, controlFn func(string, string, syscall.RawConn) error, gw netip.AddrPort, local netip.Addr, info *ipsecInfo) (*espConn, error) {
+	out, err := newESPSA(info.C2SSPI, info.EncAlgo, info.HMACAlgo, info.EKeyC2S, info.AKeyC2S)
+	if err != nil {
+		return nil, fmt.Errorf("c2s SA: %w", err)
+	}
+	in, err := newESPSA(info.S2CSPI, info.EncAlgo, info.HMACAlgo, info.EKeyS2C, info.AKeyS2C)
+	if err != nil {
+		return nil, fmt.Errorf("s2c SA: %w", err)
+	}
+
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: controlFn}
+	conn, err := dialer.DialContext(ctx, "udp", gw.String())
+	if err != nil {
+		return nil, fmt.Errorf("dial UDP %s: %w", gw, err)
+	}
+	c := &espConn{
+		conn:    conn,
+		out:     out,
+		in:      in,
+		local:   local,
+		gw:      gw.Addr(),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	buf := make([]byte, 2048)
+	for attempt := 0; attempt < 3; attempt++ {
+		c.sendProbe()
+		deadline := time.Now().Add(time.Second)
+		conn.SetReadDeadline(deadline)
+		for time.Now().Before(deadline) {
+			n, err := conn.Read(buf)
+			if err != nil {
+				break
+			}
+			if pkt, ok := c.decrypt(buf[:n]); ok && c.isProbeReply(pkt) {
+				conn.SetReadDeadline(time.Time{})
+				return c, nil
+			}
+		}
+	}
+	conn.Close()
+	return nil, fmt.Errorf("no ESP probe reply from %s", gw)
+}
+
+func (c *espConn) run() {
+	c.lastRx.Store(time.Now().UnixNano())
+	go c.readLoop()
+	go c.keepaliveLoop()
+}
+
+func (c *espConn) stop() {
+	c.cleanShutdown.Store(true)
+	close(c.done)
+	c.conn.Close()
+	<-c.stopped
+}
+
+func (c *espConn) setInboundHandler(h *func(pkt []byte) bool) {
+	c.inboundHandler.Store(h)
+}
+
+func (c *espConn) readLoop() {
+	defer close(c.stopped)
+
+	var exitErr error
+	defer func() {
+		if c.onDisconnect != nil && !c.cleanShutdown.Load() {
+			c.onDisconnect(exitErr)
+		}
+	}()
+
+	buf := make([]byte, 65535)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(3 * keepaliveInterval))
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			exitErr = fmt.Errorf("ESP read: %w", err)
+			return
+		}
+		pkt, ok := c.decrypt(buf[:n])
+		if !ok || pkt == nil || c.isProbeReply(pkt) {
+			continue
+		}
+		if hp := c.inboundHandler.Load(); hp != nil {
+			(*hp)(pkt)
+		}
+	}
+}
+
+// decrypt opens an ESP packet and applies replay protection. A nil packet
+// with ok=true is a dummy packet.
+func (c *espConn) decrypt(b []byte) ([]byte, bool) {
+	seq, nextHeader, pkt, err := c.in.open(b)
+	if err != nil {
+		return nil, false
+	}
+	c.replayMu.Lock()
+	fresh := c.replay.check(seq)
+	c.replayMu.Unlock()
+	if !fresh {
+		return nil, false
+	}
+	c.lastRx.Store(time.Now().UnixNano())
+	switch nextHeader {
+	case nextHeaderIPv4, nextHeaderIPv6:
+		return pkt, true
+	case nextHeaderNone:
+		return nil, true
+	default:
+		return nil, false
+	}
+}
+
+// keepaliveLoop sends probes so NAT mappings stay open and the gateway keeps
+// answering; the readLoop deadline detects a dead path.
+func (c *espConn) keepaliveLoop() {
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.sendProbe()
+		case <-c.done:
+			return
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+func (c *espConn) sendData(pkt []byte) bool {
+	b, err := c.out.seal(c.seq.Add(1), pkt)
+	if err != nil {
+		return false
+	}
+	_, err = c.conn.Write(b)
+	return err == nil
+}
+
+func (c *espConn) sendProbe() {
+	c.sendData(buildICMPEcho(c.local, c.gw, uint16(c.seq.Load()), espProbePayload))
+}
+
+// isProbeReply reports whether pkt is the gateway's echo reply to a probe.
+func (c *espConn) isProbeReply(pkt []byte) bool {
+	if len(pkt) < 28 || pkt[0]>>4 != 4 || pkt[9] != 1 {
+		return false
+	}
+	ihl := int(pkt[0]&0x0f) * 4
+	if len(pkt) < ihl+8 || pkt[ihl] != 0 {
+		return false
+	}
+	src, _ := netip.AddrFromSlice(pkt[12:16])
+	return src == c.gw && string(pkt[ihl+8:]) == string(espProbePayload)
+}
+
+// buildICMPEcho builds an IPv4 ICMP echo request.
+func buildICMPEcho(src, dst netip.Addr, seq uint16, payload []byte) []byte {
+	pkt := make([]byte, 20+8+len(payload))
+	pkt[0] = 0x45
+	binary.BigEndian.PutUint16(pkt[2:4], uint16(len(pkt)))
+	pkt[8] = 64 // TTL
+	pkt[9] = 1  // ICMP
+	s4, d4 := src.As4(), dst.As4()
+	copy(pkt[12:16], s4[:])
+	copy(pkt[16:20], d4[:])
+	binary.BigEndian.PutUint16(pkt[10:12], ^checksum(pkt[:20]))
+
+	icmp := pkt[20:]
+	icmp[0] = 8 // echo request
+	binary.BigEndian.PutUint16(icmp[4:6], 0x4747)
+	binary.BigEndian.PutUint16(icmp[6:8], seq)
+	copy(icmp[8:], payload)
+	binary.BigEndian.PutUint16(icmp[2:4], ^checksum(icmp))
+	return pkt
+}
+
+// checksum returns the folded one's-complement sum of b.
+func checksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum > 0xffff {
+		sum = (sum >> 16) + (sum & 0xffff)
+	}
+	return uint16(sum)
+}
@@ -0,0 +1,107 @@
+package globalprotect
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestParseGatewayConfig(t *testing.T) {
+	body := []byte(`<response status="success">
+  <ip-address>10.8.0.23</ip-address>
+  <mtu>1400</mtu>
+  <dns><member>10.0.0.53</member></dns>
+  <dns-suffix><member>corp.example.com</member></dns-suffix>
+  <access-routes><member>0.0.0.0/0</member><member>10.0.0.0/8</member></access-routes>
+  <exclude-access-routes><member>10.9.0.0/16</member></exclude-access-routes>
+  <ipsec>
+    <udp-port>4501</udp-port>
+    <enc-algo>aes-128-cbc</enc-algo>
+    <hmac-algo>sha1</hmac-algo>
+    <c2s-spi>0x1234ABCD</c2s-spi>
+  </ipsec>
+</response>`)
+	gc, err := parseGatewayConfig(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if gc.IPAddress != "10.8.0.23" || gc.MTU != 1400 || len(gc.DNS) != 1 || gc.IPsec == nil || gc.IPsec.UDPPort != 4501 {
+		t.Fatalf("unexpected config: %+v", gc)
+	}
+	include, exclude := gc.routes()
+	if len(include) != 1 || include[0] != netip.MustParsePrefix("10.0.0.0/8") {
+		t.Errorf("include = %v, want [10.0.0.0/8] (default route dropped)", include)
+	}
+	if len(exclude) != 1 || exclude[0] != netip.MustParsePrefix("10.9.0.0/16") {
+		t.Errorf("exclude = %v", exclude)
+	}
+
+	if _, err := parseGatewayConfig([]byte(`<response status="error"><error>session expired</error></response>`)); err == nil {
+		t.Error("expected error response to fail")
+	}
+}
+
+func TestParseLogin(t *testing.T) {
+	c := &client{computer: "host1"}
+	body := []byte(`<jnlp><application-desc>
+<argument>(null)</argument><argument>cookie123</argument><argument>x</argument>
+<argument>PortalA</argument><argument>alice</argument><argument>LDAP</argument>
+<argument>vsys1</argument><argument>corp</argument><argument>(null)</argument>
+<argument></argument><argument></argument><argument></argument>
+<argument>tunnel</argument><argument>-1</argument><argument>4100</argument>
+<argument>10.8.0.23</argument>
+</application-desc></jnlp>`)
+	s, err := c.parseLogin(body)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := session{AuthCookie: "cookie123", Portal: "PortalA", User: "alice", Domain: "corp", PreferredIP: "10.8.0.23", Computer: "host1"}
+	if *s != want {
+		t.Errorf("session = %+v, want %+v", *s, want)
+	}
+}
+
+func TestESPRoundTrip(t *testing.T) {
+	for _, hmacAlgo := range []string{"sha1", "md5", "sha256"} {
+		sa, err := newESPSA("0x0000beef", "aes-256-cbc", hmacAlgo,
+			"000102030405060708090a0b0c0d0e0f000102030405060708090a0b0c0d0e0f",
+			"0f0e0d0c0b0a09080706050403020100")
+		if err != nil {
+			t.Fatalf("%s: new SA: %v", hmacAlgo, err)
+		}
+		pkt := buildICMPEcho(netip.MustParseAddr("10.8.0.23"), netip.MustParseAddr("10.0.0.1"), 1, espProbePayload)
+		esp, err := sa.seal(7, pkt)
+		if err != nil {
+			t.Fatalf("%s: seal: %v", hmacAlgo, err)
+		}
+		seq, nh, inner, err := sa.open(esp)
+		if err != nil {
+			t.Fatalf("%s: open: %v", hmacAlgo, err)
+		}
+		if seq != 7 || nh != nextHeaderIPv4 || !bytes.Equal(inner, pkt) {
+			t.Errorf("%s: got seq=%d nh=%d inner=%x", hmacAlgo, seq, nh, inner)
+		}
+		esp[len(esp)-1] ^= 1
+		if _, _, _, err := sa.open(esp); err == nil {
+			t.Errorf("%s: tampered packet accepted", hmacAlgo)
+		}
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	var w replayWindow
+	for _, seq := range []uint32{1, 2, 5} {
+		if !w.check(seq) {
+			t.Fatalf("seq %d rejected", seq)
+		}
+	}
+	if w.check(2) {
+		t.Error("duplicate seq accepted")
+	}
+	if !w.check(3) {
+		t.Error("late in-window seq rejected")
+	}
+	if !w.check(100) || w.check(5) {
+		t.Error("window did not slide")
+	}
+}
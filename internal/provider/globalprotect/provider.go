@@ -0,0 +1,552 @@
+package globalprotect
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/platform"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// Provider implements provider.TunnelProvider, provider.RawForwarder,
+// provider.EndpointProvider, provider.SplitRouteProvider, provider.DNSProvider
+// and provider.AuthParamSetter for Palo Alto GlobalProtect.
+//
+// Authentication (prelogin, portal and gateway login, SAML, OTP challenges)
+// runs over HTTPS; packets flow over ESP-in-UDP when the gateway answers the
+// ESP probe, otherwise over the HTTPS tunnel.
+type Provider struct {
+	mu     sync.RWMutex
+	config Config
+	state  core.TunnelState
+	name   string
+
+	tlsCerts []tls.Certificate // client certificate for mutual TLS (optional)
+
+	adapterIP       netip.Addr
+	serverEndpoints []netip.AddrPort
+	splitInclude    []netip.Prefix
+	splitExclude    []netip.Prefix
+	dns             []string
+
+	// authParams holds ephemeral auth params (otp_code, prelogin_cookie,
+	// saml_username) set at connect time. Cleared after each Connect attempt.
+	authParams map[string]string
+
+	// Real NIC info for bypassing TUN DNS and routing.
+	realNICIndex uint32
+	binder       platform.InterfaceBinder
+
+	// rawUDP carries DialUDP connections as raw IP packets over the tunnel.
+	rawUDP *anyconnect.RawUDP
+
+	// onSessionDrop is called when the tunnel drops unexpectedly.
+	onSessionDrop func(tunnelID string, err error)
+
+	eventBus *core.EventBus
+
+	// Session resumption: saved gateway login for reconnect.
+	savedSession *session
+	savedGateway string
+
+	// Network roaming: debounce rapid network change events.
+	networkChangeMu   sync.Mutex
+	networkChangeTime time.Time
+
+	transport      transport
+	inboundHandler *func(pkt []byte) bool
+}
+
+// New creates a new GlobalProtect provider.
+func New(name string, cfg Config) (*Provider, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("globalprotect: server is required")
+	}
+	if cfg.Port <= 0 {
+		cfg.Port = 443
+	}
+	certs, err := anyconnect.LoadClientCert(cfg.ClientCert, cfg.ClientKey, cfg.ClientCertPassword)
+	if err != nil {
+		return nil, err
+	}
+	p := &Provider{
+		name:     name,
+		config:   cfg,
+		state:    core.TunnelStateDown,
+		tlsCerts: certs,
+	}
+	p.rawUDP = anyconnect.NewRawUDP(p.sendData)
+	return p, nil
+}
+
+func (p *Provider) Name() string     { return p.name }
+func (p *Provider) Protocol() string { return core.ProtocolGlobalProtect }
+
+// SetAuthParams implements provider.AuthParamSetter.
+// Accepts otp_code, and prelogin_cookie/saml_username for SAML gateways.
+func (p *Provider) SetAuthParams(params map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.authParams = params
+}
+
+// SetRealNICIndex sets the real NIC interface index for DNS resolution bypass.
+func (p *Provider) SetRealNICIndex(index uint32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.realNICIndex = index
+}
+
+// SetInterfaceBinder sets the platform-specific interface binder.
+func (p *Provider) SetInterfaceBinder(binder platform.InterfaceBinder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.binder = binder
+}
+
+// SetOnSessionDrop sets a callback invoked when the tunnel drops unexpectedly.
+func (p *Provider) SetOnSessionDrop(fn func(tunnelID string, err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onSessionDrop = fn
+}
+
+// SetEventBus sets the event bus for publishing tunnel events.
+func (p *Provider) SetEventBus(bus *core.EventBus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.eventBus = bus
+}
+
+func (p *Provider) State() core.TunnelState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.state
+}
+
+func (p *Provider) GetAdapterIP() netip.Addr {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.adapterIP
+}
+
+// GetServerEndpoints implements provider.EndpointProvider.
+func (p *Provider) GetServerEndpoints() []netip.AddrPort {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.serverEndpoints
+}
+
+// GetSplitInclude returns the gateway's access-routes.
+func (p *Provider) GetSplitInclude() []netip.Prefix {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.splitInclude
+}
+
+// GetSplitExclude returns the gateway's exclude-access-routes.
+func (p *Provider) GetSplitExclude() []netip.Prefix {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.splitExclude
+}
+
+// GetDNS returns DNS servers assigned by the gateway.
+func (p *Provider) GetDNS() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.dns
+}
+
+// HasSavedSession returns true if a gateway login is available for resumption.
+func (p *Provider) HasSavedSession() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.savedSession != nil
+}
+
+// ClearSession clears the saved gateway login, forcing full re-auth on next connect.
+func (p *Provider) ClearSession() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.savedSession = nil
+}
+
+// Connect authenticates and establishes the GlobalProtect tunnel.
+func (p *Provider) Connect(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = core.TunnelStateConnecting
+	p.serverEndpoints = nil
+	core.Log.Infof("GlobalProtect", "Connecting tunnel %q to %s:%d...", p.name, p.config.Server, p.config.Port)
+
+	var controlFn func(string, string, syscall.RawConn) error
+	if p.binder != nil && p.realNICIndex > 0 {
+		controlFn = p.binder.BindControl(p.realNICIndex)
+	}
+
+	// Consume one-shot auth params early so they are never reused.
+	params := p.authParams
+	p.authParams = nil
+	creds := credentials{
+		Username: p.config.Username,
+		Password: p.config.Password,
+		OTPCode:  params["otp_code"],
+	}
+
+	hc := p.newHTTPClient(controlFn)
+	defer hc.CloseIdleConnections()
+	c := newClient(hc, p.config.ClientOS)
+
+	// Try session resumption first if we have a saved gateway login.
+	if p.savedSession != nil {
+		core.Log.Infof("GlobalProtect", "Attempting session resumption for %q", p.name)
+		if p.eventBus != nil {
+			p.eventBus.PublishAsync(core.Event{
+				Type:    core.EventTunnelResuming,
+				Payload: core.TunnelStatePayload{TunnelID: p.name},
+			})
+		}
+		err := p.establish(ctx, c, p.savedGateway, p.savedSession, controlFn)
+		if err == nil {
+			core.Log.Infof("GlobalProtect", "Session resumed successfully for %q", p.name)
+			return nil
+		}
+		core.Log.Warnf("GlobalProtect", "Session resumption failed: %v, falling back to full auth", err)
+		p.savedSession = nil
+	}
+
+	gateway, sess, err := p.authenticate(ctx, c, &creds, params)
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[GlobalProtect] auth: %w", err)
+	}
+	core.Log.Infof("GlobalProtect", "Authentication successful on gateway %s", gateway)
+
+	p.savedSession = sess
+	p.savedGateway = gateway
+
+	if err := p.establish(ctx, c, gateway, sess, controlFn); err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[GlobalProtect] tunnel: %w", err)
+	}
+	return nil
+}
+
+// authenticate runs the portal (unless GatewayOnly) and gateway logins and
+// returns the chosen gateway and its session.
+func (p *Provider) authenticate(ctx context.Context, c *client, creds *credentials, params map[string]string) (string, *session, error) {
+	port := p.config.Port
+	gateway := p.config.Server
+
+	if !p.config.GatewayOnly {
+		pr, err := c.prelogin(ctx, p.config.Server, port, true)
+		if err != nil {
+			return "", nil, fmt.Errorf("portal %w", err)
+		}
+		if pr.SAMLRequest != "" {
+			if err := samlLogin(ctx, pr, creds, params); err != nil {
+				return "", nil, err
+			}
+		}
+		core.Log.Infof("GlobalProtect", "Authenticating as %q on portal %s...", creds.Username, p.config.Server)
+		pc, err := c.portalConfig(ctx, p.config.Server, port, creds)
+		if err != nil {
+			return "", nil, err
+		}
+		gateway, err = selectGateway(pc.Gateways, p.config.Gateway)
+		if err != nil {
+			return "", nil, err
+		}
+		// The portal cookie replaces the password and SAML cookie on the gateway.
+		if pc.UserAuthCookie != "" {
+			creds.PortalUserAuthCookie = pc.UserAuthCookie
+			creds.PortalPrelogonCookie = pc.PrelogonUserAuthCookie
+			creds.PreloginCookie = ""
+			creds.Password = ""
+		}
+	} else {
+		pr, err := c.prelogin(ctx, gateway, port, false)
+		if err != nil {
+			return "", nil, fmt.Errorf("gateway %w", err)
+		}
+		if pr.SAMLRequest != "" {
+			if err := samlLogin(ctx, pr, creds, params); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	core.Log.Infof("GlobalProtect", "Logging in as %q on gateway %s...", creds.Username, gateway)
+	sess, err := c.login(ctx, gateway, port, creds)
+	if err != nil {
+		return "", nil, err
+	}
+	return gateway, sess, nil
+}
+
+// establish fetches the tunnel configuration and starts the data transport:
+// ESP if enabled and the gateway answers, otherwise the HTTPS tunnel.
+// Must be called under p.mu.
+func (p *Provider) establish(ctx context.Context, c *client, gateway string, sess *session, controlFn func(string, string, syscall.RawConn) error) error {
+	port := p.config.Port
+	gc, err := c.getConfig(ctx, gateway, port, sess)
+	if err != nil {
+		return err
+	}
+	adapterIP, _ := netip.ParseAddr(gc.IPAddress)
+	include, exclude := gc.routes()
+
+	core.Log.Infof("GlobalProtect", "Tunnel config: IP=%s MTU=%d DNS=%v routes=%d excludes=%d",
+		adapterIP, gc.MTU, gc.DNS, len(include), len(exclude))
+	if gc.IdleTimeout > 0 {
+		core.Log.Infof("GlobalProtect", "Idle timeout: %d seconds", gc.IdleTimeout)
+	}
+	if gc.Lifetime > 0 {
+		core.Log.Infof("GlobalProtect", "Session lifetime: %d seconds", gc.Lifetime)
+	}
+
+	var t transport
+	if p.config.ESP && gc.IPsec != nil && gc.IPsec.UDPPort > 0 {
+		t = p.tryESP(ctx, gc, adapterIP, controlFn)
+	}
+	if t == nil {
+		tlsConn, err := p.dialTLS(ctx, gateway, port, controlFn)
+		if err != nil {
+			return err
+		}
+		h, err := startHTTPSTunnel(tlsConn, sess)
+		if err != nil {
+			tlsConn.Close()
+			return err
+		}
+		h.onDisconnect = p.onTransportDrop
+		h.run()
+		t = h
+		core.Log.Infof("GlobalProtect", "HTTPS transport active")
+	}
+
+	p.adapterIP = adapterIP
+	p.splitInclude = include
+	p.splitExclude = exclude
+	p.dns = gc.DNS
+	p.transport = t
+	if p.inboundHandler != nil {
+		t.setInboundHandler(p.inboundHandler)
+	}
+	p.state = core.TunnelStateUp
+	core.Log.Infof("GlobalProtect", "Tunnel %q is UP", p.name)
+	return nil
+}
+
+// tryESP probes the gateway's ESP endpoint; nil means fall back to HTTPS.
+func (p *Provider) tryESP(ctx context.Context, gc *gatewayConfig, adapterIP netip.Addr, controlFn func(string, string, syscall.RawConn) error) transport {
+	gwAddr, err := netip.ParseAddr(gc.GatewayAddress)
+	if err != nil || !gwAddr.Is4() || !adapterIP.Is4() {
+		core.Log.Warnf("GlobalProtect", "ESP unavailable (gw-address %q), using HTTPS", gc.GatewayAddress)
+		return nil
+	}
+	gw := netip.AddrPortFrom(gwAddr, uint16(gc.IPsec.UDPPort))
+	e, err := dialESP(ctx, controlFn, gw, adapterIP, gc.IPsec)
+	if err != nil {
+		core.Log.Warnf("GlobalProtect", "ESP failed, using HTTPS: %v", err)
+		return nil
+	}
+	p.addEndpoint(gw)
+	e.onDisconnect = p.onTransportDrop
+	e.run()
+	core.Log.Infof("GlobalProtect", "ESP transport active (%s, %s/%s)", gw, gc.IPsec.EncAlgo, gc.IPsec.HMACAlgo)
+	return e
+}
+
+// onTransportDrop marks the tunnel failed and notifies the controller.
+func (p *Provider) onTransportDrop(err error) {
+	core.Log.Warnf("GlobalProtect", "Session dropped for %q: %v", p.name, err)
+	p.mu.Lock()
+	p.state = core.TunnelStateError
+	p.transport = nil
+	onDrop := p.onSessionDrop
+	p.mu.Unlock()
+	if onDrop != nil {
+		onDrop(p.name, err)
+	}
+}
+
+// newHTTPClient returns an HTTP client for the XML API whose connections go
+// through dialTLS (real NIC, proxy, client certificate).
+func (p *Provider) newHTTPClient(controlFn func(string, string, syscall.RawConn) error) *http.Client {
+	tr := &http.Transport{
+		DialTLSContext: func(ctx context.Context, _, addr string) (net.Conn, error) {
+			host, portStr, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			port, _ := strconv.Atoi(portStr)
+			return p.dialTLS(ctx, host, port, controlFn)
+		},
+		MaxIdleConnsPerHost: 1,
+	}
+	return &http.Client{Transport: tr, Timeout: 30 * time.Second}
+}
+
+// dialTLS connects to a portal or gateway and records its address for bypass routes.
+func (p *Provider) dialTLS(ctx context.Context, server string, port int, controlFn func(string, string, syscall.RawConn) error) (*tls.Conn, error) {
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: p.config.TLSSkipVerify,
+		ServerName:         server,
+		Certificates:       p.tlsCerts,
+	}
+	proxy := anyconnect.Proxy{URL: p.config.ProxyURL, Username: p.config.ProxyUsername, Password: p.config.ProxyPassword}
+	conn, endpoint, err := anyconnect.DialTLS(ctx, server, port, tlsCfg, controlFn, proxy)
+	if endpoint.IsValid() {
+		p.addEndpoint(endpoint)
+	}
+	return conn, err
+}
+
+// addEndpoint records a server address for bypass routes. Called under p.mu
+// (Connect holds it for the whole handshake).
+func (p *Provider) addEndpoint(ep netip.AddrPort) {
+	for _, e := range p.serverEndpoints {
+		if e == ep {
+			return
+		}
+	}
+	p.serverEndpoints = append(p.serverEndpoints, ep)
+}
+
+// Disconnect tears down the tunnel. The gateway login is kept for resumption.
+func (p *Provider) Disconnect() error {
+	// Release the lock before stop(): the transport's onDisconnect path
+	// also takes p.mu.
+	p.mu.Lock()
+	t := p.transport
+	p.transport = nil
+	p.mu.Unlock()
+
+	if t != nil {
+		t.stop()
+	}
+
+	p.mu.Lock()
+	p.state = core.TunnelStateDown
+	p.adapterIP = netip.Addr{}
+	p.mu.Unlock()
+
+	core.Log.Infof("GlobalProtect", "Tunnel %q disconnected", p.name)
+	return nil
+}
+
+// ---- RawForwarder interface ----
+
+// InjectOutbound sends an IP packet through the tunnel.
+func (p *Provider) InjectOutbound(pkt []byte) bool {
+	return p.sendData(pkt)
+}
+
+// InjectOutboundPriority sends an IP packet (priority is ignored).
+func (p *Provider) InjectOutboundPriority(pkt []byte, _ byte) bool {
+	return p.sendData(pkt)
+}
+
+// SetInboundHandler registers a callback for incoming IP packets from the tunnel.
+// Wraps the handler with the DialUDP response interceptor.
+func (p *Provider) SetInboundHandler(handler func(pkt []byte) bool) {
+	wrapped := func(pkt []byte) bool {
+		if p.rawUDP.Intercept(pkt) {
+			return true
+		}
+		return handler(pkt)
+	}
+
+	p.mu.Lock()
+	p.inboundHandler = &wrapped
+	t := p.transport
+	p.mu.Unlock()
+
+	if t != nil {
+		t.setInboundHandler(&wrapped)
+	}
+}
+
+func (p *Provider) sendData(pkt []byte) bool {
+	p.mu.RLock()
+	t := p.transport
+	p.mu.RUnlock()
+	return t != nil && t.sendData(pkt)
+}
+
+// ---- Network Roaming ----
+
+// HandleNetworkChange closes the transport after a network change so the
+// controller reconnects with the saved gateway login. Debounced to 3 seconds.
+func (p *Provider) HandleNetworkChange() {
+	p.networkChangeMu.Lock()
+	if time.Since(p.networkChangeTime) < 3*time.Second {
+		p.networkChangeMu.Unlock()
+		return
+	}
+	p.networkChangeTime = time.Now()
+	p.networkChangeMu.Unlock()
+
+	p.mu.Lock()
+	t := p.transport
+	up := p.state == core.TunnelStateUp && p.savedSession != nil
+	p.transport = nil
+	if !up {
+		p.transport = t
+	}
+	p.mu.Unlock()
+
+	if !up || t == nil {
+		return
+	}
+	core.Log.Infof("GlobalProtect", "Network change detected for %q, triggering session resume", p.name)
+	t.stop()
+	p.onTransportDrop(errors.New("network changed"))
+}
+
+// ---- DialTCP / DialUDP ----
+// GlobalProtect uses RawForwarder for IP-level forwarding.
+
+func (p *Provider) DialTCP(_ context.Context, _ string) (net.Conn, error) {
+	return nil, fmt.Errorf("globalprotect: DialTCP not supported, use RawForwarder")
+}
+
+// DialUDP creates a virtual UDP connection tunneled at the raw IP level.
+func (p *Provider) DialUDP(_ context.Context, addr string) (net.Conn, error) {
+	p.mu.RLock()
+	t := p.transport
+	adapterIP := p.adapterIP
+	p.mu.RUnlock()
+
+	if t == nil {
+		return nil, fmt.Errorf("globalprotect: not connected")
+	}
+	conn, err := p.rawUDP.Dial(addr, adapterIP)
+	if err != nil {
+		return nil, fmt.Errorf("globalprotect: %w", err)
+	}
+	return conn, nil
+}
+
+var (
+	_ provider.TunnelProvider     = (*Provider)(nil)
+	_ provider.RawForwarder       = (*Provider)(nil)
+	_ provider.EndpointProvider   = (*Provider)(nil)
+	_ provider.SplitRouteProvider = (*Provider)(nil)
+	_ provider.DNSProvider        = (*Provider)(nil)
+	_ provider.AuthParamSetter    = (*Provider)(nil)
+)
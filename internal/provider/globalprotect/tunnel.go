@@ -0,0 +1,188 @@
+package globalprotect
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// transport carries IP packets between the provider and the gateway:
+// the HTTPS tunnel or ESP over UDP.
+type transport interface {
+	sendData(pkt []byte) bool
+	setInboundHandler(h *func(pkt []byte) bool)
+	// stop closes the transport without firing onDisconnect.
+	stop()
+}
+
+// GlobalProtect HTTPS tunnel frame header (16 bytes):
+// magic 1a2b3c4d, ethertype BE16, payload length BE16, 01 00 00 00, 00 00 00 00.
+// A header with ethertype and length zero is a keepalive.
+const (
+	gpstHeaderLen = 16
+	gpstMagic     = 0x1a2b3c4d
+
+	etherTypeIPv4 = 0x0800
+	etherTypeIPv6 = 0x86dd
+
+	keepaliveInterval = 10 * time.Second
+)
+
+// httpsConn is the GlobalProtect HTTPS (GPST) tunnel over TLS.
+type httpsConn struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+
+	inboundHandler atomic.Pointer[func(pkt []byte) bool]
+
+	// onDisconnect is called when the readLoop exits (server close, I/O error).
+	onDisconnect func(error)
+
+	// cleanShutdown suppresses onDisconnect on intentional stop.
+	cleanShutdown atomic.Bool
+
+	stopped chan struct{}
+}
+
+// startHTTPSTunnel requests the tunnel on an established TLS connection and
+// waits for the server's START_TUNNEL reply.
+func startHTTPSTunnel(conn net.Conn, s *session) (*httpsConn, error) {
+	q := url.Values{}
+	q.Set("user", s.User)
+	q.Set("authcookie", s.AuthCookie)
+	req := "GET /ssl-tunnel-connect.sslvpn?" + q.Encode() + " HTTP/1.1\r\n\r\n"
+
+	conn.SetDeadline(time.Now().Add(15 * time.Second))
+	if _, err := io.WriteString(conn, req); err != nil {
+		return nil, fmt.Errorf("write tunnel request: %w", err)
+	}
+	br := bufio.NewReader(conn)
+	reply := make([]byte, len("START_TUNNEL"))
+	if _, err := io.ReadFull(br, reply); err != nil {
+		return nil, fmt.Errorf("read tunnel reply: %w", err)
+	}
+	if string(reply) != "START_TUNNEL" {
+		// Typically "HTTP/1.1 502 Bad Gateway" when the cookie is no longer valid.
+		line, _ := br.ReadString('\n')
+		return nil, fmt.Errorf("tunnel rejected: %s", bytes.TrimSpace(append(reply, line...)))
+	}
+	conn.SetDeadline(time.Time{})
+
+	return &httpsConn{
+		conn:    conn,
+		br:      br,
+		stopped: make(chan struct{}),
+	}, nil
+}
+
+// run starts the read and keepalive goroutines.
+func (c *httpsConn) run() {
+	go c.readLoop()
+	go c.keepaliveLoop()
+}
+
+func (c *httpsConn) stop() {
+	c.cleanShutdown.Store(true)
+	c.conn.Close()
+	<-c.stopped
+}
+
+func (c *httpsConn) setInboundHandler(h *func(pkt []byte) bool) {
+	c.inboundHandler.Store(h)
+}
+
+func (c *httpsConn) readLoop() {
+	defer close(c.stopped)
+
+	var exitErr error
+	defer func() {
+		if c.onDisconnect != nil && !c.cleanShutdown.Load() {
+			c.onDisconnect(exitErr)
+		}
+	}()
+
+	hdr := make([]byte, gpstHeaderLen)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(3 * keepaliveInterval))
+
+		if _, err := io.ReadFull(c.br, hdr); err != nil {
+			exitErr = fmt.Errorf("read header: %w", err)
+			return
+		}
+		if binary.BigEndian.Uint32(hdr[0:4]) != gpstMagic {
+			exitErr = fmt.Errorf("invalid GPST magic")
+			return
+		}
+		etherType := binary.BigEndian.Uint16(hdr[4:6])
+		pktLen := binary.BigEndian.Uint16(hdr[6:8])
+		if pktLen == 0 {
+			// Keepalive reply.
+			continue
+		}
+		buf := make([]byte, pktLen)
+		if _, err := io.ReadFull(c.br, buf); err != nil {
+			exitErr = fmt.Errorf("read data: %w", err)
+			return
+		}
+		if etherType != etherTypeIPv4 && etherType != etherTypeIPv6 {
+			continue
+		}
+		if hp := c.inboundHandler.Load(); hp != nil {
+			(*hp)(buf)
+		}
+	}
+}
+
+func (c *httpsConn) keepaliveLoop() {
+	ticker := time.NewTicker(keepaliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.write(gpstFrame(0, nil))
+		case <-c.stopped:
+			return
+		}
+	}
+}
+
+// sendData sends an IP packet in a GPST frame.
+func (c *httpsConn) sendData(pkt []byte) bool {
+	if len(pkt) == 0 || len(pkt) > 65535 {
+		return false
+	}
+	etherType := uint16(etherTypeIPv4)
+	if pkt[0]>>4 == 6 {
+		etherType = etherTypeIPv6
+	}
+	return c.write(gpstFrame(etherType, pkt))
+}
+
+func (c *httpsConn) write(frame []byte) bool {
+	c.writeMu.Lock()
+	c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	_, err := c.conn.Write(frame)
+	c.writeMu.Unlock()
+	return err == nil
+}
+
+// gpstFrame builds a GPST frame; etherType 0 with no payload is a keepalive.
+func gpstFrame(etherType uint16, pkt []byte) []byte {
+	frame := make([]byte, gpstHeaderLen+len(pkt))
+	binary.BigEndian.PutUint32(frame[0:4], gpstMagic)
+	binary.BigEndian.PutUint16(frame[4:6], etherType)
+	binary.BigEndian.PutUint16(frame[6:8], uint16(len(pkt)))
+	if etherType != 0 {
+		frame[8] = 0x01
+	}
+	copy(frame[gpstHeaderLen:], pkt)
+	return frame
+}
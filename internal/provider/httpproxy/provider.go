@@ -15,6 +15,7 @@ import (
 
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/obfs"
 )
 
 // Config holds HTTP proxy-specific tunnel configuration.
@@ -31,6 +32,8 @@ type Config struct {
 	TLS bool `yaml:"tls"`
 	// TLSSkipVerify disables TLS certificate verification (insecure).
 	TLSSkipVerify bool `yaml:"tls_skip_verify"`
+	// Obfs wraps the connection to the proxy (TLS, WebSocket).
+	Obfs obfs.Config `yaml:"obfs"`
 }
 
 // Provider implements TunnelProvider for HTTP CONNECT proxy protocol.
@@ -53,6 +56,9 @@ func New(name string, cfg Config) (*Provider, error) {
 	if cfg.Port <= 0 || cfg.Port > 65535 {
 		return nil, fmt.Errorf("[HTTP] invalid port %d", cfg.Port)
 	}
+	if err := cfg.Obfs.Validate(false); err != nil {
+		return nil, fmt.Errorf("[HTTP] %w", err)
+	}
 
 	return &Provider{
 		config: cfg,
@@ -71,7 +77,14 @@ func (p *Provider) Connect(ctx context.Context) error {
 	core.Log.Infof("HTTP", "Connecting tunnel %q to %s...", p.name, serverStr)
 
 	// Resolve server address for bypass routes.
-	if ap, err := netip.ParseAddrPort(serverStr); err == nil {
+	if p.config.Obfs.Server != "" {
+		ap, err := p.config.Obfs.ResolveServer(ctx, net.DefaultResolver)
+		if err != nil {
+			p.state = core.TunnelStateError
+			return fmt.Errorf("[HTTP] %w", err)
+		}
+		p.serverAddr = ap
+	} else if ap, err := netip.ParseAddrPort(serverStr); err == nil {
 		p.serverAddr = ap
 	} else {
 		ips, err := net.DefaultResolver.LookupHost(ctx, p.config.Server)
@@ -86,8 +99,11 @@ func (p *Provider) Connect(ctx context.Context) error {
 		}
 	}
 
-	// Probe: verify the proxy is reachable.
-	probeConn, err := net.DialTimeout("tcp", serverStr, 10*time.Second)
+	// Probe: verify the proxy is reachable (through the obfuscation layer,
+	// if any).
+	probeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	probeConn, err := p.dialServer(probeCtx, serverStr)
+	cancel()
 	if err != nil {
 		p.state = core.TunnelStateError
 		return fmt.Errorf("[HTTP] server unreachable at %s: %w", serverStr, err)
@@ -95,7 +111,7 @@ func (p *Provider) Connect(ctx context.Context) error {
 	probeConn.Close()
 
 	p.state = core.TunnelStateUp
-	core.Log.Infof("HTTP", "Tunnel %q is UP (server=%s, tls=%v)", p.name, serverStr, p.config.TLS)
+	core.Log.Infof("HTTP", "Tunnel %q is UP (server=%s, tls=%v, obfs=%q)", p.name, serverStr, p.config.TLS, p.config.Obfs.Mode)
 	return nil
 }
 
@@ -134,8 +150,7 @@ func (p *Provider) DialTCP(ctx context.Context, addr string) (net.Conn, error) {
 	serverStr := net.JoinHostPort(p.config.Server, fmt.Sprintf("%d", p.config.Port))
 
 	// Connect to the proxy server.
-	d := net.Dialer{Timeout: 10 * time.Second}
-	rawConn, err := d.DialContext(ctx, "tcp", serverStr)
+	rawConn, err := p.dialServer(ctx, serverStr)
 	if err != nil {
 		return nil, fmt.Errorf("[HTTP] connect to proxy: %w", err)
 	}
@@ -200,6 +215,15 @@ func (p *Provider) DialTCP(ctx context.Context, addr string) (net.Conn, error) {
 	return conn, nil
 }
 
+// dialServer opens the transport connection to the proxy server.
+func (p *Provider) dialServer(ctx context.Context, serverStr string) (net.Conn, error) {
+	if p.config.Obfs.Enabled() {
+		return p.config.Obfs.Dialer().DialContext(ctx, "tcp", serverStr)
+	}
+	d := net.Dialer{Timeout: 10 * time.Second}
+	return d.DialContext(ctx, "tcp", serverStr)
+}
+
 // DialUDP is not supported by HTTP CONNECT proxy.
 func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
 	return nil, provider.ErrUDPNotSupported
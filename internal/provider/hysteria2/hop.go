@@ -0,0 +1,335 @@
+package hysteria2
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// DefaultHopInterval is how often a multi-port server switches ports.
+	DefaultHopInterval = 30 * time.Second
+	// minHopInterval matches the lower bound enforced by the reference client.
+	minHopInterval = 5 * time.Second
+
+	hopRecvQueue = 1024
+)
+
+// splitServer splits "host:ports" where ports is a single port, a
+// comma-separated list, ranges ("20000-30000") or a mix of those.
+// A missing port means 443.
+func splitServer(server string) (string, []uint16, error) {
+	host, portSpec, err := net.SplitHostPort(server)
+	if err != nil {
+		// No port at all (a bare IPv6 address has to be bracketed).
+		if strings.Contains(server, ":") && !strings.HasPrefix(server, "[") {
+			return "", nil, fmt.Errorf("invalid server address %q: %w", server, err)
+		}
+		host, portSpec = strings.Trim(server, "[]"), "443"
+	}
+	if host == "" {
+		return "", nil, fmt.Errorf("invalid server address %q: empty host", server)
+	}
+	ports, err := parsePorts(portSpec)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid server address %q: %w", server, err)
+	}
+	return host, ports, nil
+}
+
+// parsePorts expands a port list such as "443,8443,20000-20010".
+func parsePorts(spec string) ([]uint16, error) {
+	var ports []uint16
+	seen := make(map[uint16]bool)
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		start, err := parsePort(lo)
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if isRange {
+			if end, err = parsePort(hi); err != nil {
+				return nil, err
+			}
+			if end < start {
+				return nil, fmt.Errorf("invalid port range %q", part)
+			}
+		}
+		for p := int(start); p <= int(end); p++ {
+			if !seen[uint16(p)] {
+				seen[uint16(p)] = true
+				ports = append(ports, uint16(p))
+			}
+		}
+	}
+	return ports, nil
+}
+
+func parsePort(s string) (uint16, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 1 || n > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return uint16(n), nil
+}
+
+// hopAddr is the fixed remote address the QUIC connection sees while the
+// real destination port changes underneath it.
+type hopAddr struct {
+	addr  netip.Addr
+	ports []uint16
+}
+
+func (a *hopAddr) Network() string { return "udphop" }
+
+func (a *hopAddr) String() string {
+	if len(a.ports) == 1 {
+		return netip.AddrPortFrom(a.addr, a.ports[0]).String()
+	}
+	return net.JoinHostPort(a.addr.String(), fmt.Sprintf("%d-ports", len(a.ports)))
+}
+
+// hopConnFactory creates port-hopping packet conns; with a single port it
+// behaves like a plain UDP socket.
+type hopConnFactory struct {
+	interval time.Duration
+	next     func(net.PacketConn) net.PacketConn // optional wrapper (obfuscation)
+}
+
+func (f *hopConnFactory) New(addr net.Addr) (net.PacketConn, error) {
+	ha, ok := addr.(*hopAddr)
+	if !ok {
+		return nil, fmt.Errorf("unexpected server address type %T", addr)
+	}
+	conn, err := newHopConn(ha, f.interval)
+	if err != nil {
+		return nil, err
+	}
+	if f.next != nil {
+		return f.next(conn), nil
+	}
+	return conn, nil
+}
+
+type hopPacket struct {
+	buf []byte
+	n   int
+}
+
+// hopConn is a net.PacketConn that sends to addr:ports[i] and switches to a
+// new random port (and a fresh local socket) every interval. The previous
+// socket keeps receiving until the next hop so in-flight replies are not
+// lost. All packets are reported as coming from the hopAddr.
+type hopConn struct {
+	addr     *hopAddr
+	interval time.Duration
+
+	mu         sync.Mutex
+	cur        *net.UDPConn
+	prev       *net.UDPConn
+	target     *net.UDPAddr
+	closed     bool
+	deadline   time.Time
+	deadlineCh chan struct{} // closed when the read deadline changes
+	readBuf    int           // socket buffer sizes, re-applied after each hop
+	writeBuf   int
+
+	recv    chan hopPacket
+	done    chan struct{}
+	bufPool sync.Pool
+}
+
+func newHopConn(addr *hopAddr, interval time.Duration) (*hopConn, error) {
+	if interval < minHopInterval {
+		interval = DefaultHopInterval
+	}
+	c := &hopConn{
+		addr:       addr,
+		interval:   interval,
+		recv:       make(chan hopPacket, hopRecvQueue),
+		done:       make(chan struct{}),
+		deadlineCh: make(chan struct{}),
+	}
+	c.bufPool.New = func() any { return make([]byte, 64*1024) }
+
+	conn, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	c.cur = conn
+	c.target = c.pickTarget()
+	core.SafeGo("hysteria2.hop-recv", func() { c.recvLoop(conn) })
+	if len(addr.ports) > 1 {
+		core.SafeGo("hysteria2.hop", c.hopLoop)
+	}
+	return c, nil
+}
+
+func (c *hopConn) pickTarget() *net.UDPAddr {
+	port := c.addr.ports[rand.IntN(len(c.addr.ports))]
+	return net.UDPAddrFromAddrPort(netip.AddrPortFrom(c.addr.addr, port))
+}
+
+func (c *hopConn) recvLoop(conn *net.UDPConn) {
+	for {
+		buf := c.bufPool.Get().([]byte)
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			c.bufPool.Put(buf)
+			return // socket closed by a hop or Close
+		}
+		select {
+		case c.recv <- hopPacket{buf: buf, n: n}:
+		default:
+			c.bufPool.Put(buf) // queue full: drop like a full socket buffer
+		}
+	}
+}
+
+func (c *hopConn) hopLoop() {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+		conn, err := net.ListenUDP("udp", nil)
+		if err != nil {
+			core.Log.Warnf("Hysteria2", "Port hop failed: %v", err)
+			continue
+		}
+		c.mu.Lock()
+		if c.closed {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		if c.prev != nil {
+			c.prev.Close()
+		}
+		if c.readBuf > 0 {
+			conn.SetReadBuffer(c.readBuf)
+		}
+		if c.writeBuf > 0 {
+			conn.SetWriteBuffer(c.writeBuf)
+		}
+		c.prev, c.cur = c.cur, conn
+		c.target = c.pickTarget()
+		target := c.target
+		c.mu.Unlock()
+		core.SafeGo("hysteria2.hop-recv", func() { c.recvLoop(conn) })
+		core.Log.Debugf("Hysteria2", "Hopped to %s", target)
+	}
+}
+
+func (c *hopConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		c.mu.Lock()
+		deadline, deadlineCh := c.deadline, c.deadlineCh
+		c.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			d := time.Until(deadline)
+			if d <= 0 {
+				return 0, nil, os.ErrDeadlineExceeded
+			}
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+		var p hopPacket
+		var err error
+		select {
+		case p = <-c.recv:
+		case <-c.done:
+			err = net.ErrClosed
+		case <-timeout:
+			err = os.ErrDeadlineExceeded
+		case <-deadlineCh:
+			// Deadline changed: wait again with the new one.
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		switch {
+		case err != nil:
+			return 0, nil, err
+		case p.buf != nil:
+			n := copy(b, p.buf[:p.n])
+			c.bufPool.Put(p.buf)
+			return n, c.addr, nil
+		}
+	}
+}
+
+func (c *hopConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	c.mu.Lock()
+	conn, target, closed := c.cur, c.target, c.closed
+	c.mu.Unlock()
+	if closed {
+		return 0, net.ErrClosed
+	}
+	return conn.WriteToUDP(b, target)
+}
+
+func (c *hopConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	if c.prev != nil {
+		c.prev.Close()
+	}
+	return c.cur.Close()
+}
+
+func (c *hopConn) LocalAddr() net.Addr {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cur.LocalAddr()
+}
+
+func (c *hopConn) SetDeadline(t time.Time) error { return c.SetReadDeadline(t) }
+
+func (c *hopConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	close(c.deadlineCh)
+	c.deadlineCh = make(chan struct{})
+	return nil
+}
+
+func (c *hopConn) SetWriteDeadline(time.Time) error { return nil }
+
+// SetReadBuffer and SetWriteBuffer let quic-go size the socket buffers;
+// the sizes carry over to the sockets opened by later hops.
+func (c *hopConn) SetReadBuffer(n int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readBuf = n
+	return c.cur.SetReadBuffer(n)
+}
+
+func (c *hopConn) SetWriteBuffer(n int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeBuf = n
+	return c.cur.SetWriteBuffer(n)
+}
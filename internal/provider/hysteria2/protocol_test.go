@@ -0,0 +1,140 @@
+package hysteria2
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestSplitServer(t *testing.T) {
+	tests := []struct {
+		server string
+		host   string
+		ports  int
+		first  uint16
+	}{
+		{"example.com:443", "example.com", 1, 443},
+		{"example.com", "example.com", 1, 443},
+		{"example.com:443,20000-20009", "example.com", 11, 443},
+		{"[2001:db8::1]:1000-1002", "2001:db8::1", 3, 1000},
+		{"1.2.3.4:5000,5000,5001", "1.2.3.4", 2, 5000},
+	}
+	for _, tt := range tests {
+		host, ports, err := splitServer(tt.server)
+		if err != nil {
+			t.Errorf("%s: %v", tt.server, err)
+			continue
+		}
+		if host != tt.host || len(ports) != tt.ports || ports[0] != tt.first {
+			t.Errorf("%s: host=%q ports=%v", tt.server, host, ports)
+		}
+	}
+	for _, bad := range []string{"example.com:0", "example.com:3000-2000", "example.com:abc", ":443", "2001:db8::1"} {
+		if _, _, err := splitServer(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestParseURI(t *testing.T) {
+	cfg, name, err := ParseURI("hysteria2://letmein@example.com:443,20000-30000/?sni=real.example.com&obfs=salamander&obfs-password=gawr&insecure=1#My%20Server")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if name != "My Server" {
+		t.Errorf("name = %q", name)
+	}
+	want := Config{
+		Server:       "example.com:443,20000-30000",
+		Password:     "letmein",
+		SNI:          "real.example.com",
+		Insecure:     true,
+		ObfsType:     "salamander",
+		ObfsPassword: "gawr",
+	}
+	if cfg != want {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	cfg, _, err = ParseURI("hy2://user:p%40ss@[2001:db8::1]?mport=1000-2000&hop-interval=15s")
+	if err != nil {
+		t.Fatalf("parse hy2: %v", err)
+	}
+	if cfg.Password != "user:p@ss" || cfg.Server != "[2001:db8::1]:1000-2000" || cfg.HopInterval != 15 {
+		t.Errorf("cfg = %+v", cfg)
+	}
+
+	for _, bad := range []string{"vless://x@y:1", "hysteria2://example.com:443", "hysteria2://pw@:443", "hysteria2://pw@h:99999"} {
+		if _, _, err := ParseURI(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestHopConn(t *testing.T) {
+	// Two echo servers on consecutive-looking ports stand in for a
+	// multi-port server.
+	var ports []uint16
+	for i := 0; i < 2; i++ {
+		srv, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer srv.Close()
+		ports = append(ports, uint16(srv.LocalAddr().(*net.UDPAddr).Port))
+		go func() {
+			buf := make([]byte, 2048)
+			for {
+				n, from, err := srv.ReadFromUDP(buf)
+				if err != nil {
+					return
+				}
+				srv.WriteToUDP(buf[:n], from)
+			}
+		}()
+	}
+
+	addr := &hopAddr{addr: netip.MustParseAddr("127.0.0.1"), ports: ports}
+	conn, err := newHopConn(addr, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteTo([]byte("ping"), addr); err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	buf := make([]byte, 64)
+	n, from, err := conn.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "ping" || from != addr {
+		t.Fatalf("read = %q from %v, %v", buf[:n], from, err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(10 * time.Millisecond))
+	if _, _, err := conn.ReadFrom(buf); err == nil {
+		t.Error("expected deadline error")
+	} else if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("err = %v, want timeout", err)
+	}
+
+	conn.Close()
+	if _, err := conn.WriteTo([]byte("x"), addr); err == nil {
+		t.Error("write after close succeeded")
+	}
+}
+
+func TestSalamanderRoundTrip(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	data := []byte("hello, hysteria2 over salamander")
+	enc := append([]byte(nil), data...)
+	xorBytes(enc, key)
+	if string(enc) == string(data) {
+		t.Fatal("xor did not change data")
+	}
+	xorBytes(enc, key)
+	if string(enc) != string(data) {
+		t.Errorf("round trip = %q", enc)
+	}
+}
@@ -18,7 +18,9 @@ import (
 
 // Config holds Hysteria2-specific tunnel configuration.
 type Config struct {
-	// Server is the Hysteria2 server address in host:port format.
+	// Server is the Hysteria2 server address in host:port format. The port
+	// part may list several ports and ranges ("443,20000-30000") for
+	// port hopping.
 	Server string `yaml:"server"`
 	// Password is the authentication password.
 	Password string `yaml:"password"`
@@ -34,6 +36,9 @@ type Config struct {
 	UpMbps int `yaml:"up_mbps"`
 	// DownMbps is the download bandwidth hint in Mbps (0 = auto/BBR).
 	DownMbps int `yaml:"down_mbps"`
+	// HopInterval is the port hopping interval in seconds for multi-port
+	// servers (0 = 30, minimum 5).
+	HopInterval int `yaml:"hop_interval"`
 }
 
 // Provider implements TunnelProvider for Hysteria2 (QUIC-based) protocol.
@@ -65,6 +70,12 @@ func New(name string, cfg Config) (*Provider, error) {
 	if cfg.ObfsType == "salamander" && cfg.ObfsPassword == "" {
 		return nil, fmt.Errorf("[Hysteria2] obfs_password is required when obfs_type is \"salamander\"")
 	}
+	if _, _, err := splitServer(cfg.Server); err != nil {
+		return nil, fmt.Errorf("[Hysteria2] %w", err)
+	}
+	if cfg.HopInterval != 0 && time.Duration(cfg.HopInterval)*time.Second < minHopInterval {
+		return nil, fmt.Errorf("[Hysteria2] hop_interval must be at least %s", minHopInterval)
+	}
 
 	return &Provider{
 		config: cfg,
@@ -81,20 +92,20 @@ func (p *Provider) Connect(ctx context.Context) error {
 	p.state = core.TunnelStateConnecting
 	core.Log.Infof("Hysteria2", "Connecting tunnel %q to %s...", p.name, p.config.Server)
 
-	// Parse or resolve server address.
-	host, portStr, err := net.SplitHostPort(p.config.Server)
+	// Parse server address: one port, or several for port hopping.
+	host, ports, err := splitServer(p.config.Server)
 	if err != nil {
 		p.state = core.TunnelStateError
-		return fmt.Errorf("[Hysteria2] invalid server address %q: %w", p.config.Server, err)
+		return fmt.Errorf("[Hysteria2] %w", err)
 	}
 
 	// Resolve server IP for bypass routes.
-	serverAddr, err := resolveAddrPort(ctx, host, portStr)
+	serverIP, err := resolveHost(ctx, host)
 	if err != nil {
 		p.state = core.TunnelStateError
-		return fmt.Errorf("[Hysteria2] resolve %q: %w", p.config.Server, err)
+		return fmt.Errorf("[Hysteria2] resolve %q: %w", host, err)
 	}
-	p.serverAddr = serverAddr
+	p.serverAddr = netip.AddrPortFrom(serverIP, ports[0])
 
 	// Determine SNI.
 	sni := p.config.SNI
@@ -104,7 +115,7 @@ func (p *Provider) Connect(ctx context.Context) error {
 
 	// Build hysteria2 client config.
 	hyConfig := &hyclient.Config{
-		ServerAddr: net.UDPAddrFromAddrPort(serverAddr),
+		ServerAddr: &hopAddr{addr: serverIP, ports: ports},
 		Auth:       p.config.Password,
 		TLSConfig: hyclient.TLSConfig{
 			ServerName:         sni,
@@ -117,12 +128,20 @@ func (p *Provider) Connect(ctx context.Context) error {
 		FastOpen: true,
 	}
 
-	// Set up obfuscation via custom ConnFactory if salamander is requested.
+	// Port hopping is a no-op for single-port servers; Salamander obfuscation
+	// wraps the hopping socket.
+	hopInterval := time.Duration(p.config.HopInterval) * time.Second
+	if hopInterval == 0 {
+		hopInterval = DefaultHopInterval
+	}
+	factory := &hopConnFactory{interval: hopInterval}
 	if p.config.ObfsType == "salamander" {
-		hyConfig.ConnFactory = &salamanderConnFactory{
-			password: p.config.ObfsPassword,
+		key := sha256.Sum256([]byte(p.config.ObfsPassword))
+		factory.next = func(conn net.PacketConn) net.PacketConn {
+			return &salamanderConn{PacketConn: conn, key: key[:]}
 		}
 	}
+	hyConfig.ConnFactory = factory
 
 	// Create the hysteria2 client (performs QUIC handshake + auth).
 	client, info, err := hyclient.NewClient(hyConfig)
@@ -139,8 +158,12 @@ func (p *Provider) Connect(ctx context.Context) error {
 	if info.Tx > 0 {
 		txInfo = fmt.Sprintf("%d Mbps", info.Tx*8/1_000_000)
 	}
-	core.Log.Infof("Hysteria2", "Tunnel %q is UP (server=%s, udp=%v, tx=%s)",
-		p.name, p.serverAddr, p.udpEnabled, txInfo)
+	hopInfo := ""
+	if len(ports) > 1 {
+		hopInfo = fmt.Sprintf(", hopping %d ports every %s", len(ports), hopInterval)
+	}
+	core.Log.Infof("Hysteria2", "Tunnel %q is UP (server=%s, udp=%v, tx=%s%s)",
+		p.name, p.serverAddr, p.udpEnabled, txInfo, hopInfo)
 	return nil
 }
 
@@ -220,6 +243,30 @@ func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
 	}, nil
 }
 
+// ListenUDP opens a Hysteria2 UDP session that is not bound to one target.
+// Implements provider.UDPListener.
+func (p *Provider) ListenUDP(_ context.Context) (net.PacketConn, error) {
+	p.mu.RLock()
+	state := p.state
+	client := p.client
+	udpEnabled := p.udpEnabled
+	p.mu.RUnlock()
+
+	if state != core.TunnelStateUp || client == nil {
+		return nil, fmt.Errorf("[Hysteria2] tunnel %q is not up (state=%d)", p.name, state)
+	}
+	if !udpEnabled {
+		return nil, provider.ErrUDPNotSupported
+	}
+
+	hyUDP, err := client.UDP()
+	if err != nil {
+		p.markErrorIfClosed(err)
+		return nil, fmt.Errorf("[Hysteria2] UDP session: %w", err)
+	}
+	return &udpPacketAdapter{hyConn: hyUDP}, nil
+}
+
 // Name returns the human-readable tunnel name.
 func (p *Provider) Name() string {
 	return p.name
@@ -256,33 +303,27 @@ func (p *Provider) markErrorIfClosed(err error) {
 
 // --- Helpers ---
 
-// resolveAddrPort resolves a host:port string to a netip.AddrPort.
-func resolveAddrPort(ctx context.Context, host, port string) (netip.AddrPort, error) {
+// resolveHost resolves a hostname (or parses an IP literal) to an address.
+func resolveHost(ctx context.Context, host string) (netip.Addr, error) {
 	// Try parsing as an IP first (no DNS needed).
-	addrPort := net.JoinHostPort(host, port)
-	if ap, err := netip.ParseAddrPort(addrPort); err == nil {
-		return ap, nil
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap(), nil
 	}
 
 	// Resolve hostname.
 	ips, err := net.DefaultResolver.LookupHost(ctx, host)
 	if err != nil {
-		return netip.AddrPort{}, err
+		return netip.Addr{}, err
 	}
 	if len(ips) == 0 {
-		return netip.AddrPort{}, fmt.Errorf("no addresses found for %q", host)
+		return netip.Addr{}, fmt.Errorf("no addresses found for %q", host)
 	}
 
 	addr, err := netip.ParseAddr(ips[0])
 	if err != nil {
-		return netip.AddrPort{}, err
+		return netip.Addr{}, err
 	}
-
-	portNum, err := net.DefaultResolver.LookupPort(ctx, "udp", port)
-	if err != nil {
-		return netip.AddrPort{}, err
-	}
-	return netip.AddrPortFrom(addr, uint16(portNum)), nil
+	return addr.Unmap(), nil
 }
 
 // mbpsToBps converts megabits per second to bytes per second.
@@ -358,34 +399,72 @@ func (u *udpConnAdapter) SetWriteDeadline(t time.Time) error {
 	return nil // HyUDPConn does not support deadlines
 }
 
-// --- Salamander Obfuscation ---
+// udpPacketAdapter wraps HyUDPConn into a net.PacketConn: each datagram
+// carries its own destination, and replies name their sender.
+type udpPacketAdapter struct {
+	hyConn hyclient.HyUDPConn
+	mu     sync.Mutex
+	closed bool
+}
 
-// salamanderConnFactory implements hyclient.ConnFactory with Salamander XOR obfuscation.
-// Each UDP packet is XOR'd with a key derived from SHA-256(password).
-type salamanderConnFactory struct {
-	password string
+func (u *udpPacketAdapter) ReadFrom(b []byte) (int, net.Addr, error) {
+	for {
+		data, from, err := u.hyConn.Receive()
+		if err != nil {
+			return 0, nil, err
+		}
+		ap, err := netip.ParseAddrPort(from)
+		if err != nil {
+			continue // sender given by name; cannot be hairpinned back
+		}
+		return copy(b, data), net.UDPAddrFromAddrPort(ap), nil
+	}
 }
 
-func (f *salamanderConnFactory) New(addr net.Addr) (net.PacketConn, error) {
-	conn, err := net.ListenUDP("udp", nil)
-	if err != nil {
-		return nil, err
+func (u *udpPacketAdapter) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if err := u.hyConn.Send(b, addr.String()); err != nil {
+		return 0, err
 	}
-	key := sha256.Sum256([]byte(f.password))
-	return &salamanderConn{
-		UDPConn: conn,
-		key:     key[:],
-	}, nil
+	return len(b), nil
+}
+
+func (u *udpPacketAdapter) Close() error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.closed {
+		return nil
+	}
+	u.closed = true
+	return u.hyConn.Close()
 }
 
-// salamanderConn wraps a UDP connection with Salamander XOR obfuscation.
+func (u *udpPacketAdapter) LocalAddr() net.Addr {
+	return &net.UDPAddr{IP: net.IPv4(0, 0, 0, 0), Port: 0}
+}
+
+func (u *udpPacketAdapter) SetDeadline(t time.Time) error {
+	return nil // HyUDPConn does not support deadlines
+}
+
+func (u *udpPacketAdapter) SetReadDeadline(t time.Time) error {
+	return nil // HyUDPConn does not support deadlines
+}
+
+func (u *udpPacketAdapter) SetWriteDeadline(t time.Time) error {
+	return nil // HyUDPConn does not support deadlines
+}
+
+// --- Salamander Obfuscation ---
+
+// salamanderConn wraps a packet connection with Salamander XOR obfuscation.
+// Each UDP packet is XOR'd with a key derived from SHA-256(password).
 type salamanderConn struct {
-	*net.UDPConn
+	net.PacketConn
 	key []byte
 }
 
 func (c *salamanderConn) ReadFrom(b []byte) (int, net.Addr, error) {
-	n, addr, err := c.UDPConn.ReadFrom(b)
+	n, addr, err := c.PacketConn.ReadFrom(b)
 	if err != nil {
 		return n, addr, err
 	}
@@ -398,7 +477,7 @@ func (c *salamanderConn) WriteTo(b []byte, addr net.Addr) (int, error) {
 	buf := make([]byte, len(b))
 	copy(buf, b)
 	xorBytes(buf, c.key)
-	return c.UDPConn.WriteTo(buf, addr)
+	return c.PacketConn.WriteTo(buf, addr)
 }
 
 // xorBytes XORs data in-place with the key (repeating the key as needed).
@@ -427,6 +506,7 @@ func xorBytes(data, key []byte) {
 
 // Compile-time interface checks.
 var (
-	_ provider.TunnelProvider = (*Provider)(nil)
+	_ provider.TunnelProvider   = (*Provider)(nil)
 	_ provider.EndpointProvider = (*Provider)(nil)
+	_ provider.UDPListener      = (*Provider)(nil)
 )
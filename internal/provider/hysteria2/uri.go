@@ -0,0 +1,126 @@
+package hysteria2
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// IsURI reports whether s is a hysteria2:// or hy2:// share link.
+func IsURI(s string) bool {
+	return strings.HasPrefix(s, "hysteria2://") || strings.HasPrefix(s, "hy2://")
+}
+
+// ParseURI parses a Hysteria2 share link:
+//
+//	hysteria2://auth@host:port[,port|range...]/?sni=...&obfs=salamander&obfs-password=...&insecure=1#name
+//
+// The port part may carry a multi-port spec, and "mport" (used by some
+// clients) overrides it. Returns the config and the display name.
+func ParseURI(uri string) (Config, string, error) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok || (scheme != "hysteria2" && scheme != "hy2") {
+		return Config{}, "", fmt.Errorf("not a hysteria2:// URI")
+	}
+
+	// url.Parse rejects multi-port specs, so split the authority by hand.
+	rest, fragment, _ := strings.Cut(rest, "#")
+	name, err := url.PathUnescape(fragment)
+	if err != nil {
+		name = fragment
+	}
+	rest, rawQuery, _ := strings.Cut(rest, "?")
+	authority, _, _ := strings.Cut(rest, "/")
+	q, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Config{}, "", fmt.Errorf("hysteria2 URI: %w", err)
+	}
+
+	var auth string
+	if at := strings.LastIndex(authority, "@"); at >= 0 {
+		// user:pass auth is sent as-is, joined with a colon.
+		if auth, err = url.PathUnescape(authority[:at]); err != nil {
+			return Config{}, "", fmt.Errorf("hysteria2 URI: invalid auth: %w", err)
+		}
+		authority = authority[at+1:]
+	}
+	if auth == "" {
+		auth = q.Get("auth")
+	}
+	if auth == "" {
+		return Config{}, "", fmt.Errorf("hysteria2 URI: missing auth")
+	}
+
+	host, ports := authority, "443"
+	if h, p, err := net.SplitHostPort(authority); err == nil {
+		host, ports = h, p
+	}
+	host = strings.Trim(host, "[]")
+	if mport := q.Get("mport"); mport != "" {
+		ports = mport
+	}
+	if host == "" {
+		return Config{}, "", fmt.Errorf("hysteria2 URI: missing host")
+	}
+	if _, err := parsePorts(ports); err != nil {
+		return Config{}, "", fmt.Errorf("hysteria2 URI: %w", err)
+	}
+
+	cfg := Config{
+		Server:   net.JoinHostPort(host, ports),
+		Password: auth,
+		SNI:      q.Get("sni"),
+		Insecure: q.Get("insecure") == "1" || q.Get("insecure") == "true",
+	}
+	if obfs := q.Get("obfs"); obfs != "" && obfs != "none" {
+		cfg.ObfsType = obfs
+		cfg.ObfsPassword = q.Get("obfs-password")
+	}
+	if v := q.Get("hop-interval"); v != "" {
+		// Accepts "30" or "30s".
+		if n, err := strconv.Atoi(strings.TrimSuffix(v, "s")); err == nil {
+			cfg.HopInterval = n
+		}
+	}
+	return cfg, name, nil
+}
+
+// ConfigToSettings converts a parsed Config to TunnelConfig settings.
+func ConfigToSettings(cfg Config) map[string]any {
+	settings := map[string]any{
+		"server":   cfg.Server,
+		"password": cfg.Password,
+	}
+	if cfg.SNI != "" {
+		settings["sni"] = cfg.SNI
+	}
+	if cfg.Insecure {
+		settings["insecure"] = true
+	}
+	if cfg.ObfsType != "" {
+		settings["obfs_type"] = cfg.ObfsType
+		settings["obfs_password"] = cfg.ObfsPassword
+	}
+	if cfg.HopInterval > 0 {
+		settings["hop_interval"] = cfg.HopInterval
+	}
+	return settings
+}
+
+// ParseURIToTunnelConfig parses a hysteria2:// share link into a tunnel
+// definition for the subscription manager.
+func ParseURIToTunnelConfig(uri string) (core.TunnelConfig, error) {
+	cfg, name, err := ParseURI(uri)
+	if err != nil {
+		return core.TunnelConfig{}, err
+	}
+	return core.TunnelConfig{
+		Protocol: core.ProtocolHysteria2,
+		Name:     name,
+		Settings: ConfigToSettings(cfg),
+	}, nil
+}
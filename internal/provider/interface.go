@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net"
 	"net/netip"
+	"time"
 
 	"awg-split-tunnel/internal/core"
 )
@@ -12,6 +13,10 @@ import (
 // ErrUDPNotSupported is returned by providers that do not support UDP (e.g. HTTP CONNECT proxy).
 var ErrUDPNotSupported = errors.New("UDP not supported by this provider")
 
+// ErrAuthRequired is returned by Connect when the server asks for an
+// interactive credential (e.g. an OTP code) that was not passed via AuthParamSetter.
+var ErrAuthRequired = errors.New("interactive authentication required")
+
 // RawForwarder allows injecting raw IP packets directly into a VPN tunnel,
 // bypassing the userspace TCP proxy and gVisor stack. Providers that support
 // raw forwarding (e.g. AmneziaWG) implement this interface in addition to
@@ -66,6 +71,31 @@ type EndpointProvider interface {
 	GetServerEndpoints() []netip.AddrPort
 }
 
+// EndpointChange describes a server endpoint that moved to a new address.
+type EndpointChange struct {
+	Old netip.AddrPort
+	New netip.AddrPort
+}
+
+// EndpointRefresher is optionally implemented by providers whose server endpoints
+// may be given as hostnames (e.g. WireGuard/AmneziaWG behind dynamic DNS).
+// RefreshEndpoints re-resolves them with the given resolver and applies changed
+// addresses to the running tunnel without reconnecting. GetServerEndpoints
+// reflects the new addresses afterwards; the returned changes let the caller
+// move bypass routes accordingly.
+type EndpointRefresher interface {
+	RefreshEndpoints(ctx context.Context, resolver *net.Resolver) ([]EndpointChange, error)
+}
+
+// UDPListener is optionally implemented by providers that can open an
+// unconnected UDP socket inside the tunnel (WireGuard netstack, SOCKS5 UDP
+// ASSOCIATE, Hysteria2). One socket sends to and receives from any remote,
+// which the UDP proxy uses for full-cone NAT. Addresses passed to WriteTo
+// and returned by ReadFrom are *net.UDPAddr.
+type UDPListener interface {
+	ListenUDP(ctx context.Context) (net.PacketConn, error)
+}
+
 // AuthParamSetter is optionally implemented by providers that accept ephemeral
 // authentication parameters at connect time (e.g. OTP codes that change every minute).
 // These params are NOT saved to config.
@@ -88,9 +118,45 @@ type DNSProvider interface {
 	GetDNS() []string
 }
 
+// MTUProvider is optionally implemented by providers that know the MTU of
+// their tunnel (WireGuard Interface MTU, AnyConnect X-CSTP-MTU). Path MTU
+// probing never goes above it.
+type MTUProvider interface {
+	MTU() int
+}
+
 // HealthCheckable is optionally implemented by providers that support WireGuard-style
 // IPC health queries. Used by the health monitor to detect stale peers via
 // last_handshake_time inspection.
 type HealthCheckable interface {
 	IpcGet() (string, error)
 }
+
+// PeerInfo is the state of one peer of a multi-peer tunnel.
+type PeerInfo struct {
+	ID            string // selector usable in rules as "tunnel_id/ID"
+	PublicKey     string // base64
+	Endpoint      string
+	AllowedIPs    []netip.Prefix
+	LastHandshake time.Time // zero if no handshake completed
+	RxBytes       uint64
+	TxBytes       uint64
+	Stale         bool // marked stale by the health monitor
+	Active        bool // currently carries at least one route
+}
+
+// MultiPeerProvider is optionally implemented by WireGuard-style providers
+// whose config has several [Peer] sections. Each peer is a sub-exit: rules can
+// pin destinations to it, and prefixes shared by several peers fail over to
+// the next healthy one when a peer stops handshaking.
+type MultiPeerProvider interface {
+	// Peers returns per-peer state and statistics.
+	Peers() ([]PeerInfo, error)
+	// PinPeer routes dst through the peer identified by sel (name, 1-based
+	// index or public key).
+	PinPeer(sel string, dst netip.Addr) error
+	// SetStalePeers marks the peers with the given hex public keys (as in
+	// IpcGet output) stale and re-routes around them. Returns true if routes
+	// moved.
+	SetStalePeers(publicKeys []string) (bool, error)
+}
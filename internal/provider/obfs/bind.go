@@ -0,0 +1,380 @@
+package obfs
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand/v2"
+	"net"
+	"net/netip"
+	"sync"
+
+	"awg-split-tunnel/internal/core"
+
+	"github.com/amnezia-vpn/amneziawg-go/conn"
+	"github.com/coder/websocket"
+)
+
+const (
+	// WireGuard handshake initiation: message type 1, 148 bytes.
+	wgHandshakeInitiation = 1
+	wgHandshakeInitLen    = 148
+
+	// maxDatagram is the largest datagram the 2-byte length framing carries.
+	maxDatagram = 65535
+
+	// recvQueue buffers datagrams read from the streams until WireGuard
+	// receives them.
+	recvQueue = 256
+)
+
+var errDatagramTooLarge = errors.New("obfs: datagram too large")
+
+// NewBind wraps inner, the WireGuard bind of a tunnel, with the obfuscation
+// configured in cfg. Stream modes replace inner entirely.
+func NewBind(cfg Config, inner conn.Bind) conn.Bind {
+	cfg = cfg.withDefaults()
+	switch cfg.Mode {
+	case ModeJunk:
+		return &junkBind{Bind: inner, cfg: cfg}
+	case ModeTCP, ModeTLS, ModeWS, ModeWSS:
+		return &streamBind{cfg: cfg}
+	}
+	return inner
+}
+
+// ---------------------------------------------------------------------------
+// Junk — random datagrams ahead of each handshake
+// ---------------------------------------------------------------------------
+
+// junkBind sends cfg.Jc random datagrams before every handshake initiation,
+// so the first packets of a session do not have WireGuard's fixed sizes.
+type junkBind struct {
+	conn.Bind
+	cfg Config
+}
+
+func (b *junkBind) Send(bufs [][]byte, ep conn.Endpoint) error {
+	for _, buf := range bufs {
+		if isHandshakeInitiation(buf) {
+			// Junk is best effort: a failed send must not stop the handshake.
+			if err := b.Bind.Send(junkPackets(b.cfg), ep); err != nil {
+				core.Log.Debugf("Obfs", "junk to %s: %v", ep.DstToString(), err)
+			}
+			break
+		}
+	}
+	return b.Bind.Send(bufs, ep)
+}
+
+func isHandshakeInitiation(buf []byte) bool {
+	return len(buf) == wgHandshakeInitLen &&
+		buf[0] == wgHandshakeInitiation && buf[1] == 0 && buf[2] == 0 && buf[3] == 0
+}
+
+// junkPackets returns cfg.Jc random datagrams of cfg.Jmin..cfg.Jmax bytes.
+func junkPackets(cfg Config) [][]byte {
+	pkts := make([][]byte, cfg.Jc)
+	for i := range pkts {
+		pkts[i] = make([]byte, cfg.Jmin+mrand.IntN(cfg.Jmax-cfg.Jmin+1))
+		rand.Read(pkts[i])
+	}
+	return pkts
+}
+
+// ---------------------------------------------------------------------------
+// Stream — datagrams over TCP, TLS or WebSocket
+// ---------------------------------------------------------------------------
+
+// packetConn carries whole datagrams over a stream connection.
+type packetConn interface {
+	ReadPacket(buf []byte) (int, error)
+	WritePacket(pkt []byte) error
+	Close() error
+}
+
+// lengthConn frames each datagram with its 2-byte big-endian length.
+type lengthConn struct {
+	c  net.Conn
+	br *bufio.Reader
+
+	wmu  sync.Mutex
+	wbuf []byte
+}
+
+func newLengthConn(c net.Conn) *lengthConn {
+	return &lengthConn{c: c, br: bufio.NewReaderSize(c, 64*1024)}
+}
+
+func (l *lengthConn) ReadPacket(buf []byte) (int, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(l.br, hdr[:]); err != nil {
+		return 0, err
+	}
+	n := int(binary.BigEndian.Uint16(hdr[:]))
+	if n > len(buf) {
+		return 0, errDatagramTooLarge
+	}
+	_, err := io.ReadFull(l.br, buf[:n])
+	return n, err
+}
+
+func (l *lengthConn) WritePacket(pkt []byte) error {
+	if len(pkt) > maxDatagram {
+		return errDatagramTooLarge
+	}
+	l.wmu.Lock()
+	defer l.wmu.Unlock()
+	// One write per datagram keeps header and payload in one segment.
+	l.wbuf = binary.BigEndian.AppendUint16(l.wbuf[:0], uint16(len(pkt)))
+	l.wbuf = append(l.wbuf, pkt...)
+	_, err := l.c.Write(l.wbuf)
+	return err
+}
+
+func (l *lengthConn) Close() error {
+	return l.c.Close()
+}
+
+// wsConn carries one datagram per binary WebSocket message.
+type wsConn struct {
+	ws *websocket.Conn
+}
+
+func (w wsConn) ReadPacket(buf []byte) (int, error) {
+	_, r, err := w.ws.Reader(context.Background())
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(r, buf)
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return n, nil
+	case nil:
+		return 0, errDatagramTooLarge
+	}
+	return 0, err
+}
+
+func (w wsConn) WritePacket(pkt []byte) error {
+	return w.ws.Write(context.Background(), websocket.MessageBinary, pkt)
+}
+
+func (w wsConn) Close() error {
+	return w.ws.Close(websocket.StatusNormalClosure, "")
+}
+
+// streamBind is a WireGuard bind that sends datagrams over one stream per
+// target, dialed on first send and redialed after it fails.
+type streamBind struct {
+	cfg Config
+
+	mu     sync.Mutex
+	peers  map[string]*streamPeer // by target address
+	recv   chan received
+	closed chan struct{} // nil while not open
+}
+
+type streamPeer struct {
+	pc packetConn
+	ep conn.Endpoint
+}
+
+type received struct {
+	buf *[]byte
+	n   int
+	ep  conn.Endpoint
+}
+
+var datagramPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, maxDatagram+1) // +1: a full buffer means too large
+		return &b
+	},
+}
+
+func (b *streamBind) Open(port uint16) ([]conn.ReceiveFunc, uint16, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed != nil {
+		return nil, 0, conn.ErrBindAlreadyOpen
+	}
+	b.peers = make(map[string]*streamPeer)
+	b.recv = make(chan received, recvQueue)
+	b.closed = make(chan struct{})
+	recv, closed := b.recv, b.closed
+
+	fn := func(packets [][]byte, sizes []int, eps []conn.Endpoint) (int, error) {
+		select {
+		case r := <-recv:
+			sizes[0] = copy(packets[0], (*r.buf)[:r.n])
+			eps[0] = r.ep
+			datagramPool.Put(r.buf)
+			return 1, nil
+		case <-closed:
+			return 0, net.ErrClosed
+		}
+	}
+	return []conn.ReceiveFunc{fn}, port, nil
+}
+
+func (b *streamBind) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed == nil {
+		return nil
+	}
+	close(b.closed)
+	for _, p := range b.peers {
+		p.pc.Close()
+	}
+	b.peers = nil
+	b.closed = nil
+	return nil
+}
+
+// SetMark is a no-op: streams are dialed like any other outbound socket.
+func (b *streamBind) SetMark(uint32) error {
+	return nil
+}
+
+func (b *streamBind) BatchSize() int {
+	return 1
+}
+
+func (b *streamBind) ParseEndpoint(s string) (conn.Endpoint, error) {
+	ap, err := netip.ParseAddrPort(s)
+	if err != nil {
+		return nil, err
+	}
+	return streamEndpoint{dst: ap}, nil
+}
+
+func (b *streamBind) Send(bufs [][]byte, ep conn.Endpoint) error {
+	target := b.cfg.Target(ep.DstToString())
+	p, err := b.peer(target, ep)
+	if err != nil {
+		return err
+	}
+	for _, buf := range bufs {
+		if err := p.pc.WritePacket(buf); err != nil {
+			b.drop(target, p)
+			return fmt.Errorf("obfs send to %s: %w", target, err)
+		}
+	}
+	return nil
+}
+
+// peer returns the stream to target, dialing it if needed.
+func (b *streamBind) peer(target string, ep conn.Endpoint) (*streamPeer, error) {
+	b.mu.Lock()
+	if b.closed == nil {
+		b.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	if p := b.peers[target]; p != nil {
+		b.mu.Unlock()
+		return p, nil
+	}
+	b.mu.Unlock()
+
+	pc, err := b.dial(target)
+	if err != nil {
+		return nil, fmt.Errorf("obfs dial %s: %w", target, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed == nil {
+		pc.Close()
+		return nil, net.ErrClosed
+	}
+	if p := b.peers[target]; p != nil {
+		pc.Close() // lost a race with another send
+		return p, nil
+	}
+	p := &streamPeer{pc: pc, ep: ep}
+	b.peers[target] = p
+	core.Log.Infof("Obfs", "%s stream to %s established", b.cfg.Mode, target)
+	core.SafeGo("obfs.stream-read", func() { b.readLoop(target, p, b.recv, b.closed) })
+	return p, nil
+}
+
+func (b *streamBind) dial(target string) (packetConn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+	switch b.cfg.Mode {
+	case ModeWS, ModeWSS:
+		ws, err := b.cfg.dialWebSocket(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		ws.SetReadLimit(maxDatagram)
+		return wsConn{ws: ws}, nil
+	case ModeTLS:
+		c, err := b.cfg.dialTLS(ctx, target)
+		if err != nil {
+			return nil, err
+		}
+		return newLengthConn(c), nil
+	}
+	d := net.Dialer{Timeout: dialTimeout}
+	c, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, err
+	}
+	return newLengthConn(c), nil
+}
+
+// readLoop queues datagrams from p until its stream fails.
+func (b *streamBind) readLoop(target string, p *streamPeer, recv chan<- received, closed <-chan struct{}) {
+	defer b.drop(target, p)
+	for {
+		buf := datagramPool.Get().(*[]byte)
+		n, err := p.pc.ReadPacket(*buf)
+		if err != nil {
+			datagramPool.Put(buf)
+			select {
+			case <-closed:
+			default:
+				core.Log.Warnf("Obfs", "stream to %s closed: %v", target, err)
+			}
+			return
+		}
+		select {
+		case recv <- received{buf: buf, n: n, ep: p.ep}:
+		case <-closed:
+			datagramPool.Put(buf)
+			return
+		}
+	}
+}
+
+// drop closes p and forgets it, so the next send redials.
+func (b *streamBind) drop(target string, p *streamPeer) {
+	p.pc.Close()
+	b.mu.Lock()
+	if b.peers[target] == p {
+		delete(b.peers, target)
+	}
+	b.mu.Unlock()
+}
+
+// streamEndpoint is a WireGuard peer address on a stream bind.
+type streamEndpoint struct {
+	dst netip.AddrPort
+}
+
+func (e streamEndpoint) ClearSrc()           {}
+func (e streamEndpoint) SrcToString() string { return "" }
+func (e streamEndpoint) DstToString() string { return e.dst.String() }
+func (e streamEndpoint) DstToBytes() []byte {
+	b, _ := e.dst.MarshalBinary()
+	return b
+}
+func (e streamEndpoint) DstIP() netip.Addr { return e.dst.Addr() }
+func (e streamEndpoint) SrcIP() netip.Addr { return netip.Addr{} }
@@ -0,0 +1,249 @@
+// Package obfs wraps the outer connection of tunnels that have no DPI
+// resistance of their own (plain WireGuard, SOCKS5, HTTP proxy).
+//
+// A tunnel selects a mode in its "obfs" settings:
+//
+//   - junk: before every WireGuard handshake initiation, send jc random
+//     datagrams of jmin..jmax bytes, like AmneziaWG's Jc/Jmin/Jmax. A plain
+//     WireGuard server drops them, so no server change is needed.
+//   - tcp, tls: carry WireGuard datagrams over a TCP (or TLS) stream, each
+//     prefixed with its 2-byte big-endian length. This is the framing of
+//     udp-over-tcp relays such as tcp2udp; for tls, put a TLS terminator
+//     in front of the relay.
+//   - ws, wss: WebSocket (over TLS). WireGuard sends one datagram per binary
+//     message (wstunnel-style); SOCKS5/HTTP proxies get the byte stream in
+//     binary messages (websockify-style).
+//
+// SOCKS5 and HTTP proxies accept tls, ws and wss.
+package obfs
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// Obfuscation modes.
+const (
+	ModeOff  = ""
+	ModeJunk = "junk"
+	ModeTCP  = "tcp"
+	ModeTLS  = "tls"
+	ModeWS   = "ws"
+	ModeWSS  = "wss"
+)
+
+const (
+	// Junk defaults: AmneziaWG's recommended Jc/Jmin/Jmax.
+	defaultJc   = 4
+	defaultJmin = 40
+	defaultJmax = 70
+
+	maxJc      = 128
+	maxJunkLen = 1280
+
+	dialTimeout = 10 * time.Second
+)
+
+// Config is the obfuscation of one tunnel (Settings["obfs"]).
+type Config struct {
+	// Mode is one of the Mode constants; empty disables obfuscation.
+	Mode string `yaml:"mode"`
+	// Server is the host:port of the obfuscation endpoint (udp-over-tcp
+	// relay, TLS terminator, WebSocket server). Empty uses the tunnel's own
+	// server address.
+	Server string `yaml:"server"`
+	// Host is the TLS SNI and WebSocket Host header (default: server host).
+	Host string `yaml:"host"`
+	// Path is the WebSocket request path (default "/").
+	Path string `yaml:"path"`
+	// SkipVerify disables TLS certificate verification (insecure).
+	SkipVerify bool `yaml:"skip_verify"`
+
+	// Jc, Jmin, Jmax configure junk mode: junk datagrams per handshake and
+	// their size range in bytes.
+	Jc   int `yaml:"jc"`
+	Jmin int `yaml:"jmin"`
+	Jmax int `yaml:"jmax"`
+}
+
+// Enabled reports whether any obfuscation is configured.
+func (c Config) Enabled() bool {
+	return c.Mode != ModeOff
+}
+
+// Validate checks c for a tunnel that carries datagrams (WireGuard) or a
+// byte stream (SOCKS5, HTTP proxy).
+func (c Config) Validate(datagram bool) error {
+	switch c.Mode {
+	case ModeOff, ModeTLS, ModeWS, ModeWSS:
+	case ModeJunk, ModeTCP:
+		if !datagram {
+			return fmt.Errorf("obfs mode %q is only supported by WireGuard", c.Mode)
+		}
+	default:
+		return fmt.Errorf("unknown obfs mode %q", c.Mode)
+	}
+	if c.Server != "" {
+		if _, _, err := net.SplitHostPort(c.Server); err != nil {
+			return fmt.Errorf("obfs server %q: %w", c.Server, err)
+		}
+	}
+	if c.Mode == ModeJunk {
+		c = c.withDefaults()
+		if c.Jc < 1 || c.Jc > maxJc {
+			return fmt.Errorf("obfs jc %d out of range 1-%d", c.Jc, maxJc)
+		}
+		if c.Jmin < 1 || c.Jmin > c.Jmax || c.Jmax > maxJunkLen {
+			return fmt.Errorf("obfs jmin/jmax %d/%d: need 1 <= jmin <= jmax <= %d", c.Jmin, c.Jmax, maxJunkLen)
+		}
+	}
+	return nil
+}
+
+func (c Config) withDefaults() Config {
+	if c.Jc == 0 {
+		c.Jc = defaultJc
+	}
+	if c.Jmin == 0 && c.Jmax == 0 {
+		c.Jmin, c.Jmax = defaultJmin, defaultJmax
+	}
+	if c.Path == "" {
+		c.Path = "/"
+	}
+	return c
+}
+
+// Target returns the address the wrapped connection is made to: the
+// obfuscation server, or addr (the tunnel's server) if none is set.
+func (c Config) Target(addr string) string {
+	if c.Server != "" {
+		return c.Server
+	}
+	return addr
+}
+
+// ResolveServer resolves the obfuscation server, so the caller can add a
+// bypass route for it. Returns an invalid AddrPort when no server is set.
+func (c Config) ResolveServer(ctx context.Context, resolver *net.Resolver) (netip.AddrPort, error) {
+	if c.Server == "" || c.Mode == ModeJunk {
+		return netip.AddrPort{}, nil
+	}
+	if ap, err := netip.ParseAddrPort(c.Server); err == nil {
+		return ap, nil
+	}
+	host, port, err := net.SplitHostPort(c.Server)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	pn, err := resolver.LookupPort(ctx, "tcp", port)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	ips, err := resolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("resolve obfs server %q: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return netip.AddrPort{}, fmt.Errorf("resolve obfs server %q: no addresses", host)
+	}
+	return netip.AddrPortFrom(ips[0].Unmap(), uint16(pn)), nil
+}
+
+// Dialer returns a dialer that connects through the obfuscation layer. It
+// implements golang.org/x/net/proxy.Dialer and ContextDialer, so it can be
+// the forward dialer of a SOCKS5 client.
+func (c Config) Dialer() *Dialer {
+	return &Dialer{cfg: c.withDefaults()}
+}
+
+// Dialer opens obfuscated byte streams to a proxy server.
+type Dialer struct {
+	cfg Config
+}
+
+// Dial connects to addr through the obfuscation layer.
+func (d *Dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to addr (the proxy server) through the obfuscation
+// layer. With a server set, the connection goes there instead and the
+// server forwards it to the proxy.
+func (d *Dialer) DialContext(ctx context.Context, _, addr string) (net.Conn, error) {
+	switch d.cfg.Mode {
+	case ModeWS, ModeWSS:
+		ws, err := d.cfg.dialWebSocket(ctx, d.cfg.Target(addr))
+		if err != nil {
+			return nil, err
+		}
+		return websocket.NetConn(context.Background(), ws, websocket.MessageBinary), nil
+	case ModeTLS:
+		return d.cfg.dialTLS(ctx, d.cfg.Target(addr))
+	case ModeOff:
+		var nd net.Dialer
+		return nd.DialContext(ctx, "tcp", addr)
+	}
+	return nil, fmt.Errorf("obfs mode %q does not carry streams", d.cfg.Mode)
+}
+
+func (c Config) tlsConfig(target string) *tls.Config {
+	host := c.Host
+	if host == "" {
+		host, _, _ = net.SplitHostPort(target)
+	}
+	return &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: c.SkipVerify,
+	}
+}
+
+func (c Config) dialTLS(ctx context.Context, target string) (net.Conn, error) {
+	d := net.Dialer{Timeout: dialTimeout}
+	raw, err := d.DialContext(ctx, "tcp", target)
+	if err != nil {
+		return nil, err
+	}
+	tc := tls.Client(raw, c.tlsConfig(target))
+	if err := tc.HandshakeContext(ctx); err != nil {
+		raw.Close()
+		return nil, fmt.Errorf("obfs TLS handshake: %w", err)
+	}
+	return tc, nil
+}
+
+// dialWebSocket opens a WebSocket to target.
+func (c Config) dialWebSocket(ctx context.Context, target string) (*websocket.Conn, error) {
+	scheme := "ws"
+	if c.Mode == ModeWSS {
+		scheme = "wss"
+	}
+	u := url.URL{Scheme: scheme, Host: target, Path: c.Path}
+	nd := net.Dialer{Timeout: dialTimeout}
+	client := &http.Client{
+		Transport: &http.Transport{
+			// Always dial target; the URL host only names the request.
+			DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				return nd.DialContext(ctx, network, target)
+			},
+			TLSClientConfig: c.tlsConfig(target),
+		},
+	}
+	ctx, cancel := context.WithTimeout(ctx, dialTimeout)
+	defer cancel()
+	ws, _, err := websocket.Dial(ctx, u.String(), &websocket.DialOptions{
+		HTTPClient: client,
+		Host:       c.Host,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("obfs WebSocket %s: %w", u.String(), err)
+	}
+	return ws, nil
+}
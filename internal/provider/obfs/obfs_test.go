@@ -0,0 +1,185 @@
+package obfs
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/amnezia-vpn/amneziawg-go/conn"
+	"github.com/coder/websocket"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		cfg      Config
+		datagram bool
+		ok       bool
+	}{
+		{Config{}, false, true},
+		{Config{Mode: ModeJunk}, true, true},
+		{Config{Mode: ModeJunk}, false, false},
+		{Config{Mode: ModeTCP}, false, false},
+		{Config{Mode: ModeWSS, Server: "ws.example.com:443"}, false, true},
+		{Config{Mode: ModeTLS, Server: "no-port"}, true, false},
+		{Config{Mode: ModeJunk, Jc: 200}, true, false},
+		{Config{Mode: ModeJunk, Jmin: 90, Jmax: 50}, true, false},
+		{Config{Mode: "udp2raw"}, true, false},
+	}
+	for _, tt := range tests {
+		if err := tt.cfg.Validate(tt.datagram); (err == nil) != tt.ok {
+			t.Errorf("%+v datagram=%v: err=%v, want ok=%v", tt.cfg, tt.datagram, err, tt.ok)
+		}
+	}
+}
+
+// recordBind records what is sent through it.
+type recordBind struct {
+	conn.Bind
+	sent [][]byte
+}
+
+func (b *recordBind) Send(bufs [][]byte, _ conn.Endpoint) error {
+	b.sent = append(b.sent, bufs...)
+	return nil
+}
+
+func TestJunkBeforeHandshake(t *testing.T) {
+	inner := &recordBind{}
+	b := NewBind(Config{Mode: ModeJunk, Jc: 3, Jmin: 10, Jmax: 20}, inner)
+	ep, _ := (&streamBind{}).ParseEndpoint("192.0.2.1:51820")
+
+	data := make([]byte, 96)
+	data[0] = 4 // transport data
+	b.Send([][]byte{data}, ep)
+	if len(inner.sent) != 1 {
+		t.Fatalf("data packet: %d datagrams sent, want 1", len(inner.sent))
+	}
+
+	inner.sent = nil
+	init := make([]byte, wgHandshakeInitLen)
+	init[0] = wgHandshakeInitiation
+	b.Send([][]byte{init}, ep)
+	if len(inner.sent) != 4 {
+		t.Fatalf("handshake: %d datagrams sent, want 3 junk + 1", len(inner.sent))
+	}
+	for _, j := range inner.sent[:3] {
+		if len(j) < 10 || len(j) > 20 {
+			t.Errorf("junk of %d bytes, want 10..20", len(j))
+		}
+	}
+	if len(inner.sent[3]) != wgHandshakeInitLen {
+		t.Error("handshake not sent after the junk")
+	}
+}
+
+// lengthEcho is a udp-over-tcp relay that echoes each framed datagram.
+func lengthEcho(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		var hdr [2]byte
+		for {
+			if _, err := io.ReadFull(c, hdr[:]); err != nil {
+				return
+			}
+			pkt := make([]byte, binary.BigEndian.Uint16(hdr[:]))
+			if _, err := io.ReadFull(c, pkt); err != nil {
+				return
+			}
+			c.Write(append(hdr[:], pkt...))
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// wsEcho is a WebSocket server that echoes each message.
+func wsEcho(t *testing.T) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer ws.CloseNow()
+		for {
+			typ, msg, err := ws.Read(r.Context())
+			if err != nil {
+				return
+			}
+			ws.Write(r.Context(), typ, msg)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestStreamBindRoundTrip(t *testing.T) {
+	for _, mode := range []string{ModeTCP, ModeWS} {
+		var server string
+		if mode == ModeTCP {
+			server = lengthEcho(t)
+		} else {
+			server = wsEcho(t)
+		}
+		b := NewBind(Config{Mode: mode, Server: server}, nil)
+		fns, _, err := b.Open(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ep, _ := b.ParseEndpoint("192.0.2.1:51820")
+
+		want := []string{"first datagram", "second"}
+		for _, s := range want {
+			if err := b.Send([][]byte{[]byte(s)}, ep); err != nil {
+				t.Fatalf("%s: send: %v", mode, err)
+			}
+		}
+		packets := [][]byte{make([]byte, 1500)}
+		sizes := make([]int, 1)
+		eps := make([]conn.Endpoint, 1)
+		for _, s := range want {
+			if _, err := fns[0](packets, sizes, eps); err != nil {
+				t.Fatalf("%s: receive: %v", mode, err)
+			}
+			if got := string(packets[0][:sizes[0]]); got != s {
+				t.Errorf("%s: received %q, want %q", mode, got, s)
+			}
+			if eps[0].DstToString() != "192.0.2.1:51820" {
+				t.Errorf("%s: endpoint %s", mode, eps[0].DstToString())
+			}
+		}
+
+		b.Close()
+		if _, err := fns[0](packets, sizes, eps); err != net.ErrClosed {
+			t.Errorf("%s: receive after close: %v", mode, err)
+		}
+	}
+}
+
+func TestDialerWebSocket(t *testing.T) {
+	server := wsEcho(t)
+	c, err := Config{Mode: ModeWS, Server: server}.Dialer().DialContext(context.Background(), "tcp", "proxy.example.com:1080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Write([]byte("hello"))
+	buf := make([]byte, 5)
+	if _, err := io.ReadFull(c, buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("read %q, %v", buf, err)
+	}
+}
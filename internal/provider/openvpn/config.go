@@ -0,0 +1,15 @@
+package openvpn
+
+// Config holds OpenVPN provider configuration parsed from TunnelConfig.Settings.
+// Everything about the server comes from the .ovpn profile; only credentials
+// that profiles usually leave out can be given here.
+// OTP codes are NOT stored here — they are passed at connect time via SetAuthParams.
+type Config struct {
+	ConfigFile string // path to the .ovpn profile
+	ConfigText string // inline profile text (takes precedence over ConfigFile)
+
+	// Username/Password answer auth-user-pass. They override a credentials
+	// file referenced by the profile.
+	Username string
+	Password string
+}
@@ -0,0 +1,367 @@
+package openvpn
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// ---- Key method 2 ----
+
+// keySource is one side's random contribution to the data channel keys.
+// Only the client sends a pre-master secret.
+type keySource struct {
+	preMaster [48]byte
+	random1   [32]byte
+	random2   [32]byte
+}
+
+func newClientKeySource() (*keySource, error) {
+	var s keySource
+	for _, b := range [][]byte{s.preMaster[:], s.random1[:], s.random2[:]} {
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// clientKeyMessage builds the client's key method 2 message:
+// uint32 0 | method 2 | pre-master | random1 | random2 | options |
+// username | password | peer info.
+func clientKeyMessage(src *keySource, options, username, password, peerInfo string) []byte {
+	b := make([]byte, 4, 512)
+	b = append(b, 2)
+	b = append(b, src.preMaster[:]...)
+	b = append(b, src.random1[:]...)
+	b = append(b, src.random2[:]...)
+	b = appendString(b, options)
+	b = appendString(b, username)
+	b = appendString(b, password)
+	return appendString(b, peerInfo)
+}
+
+// appendString writes a length-prefixed, NUL-terminated string. The empty
+// string is written as a bare zero length.
+func appendString(b []byte, s string) []byte {
+	if s == "" {
+		return append(b, 0, 0)
+	}
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)+1))
+	b = append(b, s...)
+	return append(b, 0)
+}
+
+// parseServerKeyMessage parses the server's key method 2 message and returns
+// its randoms and options string.
+func parseServerKeyMessage(b []byte) (*keySource, string, error) {
+	if len(b) < 4+1+64+2 {
+		return nil, "", errors.New("key method message too short")
+	}
+	if binary.BigEndian.Uint32(b) != 0 || b[4] != 2 {
+		return nil, "", fmt.Errorf("unexpected key method %d", b[4])
+	}
+	var s keySource
+	copy(s.random1[:], b[5:37])
+	copy(s.random2[:], b[37:69])
+	n := int(binary.BigEndian.Uint16(b[69:]))
+	rest := b[71:]
+	if n > len(rest) {
+		return nil, "", errors.New("truncated options string")
+	}
+	return &s, string(bytes.TrimRight(rest[:n], "\x00")), nil
+}
+
+// deriveKeysPRF computes the 256 bytes of data channel key material with the
+// TLS 1.0 PRF (OpenVPN's default when tls-ekm is not negotiated).
+func deriveKeysPRF(client, server *keySource, clientSID, serverSID sessionID) []byte {
+	master := make([]byte, 48)
+	prf(client.preMaster[:], "OpenVPN master secret", concat(client.random1[:], server.random1[:]), master)
+	out := make([]byte, 256)
+	prf(master, "OpenVPN key expansion", concat(client.random2[:], server.random2[:], clientSID[:], serverSID[:]), out)
+	return out
+}
+
+// prf is the TLS 1.0 PRF: P_MD5 over the first half of the secret XOR
+// P_SHA1 over the second half.
+func prf(secret []byte, label string, seed []byte, out []byte) {
+	ls := concat([]byte(label), seed)
+	half := (len(secret) + 1) / 2
+	md5Out := make([]byte, len(out))
+	pHash(md5.New, secret[:half], ls, md5Out)
+	pHash(sha1.New, secret[len(secret)-half:], ls, out)
+	for i := range out {
+		out[i] ^= md5Out[i]
+	}
+}
+
+func pHash(h func() hash.Hash, secret, seed, out []byte) {
+	mac := hmac.New(h, secret)
+	mac.Write(seed)
+	a := mac.Sum(nil)
+	for len(out) > 0 {
+		mac.Reset()
+		mac.Write(a)
+		mac.Write(seed)
+		n := copy(out, mac.Sum(nil))
+		out = out[n:]
+
+		mac.Reset()
+		mac.Write(a)
+		a = mac.Sum(nil)
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	var b []byte
+	for _, p := range parts {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// ---- Data channel ----
+
+// aeadKeyLen returns the key length of a supported data channel cipher, or 0.
+func aeadKeyLen(name string) int {
+	switch name {
+	case "AES-128-GCM":
+		return 16
+	case "AES-192-GCM":
+		return 24
+	case "AES-256-GCM", "CHACHA20-POLY1305":
+		return 32
+	}
+	return 0
+}
+
+func newAEAD(name string, key []byte) (cipher.AEAD, error) {
+	if name == "CHACHA20-POLY1305" {
+		return chacha20poly1305.New(key)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// dataKeys holds one key id's data channel state. Packets are
+// header|packet id|tag|ciphertext; the nonce is the packet id followed by
+// 8 bytes of implicit IV (the start of the HMAC key slot).
+type dataKeys struct {
+	keyID  byte
+	peerID int // -1 sends P_DATA_V1
+
+	enc, dec     cipher.AEAD
+	encIV, decIV [8]byte
+
+	sendID atomic.Uint32
+
+	mu     sync.Mutex
+	replay replayWindow
+}
+
+// newDataKeys splits 256 bytes of key material for the client side: it
+// encrypts with key 0 and decrypts with key 1.
+func newDataKeys(cipherName string, material []byte, keyID byte, peerID int) (*dataKeys, error) {
+	keyLen := aeadKeyLen(cipherName)
+	if keyLen == 0 {
+		return nil, fmt.Errorf("unsupported data cipher %q", cipherName)
+	}
+	d := &dataKeys{keyID: keyID, peerID: peerID}
+	var err error
+	if d.enc, err = newAEAD(cipherName, material[0:keyLen]); err != nil {
+		return nil, err
+	}
+	if d.dec, err = newAEAD(cipherName, material[128:128+keyLen]); err != nil {
+		return nil, err
+	}
+	copy(d.encIV[:], material[64:72])
+	copy(d.decIV[:], material[192:200])
+	return d, nil
+}
+
+// seal encrypts plain into a complete data packet.
+func (d *dataKeys) seal(plain []byte) []byte {
+	var hdr []byte
+	if d.peerID >= 0 {
+		hdr = []byte{opDataV2<<3 | d.keyID, byte(d.peerID >> 16), byte(d.peerID >> 8), byte(d.peerID)}
+	} else {
+		hdr = []byte{opDataV1<<3 | d.keyID}
+	}
+	pid := d.sendID.Add(1)
+
+	var nonce [12]byte
+	binary.BigEndian.PutUint32(nonce[:], pid)
+	copy(nonce[4:], d.encIV[:])
+
+	out := binary.BigEndian.AppendUint32(hdr, pid)
+	// P_DATA_V1 authenticates only the packet id, P_DATA_V2 the header too.
+	ad := out
+	if d.peerID < 0 {
+		ad = out[1:]
+	}
+
+	// Seal returns ciphertext||tag; on the wire the tag comes first.
+	sealed := d.enc.Seal(nil, nonce[:], plain, ad)
+	out = append(out, sealed[len(plain):]...)
+	out = append(out, sealed[:len(plain)]...)
+	return out
+}
+
+// open authenticates and decrypts a data packet.
+func (d *dataKeys) open(pkt []byte) ([]byte, error) {
+	hdrLen := 1
+	if pkt[0]>>3 == opDataV2 {
+		hdrLen = 4
+	}
+	tagSize := d.dec.Overhead()
+	if len(pkt) < hdrLen+4+tagSize {
+		return nil, errors.New("data packet too short")
+	}
+	ad := pkt[:hdrLen+4]
+	if hdrLen == 1 {
+		ad = pkt[1:5]
+	}
+	pid := binary.BigEndian.Uint32(pkt[hdrLen:])
+	tag := pkt[hdrLen+4 : hdrLen+4+tagSize]
+	ct := pkt[hdrLen+4+tagSize:]
+
+	var nonce [12]byte
+	binary.BigEndian.PutUint32(nonce[:], pid)
+	copy(nonce[4:], d.decIV[:])
+
+	buf := make([]byte, 0, len(ct)+tagSize)
+	buf = append(buf, ct...)
+	buf = append(buf, tag...)
+	plain, err := d.dec.Open(buf[:0], nonce[:], buf, ad)
+	if err != nil {
+		return nil, errors.New("data packet authentication failed")
+	}
+
+	d.mu.Lock()
+	fresh := d.replay.check(pid)
+	d.mu.Unlock()
+	if !fresh {
+		return nil, errors.New("replayed data packet")
+	}
+	return plain, nil
+}
+
+// replayWindow is a 64-packet sliding anti-replay window.
+type replayWindow struct {
+	top    uint32
+	bitmap uint64
+}
+
+// check reports whether id is new and records it.
+func (w *replayWindow) check(id uint32) bool {
+	switch {
+	case id == 0:
+		return false
+	case id > w.top:
+		shift := id - w.top
+		if shift >= 64 {
+			w.bitmap = 0
+		} else {
+			w.bitmap <<= shift
+		}
+		w.bitmap |= 1
+		w.top = id
+		return true
+	case w.top-id >= 64:
+		return false
+	default:
+		bit := uint64(1) << (w.top - id)
+		if w.bitmap&bit != 0 {
+			return false
+		}
+		w.bitmap |= bit
+		return true
+	}
+}
+
+// ---- Payload framing ----
+
+// pingMagic is the payload of an OpenVPN keepalive ping.
+var pingMagic = []byte{
+	0x2a, 0x18, 0x7b, 0xf3, 0x64, 0x1e, 0xb4, 0xcb,
+	0x07, 0xed, 0x2d, 0x0a, 0x98, 0x1f, 0xc7, 0x48,
+}
+
+// occMagic starts an OCC message; the byte after it is the message type.
+var occMagic = []byte{
+	0x28, 0x7f, 0x34, 0x6b, 0xd4, 0xef, 0x7a, 0x81,
+	0x2d, 0x56, 0xb8, 0xd3, 0xaf, 0xc5, 0x45, 0x9c,
+}
+
+const occExit = 6
+
+// Compression framing bytes. The client never compresses, it only adds the
+// "not compressed" marker the server's compress setting expects.
+const (
+	noCompressByte     = 0xfa // comp-lzo
+	noCompressSwapByte = 0xfb // compress (v1): marker replaces the first byte, which moves to the end
+	compV2Escape       = 0x50 // compress stub-v2: escapes packets starting with 0x50
+)
+
+// frameOutbound adds the compression marker for framing.
+func frameOutbound(framing string, pkt []byte) []byte {
+	switch framing {
+	case "lzo":
+		return append([]byte{noCompressByte}, pkt...)
+	case "stub":
+		if len(pkt) == 0 {
+			return []byte{noCompressSwapByte}
+		}
+		out := make([]byte, 0, len(pkt)+1)
+		out = append(out, noCompressSwapByte)
+		out = append(out, pkt[1:]...)
+		return append(out, pkt[0])
+	case "stub-v2":
+		if len(pkt) > 0 && pkt[0] == compV2Escape {
+			return append([]byte{compV2Escape, 0}, pkt...)
+		}
+	}
+	return pkt
+}
+
+// unframeInbound strips any compression marker. The markers never collide
+// with the first byte of an IP packet (or a ping), so all framings are
+// accepted. Compressed payloads are rejected: the client advertises stub
+// support only.
+func unframeInbound(pkt []byte) ([]byte, bool) {
+	if len(pkt) == 0 {
+		return nil, false
+	}
+	switch pkt[0] {
+	case noCompressByte:
+		return pkt[1:], true
+	case noCompressSwapByte:
+		if len(pkt) < 2 {
+			return nil, false
+		}
+		out := make([]byte, 0, len(pkt)-1)
+		out = append(out, pkt[len(pkt)-1])
+		return append(out, pkt[1:len(pkt)-1]...), true
+	case compV2Escape:
+		if len(pkt) < 2 || pkt[1] != 0 {
+			return nil, false
+		}
+		return pkt[2:], true
+	}
+	return pkt, true
+}
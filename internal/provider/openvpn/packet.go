@@ -0,0 +1,292 @@
+package openvpn
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Packet opcodes (high 5 bits of the first byte; the low 3 bits are the key id).
+const (
+	opControlSoftResetV1       = 3
+	opControlV1                = 4
+	opAckV1                    = 5
+	opDataV1                   = 6
+	opControlHardResetClientV2 = 7
+	opControlHardResetServerV2 = 8
+	opDataV2                   = 9
+)
+
+// maxAcks is the most acknowledgements carried by one control packet.
+const maxAcks = 4
+
+type sessionID [8]byte
+
+// controlPacket is a control channel packet with any tls-auth/tls-crypt
+// wrapping already removed.
+type controlPacket struct {
+	opcode  byte
+	keyID   byte
+	sid     sessionID // sender's session id
+	acks    []uint32
+	ackSID  sessionID // receiver's session id, present when acks is not empty
+	msgID   uint32    // absent in P_ACK_V1
+	payload []byte
+}
+
+// marshal encodes the packet as
+// op|sid|ack count|acks|[ack sid]|[message id]|payload.
+func (p *controlPacket) marshal() []byte {
+	b := make([]byte, 0, 1+8+1+4*len(p.acks)+8+4+len(p.payload))
+	b = append(b, p.opcode<<3|p.keyID&7)
+	b = append(b, p.sid[:]...)
+	b = append(b, byte(len(p.acks)))
+	for _, a := range p.acks {
+		b = binary.BigEndian.AppendUint32(b, a)
+	}
+	if len(p.acks) > 0 {
+		b = append(b, p.ackSID[:]...)
+	}
+	if p.opcode != opAckV1 {
+		b = binary.BigEndian.AppendUint32(b, p.msgID)
+		b = append(b, p.payload...)
+	}
+	return b
+}
+
+func parseControlPacket(b []byte) (*controlPacket, error) {
+	if len(b) < 10 {
+		return nil, errors.New("control packet too short")
+	}
+	p := &controlPacket{opcode: b[0] >> 3, keyID: b[0] & 7}
+	copy(p.sid[:], b[1:9])
+	n := int(b[9])
+	b = b[10:]
+	if n > 0 {
+		if len(b) < 4*n+8 {
+			return nil, errors.New("truncated ack list")
+		}
+		p.acks = make([]uint32, n)
+		for i := range p.acks {
+			p.acks[i] = binary.BigEndian.Uint32(b[4*i:])
+		}
+		copy(p.ackSID[:], b[4*n:4*n+8])
+		b = b[4*n+8:]
+	}
+	if p.opcode != opAckV1 {
+		if len(b) < 4 {
+			return nil, errors.New("missing message id")
+		}
+		p.msgID = binary.BigEndian.Uint32(b)
+		p.payload = b[4:]
+	}
+	return p, nil
+}
+
+// ---- Static keys (tls-auth / tls-crypt) ----
+
+// staticKey is an "OpenVPN Static key V1": two keys, each 64 bytes of cipher
+// key followed by 64 bytes of HMAC key.
+type staticKey [256]byte
+
+func (k *staticKey) cipherKey(i int) []byte { return k[i*128 : i*128+64] }
+func (k *staticKey) hmacKey(i int) []byte   { return k[i*128+64 : i*128+128] }
+
+func parseStaticKey(text string) (*staticKey, error) {
+	const begin = "-----BEGIN OpenVPN Static key V1-----"
+	const end = "-----END OpenVPN Static key V1-----"
+	start := strings.Index(text, begin)
+	stop := strings.Index(text, end)
+	if start < 0 || stop < start {
+		return nil, errors.New("no OpenVPN Static key V1 block")
+	}
+	hexText := strings.Join(strings.Fields(text[start+len(begin):stop]), "")
+	raw, err := hex.DecodeString(hexText)
+	if err != nil {
+		return nil, fmt.Errorf("static key: %w", err)
+	}
+	if len(raw) != len(staticKey{}) {
+		return nil, fmt.Errorf("static key: %d bytes, want 256", len(raw))
+	}
+	var k staticKey
+	copy(k[:], raw)
+	return &k, nil
+}
+
+// hmacHash returns the hash for an OpenVPN "auth" digest name.
+func hmacHash(name string) func() hash.Hash {
+	switch strings.ToUpper(name) {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	case "MD5":
+		return md5.New
+	}
+	return nil
+}
+
+// ---- Control channel wrapping ----
+
+// controlWrapper protects control packets: nothing, tls-auth (HMAC) or
+// tls-crypt (HMAC + AES-256-CTR). wrap takes and unwrap returns the plain
+// op|sid|... encoding.
+type controlWrapper interface {
+	wrap(raw []byte) []byte
+	unwrap(pkt []byte) ([]byte, error)
+}
+
+type plainWrapper struct{}
+
+func (plainWrapper) wrap(raw []byte) []byte            { return raw }
+func (plainWrapper) unwrap(pkt []byte) ([]byte, error) { return pkt, nil }
+
+// replayID is the long-form packet id of wrapped control packets:
+// a counter plus the session start time.
+type replayID struct {
+	counter atomic.Uint32
+	start   uint32
+}
+
+func (r *replayID) next() []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint32(b, r.counter.Add(1))
+	binary.BigEndian.PutUint32(b[4:], r.start)
+	return b
+}
+
+// tlsAuthWrapper implements --tls-auth:
+// op|sid|HMAC|packet id|time|rest, HMAC over packet id|time|op|sid|rest.
+type tlsAuthWrapper struct {
+	hash      func() hash.Hash
+	out, in   []byte
+	packetIDs replayID
+}
+
+// newTLSAuth picks the HMAC keys for the client side of key-direction dir
+// (-1 = bidirectional).
+func newTLSAuth(k *staticKey, digest string, dir int) *tlsAuthWrapper {
+	h := hmacHash(digest)
+	size := h().Size()
+	outIdx, inIdx := 0, 0
+	switch dir {
+	case 0:
+		outIdx, inIdx = 0, 1
+	case 1:
+		outIdx, inIdx = 1, 0
+	}
+	w := &tlsAuthWrapper{hash: h, out: k.hmacKey(outIdx)[:size], in: k.hmacKey(inIdx)[:size]}
+	w.packetIDs.start = uint32(time.Now().Unix())
+	return w
+}
+
+func (w *tlsAuthWrapper) wrap(raw []byte) []byte {
+	head, rest := raw[:9], raw[9:]
+	pid := w.packetIDs.next()
+	mac := hmac.New(w.hash, w.out)
+	mac.Write(pid)
+	mac.Write(head)
+	mac.Write(rest)
+	sum := mac.Sum(nil)
+
+	out := make([]byte, 0, len(raw)+len(sum)+len(pid))
+	out = append(out, head...)
+	out = append(out, sum...)
+	out = append(out, pid...)
+	return append(out, rest...)
+}
+
+func (w *tlsAuthWrapper) unwrap(pkt []byte) ([]byte, error) {
+	size := len(w.in)
+	if len(pkt) < 9+size+8 {
+		return nil, errors.New("tls-auth: packet too short")
+	}
+	head, sum := pkt[:9], pkt[9:9+size]
+	pid, rest := pkt[9+size:9+size+8], pkt[9+size+8:]
+	mac := hmac.New(w.hash, w.in)
+	mac.Write(pid)
+	mac.Write(head)
+	mac.Write(rest)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return nil, errors.New("tls-auth: HMAC mismatch")
+	}
+	out := make([]byte, 0, 9+len(rest))
+	out = append(out, head...)
+	return append(out, rest...), nil
+}
+
+// tlsCryptWrapper implements --tls-crypt:
+// op|sid|packet id|time|tag|AES-256-CTR(rest), where the tag is
+// HMAC-SHA256 over op|sid|packet id|time|rest and its first 16 bytes are the IV.
+type tlsCryptWrapper struct {
+	outEnc, outMAC []byte
+	inEnc, inMAC   []byte
+	packetIDs      replayID
+}
+
+// newTLSCrypt uses the inverse key direction: the client sends with key 1
+// and receives with key 0.
+func newTLSCrypt(k *staticKey) *tlsCryptWrapper {
+	w := &tlsCryptWrapper{
+		outEnc: k.cipherKey(1)[:32], outMAC: k.hmacKey(1)[:32],
+		inEnc: k.cipherKey(0)[:32], inMAC: k.hmacKey(0)[:32],
+	}
+	w.packetIDs.start = uint32(time.Now().Unix())
+	return w
+}
+
+func (w *tlsCryptWrapper) wrap(raw []byte) []byte {
+	head := make([]byte, 0, 17)
+	head = append(head, raw[:9]...)
+	head = append(head, w.packetIDs.next()...)
+	rest := raw[9:]
+
+	mac := hmac.New(sha256.New, w.outMAC)
+	mac.Write(head)
+	mac.Write(rest)
+	tag := mac.Sum(nil)
+
+	out := make([]byte, len(head)+len(tag)+len(rest))
+	copy(out, head)
+	copy(out[len(head):], tag)
+	ctr(w.outEnc, tag[:16], out[len(head)+len(tag):], rest)
+	return out
+}
+
+func (w *tlsCryptWrapper) unwrap(pkt []byte) ([]byte, error) {
+	if len(pkt) < 17+32 {
+		return nil, errors.New("tls-crypt: packet too short")
+	}
+	head, tag, ct := pkt[:17], pkt[17:49], pkt[49:]
+	plain := make([]byte, len(ct))
+	ctr(w.inEnc, tag[:16], plain, ct)
+
+	mac := hmac.New(sha256.New, w.inMAC)
+	mac.Write(head)
+	mac.Write(plain)
+	if !hmac.Equal(tag, mac.Sum(nil)) {
+		return nil, errors.New("tls-crypt: authentication failed")
+	}
+	out := make([]byte, 0, 9+len(plain))
+	out = append(out, pkt[:9]...)
+	return append(out, plain...), nil
+}
+
+func ctr(key, iv, dst, src []byte) {
+	block, _ := aes.NewCipher(key) // key is always 32 bytes
+	cipher.NewCTR(block, iv).XORKeyStream(dst, src)
+}
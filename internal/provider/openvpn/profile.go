@@ -0,0 +1,605 @@
+package openvpn
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// Remote is one server entry of a profile (remote line or <connection> block).
+type Remote struct {
+	Host  string
+	Port  int
+	Proto string // "udp" or "tcp"
+}
+
+// Profile is the part of an .ovpn client profile the provider understands.
+type Profile struct {
+	Remotes      []Remote
+	RemoteRandom bool
+
+	CAPool *x509.CertPool
+	Certs  []tls.Certificate // client certificate (optional with auth-user-pass)
+
+	TLSAuth      *staticKey
+	KeyDirection int // -1 = bidirectional (no key-direction)
+	TLSCrypt     *staticKey
+	Auth         string // HMAC digest for tls-auth, default SHA1
+
+	Cipher      string   // legacy cipher option, used when the server does not push one
+	DataCiphers []string // data-ciphers offered to the server (IV_CIPHERS)
+
+	AuthUserPass bool
+	Username     string // from the auth-user-pass file, if any
+	Password     string
+
+	RemoteCertTLS  string // "server" requires the serverAuth EKU
+	VerifyX509Name string
+	VerifyX509Type string // subject, name or name-prefix
+	TLSVersionMin  uint16
+
+	StaticChallenge     string // prompt of static-challenge; requires an OTP code
+	StaticChallengeEcho bool
+
+	Compress    string // data channel framing: "", "lzo", "stub" or "stub-v2"
+	TunMTU      int
+	Ping        int // seconds
+	PingRestart int // seconds
+	RouteNoPull bool
+
+	// Routes holds route/dhcp-option style directives given in the profile
+	// itself. They are applied before the pushed ones.
+	Routes [][]string
+}
+
+// defaultDataCiphers are the AEAD ciphers the data channel implements, in
+// order of preference.
+var defaultDataCiphers = []string{"AES-256-GCM", "AES-128-GCM", "CHACHA20-POLY1305"}
+
+// LoadProfile reads and parses an .ovpn file. Relative file references
+// (ca, cert, key, ...) are resolved against the profile's directory.
+func LoadProfile(path string) (*Profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read profile: %w", err)
+	}
+	return ParseProfile(string(data), filepath.Dir(path))
+}
+
+// ParseProfile parses .ovpn profile text. dir is used to resolve file
+// references; inline <ca>/<cert>/<key>/<tls-auth>/<tls-crypt> blocks need none.
+func ParseProfile(text, dir string) (*Profile, error) {
+	lines, blocks, err := splitProfile(text)
+	if err != nil {
+		return nil, err
+	}
+	// An inline block stands for its directive ("<ca>" alone means "ca [inline]").
+	for _, name := range inlineDirectives {
+		if _, ok := blocks[name]; ok && !hasDirective(lines, name) {
+			lines = append([][]string{{name}}, lines...)
+		}
+	}
+
+	p := &Profile{KeyDirection: -1, Auth: "SHA1"}
+	def := Remote{Port: 1194, Proto: "udp"}
+	var remotes []Remote
+	var caPEM, certPEM, keyPEM []byte
+	var pkcs12Path string
+
+	// material returns the inline block for name or reads the named file.
+	material := func(name string, args []string) ([]byte, error) {
+		if len(args) < 2 || args[1] == "[inline]" {
+			b, ok := blocks[name]
+			if !ok {
+				return nil, fmt.Errorf("%s: no file and no inline <%s> block", name, name)
+			}
+			return []byte(b), nil
+		}
+		path := args[1]
+		if !filepath.IsAbs(path) && dir != "" {
+			path = filepath.Join(dir, path)
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return b, nil
+	}
+
+	for _, args := range lines {
+		name := strings.ToLower(args[0])
+		switch name {
+		case "client", "nobind", "persist-key", "persist-tun", "pull", "tls-client",
+			"auth-nocache", "resolv-retry", "verb", "mute", "mute-replay-warnings",
+			"float", "explicit-exit-notify", "setenv", "setenv-safe", "ignore-unknown-option",
+			"script-security", "up", "down", "block-outside-dns", "route-delay", "route-method",
+			"sndbuf", "rcvbuf", "fast-io", "reneg-sec", "tls-timeout", "hand-window",
+			"server-poll-timeout", "connect-timeout", "connect-retry", "connect-retry-max",
+			"tls-cipher", "tls-ciphersuites", "tls-groups", "mssfix", "fragment", "topology",
+			"dev-node", "windows-driver", "register-dns", "pull-filter", "allow-compression",
+			"user", "group", "chroot", "log", "log-append", "status", "management",
+			"remote-cert-ku", "auth-retry", "push-peer-info", "data-ciphers-fallback", "tun-ipv6":
+			// No effect on a userspace client (or harmless to ignore).
+			if name == "data-ciphers-fallback" && len(args) > 1 && p.Cipher == "" {
+				p.Cipher = strings.ToUpper(args[1])
+			}
+		case "dev":
+			if len(args) > 1 && strings.HasPrefix(strings.ToLower(args[1]), "tap") {
+				return nil, fmt.Errorf("dev %s: TAP (layer 2) profiles are not supported", args[1])
+			}
+		case "dev-type":
+			if len(args) > 1 && strings.EqualFold(args[1], "tap") {
+				return nil, fmt.Errorf("dev-type tap: TAP (layer 2) profiles are not supported")
+			}
+		case "proto":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("proto: missing argument")
+			}
+			proto, err := normalizeProto(args[1])
+			if err != nil {
+				return nil, err
+			}
+			def.Proto = proto
+		case "port", "rport":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("%s: missing argument", name)
+			}
+			port, err := parsePort(args[1])
+			if err != nil {
+				return nil, err
+			}
+			def.Port = port
+		case "remote":
+			r, err := parseRemote(args, Remote{})
+			if err != nil {
+				return nil, err
+			}
+			remotes = append(remotes, r)
+		case "remote-random":
+			p.RemoteRandom = true
+		case "ca":
+			if caPEM, err = material("ca", args); err != nil {
+				return nil, err
+			}
+		case "cert":
+			if certPEM, err = material("cert", args); err != nil {
+				return nil, err
+			}
+		case "key":
+			if keyPEM, err = material("key", args); err != nil {
+				return nil, err
+			}
+		case "pkcs12":
+			if len(args) < 2 || args[1] == "[inline]" {
+				return nil, fmt.Errorf("pkcs12: inline PKCS#12 is not supported, reference a .p12 file")
+			}
+			pkcs12Path = args[1]
+			if !filepath.IsAbs(pkcs12Path) && dir != "" {
+				pkcs12Path = filepath.Join(dir, pkcs12Path)
+			}
+		case "tls-auth":
+			b, err := material("tls-auth", args)
+			if err != nil {
+				return nil, err
+			}
+			if p.TLSAuth, err = parseStaticKey(string(b)); err != nil {
+				return nil, fmt.Errorf("tls-auth: %w", err)
+			}
+			if len(args) > 2 {
+				if p.KeyDirection, err = parseKeyDirection(args[2]); err != nil {
+					return nil, err
+				}
+			}
+		case "key-direction":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("key-direction: missing argument")
+			}
+			if p.KeyDirection, err = parseKeyDirection(args[1]); err != nil {
+				return nil, err
+			}
+		case "tls-crypt":
+			b, err := material("tls-crypt", args)
+			if err != nil {
+				return nil, err
+			}
+			if p.TLSCrypt, err = parseStaticKey(string(b)); err != nil {
+				return nil, fmt.Errorf("tls-crypt: %w", err)
+			}
+		case "auth":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("auth: missing argument")
+			}
+			p.Auth = strings.ToUpper(args[1])
+		case "cipher":
+			if len(args) > 1 {
+				p.Cipher = strings.ToUpper(args[1])
+			}
+		case "data-ciphers", "ncp-ciphers":
+			if len(args) > 1 {
+				p.DataCiphers = nil
+				for _, c := range strings.Split(args[1], ":") {
+					c = strings.ToUpper(strings.TrimSpace(c))
+					if aeadKeyLen(c) > 0 {
+						p.DataCiphers = append(p.DataCiphers, c)
+					}
+				}
+			}
+		case "auth-user-pass":
+			p.AuthUserPass = true
+			if len(args) > 1 || blocks["auth-user-pass"] != "" {
+				b, err := material("auth-user-pass", args)
+				if err != nil {
+					return nil, err
+				}
+				sc := bufio.NewScanner(strings.NewReader(string(b)))
+				if sc.Scan() {
+					p.Username = strings.TrimSpace(sc.Text())
+				}
+				if sc.Scan() {
+					p.Password = strings.TrimRight(sc.Text(), "\r")
+				}
+			}
+		case "remote-cert-tls":
+			if len(args) > 1 {
+				p.RemoteCertTLS = strings.ToLower(args[1])
+			}
+		case "ns-cert-type":
+			core.Log.Warnf("OpenVPN", "ns-cert-type is obsolete and not checked, use remote-cert-tls server")
+		case "verify-x509-name":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("verify-x509-name: missing argument")
+			}
+			p.VerifyX509Name = args[1]
+			p.VerifyX509Type = "subject"
+			if len(args) > 2 {
+				p.VerifyX509Type = strings.ToLower(args[2])
+			}
+		case "tls-version-min":
+			if len(args) > 1 {
+				p.TLSVersionMin = tlsVersion(args[1])
+			}
+		case "static-challenge":
+			if len(args) < 2 {
+				return nil, fmt.Errorf("static-challenge: missing prompt")
+			}
+			p.StaticChallenge = args[1]
+			p.StaticChallengeEcho = len(args) > 2 && args[2] == "1"
+		case "comp-lzo":
+			p.Compress = "lzo"
+		case "compress":
+			p.Compress = compressFraming(args)
+		case "tun-mtu":
+			if len(args) > 1 {
+				p.TunMTU, _ = strconv.Atoi(args[1])
+			}
+		case "keepalive":
+			if len(args) > 2 {
+				p.Ping, _ = strconv.Atoi(args[1])
+				p.PingRestart, _ = strconv.Atoi(args[2])
+			}
+		case "ping":
+			if len(args) > 1 {
+				p.Ping, _ = strconv.Atoi(args[1])
+			}
+		case "ping-restart", "ping-exit":
+			if len(args) > 1 {
+				p.PingRestart, _ = strconv.Atoi(args[1])
+			}
+		case "route-nopull":
+			p.RouteNoPull = true
+		case "route", "route-ipv6", "redirect-gateway", "dhcp-option", "dns":
+			p.Routes = append(p.Routes, args)
+		case "tls-crypt-v2", "secret", "tls-server", "server", "mode":
+			return nil, fmt.Errorf("%s: not supported by the built-in OpenVPN client", name)
+		case "http-proxy", "socks-proxy":
+			return nil, fmt.Errorf("%s: connecting through a proxy is not supported", name)
+		case "cryptoapicert", "pkcs11-id", "pkcs11-providers", "management-external-key":
+			return nil, fmt.Errorf("%s: certificates from a token or system store are not supported, export them to a file", name)
+		default:
+			core.Log.Debugf("OpenVPN", "Ignoring profile option %q", args[0])
+		}
+	}
+
+	// <connection> blocks carry their own remote/proto/port and default to
+	// the global values.
+	for _, block := range blocks.connections() {
+		cl, _, err := splitProfile(block)
+		if err != nil {
+			return nil, err
+		}
+		conn := def
+		var host []string
+		for _, args := range cl {
+			switch strings.ToLower(args[0]) {
+			case "remote":
+				host = args
+			case "proto":
+				if len(args) > 1 {
+					if conn.Proto, err = normalizeProto(args[1]); err != nil {
+						return nil, err
+					}
+				}
+			case "port", "rport":
+				if len(args) > 1 {
+					if conn.Port, err = parsePort(args[1]); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		if host == nil {
+			return nil, fmt.Errorf("<connection> block without remote")
+		}
+		r, err := parseRemote(host, conn)
+		if err != nil {
+			return nil, err
+		}
+		remotes = append(remotes, r)
+	}
+
+	for _, r := range remotes {
+		if r.Port == 0 {
+			r.Port = def.Port
+		}
+		if r.Proto == "" {
+			r.Proto = def.Proto
+		}
+		p.Remotes = append(p.Remotes, r)
+	}
+	if len(p.Remotes) == 0 {
+		return nil, fmt.Errorf("profile has no remote")
+	}
+
+	if len(caPEM) == 0 {
+		return nil, fmt.Errorf("profile has no ca")
+	}
+	p.CAPool = x509.NewCertPool()
+	if !p.CAPool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("ca: no PEM certificates found")
+	}
+
+	switch {
+	case pkcs12Path != "":
+		if p.Certs, err = anyconnect.LoadClientCert(pkcs12Path, "", ""); err != nil {
+			return nil, fmt.Errorf("pkcs12: %w", err)
+		}
+	case len(certPEM) > 0:
+		if len(keyPEM) == 0 {
+			return nil, fmt.Errorf("cert given without key")
+		}
+		if strings.Contains(string(keyPEM), "ENCRYPTED") {
+			return nil, fmt.Errorf("key: encrypted private keys are not supported")
+		}
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("cert/key: %w", err)
+		}
+		p.Certs = []tls.Certificate{cert}
+	}
+	if len(p.Certs) == 0 && !p.AuthUserPass {
+		return nil, fmt.Errorf("profile has neither a client certificate nor auth-user-pass")
+	}
+	if p.TLSAuth != nil && p.TLSCrypt != nil {
+		return nil, fmt.Errorf("tls-auth and tls-crypt are mutually exclusive")
+	}
+	if p.TLSAuth != nil && hmacHash(p.Auth) == nil {
+		return nil, fmt.Errorf("auth %s: unsupported digest for tls-auth", p.Auth)
+	}
+	if len(p.DataCiphers) == 0 {
+		p.DataCiphers = defaultDataCiphers
+	}
+	return p, nil
+}
+
+// profileBlocks maps inline block names (<ca>, <tls-crypt>, ...) to their
+// content. Repeated <connection> blocks are kept in order under numbered keys.
+type profileBlocks map[string]string
+
+func (b profileBlocks) connections() []string {
+	var out []string
+	for i := 0; ; i++ {
+		c, ok := b["connection#"+strconv.Itoa(i)]
+		if !ok {
+			return out
+		}
+		out = append(out, c)
+	}
+}
+
+// inlineDirectives are the directives whose argument may be an inline block.
+var inlineDirectives = []string{"ca", "cert", "key", "tls-auth", "tls-crypt", "tls-crypt-v2", "secret", "auth-user-pass"}
+
+func hasDirective(lines [][]string, name string) bool {
+	for _, args := range lines {
+		if strings.EqualFold(args[0], name) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitProfile tokenizes profile text into directive lines and inline blocks.
+func splitProfile(text string) ([][]string, profileBlocks, error) {
+	var lines [][]string
+	blocks := make(profileBlocks)
+	nconn := 0
+
+	var inBlock string
+	var body strings.Builder
+	for _, raw := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := strings.TrimSpace(raw)
+		if inBlock != "" {
+			if strings.EqualFold(line, "</"+inBlock+">") {
+				key := inBlock
+				if key == "connection" {
+					key = "connection#" + strconv.Itoa(nconn)
+					nconn++
+				}
+				blocks[key] = body.String()
+				inBlock = ""
+				body.Reset()
+				continue
+			}
+			body.WriteString(raw)
+			body.WriteByte('\n')
+			continue
+		}
+		if len(line) > 2 && line[0] == '<' && line[len(line)-1] == '>' && line[1] != '/' {
+			inBlock = strings.ToLower(line[1 : len(line)-1])
+			continue
+		}
+		if args := splitLine(line); len(args) > 0 {
+			args[0] = strings.TrimPrefix(args[0], "--")
+			lines = append(lines, args)
+		}
+	}
+	if inBlock != "" {
+		return nil, nil, fmt.Errorf("unterminated <%s> block", inBlock)
+	}
+	return lines, blocks, nil
+}
+
+// splitLine splits one directive line the way OpenVPN does: on whitespace,
+// honouring "double" and 'single' quotes and backslash escapes. A token
+// starting with # or ; begins a comment.
+func splitLine(line string) []string {
+	var args []string
+	var cur strings.Builder
+	inToken := false
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(line) {
+				i++
+				cur.WriteByte(line[i])
+			} else {
+				cur.WriteByte(c)
+			}
+		case c == ' ' || c == '\t':
+			if inToken {
+				args = append(args, cur.String())
+				cur.Reset()
+				inToken = false
+			}
+		case !inToken && (c == '#' || c == ';'):
+			return args
+		case c == '"' || c == '\'':
+			quote = c
+			inToken = true
+		case c == '\\' && i+1 < len(line):
+			i++
+			cur.WriteByte(line[i])
+			inToken = true
+		default:
+			cur.WriteByte(c)
+			inToken = true
+		}
+	}
+	if inToken {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+func parseRemote(args []string, def Remote) (Remote, error) {
+	if len(args) < 2 {
+		return Remote{}, fmt.Errorf("remote: missing host")
+	}
+	r := def
+	r.Host = args[1]
+	if len(args) > 2 {
+		port, err := parsePort(args[2])
+		if err != nil {
+			return Remote{}, err
+		}
+		r.Port = port
+	}
+	if len(args) > 3 {
+		proto, err := normalizeProto(args[3])
+		if err != nil {
+			return Remote{}, err
+		}
+		r.Proto = proto
+	}
+	return r, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", s)
+	}
+	return port, nil
+}
+
+// normalizeProto maps udp/udp4/udp6/tcp/tcp-client/tcp4-client/... to "udp" or "tcp".
+func normalizeProto(s string) (string, error) {
+	s = strings.ToLower(s)
+	switch {
+	case strings.HasPrefix(s, "udp"):
+		return "udp", nil
+	case s == "tcp-server" || strings.HasSuffix(s, "-server"):
+		return "", fmt.Errorf("proto %s: server mode is not supported", s)
+	case strings.HasPrefix(s, "tcp"):
+		return "tcp", nil
+	}
+	return "", fmt.Errorf("proto %s: unsupported transport", s)
+}
+
+func parseKeyDirection(s string) (int, error) {
+	switch s {
+	case "0":
+		return 0, nil
+	case "1":
+		return 1, nil
+	}
+	return 0, fmt.Errorf("invalid key-direction %q", s)
+}
+
+func tlsVersion(s string) uint16 {
+	switch s {
+	case "1.0":
+		return tls.VersionTLS10
+	case "1.1":
+		return tls.VersionTLS11
+	case "1.2":
+		return tls.VersionTLS12
+	case "1.3":
+		return tls.VersionTLS13
+	}
+	return 0
+}
+
+// compressFraming returns the data channel framing for a compress or
+// comp-lzo directive. Compression itself is never used: the client only
+// speaks the uncompressed ("stub") framing the server expects.
+func compressFraming(args []string) string {
+	if strings.EqualFold(args[0], "comp-lzo") {
+		return "lzo"
+	}
+	alg := ""
+	if len(args) > 1 {
+		alg = strings.ToLower(args[1])
+	}
+	switch alg {
+	case "lzo":
+		return "lzo"
+	case "", "stub", "lz4":
+		return "stub"
+	case "stub-v2", "lz4-v2":
+		return "stub-v2"
+	}
+	return ""
+}
@@ -0,0 +1,378 @@
+package openvpn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/provider"
+)
+
+func testCAPEM(t *testing.T) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+}
+
+func testStaticKey() (*staticKey, string) {
+	var k staticKey
+	for i := range k {
+		k[i] = byte(i * 7)
+	}
+	h := hex.EncodeToString(k[:])
+	var sb strings.Builder
+	sb.WriteString("-----BEGIN OpenVPN Static key V1-----\n")
+	for i := 0; i < len(h); i += 32 {
+		sb.WriteString(h[i:i+32] + "\n")
+	}
+	sb.WriteString("-----END OpenVPN Static key V1-----\n")
+	return &k, sb.String()
+}
+
+func TestParseProfile(t *testing.T) {
+	_, keyText := testStaticKey()
+	text := `# exported profile
+client
+dev tun
+proto udp
+remote vpn1.example.com 1194
+remote "vpn2.example.com" 443 tcp-client
+remote-random
+cipher AES-256-CBC
+data-ciphers AES-128-GCM:CHACHA20-POLY1305
+auth SHA256
+auth-user-pass
+static-challenge "Enter OTP" 1
+remote-cert-tls server
+key-direction 1
+comp-lzo
+<ca>
+` + testCAPEM(t) + `</ca>
+<tls-auth>
+` + keyText + `</tls-auth>
+`
+	p, err := ParseProfile(text, "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Remote{{"vpn1.example.com", 1194, "udp"}, {"vpn2.example.com", 443, "tcp"}}
+	if len(p.Remotes) != 2 || p.Remotes[0] != want[0] || p.Remotes[1] != want[1] {
+		t.Errorf("remotes = %+v", p.Remotes)
+	}
+	if !p.RemoteRandom || !p.AuthUserPass || p.RemoteCertTLS != "server" {
+		t.Errorf("flags: random=%v auth-user-pass=%v remote-cert-tls=%q", p.RemoteRandom, p.AuthUserPass, p.RemoteCertTLS)
+	}
+	if p.TLSAuth == nil || p.KeyDirection != 1 || p.Auth != "SHA256" {
+		t.Errorf("tls-auth = %v, key-direction = %d, auth = %s", p.TLSAuth != nil, p.KeyDirection, p.Auth)
+	}
+	if len(p.DataCiphers) != 2 || p.DataCiphers[0] != "AES-128-GCM" {
+		t.Errorf("data-ciphers = %v", p.DataCiphers)
+	}
+	if p.StaticChallenge != "Enter OTP" || !p.StaticChallengeEcho {
+		t.Errorf("static-challenge = %q echo=%v", p.StaticChallenge, p.StaticChallengeEcho)
+	}
+	if p.Compress != "lzo" {
+		t.Errorf("compress = %q", p.Compress)
+	}
+}
+
+func TestParseProfileConnectionBlocks(t *testing.T) {
+	text := `client
+proto tcp
+port 443
+auth-user-pass
+<connection>
+remote a.example.com
+</connection>
+<connection>
+remote b.example.com 1194 udp
+</connection>
+<ca>
+` + testCAPEM(t) + `</ca>
+`
+	p, err := ParseProfile(text, "")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := []Remote{{"a.example.com", 443, "tcp"}, {"b.example.com", 1194, "udp"}}
+	if len(p.Remotes) != 2 || p.Remotes[0] != want[0] || p.Remotes[1] != want[1] {
+		t.Errorf("remotes = %+v", p.Remotes)
+	}
+}
+
+func TestParseProfileRejects(t *testing.T) {
+	ca := "<ca>\n" + testCAPEM(t) + "</ca>\n"
+	tests := map[string]string{
+		"tap":       "dev tap\nremote a\nauth-user-pass\n" + ca,
+		"no ca":     "remote a\nauth-user-pass\n",
+		"no auth":   "remote a\n" + ca,
+		"no remote": "auth-user-pass\n" + ca,
+	}
+	for name, text := range tests {
+		if _, err := ParseProfile(text, ""); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestSplitLine(t *testing.T) {
+	got := splitLine(`static-challenge "Enter \"the\" code" 1 # comment`)
+	if len(got) != 3 || got[1] != `Enter "the" code` || got[2] != "1" {
+		t.Errorf("splitLine = %q", got)
+	}
+	if got := splitLine("; comment only"); len(got) != 0 {
+		t.Errorf("comment = %q", got)
+	}
+}
+
+func TestPushReply(t *testing.T) {
+	c := pushedConfig{PeerID: -1}
+	msg := "PUSH_REPLY,route-gateway 10.8.0.1,ifconfig 10.8.0.6 255.255.255.0,topology subnet," +
+		"route 192.168.10.0 255.255.255.0,route 203.0.113.0 255.255.255.0 net_gateway," +
+		"route-ipv6 2001:db8:1::/48,ifconfig-ipv6 fd00::1000/64 fd00::1," +
+		"dhcp-option DNS 10.8.0.1,dhcp-option DOMAIN corp.example.com," +
+		"dns server 0 address [fd00::53]:53 10.8.0.1,peer-id 3,cipher AES-256-GCM," +
+		"key-derivation tls-ekm,ping 10,ping-restart 60,auth-token SESS_ID_abc"
+	for _, o := range splitPushOptions(msg) {
+		c.apply(o)
+	}
+	if c.IPv4 != netip.MustParsePrefix("10.8.0.6/24") || c.IPv6 != netip.MustParsePrefix("fd00::1000/64") {
+		t.Errorf("addresses = %v %v", c.IPv4, c.IPv6)
+	}
+	if len(c.Routes) != 2 || c.Routes[0] != netip.MustParsePrefix("192.168.10.0/24") {
+		t.Errorf("routes = %v", c.Routes)
+	}
+	if len(c.Excludes) != 1 || c.Excludes[0] != netip.MustParsePrefix("203.0.113.0/24") {
+		t.Errorf("excludes = %v", c.Excludes)
+	}
+	if len(c.DNS) != 2 || c.DNS[0] != "10.8.0.1" || c.DNS[1] != "fd00::53" {
+		t.Errorf("dns = %v", c.DNS)
+	}
+	if c.PeerID != 3 || c.Cipher != "AES-256-GCM" || c.KeyDerivation != "tls-ekm" || c.AuthToken != "SESS_ID_abc" {
+		t.Errorf("peer-id=%d cipher=%s kdf=%s token=%s", c.PeerID, c.Cipher, c.KeyDerivation, c.AuthToken)
+	}
+	if c.Ping != 10 || c.PingRestart != 60 {
+		t.Errorf("ping = %d/%d", c.Ping, c.PingRestart)
+	}
+	include := c.splitInclude()
+	if len(include) != 4 || include[2] != netip.MustParsePrefix("10.8.0.0/24") {
+		t.Errorf("include = %v", include)
+	}
+}
+
+func TestPushReplyRedirectGateway(t *testing.T) {
+	var c pushedConfig
+	for _, o := range splitPushOptions("PUSH_REPLY,redirect-gateway def1 bypass-dhcp,ifconfig 10.8.0.6 10.8.0.5") {
+		c.apply(o)
+	}
+	if c.IPv4 != netip.MustParsePrefix("10.8.0.6/32") {
+		t.Errorf("net30 address = %v", c.IPv4)
+	}
+	include := c.splitInclude()
+	if len(include) != 1 || include[0] != netip.MustParsePrefix("0.0.0.0/0") {
+		t.Errorf("include = %v", include)
+	}
+
+	var none pushedConfig
+	none.apply([]string{"ifconfig", "10.8.0.6", "255.255.255.0"})
+	if none.splitInclude() != nil {
+		t.Errorf("no routes pushed: include = %v", none.splitInclude())
+	}
+}
+
+func TestDataChannelRoundTrip(t *testing.T) {
+	material := make([]byte, 256)
+	for i := range material {
+		material[i] = byte(i)
+	}
+	// The server's view: keys 0 and 1 swapped.
+	serverMaterial := append(append([]byte(nil), material[128:]...), material[:128]...)
+
+	for _, cipherName := range []string{"AES-256-GCM", "AES-128-GCM", "CHACHA20-POLY1305"} {
+		for _, peerID := range []int{-1, 7} {
+			client, err := newDataKeys(cipherName, material, 1, peerID)
+			if err != nil {
+				t.Fatalf("%s: %v", cipherName, err)
+			}
+			server, err := newDataKeys(cipherName, serverMaterial, 1, peerID)
+			if err != nil {
+				t.Fatalf("%s: %v", cipherName, err)
+			}
+			plain := []byte{0x45, 0, 0, 20, 1, 2, 3, 4}
+			pkt := client.seal(plain)
+			wantOp := byte(opDataV1)
+			if peerID >= 0 {
+				wantOp = opDataV2
+			}
+			if pkt[0]>>3 != wantOp || pkt[0]&7 != 1 {
+				t.Errorf("%s/%d: header %#x", cipherName, peerID, pkt[0])
+			}
+			got, err := server.open(pkt)
+			if err != nil || !bytes.Equal(got, plain) {
+				t.Fatalf("%s/%d: open = %x, %v", cipherName, peerID, got, err)
+			}
+			if _, err := server.open(pkt); err == nil {
+				t.Errorf("%s/%d: replay accepted", cipherName, peerID)
+			}
+			tampered := client.seal(plain)
+			tampered[len(tampered)-1] ^= 1
+			if _, err := server.open(tampered); err == nil {
+				t.Errorf("%s/%d: tampered packet accepted", cipherName, peerID)
+			}
+		}
+	}
+}
+
+func TestReplayWindow(t *testing.T) {
+	var w replayWindow
+	for _, id := range []uint32{1, 3, 2, 100} {
+		if !w.check(id) {
+			t.Errorf("fresh id %d rejected", id)
+		}
+	}
+	for _, id := range []uint32{0, 3, 2, 30} {
+		if w.check(id) {
+			t.Errorf("id %d accepted", id)
+		}
+	}
+	if !w.check(99) {
+		t.Error("in-window id 99 rejected")
+	}
+}
+
+func TestTLSAuthWrap(t *testing.T) {
+	k, _ := testStaticKey()
+	raw := (&controlPacket{opcode: opControlV1, sid: sessionID{1, 2, 3, 4, 5, 6, 7, 8}, msgID: 5, payload: []byte("hello")}).marshal()
+
+	for _, dir := range []int{-1, 0, 1} {
+		client := newTLSAuth(k, "SHA256", dir)
+		// The server uses the opposite direction.
+		serverDir := dir
+		if dir >= 0 {
+			serverDir = 1 - dir
+		}
+		server := newTLSAuth(k, "SHA256", serverDir)
+		got, err := server.unwrap(client.wrap(raw))
+		if err != nil || !bytes.Equal(got, raw) {
+			t.Fatalf("dir %d: unwrap = %x, %v", dir, got, err)
+		}
+		p, err := parseControlPacket(got)
+		if err != nil || p.msgID != 5 || string(p.payload) != "hello" {
+			t.Errorf("dir %d: parsed %+v, %v", dir, p, err)
+		}
+	}
+
+	wrapped := newTLSAuth(k, "SHA1", -1).wrap(raw)
+	wrapped[len(wrapped)-1] ^= 1
+	if _, err := newTLSAuth(k, "SHA1", -1).unwrap(wrapped); err == nil {
+		t.Error("tampered tls-auth packet accepted")
+	}
+}
+
+func TestTLSCryptWrap(t *testing.T) {
+	k, _ := testStaticKey()
+	raw := (&controlPacket{opcode: opControlHardResetClientV2, sid: sessionID{9}, acks: []uint32{1}, ackSID: sessionID{8}}).marshal()
+
+	client := newTLSCrypt(k)
+	// The server sends with key 0 and receives with key 1.
+	server := &tlsCryptWrapper{
+		outEnc: client.inEnc, outMAC: client.inMAC,
+		inEnc: client.outEnc, inMAC: client.outMAC,
+	}
+	wrapped := client.wrap(raw)
+	if bytes.Contains(wrapped, raw[9:]) {
+		t.Error("tls-crypt payload not encrypted")
+	}
+	got, err := server.unwrap(wrapped)
+	if err != nil || !bytes.Equal(got, raw) {
+		t.Fatalf("unwrap = %x, %v", got, err)
+	}
+	back, err := client.unwrap(server.wrap(raw))
+	if err != nil || !bytes.Equal(back, raw) {
+		t.Fatalf("client unwrap = %x, %v", back, err)
+	}
+	if _, err := server.unwrap(server.wrap(raw)); err == nil {
+		t.Error("packet accepted with the wrong key direction")
+	}
+}
+
+func TestCompressionFraming(t *testing.T) {
+	pkt := []byte{0x45, 1, 2, 3}
+	for _, framing := range []string{"", "lzo", "stub", "stub-v2"} {
+		got, ok := unframeInbound(frameOutbound(framing, pkt))
+		if !ok || !bytes.Equal(got, pkt) {
+			t.Errorf("%q: round trip = %x, %v", framing, got, ok)
+		}
+	}
+	escaped := []byte{0x50, 9}
+	got, ok := unframeInbound(frameOutbound("stub-v2", escaped))
+	if !ok || !bytes.Equal(got, escaped) {
+		t.Errorf("stub-v2 escape = %x, %v", got, ok)
+	}
+}
+
+func TestKeyMethod2(t *testing.T) {
+	src, err := newClientKeySource()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := clientKeyMessage(src, "V4,dev-type tun", "alice", "secret", "IV_VER=2.6.0\n")
+	if !bytes.Contains(msg, []byte("alice\x00")) || !bytes.Contains(msg, []byte("V4,dev-type tun\x00")) {
+		t.Errorf("key message = %q", msg)
+	}
+
+	server := &keySource{}
+	copy(server.random1[:], bytes.Repeat([]byte{1}, 32))
+	copy(server.random2[:], bytes.Repeat([]byte{2}, 32))
+	a := deriveKeysPRF(src, server, sessionID{1}, sessionID{2})
+	b := deriveKeysPRF(src, server, sessionID{1}, sessionID{3})
+	if len(a) != 256 || bytes.Equal(a, b) {
+		t.Errorf("derived keys: len %d, session-bound %v", len(a), !bytes.Equal(a, b))
+	}
+}
+
+func TestParseAuthFailed(t *testing.T) {
+	err := parseAuthFailed("AUTH_FAILED,CRV1:R,E:Om01u7Fh4LrGBS7uh0SWmzwabUiGiW6l:Y3Ix:Please enter token PIN")
+	var ch *challengeError
+	if !errors.As(err, &ch) {
+		t.Fatalf("err = %v", err)
+	}
+	if ch.state != "Om01u7Fh4LrGBS7uh0SWmzwabUiGiW6l" || ch.username != "cr1" || ch.prompt != "Please enter token PIN" {
+		t.Errorf("challenge = %+v", ch)
+	}
+	if !errors.Is(err, provider.ErrAuthRequired) {
+		t.Error("challenge does not wrap ErrAuthRequired")
+	}
+
+	err = parseAuthFailed("AUTH_FAILED,bad password")
+	var af *authFailedError
+	if !errors.As(err, &af) || af.reason != "bad password" || errors.Is(err, provider.ErrAuthRequired) {
+		t.Errorf("err = %v", err)
+	}
+}
@@ -0,0 +1,483 @@
+package openvpn
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/netip"
+	"path/filepath"
+	"sync"
+	"syscall"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/platform"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// Provider implements provider.TunnelProvider, provider.RawForwarder,
+// provider.EndpointProvider, provider.SplitRouteProvider, provider.DNSProvider
+// and provider.AuthParamSetter for OpenVPN servers (userspace client, TUN
+// mode, AEAD data channel over UDP or TCP).
+type Provider struct {
+	mu     sync.RWMutex
+	config Config
+	state  core.TunnelState
+	name   string
+
+	adapterIP       netip.Addr
+	serverEndpoints []netip.AddrPort
+	splitInclude    []netip.Prefix
+	splitExclude    []netip.Prefix
+	dns             []string
+
+	// authParams holds ephemeral auth params (otp_code) set at connect time.
+	// Cleared after each Connect attempt.
+	authParams map[string]string
+
+	// Real NIC info for bypassing TUN DNS and routing.
+	realNICIndex uint32
+	binder       platform.InterfaceBinder
+
+	// rawUDP carries DialUDP connections as raw IP packets through the tunnel.
+	rawUDP *anyconnect.RawUDP
+
+	// onSessionDrop is called when the tunnel drops unexpectedly.
+	onSessionDrop func(tunnelID string, err error)
+
+	eventBus *core.EventBus
+
+	// Reconnect state: the pushed auth-token, or a pending CRV1 challenge.
+	authToken     string
+	authTokenUser string
+	challenge     *challengeError
+	resumable     bool // last connect needed no one-time input, or got a token
+
+	sess           *session
+	inboundHandler *func(pkt []byte) bool
+}
+
+// New creates a new OpenVPN provider. The profile is read on every Connect,
+// so edits to the .ovpn file apply on the next connection.
+func New(name string, cfg Config) (*Provider, error) {
+	if cfg.ConfigFile == "" && cfg.ConfigText == "" {
+		return nil, fmt.Errorf("openvpn: config_file is required")
+	}
+	p := &Provider{
+		name:   name,
+		config: cfg,
+		state:  core.TunnelStateDown,
+	}
+	if _, err := p.loadProfile(); err != nil {
+		return nil, fmt.Errorf("openvpn: %w", err)
+	}
+	p.rawUDP = anyconnect.NewRawUDP(p.sendData)
+	return p, nil
+}
+
+func (p *Provider) Name() string     { return p.name }
+func (p *Provider) Protocol() string { return core.ProtocolOpenVPN }
+
+func (p *Provider) loadProfile() (*Profile, error) {
+	if p.config.ConfigText != "" {
+		dir := ""
+		if p.config.ConfigFile != "" {
+			dir = filepath.Dir(p.config.ConfigFile)
+		}
+		return ParseProfile(p.config.ConfigText, dir)
+	}
+	return LoadProfile(p.config.ConfigFile)
+}
+
+// SetAuthParams implements provider.AuthParamSetter.
+// Accepts otp_code: the static-challenge response or the answer to a CRV1
+// dynamic challenge from the previous attempt.
+func (p *Provider) SetAuthParams(params map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.authParams = params
+}
+
+// SetRealNICIndex sets the real NIC interface index for DNS resolution bypass.
+func (p *Provider) SetRealNICIndex(index uint32) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.realNICIndex = index
+}
+
+// SetInterfaceBinder sets the platform-specific interface binder.
+func (p *Provider) SetInterfaceBinder(binder platform.InterfaceBinder) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.binder = binder
+}
+
+// SetOnSessionDrop sets a callback invoked when the tunnel drops unexpectedly.
+func (p *Provider) SetOnSessionDrop(fn func(tunnelID string, err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onSessionDrop = fn
+}
+
+// SetEventBus sets the event bus for publishing tunnel events.
+func (p *Provider) SetEventBus(bus *core.EventBus) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.eventBus = bus
+}
+
+func (p *Provider) State() core.TunnelState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.state
+}
+
+func (p *Provider) GetAdapterIP() netip.Addr {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.adapterIP
+}
+
+// GetServerEndpoints implements provider.EndpointProvider.
+func (p *Provider) GetServerEndpoints() []netip.AddrPort {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.serverEndpoints
+}
+
+// GetSplitInclude returns the pushed routes (redirect-gateway as a default route).
+func (p *Provider) GetSplitInclude() []netip.Prefix {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.splitInclude
+}
+
+// GetSplitExclude returns the routes pushed via net_gateway.
+func (p *Provider) GetSplitExclude() []netip.Prefix {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.splitExclude
+}
+
+// GetDNS returns the DNS servers pushed with dhcp-option DNS or dns server.
+func (p *Provider) GetDNS() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.dns
+}
+
+// HasSavedSession reports whether a dropped session can be re-established
+// without the user: the server pushed an auth-token, or the last connect did
+// not need a one-time code.
+func (p *Provider) HasSavedSession() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.resumable
+}
+
+// ClearSession forgets the auth-token, forcing full authentication on the
+// next connect.
+func (p *Provider) ClearSession() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.authToken = ""
+	p.authTokenUser = ""
+	p.resumable = false
+}
+
+// Connect reads the profile and connects to its remotes in order until one
+// succeeds.
+func (p *Provider) Connect(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = core.TunnelStateConnecting
+
+	// Consume one-shot auth params early so they are never reused.
+	params := p.authParams
+	p.authParams = nil
+
+	prof, err := p.loadProfile()
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[OpenVPN] %w", err)
+	}
+	creds, usedOTP, err := p.credentials(prof, params["otp_code"])
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[OpenVPN] %w", err)
+	}
+
+	var controlFn func(string, string, syscall.RawConn) error
+	if p.binder != nil && p.realNICIndex > 0 {
+		controlFn = p.binder.BindControl(p.realNICIndex)
+	}
+
+	remotes := prof.Remotes
+	if prof.RemoteRandom {
+		remotes = append([]Remote(nil), remotes...)
+		rand.Shuffle(len(remotes), func(i, j int) { remotes[i], remotes[j] = remotes[j], remotes[i] })
+	}
+
+	var lastErr error
+	for _, r := range remotes {
+		if ctx.Err() != nil {
+			break
+		}
+		core.Log.Infof("OpenVPN", "Connecting tunnel %q to %s:%d/%s...", p.name, r.Host, r.Port, r.Proto)
+		err := p.establish(ctx, prof, r, creds, controlFn)
+		if err == nil {
+			p.resumable = p.authToken != "" || !usedOTP
+			return nil
+		}
+		lastErr = err
+		core.Log.Warnf("OpenVPN", "Remote %s:%d/%s failed: %v", r.Host, r.Port, r.Proto, err)
+
+		var chErr *challengeError
+		var authErr *authFailedError
+		switch {
+		case errors.As(err, &chErr):
+			if chErr.username == "" {
+				chErr.username = creds.username
+			}
+			p.challenge = chErr
+		case errors.As(err, &authErr):
+			// A rejected token or password fails on every remote alike.
+			p.authToken = ""
+			p.authTokenUser = ""
+			p.resumable = false
+		default:
+			continue
+		}
+		break
+	}
+	if lastErr == nil {
+		lastErr = ctx.Err()
+	}
+	p.state = core.TunnelStateError
+	return fmt.Errorf("[OpenVPN] %w", lastErr)
+}
+
+// credentials picks what to send for auth-user-pass, in order: the pushed
+// auth-token of the previous session, the answer to a pending CRV1
+// challenge, or the configured password (SCRV1-encoded with the OTP for
+// static-challenge profiles). usedOTP reports whether a one-time code was
+// needed. Must be called under p.mu.
+func (p *Provider) credentials(prof *Profile, otp string) (credentials, bool, error) {
+	user := p.config.Username
+	if user == "" {
+		user = prof.Username
+	}
+	pass := p.config.Password
+	if pass == "" {
+		pass = prof.Password
+	}
+
+	switch {
+	case p.authToken != "":
+		if p.authTokenUser != "" {
+			user = p.authTokenUser
+		}
+		return credentials{user, p.authToken}, false, nil
+	case p.challenge != nil:
+		if otp == "" {
+			return credentials{}, true, p.challenge
+		}
+		ch := p.challenge
+		p.challenge = nil
+		return credentials{ch.username, "CRV1::" + ch.state + "::" + otp}, true, nil
+	case !prof.AuthUserPass:
+		return credentials{}, false, nil
+	}
+
+	if user == "" {
+		return credentials{}, false, fmt.Errorf("auth-user-pass: username is required")
+	}
+	if prof.StaticChallenge != "" {
+		if otp == "" {
+			return credentials{}, true, fmt.Errorf("%w: %s", provider.ErrAuthRequired, prof.StaticChallenge)
+		}
+		pass = "SCRV1:" + base64.StdEncoding.EncodeToString([]byte(pass)) + ":" +
+			base64.StdEncoding.EncodeToString([]byte(otp))
+		return credentials{user, pass}, true, nil
+	}
+	return credentials{user, pass}, false, nil
+}
+
+// establish connects to one remote and brings the tunnel up.
+// Must be called under p.mu.
+func (p *Provider) establish(ctx context.Context, prof *Profile, r Remote, creds credentials, controlFn func(string, string, syscall.RawConn) error) error {
+	tr, endpoint, err := dialTransport(ctx, r, controlFn)
+	if endpoint.IsValid() {
+		p.serverEndpoints = []netip.AddrPort{endpoint}
+	}
+	if err != nil {
+		return err
+	}
+	s, err := newSession(prof, tr, creds)
+	if err != nil {
+		tr.close()
+		return err
+	}
+	// Drops before the tunnel is up are ignored: p.sess is not s yet.
+	s.onClose = func(err error) { p.onTunnelDrop(s, err) }
+	pushed, err := s.start(ctx)
+	if err != nil {
+		s.close()
+		return err
+	}
+	if !pushed.IPv4.IsValid() && !pushed.IPv6.IsValid() {
+		s.close()
+		return fmt.Errorf("server pushed no tunnel address")
+	}
+
+	p.adapterIP = pushed.IPv4.Addr()
+	if !pushed.IPv4.IsValid() {
+		p.adapterIP = pushed.IPv6.Addr()
+	}
+	p.splitInclude = pushed.splitInclude()
+	p.splitExclude = pushed.Excludes
+	p.dns = pushed.DNS
+	p.authToken = pushed.AuthToken
+	p.authTokenUser = pushed.AuthTokenUser
+
+	if p.inboundHandler != nil {
+		s.setInboundHandler(p.inboundHandler)
+	}
+	p.sess = s
+	p.state = core.TunnelStateUp
+
+	core.Log.Infof("OpenVPN", "Tunnel established: IP=%s IPv6=%s DNS=%v routes=%d excludes=%d cipher=%s",
+		pushed.IPv4, pushed.IPv6, pushed.DNS, len(p.splitInclude), len(p.splitExclude), s.cipher)
+	core.Log.Infof("OpenVPN", "Tunnel %q is UP", p.name)
+	return nil
+}
+
+// onTunnelDrop marks the tunnel failed and notifies the controller.
+func (p *Provider) onTunnelDrop(s *session, err error) {
+	p.mu.Lock()
+	if p.sess != s {
+		p.mu.Unlock()
+		return
+	}
+	core.Log.Warnf("OpenVPN", "Session dropped for %q: %v", p.name, err)
+	p.state = core.TunnelStateError
+	p.sess = nil
+	var authErr *authFailedError
+	if errors.As(err, &authErr) {
+		p.authToken = ""
+		p.resumable = false
+	}
+	onDrop := p.onSessionDrop
+	p.mu.Unlock()
+	if onDrop != nil {
+		onDrop(p.name, err)
+	}
+}
+
+// Disconnect tears down the tunnel. An auth-token is kept for reconnects.
+func (p *Provider) Disconnect() error {
+	p.mu.Lock()
+	s := p.sess
+	p.sess = nil
+	p.mu.Unlock()
+
+	if s != nil {
+		s.close()
+	}
+
+	p.mu.Lock()
+	p.state = core.TunnelStateDown
+	p.adapterIP = netip.Addr{}
+	p.mu.Unlock()
+
+	core.Log.Infof("OpenVPN", "Tunnel %q disconnected", p.name)
+	return nil
+}
+
+// ---- RawForwarder interface ----
+
+// InjectOutbound sends an IP packet through the tunnel.
+func (p *Provider) InjectOutbound(pkt []byte) bool {
+	return p.sendData(pkt)
+}
+
+// InjectOutboundPriority sends an IP packet (priority is ignored).
+func (p *Provider) InjectOutboundPriority(pkt []byte, _ byte) bool {
+	return p.sendData(pkt)
+}
+
+// SetInboundHandler registers a callback for incoming IP packets from the tunnel.
+// Wraps the handler with the DialUDP response interceptor.
+func (p *Provider) SetInboundHandler(handler func(pkt []byte) bool) {
+	var wrapped func(pkt []byte) bool
+	if handler != nil {
+		wrapped = func(pkt []byte) bool {
+			if p.rawUDP.Intercept(pkt) {
+				return true
+			}
+			return handler(pkt)
+		}
+	}
+
+	p.mu.Lock()
+	p.inboundHandler = &wrapped
+	s := p.sess
+	p.mu.Unlock()
+
+	if s != nil {
+		s.setInboundHandler(&wrapped)
+	}
+}
+
+func (s *session) setInboundHandler(h *func(pkt []byte) bool) {
+	fn := func(pkt []byte) {
+		if *h != nil {
+			(*h)(pkt)
+		}
+	}
+	s.inbound.Store(&fn)
+}
+
+func (p *Provider) sendData(pkt []byte) bool {
+	p.mu.RLock()
+	s := p.sess
+	p.mu.RUnlock()
+	return s != nil && s.sendData(pkt)
+}
+
+// ---- DialTCP / DialUDP ----
+// OpenVPN uses RawForwarder for IP-level forwarding.
+
+func (p *Provider) DialTCP(_ context.Context, _ string) (net.Conn, error) {
+	return nil, fmt.Errorf("openvpn: DialTCP not supported, use RawForwarder")
+}
+
+// DialUDP creates a virtual UDP connection tunneled at the raw IP level.
+func (p *Provider) DialUDP(_ context.Context, addr string) (net.Conn, error) {
+	p.mu.RLock()
+	s := p.sess
+	adapterIP := p.adapterIP
+	p.mu.RUnlock()
+
+	if s == nil {
+		return nil, fmt.Errorf("openvpn: not connected")
+	}
+	conn, err := p.rawUDP.Dial(addr, adapterIP)
+	if err != nil {
+		return nil, fmt.Errorf("openvpn: %w", err)
+	}
+	return conn, nil
+}
+
+var (
+	_ provider.TunnelProvider     = (*Provider)(nil)
+	_ provider.RawForwarder       = (*Provider)(nil)
+	_ provider.EndpointProvider   = (*Provider)(nil)
+	_ provider.SplitRouteProvider = (*Provider)(nil)
+	_ provider.DNSProvider        = (*Provider)(nil)
+	_ provider.AuthParamSetter    = (*Provider)(nil)
+)
@@ -0,0 +1,193 @@
+package openvpn
+
+import (
+	"encoding/base64"
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// pushedConfig is the tunnel configuration from PUSH_REPLY, merged with the
+// route directives of the profile.
+type pushedConfig struct {
+	IPv4, IPv6      netip.Prefix
+	Topology        string
+	Routes          []netip.Prefix
+	Excludes        []netip.Prefix
+	RedirectGateway bool
+	RedirectIPv6    bool
+	DNS             []string
+
+	PeerID        int // -1 when not pushed
+	Cipher        string
+	KeyDerivation string // "tls-ekm" or empty
+	Compress      string // framing, see compressFraming
+	Ping          int
+	PingRestart   int
+	TunMTU        int
+	AuthToken     string
+	AuthTokenUser string
+
+	ifconfig []string // last ifconfig option, re-applied when topology follows it
+}
+
+// splitPushOptions splits the body of a PUSH_REPLY ("PUSH_REPLY,opt a,opt b")
+// into tokenized options.
+func splitPushOptions(msg string) [][]string {
+	msg = strings.TrimPrefix(msg, "PUSH_REPLY")
+	var opts [][]string
+	for _, o := range strings.Split(msg, ",") {
+		if args := splitLine(strings.TrimSpace(o)); len(args) > 0 {
+			opts = append(opts, args)
+		}
+	}
+	return opts
+}
+
+// apply merges one pushed or profile option into c. Unknown options are ignored.
+func (c *pushedConfig) apply(args []string) {
+	arg := func(i int) string {
+		if i < len(args) {
+			return args[i]
+		}
+		return ""
+	}
+	switch strings.ToLower(args[0]) {
+	case "ifconfig":
+		addr, err := netip.ParseAddr(arg(1))
+		if err != nil || !addr.Is4() {
+			return
+		}
+		bits := 32
+		if mask := net.ParseIP(arg(2)).To4(); mask != nil && c.Topology == "subnet" {
+			bits, _ = net.IPMask(mask).Size()
+		}
+		c.IPv4 = netip.PrefixFrom(addr, bits)
+		c.ifconfig = args
+	case "ifconfig-ipv6":
+		if pfx, err := netip.ParsePrefix(arg(1)); err == nil {
+			c.IPv6 = pfx
+		}
+	case "topology":
+		c.Topology = arg(1)
+		// ifconfig may come first; its second argument depends on the topology.
+		if c.ifconfig != nil {
+			c.apply(c.ifconfig)
+		}
+	case "route":
+		addr, err := netip.ParseAddr(arg(1))
+		if err != nil || !addr.Is4() {
+			return // vpn_gateway/net_gateway/hostnames as network
+		}
+		bits := 32
+		if m := arg(2); m != "" && m != "default" {
+			mask := net.ParseIP(m).To4()
+			if mask == nil {
+				return
+			}
+			bits, _ = net.IPMask(mask).Size()
+		}
+		c.addRoute(netip.PrefixFrom(addr, bits).Masked(), arg(3))
+	case "route-ipv6":
+		pfx, err := netip.ParsePrefix(arg(1))
+		if err != nil {
+			return
+		}
+		c.addRoute(pfx.Masked(), arg(2))
+	case "redirect-gateway":
+		c.RedirectGateway = true
+		for _, flag := range args[1:] {
+			switch flag {
+			case "ipv6":
+				c.RedirectIPv6 = true
+			case "!ipv4":
+				c.RedirectGateway = false
+			}
+		}
+	case "dhcp-option":
+		switch strings.ToUpper(arg(1)) {
+		case "DNS", "DNS6":
+			if _, err := netip.ParseAddr(arg(2)); err == nil {
+				c.addDNS(arg(2))
+			}
+		}
+	case "dns":
+		// dns server <n> address <addr[:port]> [...]
+		if arg(1) != "server" || arg(3) != "address" {
+			return
+		}
+		for _, a := range args[4:] {
+			if ap, err := netip.ParseAddrPort(a); err == nil {
+				a = ap.Addr().String()
+			}
+			if _, err := netip.ParseAddr(a); err == nil {
+				c.addDNS(a)
+			}
+		}
+	case "peer-id":
+		if id, err := strconv.Atoi(arg(1)); err == nil && id >= 0 && id < 1<<24 {
+			c.PeerID = id
+		}
+	case "cipher":
+		c.Cipher = strings.ToUpper(arg(1))
+	case "key-derivation":
+		c.KeyDerivation = arg(1)
+	case "comp-lzo", "compress":
+		c.Compress = compressFraming(args)
+	case "ping":
+		c.Ping, _ = strconv.Atoi(arg(1))
+	case "ping-restart", "ping-exit":
+		c.PingRestart, _ = strconv.Atoi(arg(1))
+	case "tun-mtu":
+		c.TunMTU, _ = strconv.Atoi(arg(1))
+	case "auth-token":
+		c.AuthToken = arg(1)
+	case "auth-token-user":
+		if u, err := base64.StdEncoding.DecodeString(arg(1)); err == nil {
+			c.AuthTokenUser = string(u)
+		}
+	}
+}
+
+// addRoute records a route; routes via net_gateway bypass the tunnel.
+func (c *pushedConfig) addRoute(pfx netip.Prefix, gateway string) {
+	if gateway == "net_gateway" {
+		c.Excludes = append(c.Excludes, pfx)
+		return
+	}
+	c.Routes = append(c.Routes, pfx)
+}
+
+func (c *pushedConfig) addDNS(addr string) {
+	for _, d := range c.DNS {
+		if d == addr {
+			return
+		}
+	}
+	c.DNS = append(c.DNS, addr)
+}
+
+// splitInclude returns the prefixes to route through the tunnel. Empty means
+// the server pushed no routing, and the user's rules decide alone.
+func (c *pushedConfig) splitInclude() []netip.Prefix {
+	var out []netip.Prefix
+	if c.RedirectGateway {
+		out = append(out, netip.MustParsePrefix("0.0.0.0/0"))
+	}
+	if c.RedirectIPv6 {
+		out = append(out, netip.MustParsePrefix("::/0"))
+	}
+	out = append(out, c.Routes...)
+	if len(out) == 0 {
+		return nil
+	}
+	// The tunnel's own subnet is reachable through it as well.
+	if c.IPv4.IsValid() && c.IPv4.Bits() < 32 {
+		out = append(out, c.IPv4.Masked())
+	}
+	if c.IPv6.IsValid() && c.IPv6.Bits() < 128 {
+		out = append(out, c.IPv6.Masked())
+	}
+	return out
+}
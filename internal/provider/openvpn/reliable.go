@@ -0,0 +1,244 @@
+package openvpn
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	reliableWindow    = 6    // control messages in flight
+	maxControlPayload = 1100 // TLS bytes per control packet, keeps UDP packets under the usual MTU
+	initialRetransmit = time.Second
+	maxRetransmit     = 8 * time.Second
+)
+
+type outMsg struct {
+	id      uint32
+	opcode  byte
+	payload []byte
+	sentAt  time.Time
+	timeout time.Duration
+}
+
+// keyState is one TLS session on the control channel, identified by its key
+// id. It implements OpenVPN's reliability layer: message ids, acks,
+// retransmission and in-order delivery of the TLS byte stream.
+type keyState struct {
+	s  *session
+	id byte
+
+	mu       sync.Mutex
+	cond     *sync.Cond // window space or inbound data
+	nextSend uint32
+	unacked  []*outMsg
+	acks     []uint32
+	nextRecv uint32
+	pending  map[uint32]*controlPacket // received out of order
+	inbound  []byte                    // in-order TLS bytes not yet read
+	closed   bool
+
+	// resetRcvd is closed when the server's reset for this key arrives.
+	resetRcvd chan struct{}
+	resetOnce sync.Once
+
+	// Set by the key exchange.
+	tls    *tls.Conn
+	client *keySource
+	server *keySource
+}
+
+func newKeyState(s *session, id byte) *keyState {
+	ks := &keyState{
+		s:         s,
+		id:        id,
+		pending:   make(map[uint32]*controlPacket),
+		resetRcvd: make(chan struct{}),
+	}
+	ks.cond = sync.NewCond(&ks.mu)
+	return ks
+}
+
+// send queues a reliable control message and transmits it, blocking while
+// the send window is full.
+func (ks *keyState) send(opcode byte, payload []byte) error {
+	ks.mu.Lock()
+	for len(ks.unacked) >= reliableWindow && !ks.closed {
+		ks.cond.Wait()
+	}
+	if ks.closed {
+		ks.mu.Unlock()
+		return net.ErrClosed
+	}
+	m := &outMsg{id: ks.nextSend, opcode: opcode, payload: payload, timeout: initialRetransmit}
+	ks.nextSend++
+	ks.unacked = append(ks.unacked, m)
+	p := ks.packetLocked(m)
+	ks.mu.Unlock()
+	return ks.s.writeControl(p)
+}
+
+// packetLocked builds the packet for m, piggybacking pending acks.
+func (ks *keyState) packetLocked(m *outMsg) *controlPacket {
+	p := &controlPacket{opcode: m.opcode, keyID: ks.id, sid: ks.s.localSID, msgID: m.id, payload: m.payload}
+	ks.attachAcksLocked(p)
+	m.sentAt = time.Now()
+	return p
+}
+
+func (ks *keyState) attachAcksLocked(p *controlPacket) {
+	n := min(len(ks.acks), maxAcks)
+	if n == 0 {
+		return
+	}
+	p.acks = append([]uint32(nil), ks.acks[:n]...)
+	p.ackSID = ks.s.remoteSID()
+	ks.acks = ks.acks[n:]
+}
+
+// receive processes an incoming control packet for this key.
+func (ks *keyState) receive(p *controlPacket) {
+	ks.mu.Lock()
+	for _, a := range p.acks {
+		for i, m := range ks.unacked {
+			if m.id == a {
+				ks.unacked = append(ks.unacked[:i], ks.unacked[i+1:]...)
+				break
+			}
+		}
+	}
+	if p.opcode != opAckV1 {
+		// Ack duplicates too: our previous ack may have been lost.
+		if !containsID(ks.acks, p.msgID) {
+			ks.acks = append(ks.acks, p.msgID)
+		}
+		switch {
+		case p.msgID == ks.nextRecv:
+			ks.deliverLocked(p)
+			ks.nextRecv++
+			for {
+				next, ok := ks.pending[ks.nextRecv]
+				if !ok {
+					break
+				}
+				delete(ks.pending, ks.nextRecv)
+				ks.deliverLocked(next)
+				ks.nextRecv++
+			}
+		case p.msgID > ks.nextRecv && p.msgID-ks.nextRecv < 4*reliableWindow:
+			ks.pending[p.msgID] = p
+		}
+	}
+	ks.cond.Broadcast()
+	ks.mu.Unlock()
+	ks.flushAcks()
+}
+
+func (ks *keyState) deliverLocked(p *controlPacket) {
+	switch p.opcode {
+	case opControlHardResetServerV2, opControlSoftResetV1:
+		ks.resetOnce.Do(func() { close(ks.resetRcvd) })
+	case opControlV1:
+		ks.inbound = append(ks.inbound, p.payload...)
+	}
+}
+
+// flushAcks sends pending acks in a P_ACK_V1 packet.
+func (ks *keyState) flushAcks() {
+	ks.mu.Lock()
+	if len(ks.acks) == 0 || ks.closed {
+		ks.mu.Unlock()
+		return
+	}
+	p := &controlPacket{opcode: opAckV1, keyID: ks.id, sid: ks.s.localSID}
+	ks.attachAcksLocked(p)
+	more := len(ks.acks) > 0
+	ks.mu.Unlock()
+	ks.s.writeControl(p)
+	if more {
+		ks.flushAcks()
+	}
+}
+
+// retransmit resends messages whose ack is overdue, backing off exponentially.
+func (ks *keyState) retransmit(now time.Time) {
+	ks.mu.Lock()
+	var resend []*controlPacket
+	for _, m := range ks.unacked {
+		if now.Sub(m.sentAt) < m.timeout {
+			continue
+		}
+		resend = append(resend, ks.packetLocked(m))
+		m.timeout = min(2*m.timeout, maxRetransmit)
+	}
+	ks.mu.Unlock()
+	for _, p := range resend {
+		ks.s.writeControl(p)
+	}
+}
+
+func (ks *keyState) close() {
+	ks.mu.Lock()
+	ks.closed = true
+	ks.cond.Broadcast()
+	ks.mu.Unlock()
+}
+
+func containsID(ids []uint32, id uint32) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// controlStream is the net.Conn crypto/tls runs over: writes become
+// reliable P_CONTROL_V1 messages, reads return the in-order payload bytes.
+type controlStream struct{ ks *keyState }
+
+func (c controlStream) Read(b []byte) (int, error) {
+	ks := c.ks
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	for len(ks.inbound) == 0 && !ks.closed {
+		ks.cond.Wait()
+	}
+	if len(ks.inbound) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, ks.inbound)
+	ks.inbound = ks.inbound[n:]
+	return n, nil
+}
+
+func (c controlStream) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		n := min(len(b)-written, maxControlPayload)
+		chunk := append([]byte(nil), b[written:written+n]...)
+		if err := c.ks.send(opControlV1, chunk); err != nil {
+			return written, err
+		}
+		written += n
+	}
+	return written, nil
+}
+
+func (c controlStream) Close() error {
+	c.ks.close()
+	return nil
+}
+
+func (c controlStream) LocalAddr() net.Addr                { return controlAddr{} }
+func (c controlStream) RemoteAddr() net.Addr               { return controlAddr{} }
+func (c controlStream) SetDeadline(_ time.Time) error      { return nil }
+func (c controlStream) SetReadDeadline(_ time.Time) error  { return nil }
+func (c controlStream) SetWriteDeadline(_ time.Time) error { return nil }
+
+type controlAddr struct{}
+
+func (controlAddr) Network() string { return "openvpn" }
+func (controlAddr) String() string  { return "openvpn-control" }
@@ -0,0 +1,748 @@
+package openvpn
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+)
+
+const (
+	resetTimeout     = 10 * time.Second // wait for the server's reset before trying the next remote
+	lameDuckDuration = 60 * time.Second // old key accepted after renegotiation
+	pushRetry        = 3 * time.Second
+	ekmLabel         = "EXPORTER-OpenVPN-datakeys"
+)
+
+// credentials are sent in the key method 2 message (auth-user-pass).
+type credentials struct {
+	username, password string
+}
+
+// challengeError is returned when the server answers with a dynamic
+// challenge (CRV1). The response is sent on the next connect as
+// "CRV1::<state>::<response>".
+type challengeError struct {
+	state    string
+	username string
+	prompt   string
+}
+
+func (e *challengeError) Error() string {
+	return "server requested a challenge response: " + e.prompt
+}
+
+func (e *challengeError) Unwrap() error { return provider.ErrAuthRequired }
+
+// authFailedError is AUTH_FAILED from the server.
+type authFailedError struct{ reason string }
+
+func (e *authFailedError) Error() string {
+	if e.reason == "" {
+		return "authentication failed"
+	}
+	return "authentication failed: " + e.reason
+}
+
+// session is one OpenVPN connection: the control channel with its key
+// states and the data channel. It ends on transport errors, ping-restart
+// or a server restart; the owner is told through onClose.
+type session struct {
+	prof     *Profile
+	tr       transport
+	wrap     controlWrapper
+	creds    credentials
+	localSID sessionID
+	remote   atomic.Pointer[sessionID]
+
+	mu      sync.Mutex
+	keys    map[byte]*keyState
+	current atomic.Pointer[dataKeys]
+	lame    *dataKeys
+	lameKS  *keyState
+	lameEnd time.Time
+
+	// Negotiated at the first key exchange.
+	cipher      string
+	ekm         bool
+	peerID      int
+	framing     string
+	ping        time.Duration
+	pingRestart time.Duration
+
+	lastRecv atomic.Int64 // unix nanoseconds
+	lastSend atomic.Int64
+
+	messages chan string // control channel text messages
+	inbound  atomic.Pointer[func(pkt []byte)]
+
+	done     chan struct{}
+	doneOnce sync.Once
+	err      error
+	stopping atomic.Bool
+	onClose  func(error)
+}
+
+func newSession(prof *Profile, tr transport, creds credentials) (*session, error) {
+	s := &session{
+		prof:     prof,
+		tr:       tr,
+		creds:    creds,
+		keys:     make(map[byte]*keyState),
+		messages: make(chan string, 16),
+		done:     make(chan struct{}),
+		peerID:   -1,
+	}
+	if _, err := rand.Read(s.localSID[:]); err != nil {
+		return nil, err
+	}
+	switch {
+	case prof.TLSCrypt != nil:
+		s.wrap = newTLSCrypt(prof.TLSCrypt)
+	case prof.TLSAuth != nil:
+		s.wrap = newTLSAuth(prof.TLSAuth, prof.Auth, prof.KeyDirection)
+	default:
+		s.wrap = plainWrapper{}
+	}
+	now := time.Now().UnixNano()
+	s.lastRecv.Store(now)
+	s.lastSend.Store(now)
+	return s, nil
+}
+
+func (s *session) remoteSID() sessionID {
+	if p := s.remote.Load(); p != nil {
+		return *p
+	}
+	return sessionID{}
+}
+
+// start runs the initial handshake: hard reset, TLS, key exchange and
+// PUSH_REQUEST. It returns the pushed configuration merged with the
+// profile's own route directives.
+func (s *session) start(ctx context.Context) (*pushedConfig, error) {
+	core.SafeGo("openvpn.read", s.readLoop)
+	core.SafeGo("openvpn.timer", s.timerLoop)
+
+	ks := s.addKeyState(0)
+	if err := ks.send(opControlHardResetClientV2, nil); err != nil {
+		return nil, err
+	}
+	resetCtx, cancel := context.WithTimeout(ctx, resetTimeout)
+	defer cancel()
+	select {
+	case <-ks.resetRcvd:
+	case <-s.done:
+		return nil, s.err
+	case <-resetCtx.Done():
+		if s.prof.TLSAuth != nil || s.prof.TLSCrypt != nil {
+			return nil, errors.New("no response from server (check the remote and the tls-auth/tls-crypt key)")
+		}
+		return nil, errors.New("no response from server")
+	}
+
+	if err := s.negotiate(ctx, ks); err != nil {
+		return nil, err
+	}
+	pushed, err := s.requestPush(ctx, ks)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cipher = pushed.Cipher
+	if aeadKeyLen(s.cipher) == 0 {
+		if s.cipher != "" {
+			return nil, fmt.Errorf("server selected unsupported data cipher %s", s.cipher)
+		}
+		if aeadKeyLen(s.prof.Cipher) == 0 {
+			return nil, errors.New("server did not negotiate an AEAD data cipher (AES-GCM or CHACHA20-POLY1305 required)")
+		}
+		s.cipher = s.prof.Cipher
+	}
+	s.ekm = pushed.KeyDerivation == "tls-ekm"
+	s.peerID = pushed.PeerID
+	s.framing = s.prof.Compress
+	if pushed.Compress != "" {
+		s.framing = pushed.Compress
+	}
+	if pushed.AuthToken != "" {
+		// Renegotiations and reconnects authenticate with the token.
+		if pushed.AuthTokenUser != "" {
+			s.creds.username = pushed.AuthTokenUser
+		}
+		s.creds.password = pushed.AuthToken
+	}
+	s.ping = seconds(pushed.Ping, s.prof.Ping, 10)
+	s.pingRestart = seconds(pushed.PingRestart, s.prof.PingRestart, 60)
+
+	if err := s.activate(ks); err != nil {
+		return nil, err
+	}
+	core.SafeGo("openvpn.messages", s.messageLoop)
+	return pushed, nil
+}
+
+func seconds(pushed, profile, def int) time.Duration {
+	switch {
+	case pushed > 0:
+		return time.Duration(pushed) * time.Second
+	case profile > 0:
+		return time.Duration(profile) * time.Second
+	}
+	return time.Duration(def) * time.Second
+}
+
+func (s *session) addKeyState(id byte) *keyState {
+	ks := newKeyState(s, id)
+	s.mu.Lock()
+	if old := s.keys[id]; old != nil {
+		old.close()
+	}
+	s.keys[id] = ks
+	s.mu.Unlock()
+	return ks
+}
+
+// negotiate runs TLS and the key method 2 exchange on ks.
+func (s *session) negotiate(ctx context.Context, ks *keyState) error {
+	stream := controlStream{ks}
+	stop := context.AfterFunc(ctx, ks.close)
+	defer stop()
+
+	conn := tls.Client(stream, s.tlsConfig())
+	if err := conn.HandshakeContext(ctx); err != nil {
+		return fmt.Errorf("TLS handshake: %w", err)
+	}
+	src, err := newClientKeySource()
+	if err != nil {
+		return err
+	}
+	msg := clientKeyMessage(src, s.optionsString(), s.creds.username, s.creds.password, peerInfo(s.prof))
+	if _, err := conn.Write(msg); err != nil {
+		return fmt.Errorf("send key exchange: %w", err)
+	}
+
+	buf := make([]byte, 16*1024)
+	n, err := conn.Read(buf)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("key exchange: %w", ctx.Err())
+		}
+		return fmt.Errorf("key exchange: %w", err)
+	}
+	server, _, err := parseServerKeyMessage(buf[:n])
+	if err != nil {
+		return fmt.Errorf("key exchange: %w", err)
+	}
+	ks.tls = conn
+	ks.client = src
+	ks.server = server
+	core.SafeGo("openvpn.control", func() { s.readControl(ks) })
+	return nil
+}
+
+// readControl splits the TLS plaintext of ks into NUL-terminated messages.
+func (s *session) readControl(ks *keyState) {
+	buf := make([]byte, 16*1024)
+	var partial []byte
+	for {
+		n, err := ks.tls.Read(buf)
+		if err != nil {
+			return
+		}
+		partial = append(partial, buf[:n]...)
+		for {
+			i := bytes.IndexByte(partial, 0)
+			if i < 0 {
+				break
+			}
+			msg := string(partial[:i])
+			partial = partial[i+1:]
+			select {
+			case s.messages <- msg:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
+
+// requestPush sends PUSH_REQUEST until the server answers with the tunnel
+// configuration, handling auth failures and pending authentication.
+func (s *session) requestPush(ctx context.Context, ks *keyState) (*pushedConfig, error) {
+	pushed := &pushedConfig{PeerID: -1}
+	for _, args := range s.prof.Routes {
+		pushed.apply(args)
+	}
+	send := func() error {
+		_, err := ks.tls.Write([]byte("PUSH_REQUEST\x00"))
+		return err
+	}
+	if err := send(); err != nil {
+		return nil, err
+	}
+	ticker := time.NewTicker(pushRetry)
+	defer ticker.Stop()
+	pending := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			if pending {
+				return nil, errors.New("authentication still pending (approve the sign-in and reconnect)")
+			}
+			return nil, fmt.Errorf("waiting for PUSH_REPLY: %w", ctx.Err())
+		case <-s.done:
+			return nil, s.err
+		case <-ticker.C:
+			if !pending {
+				send()
+			}
+		case msg := <-s.messages:
+			switch {
+			case strings.HasPrefix(msg, "PUSH_REPLY"):
+				more := false
+				for _, args := range splitPushOptions(msg) {
+					if strings.EqualFold(args[0], "push-continuation") {
+						more = len(args) > 1 && args[1] == "2"
+						continue
+					}
+					if s.prof.RouteNoPull && isRouteOption(args[0]) {
+						continue
+					}
+					pushed.apply(args)
+				}
+				if !more {
+					return pushed, nil
+				}
+			case strings.HasPrefix(msg, "AUTH_FAILED"):
+				return nil, parseAuthFailed(msg)
+			case strings.HasPrefix(msg, "AUTH_PENDING"):
+				pending = true
+				core.Log.Infof("OpenVPN", "Server is waiting for additional authentication (%s)", msg)
+			case strings.HasPrefix(msg, "INFO_PRE,") || strings.HasPrefix(msg, "INFO,"):
+				core.Log.Infof("OpenVPN", "Server message: %s", msg[strings.IndexByte(msg, ',')+1:])
+				pending = pending || strings.Contains(msg, "WEB_AUTH") || strings.Contains(msg, "OPEN_URL")
+			case msg == "RESTART" || strings.HasPrefix(msg, "RESTART,"), msg == "HALT" || strings.HasPrefix(msg, "HALT,"):
+				return nil, fmt.Errorf("server refused the session: %s", msg)
+			default:
+				core.Log.Debugf("OpenVPN", "Ignoring control message %q", msg)
+			}
+		}
+	}
+}
+
+func isRouteOption(name string) bool {
+	switch strings.ToLower(name) {
+	case "route", "route-ipv6", "redirect-gateway", "redirect-private":
+		return true
+	}
+	return false
+}
+
+// parseAuthFailed turns AUTH_FAILED[,reason] into an error. A CRV1 reason
+// is a dynamic challenge: AUTH_FAILED,CRV1:<flags>:<state>:<b64 user>:<prompt>.
+func parseAuthFailed(msg string) error {
+	reason := strings.TrimPrefix(strings.TrimPrefix(msg, "AUTH_FAILED"), ",")
+	if strings.HasPrefix(reason, "CRV1:") {
+		parts := strings.SplitN(reason, ":", 5)
+		if len(parts) == 5 {
+			user, _ := base64.StdEncoding.DecodeString(parts[3])
+			return &challengeError{state: parts[2], username: string(user), prompt: parts[4]}
+		}
+	}
+	return &authFailedError{reason: reason}
+}
+
+// messageLoop handles control messages once the tunnel is up.
+func (s *session) messageLoop() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg := <-s.messages:
+			switch {
+			case msg == "RESTART" || strings.HasPrefix(msg, "RESTART,"):
+				s.fail(errors.New("server requested a restart"))
+			case msg == "HALT" || strings.HasPrefix(msg, "HALT,"), msg == "EXIT":
+				s.fail(fmt.Errorf("server ended the session (%s)", msg))
+			case strings.HasPrefix(msg, "AUTH_FAILED"):
+				s.fail(parseAuthFailed(msg))
+			case strings.HasPrefix(msg, "INFO"):
+				core.Log.Infof("OpenVPN", "Server message: %s", msg)
+			default:
+				core.Log.Debugf("OpenVPN", "Ignoring control message %q", msg)
+			}
+		}
+	}
+}
+
+// activate derives the data channel keys of ks and makes them current. The
+// previous key stays valid for decryption for lameDuckDuration.
+func (s *session) activate(ks *keyState) error {
+	var material []byte
+	if s.ekm {
+		cs := ks.tls.ConnectionState()
+		var err error
+		if material, err = cs.ExportKeyingMaterial(ekmLabel, nil, 256); err != nil {
+			return fmt.Errorf("tls-ekm: %w", err)
+		}
+	} else {
+		material = deriveKeysPRF(ks.client, ks.server, s.localSID, s.remoteSID())
+	}
+	d, err := newDataKeys(s.cipher, material, ks.id, s.peerID)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if old := s.current.Load(); old != nil {
+		if s.lameKS != nil {
+			s.lameKS.close()
+			delete(s.keys, s.lameKS.id)
+		}
+		s.lame = old
+		s.lameKS = s.keys[old.keyID]
+		s.lameEnd = time.Now().Add(lameDuckDuration)
+	}
+	s.current.Store(d)
+	s.mu.Unlock()
+	return nil
+}
+
+// renegotiate answers a server-initiated soft reset for key id.
+func (s *session) renegotiate(id byte) *keyState {
+	ks := s.addKeyState(id)
+	core.SafeGo("openvpn.renegotiate", func() {
+		if err := ks.send(opControlSoftResetV1, nil); err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+		select {
+		case <-ks.resetRcvd:
+		case <-s.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+		if err := s.negotiate(ctx, ks); err != nil {
+			core.Log.Warnf("OpenVPN", "Key renegotiation failed: %v", err)
+			return
+		}
+		if err := s.activate(ks); err != nil {
+			core.Log.Warnf("OpenVPN", "Key renegotiation failed: %v", err)
+			return
+		}
+		core.Log.Debugf("OpenVPN", "Data channel key %d active", id)
+	})
+	return ks
+}
+
+func (s *session) tlsConfig() *tls.Config {
+	return &tls.Config{
+		// The server certificate is checked against the profile's CA in
+		// verifyServer; OpenVPN does not use host names.
+		InsecureSkipVerify:    true,
+		VerifyPeerCertificate: s.verifyServer,
+		Certificates:          s.prof.Certs,
+		MinVersion:            s.prof.TLSVersionMin,
+	}
+}
+
+func (s *session) verifyServer(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return errors.New("server sent no certificate")
+	}
+	certs := make([]*x509.Certificate, len(rawCerts))
+	for i, raw := range rawCerts {
+		c, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("server certificate: %w", err)
+		}
+		certs[i] = c
+	}
+	opts := x509.VerifyOptions{
+		Roots:         s.prof.CAPool,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if s.prof.RemoteCertTLS == "server" {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+	leaf := certs[0]
+	if _, err := leaf.Verify(opts); err != nil {
+		return fmt.Errorf("server certificate: %w", err)
+	}
+	if name := s.prof.VerifyX509Name; name != "" && !matchX509Name(leaf, name, s.prof.VerifyX509Type) {
+		return fmt.Errorf("server certificate %q does not match verify-x509-name %q", leaf.Subject.String(), name)
+	}
+	return nil
+}
+
+// matchX509Name implements verify-x509-name. For "subject" every
+// "key=value" pair of name must be present in the certificate subject.
+func matchX509Name(cert *x509.Certificate, name, kind string) bool {
+	cn := cert.Subject.CommonName
+	switch kind {
+	case "name":
+		return cn == name
+	case "name-prefix":
+		return strings.HasPrefix(cn, name)
+	}
+	attrs := make(map[string]bool)
+	for _, rdn := range strings.Split(cert.Subject.String(), ",") {
+		attrs[strings.TrimSpace(rdn)] = true
+	}
+	for _, rdn := range strings.FieldsFunc(name, func(r rune) bool { return r == ',' || r == '/' }) {
+		if rdn = strings.TrimSpace(rdn); rdn != "" && !attrs[rdn] {
+			return false
+		}
+	}
+	return true
+}
+
+// optionsString is the option compatibility string of key method 2. Servers
+// since 2.4 only log mismatches.
+func (s *session) optionsString() string {
+	proto := "UDPv4"
+	if s.tr.reliable() {
+		proto = "TCPv4_CLIENT"
+	}
+	cipher := s.prof.DataCiphers[0]
+	return fmt.Sprintf("V4,dev-type tun,link-mtu 1549,tun-mtu 1500,proto %s,cipher %s,auth [null-digest],keysize %d,key-method 2,tls-client",
+		proto, cipher, aeadKeyLen(cipher)*8)
+}
+
+// peerInfo advertises client capabilities: DATA_V2, REQUEST_PUSH,
+// TLS_KEY_EXPORT, AUTH_PENDING_KW and DNS_OPTION in IV_PROTO, the data
+// ciphers for negotiation, and stub compression only.
+func peerInfo(prof *Profile) string {
+	plat := runtime.GOOS
+	switch plat {
+	case "windows":
+		plat = "win"
+	case "darwin":
+		plat = "mac"
+	}
+	return strings.Join([]string{
+		"IV_VER=2.6.12",
+		"IV_PLAT=" + plat,
+		"IV_PROTO=94",
+		"IV_NCP=2",
+		"IV_CIPHERS=" + strings.Join(prof.DataCiphers, ":"),
+		"IV_TCPNL=1",
+		"IV_LZO_STUB=1",
+		"IV_COMP_STUB=1",
+		"IV_COMP_STUBv2=1",
+		"IV_GUI_VER=awg-split-tunnel",
+	}, "\n") + "\n"
+}
+
+// ---- Packet I/O ----
+
+func (s *session) writeControl(p *controlPacket) error {
+	err := s.tr.writePacket(s.wrap.wrap(p.marshal()))
+	if err == nil {
+		s.lastSend.Store(time.Now().UnixNano())
+	}
+	return err
+}
+
+func (s *session) readLoop() {
+	for {
+		pkt, err := s.tr.readPacket()
+		if err != nil {
+			s.fail(fmt.Errorf("connection lost: %w", err))
+			return
+		}
+		if len(pkt) == 0 {
+			continue
+		}
+		switch pkt[0] >> 3 {
+		case opDataV1, opDataV2:
+			s.handleData(pkt)
+		case opControlV1, opAckV1, opControlSoftResetV1, opControlHardResetServerV2:
+			raw, err := s.wrap.unwrap(pkt)
+			if err != nil {
+				core.Log.Debugf("OpenVPN", "Dropping control packet: %v", err)
+				continue
+			}
+			p, err := parseControlPacket(raw)
+			if err != nil {
+				core.Log.Debugf("OpenVPN", "Dropping control packet: %v", err)
+				continue
+			}
+			s.handleControl(p)
+		}
+	}
+}
+
+func (s *session) handleControl(p *controlPacket) {
+	if rs := s.remote.Load(); rs == nil {
+		if p.opcode != opControlHardResetServerV2 {
+			return
+		}
+		sid := p.sid
+		s.remote.Store(&sid)
+	} else if *rs != p.sid {
+		return
+	}
+	if len(p.acks) > 0 && p.ackSID != s.localSID {
+		return
+	}
+	s.lastRecv.Store(time.Now().UnixNano())
+
+	s.mu.Lock()
+	ks := s.keys[p.keyID]
+	s.mu.Unlock()
+	if ks == nil {
+		if p.opcode != opControlSoftResetV1 {
+			return
+		}
+		ks = s.renegotiate(p.keyID)
+	}
+	ks.receive(p)
+}
+
+func (s *session) handleData(pkt []byte) {
+	keyID := pkt[0] & 7
+	d := s.current.Load()
+	if d == nil {
+		return
+	}
+	if d.keyID != keyID {
+		s.mu.Lock()
+		d = s.lame
+		s.mu.Unlock()
+		if d == nil || d.keyID != keyID {
+			return
+		}
+	}
+	plain, err := d.open(pkt)
+	if err != nil {
+		core.Log.Debugf("OpenVPN", "Dropping data packet: %v", err)
+		return
+	}
+	s.lastRecv.Store(time.Now().UnixNano())
+
+	payload, ok := unframeInbound(plain)
+	if !ok || len(payload) == 0 {
+		return
+	}
+	switch {
+	case bytes.Equal(payload, pingMagic):
+		return
+	case len(payload) > len(occMagic) && bytes.HasPrefix(payload, occMagic):
+		if payload[len(occMagic)] == occExit {
+			s.fail(errors.New("server closed the session"))
+		}
+		return
+	}
+	if v := payload[0] >> 4; v != 4 && v != 6 {
+		return
+	}
+	if h := s.inbound.Load(); h != nil {
+		(*h)(payload)
+	}
+}
+
+// sendData encrypts and sends an IP packet.
+func (s *session) sendData(pkt []byte) bool {
+	d := s.current.Load()
+	if d == nil {
+		return false
+	}
+	if err := s.tr.writePacket(d.seal(frameOutbound(s.framing, pkt))); err != nil {
+		return false
+	}
+	s.lastSend.Store(time.Now().UnixNano())
+	return true
+}
+
+// timerLoop retransmits control packets, sends keepalive pings, enforces
+// ping-restart and retires the lame duck key.
+func (s *session) timerLoop() {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.mu.Lock()
+			keys := make([]*keyState, 0, len(s.keys))
+			for _, ks := range s.keys {
+				keys = append(keys, ks)
+			}
+			if s.lame != nil && now.After(s.lameEnd) {
+				if s.lameKS != nil {
+					s.lameKS.close()
+					delete(s.keys, s.lameKS.id)
+				}
+				s.lame, s.lameKS = nil, nil
+			}
+			s.mu.Unlock()
+
+			if !s.tr.reliable() {
+				for _, ks := range keys {
+					ks.retransmit(now)
+				}
+			}
+			if s.current.Load() == nil {
+				continue
+			}
+			if now.Sub(time.Unix(0, s.lastSend.Load())) >= s.ping {
+				s.sendData(pingMagic)
+			}
+			if idle := now.Sub(time.Unix(0, s.lastRecv.Load())); idle >= s.pingRestart {
+				s.fail(fmt.Errorf("no data from server for %s (ping-restart)", idle.Truncate(time.Second)))
+			}
+		}
+	}
+}
+
+// fail ends the session with err and reports it unless close was called.
+func (s *session) fail(err error) {
+	s.doneOnce.Do(func() {
+		s.err = err
+		close(s.done)
+		s.tr.close()
+		s.mu.Lock()
+		for _, ks := range s.keys {
+			ks.close()
+		}
+		s.mu.Unlock()
+		if !s.stopping.Load() && s.onClose != nil {
+			core.SafeGo("openvpn.on-close", func() { s.onClose(err) })
+		}
+	})
+}
+
+// close tells the server we are leaving (explicit-exit-notify on UDP) and
+// ends the session.
+func (s *session) close() {
+	s.stopping.Store(true)
+	if !s.tr.reliable() && s.current.Load() != nil {
+		exit := append(append([]byte(nil), occMagic...), occExit)
+		for i := 0; i < 2; i++ {
+			s.sendData(exit)
+		}
+	}
+	s.fail(errors.New("session closed"))
+}
@@ -0,0 +1,103 @@
+package openvpn
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"awg-split-tunnel/internal/provider/anyconnect"
+)
+
+// transport carries OpenVPN packets over UDP (one per datagram) or TCP
+// (each prefixed with a 16-bit length).
+type transport interface {
+	writePacket(b []byte) error
+	readPacket() ([]byte, error)
+	close() error
+	// reliable reports whether the transport delivers in order (TCP), in
+	// which case control packets are not retransmitted.
+	reliable() bool
+}
+
+// dialTransport resolves the remote via the real NIC and connects to it.
+// The returned endpoint is set for bypass routes even if the dial fails.
+func dialTransport(ctx context.Context, r Remote, controlFn func(string, string, syscall.RawConn) error) (transport, netip.AddrPort, error) {
+	ip, err := anyconnect.ResolveViaRealNIC(ctx, r.Host, controlFn)
+	if err != nil {
+		return nil, netip.AddrPort{}, err
+	}
+	var endpoint netip.AddrPort
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		endpoint = netip.AddrPortFrom(addr.Unmap(), uint16(r.Port))
+	}
+
+	d := net.Dialer{Control: controlFn, Timeout: 15 * time.Second}
+	conn, err := d.DialContext(ctx, r.Proto, net.JoinHostPort(ip, strconv.Itoa(r.Port)))
+	if err != nil {
+		return nil, endpoint, fmt.Errorf("dial %s %s:%d: %w", r.Proto, r.Host, r.Port, err)
+	}
+	if r.Proto == "tcp" {
+		return &tcpTransport{conn: conn, br: bufio.NewReaderSize(conn, 64*1024)}, endpoint, nil
+	}
+	return &udpTransport{conn: conn, buf: make([]byte, 65535)}, endpoint, nil
+}
+
+type udpTransport struct {
+	conn net.Conn
+	buf  []byte // only used by the single reader
+}
+
+func (t *udpTransport) writePacket(b []byte) error {
+	_, err := t.conn.Write(b)
+	return err
+}
+
+func (t *udpTransport) readPacket() ([]byte, error) {
+	n, err := t.conn.Read(t.buf)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), t.buf[:n]...), nil
+}
+
+func (t *udpTransport) close() error   { return t.conn.Close() }
+func (t *udpTransport) reliable() bool { return false }
+
+type tcpTransport struct {
+	conn    net.Conn
+	br      *bufio.Reader
+	writeMu sync.Mutex
+}
+
+func (t *tcpTransport) writePacket(b []byte) error {
+	frame := make([]byte, 2+len(b))
+	binary.BigEndian.PutUint16(frame, uint16(len(b)))
+	copy(frame[2:], b)
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	_, err := t.conn.Write(frame)
+	return err
+}
+
+func (t *tcpTransport) readPacket() ([]byte, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(t.br, hdr[:]); err != nil {
+		return nil, err
+	}
+	pkt := make([]byte, binary.BigEndian.Uint16(hdr[:]))
+	if _, err := io.ReadFull(t.br, pkt); err != nil {
+		return nil, err
+	}
+	return pkt, nil
+}
+
+func (t *tcpTransport) close() error   { return t.conn.Close() }
+func (t *tcpTransport) reliable() bool { return true }
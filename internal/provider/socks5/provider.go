@@ -10,6 +10,7 @@ import (
 
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/obfs"
 
 	"golang.org/x/net/proxy"
 )
@@ -26,6 +27,9 @@ type Config struct {
 	Password string `yaml:"password"`
 	// UDPEnabled controls whether UDP ASSOCIATE is attempted (default true).
 	UDPEnabled bool `yaml:"udp_enabled"`
+	// Obfs wraps the connection to the server (TLS, WebSocket). UDP
+	// ASSOCIATE is disabled with it: the datagrams would go out in the clear.
+	Obfs obfs.Config `yaml:"obfs"`
 }
 
 // Provider implements TunnelProvider for SOCKS5 proxy protocol.
@@ -49,6 +53,9 @@ func New(name string, cfg Config) (*Provider, error) {
 	if cfg.Port <= 0 || cfg.Port > 65535 {
 		return nil, fmt.Errorf("[SOCKS5] invalid port %d", cfg.Port)
 	}
+	if err := cfg.Obfs.Validate(false); err != nil {
+		return nil, fmt.Errorf("[SOCKS5] %w", err)
+	}
 
 	p := &Provider{
 		config: cfg,
@@ -69,7 +76,14 @@ func (p *Provider) Connect(ctx context.Context) error {
 	core.Log.Infof("SOCKS5", "Connecting tunnel %q to %s...", p.name, serverStr)
 
 	// Resolve server address for bypass routes.
-	if ap, err := netip.ParseAddrPort(serverStr); err == nil {
+	if p.config.Obfs.Server != "" {
+		ap, err := p.config.Obfs.ResolveServer(ctx, net.DefaultResolver)
+		if err != nil {
+			p.state = core.TunnelStateError
+			return fmt.Errorf("[SOCKS5] %w", err)
+		}
+		p.serverAddr = ap
+	} else if ap, err := netip.ParseAddrPort(serverStr); err == nil {
 		p.serverAddr = ap
 	} else {
 		// Server might be a hostname — resolve it.
@@ -94,14 +108,21 @@ func (p *Provider) Connect(ctx context.Context) error {
 		}
 	}
 
-	dialer, err := proxy.SOCKS5("tcp", serverStr, auth, proxy.Direct)
+	var forward proxy.Dialer = proxy.Direct
+	if p.config.Obfs.Enabled() {
+		forward = p.config.Obfs.Dialer()
+	}
+	dialer, err := proxy.SOCKS5("tcp", serverStr, auth, forward)
 	if err != nil {
 		p.state = core.TunnelStateError
 		return fmt.Errorf("[SOCKS5] create dialer: %w", err)
 	}
 
-	// Probe: verify the SOCKS5 server is reachable with a quick TCP handshake.
-	probeConn, err := net.DialTimeout("tcp", serverStr, 10*time.Second)
+	// Probe: verify the SOCKS5 server is reachable with a quick TCP handshake
+	// (through the obfuscation layer, if any).
+	probeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	probeConn, err := forward.(proxy.ContextDialer).DialContext(probeCtx, "tcp", serverStr)
+	cancel()
 	if err != nil {
 		p.state = core.TunnelStateError
 		return fmt.Errorf("[SOCKS5] server unreachable at %s: %w", serverStr, err)
@@ -110,7 +131,7 @@ func (p *Provider) Connect(ctx context.Context) error {
 
 	p.dialer = dialer
 	p.state = core.TunnelStateUp
-	core.Log.Infof("SOCKS5", "Tunnel %q is UP (server=%s)", p.name, serverStr)
+	core.Log.Infof("SOCKS5", "Tunnel %q is UP (server=%s, obfs=%q)", p.name, serverStr, p.config.Obfs.Mode)
 	return nil
 }
 
@@ -165,10 +186,36 @@ func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
 		return nil, fmt.Errorf("[SOCKS5] tunnel %q is not up (state=%d)", p.name, state)
 	}
 
-	if !p.config.UDPEnabled {
+	if !p.config.UDPEnabled || p.config.Obfs.Enabled() {
+		return nil, provider.ErrUDPNotSupported
+	}
+
+	serverStr, auth := p.udpServer()
+	return dialUDPAssociate(ctx, serverStr, auth, addr)
+}
+
+// ListenUDP opens a UDP ASSOCIATE relay that is not bound to one target:
+// every datagram names its destination, so one relay port talks to any
+// remote. Implements provider.UDPListener.
+func (p *Provider) ListenUDP(ctx context.Context) (net.PacketConn, error) {
+	p.mu.RLock()
+	state := p.state
+	p.mu.RUnlock()
+
+	if state != core.TunnelStateUp {
+		return nil, fmt.Errorf("[SOCKS5] tunnel %q is not up (state=%d)", p.name, state)
+	}
+
+	if !p.config.UDPEnabled || p.config.Obfs.Enabled() {
 		return nil, provider.ErrUDPNotSupported
 	}
 
+	serverStr, auth := p.udpServer()
+	return listenUDPAssociate(ctx, serverStr, auth)
+}
+
+// udpServer returns the server address and credentials for UDP ASSOCIATE.
+func (p *Provider) udpServer() (string, *socks5Auth) {
 	serverStr := net.JoinHostPort(p.config.Server, fmt.Sprintf("%d", p.config.Port))
 
 	var auth *socks5Auth
@@ -178,8 +225,7 @@ func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
 			password: p.config.Password,
 		}
 	}
-
-	return dialUDPAssociate(ctx, serverStr, auth, addr)
+	return serverStr, auth
 }
 
 // Name returns the human-readable tunnel name.
@@ -202,3 +248,9 @@ func (p *Provider) GetServerEndpoints() []netip.AddrPort {
 	}
 	return nil
 }
+
+// Compile-time interface checks.
+var (
+	_ provider.TunnelProvider = (*Provider)(nil)
+	_ provider.UDPListener    = (*Provider)(nil)
+)
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"net/netip"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -60,17 +61,63 @@ type udpAssociateConn struct {
 // dialUDPAssociate performs SOCKS5 UDP ASSOCIATE handshake and returns a net.Conn
 // that transparently encapsulates/decapsulates the SOCKS5 UDP header.
 func dialUDPAssociate(ctx context.Context, serverAddr string, auth *socks5Auth, targetAddr string) (net.Conn, error) {
+	// Parse target address.
+	host, portStr, err := net.SplitHostPort(targetAddr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target %q: %w", targetAddr, err)
+	}
+	var port uint16
+	_, err = fmt.Sscanf(portStr, "%d", &port)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target port %q: %w", portStr, err)
+	}
+
+	tcpConn, udpConn, relayAddr, err := associate(ctx, serverAddr, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := &udpAssociateConn{
+		udpConn:    udpConn,
+		tcpCtrl:    tcpConn,
+		relayAddr:  relayAddr,
+		targetHost: host,
+		targetPort: port,
+	}
+
+	// Keep TCP control connection alive (monitor in background).
+	go conn.monitorTCPControl()
+
+	return conn, nil
+}
+
+// listenUDPAssociate performs SOCKS5 UDP ASSOCIATE handshake and returns an
+// unconnected net.PacketConn: the destination of each datagram goes into its
+// SOCKS5 UDP header, and the header of each reply names its sender.
+func listenUDPAssociate(ctx context.Context, serverAddr string, auth *socks5Auth) (net.PacketConn, error) {
+	tcpConn, udpConn, _, err := associate(ctx, serverAddr, auth)
+	if err != nil {
+		return nil, err
+	}
+	conn := &udpRelayConn{udpConn: udpConn, tcpCtrl: tcpConn}
+	go conn.monitorTCPControl()
+	return conn, nil
+}
+
+// associate opens the TCP control connection, sends UDP ASSOCIATE and
+// connects a local UDP socket to the relay the server returned.
+func associate(ctx context.Context, serverAddr string, auth *socks5Auth) (net.Conn, *net.UDPConn, *net.UDPAddr, error) {
 	// 1. Establish TCP control connection to SOCKS5 server.
 	d := net.Dialer{Timeout: 10 * time.Second}
 	tcpConn, err := d.DialContext(ctx, "tcp", serverAddr)
 	if err != nil {
-		return nil, fmt.Errorf("connect to SOCKS5 server: %w", err)
+		return nil, nil, nil, fmt.Errorf("connect to SOCKS5 server: %w", err)
 	}
 
 	// 2. SOCKS5 handshake (auth negotiation).
 	if err := socks5Handshake(tcpConn, auth); err != nil {
 		tcpConn.Close()
-		return nil, err
+		return nil, nil, nil, err
 	}
 
 	// 3. Send UDP ASSOCIATE request.
@@ -82,14 +129,14 @@ func dialUDPAssociate(ctx context.Context, serverAddr string, auth *socks5Auth,
 	}
 	if _, err := tcpConn.Write(req); err != nil {
 		tcpConn.Close()
-		return nil, fmt.Errorf("send UDP ASSOCIATE: %w", err)
+		return nil, nil, nil, fmt.Errorf("send UDP ASSOCIATE: %w", err)
 	}
 
 	// 4. Read reply — get BND.ADDR:BND.PORT (the UDP relay address).
 	relayAddr, err := readSocks5Reply(tcpConn)
 	if err != nil {
 		tcpConn.Close()
-		return nil, fmt.Errorf("UDP ASSOCIATE reply: %w", err)
+		return nil, nil, nil, fmt.Errorf("UDP ASSOCIATE reply: %w", err)
 	}
 
 	// If server returns 0.0.0.0 as relay host, use the server's IP.
@@ -102,36 +149,9 @@ func dialUDPAssociate(ctx context.Context, serverAddr string, auth *socks5Auth,
 	udpConn, err := net.DialUDP("udp", nil, relayAddr)
 	if err != nil {
 		tcpConn.Close()
-		return nil, fmt.Errorf("connect to UDP relay %s: %w", relayAddr, err)
-	}
-
-	// Parse target address.
-	host, portStr, err := net.SplitHostPort(targetAddr)
-	if err != nil {
-		udpConn.Close()
-		tcpConn.Close()
-		return nil, fmt.Errorf("invalid target %q: %w", targetAddr, err)
+		return nil, nil, nil, fmt.Errorf("connect to UDP relay %s: %w", relayAddr, err)
 	}
-	var port uint16
-	_, err = fmt.Sscanf(portStr, "%d", &port)
-	if err != nil {
-		udpConn.Close()
-		tcpConn.Close()
-		return nil, fmt.Errorf("invalid target port %q: %w", portStr, err)
-	}
-
-	conn := &udpAssociateConn{
-		udpConn:    udpConn,
-		tcpCtrl:    tcpConn,
-		relayAddr:  relayAddr,
-		targetHost: host,
-		targetPort: port,
-	}
-
-	// Keep TCP control connection alive (monitor in background).
-	go conn.monitorTCPControl()
-
-	return conn, nil
+	return tcpConn, udpConn, relayAddr, nil
 }
 
 // socks5Handshake performs the SOCKS5 authentication negotiation.
@@ -349,6 +369,114 @@ func (c *udpAssociateConn) monitorTCPControl() {
 	})
 }
 
+// udpRelayConn is an unconnected net.PacketConn over a SOCKS5 UDP relay.
+// Used for full-cone NAT: one relay port sends to and receives from any
+// remote, which the header of each datagram names.
+type udpRelayConn struct {
+	udpConn   *net.UDPConn // UDP socket to the relay
+	tcpCtrl   net.Conn     // TCP control connection (must stay open)
+	closeOnce sync.Once
+}
+
+// WriteTo sends a datagram to addr through the relay.
+func (c *udpRelayConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	host, portStr, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return 0, fmt.Errorf("invalid target %q: %w", addr, err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return 0, fmt.Errorf("invalid target port %q: %w", portStr, err)
+	}
+	header := buildUDPHeader(host, uint16(port))
+	pkt := make([]byte, len(header)+len(b))
+	copy(pkt, header)
+	copy(pkt[len(header):], b)
+
+	if _, err := c.udpConn.Write(pkt); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// ReadFrom receives a datagram from the relay and returns the remote that
+// sent it. Fragmented datagrams and domain-name senders are skipped.
+func (c *udpRelayConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	bp := socks5ReadBufPool.Get().(*[]byte)
+	defer socks5ReadBufPool.Put(bp)
+	buf := *bp
+	for {
+		n, err := c.udpConn.Read(buf)
+		if err != nil {
+			return 0, nil, err
+		}
+		from, offset, err := udpHeaderAddr(buf[:n])
+		if err != nil {
+			continue
+		}
+		return copy(b, buf[offset:n]), from, nil
+	}
+}
+
+// Close closes both the UDP socket and the TCP control connection.
+func (c *udpRelayConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.tcpCtrl.Close()
+		c.udpConn.Close()
+	})
+	return nil
+}
+
+// LocalAddr returns the local UDP address.
+func (c *udpRelayConn) LocalAddr() net.Addr {
+	return c.udpConn.LocalAddr()
+}
+
+// SetDeadline sets deadlines on the UDP connection.
+func (c *udpRelayConn) SetDeadline(t time.Time) error {
+	return c.udpConn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline on the UDP connection.
+func (c *udpRelayConn) SetReadDeadline(t time.Time) error {
+	return c.udpConn.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the write deadline on the UDP connection.
+func (c *udpRelayConn) SetWriteDeadline(t time.Time) error {
+	return c.udpConn.SetWriteDeadline(t)
+}
+
+// monitorTCPControl closes the relay when the TCP control connection closes.
+func (c *udpRelayConn) monitorTCPControl() {
+	buf := make([]byte, 1)
+	c.tcpCtrl.Read(buf)
+	c.Close()
+}
+
+// udpHeaderAddr parses the SOCKS5 UDP header of a relayed datagram and
+// returns the sender address and the payload offset.
+func udpHeaderAddr(pkt []byte) (*net.UDPAddr, int, error) {
+	offset, err := udpHeaderLen(pkt)
+	if err != nil {
+		return nil, 0, err
+	}
+	if pkt[2] != 0 {
+		return nil, 0, fmt.Errorf("fragmented datagram")
+	}
+	var ip net.IP
+	switch pkt[3] {
+	case atypIPv4:
+		ip = net.IP(pkt[4:8])
+	case atypIPv6:
+		ip = net.IP(pkt[4:20])
+	default:
+		return nil, 0, fmt.Errorf("unsupported sender address type %d", pkt[3])
+	}
+	port := binary.BigEndian.Uint16(pkt[offset-2 : offset])
+	return &net.UDPAddr{IP: append(net.IP(nil), ip...), Port: int(port)}, offset, nil
+}
+
 // buildUDPHeader constructs the SOCKS5 UDP request header for the target address.
 // Format: RSV(2 bytes, 0x0000) + FRAG(1 byte, 0x00) + ATYP + DST.ADDR + DST.PORT
 func buildUDPHeader(host string, port uint16) []byte {
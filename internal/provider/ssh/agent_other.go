@@ -0,0 +1,22 @@
+//go:build !windows
+
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// dialAgent connects to the ssh-agent socket at path, or $SSH_AUTH_SOCK
+// when path is empty.
+func dialAgent(path string) (net.Conn, error) {
+	if path == "" {
+		path = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if path == "" {
+		return nil, fmt.Errorf("SSH_AUTH_SOCK is not set")
+	}
+	return net.DialTimeout("unix", path, 5*time.Second)
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package ssh
+
+import (
+	"net"
+	"os"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// defaultAgentPipe is the named pipe of the Windows OpenSSH agent service.
+const defaultAgentPipe = `\\.\pipe\openssh-ssh-agent`
+
+// dialAgent connects to the ssh-agent named pipe at path, $SSH_AUTH_SOCK,
+// or the Windows OpenSSH agent pipe.
+func dialAgent(path string) (net.Conn, error) {
+	if path == "" {
+		path = os.Getenv("SSH_AUTH_SOCK")
+	}
+	if path == "" {
+		path = defaultAgentPipe
+	}
+	timeout := 5 * time.Second
+	return winio.DialPipe(path, &timeout)
+}
@@ -5,11 +5,14 @@ import (
 	"context"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/netip"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -18,15 +21,16 @@ import (
 	"awg-split-tunnel/internal/provider"
 
 	gossh "golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
 // Config holds SSH tunnel-specific configuration.
 type Config struct {
 	// Server is the SSH server hostname or IP.
 	Server string `yaml:"server"`
-	// Port is the SSH server port (default 22).
+	// Port is the SSH server port (default 22, or Port from the ssh config).
 	Port int `yaml:"port"`
-	// Username for SSH authentication.
+	// Username for SSH authentication (optional if User is set in the ssh config).
 	Username string `yaml:"username"`
 	// Password for SSH password authentication (optional).
 	Password string `yaml:"password"`
@@ -34,6 +38,27 @@ type Config struct {
 	PrivateKeyPath string `yaml:"private_key_path"`
 	// PrivateKeyPassphrase is the passphrase for the private key (optional).
 	PrivateKeyPassphrase string `yaml:"private_key_passphrase"`
+	// CertificatePath is an OpenSSH user certificate (*-cert.pub) signed for
+	// the private key or for a key held by the agent (optional).
+	CertificatePath string `yaml:"certificate_path"`
+	// UseAgent authenticates with the keys held by ssh-agent.
+	UseAgent bool `yaml:"use_agent"`
+	// AgentSocket overrides the agent socket (default $SSH_AUTH_SOCK; on
+	// Windows the OpenSSH agent pipe \\.\pipe\openssh-ssh-agent).
+	AgentSocket string `yaml:"agent_socket"`
+	// KeyboardInteractive enables keyboard-interactive authentication.
+	// Password prompts are answered with Password; other prompts (OTP) with
+	// the "otp_code" auth param passed at connect time.
+	KeyboardInteractive bool `yaml:"keyboard_interactive"`
+	// ProxyJump is a comma-separated chain of jump hosts in OpenSSH syntax
+	// ([user@]host[:port],...), connected through in order (optional).
+	ProxyJump string `yaml:"proxy_jump"`
+	// JumpHostKeys pins jump host keys in authorized_keys format, keyed by
+	// host or host:port. Hosts without an entry use TOFU.
+	JumpHostKeys map[string]string `yaml:"jump_host_keys"`
+	// SSHConfigPath is an OpenSSH client config (e.g. ~/.ssh/config) used to
+	// resolve Server and jump hosts as Host aliases (optional).
+	SSHConfigPath string `yaml:"ssh_config_path"`
 	// HostKey is the expected server host key in authorized_keys format (optional).
 	// If empty and InsecureSkipHostKey is false, New() returns an error.
 	HostKey string `yaml:"host_key"`
@@ -41,25 +66,57 @@ type Config struct {
 	InsecureSkipHostKey bool `yaml:"insecure_skip_host_key"`
 	// KeepaliveInterval is the interval in seconds between keepalive probes (default 30).
 	KeepaliveInterval int `yaml:"keepalive_interval"`
+	// TunUDP carries UDP through an OpenSSH layer-3 tunnel (tun@openssh.com).
+	// The server needs "PermitTunnel yes" and a tun device routed for TunAddress.
+	TunUDP bool `yaml:"tun_udp"`
+	// TunAddress is the local address of the layer-3 tunnel (required with TunUDP).
+	TunAddress string `yaml:"tun_address"`
+	// TunUnit is the server tun device number; negative selects any free one.
+	TunUnit int `yaml:"tun_unit"`
+}
+
+// hop is one SSH server on the way to the tunnel endpoint: a jump host or
+// the final server.
+type hop struct {
+	alias         string // name as configured, before ssh config resolution
+	host          string
+	port          int
+	user          string
+	identityFiles []string
+	certFiles     []string
+	identityAgent string
+	proxyJump     string // ProxyJump from the ssh config
+	hostKey       string // pinned host key in authorized_keys format
+}
+
+func (h hop) addr() string {
+	return net.JoinHostPort(h.host, strconv.Itoa(h.port))
 }
 
 // Provider implements TunnelProvider for SSH tunnel protocol.
 // TCP connections are forwarded through the SSH channel via DialTCP.
-// UDP is not supported.
+// UDP is carried over an OpenSSH layer-3 tunnel when TunUDP is set.
 type Provider struct {
 	mu     sync.RWMutex
 	config Config
 	state  core.TunnelState
 	name   string
 
-	serverAddr     netip.AddrPort // resolved server endpoint for bypass routes
-	client         *gossh.Client  // active SSH client connection
+	serverAddr     netip.AddrPort  // resolved first-hop endpoint for bypass routes
+	client         *gossh.Client   // active SSH client connection
+	jumps          []*gossh.Client // jump host connections, in hop order
+	tun            *tunMux         // layer-3 tunnel for UDP (nil if unavailable)
+	tunAddr        netip.Addr
 	cancelKA       context.CancelFunc
 	knownHostsPath string // path to ssh_known_hosts file for TOFU
+
+	authParams map[string]string // one-shot params from SetAuthParams (otp_code)
+	authPrompt string            // unanswered keyboard-interactive prompt
 }
 
 var _ provider.TunnelProvider = (*Provider)(nil)
 var _ provider.EndpointProvider = (*Provider)(nil)
+var _ provider.AuthParamSetter = (*Provider)(nil)
 
 // New creates an SSH tunnel provider with the given configuration.
 // Validates config and expands ~ in private key path. Does NOT perform DNS resolution.
@@ -67,13 +124,11 @@ func New(name string, cfg Config) (*Provider, error) {
 	if cfg.Server == "" {
 		return nil, fmt.Errorf("[SSH] server address is required")
 	}
-	if cfg.Username == "" {
+	// Username, port and keys may come from the ssh config instead.
+	if cfg.Username == "" && cfg.SSHConfigPath == "" {
 		return nil, fmt.Errorf("[SSH] username is required")
 	}
-	if cfg.Port <= 0 {
-		cfg.Port = 22
-	}
-	if cfg.Port > 65535 {
+	if cfg.Port < 0 || cfg.Port > 65535 {
 		return nil, fmt.Errorf("[SSH] invalid port %d", cfg.Port)
 	}
 	if cfg.KeepaliveInterval <= 0 {
@@ -81,17 +136,30 @@ func New(name string, cfg Config) (*Provider, error) {
 	}
 
 	// Validate that at least one auth method is configured.
-	if cfg.Password == "" && cfg.PrivateKeyPath == "" {
-		return nil, fmt.Errorf("[SSH] at least one of password or private_key_path is required")
+	if cfg.Password == "" && cfg.PrivateKeyPath == "" && !cfg.UseAgent &&
+		!cfg.KeyboardInteractive && cfg.SSHConfigPath == "" {
+		return nil, fmt.Errorf("[SSH] at least one of password, private_key_path, use_agent or keyboard_interactive is required")
+	}
+
+	// Expand ~ in file paths.
+	for _, path := range []*string{&cfg.PrivateKeyPath, &cfg.CertificatePath, &cfg.SSHConfigPath} {
+		if *path == "" {
+			continue
+		}
+		expanded, err := expandTilde(*path)
+		if err != nil {
+			return nil, fmt.Errorf("[SSH] expand path %q: %w", *path, err)
+		}
+		*path = expanded
 	}
 
-	// Expand ~ in private key path.
-	if cfg.PrivateKeyPath != "" {
-		expanded, err := expandTilde(cfg.PrivateKeyPath)
+	var tunAddr netip.Addr
+	if cfg.TunUDP {
+		addr, err := netip.ParseAddr(cfg.TunAddress)
 		if err != nil {
-			return nil, fmt.Errorf("[SSH] expand private key path: %w", err)
+			return nil, fmt.Errorf("[SSH] tun_address is required with tun_udp: %w", err)
 		}
-		cfg.PrivateKeyPath = expanded
+		tunAddr = addr.Unmap()
 	}
 
 	// Resolve known_hosts file path (next to executable).
@@ -104,74 +172,118 @@ func New(name string, cfg Config) (*Provider, error) {
 		config:         cfg,
 		name:           name,
 		state:          core.TunnelStateDown,
+		tunAddr:        tunAddr,
 		knownHostsPath: knownHostsPath,
 	}, nil
 }
 
-// Connect establishes the SSH connection. Blocks until connected or ctx cancelled.
+// SetAuthParams implements provider.AuthParamSetter.
+// Called before Connect() to pass an OTP code for keyboard-interactive prompts.
+func (p *Provider) SetAuthParams(params map[string]string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.authParams = params
+}
+
+// Connect establishes the SSH connection, through any jump hosts in order.
+// Blocks until connected or ctx cancelled.
 func (p *Provider) Connect(ctx context.Context) error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	// Auth params are one-shot: an OTP code is only valid for this attempt.
+	defer func() { p.authParams = nil }()
 
 	p.state = core.TunnelStateConnecting
-	serverStr := net.JoinHostPort(p.config.Server, fmt.Sprintf("%d", p.config.Port))
-	core.Log.Infof("SSH", "Connecting tunnel %q to %s...", p.name, serverStr)
+	p.authPrompt = ""
+
+	hops, err := p.resolveHops()
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[SSH] %w", err)
+	}
+	first, target := hops[0], hops[len(hops)-1]
+	core.Log.Infof("SSH", "Connecting tunnel %q to %s...", p.name, target.addr())
+	if len(hops) > 1 {
+		core.Log.Infof("SSH", "Tunnel %q: %d jump host(s), first %s", p.name, len(hops)-1, first.addr())
+	}
 
-	// Resolve server address for bypass routes.
+	// Resolve the first hop for bypass routes.
 	p.serverAddr = netip.AddrPort{} // reset
-	if ap, err := netip.ParseAddrPort(serverStr); err == nil {
+	if ap, err := netip.ParseAddrPort(first.addr()); err == nil {
 		p.serverAddr = ap
 	} else {
-		ips, err := net.DefaultResolver.LookupHost(ctx, p.config.Server)
+		ips, err := net.DefaultResolver.LookupHost(ctx, first.host)
 		if err != nil {
 			p.state = core.TunnelStateError
-			return fmt.Errorf("[SSH] resolve %q: %w", p.config.Server, err)
+			return fmt.Errorf("[SSH] resolve %q: %w", first.host, err)
 		}
 		if len(ips) > 0 {
 			if addr, err2 := netip.ParseAddr(ips[0]); err2 == nil {
-				p.serverAddr = netip.AddrPortFrom(addr, uint16(p.config.Port))
+				p.serverAddr = netip.AddrPortFrom(addr, uint16(first.port))
 			}
 		}
 	}
 
-	// Build auth methods.
-	authMethods, err := p.buildAuthMethods()
-	if err != nil {
-		p.state = core.TunnelStateError
-		return fmt.Errorf("[SSH] build auth: %w", err)
-	}
+	// Agent connections are only needed during authentication.
+	var closers []io.Closer
+	defer func() {
+		for _, c := range closers {
+			c.Close()
+		}
+	}()
 
-	// Build host key callback.
-	hostKeyCallback, err := p.buildHostKeyCallback()
-	if err != nil {
+	var clients []*gossh.Client
+	fail := func(err error) error {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
 		p.state = core.TunnelStateError
-		return fmt.Errorf("[SSH] host key callback: %w", err)
+		return err
 	}
 
-	sshConfig := &gossh.ClientConfig{
-		User:            p.config.Username,
-		Auth:            authMethods,
-		HostKeyCallback: hostKeyCallback,
-		Timeout:         15 * time.Second,
-	}
+	for i, h := range hops {
+		sshConfig, err := p.clientConfig(h, &closers)
+		if err != nil {
+			return fail(fmt.Errorf("[SSH] %s: %w", h.addr(), err))
+		}
 
-	// Dial with context support.
-	var d net.Dialer
-	tcpConn, err := d.DialContext(ctx, "tcp", serverStr)
-	if err != nil {
-		p.state = core.TunnelStateError
-		return fmt.Errorf("[SSH] TCP dial %s: %w", serverStr, err)
-	}
+		var conn net.Conn
+		if i == 0 {
+			var d net.Dialer
+			conn, err = d.DialContext(ctx, "tcp", h.addr())
+			if err != nil {
+				return fail(fmt.Errorf("[SSH] TCP dial %s: %w", h.addr(), err))
+			}
+		} else {
+			conn, err = clients[i-1].DialContext(ctx, "tcp", h.addr())
+			if err != nil {
+				return fail(fmt.Errorf("[SSH] dial %s via %s: %w", h.addr(), hops[i-1].addr(), err))
+			}
+		}
 
-	// Perform SSH handshake over the TCP connection.
-	c, chans, reqs, err := gossh.NewClientConn(tcpConn, serverStr, sshConfig)
-	if err != nil {
-		tcpConn.Close()
-		p.state = core.TunnelStateError
-		return fmt.Errorf("[SSH] handshake with %s: %w", serverStr, err)
+		// Perform SSH handshake over the connection.
+		c, chans, reqs, err := gossh.NewClientConn(conn, h.addr(), sshConfig)
+		if err != nil {
+			conn.Close()
+			if p.authPrompt != "" {
+				return fail(fmt.Errorf("[SSH] %w: %s asked for %q", provider.ErrAuthRequired, h.addr(), p.authPrompt))
+			}
+			return fail(fmt.Errorf("[SSH] handshake with %s: %w", h.addr(), err))
+		}
+		clients = append(clients, gossh.NewClient(c, chans, reqs))
 	}
 
-	p.client = gossh.NewClient(c, chans, reqs)
+	p.client = clients[len(clients)-1]
+	p.jumps = clients[:len(clients)-1]
+
+	if p.config.TunUDP {
+		mux, err := openTunMux(p.client, p.config.TunUnit, p.tunAddr)
+		if err != nil {
+			core.Log.Warnf("SSH", "Tunnel %q: UDP unavailable (server needs PermitTunnel yes): %v", p.name, err)
+		} else {
+			p.tun = mux
+		}
+	}
 	p.state = core.TunnelStateUp
 
 	// Start keepalive goroutine.
@@ -179,7 +291,7 @@ func (p *Provider) Connect(ctx context.Context) error {
 	p.cancelKA = kaCancel
 	go p.keepalive(kaCtx, p.client)
 
-	core.Log.Infof("SSH", "Tunnel %q is UP (server=%s)", p.name, serverStr)
+	core.Log.Infof("SSH", "Tunnel %q is UP (server=%s)", p.name, target.addr())
 	return nil
 }
 
@@ -193,10 +305,18 @@ func (p *Provider) Disconnect() error {
 		p.cancelKA = nil
 	}
 
+	if p.tun != nil {
+		p.tun.Close()
+		p.tun = nil
+	}
 	if p.client != nil {
 		p.client.Close()
 		p.client = nil
 	}
+	for i := len(p.jumps) - 1; i >= 0; i-- {
+		p.jumps[i].Close()
+	}
+	p.jumps = nil
 
 	p.state = core.TunnelStateDown
 	core.Log.Infof("SSH", "Tunnel %q disconnected", p.name)
@@ -245,9 +365,30 @@ func (p *Provider) DialTCP(ctx context.Context, addr string) (net.Conn, error) {
 	}
 }
 
-// DialUDP returns an error — SSH tunnel does not support UDP.
-func (p *Provider) DialUDP(_ context.Context, _ string) (net.Conn, error) {
-	return nil, fmt.Errorf("[SSH] UDP not supported")
+// DialUDP creates a UDP flow over the layer-3 tunnel. Returns
+// provider.ErrUDPNotSupported unless TunUDP is enabled and the server
+// accepted the tunnel.
+func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
+	p.mu.RLock()
+	state := p.state
+	mux := p.tun
+	p.mu.RUnlock()
+
+	if state != core.TunnelStateUp {
+		return nil, fmt.Errorf("[SSH] tunnel %q is not up (state=%d)", p.name, state)
+	}
+	if mux == nil {
+		return nil, provider.ErrUDPNotSupported
+	}
+	remote, err := resolveUDPAddr(ctx, addr, p.tunAddr)
+	if err != nil {
+		return nil, fmt.Errorf("[SSH] resolve %q: %w", addr, err)
+	}
+	conn, err := mux.dial(remote)
+	if err != nil {
+		return nil, fmt.Errorf("[SSH] UDP %s: %w", addr, err)
+	}
+	return conn, nil
 }
 
 // Name returns the human-readable tunnel name.
@@ -307,28 +448,178 @@ func (p *Provider) keepalive(ctx context.Context, client *gossh.Client) {
 	}
 }
 
-// buildAuthMethods constructs SSH authentication methods from the config.
-func (p *Provider) buildAuthMethods() ([]gossh.AuthMethod, error) {
-	var methods []gossh.AuthMethod
+// resolveHops returns the jump hosts followed by the target server, with
+// Host aliases resolved through the ssh config if one is configured.
+// Explicit settings take precedence over the ssh config.
+func (p *Provider) resolveHops() ([]hop, error) {
+	var sshCfg *sshConfig
+	if p.config.SSHConfigPath != "" {
+		c, err := loadSSHConfig(p.config.SSHConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("read ssh config: %w", err)
+		}
+		sshCfg = c
+	}
+
+	target := resolveHop(sshCfg, p.config.Server, p.config.Port, p.config.Username)
+	if target.user == "" {
+		return nil, fmt.Errorf("no username for %s", target.alias)
+	}
+	target.hostKey = p.config.HostKey
+	p.addConfiguredKeys(&target)
+
+	jump := p.config.ProxyJump
+	if jump == "" {
+		jump = target.proxyJump
+	}
+	var hops []hop
+	if jump != "" && !strings.EqualFold(jump, "none") {
+		for _, spec := range strings.Split(jump, ",") {
+			user, host, port, err := parseJumpSpec(strings.TrimSpace(spec))
+			if err != nil {
+				return nil, fmt.Errorf("proxy_jump: %w", err)
+			}
+			h := resolveHop(sshCfg, host, port, user)
+			if h.user == "" {
+				h.user = target.user
+			}
+			h.hostKey = p.jumpHostKey(h)
+			p.addConfiguredKeys(&h)
+			hops = append(hops, h)
+		}
+	}
+	return append(hops, target), nil
+}
+
+// resolveHop fills in a hop from the ssh config entry for alias.
+func resolveHop(sshCfg *sshConfig, alias string, port int, user string) hop {
+	h := hop{alias: alias, host: alias, port: port, user: user}
+	if sshCfg != nil {
+		hc := sshCfg.lookup(alias)
+		h.host = hc.HostName
+		if h.port == 0 {
+			h.port = hc.Port
+		}
+		if h.user == "" {
+			h.user = hc.User
+		}
+		for _, f := range hc.IdentityFiles {
+			if expanded, err := expandTilde(f); err == nil {
+				h.identityFiles = append(h.identityFiles, expanded)
+			}
+		}
+		for _, f := range hc.CertificateFiles {
+			if expanded, err := expandTilde(f); err == nil {
+				h.certFiles = append(h.certFiles, expanded)
+			}
+		}
+		h.identityAgent = hc.IdentityAgent
+		h.proxyJump = hc.ProxyJump
+	}
+	if h.port == 0 {
+		h.port = 22
+	}
+	return h
+}
 
-	// Public key authentication.
+// addConfiguredKeys puts the configured key and certificate ahead of those
+// from the ssh config. They apply to jump hosts as well.
+func (p *Provider) addConfiguredKeys(h *hop) {
 	if p.config.PrivateKeyPath != "" {
-		keyData, err := os.ReadFile(p.config.PrivateKeyPath)
+		h.identityFiles = append([]string{p.config.PrivateKeyPath}, h.identityFiles...)
+	}
+	if p.config.CertificatePath != "" {
+		h.certFiles = append([]string{p.config.CertificatePath}, h.certFiles...)
+	}
+}
+
+// jumpHostKey returns the pinned host key for a jump host, if any.
+func (p *Provider) jumpHostKey(h hop) string {
+	for _, k := range []string{h.addr(), h.alias, h.host} {
+		if key, ok := p.config.JumpHostKeys[k]; ok {
+			return key
+		}
+	}
+	return ""
+}
+
+// clientConfig builds the SSH client config for one hop. Agent connections
+// opened for it are appended to closers.
+func (p *Provider) clientConfig(h hop, closers *[]io.Closer) (*gossh.ClientConfig, error) {
+	authMethods, err := p.buildAuthMethods(h, closers)
+	if err != nil {
+		return nil, fmt.Errorf("build auth: %w", err)
+	}
+	hostKeyCallback, err := p.buildHostKeyCallback(h)
+	if err != nil {
+		return nil, fmt.Errorf("host key callback: %w", err)
+	}
+	return &gossh.ClientConfig{
+		User:            h.user,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         15 * time.Second,
+	}, nil
+}
+
+// buildAuthMethods constructs SSH authentication methods for a hop:
+// public keys (agent and key files, with matching certificates first),
+// then password, then keyboard-interactive.
+func (p *Provider) buildAuthMethods(h hop, closers *[]io.Closer) ([]gossh.AuthMethod, error) {
+	var methods []gossh.AuthMethod
+
+	var signers []gossh.Signer
+	for _, path := range h.identityFiles {
+		signer, err := p.loadSigner(path)
 		if err != nil {
-			return nil, fmt.Errorf("read private key %q: %w", p.config.PrivateKeyPath, err)
+			// The configured key must load; keys listed by the ssh config
+			// are skipped when missing or encrypted, as OpenSSH does.
+			if path == p.config.PrivateKeyPath {
+				return nil, err
+			}
+			core.Log.Debugf("SSH", "Skipping identity %q: %v", path, err)
+			continue
 		}
+		signers = append(signers, signer)
+	}
 
-		var signer gossh.Signer
-		if p.config.PrivateKeyPassphrase != "" {
-			signer, err = gossh.ParsePrivateKeyWithPassphrase(keyData, []byte(p.config.PrivateKeyPassphrase))
+	// Agent keys are fetched when the server asks for public keys.
+	var agentClient agent.ExtendedAgent
+	if agentPath, ok := p.agentPath(h); ok {
+		conn, err := dialAgent(agentPath)
+		if err != nil {
+			core.Log.Warnf("SSH", "Tunnel %q: ssh-agent unavailable: %v", p.name, err)
 		} else {
-			signer, err = gossh.ParsePrivateKey(keyData)
+			*closers = append(*closers, conn)
+			agentClient = agent.NewClient(conn)
 		}
+	}
+
+	var certs []*gossh.Certificate
+	for _, path := range h.certFiles {
+		cert, err := loadCertificate(path)
 		if err != nil {
-			return nil, fmt.Errorf("parse private key %q: %w", p.config.PrivateKeyPath, err)
+			if path == p.config.CertificatePath {
+				return nil, err
+			}
+			core.Log.Debugf("SSH", "Skipping certificate %q: %v", path, err)
+			continue
 		}
+		certs = append(certs, cert)
+	}
 
-		methods = append(methods, gossh.PublicKeys(signer))
+	if len(signers) > 0 || agentClient != nil {
+		methods = append(methods, gossh.PublicKeysCallback(func() ([]gossh.Signer, error) {
+			all := signers
+			if agentClient != nil {
+				agentSigners, err := agentClient.Signers()
+				if err != nil {
+					core.Log.Warnf("SSH", "Tunnel %q: list ssh-agent keys: %v", p.name, err)
+				}
+				all = append(all[:len(all):len(all)], agentSigners...)
+			}
+			return withCertificates(all, certs), nil
+		}))
 	}
 
 	// Password authentication.
@@ -336,17 +627,128 @@ func (p *Provider) buildAuthMethods() ([]gossh.AuthMethod, error) {
 		methods = append(methods, gossh.Password(p.config.Password))
 	}
 
+	if p.config.KeyboardInteractive {
+		methods = append(methods, gossh.KeyboardInteractive(p.answerChallenge))
+	}
+
 	return methods, nil
 }
 
-// buildHostKeyCallback constructs the host key verification callback.
-// Priority: explicit HostKey > InsecureSkipHostKey > TOFU (default).
-func (p *Provider) buildHostKeyCallback() (gossh.HostKeyCallback, error) {
+// agentPath reports whether ssh-agent should be used for a hop, and which
+// socket to use ("" for the default). IdentityAgent in the ssh config
+// enables the agent for that host, or disables it with "none".
+func (p *Provider) agentPath(h hop) (string, bool) {
+	switch h.identityAgent {
+	case "":
+		return p.config.AgentSocket, p.config.UseAgent
+	case "none":
+		return "", false
+	case "SSH_AUTH_SOCK":
+		return p.config.AgentSocket, true
+	}
+	path, err := expandTilde(h.identityAgent)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// loadSigner reads a private key file, using PrivateKeyPassphrase if the
+// key is encrypted.
+func (p *Provider) loadSigner(path string) (gossh.Signer, error) {
+	keyData, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read private key %q: %w", path, err)
+	}
+	signer, err := gossh.ParsePrivateKey(keyData)
+	var missing *gossh.PassphraseMissingError
+	if errors.As(err, &missing) && p.config.PrivateKeyPassphrase != "" {
+		signer, err = gossh.ParsePrivateKeyWithPassphrase(keyData, []byte(p.config.PrivateKeyPassphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse private key %q: %w", path, err)
+	}
+	return signer, nil
+}
+
+// loadCertificate reads an OpenSSH user certificate (*-cert.pub).
+func loadCertificate(path string) (*gossh.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read certificate %q: %w", path, err)
+	}
+	pub, _, _, _, err := gossh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate %q: %w", path, err)
+	}
+	cert, ok := pub.(*gossh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%q is not an OpenSSH certificate", path)
+	}
+	return cert, nil
+}
+
+// withCertificates puts a certificate signer in front of each signer whose
+// key a certificate was issued for.
+func withCertificates(signers []gossh.Signer, certs []*gossh.Certificate) []gossh.Signer {
+	if len(certs) == 0 {
+		return signers
+	}
+	out := make([]gossh.Signer, 0, len(signers)+len(certs))
+	for _, s := range signers {
+		for _, cert := range certs {
+			if subtle.ConstantTimeCompare(cert.Key.Marshal(), s.PublicKey().Marshal()) != 1 {
+				continue
+			}
+			if cs, err := gossh.NewCertSigner(cert, s); err == nil {
+				out = append(out, cs)
+			}
+		}
+	}
+	return append(out, signers...)
+}
+
+// answerChallenge answers keyboard-interactive prompts: password prompts
+// with the configured password, anything else with the one-shot OTP code.
+// If no OTP was supplied it records the prompt so Connect can report
+// provider.ErrAuthRequired. Runs inside Connect with p.mu held.
+func (p *Provider) answerChallenge(_, _ string, questions []string, _ []bool) ([]string, error) {
+	answers := make([]string, len(questions))
+	for i, q := range questions {
+		if isPasswordPrompt(q) && p.config.Password != "" {
+			answers[i] = p.config.Password
+			continue
+		}
+		otp := p.authParams["otp_code"]
+		if otp == "" {
+			p.authPrompt = strings.TrimSpace(q)
+			return nil, fmt.Errorf("no answer for prompt %q", q)
+		}
+		answers[i] = otp
+	}
+	return answers, nil
+}
+
+// isPasswordPrompt reports whether a keyboard-interactive prompt asks for
+// the account password rather than a one-time code.
+func isPasswordPrompt(q string) bool {
+	q = strings.ToLower(q)
+	for _, otp := range []string{"one-time", "one time", "otp", "code", "token", "verification"} {
+		if strings.Contains(q, otp) {
+			return false
+		}
+	}
+	return strings.Contains(q, "password")
+}
+
+// buildHostKeyCallback constructs the host key verification callback for a hop.
+// Priority: explicit host key > InsecureSkipHostKey > TOFU (default).
+func (p *Provider) buildHostKeyCallback(h hop) (gossh.HostKeyCallback, error) {
 	// 1. Explicit host key pinning — highest security.
-	if p.config.HostKey != "" {
-		pubKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(p.config.HostKey))
+	if h.hostKey != "" {
+		pubKey, _, _, _, err := gossh.ParseAuthorizedKey([]byte(h.hostKey))
 		if err != nil {
-			return nil, fmt.Errorf("parse host_key: %w", err)
+			return nil, fmt.Errorf("parse host key for %s: %w", h.addr(), err)
 		}
 		return gossh.FixedHostKey(pubKey), nil
 	}
@@ -367,8 +769,10 @@ func (p *Provider) buildHostKeyCallback() (gossh.HostKeyCallback, error) {
 // - If host is known and key changed: reject (possible MITM).
 func (p *Provider) tofuHostKeyCallback() gossh.HostKeyCallback {
 	return func(hostname string, remote net.Addr, key gossh.PublicKey) error {
+		// Direct connections are keyed by resolved IP:port; hops reached
+		// through a jump host have no remote address, so use host:port.
 		hostPort := hostname
-		if remote != nil {
+		if tcp, ok := remote.(*net.TCPAddr); ok && !tcp.IP.IsUnspecified() {
 			hostPort = remote.String()
 		}
 		keyType := key.Type()
@@ -0,0 +1,212 @@
+package ssh
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// hostConfig is the subset of an OpenSSH client config (~/.ssh/config)
+// entry that the provider understands.
+type hostConfig struct {
+	HostName         string
+	Port             int
+	User             string
+	IdentityFiles    []string
+	CertificateFiles []string
+	ProxyJump        string
+	IdentityAgent    string
+}
+
+// sshConfig is a parsed OpenSSH client config file.
+type sshConfig struct {
+	blocks []sshConfigBlock
+}
+
+type sshConfigBlock struct {
+	patterns []string // Host patterns; nil for the implicit global block
+	match    bool     // Match block — not supported, never applies
+	options  [][2]string
+}
+
+// loadSSHConfig reads an OpenSSH client config file.
+func loadSSHConfig(path string) (*sshConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseSSHConfig(f)
+}
+
+// parseSSHConfig parses OpenSSH client config syntax. Include and Match are
+// not supported; options inside a Match block are ignored.
+func parseSSHConfig(r io.Reader) (*sshConfig, error) {
+	cfg := &sshConfig{blocks: []sshConfigBlock{{}}}
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value := splitConfigLine(line)
+		if value == "" {
+			return nil, fmt.Errorf("line %d: missing value for %q", lineNo, key)
+		}
+		switch strings.ToLower(key) {
+		case "host":
+			cfg.blocks = append(cfg.blocks, sshConfigBlock{patterns: strings.Fields(value)})
+		case "match":
+			cfg.blocks = append(cfg.blocks, sshConfigBlock{match: true})
+		default:
+			cur := &cfg.blocks[len(cfg.blocks)-1]
+			cur.options = append(cur.options, [2]string{strings.ToLower(key), unquote(value)})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// lookup returns the settings that apply to host. As in OpenSSH, the first
+// value obtained for each option wins, except IdentityFile and
+// CertificateFile, which accumulate.
+func (c *sshConfig) lookup(host string) hostConfig {
+	var hc hostConfig
+	for _, b := range c.blocks {
+		if b.match || (b.patterns != nil && !matchHostPatterns(b.patterns, host)) {
+			continue
+		}
+		for _, opt := range b.options {
+			key, value := opt[0], opt[1]
+			switch key {
+			case "hostname":
+				if hc.HostName == "" {
+					hc.HostName = strings.ReplaceAll(value, "%h", host)
+				}
+			case "port":
+				if hc.Port == 0 {
+					hc.Port, _ = strconv.Atoi(value)
+				}
+			case "user":
+				if hc.User == "" {
+					hc.User = value
+				}
+			case "proxyjump":
+				if hc.ProxyJump == "" {
+					hc.ProxyJump = value
+				}
+			case "identityagent":
+				if hc.IdentityAgent == "" {
+					hc.IdentityAgent = value
+				}
+			case "identityfile":
+				hc.IdentityFiles = append(hc.IdentityFiles, value)
+			case "certificatefile":
+				hc.CertificateFiles = append(hc.CertificateFiles, value)
+			}
+		}
+	}
+	if hc.HostName == "" {
+		hc.HostName = host
+	}
+	for i, f := range hc.IdentityFiles {
+		hc.IdentityFiles[i] = strings.ReplaceAll(f, "%h", hc.HostName)
+	}
+	for i, f := range hc.CertificateFiles {
+		hc.CertificateFiles[i] = strings.ReplaceAll(f, "%h", hc.HostName)
+	}
+	return hc
+}
+
+// parseJumpSpec parses one ProxyJump element: [user@]host[:port], with
+// IPv6 hosts in brackets, optionally as an ssh:// URI.
+func parseJumpSpec(spec string) (user, host string, port int, err error) {
+	spec = strings.TrimPrefix(spec, "ssh://")
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		user, spec = spec[:i], spec[i+1:]
+	}
+	host = spec
+	if h, portStr, splitErr := net.SplitHostPort(spec); splitErr == nil {
+		host = h
+		port, err = strconv.Atoi(portStr)
+		if err != nil || port <= 0 || port > 65535 {
+			return "", "", 0, fmt.Errorf("invalid port in %q", spec)
+		}
+	} else if strings.HasPrefix(spec, "[") && strings.HasSuffix(spec, "]") {
+		host = spec[1 : len(spec)-1]
+	}
+	if host == "" {
+		return "", "", 0, fmt.Errorf("empty host in %q", spec)
+	}
+	return user, host, port, nil
+}
+
+// splitConfigLine splits "Key value" or "Key=value".
+func splitConfigLine(line string) (key, value string) {
+	i := strings.IndexAny(line, " \t=")
+	if i < 0 {
+		return line, ""
+	}
+	key = line[:i]
+	value = strings.TrimSpace(line[i:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	return key, value
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
+
+// matchHostPatterns reports whether host matches a Host line: at least one
+// pattern matches and no negated (!pattern) one does.
+func matchHostPatterns(patterns []string, host string) bool {
+	matched := false
+	for _, p := range patterns {
+		if neg, ok := strings.CutPrefix(p, "!"); ok {
+			if wildcardMatch(strings.ToLower(neg), strings.ToLower(host)) {
+				return false
+			}
+			continue
+		}
+		if wildcardMatch(strings.ToLower(p), strings.ToLower(host)) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// wildcardMatch matches s against a pattern with * and ? wildcards.
+func wildcardMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if wildcardMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
@@ -0,0 +1,114 @@
+package ssh
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestSSHConfigLookup(t *testing.T) {
+	conf := `# global defaults
+User fallback
+
+Host bastion
+    HostName bastion.example.com
+    Port 2222
+    IdentityFile ~/.ssh/bastion
+
+Host *.internal !db.internal
+    ProxyJump bastion
+    User=admin
+
+Host vps
+    HostName "203.0.113.7"
+    IdentityFile ~/.ssh/id_ed25519
+    CertificateFile ~/.ssh/id_ed25519-cert.pub
+    IdentityAgent none
+
+Host *
+    Port 22
+    IdentityFile ~/.ssh/%h.key
+
+Match host vps
+    User ignored
+`
+	cfg, err := parseSSHConfig(strings.NewReader(conf))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	b := cfg.lookup("bastion")
+	if b.HostName != "bastion.example.com" || b.Port != 2222 || b.User != "fallback" {
+		t.Errorf("bastion = %+v", b)
+	}
+	if len(b.IdentityFiles) != 2 || b.IdentityFiles[1] != "~/.ssh/bastion.example.com.key" {
+		t.Errorf("bastion identity files = %v", b.IdentityFiles)
+	}
+
+	w := cfg.lookup("web.internal")
+	if w.ProxyJump != "bastion" || w.User != "fallback" || w.HostName != "web.internal" || w.Port != 22 {
+		t.Errorf("web.internal = %+v", w)
+	}
+	if db := cfg.lookup("db.internal"); db.ProxyJump != "" {
+		t.Errorf("negated pattern matched: %+v", db)
+	}
+
+	v := cfg.lookup("VPS")
+	if v.HostName != "203.0.113.7" || v.IdentityAgent != "none" || len(v.CertificateFiles) != 1 {
+		t.Errorf("vps = %+v", v)
+	}
+}
+
+func TestParseJumpSpec(t *testing.T) {
+	tests := []struct {
+		spec       string
+		user, host string
+		port       int
+		wantErr    bool
+	}{
+		{spec: "bastion", host: "bastion"},
+		{spec: "alice@bastion:2222", user: "alice", host: "bastion", port: 2222},
+		{spec: "ssh://bob@[2001:db8::1]:22", user: "bob", host: "2001:db8::1", port: 22},
+		{spec: "[2001:db8::2]", host: "2001:db8::2"},
+		{spec: "host:99999", wantErr: true},
+		{spec: "user@", wantErr: true},
+	}
+	for _, tt := range tests {
+		user, host, port, err := parseJumpSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if user != tt.user || host != tt.host || port != tt.port {
+			t.Errorf("%q = (%q, %q, %d), want (%q, %q, %d)", tt.spec, user, host, port, tt.user, tt.host, tt.port)
+		}
+	}
+}
+
+func TestUDPPacketRoundTrip(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"10.200.0.2:49152", "1.1.1.1:53"},
+		{"[fd00::2]:49153", "[2606:4700::1111]:53"},
+	} {
+		src := netip.MustParseAddrPort(pair[0])
+		dst := netip.MustParseAddrPort(pair[1])
+		pkt := buildUDPPacket(src, dst, []byte("hello"), 7)
+
+		gotSrc, gotDst, payload, ok := parseUDPPacket(pkt)
+		if !ok || gotSrc != src || gotDst != dst || string(payload) != "hello" {
+			t.Fatalf("%v: parse = %v %v %q %v", pair, gotSrc, gotDst, payload, ok)
+		}
+
+		// A packet with a valid UDP checksum sums to 0xffff including the
+		// pseudo-header.
+		udp := pkt[len(pkt)-13:]
+		if sum := checksum(pseudoHeaderSum(src.Addr(), dst.Addr(), len(udp)), udp); sum != 0xffff {
+			t.Errorf("%v: bad UDP checksum (sum %#x)", pair, sum)
+		}
+		if src.Addr().Is4() {
+			if sum := checksum(0, pkt[:20]); sum != 0xffff {
+				t.Errorf("bad IPv4 header checksum (sum %#x)", sum)
+			}
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package ssh
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// OpenSSH tunnel forwarding ("ssh -w"): a tun@openssh.com channel carries
+// raw IP packets to a tun device on the server. Each packet is framed as an
+// SSH string whose first 4 bytes are the address family in OpenBSD
+// numbering. The server must allow it with "PermitTunnel yes" and route or
+// NAT the tunnel's addresses.
+const (
+	tunChannelType   = "tun@openssh.com"
+	tunModePointToPt = 1          // SSH_TUNMODE_POINTOPOINT (layer 3)
+	tunUnitAny       = 0x7fffffff // SSH_TUNID_ANY
+	tunAFInet        = 2          // SSH_TUN_AF_INET
+	tunAFInet6       = 24         // SSH_TUN_AF_INET6
+
+	tunPortFirst   = 49152 // ephemeral source port range for UDP flows
+	tunPortLast    = 65535
+	tunConnBacklog = 128 // inbound datagrams queued per flow before dropping
+)
+
+// tunMux multiplexes UDP flows over one tun@openssh.com channel. It speaks
+// just enough IPv4/IPv6 to wrap outgoing datagrams with a source address of
+// local and hand incoming ones to the flow owning the destination port.
+type tunMux struct {
+	ch    gossh.Channel
+	local netip.Addr
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	conns   map[uint16]*tunUDPConn
+	next    uint16
+	ipID    uint16
+	closed  bool
+}
+
+// openTunMux opens a layer-3 tunnel channel on client. local is the
+// address assigned to this end of the tunnel.
+func openTunMux(client *gossh.Client, unit int, local netip.Addr) (*tunMux, error) {
+	if unit < 0 {
+		unit = tunUnitAny
+	}
+	payload := gossh.Marshal(struct {
+		Mode uint32
+		Unit uint32
+	}{tunModePointToPt, uint32(unit)})
+	ch, reqs, err := client.OpenChannel(tunChannelType, payload)
+	if err != nil {
+		return nil, fmt.Errorf("open %s channel: %w", tunChannelType, err)
+	}
+	go gossh.DiscardRequests(reqs)
+
+	m := &tunMux{
+		ch:    ch,
+		local: local,
+		conns: make(map[uint16]*tunUDPConn),
+		next:  tunPortFirst,
+	}
+	core.SafeGo("ssh.tun-read", m.readLoop)
+	return m, nil
+}
+
+// Close tears down the channel and all flows.
+func (m *tunMux) Close() error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	conns := m.conns
+	m.conns = make(map[uint16]*tunUDPConn)
+	m.mu.Unlock()
+
+	for _, c := range conns {
+		c.shutdown()
+	}
+	return m.ch.Close()
+}
+
+// dial creates a UDP flow to remote with a fresh ephemeral source port.
+func (m *tunMux) dial(remote netip.AddrPort) (*tunUDPConn, error) {
+	if remote.Addr().Is4() != m.local.Is4() {
+		return nil, fmt.Errorf("tunnel address %s cannot reach %s", m.local, remote.Addr())
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil, net.ErrClosed
+	}
+	for range tunPortLast - tunPortFirst + 1 {
+		port := m.next
+		if m.next == tunPortLast {
+			m.next = tunPortFirst
+		} else {
+			m.next++
+		}
+		if _, used := m.conns[port]; used {
+			continue
+		}
+		c := &tunUDPConn{
+			mux:    m,
+			local:  netip.AddrPortFrom(m.local, port),
+			remote: remote,
+			in:     make(chan []byte, tunConnBacklog),
+			closed: make(chan struct{}),
+			wake:   make(chan struct{}, 1),
+		}
+		m.conns[port] = c
+		return c, nil
+	}
+	return nil, fmt.Errorf("no free UDP source port")
+}
+
+func (m *tunMux) release(port uint16) {
+	m.mu.Lock()
+	delete(m.conns, port)
+	m.mu.Unlock()
+}
+
+// readLoop reads framed packets until the channel closes.
+func (m *tunMux) readLoop() {
+	defer m.Close()
+	r := bufio.NewReader(m.ch)
+	var hdr [4]byte
+	buf := make([]byte, 65535+4)
+	for {
+		if _, err := io.ReadFull(r, hdr[:]); err != nil {
+			if err != io.EOF {
+				core.Log.Debugf("SSH", "Tunnel channel read: %v", err)
+			}
+			return
+		}
+		n := binary.BigEndian.Uint32(hdr[:])
+		if n > uint32(len(buf)) {
+			core.Log.Warnf("SSH", "Tunnel channel: oversized frame (%d bytes), closing", n)
+			return
+		}
+		if _, err := io.ReadFull(r, buf[:n]); err != nil {
+			return
+		}
+		if n > 4 {
+			m.deliver(buf[4:n])
+		}
+	}
+}
+
+// deliver hands the UDP payload of an inbound IP packet to its flow.
+func (m *tunMux) deliver(pkt []byte) {
+	src, dst, payload, ok := parseUDPPacket(pkt)
+	if !ok || dst.Addr() != m.local {
+		return
+	}
+	m.mu.Lock()
+	c := m.conns[dst.Port()]
+	m.mu.Unlock()
+	if c == nil || src != c.remote {
+		return
+	}
+	select {
+	case c.in <- append([]byte(nil), payload...):
+	default: // reader too slow — drop like a real socket buffer would
+	}
+}
+
+// send wraps payload in IP/UDP headers and writes it to the channel.
+func (m *tunMux) send(local, remote netip.AddrPort, payload []byte) error {
+	m.mu.Lock()
+	m.ipID++
+	id := m.ipID
+	m.mu.Unlock()
+
+	pkt := buildUDPPacket(local, remote, payload, id)
+	af := uint32(tunAFInet)
+	if remote.Addr().Is6() {
+		af = tunAFInet6
+	}
+	frame := make([]byte, 8+len(pkt))
+	binary.BigEndian.PutUint32(frame[0:4], uint32(4+len(pkt)))
+	binary.BigEndian.PutUint32(frame[4:8], af)
+	copy(frame[8:], pkt)
+
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	_, err := m.ch.Write(frame)
+	return err
+}
+
+// ---------------------------------------------------------------------------
+// UDP flow
+// ---------------------------------------------------------------------------
+
+// tunUDPConn is a connected UDP socket carried over a tunMux.
+type tunUDPConn struct {
+	mux    *tunMux
+	local  netip.AddrPort
+	remote netip.AddrPort
+	in     chan []byte
+
+	closeOnce sync.Once
+	closed    chan struct{}
+
+	mu           sync.Mutex
+	readDeadline time.Time
+	wake         chan struct{} // signalled when the read deadline changes
+}
+
+func (c *tunUDPConn) Read(b []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		dl := c.readDeadline
+		c.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !dl.IsZero() {
+			d := time.Until(dl)
+			if d <= 0 {
+				return 0, os.ErrDeadlineExceeded
+			}
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+
+		var n int
+		var err error
+		done := true
+		select {
+		case pkt := <-c.in:
+			n = copy(b, pkt)
+		case <-c.closed:
+			err = net.ErrClosed
+		case <-timeout:
+			err = os.ErrDeadlineExceeded
+		case <-c.wake:
+			done = false
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if done {
+			return n, err
+		}
+	}
+}
+
+func (c *tunUDPConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	if err := c.mux.send(c.local, c.remote, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+func (c *tunUDPConn) Close() error {
+	c.mux.release(c.local.Port())
+	c.shutdown()
+	return nil
+}
+
+func (c *tunUDPConn) shutdown() {
+	c.closeOnce.Do(func() { close(c.closed) })
+}
+
+func (c *tunUDPConn) LocalAddr() net.Addr  { return net.UDPAddrFromAddrPort(c.local) }
+func (c *tunUDPConn) RemoteAddr() net.Addr { return net.UDPAddrFromAddrPort(c.remote) }
+
+func (c *tunUDPConn) SetDeadline(t time.Time) error {
+	return c.SetReadDeadline(t)
+}
+
+func (c *tunUDPConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// SetWriteDeadline is a no-op: writes go straight to the SSH channel.
+func (c *tunUDPConn) SetWriteDeadline(time.Time) error { return nil }
+
+// resolveUDPAddr resolves addr for a tunnel flow, preferring an address of
+// the same family as the tunnel's local address.
+func resolveUDPAddr(ctx context.Context, addr string, local netip.Addr) (netip.AddrPort, error) {
+	if ap, err := netip.ParseAddrPort(addr); err == nil {
+		return netip.AddrPortFrom(ap.Addr().Unmap(), ap.Port()), nil
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", portStr)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	network := "ip6"
+	if local.Is4() {
+		network = "ip4"
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, network, host)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	return netip.AddrPortFrom(ips[0].Unmap(), uint16(port)), nil
+}
+
+// ---------------------------------------------------------------------------
+// IP/UDP packet helpers
+// ---------------------------------------------------------------------------
+
+// buildUDPPacket returns an IPv4 or IPv6 packet carrying payload.
+func buildUDPPacket(src, dst netip.AddrPort, payload []byte, id uint16) []byte {
+	udpLen := 8 + len(payload)
+	var pkt []byte
+	var udp []byte
+	if src.Addr().Is4() {
+		pkt = make([]byte, 20+udpLen)
+		ip := pkt[:20]
+		ip[0] = 0x45
+		binary.BigEndian.PutUint16(ip[2:4], uint16(len(pkt)))
+		binary.BigEndian.PutUint16(ip[4:6], id)
+		ip[8] = 64 // TTL
+		ip[9] = 17 // UDP
+		s, d := src.Addr().As4(), dst.Addr().As4()
+		copy(ip[12:16], s[:])
+		copy(ip[16:20], d[:])
+		binary.BigEndian.PutUint16(ip[10:12], ^uint16(checksum(0, ip)))
+		udp = pkt[20:]
+	} else {
+		pkt = make([]byte, 40+udpLen)
+		ip := pkt[:40]
+		ip[0] = 0x60
+		binary.BigEndian.PutUint16(ip[4:6], uint16(udpLen))
+		ip[6] = 17 // next header: UDP
+		ip[7] = 64 // hop limit
+		s, d := src.Addr().As16(), dst.Addr().As16()
+		copy(ip[8:24], s[:])
+		copy(ip[24:40], d[:])
+		udp = pkt[40:]
+	}
+	binary.BigEndian.PutUint16(udp[0:2], src.Port())
+	binary.BigEndian.PutUint16(udp[2:4], dst.Port())
+	binary.BigEndian.PutUint16(udp[4:6], uint16(udpLen))
+	copy(udp[8:], payload)
+
+	sum := pseudoHeaderSum(src.Addr(), dst.Addr(), udpLen)
+	cs := ^uint16(checksum(sum, udp))
+	if cs == 0 {
+		cs = 0xffff
+	}
+	binary.BigEndian.PutUint16(udp[6:8], cs)
+	return pkt
+}
+
+// parseUDPPacket extracts addresses and payload from an IPv4 or IPv6 UDP
+// packet. Fragments and IPv6 extension headers are not handled.
+func parseUDPPacket(pkt []byte) (src, dst netip.AddrPort, payload []byte, ok bool) {
+	if len(pkt) < 1 {
+		return
+	}
+	var srcIP, dstIP netip.Addr
+	var udp []byte
+	switch pkt[0] >> 4 {
+	case 4:
+		ihl := int(pkt[0]&0x0f) * 4
+		if ihl < 20 || len(pkt) < ihl+8 || pkt[9] != 17 {
+			return
+		}
+		if binary.BigEndian.Uint16(pkt[6:8])&0x3fff != 0 { // MF or fragment offset
+			return
+		}
+		total := int(binary.BigEndian.Uint16(pkt[2:4]))
+		if total < ihl+8 || total > len(pkt) {
+			return
+		}
+		srcIP = netip.AddrFrom4([4]byte(pkt[12:16]))
+		dstIP = netip.AddrFrom4([4]byte(pkt[16:20]))
+		udp = pkt[ihl:total]
+	case 6:
+		if len(pkt) < 48 || pkt[6] != 17 {
+			return
+		}
+		plen := int(binary.BigEndian.Uint16(pkt[4:6]))
+		if plen < 8 || 40+plen > len(pkt) {
+			return
+		}
+		srcIP = netip.AddrFrom16([16]byte(pkt[8:24]))
+		dstIP = netip.AddrFrom16([16]byte(pkt[24:40]))
+		udp = pkt[40 : 40+plen]
+	default:
+		return
+	}
+	udpLen := int(binary.BigEndian.Uint16(udp[4:6]))
+	if udpLen < 8 || udpLen > len(udp) {
+		return
+	}
+	src = netip.AddrPortFrom(srcIP, binary.BigEndian.Uint16(udp[0:2]))
+	dst = netip.AddrPortFrom(dstIP, binary.BigEndian.Uint16(udp[2:4]))
+	return src, dst, udp[8:udpLen], true
+}
+
+func pseudoHeaderSum(src, dst netip.Addr, length int) uint32 {
+	sum := checksum(0, src.AsSlice())
+	sum = checksum(sum, dst.AsSlice())
+	return sum + 17 + uint32(length)
+}
+
+// checksum adds b to a running ones' complement sum and returns it folded
+// to 16 bits (not inverted).
+func checksum(sum uint32, b []byte) uint32 {
+	for len(b) >= 2 {
+		sum += uint32(b[0])<<8 | uint32(b[1])
+		b = b[2:]
+	}
+	if len(b) == 1 {
+		sum += uint32(b[0]) << 8
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return sum
+}
@@ -0,0 +1,526 @@
+package tuic
+
+import (
+	"math"
+	"time"
+
+	"github.com/apernet/quic-go"
+	"github.com/apernet/quic-go/congestion"
+)
+
+// Congestion control algorithms selectable with congestion_control.
+// "cubic" is quic-go's built-in sender; the others are implemented here.
+const (
+	CongestionCubic   = "cubic"
+	CongestionNewReno = "new_reno"
+	CongestionBBR     = "bbr"
+)
+
+const (
+	initialCwndPackets = 32
+	minCwndPackets     = 4
+	maxBurstPackets    = 10
+	defaultRTT         = 100 * time.Millisecond
+)
+
+// setCongestionControl installs the named controller on conn.
+func setCongestionControl(conn *quic.Conn, name string) {
+	switch name {
+	case CongestionNewReno:
+		conn.SetCongestionControl(newRenoSender())
+	case CongestionBBR:
+		conn.SetCongestionControl(newBBRSender())
+	}
+}
+
+// pacer is a token bucket that spreads a congestion window over an RTT
+// instead of sending it in one burst.
+type pacer struct {
+	budgetAtLastSent congestion.ByteCount
+	maxDatagramSize  congestion.ByteCount
+	lastSentTime     congestion.Time
+	bandwidth        func() uint64 // bytes per second
+}
+
+func newPacer(bandwidth func() uint64) *pacer {
+	p := &pacer{maxDatagramSize: congestion.InitialPacketSize, bandwidth: bandwidth}
+	p.budgetAtLastSent = p.maxBurst()
+	return p
+}
+
+func (p *pacer) maxBurst() congestion.ByteCount {
+	return max(p.scaled(congestion.MinPacingDelay+time.Millisecond), maxBurstPackets*p.maxDatagramSize)
+}
+
+// scaled returns how many bytes may be sent in d at the current rate.
+func (p *pacer) scaled(d time.Duration) congestion.ByteCount {
+	bw := p.bandwidth()
+	if bw == 0 || d <= 0 {
+		return 0
+	}
+	if uint64(d) > math.MaxUint64/bw {
+		return maxBurstPackets * p.maxDatagramSize
+	}
+	return congestion.ByteCount(bw * uint64(d) / uint64(time.Second))
+}
+
+func (p *pacer) budget(now congestion.Time) congestion.ByteCount {
+	if p.lastSentTime.IsZero() {
+		return p.maxBurst()
+	}
+	return min(p.maxBurst(), p.budgetAtLastSent+p.scaled(now.Sub(p.lastSentTime)))
+}
+
+func (p *pacer) sent(now congestion.Time, size congestion.ByteCount) {
+	b := p.budget(now)
+	if size >= b {
+		p.budgetAtLastSent = 0
+	} else {
+		p.budgetAtLastSent = b - size
+	}
+	p.lastSentTime = now
+}
+
+func (p *pacer) timeUntilSend() congestion.Time {
+	if p.budgetAtLastSent >= p.maxDatagramSize {
+		return 0
+	}
+	bw := p.bandwidth()
+	if bw == 0 {
+		return 0
+	}
+	missing := uint64(p.maxDatagramSize - p.budgetAtLastSent)
+	d := time.Duration((missing*uint64(time.Second) + bw - 1) / bw)
+	return p.lastSentTime.Add(max(congestion.MinPacingDelay, d))
+}
+
+// ---- NewReno ----
+
+// renoSender is RFC 9002 NewReno: slow start, then one datagram per window
+// per RTT, halving the window once per loss episode.
+type renoSender struct {
+	rtt   congestion.RTTStatsProvider
+	pacer *pacer
+
+	maxDatagramSize congestion.ByteCount
+	cwnd            congestion.ByteCount
+	ssthresh        congestion.ByteCount
+	ackedBytes      congestion.ByteCount // acked since the last increase in congestion avoidance
+
+	largestSent       congestion.PacketNumber
+	largestAcked      congestion.PacketNumber
+	recoveryStartedAt congestion.PacketNumber // largest sent when the last loss episode began, -1 if none
+}
+
+func newRenoSender() *renoSender {
+	s := &renoSender{
+		maxDatagramSize:   congestion.InitialPacketSize,
+		cwnd:              initialCwndPackets * congestion.InitialPacketSize,
+		ssthresh:          math.MaxInt64,
+		largestSent:       -1,
+		largestAcked:      -1,
+		recoveryStartedAt: -1,
+	}
+	s.pacer = newPacer(s.bandwidth)
+	return s
+}
+
+// bandwidth paces one window per smoothed RTT, with headroom so pacing is
+// not what limits the sender.
+func (s *renoSender) bandwidth() uint64 {
+	srtt := defaultRTT
+	if s.rtt != nil && s.rtt.SmoothedRTT() > 0 {
+		srtt = s.rtt.SmoothedRTT()
+	}
+	return uint64(s.cwnd) * uint64(time.Second) / uint64(srtt) * 5 / 4
+}
+
+func (s *renoSender) minCwnd() congestion.ByteCount { return minCwndPackets * s.maxDatagramSize }
+func (s *renoSender) maxCwnd() congestion.ByteCount {
+	return congestion.MaxCongestionWindowPackets * s.maxDatagramSize
+}
+
+func (s *renoSender) SetRTTStatsProvider(p congestion.RTTStatsProvider) { s.rtt = p }
+
+func (s *renoSender) TimeUntilSend(congestion.ByteCount) congestion.Time {
+	return s.pacer.timeUntilSend()
+}
+
+func (s *renoSender) HasPacingBudget(now congestion.Time) bool {
+	return s.pacer.budget(now) >= s.maxDatagramSize
+}
+
+func (s *renoSender) OnPacketSent(sentTime congestion.Time, _ congestion.ByteCount, pn congestion.PacketNumber, bytes congestion.ByteCount, retransmittable bool) {
+	s.pacer.sent(sentTime, bytes)
+	if retransmittable {
+		s.largestSent = max(s.largestSent, pn)
+	}
+}
+
+func (s *renoSender) CanSend(bytesInFlight congestion.ByteCount) bool {
+	return bytesInFlight < s.cwnd
+}
+
+func (s *renoSender) MaybeExitSlowStart() {}
+
+func (s *renoSender) OnPacketAcked(pn congestion.PacketNumber, acked, priorInFlight congestion.ByteCount, _ congestion.Time) {
+	s.largestAcked = max(s.largestAcked, pn)
+	if s.InRecovery() {
+		return
+	}
+	// Don't grow a window the sender isn't using.
+	if priorInFlight+acked < s.cwnd/2 && !s.InSlowStart() {
+		return
+	}
+	if s.InSlowStart() {
+		s.cwnd = min(s.cwnd+acked, s.maxCwnd())
+		return
+	}
+	s.ackedBytes += acked
+	if s.ackedBytes >= s.cwnd {
+		s.ackedBytes -= s.cwnd
+		s.cwnd = min(s.cwnd+s.maxDatagramSize, s.maxCwnd())
+	}
+}
+
+func (s *renoSender) OnCongestionEvent(pn congestion.PacketNumber, _, _ congestion.ByteCount) {
+	// One reduction per loss episode: losses of packets sent before the
+	// episode began are part of it.
+	if pn <= s.recoveryStartedAt {
+		return
+	}
+	s.recoveryStartedAt = s.largestSent
+	s.cwnd = max(s.cwnd/2, s.minCwnd())
+	s.ssthresh = s.cwnd
+	s.ackedBytes = 0
+}
+
+func (s *renoSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	if !packetsRetransmitted {
+		return
+	}
+	s.recoveryStartedAt = -1
+	s.ssthresh = max(s.cwnd/2, s.minCwnd())
+	s.cwnd = s.minCwnd()
+}
+
+func (s *renoSender) SetMaxDatagramSize(size congestion.ByteCount) {
+	grow := s.cwnd == initialCwndPackets*s.maxDatagramSize
+	s.maxDatagramSize = size
+	s.pacer.maxDatagramSize = size
+	if grow {
+		s.cwnd = initialCwndPackets * size
+	}
+	s.cwnd = max(s.cwnd, s.minCwnd())
+}
+
+func (s *renoSender) InSlowStart() bool { return s.cwnd < s.ssthresh }
+
+func (s *renoSender) InRecovery() bool {
+	return s.recoveryStartedAt >= 0 && s.largestAcked <= s.recoveryStartedAt
+}
+
+func (s *renoSender) GetCongestionWindow() congestion.ByteCount { return s.cwnd }
+
+// ---- BBR ----
+
+type bbrMode int
+
+const (
+	bbrStartup bbrMode = iota
+	bbrDrain
+	bbrProbeBW
+	bbrProbeRTT
+)
+
+const (
+	bbrHighGain        = 2.885 // 2/ln(2): doubles the sending rate every round in startup
+	bbrBWWindowRounds  = 10
+	bbrMinRTTWindow    = 10 * time.Second
+	bbrProbeRTTTime    = 200 * time.Millisecond
+	bbrFullBWThreshold = 1.25
+	bbrFullBWRounds    = 3
+)
+
+var bbrCycleGains = [...]float64{1.25, 0.75, 1, 1, 1, 1, 1, 1}
+
+type bbrSentPacket struct {
+	sentTime      congestion.Time
+	delivered     congestion.ByteCount // total delivered when the packet was sent
+	deliveredTime congestion.Time
+}
+
+type bbrBWSample struct {
+	round uint64
+	bw    uint64
+}
+
+// bbrSender is a compact BBR (v1) model: it estimates the bottleneck
+// bandwidth as the windowed max delivery rate and the propagation delay as
+// the windowed min RTT, and paces at gain × bandwidth with a window of
+// gain × BDP. Losses do not reduce the window.
+type bbrSender struct {
+	rtt   congestion.RTTStatsProvider
+	pacer *pacer
+
+	maxDatagramSize congestion.ByteCount
+	cwnd            congestion.ByteCount
+	mode            bbrMode
+	pacingGain      float64
+	cwndGain        float64
+
+	// Delivery rate sampling.
+	sent          map[congestion.PacketNumber]bbrSentPacket
+	delivered     congestion.ByteCount
+	deliveredTime congestion.Time
+	bytesInFlight congestion.ByteCount
+
+	// Round trips: a round ends when a packet sent after it began is acked.
+	round              uint64
+	nextRoundDelivered congestion.ByteCount
+	bwSamples          [bbrBWWindowRounds]bbrBWSample
+
+	minRTT      time.Duration
+	minRTTStamp congestion.Time
+
+	// Startup exit: bandwidth stopped growing for bbrFullBWRounds rounds.
+	fullBW      uint64
+	fullBWCount int
+	filledPipe  bool
+
+	cycleIndex int
+	cycleStamp congestion.Time
+
+	probeRTTDone congestion.Time
+	priorCwnd    congestion.ByteCount
+}
+
+func newBBRSender() *bbrSender {
+	s := &bbrSender{
+		maxDatagramSize: congestion.InitialPacketSize,
+		cwnd:            initialCwndPackets * congestion.InitialPacketSize,
+		mode:            bbrStartup,
+		pacingGain:      bbrHighGain,
+		cwndGain:        bbrHighGain,
+		sent:            make(map[congestion.PacketNumber]bbrSentPacket),
+	}
+	s.pacer = newPacer(s.pacingRate)
+	return s
+}
+
+// maxBW is the bottleneck bandwidth estimate in bytes per second.
+func (s *bbrSender) maxBW() uint64 {
+	var bw uint64
+	for _, smp := range s.bwSamples {
+		if smp.round+bbrBWWindowRounds > s.round {
+			bw = max(bw, smp.bw)
+		}
+	}
+	return bw
+}
+
+func (s *bbrSender) pacingRate() uint64 {
+	bw := s.maxBW()
+	if bw == 0 {
+		// No sample yet: spread the initial window over the initial RTT.
+		rtt := defaultRTT
+		if s.rtt != nil && s.rtt.SmoothedRTT() > 0 {
+			rtt = s.rtt.SmoothedRTT()
+		}
+		bw = uint64(s.cwnd) * uint64(time.Second) / uint64(rtt)
+	}
+	return uint64(float64(bw) * s.pacingGain)
+}
+
+// bdp is the bandwidth-delay product in bytes, 0 while either is unknown.
+func (s *bbrSender) bdp() congestion.ByteCount {
+	bw := s.maxBW()
+	if bw == 0 || s.minRTT == 0 {
+		return 0
+	}
+	return congestion.ByteCount(bw * uint64(s.minRTT) / uint64(time.Second))
+}
+
+func (s *bbrSender) minCwnd() congestion.ByteCount { return minCwndPackets * s.maxDatagramSize }
+
+func (s *bbrSender) SetRTTStatsProvider(p congestion.RTTStatsProvider) { s.rtt = p }
+
+func (s *bbrSender) TimeUntilSend(congestion.ByteCount) congestion.Time {
+	return s.pacer.timeUntilSend()
+}
+
+func (s *bbrSender) HasPacingBudget(now congestion.Time) bool {
+	return s.pacer.budget(now) >= s.maxDatagramSize
+}
+
+func (s *bbrSender) OnPacketSent(sentTime congestion.Time, bytesInFlight congestion.ByteCount, pn congestion.PacketNumber, bytes congestion.ByteCount, retransmittable bool) {
+	s.pacer.sent(sentTime, bytes)
+	if !retransmittable {
+		return
+	}
+	if s.deliveredTime.IsZero() {
+		s.deliveredTime = sentTime
+	}
+	s.sent[pn] = bbrSentPacket{sentTime: sentTime, delivered: s.delivered, deliveredTime: s.deliveredTime}
+	s.bytesInFlight = bytesInFlight + bytes
+}
+
+func (s *bbrSender) CanSend(bytesInFlight congestion.ByteCount) bool {
+	return bytesInFlight < s.GetCongestionWindow()
+}
+
+func (s *bbrSender) MaybeExitSlowStart() {}
+
+func (s *bbrSender) OnPacketAcked(pn congestion.PacketNumber, acked, priorInFlight congestion.ByteCount, now congestion.Time) {
+	p, ok := s.sent[pn]
+	if !ok {
+		return
+	}
+	delete(s.sent, pn)
+	s.delivered += acked
+	s.deliveredTime = now
+	s.bytesInFlight = max(priorInFlight-acked, 0)
+
+	// Round trip accounting.
+	roundStart := false
+	if p.delivered >= s.nextRoundDelivered {
+		s.round++
+		s.nextRoundDelivered = s.delivered
+		roundStart = true
+	}
+
+	// Delivery rate sample over the interval since the packet was sent.
+	if interval := now.Sub(p.deliveredTime); interval > 0 {
+		bw := uint64(s.delivered-p.delivered) * uint64(time.Second) / uint64(interval)
+		slot := &s.bwSamples[s.round%bbrBWWindowRounds]
+		if slot.round != s.round || bw > slot.bw {
+			*slot = bbrBWSample{round: s.round, bw: bw}
+		}
+	}
+
+	// Windowed min RTT.
+	if rtt := now.Sub(p.sentTime); rtt > 0 {
+		if s.minRTT == 0 || rtt <= s.minRTT || now.Sub(s.minRTTStamp) > bbrMinRTTWindow {
+			if s.mode != bbrProbeRTT || rtt <= s.minRTT || s.minRTT == 0 {
+				s.minRTT = rtt
+				s.minRTTStamp = now
+			}
+		}
+	}
+
+	s.updateMode(now, roundStart)
+	s.updateCwnd(acked)
+}
+
+func (s *bbrSender) updateMode(now congestion.Time, roundStart bool) {
+	if roundStart && !s.filledPipe {
+		bw := s.maxBW()
+		if float64(bw) >= float64(s.fullBW)*bbrFullBWThreshold {
+			s.fullBW = bw
+			s.fullBWCount = 0
+		} else if s.fullBWCount++; s.fullBWCount >= bbrFullBWRounds {
+			s.filledPipe = true
+		}
+	}
+
+	switch s.mode {
+	case bbrStartup:
+		if s.filledPipe {
+			s.mode = bbrDrain
+			s.pacingGain = 1 / bbrHighGain
+			s.cwndGain = bbrHighGain
+		}
+	case bbrDrain:
+		if s.bytesInFlight <= s.bdp() {
+			s.enterProbeBW(now)
+		}
+	case bbrProbeBW:
+		if now.Sub(s.cycleStamp) > s.minRTT {
+			s.cycleIndex = (s.cycleIndex + 1) % len(bbrCycleGains)
+			s.cycleStamp = now
+			s.pacingGain = bbrCycleGains[s.cycleIndex]
+		}
+	case bbrProbeRTT:
+		if s.probeRTTDone.IsZero() && s.bytesInFlight <= s.minCwnd() {
+			s.probeRTTDone = now.Add(bbrProbeRTTTime)
+		}
+		if !s.probeRTTDone.IsZero() && now.After(s.probeRTTDone) {
+			s.minRTTStamp = now
+			s.cwnd = max(s.cwnd, s.priorCwnd)
+			if s.filledPipe {
+				s.enterProbeBW(now)
+			} else {
+				s.mode = bbrStartup
+				s.pacingGain, s.cwndGain = bbrHighGain, bbrHighGain
+			}
+		}
+		return
+	}
+
+	// Drain the queue once per min-RTT window to re-measure the path delay.
+	if s.minRTT > 0 && now.Sub(s.minRTTStamp) > bbrMinRTTWindow {
+		s.mode = bbrProbeRTT
+		s.pacingGain, s.cwndGain = 1, 1
+		s.priorCwnd = s.cwnd
+		s.probeRTTDone = 0
+	}
+}
+
+func (s *bbrSender) enterProbeBW(now congestion.Time) {
+	s.mode = bbrProbeBW
+	s.cwndGain = 2
+	// Start anywhere but the drain phase.
+	s.cycleIndex = int(uint64(now) % uint64(len(bbrCycleGains)))
+	if s.cycleIndex == 1 {
+		s.cycleIndex = 2
+	}
+	s.pacingGain = bbrCycleGains[s.cycleIndex]
+	s.cycleStamp = now
+}
+
+func (s *bbrSender) updateCwnd(acked congestion.ByteCount) {
+	target := congestion.ByteCount(float64(s.bdp()) * s.cwndGain)
+	if target == 0 {
+		s.cwnd += acked // no model yet: grow like slow start
+		return
+	}
+	target = max(target, s.minCwnd())
+	if s.filledPipe {
+		s.cwnd = min(s.cwnd+acked, target)
+	} else if s.cwnd < target {
+		s.cwnd += acked
+	}
+	s.cwnd = max(s.cwnd, s.minCwnd())
+}
+
+func (s *bbrSender) OnCongestionEvent(pn congestion.PacketNumber, lost, priorInFlight congestion.ByteCount) {
+	delete(s.sent, pn)
+	s.bytesInFlight = max(priorInFlight-lost, 0)
+}
+
+func (s *bbrSender) OnRetransmissionTimeout(packetsRetransmitted bool) {
+	if packetsRetransmitted {
+		clear(s.sent)
+	}
+}
+
+func (s *bbrSender) SetMaxDatagramSize(size congestion.ByteCount) {
+	s.maxDatagramSize = size
+	s.pacer.maxDatagramSize = size
+	s.cwnd = max(s.cwnd, s.minCwnd())
+}
+
+func (s *bbrSender) InSlowStart() bool { return s.mode == bbrStartup }
+func (s *bbrSender) InRecovery() bool  { return false }
+
+func (s *bbrSender) GetCongestionWindow() congestion.ByteCount {
+	if s.mode == bbrProbeRTT {
+		return s.minCwnd()
+	}
+	return s.cwnd
+}
+
+var (
+	_ congestion.CongestionControl = (*renoSender)(nil)
+	_ congestion.CongestionControl = (*bbrSender)(nil)
+)
@@ -0,0 +1,306 @@
+package tuic
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TUIC v5 wire format.
+// https://github.com/tuic-protocol/tuic/blob/main/SPEC.md
+const (
+	protocolVersion = 0x05
+
+	cmdAuthenticate = 0x00
+	cmdConnect      = 0x01
+	cmdPacket       = 0x02
+	cmdDissociate   = 0x03
+	cmdHeartbeat    = 0x04
+
+	addrNone   = 0xff
+	addrDomain = 0x00
+	addrIPv4   = 0x01
+	addrIPv6   = 0x02
+
+	// packetHeaderLen is VER, TYPE, ASSOC_ID, PKT_ID, FRAG_TOTAL, FRAG_ID, SIZE.
+	packetHeaderLen = 2 + 2 + 2 + 1 + 1 + 2
+
+	// fragmentTimeout drops partially received packets.
+	fragmentTimeout = 10 * time.Second
+)
+
+var errBadCommand = errors.New("malformed TUIC command")
+
+// appendAddr encodes "host:port" as a TUIC ADDR field. An empty addr
+// encodes the None type used by fragments after the first one.
+func appendAddr(b []byte, addr string) ([]byte, error) {
+	if addr == "" {
+		return append(b, addrNone), nil
+	}
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port in %q", addr)
+	}
+	if ip, err := netip.ParseAddr(host); err == nil {
+		ip = ip.Unmap()
+		if ip.Is4() {
+			b = append(b, addrIPv4)
+		} else {
+			b = append(b, addrIPv6)
+		}
+		b = append(b, ip.AsSlice()...)
+	} else {
+		if len(host) == 0 || len(host) > 255 {
+			return nil, fmt.Errorf("invalid host in %q", addr)
+		}
+		b = append(b, addrDomain, byte(len(host)))
+		b = append(b, host...)
+	}
+	return binary.BigEndian.AppendUint16(b, uint16(port)), nil
+}
+
+// readAddr decodes an ADDR field; None yields "".
+func readAddr(r io.Reader) (string, error) {
+	var typ [1]byte
+	if _, err := io.ReadFull(r, typ[:]); err != nil {
+		return "", err
+	}
+	var host string
+	switch typ[0] {
+	case addrNone:
+		return "", nil
+	case addrIPv4, addrIPv6:
+		ip := make([]byte, 4)
+		if typ[0] == addrIPv6 {
+			ip = make([]byte, 16)
+		}
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		a, _ := netip.AddrFromSlice(ip)
+		host = a.String()
+	case addrDomain:
+		var n [1]byte
+		if _, err := io.ReadFull(r, n[:]); err != nil {
+			return "", err
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(r, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", fmt.Errorf("%w: address type %#x", errBadCommand, typ[0])
+	}
+	var port [2]byte
+	if _, err := io.ReadFull(r, port[:]); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port[:])))), nil
+}
+
+// encodeAuthenticate builds the Authenticate command.
+func encodeAuthenticate(uuid [16]byte, token []byte) []byte {
+	b := make([]byte, 0, 2+16+32)
+	b = append(b, protocolVersion, cmdAuthenticate)
+	b = append(b, uuid[:]...)
+	return append(b, token...)
+}
+
+// encodeConnect builds the Connect command that opens a TCP relay stream.
+func encodeConnect(addr string) ([]byte, error) {
+	return appendAddr([]byte{protocolVersion, cmdConnect}, addr)
+}
+
+func encodeDissociate(assocID uint16) []byte {
+	return binary.BigEndian.AppendUint16([]byte{protocolVersion, cmdDissociate}, assocID)
+}
+
+func encodeHeartbeat() []byte {
+	return []byte{protocolVersion, cmdHeartbeat}
+}
+
+// packet is one (possibly fragmented) UDP datagram relayed over TUIC.
+type packet struct {
+	assocID   uint16
+	pktID     uint16
+	fragTotal uint8
+	fragID    uint8
+	addr      string // "" on all but the first fragment
+	payload   []byte
+}
+
+func (p *packet) encode() ([]byte, error) {
+	b := make([]byte, 0, packetHeaderLen+19+len(p.payload))
+	b = append(b, protocolVersion, cmdPacket)
+	b = binary.BigEndian.AppendUint16(b, p.assocID)
+	b = binary.BigEndian.AppendUint16(b, p.pktID)
+	b = append(b, p.fragTotal, p.fragID)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(p.payload)))
+	b, err := appendAddr(b, p.addr)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, p.payload...), nil
+}
+
+// fragments splits a datagram so each encoded fragment fits in maxSize
+// bytes. Only the first fragment carries the address.
+func fragments(assocID, pktID uint16, addr string, payload []byte, maxSize int) ([]*packet, error) {
+	first, err := appendAddr(nil, addr)
+	if err != nil {
+		return nil, err
+	}
+	firstRoom := maxSize - packetHeaderLen - len(first)
+	restRoom := maxSize - packetHeaderLen - 1
+	if firstRoom <= 0 || restRoom <= 0 {
+		return nil, fmt.Errorf("datagram size limit %d too small", maxSize)
+	}
+	total := 1
+	if len(payload) > firstRoom {
+		total += (len(payload) - firstRoom + restRoom - 1) / restRoom
+	}
+	if total > 255 {
+		return nil, fmt.Errorf("datagram of %d bytes needs too many fragments", len(payload))
+	}
+	pkts := make([]*packet, 0, total)
+	for i := 0; i < total; i++ {
+		p := &packet{assocID: assocID, pktID: pktID, fragTotal: uint8(total), fragID: uint8(i)}
+		room := restRoom
+		if i == 0 {
+			p.addr, room = addr, firstRoom
+		}
+		n := min(room, len(payload))
+		p.payload, payload = payload[:n], payload[n:]
+		pkts = append(pkts, p)
+	}
+	return pkts, nil
+}
+
+// readCommand reads a command header and, for Packet, its body. Other
+// commands a client may receive (Heartbeat) carry no body.
+func readCommand(r io.Reader) (byte, *packet, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return 0, nil, err
+	}
+	if hdr[0] != protocolVersion {
+		return 0, nil, fmt.Errorf("%w: version %#x", errBadCommand, hdr[0])
+	}
+	if hdr[1] != cmdPacket {
+		return hdr[1], nil, nil
+	}
+	var fixed [8]byte
+	if _, err := io.ReadFull(r, fixed[:]); err != nil {
+		return 0, nil, err
+	}
+	p := &packet{
+		assocID:   binary.BigEndian.Uint16(fixed[0:]),
+		pktID:     binary.BigEndian.Uint16(fixed[2:]),
+		fragTotal: fixed[4],
+		fragID:    fixed[5],
+	}
+	size := binary.BigEndian.Uint16(fixed[6:])
+	addr, err := readAddr(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	p.addr = addr
+	p.payload = make([]byte, size)
+	if _, err := io.ReadFull(r, p.payload); err != nil {
+		return 0, nil, err
+	}
+	if p.fragTotal == 0 || p.fragID >= p.fragTotal {
+		return 0, nil, fmt.Errorf("%w: fragment %d/%d", errBadCommand, p.fragID, p.fragTotal)
+	}
+	return cmdPacket, p, nil
+}
+
+type fragKey struct {
+	assocID uint16
+	pktID   uint16
+}
+
+type fragBuffer struct {
+	parts    [][]byte
+	received int
+	addr     string
+	started  time.Time
+}
+
+// reassembler joins fragmented packets. Incomplete packets are dropped
+// after fragmentTimeout.
+type reassembler struct {
+	mu      sync.Mutex
+	pending map[fragKey]*fragBuffer
+}
+
+func newReassembler() *reassembler {
+	return &reassembler{pending: make(map[fragKey]*fragBuffer)}
+}
+
+// add returns the complete datagram and its source address once all
+// fragments of p have arrived.
+func (r *reassembler) add(p *packet) ([]byte, string, bool) {
+	if p.fragTotal == 1 {
+		return p.payload, p.addr, true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := fragKey{p.assocID, p.pktID}
+	fb := r.pending[key]
+	if fb == nil || len(fb.parts) != int(p.fragTotal) {
+		fb = &fragBuffer{parts: make([][]byte, p.fragTotal), started: time.Now()}
+		r.pending[key] = fb
+	}
+	if fb.parts[p.fragID] != nil {
+		return nil, "", false
+	}
+	fb.parts[p.fragID] = p.payload
+	fb.received++
+	if p.fragID == 0 {
+		fb.addr = p.addr
+	}
+	if fb.received < len(fb.parts) {
+		return nil, "", false
+	}
+	delete(r.pending, key)
+	var data []byte
+	for _, part := range fb.parts {
+		data = append(data, part...)
+	}
+	return data, fb.addr, true
+}
+
+// expire drops incomplete packets older than fragmentTimeout.
+func (r *reassembler) expire(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, fb := range r.pending {
+		if now.Sub(fb.started) > fragmentTimeout {
+			delete(r.pending, key)
+		}
+	}
+}
+
+// dissociate drops pending fragments of a closed association.
+func (r *reassembler) dissociate(assocID uint16) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.pending {
+		if key.assocID == assocID {
+			delete(r.pending, key)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package tuic
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+
+	"github.com/google/uuid"
+)
+
+// UDP relay modes.
+const (
+	// UDPRelayNative sends each UDP packet as a QUIC datagram (unreliable).
+	UDPRelayNative = "native"
+	// UDPRelayQUIC sends each UDP packet on its own unidirectional stream
+	// (reliable, no fragmentation limits from the path MTU).
+	UDPRelayQUIC = "quic"
+)
+
+// Config holds TUIC v5 tunnel configuration.
+type Config struct {
+	// Server is the TUIC server address in host:port format.
+	Server string `yaml:"server"`
+	// UUID identifies the user.
+	UUID string `yaml:"uuid"`
+	// Password is the user's password.
+	Password string `yaml:"password"`
+	// SNI is the TLS server name indication (defaults to server hostname).
+	SNI string `yaml:"sni"`
+	// ALPN lists the TLS application protocols (defaults to "h3").
+	ALPN []string `yaml:"alpn"`
+	// Insecure disables TLS certificate verification.
+	Insecure bool `yaml:"insecure"`
+	// UDPRelayMode is "native" (default) or "quic".
+	UDPRelayMode string `yaml:"udp_relay_mode"`
+	// CongestionControl is "cubic" (default), "new_reno" or "bbr".
+	CongestionControl string `yaml:"congestion_control"`
+}
+
+// Provider implements TunnelProvider for TUIC v5 (QUIC-based) protocol.
+// TCP connections are relayed over bidirectional streams and UDP over
+// QUIC datagrams or unidirectional streams.
+// Does NOT implement RawForwarder — not an IP-level tunnel.
+type Provider struct {
+	mu     sync.RWMutex
+	config Config
+	uuid   uuid.UUID
+	state  core.TunnelState
+	name   string
+
+	serverAddr netip.AddrPort // resolved endpoint for bypass routes
+	sess       *session
+}
+
+// New creates a TUIC provider with the given configuration.
+// DNS resolution is deferred to Connect().
+func New(name string, cfg Config) (*Provider, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("[TUIC] server address is required")
+	}
+	if _, _, err := net.SplitHostPort(cfg.Server); err != nil {
+		return nil, fmt.Errorf("[TUIC] invalid server address %q: %w", cfg.Server, err)
+	}
+	id, err := uuid.Parse(cfg.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("[TUIC] invalid uuid %q: %w", cfg.UUID, err)
+	}
+	if cfg.Password == "" {
+		return nil, fmt.Errorf("[TUIC] password is required")
+	}
+	switch cfg.UDPRelayMode {
+	case "":
+		cfg.UDPRelayMode = UDPRelayNative
+	case UDPRelayNative, UDPRelayQUIC:
+	default:
+		return nil, fmt.Errorf("[TUIC] unsupported udp_relay_mode %q (use \"native\" or \"quic\")", cfg.UDPRelayMode)
+	}
+	switch cfg.CongestionControl {
+	case "":
+		cfg.CongestionControl = CongestionCubic
+	case CongestionCubic, CongestionNewReno, CongestionBBR:
+	default:
+		return nil, fmt.Errorf("[TUIC] unsupported congestion_control %q (use \"cubic\", \"new_reno\" or \"bbr\")", cfg.CongestionControl)
+	}
+	if len(cfg.ALPN) == 0 {
+		cfg.ALPN = []string{"h3"}
+	}
+
+	return &Provider{
+		config: cfg,
+		uuid:   id,
+		name:   name,
+		state:  core.TunnelStateDown,
+	}, nil
+}
+
+// Connect performs the QUIC handshake and authenticates with the server.
+func (p *Provider) Connect(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.state = core.TunnelStateConnecting
+	core.Log.Infof("TUIC", "Connecting tunnel %q to %s...", p.name, p.config.Server)
+
+	host, portStr, _ := net.SplitHostPort(p.config.Server)
+	port, err := net.DefaultResolver.LookupPort(ctx, "udp", portStr)
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[TUIC] invalid port %q: %w", portStr, err)
+	}
+	ip, err := resolveHost(ctx, host)
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[TUIC] resolve %q: %w", host, err)
+	}
+	p.serverAddr = netip.AddrPortFrom(ip, uint16(port))
+
+	sni := p.config.SNI
+	if sni == "" {
+		sni = host
+	}
+
+	sess, err := dialSession(ctx, sessionConfig{
+		server:     p.serverAddr,
+		sni:        sni,
+		alpn:       p.config.ALPN,
+		insecure:   p.config.Insecure,
+		uuid:       p.uuid,
+		password:   p.config.Password,
+		native:     p.config.UDPRelayMode == UDPRelayNative,
+		congestion: p.config.CongestionControl,
+	})
+	if err != nil {
+		p.state = core.TunnelStateError
+		return fmt.Errorf("[TUIC] connect failed: %w", err)
+	}
+	p.sess = sess
+	p.state = core.TunnelStateUp
+
+	// A dead QUIC connection can't be revived: flag it so the tunnel
+	// controller reconnects.
+	core.SafeGo("tuic.monitor", func() {
+		<-sess.done()
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.sess == sess && p.state == core.TunnelStateUp {
+			p.state = core.TunnelStateError
+			core.Log.Warnf("TUIC", "Tunnel %q connection lost: %v", p.name, sess.err())
+		}
+	})
+
+	core.Log.Infof("TUIC", "Tunnel %q is UP (server=%s, udp=%s, cc=%s)",
+		p.name, p.serverAddr, p.config.UDPRelayMode, p.config.CongestionControl)
+	return nil
+}
+
+// Disconnect closes the QUIC connection.
+func (p *Provider) Disconnect() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sess != nil {
+		p.sess.close()
+		p.sess = nil
+	}
+	p.state = core.TunnelStateDown
+	core.Log.Infof("TUIC", "Tunnel %q disconnected", p.name)
+	return nil
+}
+
+// State returns the current tunnel state.
+func (p *Provider) State() core.TunnelState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.state
+}
+
+// GetAdapterIP returns an invalid address — TUIC has no local VPN adapter IP.
+func (p *Provider) GetAdapterIP() netip.Addr {
+	return netip.Addr{}
+}
+
+func (p *Provider) session() (*session, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.state != core.TunnelStateUp || p.sess == nil {
+		return nil, fmt.Errorf("[TUIC] tunnel %q is not up (state=%d)", p.name, p.state)
+	}
+	return p.sess, nil
+}
+
+// DialTCP opens a TCP relay stream through the TUIC tunnel.
+func (p *Provider) DialTCP(ctx context.Context, addr string) (net.Conn, error) {
+	sess, err := p.session()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sess.dialTCP(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("[TUIC] TCP dial %s: %w", addr, err)
+	}
+	return conn, nil
+}
+
+// DialUDP opens a UDP association through the TUIC tunnel bound to addr.
+func (p *Provider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
+	sess, err := p.session()
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("[TUIC] UDP dial %s: %w", addr, err)
+	}
+	return sess.associate(addr), nil
+}
+
+// Name returns the human-readable tunnel name.
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// Protocol returns "tuic".
+func (p *Provider) Protocol() string {
+	return core.ProtocolTUIC
+}
+
+// GetServerEndpoints returns the TUIC server endpoint for bypass route management.
+// Implements provider.EndpointProvider.
+func (p *Provider) GetServerEndpoints() []netip.AddrPort {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.serverAddr.IsValid() {
+		return []netip.AddrPort{p.serverAddr}
+	}
+	return nil
+}
+
+// resolveHost resolves a hostname (or parses an IP literal) to an address.
+func resolveHost(ctx context.Context, host string) (netip.Addr, error) {
+	if addr, err := netip.ParseAddr(strings.Trim(host, "[]")); err == nil {
+		return addr.Unmap(), nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return netip.Addr{}, err
+	}
+	if len(ips) == 0 {
+		return netip.Addr{}, fmt.Errorf("no addresses found for %q", host)
+	}
+	return ips[0].Unmap(), nil
+}
+
+var (
+	_ provider.TunnelProvider   = (*Provider)(nil)
+	_ provider.EndpointProvider = (*Provider)(nil)
+)
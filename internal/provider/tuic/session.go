@@ -0,0 +1,438 @@
+package tuic
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+
+	"github.com/apernet/quic-go"
+	"github.com/google/uuid"
+)
+
+const (
+	heartbeatInterval = 10 * time.Second
+	maxIdleTimeout    = 30 * time.Second
+	udpRecvQueue      = 256
+
+	// datagramOverhead is the QUIC short header, AEAD tag and DATAGRAM
+	// frame header. quic-go reports the path MTU as the datagram size
+	// limit, so fragments must leave room for these or they are dropped.
+	datagramOverhead = 48
+)
+
+type sessionConfig struct {
+	server     netip.AddrPort
+	sni        string
+	alpn       []string
+	insecure   bool
+	uuid       uuid.UUID
+	password   string
+	native     bool
+	congestion string
+}
+
+// session is one authenticated QUIC connection to a TUIC server.
+type session struct {
+	conn   *quic.Conn
+	udp    *net.UDPConn
+	native bool
+	reasm  *reassembler
+
+	pktID atomic.Uint32
+
+	mu        sync.Mutex
+	assocs    map[uint16]*udpConn
+	nextAssoc uint16
+}
+
+func dialSession(ctx context.Context, cfg sessionConfig) (*session, error) {
+	udp, err := net.ListenUDP("udp", nil)
+	if err != nil {
+		return nil, err
+	}
+	tlsConf := &tls.Config{
+		ServerName:         cfg.sni,
+		InsecureSkipVerify: cfg.insecure,
+		NextProtos:         cfg.alpn,
+		MinVersion:         tls.VersionTLS13,
+	}
+	quicConf := &quic.Config{
+		EnableDatagrams:       true,
+		MaxIdleTimeout:        maxIdleTimeout,
+		KeepAlivePeriod:       heartbeatInterval,
+		MaxIncomingUniStreams: 1024,
+	}
+	conn, err := quic.Dial(ctx, udp, net.UDPAddrFromAddrPort(cfg.server), tlsConf, quicConf)
+	if err != nil {
+		udp.Close()
+		return nil, err
+	}
+	setCongestionControl(conn, cfg.congestion)
+
+	s := &session{
+		conn:   conn,
+		udp:    udp,
+		native: cfg.native,
+		reasm:  newReassembler(),
+		assocs: make(map[uint16]*udpConn),
+	}
+	if err := s.authenticate(cfg.uuid, cfg.password); err != nil {
+		s.close()
+		return nil, fmt.Errorf("authenticate: %w", err)
+	}
+
+	core.SafeGo("tuic.heartbeat", s.heartbeatLoop)
+	core.SafeGo("tuic.datagrams", s.datagramLoop)
+	core.SafeGo("tuic.uni-streams", s.uniStreamLoop)
+	return s, nil
+}
+
+// authenticate sends the Authenticate command. The token is derived from
+// the TLS session so it can't be replayed on another connection.
+func (s *session) authenticate(id uuid.UUID, password string) error {
+	state := s.conn.ConnectionState().TLS
+	token, err := state.ExportKeyingMaterial(string(id[:]), []byte(password), 32)
+	if err != nil {
+		return err
+	}
+	return s.sendUni(encodeAuthenticate(id, token))
+}
+
+// sendUni writes one command on a fresh unidirectional stream.
+func (s *session) sendUni(b []byte) error {
+	stream, err := s.conn.OpenUniStream()
+	if err != nil {
+		return err
+	}
+	if _, err := stream.Write(b); err != nil {
+		stream.CancelWrite(0)
+		return err
+	}
+	return stream.Close()
+}
+
+func (s *session) done() <-chan struct{} { return s.conn.Context().Done() }
+
+func (s *session) err() error { return context.Cause(s.conn.Context()) }
+
+func (s *session) close() {
+	_ = s.conn.CloseWithError(0, "")
+	_ = s.udp.Close()
+
+	s.mu.Lock()
+	assocs := s.assocs
+	s.assocs = make(map[uint16]*udpConn)
+	s.mu.Unlock()
+	for _, c := range assocs {
+		c.shutdown()
+	}
+}
+
+// heartbeatLoop keeps the server from timing out idle relays and drops
+// stale fragments.
+func (s *session) heartbeatLoop() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done():
+			return
+		case now := <-ticker.C:
+			_ = s.conn.SendDatagram(encodeHeartbeat())
+			s.reasm.expire(now)
+		}
+	}
+}
+
+func (s *session) datagramLoop() {
+	ctx := s.conn.Context()
+	for {
+		b, err := s.conn.ReceiveDatagram(ctx)
+		if err != nil {
+			return
+		}
+		cmd, p, err := readCommand(bytes.NewReader(b))
+		if err != nil {
+			core.Log.Debugf("TUIC", "Bad datagram: %v", err)
+			continue
+		}
+		if cmd == cmdPacket {
+			s.handlePacket(p)
+		}
+	}
+}
+
+func (s *session) uniStreamLoop() {
+	ctx := s.conn.Context()
+	for {
+		stream, err := s.conn.AcceptUniStream(ctx)
+		if err != nil {
+			return
+		}
+		core.SafeGo("tuic.uni-stream", func() {
+			defer stream.CancelRead(0)
+			_ = stream.SetReadDeadline(time.Now().Add(maxIdleTimeout))
+			cmd, p, err := readCommand(stream)
+			if err != nil {
+				core.Log.Debugf("TUIC", "Bad uni stream: %v", err)
+				return
+			}
+			if cmd == cmdPacket {
+				s.handlePacket(p)
+			}
+		})
+	}
+}
+
+func (s *session) handlePacket(p *packet) {
+	s.mu.Lock()
+	c := s.assocs[p.assocID]
+	s.mu.Unlock()
+	if c == nil {
+		return
+	}
+	if data, _, ok := s.reasm.add(p); ok {
+		c.deliver(data)
+	}
+}
+
+// dialTCP opens a bidirectional stream and sends Connect. The server
+// relays data right away; there is no response.
+func (s *session) dialTCP(ctx context.Context, addr string) (net.Conn, error) {
+	req, err := encodeConnect(addr)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := s.conn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stream.Write(req); err != nil {
+		stream.CancelRead(0)
+		stream.CancelWrite(0)
+		return nil, err
+	}
+	return &tcpConn{Stream: stream, local: s.conn.LocalAddr(), remote: addrOf(addr)}, nil
+}
+
+// associate allocates a UDP association ID for a new relay.
+func (s *session) associate(target string) *udpConn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		s.nextAssoc++
+		if _, used := s.assocs[s.nextAssoc]; !used {
+			break
+		}
+	}
+	c := &udpConn{
+		sess:       s,
+		assocID:    s.nextAssoc,
+		target:     target,
+		remote:     addrOf(target),
+		recv:       make(chan []byte, udpRecvQueue),
+		closed:     make(chan struct{}),
+		deadlineCh: make(chan struct{}),
+	}
+	s.assocs[c.assocID] = c
+	return c
+}
+
+// dissociate tells the server to release an association.
+func (s *session) dissociate(assocID uint16) {
+	s.mu.Lock()
+	delete(s.assocs, assocID)
+	s.mu.Unlock()
+	s.reasm.dissociate(assocID)
+	select {
+	case <-s.done():
+	default:
+		_ = s.sendUni(encodeDissociate(assocID))
+	}
+}
+
+// sendPacket relays one UDP datagram. In native mode it is sent as QUIC
+// datagrams, fragmented when it doesn't fit the path; in quic mode it goes
+// on its own unidirectional stream.
+func (s *session) sendPacket(assocID uint16, addr string, payload []byte) error {
+	p := &packet{
+		assocID:   assocID,
+		pktID:     uint16(s.pktID.Add(1)),
+		fragTotal: 1,
+		addr:      addr,
+		payload:   payload,
+	}
+	b, err := p.encode()
+	if err != nil {
+		return err
+	}
+	if !s.native {
+		return s.sendUni(b)
+	}
+
+	err = s.conn.SendDatagram(b)
+	var tooLarge *quic.DatagramTooLargeError
+	if !errors.As(err, &tooLarge) {
+		return err
+	}
+	frags, err := fragments(p.assocID, p.pktID, addr, payload, int(tooLarge.MaxDatagramPayloadSize)-datagramOverhead)
+	if err != nil {
+		return err
+	}
+	for _, f := range frags {
+		if b, err = f.encode(); err != nil {
+			return err
+		}
+		if err := s.conn.SendDatagram(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addrOf returns a net.Addr for "host:port", resolving nothing: domain
+// targets are reported as an unspecified address with the right port.
+func addrOf(hostport string) net.Addr {
+	if ap, err := netip.ParseAddrPort(hostport); err == nil {
+		return net.UDPAddrFromAddrPort(ap)
+	}
+	_, port, _ := net.SplitHostPort(hostport)
+	p, _ := net.LookupPort("udp", port)
+	return &net.UDPAddr{IP: net.IPv4zero, Port: p}
+}
+
+// tcpConn is a TCP relay over one bidirectional QUIC stream.
+type tcpConn struct {
+	*quic.Stream
+	local  net.Addr
+	remote net.Addr
+}
+
+func (c *tcpConn) Close() error {
+	c.Stream.CancelRead(0)
+	return c.Stream.Close()
+}
+
+func (c *tcpConn) LocalAddr() net.Addr  { return c.local }
+func (c *tcpConn) RemoteAddr() net.Addr { return c.remote }
+
+// udpConn is a connected UDP relay over one TUIC association.
+type udpConn struct {
+	sess    *session
+	assocID uint16
+	target  string
+	remote  net.Addr
+
+	recv      chan []byte
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	deadline   time.Time
+	deadlineCh chan struct{} // closed when the read deadline changes
+}
+
+func (c *udpConn) deliver(b []byte) {
+	select {
+	case c.recv <- b:
+	case <-c.closed:
+	default:
+		// Queue full: drop like a full socket buffer.
+	}
+}
+
+func (c *udpConn) Read(b []byte) (int, error) {
+	for {
+		c.mu.Lock()
+		deadline, deadlineCh := c.deadline, c.deadlineCh
+		c.mu.Unlock()
+
+		var timer *time.Timer
+		var timeout <-chan time.Time
+		if !deadline.IsZero() {
+			d := time.Until(deadline)
+			if d <= 0 {
+				return 0, os.ErrDeadlineExceeded
+			}
+			timer = time.NewTimer(d)
+			timeout = timer.C
+		}
+		var data []byte
+		var err error
+		changed := false
+		select {
+		case data = <-c.recv:
+		case <-c.closed:
+			err = net.ErrClosed
+		case <-timeout:
+			err = os.ErrDeadlineExceeded
+		case <-deadlineCh:
+			// Deadline changed: wait again with the new one.
+			changed = true
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		switch {
+		case err != nil:
+			return 0, err
+		case !changed:
+			return copy(b, data), nil
+		}
+	}
+}
+
+func (c *udpConn) Write(b []byte) (int, error) {
+	select {
+	case <-c.closed:
+		return 0, net.ErrClosed
+	default:
+	}
+	if err := c.sess.sendPacket(c.assocID, c.target, b); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// shutdown closes the conn without notifying the server.
+func (c *udpConn) shutdown() bool {
+	first := false
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		first = true
+	})
+	return first
+}
+
+func (c *udpConn) Close() error {
+	if c.shutdown() {
+		c.sess.dissociate(c.assocID)
+	}
+	return nil
+}
+
+func (c *udpConn) LocalAddr() net.Addr  { return c.sess.conn.LocalAddr() }
+func (c *udpConn) RemoteAddr() net.Addr { return c.remote }
+
+func (c *udpConn) SetDeadline(t time.Time) error { return c.SetReadDeadline(t) }
+
+func (c *udpConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deadline = t
+	close(c.deadlineCh)
+	c.deadlineCh = make(chan struct{})
+	return nil
+}
+
+func (c *udpConn) SetWriteDeadline(time.Time) error { return nil }
@@ -1,6 +1,7 @@
 package vless
 
 import (
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -8,19 +9,29 @@ import (
 	"strings"
 )
 
+// Outbound protocols supported through the xray wrapper.
+const (
+	OutboundVLESS       = "vless"
+	OutboundVMess       = "vmess"
+	OutboundShadowsocks = "shadowsocks"
+)
+
 // Config holds VLESS-specific tunnel configuration.
 type Config struct {
+	// Outbound is the xray outbound protocol: "vless" (default), "vmess"
+	// or "shadowsocks". Transport, security and mux settings apply to all.
+	Outbound string `yaml:"outbound"`
 	// Address is the VLESS server hostname or IP.
 	Address string `yaml:"address"`
 	// Port is the VLESS server port.
 	Port int `yaml:"port"`
-	// UUID is the VLESS user UUID.
+	// UUID is the VLESS/VMess user UUID.
 	UUID string `yaml:"uuid"`
 	// Flow is the XTLS flow type (e.g. "xtls-rprx-vision"). Optional.
 	Flow string `yaml:"flow"`
 	// Encryption is always "none" for VLESS.
 	Encryption string `yaml:"encryption"`
-	// Network is the transport protocol: "tcp", "ws", "grpc", "h2".
+	// Network is the transport protocol: "tcp", "ws", "grpc", "xhttp", "httpupgrade".
 	Network string `yaml:"network"`
 	// Security is the TLS layer: "reality", "tls", "none".
 	Security string `yaml:"security"`
@@ -39,6 +50,100 @@ type Config struct {
 
 	// XHTTP holds XHTTP/SplitHTTP-specific settings (when Network == "xhttp" or "splithttp").
 	XHTTP XHTTPConfig `yaml:"xhttp"`
+
+	// HTTPUpgrade holds HTTPUpgrade-specific settings (when Network == "httpupgrade").
+	HTTPUpgrade HTTPUpgradeConfig `yaml:"httpupgrade"`
+
+	// VMess holds VMess user settings (when Outbound == "vmess").
+	VMess VMessConfig `yaml:"vmess"`
+
+	// Shadowsocks holds Shadowsocks settings (when Outbound == "shadowsocks").
+	Shadowsocks ShadowsocksConfig `yaml:"shadowsocks"`
+
+	// Mux enables xray multiplexing for TCP and XUDP for UDP.
+	Mux MuxConfig `yaml:"mux"`
+
+	// Fragment splits outgoing TCP (typically the TLS ClientHello) to evade DPI.
+	Fragment FragmentConfig `yaml:"fragment"`
+
+	// Noises are junk UDP packets sent before the first real one (QUIC/XHTTP-H3
+	// transports), to confuse UDP fingerprinting.
+	Noises []NoiseConfig `yaml:"noises"`
+}
+
+// VMessConfig holds VMess user settings.
+type VMessConfig struct {
+	// Security is the VMess cipher: "auto" (default), "aes-128-gcm",
+	// "chacha20-poly1305", "none" or "zero".
+	Security string `yaml:"security"`
+}
+
+// ShadowsocksConfig holds Shadowsocks client settings.
+type ShadowsocksConfig struct {
+	// Method is the cipher, e.g. "aes-256-gcm", "chacha20-ietf-poly1305"
+	// or a "2022-blake3-*" method.
+	Method string `yaml:"method"`
+	// Password is the Shadowsocks password (base64 key for 2022 methods).
+	Password string `yaml:"password"`
+	// UoT tunnels UDP over a TCP stream (UDP-over-TCP v2).
+	UoT bool `yaml:"uot"`
+}
+
+// HTTPUpgradeConfig holds HTTPUpgrade transport settings.
+type HTTPUpgradeConfig struct {
+	// Path is the HTTP request path.
+	Path string `yaml:"path"`
+	// Host is the HTTP Host header value.
+	Host string `yaml:"host"`
+}
+
+// MuxConfig holds xray mux settings.
+type MuxConfig struct {
+	// Enabled turns on mux for TCP and XUDP for UDP.
+	Enabled bool `yaml:"enabled"`
+	// Concurrency is the max TCP streams per mux connection (0 = 8, -1 = TCP not muxed).
+	Concurrency int `yaml:"concurrency"`
+	// XUDPConcurrency is the max UDP sessions per XUDP connection (0 = 16).
+	XUDPConcurrency int `yaml:"xudp_concurrency"`
+	// XUDPProxyUDP443 controls UDP/443 (QUIC) under XUDP: "reject" (default,
+	// browsers fall back to TCP), "allow" or "skip" (not muxed).
+	XUDPProxyUDP443 string `yaml:"xudp_proxy_udp443"`
+}
+
+// FragmentConfig holds freedom "fragment" settings. Values are xray ranges
+// such as "10-20".
+type FragmentConfig struct {
+	// Packets selects what to split: "tlshello" or a TCP segment range ("1-3").
+	// Empty disables fragmentation.
+	Packets string `yaml:"packets"`
+	// Length is the fragment size range in bytes.
+	Length string `yaml:"length"`
+	// Interval is the delay between fragments in milliseconds.
+	Interval string `yaml:"interval"`
+}
+
+// NoiseConfig is one freedom "noises" entry.
+type NoiseConfig struct {
+	// Type is "rand" (Packet is a length range), "str", "hex" or "base64".
+	Type string `yaml:"type"`
+	// Packet is the payload or, for "rand", its length range.
+	Packet string `yaml:"packet"`
+	// Delay is the pause after the packet in milliseconds (range).
+	Delay string `yaml:"delay"`
+}
+
+// outbound returns the effective outbound protocol.
+func (c *Config) outbound() string {
+	if c.Outbound == "" {
+		return OutboundVLESS
+	}
+	return c.Outbound
+}
+
+// usesFragment reports whether traffic to the server goes through the
+// fragmenting freedom outbound.
+func (c *Config) usesFragment() bool {
+	return c.Fragment.Packets != "" || len(c.Noises) > 0
 }
 
 // RealityConfig holds REALITY TLS settings.
@@ -102,21 +207,25 @@ func buildXrayJSON(cfg Config, socksPort int) ([]byte, error) {
 		cfg.Network = "tcp"
 	}
 	if cfg.Security == "" {
-		cfg.Security = "reality"
-	}
-
-	user := map[string]any{
-		"id":         cfg.UUID,
-		"encryption": cfg.Encryption,
-	}
-	if cfg.Flow != "" {
-		user["flow"] = cfg.Flow
+		// Reality is the VLESS default; VMess/Shadowsocks servers are
+		// usually plain or behind TLS set explicitly.
+		cfg.Security = "none"
+		if cfg.outbound() == OutboundVLESS {
+			cfg.Security = "reality"
+		}
 	}
 
-	outbound := map[string]any{
-		"tag":      "vless-out",
-		"protocol": "vless",
-		"settings": map[string]any{
+	var settings map[string]any
+	switch cfg.outbound() {
+	case OutboundVLESS:
+		user := map[string]any{
+			"id":         cfg.UUID,
+			"encryption": cfg.Encryption,
+		}
+		if cfg.Flow != "" {
+			user["flow"] = cfg.Flow
+		}
+		settings = map[string]any{
 			"vnext": []map[string]any{
 				{
 					"address": cfg.Address,
@@ -124,7 +233,45 @@ func buildXrayJSON(cfg Config, socksPort int) ([]byte, error) {
 					"users":   []map[string]any{user},
 				},
 			},
-		},
+		}
+	case OutboundVMess:
+		cipher := cfg.VMess.Security
+		if cipher == "" {
+			cipher = "auto"
+		}
+		settings = map[string]any{
+			"vnext": []map[string]any{
+				{
+					"address": cfg.Address,
+					"port":    cfg.Port,
+					"users": []map[string]any{
+						{"id": cfg.UUID, "security": cipher},
+					},
+				},
+			},
+		}
+	case OutboundShadowsocks:
+		server := map[string]any{
+			"address":  cfg.Address,
+			"port":     cfg.Port,
+			"method":   cfg.Shadowsocks.Method,
+			"password": cfg.Shadowsocks.Password,
+		}
+		if cfg.Shadowsocks.UoT {
+			server["uot"] = true
+			server["uotVersion"] = 2
+		}
+		settings = map[string]any{
+			"servers": []map[string]any{server},
+		}
+	default:
+		return nil, fmt.Errorf("unsupported outbound protocol %q", cfg.Outbound)
+	}
+
+	outbound := map[string]any{
+		"tag":      "vless-out",
+		"protocol": cfg.outbound(),
+		"settings": settings,
 	}
 
 	// Stream settings.
@@ -201,13 +348,30 @@ func buildXrayJSON(cfg Config, socksPort int) ([]byte, error) {
 			xhttpSettings[k] = v
 		}
 		stream["xhttpSettings"] = xhttpSettings
+	case "httpupgrade":
+		huSettings := map[string]any{
+			"path": cfg.HTTPUpgrade.Path,
+		}
+		if cfg.HTTPUpgrade.Host != "" {
+			huSettings["host"] = cfg.HTTPUpgrade.Host
+		}
+		stream["httpupgradeSettings"] = huSettings
+	}
+
+	// Fragment/noise: dial the server through a freedom outbound that
+	// splits the first TCP segments and prepends junk UDP packets.
+	if cfg.usesFragment() {
+		stream["sockopt"] = map[string]any{
+			"dialerProxy": "fragment",
+		}
 	}
 
 	outbound["streamSettings"] = stream
 
-	// Build two outbounds: TCP and UDP separated so that UDP is never muxed.
-	// Mux is disabled on both by default — it requires explicit opt-in via Config
-	// because many transports (XHTTP/SplitHTTP) and server setups don't support it.
+	// Build two outbounds: TCP and UDP separated so that UDP is never muxed
+	// as TCP streams (it uses XUDP instead). Mux is disabled on both by
+	// default — it requires explicit opt-in via Config because many
+	// transports (XHTTP/SplitHTTP) and server setups don't support it.
 	tcpOutbound := deepCopyMap(outbound)
 	tcpOutbound["tag"] = "vless-tcp"
 	tcpOutbound["mux"] = map[string]any{
@@ -222,6 +386,48 @@ func buildXrayJSON(cfg Config, socksPort int) ([]byte, error) {
 		"concurrency": -1,
 	}
 
+	if cfg.Mux.Enabled {
+		concurrency := cfg.Mux.Concurrency
+		if concurrency == 0 {
+			concurrency = 8
+		}
+		// XTLS Vision can't be muxed; xray only allows XUDP with it.
+		if cfg.Flow != "" {
+			concurrency = -1
+		}
+		tcpOutbound["mux"] = map[string]any{
+			"enabled":     concurrency > 0,
+			"concurrency": concurrency,
+		}
+
+		xudp := cfg.Mux.XUDPConcurrency
+		if xudp == 0 {
+			xudp = 16
+		}
+		udpMux := map[string]any{
+			"enabled":         true,
+			"concurrency":     -1,
+			"xudpConcurrency": xudp,
+		}
+		if cfg.Mux.XUDPProxyUDP443 != "" {
+			udpMux["xudpProxyUDP443"] = cfg.Mux.XUDPProxyUDP443
+		}
+		udpOutbound["mux"] = udpMux
+	}
+
+	outbounds := []map[string]any{
+		tcpOutbound,
+		udpOutbound,
+		{
+			"tag":      "direct",
+			"protocol": "freedom",
+			"settings": map[string]any{},
+		},
+	}
+	if cfg.usesFragment() {
+		outbounds = append(outbounds, fragmentOutbound(cfg))
+	}
+
 	xrayConfig := map[string]any{
 		"log": map[string]any{
 			"loglevel": "warning",
@@ -238,15 +444,7 @@ func buildXrayJSON(cfg Config, socksPort int) ([]byte, error) {
 				"listen": "127.0.0.1",
 			},
 		},
-		"outbounds": []map[string]any{
-			tcpOutbound,
-			udpOutbound,
-			{
-				"tag":      "direct",
-				"protocol": "freedom",
-				"settings": map[string]any{},
-			},
-		},
+		"outbounds": outbounds,
 		"routing": map[string]any{
 			"domainStrategy": "AsIs",
 			"rules": []map[string]any{
@@ -271,6 +469,48 @@ func buildXrayJSON(cfg Config, socksPort int) ([]byte, error) {
 	return data, nil
 }
 
+// fragmentOutbound builds the freedom outbound used as dialerProxy for
+// TLS ClientHello fragmentation and UDP noise.
+func fragmentOutbound(cfg Config) map[string]any {
+	settings := map[string]any{}
+	if cfg.Fragment.Packets != "" {
+		fragment := map[string]any{
+			"packets": cfg.Fragment.Packets,
+		}
+		if cfg.Fragment.Length != "" {
+			fragment["length"] = cfg.Fragment.Length
+		}
+		if cfg.Fragment.Interval != "" {
+			fragment["interval"] = cfg.Fragment.Interval
+		}
+		settings["fragment"] = fragment
+	}
+	if len(cfg.Noises) > 0 {
+		noises := make([]map[string]any, 0, len(cfg.Noises))
+		for _, n := range cfg.Noises {
+			noise := map[string]any{
+				"type":   n.Type,
+				"packet": n.Packet,
+			}
+			if n.Delay != "" {
+				noise["delay"] = n.Delay
+			}
+			noises = append(noises, noise)
+		}
+		settings["noises"] = noises
+	}
+	return map[string]any{
+		"tag":      "fragment",
+		"protocol": "freedom",
+		"settings": settings,
+		"streamSettings": map[string]any{
+			"sockopt": map[string]any{
+				"tcpNoDelay": true,
+			},
+		},
+	}
+}
+
 // deepCopyMap performs a shallow-ish copy of a map[string]any, recursing into
 // nested map[string]any and []map[string]any values so that the copy can be
 // mutated independently of the original.
@@ -294,11 +534,26 @@ func deepCopyMap(src map[string]any) map[string]any {
 }
 
 // ParseXrayJSON parses a standard xray-core JSON config file and extracts
-// VLESS connection parameters into a Config struct.
-// Supports the typical xray config with outbounds[].protocol == "vless".
+// connection parameters into a Config struct.
+// Supports the typical xray config with outbounds[].protocol == "vless",
+// "vmess" or "shadowsocks". Fragment/noise settings are taken from the
+// freedom outbound named by sockopt.dialerProxy.
 func ParseXrayJSON(data []byte) (Config, error) {
+	type freedomSettings struct {
+		Fragment *struct {
+			Packets  string          `json:"packets"`
+			Length   json.RawMessage `json:"length"`
+			Interval json.RawMessage `json:"interval"`
+		} `json:"fragment"`
+		Noises []struct {
+			Type   string          `json:"type"`
+			Packet string          `json:"packet"`
+			Delay  json.RawMessage `json:"delay"`
+		} `json:"noises"`
+	}
 	var raw struct {
 		Outbounds []struct {
+			Tag      string `json:"tag"`
 			Protocol string `json:"protocol"`
 			Settings struct {
 				Vnext []struct {
@@ -308,12 +563,21 @@ func ParseXrayJSON(data []byte) (Config, error) {
 						ID         string `json:"id"`
 						Encryption string `json:"encryption"`
 						Flow       string `json:"flow"`
+						Security   string `json:"security"`
 					} `json:"users"`
 				} `json:"vnext"`
+				Servers []struct {
+					Address  string `json:"address"`
+					Port     int    `json:"port"`
+					Method   string `json:"method"`
+					Password string `json:"password"`
+					UoT      bool   `json:"uot"`
+				} `json:"servers"`
+				freedomSettings
 			} `json:"settings"`
 			StreamSettings struct {
-				Network  string `json:"network"`
-				Security string `json:"security"`
+				Network         string `json:"network"`
+				Security        string `json:"security"`
 				RealitySettings struct {
 					Fingerprint string `json:"fingerprint"`
 					ServerName  string `json:"serverName"`
@@ -338,7 +602,20 @@ func ParseXrayJSON(data []byte) (Config, error) {
 					Host string `json:"host"`
 					Mode string `json:"mode"`
 				} `json:"xhttpSettings"`
+				HTTPUpgradeSettings struct {
+					Path string `json:"path"`
+					Host string `json:"host"`
+				} `json:"httpupgradeSettings"`
+				Sockopt struct {
+					DialerProxy string `json:"dialerProxy"`
+				} `json:"sockopt"`
 			} `json:"streamSettings"`
+			Mux struct {
+				Enabled         bool   `json:"enabled"`
+				Concurrency     int    `json:"concurrency"`
+				XUDPConcurrency int    `json:"xudpConcurrency"`
+				XUDPProxyUDP443 string `json:"xudpProxyUDP443"`
+			} `json:"mux"`
 		} `json:"outbounds"`
 	}
 
@@ -346,36 +623,57 @@ func ParseXrayJSON(data []byte) (Config, error) {
 		return Config{}, fmt.Errorf("parse xray JSON: %w", err)
 	}
 
-	// Find the first VLESS outbound.
+	// Find the first supported proxy outbound.
 	for _, ob := range raw.Outbounds {
-		if ob.Protocol != "vless" {
-			continue
-		}
-		if len(ob.Settings.Vnext) == 0 || len(ob.Settings.Vnext[0].Users) == 0 {
-			return Config{}, fmt.Errorf("xray JSON: vless outbound has no vnext/users")
-		}
-
-		vnext := ob.Settings.Vnext[0]
-		user := vnext.Users[0]
 		ss := ob.StreamSettings
-
 		cfg := Config{
-			Address:    vnext.Address,
-			Port:       vnext.Port,
-			UUID:       user.ID,
-			Flow:       user.Flow,
-			Encryption: user.Encryption,
-			Network:    ss.Network,
-			Security:   ss.Security,
+			Outbound: ob.Protocol,
+			Network:  ss.Network,
+			Security: ss.Security,
+		}
+
+		switch ob.Protocol {
+		case OutboundVLESS, OutboundVMess:
+			if len(ob.Settings.Vnext) == 0 || len(ob.Settings.Vnext[0].Users) == 0 {
+				return Config{}, fmt.Errorf("xray JSON: %s outbound has no vnext/users", ob.Protocol)
+			}
+			vnext := ob.Settings.Vnext[0]
+			user := vnext.Users[0]
+			cfg.Address = vnext.Address
+			cfg.Port = vnext.Port
+			cfg.UUID = user.ID
+			if ob.Protocol == OutboundVLESS {
+				cfg.Flow = user.Flow
+				cfg.Encryption = user.Encryption
+			} else {
+				cfg.VMess.Security = user.Security
+			}
+		case OutboundShadowsocks:
+			if len(ob.Settings.Servers) == 0 {
+				return Config{}, fmt.Errorf("xray JSON: shadowsocks outbound has no servers")
+			}
+			server := ob.Settings.Servers[0]
+			cfg.Address = server.Address
+			cfg.Port = server.Port
+			cfg.Shadowsocks = ShadowsocksConfig{
+				Method:   server.Method,
+				Password: server.Password,
+				UoT:      server.UoT,
+			}
+		default:
+			continue
 		}
 
 		// Defaults.
-		if cfg.Encryption == "" {
+		if cfg.Outbound == OutboundVLESS && cfg.Encryption == "" {
 			cfg.Encryption = "none"
 		}
 		if cfg.Network == "" {
 			cfg.Network = "tcp"
 		}
+		if cfg.Security == "" {
+			cfg.Security = "none"
+		}
 
 		// Reality settings.
 		if ss.Security == "reality" {
@@ -422,17 +720,114 @@ func ParseXrayJSON(data []byte) (Config, error) {
 			}
 		}
 
+		// HTTPUpgrade settings.
+		if ss.Network == "httpupgrade" {
+			cfg.HTTPUpgrade = HTTPUpgradeConfig{
+				Path: ss.HTTPUpgradeSettings.Path,
+				Host: ss.HTTPUpgradeSettings.Host,
+			}
+		}
+
+		// Mux settings.
+		if ob.Mux.Enabled {
+			cfg.Mux = MuxConfig{
+				Enabled:         true,
+				Concurrency:     ob.Mux.Concurrency,
+				XUDPConcurrency: ob.Mux.XUDPConcurrency,
+				XUDPProxyUDP443: ob.Mux.XUDPProxyUDP443,
+			}
+		}
+
+		// Fragment/noise settings from the dialer proxy.
+		if tag := ss.Sockopt.DialerProxy; tag != "" {
+			for _, fo := range raw.Outbounds {
+				if fo.Tag != tag || fo.Protocol != "freedom" {
+					continue
+				}
+				if f := fo.Settings.Fragment; f != nil {
+					cfg.Fragment = FragmentConfig{
+						Packets:  f.Packets,
+						Length:   rangeString(f.Length),
+						Interval: rangeString(f.Interval),
+					}
+				}
+				for _, n := range fo.Settings.Noises {
+					cfg.Noises = append(cfg.Noises, NoiseConfig{
+						Type:   n.Type,
+						Packet: n.Packet,
+						Delay:  rangeString(n.Delay),
+					})
+				}
+			}
+		}
+
 		return cfg, nil
 	}
 
-	return Config{}, fmt.Errorf("xray JSON: no vless outbound found")
+	return Config{}, fmt.Errorf("xray JSON: no vless, vmess or shadowsocks outbound found")
+}
+
+// rangeString converts an xray Int32Range JSON value (10, "10" or "10-20")
+// to its string form.
+func rangeString(raw json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// ParseNoises parses the compact noise list used in tunnel settings:
+// entries separated by ";" or newlines, each "type:packet" with an
+// optional "@delay" suffix, e.g. "rand:10-20@10-16; str:hello".
+func ParseNoises(s string) ([]NoiseConfig, error) {
+	var noises []NoiseConfig
+	for _, entry := range strings.FieldsFunc(s, func(r rune) bool { return r == ';' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		typ, rest, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("noise %q: expected type:packet", entry)
+		}
+		typ = strings.TrimSpace(typ)
+		switch typ {
+		case "rand", "str", "hex", "base64":
+		default:
+			return nil, fmt.Errorf("noise %q: unknown type %q", entry, typ)
+		}
+		n := NoiseConfig{Type: typ, Packet: strings.TrimSpace(rest)}
+		if i := strings.LastIndex(n.Packet, "@"); i >= 0 {
+			n.Delay = strings.TrimSpace(n.Packet[i+1:])
+			n.Packet = strings.TrimSpace(n.Packet[:i])
+		}
+		if n.Packet == "" {
+			return nil, fmt.Errorf("noise %q: empty packet", entry)
+		}
+		noises = append(noises, n)
+	}
+	return noises, nil
+}
+
+// FormatNoises is the inverse of ParseNoises.
+func FormatNoises(noises []NoiseConfig) string {
+	parts := make([]string, 0, len(noises))
+	for _, n := range noises {
+		part := n.Type + ":" + n.Packet
+		if n.Delay != "" {
+			part += "@" + n.Delay
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "; ")
 }
 
 // ParseVLESSURI parses a vless:// share link into a Config and a display name.
 // Format: vless://UUID@host:port?params#name
 // Standard query params:
 //
-//	type      → network (tcp, ws, grpc, h2)
+//	type      → network (tcp, ws, grpc, xhttp, httpupgrade)
 //	encryption→ encryption (none)
 //	security  → security (reality, tls, none)
 //	flow      → flow (xtls-rprx-vision)
@@ -533,6 +928,11 @@ func ParseVLESSURI(uri string) (Config, string, error) {
 			Host: q.Get("host"),
 			Mode: q.Get("mode"),
 		}
+	case "httpupgrade":
+		cfg.HTTPUpgrade = HTTPUpgradeConfig{
+			Path: q.Get("path"),
+			Host: q.Get("host"),
+		}
 	}
 
 	// Fragment is the display name.
@@ -540,3 +940,108 @@ func ParseVLESSURI(uri string) (Config, string, error) {
 
 	return cfg, name, nil
 }
+
+// ParseVMessURI parses a vmess:// share link (v2rayN format: base64 of a
+// JSON object) into a Config and a display name. Fields:
+//
+//	ps    → name
+//	add   → address
+//	port  → port (number or string)
+//	id    → uuid
+//	scy   → vmess.security (cipher)
+//	net   → network (tcp, ws, grpc, xhttp, httpupgrade)
+//	host  → ws/xhttp/httpupgrade host header
+//	path  → ws/xhttp/httpupgrade path, grpc service name
+//	type  → xhttp mode
+//	tls   → security ("tls" or empty)
+//	sni, fp → tls.server_name, tls.fingerprint
+func ParseVMessURI(uri string) (Config, string, error) {
+	if !strings.HasPrefix(uri, "vmess://") {
+		return Config{}, "", fmt.Errorf("not a vmess:// URI")
+	}
+
+	payload := strings.TrimSpace(strings.TrimPrefix(uri, "vmess://"))
+	var data []byte
+	var err error
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding, base64.RawStdEncoding,
+		base64.URLEncoding, base64.RawURLEncoding,
+	} {
+		if data, err = enc.DecodeString(payload); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return Config{}, "", fmt.Errorf("vmess URI: invalid base64: %w", err)
+	}
+
+	var v struct {
+		PS   string          `json:"ps"`
+		Add  string          `json:"add"`
+		Port json.RawMessage `json:"port"`
+		ID   string          `json:"id"`
+		Scy  string          `json:"scy"`
+		Net  string          `json:"net"`
+		Type string          `json:"type"`
+		Host string          `json:"host"`
+		Path string          `json:"path"`
+		TLS  string          `json:"tls"`
+		SNI  string          `json:"sni"`
+		FP   string          `json:"fp"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Config{}, "", fmt.Errorf("vmess URI: parse JSON: %w", err)
+	}
+	if v.ID == "" {
+		return Config{}, "", fmt.Errorf("vmess URI: missing id")
+	}
+	if v.Add == "" {
+		return Config{}, "", fmt.Errorf("vmess URI: missing address")
+	}
+
+	port := 443
+	if p := rangeString(v.Port); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return Config{}, "", fmt.Errorf("vmess URI: invalid port %q: %w", p, err)
+		}
+		port = n
+	}
+
+	cfg := Config{
+		Outbound: OutboundVMess,
+		Address:  v.Add,
+		Port:     port,
+		UUID:     v.ID,
+		Network:  v.Net,
+		Security: "none",
+		VMess:    VMessConfig{Security: v.Scy},
+	}
+	if cfg.Network == "" {
+		cfg.Network = "tcp"
+	}
+	if v.TLS == "tls" {
+		cfg.Security = "tls"
+		cfg.TLS = TLSConfig{
+			ServerName:  v.SNI,
+			Fingerprint: v.FP,
+		}
+	}
+
+	switch cfg.Network {
+	case "ws":
+		cfg.WebSocket = WSConfig{Path: v.Path}
+		if v.Host != "" {
+			cfg.WebSocket.Headers = map[string]string{"Host": v.Host}
+		}
+	case "grpc":
+		cfg.GRPC = GRPCConfig{ServiceName: v.Path}
+	case "xhttp", "splithttp":
+		cfg.Network = "xhttp"
+		cfg.XHTTP = XHTTPConfig{Path: v.Path, Host: v.Host, Mode: v.Type}
+	case "httpupgrade":
+		cfg.HTTPUpgrade = HTTPUpgradeConfig{Path: v.Path, Host: v.Host}
+	}
+
+	return cfg, v.PS, nil
+}
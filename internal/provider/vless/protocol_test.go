@@ -0,0 +1,180 @@
+package vless
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+)
+
+func TestParseVMessURI(t *testing.T) {
+	link := `{"v":"2","ps":"Test VMess","add":"vm.example.com","port":"8443","id":"0b0b6f4c-7d7e-4a3a-9c1e-4b7e1f0f8e11","scy":"aes-128-gcm","net":"ws","host":"cdn.example.com","path":"/ws","tls":"tls","sni":"cdn.example.com","fp":"chrome"}`
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawURLEncoding} {
+		cfg, name, err := ParseVMessURI("vmess://" + enc.EncodeToString([]byte(link)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if name != "Test VMess" || cfg.Outbound != OutboundVMess || cfg.Address != "vm.example.com" ||
+			cfg.Port != 8443 || cfg.VMess.Security != "aes-128-gcm" || cfg.Network != "ws" ||
+			cfg.WebSocket.Path != "/ws" || cfg.WebSocket.Headers["Host"] != "cdn.example.com" ||
+			cfg.Security != "tls" || cfg.TLS.ServerName != "cdn.example.com" {
+			t.Errorf("cfg = %+v, name = %q", cfg, name)
+		}
+	}
+
+	// Numeric port and grpc service name in "path".
+	raw := base64.StdEncoding.EncodeToString([]byte(`{"add":"1.2.3.4","port":443,"id":"x","net":"grpc","path":"svc"}`))
+	tc, err := ParseURIToTunnelConfig("vmess://" + raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tc.Settings["outbound"] != OutboundVMess || tc.Settings["port"] != 443 ||
+		tc.Settings["grpc"].(map[string]any)["service_name"] != "svc" {
+		t.Errorf("settings = %v", tc.Settings)
+	}
+
+	for _, bad := range []string{"vless://x@y:1", "vmess://!!!", "vmess://" + base64.StdEncoding.EncodeToString([]byte(`{"add":"h"}`))} {
+		if _, _, err := ParseVMessURI(bad); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+}
+
+func TestNoisesRoundTrip(t *testing.T) {
+	noises, err := ParseNoises("rand:10-20@10-16;\nstr:hello ; hex:deadbeef")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(noises) != 3 || noises[0] != (NoiseConfig{Type: "rand", Packet: "10-20", Delay: "10-16"}) ||
+		noises[1] != (NoiseConfig{Type: "str", Packet: "hello"}) {
+		t.Fatalf("noises = %+v", noises)
+	}
+	again, err := ParseNoises(FormatNoises(noises))
+	if err != nil || len(again) != 3 || again[2] != noises[2] {
+		t.Errorf("round trip = %+v, %v", again, err)
+	}
+	for _, bad := range []string{"rand", "udp:1-2", "str:"} {
+		if _, err := ParseNoises(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+// outbounds decodes buildXrayJSON output and indexes outbounds by tag.
+func outbounds(t *testing.T, cfg Config) map[string]map[string]any {
+	t.Helper()
+	data, err := buildXrayJSON(cfg, 10800)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Outbounds []map[string]any `json:"outbounds"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	byTag := make(map[string]map[string]any)
+	for _, ob := range doc.Outbounds {
+		byTag[ob["tag"].(string)] = ob
+	}
+	return byTag
+}
+
+func TestBuildXrayJSONShadowsocksFragment(t *testing.T) {
+	obs := outbounds(t, Config{
+		Outbound:    OutboundShadowsocks,
+		Address:     "ss.example.com",
+		Port:        8388,
+		Network:     "httpupgrade",
+		HTTPUpgrade: HTTPUpgradeConfig{Path: "/up", Host: "h.example.com"},
+		Shadowsocks: ShadowsocksConfig{Method: "2022-blake3-aes-128-gcm", Password: "key", UoT: true},
+		Fragment:    FragmentConfig{Packets: "tlshello", Length: "100-200", Interval: "10-20"},
+		Noises:      []NoiseConfig{{Type: "rand", Packet: "10-20"}},
+	})
+
+	tcp := obs["vless-tcp"]
+	if tcp["protocol"] != OutboundShadowsocks {
+		t.Fatalf("protocol = %v", tcp["protocol"])
+	}
+	server := tcp["settings"].(map[string]any)["servers"].([]any)[0].(map[string]any)
+	if server["method"] != "2022-blake3-aes-128-gcm" || server["uot"] != true {
+		t.Errorf("server = %v", server)
+	}
+	stream := tcp["streamSettings"].(map[string]any)
+	if stream["security"] != "none" || stream["network"] != "httpupgrade" {
+		t.Errorf("stream = %v", stream)
+	}
+	if hu := stream["httpupgradeSettings"].(map[string]any); hu["path"] != "/up" || hu["host"] != "h.example.com" {
+		t.Errorf("httpupgradeSettings = %v", hu)
+	}
+	if sockopt := stream["sockopt"].(map[string]any); sockopt["dialerProxy"] != "fragment" {
+		t.Errorf("sockopt = %v", sockopt)
+	}
+
+	frag := obs["fragment"]
+	if frag == nil || frag["protocol"] != "freedom" {
+		t.Fatalf("fragment outbound = %v", frag)
+	}
+	settings := frag["settings"].(map[string]any)
+	if f := settings["fragment"].(map[string]any); f["packets"] != "tlshello" || f["length"] != "100-200" {
+		t.Errorf("fragment = %v", f)
+	}
+	if n := settings["noises"].([]any); len(n) != 1 {
+		t.Errorf("noises = %v", n)
+	}
+
+	// The generated config round-trips through ParseXrayJSON.
+	data, _ := buildXrayJSON(Config{
+		Outbound:    OutboundShadowsocks,
+		Address:     "ss.example.com",
+		Port:        8388,
+		Shadowsocks: ShadowsocksConfig{Method: "aes-256-gcm", Password: "pw"},
+		Fragment:    FragmentConfig{Packets: "1-3", Length: "5"},
+	}, 10800)
+	cfg, err := ParseXrayJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Outbound != OutboundShadowsocks || cfg.Shadowsocks.Password != "pw" ||
+		cfg.Fragment != (FragmentConfig{Packets: "1-3", Length: "5"}) {
+		t.Errorf("parsed = %+v", cfg)
+	}
+}
+
+func TestBuildXrayJSONMux(t *testing.T) {
+	base := Config{
+		Outbound: OutboundVMess,
+		Address:  "vm.example.com",
+		Port:     443,
+		UUID:     "0b0b6f4c-7d7e-4a3a-9c1e-4b7e1f0f8e11",
+		Network:  "tcp",
+		Mux:      MuxConfig{Enabled: true, XUDPProxyUDP443: "skip"},
+	}
+	obs := outbounds(t, base)
+	if obs["fragment"] != nil {
+		t.Error("unexpected fragment outbound")
+	}
+	user := obs["vless-tcp"]["settings"].(map[string]any)["vnext"].([]any)[0].(map[string]any)["users"].([]any)[0].(map[string]any)
+	if user["security"] != "auto" {
+		t.Errorf("vmess user = %v", user)
+	}
+	if mux := obs["vless-tcp"]["mux"].(map[string]any); mux["enabled"] != true || mux["concurrency"] != 8.0 {
+		t.Errorf("tcp mux = %v", mux)
+	}
+	if mux := obs["vless-udp"]["mux"].(map[string]any); mux["concurrency"] != -1.0 ||
+		mux["xudpConcurrency"] != 16.0 || mux["xudpProxyUDP443"] != "skip" {
+		t.Errorf("udp mux = %v", mux)
+	}
+
+	// XTLS Vision only allows XUDP.
+	vision := base
+	vision.Outbound = OutboundVLESS
+	vision.Flow = "xtls-rprx-vision"
+	vision.Encryption = "none"
+	obs = outbounds(t, vision)
+	if mux := obs["vless-tcp"]["mux"].(map[string]any); mux["enabled"] != false {
+		t.Errorf("vision tcp mux = %v", mux)
+	}
+	if mux := obs["vless-udp"]["mux"].(map[string]any); mux["enabled"] != true {
+		t.Errorf("vision udp mux = %v", mux)
+	}
+}
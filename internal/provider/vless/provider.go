@@ -95,8 +95,17 @@ func New(name string, cfg Config) (*Provider, error) {
 	if cfg.Port <= 0 || cfg.Port > 65535 {
 		return nil, fmt.Errorf("[VLESS] invalid port %d", cfg.Port)
 	}
-	if cfg.UUID == "" {
-		return nil, fmt.Errorf("[VLESS] UUID is required")
+	switch cfg.outbound() {
+	case OutboundVLESS, OutboundVMess:
+		if cfg.UUID == "" {
+			return nil, fmt.Errorf("[VLESS] UUID is required")
+		}
+	case OutboundShadowsocks:
+		if cfg.Shadowsocks.Method == "" || cfg.Shadowsocks.Password == "" {
+			return nil, fmt.Errorf("[VLESS] shadowsocks method and password are required")
+		}
+	default:
+		return nil, fmt.Errorf("[VLESS] unsupported outbound %q", cfg.Outbound)
 	}
 
 	return &Provider{
@@ -114,7 +123,7 @@ func (p *Provider) Connect(ctx context.Context) error {
 	p.state = core.TunnelStateConnecting
 	// Resolve server address for bypass routes.
 	serverStr := net.JoinHostPort(p.config.Address, fmt.Sprintf("%d", p.config.Port))
-	core.Log.Infof("VLESS", "Connecting tunnel %q to %s...", p.name, serverStr)
+	core.Log.Infof("VLESS", "Connecting tunnel %q to %s (%s)...", p.name, serverStr, p.config.outbound())
 	if ap, err := netip.ParseAddrPort(serverStr); err == nil {
 		p.serverAddr = ap
 	} else {
@@ -1,13 +1,25 @@
 package vless
 
 import (
+	"strings"
+
 	"awg-split-tunnel/internal/core"
 )
 
-// ParseURIToTunnelConfig parses a vless:// URI and converts it into a
-// core.TunnelConfig suitable for use by the subscription manager.
+// IsURI reports whether s is a share link handled by this provider
+// (vless:// or vmess://).
+func IsURI(s string) bool {
+	return strings.HasPrefix(s, "vless://") || strings.HasPrefix(s, "vmess://")
+}
+
+// ParseURIToTunnelConfig parses a vless:// or vmess:// URI and converts it
+// into a core.TunnelConfig suitable for use by the subscription manager.
 func ParseURIToTunnelConfig(uri string) (core.TunnelConfig, error) {
-	cfg, name, err := ParseVLESSURI(uri)
+	parse := ParseVLESSURI
+	if strings.HasPrefix(uri, "vmess://") {
+		parse = ParseVMessURI
+	}
+	cfg, name, err := parse(uri)
 	if err != nil {
 		return core.TunnelConfig{}, err
 	}
@@ -24,20 +36,41 @@ func ParseURIToTunnelConfig(uri string) (core.TunnelConfig, error) {
 	return tc, nil
 }
 
-// ConfigToSettings converts a parsed VLESS Config to a map[string]any
+// ConfigToSettings converts a parsed Config to a map[string]any
 // suitable for use as TunnelConfig.Settings.
 func ConfigToSettings(cfg Config) map[string]any {
 	settings := map[string]any{
-		"address":    cfg.Address,
-		"port":       cfg.Port,
-		"uuid":       cfg.UUID,
-		"encryption": cfg.Encryption,
-		"network":    cfg.Network,
-		"security":   cfg.Security,
+		"address":  cfg.Address,
+		"port":     cfg.Port,
+		"network":  cfg.Network,
+		"security": cfg.Security,
 	}
 
-	if cfg.Flow != "" {
-		settings["flow"] = cfg.Flow
+	switch cfg.outbound() {
+	case OutboundVLESS:
+		settings["uuid"] = cfg.UUID
+		settings["encryption"] = cfg.Encryption
+		if cfg.Flow != "" {
+			settings["flow"] = cfg.Flow
+		}
+	case OutboundVMess:
+		settings["outbound"] = OutboundVMess
+		settings["uuid"] = cfg.UUID
+		if cfg.VMess.Security != "" {
+			settings["vmess"] = map[string]any{
+				"security": cfg.VMess.Security,
+			}
+		}
+	case OutboundShadowsocks:
+		settings["outbound"] = OutboundShadowsocks
+		shadowsocks := map[string]any{
+			"method":   cfg.Shadowsocks.Method,
+			"password": cfg.Shadowsocks.Password,
+		}
+		if cfg.Shadowsocks.UoT {
+			shadowsocks["uot"] = true
+		}
+		settings["shadowsocks"] = shadowsocks
 	}
 
 	// Reality settings.
@@ -112,6 +145,50 @@ func ConfigToSettings(cfg Config) map[string]any {
 		}
 	}
 
+	// HTTPUpgrade settings.
+	if cfg.Network == "httpupgrade" {
+		httpupgrade := map[string]any{}
+		if cfg.HTTPUpgrade.Path != "" {
+			httpupgrade["path"] = cfg.HTTPUpgrade.Path
+		}
+		if cfg.HTTPUpgrade.Host != "" {
+			httpupgrade["host"] = cfg.HTTPUpgrade.Host
+		}
+		if len(httpupgrade) > 0 {
+			settings["httpupgrade"] = httpupgrade
+		}
+	}
+
+	// Mux settings.
+	if cfg.Mux.Enabled {
+		mux := map[string]any{"enabled": true}
+		if cfg.Mux.Concurrency != 0 {
+			mux["concurrency"] = cfg.Mux.Concurrency
+		}
+		if cfg.Mux.XUDPConcurrency != 0 {
+			mux["xudp_concurrency"] = cfg.Mux.XUDPConcurrency
+		}
+		if cfg.Mux.XUDPProxyUDP443 != "" {
+			mux["xudp_proxy_udp443"] = cfg.Mux.XUDPProxyUDP443
+		}
+		settings["mux"] = mux
+	}
+
+	// Fragment and noise settings.
+	if cfg.Fragment.Packets != "" {
+		fragment := map[string]any{"packets": cfg.Fragment.Packets}
+		if cfg.Fragment.Length != "" {
+			fragment["length"] = cfg.Fragment.Length
+		}
+		if cfg.Fragment.Interval != "" {
+			fragment["interval"] = cfg.Fragment.Interval
+		}
+		settings["fragment"] = fragment
+	}
+	if len(cfg.Noises) > 0 {
+		settings["noises"] = FormatNoises(cfg.Noises)
+	}
+
 	return settings
 }
 
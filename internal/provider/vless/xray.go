@@ -14,6 +14,11 @@ import (
 	// VLESS outbound protocol.
 	_ "github.com/xtls/xray-core/proxy/vless/outbound"
 
+	// VMess and Shadowsocks (classic AEAD and 2022) outbounds.
+	_ "github.com/xtls/xray-core/proxy/shadowsocks"
+	_ "github.com/xtls/xray-core/proxy/shadowsocks_2022"
+	_ "github.com/xtls/xray-core/proxy/vmess/outbound"
+
 	// Freedom outbound (direct, needed for routing fallback).
 	_ "github.com/xtls/xray-core/proxy/freedom"
 
@@ -30,4 +35,7 @@ import (
 
 	// Transport: XHTTP / SplitHTTP (optional, for xhttp transport).
 	_ "github.com/xtls/xray-core/transport/internet/splithttp"
+
+	// Transport: HTTPUpgrade (optional, for httpupgrade transport).
+	_ "github.com/xtls/xray-core/transport/internet/httpupgrade"
 )
@@ -0,0 +1,51 @@
+package wgconf
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+const keyLen = 32
+
+// GenerateKeyPair returns a new base64 Curve25519 private key (clamped, as
+// `wg genkey` does) and its public key.
+func GenerateKeyPair() (privateKey, publicKey string, err error) {
+	var k [keyLen]byte
+	if _, err := rand.Read(k[:]); err != nil {
+		return "", "", err
+	}
+	k[0] &= 248
+	k[31] = (k[31] & 127) | 64
+	privateKey = base64.StdEncoding.EncodeToString(k[:])
+	publicKey, err = PublicKey(privateKey)
+	return privateKey, publicKey, err
+}
+
+// PublicKey derives the base64 public key from a base64 private key.
+func PublicKey(privateKey string) (string, error) {
+	raw, err := decodeKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(raw)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(priv.PublicKey().Bytes()), nil
+}
+
+func decodeKey(b64 string) ([]byte, error) {
+	if b64 == "" {
+		return nil, fmt.Errorf("missing key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(raw) != keyLen {
+		return nil, fmt.Errorf("key must be %d bytes, got %d", keyLen, len(raw))
+	}
+	return raw, nil
+}
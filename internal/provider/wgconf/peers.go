@@ -0,0 +1,367 @@
+package wgconf
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/provider"
+)
+
+// maxPeerPins bounds the per-destination pins of a PeerRouter; the oldest
+// pin is dropped when the limit is reached.
+const maxPeerPins = 4096
+
+// Peer is one [Peer] section of a running tunnel, as routed by PeerRouter.
+type Peer struct {
+	PublicKey  string // hex, as used by UAPI
+	Name       string // from a "# Name = ..." comment; may be empty
+	AllowedIPs []netip.Prefix
+}
+
+// ID returns the selector that identifies the peer in rules
+// ("tunnel_id/ID"): its name, or its 1-based position in the config.
+func (p Peer) ID(index int) string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return strconv.Itoa(index + 1)
+}
+
+// PeerRouter decides which peer of a multi-peer tunnel carries each
+// AllowedIPs prefix. WireGuard's cryptokey routing gives every prefix to
+// exactly one peer, so for prefixes listed by several peers PeerRouter picks
+// the first healthy one in config order and moves the prefix when that peer
+// goes stale. Destinations can also be pinned to a specific peer; a pin is
+// a host prefix that wins by longest match.
+//
+// Changes are pushed to the device as UAPI text through the apply callback
+// (normally Device.IpcSet), called with the router's lock held so updates
+// reach the device in order.
+type PeerRouter struct {
+	mu       sync.Mutex
+	apply    func(uapi string) error
+	peers    []Peer
+	stale    []bool
+	pins     map[netip.Addr]int // destination → peer index
+	pinOrder []netip.Addr       // insertion order, for eviction
+	applied  [][]netip.Prefix   // allowed IPs currently set per peer
+}
+
+// NewPeerRouter creates a router for peers as configured by the initial
+// UAPI config, in which a prefix listed twice belongs to the last peer.
+// Call Apply once after the device is configured to hand shared prefixes
+// to the first peer instead.
+func NewPeerRouter(peers []Peer, apply func(uapi string) error) *PeerRouter {
+	r := &PeerRouter{
+		apply:   apply,
+		peers:   peers,
+		stale:   make([]bool, len(peers)),
+		pins:    make(map[netip.Addr]int),
+		applied: make([][]netip.Prefix, len(peers)),
+	}
+	owner := make(map[netip.Prefix]int)
+	for i, p := range peers {
+		for _, pfx := range p.AllowedIPs {
+			owner[pfx.Masked()] = i
+		}
+	}
+	for i, p := range peers {
+		for _, pfx := range p.AllowedIPs {
+			if owner[pfx.Masked()] == i {
+				r.applied[i] = appendPrefix(r.applied[i], pfx.Masked())
+			}
+		}
+	}
+	return r
+}
+
+// Lookup resolves a peer selector: a peer name (case-insensitive), a 1-based
+// index, or the peer's public key in base64 or hex.
+func (r *PeerRouter) Lookup(sel string) (int, bool) {
+	for i, p := range r.peers {
+		if p.Name != "" && strings.EqualFold(p.Name, sel) {
+			return i, true
+		}
+	}
+	if n, err := strconv.Atoi(sel); err == nil && n >= 1 && n <= len(r.peers) {
+		return n - 1, true
+	}
+	key := strings.ToLower(sel)
+	if raw, err := base64.StdEncoding.DecodeString(sel); err == nil && len(raw) == keyLen {
+		key = hex.EncodeToString(raw)
+	}
+	for i, p := range r.peers {
+		if p.PublicKey == key {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Peers returns the configured peers.
+func (r *PeerRouter) Peers() []Peer {
+	return r.peers
+}
+
+// Status reports whether peer i is stale and whether it currently carries
+// any prefix or pinned destination.
+func (r *PeerRouter) Status(i int) (stale, active bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stale[i], len(r.applied[i]) > 0
+}
+
+// PeerInfo combines the routing state with the device's IpcGet output.
+func (r *PeerRouter) PeerInfo(ipc string) []provider.PeerInfo {
+	status := make(map[string]PeerStatus)
+	for _, st := range ParsePeerStatus(ipc) {
+		status[st.PublicKey] = st
+	}
+	out := make([]provider.PeerInfo, 0, len(r.peers))
+	for i, p := range r.peers {
+		st := status[p.PublicKey]
+		stale, active := r.Status(i)
+		pub := p.PublicKey
+		if raw, err := hex.DecodeString(p.PublicKey); err == nil {
+			pub = base64.StdEncoding.EncodeToString(raw)
+		}
+		out = append(out, provider.PeerInfo{
+			ID:            p.ID(i),
+			PublicKey:     pub,
+			Endpoint:      st.Endpoint,
+			AllowedIPs:    p.AllowedIPs,
+			LastHandshake: st.LastHandshake,
+			RxBytes:       st.RxBytes,
+			TxBytes:       st.TxBytes,
+			Stale:         stale,
+			Active:        active,
+		})
+	}
+	return out
+}
+
+// Pin routes dst through the selected peer. It is a no-op when dst is
+// already pinned there.
+func (r *PeerRouter) Pin(sel string, dst netip.Addr) error {
+	i, ok := r.Lookup(sel)
+	if !ok {
+		return fmt.Errorf("unknown peer %q", sel)
+	}
+	dst = dst.Unmap()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cur, ok := r.pins[dst]; ok && cur == i {
+		return nil
+	} else if !ok {
+		r.pinOrder = append(r.pinOrder, dst)
+		if len(r.pinOrder) > maxPeerPins {
+			delete(r.pins, r.pinOrder[0])
+			r.pinOrder = r.pinOrder[1:]
+		}
+	}
+	r.pins[dst] = i
+	_, err := r.applyLocked()
+	return err
+}
+
+// SetStale marks the peers with the given hex public keys as stale and all
+// others as healthy, moving routes off stale peers (and back once they
+// recover). Returns true if any route moved.
+func (r *PeerRouter) SetStale(publicKeys []string) (bool, error) {
+	staleSet := make(map[string]bool, len(publicKeys))
+	for _, k := range publicKeys {
+		staleSet[k] = true
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.peers {
+		r.stale[i] = staleSet[p.PublicKey]
+	}
+	return r.applyLocked()
+}
+
+// Apply pushes the desired routing to the device if it differs from what
+// was last applied.
+func (r *PeerRouter) Apply() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err := r.applyLocked()
+	return err
+}
+
+func (r *PeerRouter) applyLocked() (bool, error) {
+	want := make([][]netip.Prefix, len(r.peers))
+	assigned := make(map[netip.Prefix]bool)
+	// Pins first: a pin overrides a host prefix listed in the config.
+	for dst, i := range r.pins {
+		pfx := netip.PrefixFrom(dst, dst.BitLen())
+		assigned[pfx] = true
+		owner := r.owner(pfx, []int{i})
+		want[owner] = appendPrefix(want[owner], pfx)
+	}
+	for _, p := range r.peers {
+		for _, pfx := range p.AllowedIPs {
+			pfx = pfx.Masked()
+			if assigned[pfx] {
+				continue
+			}
+			assigned[pfx] = true
+			owner := r.owner(pfx, r.listing(pfx))
+			want[owner] = appendPrefix(want[owner], pfx)
+		}
+	}
+
+	var uapi strings.Builder
+	for i := range r.peers {
+		if samePrefixes(want[i], r.applied[i]) {
+			continue
+		}
+		fmt.Fprintf(&uapi, "public_key=%s\nupdate_only=true\nreplace_allowed_ips=true\n", r.peers[i].PublicKey)
+		for _, pfx := range want[i] {
+			fmt.Fprintf(&uapi, "allowed_ip=%s\n", pfx)
+		}
+	}
+	if uapi.Len() == 0 {
+		return false, nil
+	}
+	if err := r.apply(uapi.String()); err != nil {
+		return false, fmt.Errorf("apply peer routes: %w", err)
+	}
+	r.applied = want
+	return true, nil
+}
+
+// listing returns the peers that list pfx exactly, in config order.
+func (r *PeerRouter) listing(pfx netip.Prefix) []int {
+	var out []int
+	for i, p := range r.peers {
+		for _, q := range p.AllowedIPs {
+			if q.Masked() == pfx {
+				out = append(out, i)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// owner picks the peer for pfx: the first healthy preferred peer, else the
+// first healthy peer whose AllowedIPs cover pfx, else the first preferred
+// peer (nothing better is available, so leave the route where it was).
+func (r *PeerRouter) owner(pfx netip.Prefix, preferred []int) int {
+	for _, i := range preferred {
+		if !r.stale[i] {
+			return i
+		}
+	}
+	for i, p := range r.peers {
+		if r.stale[i] {
+			continue
+		}
+		for _, q := range p.AllowedIPs {
+			if q.Bits() <= pfx.Bits() && q.Contains(pfx.Addr()) {
+				return i
+			}
+		}
+	}
+	return preferred[0]
+}
+
+func appendPrefix(list []netip.Prefix, pfx netip.Prefix) []netip.Prefix {
+	for _, p := range list {
+		if p == pfx {
+			return list
+		}
+	}
+	return append(list, pfx)
+}
+
+func samePrefixes(a, b []netip.Prefix) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[netip.Prefix]bool, len(a))
+	for _, p := range a {
+		set[p] = true
+	}
+	for _, p := range b {
+		if !set[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// ---------------------------------------------------------------------------
+// UAPI status
+// ---------------------------------------------------------------------------
+
+// PeerStatus is the runtime state of one peer as reported by IpcGet.
+type PeerStatus struct {
+	PublicKey         string // hex
+	Endpoint          string
+	LastHandshake     time.Time // zero if no handshake completed
+	RxBytes           uint64
+	TxBytes           uint64
+	KeepaliveInterval int // seconds; 0 = disabled
+}
+
+// ParsePeerStatus extracts per-peer state from UAPI "get" output.
+func ParsePeerStatus(ipc string) []PeerStatus {
+	var (
+		out []PeerStatus
+		cur *PeerStatus
+	)
+	scanner := bufio.NewScanner(strings.NewReader(ipc))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		if key == "public_key" {
+			out = append(out, PeerStatus{PublicKey: value})
+			cur = &out[len(out)-1]
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		switch key {
+		case "endpoint":
+			cur.Endpoint = value
+		case "last_handshake_time_sec":
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil && n > 0 {
+				cur.LastHandshake = time.Unix(n, 0)
+			}
+		case "rx_bytes":
+			cur.RxBytes, _ = strconv.ParseUint(value, 10, 64)
+		case "tx_bytes":
+			cur.TxBytes, _ = strconv.ParseUint(value, 10, 64)
+		case "persistent_keepalive_interval":
+			cur.KeepaliveInterval, _ = strconv.Atoi(value)
+		}
+	}
+	return out
+}
+
+// StalePeers returns the hex public keys of the peers in UAPI "get" output
+// whose last handshake is older than threshold (or never completed). Peers
+// without persistent keepalive are skipped: an idle peer does not handshake.
+func StalePeers(ipc string, now time.Time, threshold time.Duration) []string {
+	var stale []string
+	for _, st := range ParsePeerStatus(ipc) {
+		if st.KeepaliveInterval > 0 && (st.LastHandshake.IsZero() || now.Sub(st.LastHandshake) > threshold) {
+			stale = append(stale, st.PublicKey)
+		}
+	}
+	return stale
+}
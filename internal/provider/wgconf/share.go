@@ -0,0 +1,256 @@
+package wgconf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// maxAmneziaPayload caps the decompressed size of a vpn:// payload. Real
+// exports are a few KiB; vpn:// links also arrive from subscription feeds,
+// so a zlib bomb must not be inflated into memory.
+const maxAmneziaPayload = 1 << 20
+
+// IsShareURI reports whether s is a share string Decode understands
+// (as opposed to raw .conf text).
+func IsShareURI(s string) bool {
+	s = strings.TrimSpace(s)
+	return strings.HasPrefix(s, "wg://") || strings.HasPrefix(s, "wireguard://") || strings.HasPrefix(s, "vpn://")
+}
+
+// Decode accepts any supported representation — .conf text, a wg:// or
+// wireguard:// link, an AmneziaVPN vpn:// string, or the text content of a
+// QR code carrying one of those — and returns the config and a display name
+// (empty if the payload has none).
+func Decode(payload string) (*Config, string, error) {
+	payload = strings.TrimSpace(payload)
+	switch {
+	case strings.HasPrefix(payload, "wg://"), strings.HasPrefix(payload, "wireguard://"):
+		return parseWireGuardURI(payload)
+	case strings.HasPrefix(payload, "vpn://"):
+		return parseAmneziaVPN(payload)
+	case strings.Contains(payload, "[Interface]"):
+		cfg, err := Parse(payload)
+		return cfg, "", err
+	}
+	return nil, "", fmt.Errorf("unrecognized WireGuard config format")
+}
+
+// TunnelConfig wraps a decoded config into a tunnel definition that stores it
+// inline. The protocol is amneziawg when obfuscation parameters are present.
+func TunnelConfig(cfg *Config, name string) core.TunnelConfig {
+	protocol := core.ProtocolWireGuard
+	if cfg.IsAmnezia() {
+		protocol = core.ProtocolAmneziaWG
+	}
+	return core.TunnelConfig{
+		Protocol: protocol,
+		Name:     name,
+		Settings: map[string]any{"config": cfg.String()},
+	}
+}
+
+// ParseURIToTunnelConfig parses a wg://, wireguard:// or vpn:// share string
+// into an inline tunnel definition for the subscription manager.
+func ParseURIToTunnelConfig(uri string) (core.TunnelConfig, error) {
+	cfg, name, err := Decode(uri)
+	if err != nil {
+		return core.TunnelConfig{}, err
+	}
+	return TunnelConfig(cfg, name), nil
+}
+
+// parseWireGuardURI parses the share link format used by v2rayN, sing-box
+// and Hiddify:
+//
+//	wireguard://PRIVATE_KEY@host:port?publickey=...&address=...&mtu=...#name
+//
+// AmneziaWG parameters (jc, jmin, ..., i5) are accepted as extra query keys.
+func parseWireGuardURI(uri string) (*Config, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, "", fmt.Errorf("parse URI: %w", err)
+	}
+	if u.User == nil {
+		return nil, "", fmt.Errorf("missing private key")
+	}
+	priv := u.User.Username()
+	q := u.Query()
+	// Base64 keys contain '+', which generators often leave unescaped in
+	// the query, where it decodes to a space.
+	get := func(keys ...string) string {
+		for _, k := range keys {
+			if v := q.Get(k); v != "" {
+				return strings.ReplaceAll(v, " ", "+")
+			}
+		}
+		return ""
+	}
+
+	cfg := &Config{}
+	cfg.Interface.Set("PrivateKey", priv)
+	cfg.Interface.Set("Address", get("address", "ip", "local_address"))
+	cfg.Interface.Set("DNS", get("dns"))
+	cfg.Interface.Set("MTU", get("mtu"))
+	for _, k := range amneziaKeys {
+		cfg.Interface.Set(k, get(strings.ToLower(k)))
+	}
+
+	var peer Section
+	peer.Set("PublicKey", get("publickey", "public_key", "peer_public_key"))
+	peer.Set("PresharedKey", get("presharedkey", "preshared_key", "psk"))
+	peer.Set("Endpoint", u.Host)
+	allowed := get("allowedips", "allowed_ips")
+	if allowed == "" {
+		allowed = "0.0.0.0/0, ::/0"
+	}
+	peer.Set("AllowedIPs", allowed)
+	peer.Set("PersistentKeepalive", get("keepalive", "persistent_keepalive"))
+	cfg.Peers = []Section{peer}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, "", err
+	}
+	return cfg, u.Fragment, nil
+}
+
+// amneziaExport is the subset of an AmneziaVPN server export we use.
+type amneziaExport struct {
+	Containers []struct {
+		Container string        `json:"container"`
+		AWG       *amneziaProto `json:"awg"`
+		WireGuard *amneziaProto `json:"wireguard"`
+	} `json:"containers"`
+	DefaultContainer string `json:"defaultContainer"`
+	Description      string `json:"description"`
+	HostName         string `json:"hostName"`
+	DNS1             string `json:"dns1"`
+	DNS2             string `json:"dns2"`
+}
+
+type amneziaProto struct {
+	LastConfig string `json:"last_config"` // JSON document encoded as a string
+	Port       string `json:"port"`
+}
+
+// parseAmneziaVPN decodes an AmneziaVPN share string: "vpn://" followed by
+// base64url of a Qt qCompress blob (4-byte big-endian size + zlib) or of
+// plain JSON. The WireGuard/AWG container's last_config carries the .conf.
+func parseAmneziaVPN(s string) (*Config, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(strings.TrimPrefix(s, "vpn://"), "="))
+	if err != nil {
+		return nil, "", fmt.Errorf("vpn:// payload: %w", err)
+	}
+	if len(data) > 4 && data[0] != '{' {
+		size := binary.BigEndian.Uint32(data[:4])
+		if size > maxAmneziaPayload {
+			return nil, "", fmt.Errorf("vpn:// payload: declared size %d exceeds %d bytes", size, maxAmneziaPayload)
+		}
+		zr, err := zlib.NewReader(bytes.NewReader(data[4:]))
+		if err != nil {
+			return nil, "", fmt.Errorf("vpn:// payload: %w", err)
+		}
+		data, err = io.ReadAll(io.LimitReader(zr, int64(size)+1))
+		zr.Close()
+		if err != nil {
+			return nil, "", fmt.Errorf("vpn:// payload: %w", err)
+		}
+		if len(data) > int(size) {
+			return nil, "", fmt.Errorf("vpn:// payload: larger than its declared size %d", size)
+		}
+	}
+
+	var exp amneziaExport
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return nil, "", fmt.Errorf("vpn:// JSON: %w", err)
+	}
+
+	var proto *amneziaProto
+	for _, c := range exp.Containers {
+		p := c.AWG
+		if p == nil {
+			p = c.WireGuard
+		}
+		if p == nil {
+			continue
+		}
+		if proto == nil || c.Container == exp.DefaultContainer {
+			proto = p
+		}
+	}
+	if proto == nil || proto.LastConfig == "" {
+		return nil, "", fmt.Errorf("vpn:// export has no WireGuard/AmneziaWG container")
+	}
+
+	var last map[string]any
+	if err := json.Unmarshal([]byte(proto.LastConfig), &last); err != nil {
+		return nil, "", fmt.Errorf("vpn:// last_config: %w", err)
+	}
+
+	text, _ := last["config"].(string)
+	if text == "" {
+		text = amneziaConfigFromFields(last, exp.HostName, proto.Port)
+	}
+	dns1, dns2 := exp.DNS1, exp.DNS2
+	if dns1 == "" {
+		dns1 = "1.1.1.1"
+	}
+	if dns2 == "" {
+		dns2 = "1.0.0.1"
+	}
+	text = strings.NewReplacer("$PRIMARY_DNS", dns1, "$SECONDARY_DNS", dns2).Replace(text)
+
+	cfg, err := Parse(text)
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, exp.Description, nil
+}
+
+// amneziaConfigFromFields rebuilds .conf text from the individual
+// last_config fields of exports that omit the rendered config.
+func amneziaConfigFromFields(f map[string]any, host, port string) string {
+	str := func(k string) string {
+		if v, ok := f[k]; ok && v != nil {
+			return settingString(v)
+		}
+		return ""
+	}
+	if h := str("hostName"); h != "" {
+		host = h
+	}
+	if p := str("port"); p != "" {
+		port = p
+	}
+
+	cfg := &Config{}
+	cfg.Interface.Set("PrivateKey", str("client_priv_key"))
+	cfg.Interface.Set("Address", str("client_ip"))
+	cfg.Interface.Set("DNS", "$PRIMARY_DNS, $SECONDARY_DNS")
+	cfg.Interface.Set("MTU", str("mtu"))
+	for _, k := range amneziaKeys {
+		cfg.Interface.Set(k, str(k))
+	}
+	var peer Section
+	peer.Set("PublicKey", str("server_pub_key"))
+	peer.Set("PresharedKey", str("psk_key"))
+	if host != "" && port != "" {
+		peer.Set("Endpoint", host+":"+port)
+	}
+	allowed := str("allowed_ips")
+	if allowed == "" {
+		allowed = "0.0.0.0/0, ::/0"
+	}
+	peer.Set("AllowedIPs", allowed)
+	peer.Set("PersistentKeepalive", str("persistent_keep_alive"))
+	cfg.Peers = []Section{peer}
+	return cfg.String()
+}
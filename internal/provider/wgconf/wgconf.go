@@ -0,0 +1,276 @@
+// Package wgconf converts between the representations of a WireGuard /
+// AmneziaWG tunnel definition: .conf text, structured tunnel settings and
+// share strings (wg://, wireguard://, AmneziaVPN vpn://). The providers
+// still parse the resulting .conf text with their own ParseConfigFile logic.
+// It also holds the per-peer routing shared by both providers (PeerRouter).
+package wgconf
+
+import (
+	"bufio"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// KeyValue is a single "Key = Value" line of a .conf section.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Section is an ordered list of key/value lines. Keys are matched
+// case-insensitively, as wg-quick does.
+type Section []KeyValue
+
+// Get returns the value of key, or "" if it is not set.
+func (s Section) Get(key string) string {
+	for _, kv := range s {
+		if strings.EqualFold(kv.Key, key) {
+			return kv.Value
+		}
+	}
+	return ""
+}
+
+// Set replaces the value of key or appends it. Empty values are ignored.
+func (s *Section) Set(key, value string) {
+	if value == "" {
+		return
+	}
+	for i, kv := range *s {
+		if strings.EqualFold(kv.Key, key) {
+			(*s)[i].Value = value
+			return
+		}
+	}
+	*s = append(*s, KeyValue{Key: key, Value: value})
+}
+
+// Config is a WireGuard/AmneziaWG .conf file. Keys keep their original order
+// and unknown keys pass through, so obfuscation parameters survive a round trip.
+type Config struct {
+	Interface Section
+	Peers     []Section
+}
+
+// amneziaKeys are the [Interface] keys that only AmneziaWG understands.
+var amneziaKeys = []string{
+	"Jc", "Jmin", "Jmax", "S1", "S2", "S3", "S4",
+	"H1", "H2", "H3", "H4", "I1", "I2", "I3", "I4", "I5",
+}
+
+// AmneziaKey returns the .conf spelling of an AmneziaWG parameter given in
+// any case (e.g. "jmin" → "Jmin"), or false if key is not one.
+func AmneziaKey(key string) (string, bool) {
+	for _, k := range amneziaKeys {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// Parse reads .conf text. Comments, blank lines and WireSock "@" extension
+// lines are dropped. The interface must have a PrivateKey and every peer a
+// PublicKey, both valid base64 keys.
+func Parse(text string) (*Config, error) {
+	cfg := &Config{}
+	var cur *Section
+	section := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(strings.TrimPrefix(text, "\xEF\xBB\xBF")))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			section = strings.ToLower(strings.Trim(line, "[] "))
+			switch section {
+			case "interface":
+				cur = &cfg.Interface
+			case "peer":
+				cfg.Peers = append(cfg.Peers, nil)
+				cur = &cfg.Peers[len(cfg.Peers)-1]
+			default:
+				cur = nil
+			}
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 || cur == nil {
+			continue
+		}
+		*cur = append(*cur, KeyValue{Key: strings.TrimSpace(parts[0]), Value: strings.TrimSpace(parts[1])})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks the keys every usable config needs.
+func (c *Config) Validate() error {
+	if _, err := decodeKey(c.Interface.Get("PrivateKey")); err != nil {
+		return fmt.Errorf("[Interface] PrivateKey: %w", err)
+	}
+	if len(c.Peers) == 0 {
+		return fmt.Errorf("no [Peer] section")
+	}
+	for i, p := range c.Peers {
+		if _, err := decodeKey(p.Get("PublicKey")); err != nil {
+			return fmt.Errorf("[Peer] #%d PublicKey: %w", i+1, err)
+		}
+		if psk := p.Get("PresharedKey"); psk != "" {
+			if _, err := decodeKey(psk); err != nil {
+				return fmt.Errorf("[Peer] #%d PresharedKey: %w", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+// IsAmnezia reports whether the config uses AmneziaWG obfuscation parameters.
+func (c *Config) IsAmnezia() bool {
+	for _, k := range amneziaKeys {
+		if c.Interface.Get(k) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders the config as standard .conf text.
+func (c *Config) String() string {
+	var b strings.Builder
+	b.WriteString("[Interface]\n")
+	for _, kv := range c.Interface {
+		fmt.Fprintf(&b, "%s = %s\n", kv.Key, kv.Value)
+	}
+	for _, p := range c.Peers {
+		b.WriteString("\n[Peer]\n")
+		for _, kv := range p {
+			fmt.Fprintf(&b, "%s = %s\n", kv.Key, kv.Value)
+		}
+	}
+	return b.String()
+}
+
+// ---------------------------------------------------------------------------
+// Structured settings
+// ---------------------------------------------------------------------------
+
+// interfaceSettingKeys maps snake_case tunnel settings to .conf keys, in the
+// order they are written.
+var interfaceSettingKeys = []KeyValue{
+	{"private_key", "PrivateKey"},
+	{"address", "Address"},
+	{"dns", "DNS"},
+	{"mtu", "MTU"},
+	{"listen_port", "ListenPort"},
+	{"jc", "Jc"}, {"jmin", "Jmin"}, {"jmax", "Jmax"},
+	{"s1", "S1"}, {"s2", "S2"}, {"s3", "S3"}, {"s4", "S4"},
+	{"h1", "H1"}, {"h2", "H2"}, {"h3", "H3"}, {"h4", "H4"},
+	{"i1", "I1"}, {"i2", "I2"}, {"i3", "I3"}, {"i4", "I4"}, {"i5", "I5"},
+}
+
+var peerSettingKeys = []KeyValue{
+	{"public_key", "PublicKey"},
+	{"preshared_key", "PresharedKey"},
+	{"endpoint", "Endpoint"},
+	{"allowed_ips", "AllowedIPs"},
+	{"persistent_keepalive", "PersistentKeepalive"},
+}
+
+// HasSettings reports whether settings hold a structured interface/peers definition.
+func HasSettings(settings map[string]any) bool {
+	_, ok := settings["interface"]
+	return ok
+}
+
+// FromSettings builds a Config from structured tunnel settings:
+//
+//	interface: {private_key, address, dns, mtu, listen_port, jc, ..., i5}
+//	peers:     [{public_key, preshared_key, endpoint, allowed_ips, persistent_keepalive}]
+//
+// List values (address, dns, allowed_ips) may be YAML lists or comma-separated
+// strings. peers may also be a map keyed by index, as produced by the flat
+// dot-notation settings of the gRPC API ("peers.0.public_key").
+func FromSettings(settings map[string]any) (*Config, error) {
+	iface, ok := settings["interface"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("settings: interface must be a map")
+	}
+	cfg := &Config{Interface: sectionFromMap(iface, interfaceSettingKeys)}
+
+	var peers []map[string]any
+	switch v := settings["peers"].(type) {
+	case []any:
+		for _, p := range v {
+			m, ok := p.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("settings: peers entries must be maps")
+			}
+			peers = append(peers, m)
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			a, errA := strconv.Atoi(keys[i])
+			b, errB := strconv.Atoi(keys[j])
+			if errA == nil && errB == nil {
+				return a < b
+			}
+			return keys[i] < keys[j]
+		})
+		for _, k := range keys {
+			m, ok := v[k].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("settings: peers.%s must be a map", k)
+			}
+			peers = append(peers, m)
+		}
+	}
+	for _, p := range peers {
+		cfg.Peers = append(cfg.Peers, sectionFromMap(p, peerSettingKeys))
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func sectionFromMap(m map[string]any, keys []KeyValue) Section {
+	var s Section
+	for _, k := range keys {
+		if v, ok := m[k.Key]; ok {
+			s.Set(k.Value, settingString(v))
+		}
+	}
+	return s
+}
+
+func settingString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []any:
+		parts := make([]string, 0, len(val))
+		for _, e := range val {
+			parts = append(parts, fmt.Sprint(e))
+		}
+		return strings.Join(parts, ", ")
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,350 @@
+package wgconf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// Fake keys (valid base64-encoded 32-byte values for testing only).
+const (
+	testPrivateKey = "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE="
+	testPublicKey  = "YmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmI="
+)
+
+const testConf = `[Interface]
+PrivateKey = ` + testPrivateKey + `
+Address = 10.8.1.2/32
+DNS = 1.1.1.1
+Jc = 4
+
+[Peer]
+PublicKey = ` + testPublicKey + `
+Endpoint = vpn.example.com:51820
+AllowedIPs = 0.0.0.0/0
+`
+
+func TestParseRoundTrip(t *testing.T) {
+	cfg, err := Parse("\xEF\xBB\xBF# exported\n" + testConf + "@ws:AllowedApps = chrome\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.IsAmnezia() {
+		t.Error("Jc present, want IsAmnezia")
+	}
+	if got := cfg.String(); got != testConf {
+		t.Errorf("String() =\n%s\nwant\n%s", got, testConf)
+	}
+}
+
+func TestParseRejectsBadKeys(t *testing.T) {
+	for _, conf := range []string{
+		"[Interface]\nAddress = 10.0.0.2/32\n[Peer]\nPublicKey = " + testPublicKey + "\n",
+		"[Interface]\nPrivateKey = " + testPrivateKey + "\n",
+		"[Interface]\nPrivateKey = " + testPrivateKey + "\n[Peer]\nPublicKey = c2hvcnQ=\n",
+	} {
+		if _, err := Parse(conf); err == nil {
+			t.Errorf("Parse(%q) succeeded, want error", conf)
+		}
+	}
+}
+
+func TestFromSettings(t *testing.T) {
+	settings := map[string]any{
+		"interface": map[string]any{
+			"private_key": testPrivateKey,
+			"address":     []any{"10.8.1.2/32"},
+			"dns":         "1.1.1.1",
+			"jc":          4,
+		},
+		// Index-keyed map, as produced by the gRPC dot-notation settings.
+		"peers": map[string]any{
+			"0": map[string]any{
+				"public_key":  testPublicKey,
+				"endpoint":    "vpn.example.com:51820",
+				"allowed_ips": "0.0.0.0/0",
+			},
+		},
+	}
+	cfg, err := FromSettings(settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.String(); got != testConf {
+		t.Errorf("String() =\n%s\nwant\n%s", got, testConf)
+	}
+}
+
+func TestDecodeWireGuardURI(t *testing.T) {
+	uri := "wireguard://" + url.PathEscape(testPrivateKey) + "@198.51.100.1:51820" +
+		"?publickey=" + testPublicKey + "&address=10.0.0.2/32&mtu=1280&jc=3#My%20Server"
+
+	cfg, name, err := Decode(uri)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "My Server" {
+		t.Errorf("name = %q", name)
+	}
+	if cfg.Interface.Get("PrivateKey") != testPrivateKey || cfg.Peers[0].Get("PublicKey") != testPublicKey {
+		t.Errorf("keys not preserved:\n%s", cfg)
+	}
+	if cfg.Peers[0].Get("Endpoint") != "198.51.100.1:51820" || cfg.Interface.Get("MTU") != "1280" {
+		t.Errorf("config =\n%s", cfg)
+	}
+	if tc := TunnelConfig(cfg, name); tc.Protocol != core.ProtocolAmneziaWG {
+		t.Errorf("protocol = %q, want amneziawg", tc.Protocol)
+	}
+}
+
+func TestDecodeAmneziaVPN(t *testing.T) {
+	last, _ := json.Marshal(map[string]any{
+		"config": strings.Replace(testConf, "DNS = 1.1.1.1", "DNS = $PRIMARY_DNS, $SECONDARY_DNS", 1),
+	})
+	export, _ := json.Marshal(map[string]any{
+		"containers": []any{map[string]any{
+			"container": "amnezia-awg",
+			"awg":       map[string]any{"last_config": string(last), "port": "51820"},
+		}},
+		"defaultContainer": "amnezia-awg",
+		"description":      "Home",
+		"dns1":             "9.9.9.9",
+	})
+
+	// Qt qCompress framing: big-endian uncompressed size + zlib stream.
+	var blob bytes.Buffer
+	binary.Write(&blob, binary.BigEndian, uint32(len(export)))
+	zw := zlib.NewWriter(&blob)
+	zw.Write(export)
+	zw.Close()
+
+	cfg, name, err := Decode("vpn://" + base64.RawURLEncoding.EncodeToString(blob.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "Home" {
+		t.Errorf("name = %q", name)
+	}
+	if dns := cfg.Interface.Get("DNS"); dns != "9.9.9.9, 1.0.0.1" {
+		t.Errorf("DNS = %q", dns)
+	}
+	if cfg.Peers[0].Get("Endpoint") != "vpn.example.com:51820" {
+		t.Errorf("config =\n%s", cfg)
+	}
+}
+
+func TestDecodeAmneziaVPNSizeLimit(t *testing.T) {
+	qCompress := func(declared uint32, n int) string {
+		var blob bytes.Buffer
+		binary.Write(&blob, binary.BigEndian, declared)
+		zw := zlib.NewWriter(&blob)
+		zw.Write(bytes.Repeat([]byte{' '}, n))
+		zw.Close()
+		return "vpn://" + base64.RawURLEncoding.EncodeToString(blob.Bytes())
+	}
+
+	// Declared size over the cap: rejected before inflating.
+	if _, _, err := Decode(qCompress(64<<20, 10)); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("oversized declaration: err = %v", err)
+	}
+	// A small declaration hiding a large stream is not inflated past it.
+	if _, _, err := Decode(qCompress(100, 8<<20)); err == nil || !strings.Contains(err.Error(), "declared size") {
+		t.Errorf("stream larger than declared: err = %v", err)
+	}
+}
+
+func TestPublicKey(t *testing.T) {
+	// RFC 7748 section 6.1 test vector (Alice).
+	priv := base64.StdEncoding.EncodeToString(mustHex("77076d0a7318a57d3c16c17251b26645df4c2f87ebc0992ab177fba51db92c2a"))
+	want := base64.StdEncoding.EncodeToString(mustHex("8520f0098930a754748b7ddcb43ef75a0dbf3a0d26381af4eba4a98eaa9b4e6a"))
+	got, err := PublicKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("PublicKey = %s, want %s", got, want)
+	}
+
+	priv, pub, err := GenerateKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if derived, _ := PublicKey(priv); derived != pub {
+		t.Errorf("generated pair mismatch: %s vs %s", derived, pub)
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// recordApply returns an apply callback that stores the last UAPI text.
+func recordApply(last *string) func(string) error {
+	return func(uapi string) error {
+		*last = uapi
+		return nil
+	}
+}
+
+func TestPeerRouterFailover(t *testing.T) {
+	all := netip.MustParsePrefix("0.0.0.0/0")
+	office := netip.MustParsePrefix("10.1.0.0/16")
+	var got string
+	r := NewPeerRouter([]Peer{
+		{PublicKey: "aa", Name: "primary", AllowedIPs: []netip.Prefix{all}},
+		{PublicKey: "bb", AllowedIPs: []netip.Prefix{all, office}},
+	}, recordApply(&got))
+
+	// The device config gave the shared prefix to the last peer.
+	want := "public_key=aa\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=0.0.0.0/0\n" +
+		"public_key=bb\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=10.1.0.0/16\n"
+	if err := r.Apply(); err != nil || got != want {
+		t.Fatalf("Apply() = %v,\n%s\nwant\n%s", err, got, want)
+	}
+	got = ""
+	if r.Apply(); got != "" {
+		t.Errorf("second Apply() = %q, want no-op", got)
+	}
+
+	// Primary stale: its prefixes move to the second peer.
+	want = "public_key=aa\nupdate_only=true\nreplace_allowed_ips=true\n" +
+		"public_key=bb\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=0.0.0.0/0\nallowed_ip=10.1.0.0/16\n"
+	if moved, _ := r.SetStale([]string{"aa"}); !moved || got != want {
+		t.Errorf("SetStale(aa) = %v,\n%s\nwant\n%s", moved, got, want)
+	}
+
+	// Second peer stale instead: the office prefix falls back to the
+	// primary, whose 0.0.0.0/0 covers it.
+	r.SetStale([]string{"bb"})
+	if !strings.Contains(got, "public_key=aa\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=0.0.0.0/0\nallowed_ip=10.1.0.0/16\n") {
+		t.Errorf("SetStale(bb) =\n%s", got)
+	}
+	if stale, active := r.Status(1); !stale || active {
+		t.Errorf("Status(1) = %v, %v; want stale, inactive", stale, active)
+	}
+}
+
+func TestPeerRouterPin(t *testing.T) {
+	all := netip.MustParsePrefix("0.0.0.0/0")
+	var got string
+	r := NewPeerRouter([]Peer{
+		{PublicKey: "aa", AllowedIPs: []netip.Prefix{all}},
+		{PublicKey: hex.EncodeToString([]byte(strings.Repeat("b", 32))), Name: "Backup", AllowedIPs: []netip.Prefix{all}},
+	}, recordApply(&got))
+	r.Apply()
+
+	dst := netip.MustParseAddr("203.0.113.7")
+	if err := r.Pin("backup", dst); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(got, "allowed_ip=203.0.113.7/32\n") || strings.Contains(got, "public_key=aa") {
+		t.Errorf("Pin() =\n%s", got)
+	}
+	got = ""
+	if r.Pin("2", dst); got != "" {
+		t.Errorf("repeated Pin() = %q, want no-op", got)
+	}
+	if i, ok := r.Lookup(testPublicKey); !ok || i != 1 {
+		t.Errorf("Lookup(base64 key) = %d, %v", i, ok)
+	}
+	if err := r.Pin("nope", dst); err == nil {
+		t.Error("Pin(unknown) succeeded")
+	}
+	if info := r.PeerInfo(""); info[0].ID != "1" || info[1].ID != "Backup" || info[1].PublicKey != testPublicKey {
+		t.Errorf("PeerInfo() = %+v", info)
+	}
+}
+
+// TestPeerFailoverOnStaleHandshake covers the health monitor path: one peer
+// stops handshaking, its routes move to the peer with overlapping
+// AllowedIPs, and come back once it handshakes again.
+func TestPeerFailoverOnStaleHandshake(t *testing.T) {
+	all := netip.MustParsePrefix("0.0.0.0/0")
+	var got string
+	r := NewPeerRouter([]Peer{
+		{PublicKey: "aa", Name: "primary", AllowedIPs: []netip.Prefix{all}},
+		{PublicKey: "bb", Name: "backup", AllowedIPs: []netip.Prefix{all}},
+	}, recordApply(&got))
+	if err := r.Apply(); err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	threshold := 3 * time.Minute
+	ipc := func(aaHandshake, bbHandshake time.Time) string {
+		return "public_key=aa\nlast_handshake_time_sec=" + strconv.FormatInt(aaHandshake.Unix(), 10) +
+			"\npersistent_keepalive_interval=25\n" +
+			"public_key=bb\nlast_handshake_time_sec=" + strconv.FormatInt(bbHandshake.Unix(), 10) +
+			"\npersistent_keepalive_interval=25\n"
+	}
+
+	// Primary's last handshake is past the threshold.
+	stale := StalePeers(ipc(now.Add(-10*time.Minute), now.Add(-10*time.Second)), now, threshold)
+	if len(stale) != 1 || stale[0] != "aa" {
+		t.Fatalf("StalePeers = %v, want [aa]", stale)
+	}
+	got = ""
+	want := "public_key=aa\nupdate_only=true\nreplace_allowed_ips=true\n" +
+		"public_key=bb\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=0.0.0.0/0\n"
+	if moved, err := r.SetStale(stale); err != nil || !moved || got != want {
+		t.Fatalf("SetStale(%v) = %v, %v,\n%s\nwant\n%s", stale, moved, err, got, want)
+	}
+	if stale, active := r.Status(0); !stale || active {
+		t.Errorf("Status(0) = %v, %v; want stale, inactive", stale, active)
+	}
+
+	// Primary handshakes again: the route returns to it.
+	stale = StalePeers(ipc(now.Add(-5*time.Second), now.Add(-10*time.Second)), now, threshold)
+	if len(stale) != 0 {
+		t.Fatalf("StalePeers = %v, want none", stale)
+	}
+	got = ""
+	want = "public_key=aa\nupdate_only=true\nreplace_allowed_ips=true\nallowed_ip=0.0.0.0/0\n" +
+		"public_key=bb\nupdate_only=true\nreplace_allowed_ips=true\n"
+	if moved, err := r.SetStale(stale); err != nil || !moved || got != want {
+		t.Fatalf("SetStale(none) = %v, %v,\n%s\nwant\n%s", moved, err, got, want)
+	}
+}
+
+func TestStalePeers(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ipc := "public_key=aa\nlast_handshake_time_sec=0\npersistent_keepalive_interval=25\n" + // never handshaked
+		"public_key=bb\nlast_handshake_time_sec=1699999990\npersistent_keepalive_interval=25\n" + // fresh
+		"public_key=cc\nlast_handshake_time_sec=1699990000\npersistent_keepalive_interval=0\n" + // no keepalive
+		"public_key=dd\nlast_handshake_time_sec=1699990000\npersistent_keepalive_interval=25\n" // old
+	got := StalePeers(ipc, now, 3*time.Minute)
+	if strings.Join(got, ",") != "aa,dd" {
+		t.Errorf("StalePeers = %v, want [aa dd]", got)
+	}
+}
+
+func TestParsePeerStatus(t *testing.T) {
+	peers := ParsePeerStatus("private_key=00\nlisten_port=1\npublic_key=aa\nendpoint=198.51.100.1:51820\n" +
+		"last_handshake_time_sec=1700000000\nlast_handshake_time_nsec=0\ntx_bytes=10\nrx_bytes=20\n" +
+		"persistent_keepalive_interval=25\nallowed_ip=0.0.0.0/0\npublic_key=bb\nlast_handshake_time_sec=0\n")
+	if len(peers) != 2 {
+		t.Fatalf("got %d peers", len(peers))
+	}
+	p := peers[0]
+	if p.Endpoint != "198.51.100.1:51820" || p.RxBytes != 20 || p.TxBytes != 10 || p.KeepaliveInterval != 25 || p.LastHandshake.Unix() != 1700000000 {
+		t.Errorf("peer 0 = %+v", p)
+	}
+	if !peers[1].LastHandshake.IsZero() {
+		t.Errorf("peer 1 handshake = %v, want zero", peers[1].LastHandshake)
+	}
+}
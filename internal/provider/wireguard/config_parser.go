@@ -2,12 +2,18 @@ package wireguard
 
 import (
 	"bufio"
+	"context"
 	"encoding/base64"
 	"encoding/hex"
 	"fmt"
+	"io"
+	"net"
 	"net/netip"
 	"os"
+	"strconv"
 	"strings"
+
+	"awg-split-tunnel/internal/provider/wgconf"
 )
 
 // ParsedConfig holds the result of parsing a standard WireGuard .conf file.
@@ -22,6 +28,19 @@ type ParsedConfig struct {
 	UAPIConfig string
 	// PeerEndpoints are the server endpoints from [Peer] sections (for bypass routes).
 	PeerEndpoints []netip.AddrPort
+	// HostEndpoints are the peers whose Endpoint was given as a hostname.
+	HostEndpoints []HostEndpoint
+	// Peers lists every [Peer] section, for per-peer routing and failover.
+	Peers []wgconf.Peer
+}
+
+// HostEndpoint is a peer endpoint configured by hostname. UAPI only accepts
+// IPs, so it is resolved at parse time and re-resolved later to follow
+// dynamic DNS changes.
+type HostEndpoint struct {
+	PublicKey string         // hex, as used by UAPI
+	Endpoint  string         // host:port as written in the config
+	Addr      netip.AddrPort // currently configured address
 }
 
 // peerAccumulator buffers UAPI lines for a single [Peer] section
@@ -30,6 +49,12 @@ type peerAccumulator struct {
 	publicKey    string
 	lines        []string
 	hasKeepalive bool
+
+	endpointHost string         // Endpoint value if it was a hostname
+	endpoint     netip.AddrPort // its resolved address
+
+	name       string // from a "# Name = ..." comment
+	allowedIPs []netip.Prefix
 }
 
 func (pa *peerAccumulator) flush(uapi *strings.Builder) error {
@@ -52,6 +77,15 @@ func (pa *peerAccumulator) flush(uapi *strings.Builder) error {
 	return nil
 }
 
+// parseComment picks up the peer name from a "# Name = ..." comment line,
+// the convention used by wg-easy and WireSock exports.
+func (pa *peerAccumulator) parseComment(line string) {
+	key, value, ok := strings.Cut(strings.TrimLeft(line, "# "), "=")
+	if ok && strings.EqualFold(strings.TrimSpace(key), "name") {
+		pa.name = strings.TrimSpace(value)
+	}
+}
+
 // ParseConfigFile reads a standard WireGuard .conf file and produces a ParsedConfig.
 // AmneziaWG obfuscation fields (Jc, Jmin, Jmax, S1-S4, H1-H4) are silently ignored.
 func ParseConfigFile(path string) (*ParsedConfig, error) {
@@ -60,7 +94,11 @@ func ParseConfigFile(path string) (*ParsedConfig, error) {
 		return nil, fmt.Errorf("open config: %w", err)
 	}
 	defer f.Close()
+	return ParseConfig(f)
+}
 
+// ParseConfig parses a standard WireGuard .conf from r (a file or inline config text).
+func ParseConfig(r io.Reader) (*ParsedConfig, error) {
 	result := &ParsedConfig{MTU: 1420}
 	var uapi strings.Builder
 	section := ""
@@ -72,13 +110,27 @@ func ParseConfigFile(path string) (*ParsedConfig, error) {
 			if err := currentPeer.flush(&uapi); err != nil {
 				return err
 			}
+			if currentPeer.endpointHost != "" {
+				result.HostEndpoints = append(result.HostEndpoints, HostEndpoint{
+					PublicKey: currentPeer.publicKey,
+					Endpoint:  currentPeer.endpointHost,
+					Addr:      currentPeer.endpoint,
+				})
+			}
+			if currentPeer.publicKey != "" {
+				result.Peers = append(result.Peers, wgconf.Peer{
+					PublicKey:  currentPeer.publicKey,
+					Name:       currentPeer.name,
+					AllowedIPs: currentPeer.allowedIPs,
+				})
+			}
 			currentPeer = nil
 		}
 		return nil
 	}
 
 	firstLine := true
-	scanner := bufio.NewScanner(f)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		line := scanner.Text()
 		// Strip UTF-8 BOM from the first line (common in Windows-exported configs).
@@ -87,6 +139,9 @@ func ParseConfigFile(path string) (*ParsedConfig, error) {
 			firstLine = false
 		}
 		line = strings.TrimSpace(line)
+		if currentPeer != nil && strings.HasPrefix(line, "#") {
+			currentPeer.parseComment(line)
+		}
 		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
 			continue
 		}
@@ -203,13 +258,25 @@ func parsePeerKey(key, value string, cfg *ParsedConfig, peer *peerAccumulator) e
 		}
 		peer.lines = append(peer.lines, fmt.Sprintf("preshared_key=%s\n", h))
 	case "endpoint":
-		peer.lines = append(peer.lines, fmt.Sprintf("endpoint=%s\n", value))
-		if ap, err := netip.ParseAddrPort(value); err == nil {
+		ep, err := resolveEndpoint(value)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint %q: %w", value, err)
+		}
+		peer.lines = append(peer.lines, fmt.Sprintf("endpoint=%s\n", ep))
+		// Store for bypass routes.
+		if ap, err := netip.ParseAddrPort(ep); err == nil {
 			cfg.PeerEndpoints = append(cfg.PeerEndpoints, ap)
+			if ep != value {
+				peer.endpointHost = value
+				peer.endpoint = ap
+			}
 		}
 	case "allowedips":
 		for _, cidr := range splitCSV(value) {
 			peer.lines = append(peer.lines, fmt.Sprintf("allowed_ip=%s\n", cidr))
+			if pfx, err := netip.ParsePrefix(cidr); err == nil {
+				peer.allowedIPs = append(peer.allowedIPs, pfx)
+			}
 		}
 	case "persistentkeepalive":
 		if value != "" && value != "0" {
@@ -220,6 +287,69 @@ func parsePeerKey(key, value string, cfg *ParsedConfig, peer *peerAccumulator) e
 	return nil
 }
 
+// lookupHost resolves config endpoints at parse time; replaced in tests.
+var lookupHost = net.LookupHost
+
+// ipResolver is the part of *net.Resolver used to re-resolve endpoints.
+type ipResolver interface {
+	LookupNetIP(ctx context.Context, network, host string) ([]netip.Addr, error)
+}
+
+// resolveEndpoint resolves a hostname:port endpoint to IP:port.
+// UAPI requires numeric IP addresses; hostnames are not accepted.
+func resolveEndpoint(endpoint string) (string, error) {
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", err
+	}
+	// Already an IP — return as-is.
+	if _, err := netip.ParseAddr(host); err == nil {
+		return endpoint, nil
+	}
+	// Resolve hostname to IP.
+	ips, err := lookupHost(host)
+	if err != nil {
+		return "", fmt.Errorf("resolve %q: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return "", fmt.Errorf("no addresses for %q", host)
+	}
+	return net.JoinHostPort(ips[0], port), nil
+}
+
+// reresolveEndpoint looks up a hostname endpoint again with resolver.
+// The current address is kept while the name still resolves to it, so
+// round-robin DNS does not cause needless endpoint churn.
+func reresolveEndpoint(ctx context.Context, resolver ipResolver, endpoint string, current netip.AddrPort) (netip.AddrPort, error) {
+	host, portStr, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return netip.AddrPort{}, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("invalid port %q", portStr)
+	}
+	// Stay within the address family the tunnel started with: bypass
+	// routes are IPv4-only.
+	network := "ip"
+	if current.Addr().Is4() {
+		network = "ip4"
+	}
+	ips, err := resolver.LookupNetIP(ctx, network, host)
+	if err != nil {
+		return netip.AddrPort{}, fmt.Errorf("resolve %q: %w", host, err)
+	}
+	if len(ips) == 0 {
+		return netip.AddrPort{}, fmt.Errorf("no addresses for %q", host)
+	}
+	for _, ip := range ips {
+		if ip.Unmap() == current.Addr() {
+			return current, nil
+		}
+	}
+	return netip.AddrPortFrom(ips[0].Unmap(), uint16(port)), nil
+}
+
 func base64ToHex(b64 string) (string, error) {
 	raw, err := base64.StdEncoding.DecodeString(b64)
 	if err != nil {
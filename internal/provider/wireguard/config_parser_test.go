@@ -0,0 +1,195 @@
+package wireguard
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// Fake WireGuard keys (valid base64-encoded 32-byte values for testing only).
+const (
+	testPrivateKey   = "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE=" // 32x 0x61
+	testPublicKey    = "YmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmI=" // 32x 0x62
+	testPresharedKey = "Y2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2M=" // 32x 0x63
+)
+
+// fakeResolver answers LookupNetIP from a fixed table.
+type fakeResolver map[string][]netip.Addr
+
+func (r fakeResolver) LookupNetIP(_ context.Context, network, host string) ([]netip.Addr, error) {
+	addrs, ok := r[host]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	var out []netip.Addr
+	for _, a := range addrs {
+		if network == "ip4" && !a.Is4() {
+			continue
+		}
+		out = append(out, a)
+	}
+	return out, nil
+}
+
+// stubLookupHost replaces the parse-time resolver for the duration of a test.
+func stubLookupHost(t *testing.T, table map[string][]string) {
+	t.Helper()
+	orig := lookupHost
+	lookupHost = func(host string) ([]string, error) {
+		if ips, ok := table[host]; ok {
+			return ips, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	t.Cleanup(func() { lookupHost = orig })
+}
+
+// TestParseHostnameEndpoint verifies that a hostname Endpoint is resolved to
+// an IP for UAPI and recorded for later re-resolution under its peer's key.
+func TestParseHostnameEndpoint(t *testing.T) {
+	stubLookupHost(t, map[string][]string{"vpn.example.com": {"192.0.2.10"}})
+
+	conf := `[Interface]
+PrivateKey = ` + testPrivateKey + `
+
+[Peer]
+Endpoint = vpn.example.com:51820
+PublicKey = ` + testPublicKey + `
+
+[Peer]
+PublicKey = ` + testPresharedKey + `
+Endpoint = 198.51.100.1:51820
+`
+	parsed, err := ParseConfig(strings.NewReader(conf))
+	if err != nil {
+		t.Fatalf("ParseConfig failed: %v", err)
+	}
+	if !strings.Contains(parsed.UAPIConfig, "endpoint=192.0.2.10:51820\n") {
+		t.Errorf("resolved endpoint missing from UAPI: %q", parsed.UAPIConfig)
+	}
+	if len(parsed.HostEndpoints) != 1 {
+		t.Fatalf("HostEndpoints = %+v, want 1 entry", parsed.HostEndpoints)
+	}
+	he := parsed.HostEndpoints[0]
+	want := HostEndpoint{
+		PublicKey: strings.Repeat("62", 32),
+		Endpoint:  "vpn.example.com:51820",
+		Addr:      netip.MustParseAddrPort("192.0.2.10:51820"),
+	}
+	if he != want {
+		t.Errorf("HostEndpoint = %+v, want %+v", he, want)
+	}
+}
+
+// TestParseUnresolvableEndpoint verifies that a hostname that does not
+// resolve fails the parse instead of producing a peer without an endpoint.
+func TestParseUnresolvableEndpoint(t *testing.T) {
+	stubLookupHost(t, nil)
+
+	conf := `[Interface]
+PrivateKey = ` + testPrivateKey + `
+
+[Peer]
+PublicKey = ` + testPublicKey + `
+Endpoint = vpn.example.com:51820
+`
+	if _, err := ParseConfig(strings.NewReader(conf)); err == nil {
+		t.Fatal("expected error for unresolvable endpoint")
+	}
+}
+
+func TestReresolveEndpoint(t *testing.T) {
+	current := netip.MustParseAddrPort("192.0.2.10:51820")
+	tests := []struct {
+		name    string
+		addrs   []netip.Addr
+		want    netip.AddrPort
+		wantErr bool
+	}{
+		{"unchanged", []netip.Addr{netip.MustParseAddr("192.0.2.10")}, current, false},
+		{"current among several", []netip.Addr{netip.MustParseAddr("192.0.2.20"), netip.MustParseAddr("192.0.2.10")}, current, false},
+		{"moved", []netip.Addr{netip.MustParseAddr("192.0.2.20")}, netip.MustParseAddrPort("192.0.2.20:51820"), false},
+		{"ipv4 only", []netip.Addr{netip.MustParseAddr("2001:db8::1"), netip.MustParseAddr("192.0.2.30")}, netip.MustParseAddrPort("192.0.2.30:51820"), false},
+		{"no address", nil, netip.AddrPort{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := fakeResolver{}
+			if tt.addrs != nil {
+				r["vpn.example.com"] = tt.addrs
+			}
+			got, err := reresolveEndpoint(context.Background(), r, "vpn.example.com:51820", current)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReresolveHosts verifies that only moved endpoints produce UAPI updates
+// and that a failed lookup does not stop the others from being refreshed.
+func TestReresolveHosts(t *testing.T) {
+	hosts := []HostEndpoint{
+		{PublicKey: "aa", Endpoint: "same.example.com:51820", Addr: netip.MustParseAddrPort("192.0.2.1:51820")},
+		{PublicKey: "bb", Endpoint: "moved.example.com:51820", Addr: netip.MustParseAddrPort("192.0.2.2:51820")},
+		{PublicKey: "cc", Endpoint: "gone.example.com:51820", Addr: netip.MustParseAddrPort("192.0.2.3:51820")},
+	}
+	r := fakeResolver{
+		"same.example.com":  {netip.MustParseAddr("192.0.2.1")},
+		"moved.example.com": {netip.MustParseAddr("198.51.100.2")},
+	}
+
+	uapi, changes, err := reresolveHosts(context.Background(), r, hosts)
+	if err == nil {
+		t.Error("expected error for unresolvable host")
+	}
+	if want := "public_key=bb\nupdate_only=true\nendpoint=198.51.100.2:51820\n"; uapi != want {
+		t.Errorf("uapi = %q, want %q", uapi, want)
+	}
+	if len(changes) != 1 || changes[0].Old != netip.MustParseAddrPort("192.0.2.2:51820") ||
+		changes[0].New != netip.MustParseAddrPort("198.51.100.2:51820") {
+		t.Errorf("changes = %+v", changes)
+	}
+	if hosts[1].Addr != netip.MustParseAddrPort("198.51.100.2:51820") {
+		t.Errorf("hosts[1].Addr = %s, not updated", hosts[1].Addr)
+	}
+	if hosts[0].Addr != netip.MustParseAddrPort("192.0.2.1:51820") || hosts[2].Addr != netip.MustParseAddrPort("192.0.2.3:51820") {
+		t.Errorf("unchanged hosts modified: %+v", hosts)
+	}
+}
+
+// TestParseMultiPeer verifies that every [Peer] is recorded with its
+// "# Name" comment and AllowedIPs for per-peer routing.
+func TestParseMultiPeer(t *testing.T) {
+	conf := `[Interface]
+PrivateKey = ` + testPrivateKey + `
+Address = 10.8.1.2/32
+
+[Peer]
+# Name = frankfurt
+PublicKey = ` + testPublicKey + `
+AllowedIPs = 0.0.0.0/0
+
+[Peer]
+PublicKey = ` + testPresharedKey + `
+AllowedIPs = 0.0.0.0/0, 10.1.0.0/16
+`
+	parsed, err := ParseConfig(strings.NewReader(conf))
+	if err != nil {
+		t.Fatalf("ParseConfig: %v", err)
+	}
+	if len(parsed.Peers) != 2 {
+		t.Fatalf("got %d peers, want 2", len(parsed.Peers))
+	}
+	if parsed.Peers[0].Name != "frankfurt" || parsed.Peers[0].PublicKey != strings.Repeat("62", 32) {
+		t.Errorf("peer 0 = %+v", parsed.Peers[0])
+	}
+	if parsed.Peers[1].Name != "" || len(parsed.Peers[1].AllowedIPs) != 2 {
+		t.Errorf("peer 1 = %+v", parsed.Peers[1])
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -62,6 +63,10 @@ const directTunnelID = "__direct__"
 type FallbackDialer struct {
 	providerLookup ProviderLookup
 	rules          *core.RuleEngine
+
+	// race enables happy-eyeballs dialing for failover rules (nil = off).
+	race     atomic.Pointer[raceSettings]
+	failures failureCache
 }
 
 // NewFallbackDialer creates a FallbackDialer with the given dependencies.
@@ -78,8 +83,17 @@ func NewFallbackDialer(providerLookup ProviderLookup, rules *core.RuleEngine) *F
 //   - PolicyAllowDirect: retry through __direct__ provider
 //   - PolicyFailover: try the next matching rule's tunnel
 //
+// With racing configured, failover rules dial their tunnels concurrently
+// with staggered starts instead.
+//
 // Returns the established connection and the actual tunnel ID used.
 func (fd *FallbackDialer) DialTCPWithFallback(ctx context.Context, info core.NATInfo) (net.Conn, string, error) {
+	if info.Fallback == core.PolicyFailover {
+		if rs := fd.race.Load(); rs != nil {
+			return fd.raceDialTCP(ctx, info, rs, "")
+		}
+	}
+
 	// Primary attempt through the designated tunnel.
 	prov, ok := fd.providerLookup(info.TunnelID)
 	if !ok {
@@ -134,6 +148,9 @@ func (fd *FallbackDialer) applyFallbackTCP(ctx context.Context, info core.NATInf
 		return fd.dialDirectTCP(ctx, info.DialDst(), originalErr)
 
 	case core.PolicyFailover:
+		if rs := fd.race.Load(); rs != nil {
+			return fd.raceDialTCP(ctx, info, rs, info.TunnelID)
+		}
 		return fd.failoverDialTCP(ctx, info, originalErr)
 
 	default:
@@ -413,6 +430,7 @@ func (fd *FallbackDialer) DetectEarlyEOF(
 	if _, err := remoteConn.Write(initialData); err != nil {
 		core.Log.Warnf("Proxy", "Early EOF: write to tunnel %s failed for %s: %v",
 			info.TunnelID, info.OriginalDst, err)
+		fd.noteFailure(info.TunnelID, info.DialDst())
 		remoteConn.Close()
 		return fd.retryWithFallbackTCP(ctx, info, initialData)
 	}
@@ -429,6 +447,7 @@ func (fd *FallbackDialer) DetectEarlyEOF(
 		// Early EOF — server likely blocked the connection.
 		core.Log.Warnf("Proxy", "Early EOF detected for %s via %s (err=%v, bytes=%d), trying fallback=%s",
 			info.OriginalDst, info.TunnelID, respErr, respN, info.Fallback)
+		fd.noteFailure(info.TunnelID, info.DialDst())
 		remoteConn.Close()
 		return fd.retryWithFallbackTCP(ctx, info, initialData)
 	}
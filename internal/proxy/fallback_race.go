@@ -0,0 +1,276 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	// defaultRaceDelay is the head start of each failover candidate
+	// (RFC 8305 "Connection Attempt Delay").
+	defaultRaceDelay = 250 * time.Millisecond
+
+	// defaultFailureTTL is how long a tunnel that failed to reach a
+	// destination is tried last for it.
+	defaultFailureTTL = 30 * time.Second
+
+	// maxFailureEntries bounds the negative cache.
+	maxFailureEntries = 4096
+)
+
+// raceSettings is the parsed form of core.FailoverConfig with Race enabled.
+type raceSettings struct {
+	delay time.Duration
+	ttl   time.Duration
+}
+
+// Configure applies the failover settings. With Race disabled, failover
+// rules try their tunnels one after another.
+func (fd *FallbackDialer) Configure(cfg *core.FailoverConfig) {
+	if cfg == nil || !cfg.Race {
+		if fd.race.Swap(nil) != nil {
+			core.Log.Infof("Proxy", "Failover racing disabled")
+		}
+		fd.failures.reset()
+		return
+	}
+	rs := &raceSettings{
+		delay: parseDurationOr(cfg.RaceDelay, defaultRaceDelay),
+		ttl:   parseDurationOr(cfg.FailureTTL, defaultFailureTTL),
+	}
+	fd.race.Store(rs)
+	core.Log.Infof("Proxy", "Failover racing enabled (delay=%v, failure_ttl=%v)", rs.delay, rs.ttl)
+}
+
+func parseDurationOr(s string, def time.Duration) time.Duration {
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d
+	}
+	return def
+}
+
+// failoverCandidates lists the tunnels a failover rule may use, in rule
+// order: the rule's own tunnel, then the tunnels of the next matching rules
+// up to the first one whose fallback is not failover. If that rule allows
+// direct, the direct tunnel comes last. skip is left out.
+func (fd *FallbackDialer) failoverCandidates(info core.NATInfo, skip string) []string {
+	ids := []string{info.TunnelID}
+	nextIdx := info.RuleIdx + 1
+	policy := core.PolicyFailover
+
+	for hop := 0; hop < maxFallbackHops && policy == core.PolicyFailover; hop++ {
+		result, idx := fd.rules.MatchPreLoweredFrom(info.ExeLower, info.BaseLower, nextIdx)
+		if !result.Matched {
+			break
+		}
+		nextIdx = idx + 1
+		if _, ok := fd.providerLookup(result.TunnelID); !ok {
+			continue
+		}
+		ids = append(ids, result.TunnelID)
+		policy = result.Fallback
+	}
+	if policy == core.PolicyAllowDirect {
+		ids = append(ids, directTunnelID)
+	}
+
+	out := ids[:0]
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if id != skip && !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// raceDialTCP dials the failover candidates for info, starting the next
+// one every rs.delay or as soon as a dial fails, and returns the first
+// connection established. Tunnels that recently failed for the destination
+// are started last; a tunnel still dialing when a less preferred one wins
+// counts as failed. Later connections are closed.
+func (fd *FallbackDialer) raceDialTCP(ctx context.Context, info core.NATInfo, rs *raceSettings, skip string) (net.Conn, string, error) {
+	dst := info.DialDst()
+	ids := fd.failures.order(fd.failoverCandidates(info, skip), dst)
+	if len(ids) == 0 {
+		return nil, "", fmt.Errorf("no failover tunnel for %s", dst)
+	}
+
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type attempt struct {
+		conn     net.Conn
+		tunnelID string
+		err      error
+	}
+	results := make(chan attempt, len(ids))
+	pending := make(map[string]bool, len(ids))
+	var next, running int
+	timer := time.NewTimer(rs.delay)
+	defer timer.Stop()
+
+	launch := func() {
+		id := ids[next]
+		next++
+		running++
+		pending[id] = true
+		timer.Reset(rs.delay)
+		prov, ok := fd.providerLookup(id)
+		if !ok {
+			results <- attempt{tunnelID: id, err: fmt.Errorf("no provider for tunnel %q", id)}
+			return
+		}
+		go func() {
+			dialCtx, dialCancel := context.WithTimeout(raceCtx, fallbackDialTimeout)
+			defer dialCancel()
+			conn, err := prov.DialTCP(dialCtx, dst)
+			results <- attempt{conn, id, err}
+		}()
+	}
+	// abandon closes connections from attempts still in flight.
+	abandon := func() {
+		cancel()
+		go func(n int) {
+			for ; n > 0; n-- {
+				if a := <-results; a.conn != nil {
+					a.conn.Close()
+				}
+			}
+		}(running)
+	}
+
+	var firstErr error
+	launch()
+	for running > 0 {
+		select {
+		case a := <-results:
+			running--
+			delete(pending, a.tunnelID)
+			if a.err == nil {
+				abandon()
+				fd.failures.remove(a.tunnelID, dst)
+				for _, id := range ids {
+					if id == a.tunnelID {
+						break
+					}
+					if pending[id] {
+						fd.failures.add(id, dst, rs.ttl)
+					}
+				}
+				if a.tunnelID != info.TunnelID {
+					core.Log.Infof("Proxy", "TCP failover race: tunnel %s won for %s", a.tunnelID, dst)
+				}
+				return a.conn, a.tunnelID, nil
+			}
+			if !shouldFallback(a.err) {
+				abandon()
+				return nil, "", a.err
+			}
+			fd.failures.add(a.tunnelID, dst, rs.ttl)
+			core.Log.Warnf("Proxy", "TCP failover race: dial %s via %s failed: %v", dst, a.tunnelID, a.err)
+			if firstErr == nil {
+				firstErr = a.err
+			}
+			if next < len(ids) {
+				launch()
+			}
+		case <-timer.C:
+			if next < len(ids) {
+				launch()
+			}
+		case <-ctx.Done():
+			abandon()
+			return nil, "", ctx.Err()
+		}
+	}
+	return nil, "", fmt.Errorf("failover race exhausted (%d tunnels): %w", len(ids), firstErr)
+}
+
+// noteFailure remembers that tunnelID could not reach dst, if racing is on.
+func (fd *FallbackDialer) noteFailure(tunnelID, dst string) {
+	if rs := fd.race.Load(); rs != nil {
+		fd.failures.add(tunnelID, dst, rs.ttl)
+	}
+}
+
+// failureKey identifies a tunnel/destination pair in the negative cache.
+type failureKey struct {
+	tunnelID string
+	dst      string
+}
+
+// failureCache is a short-lived negative cache of tunnel/destination pairs
+// that failed to connect.
+type failureCache struct {
+	mu      sync.Mutex
+	entries map[failureKey]time.Time // expiry
+}
+
+func (c *failureCache) add(tunnelID, dst string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[failureKey]time.Time)
+	}
+	if len(c.entries) >= maxFailureEntries {
+		for k, exp := range c.entries {
+			if now.After(exp) {
+				delete(c.entries, k)
+			}
+		}
+		if len(c.entries) >= maxFailureEntries {
+			clear(c.entries)
+		}
+	}
+	c.entries[failureKey{tunnelID, dst}] = now.Add(ttl)
+}
+
+func (c *failureCache) remove(tunnelID, dst string) {
+	c.mu.Lock()
+	delete(c.entries, failureKey{tunnelID, dst})
+	c.mu.Unlock()
+}
+
+func (c *failureCache) reset() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+// order moves the tunnels that recently failed for dst to the end of ids,
+// keeping the relative order of both groups.
+func (c *failureCache) order(ids []string, dst string) []string {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.entries) == 0 {
+		return ids
+	}
+	fresh := make([]string, 0, len(ids))
+	var failed []string
+	for _, id := range ids {
+		k := failureKey{id, dst}
+		exp, ok := c.entries[k]
+		switch {
+		case !ok:
+			fresh = append(fresh, id)
+		case now.After(exp):
+			delete(c.entries, k)
+			fresh = append(fresh, id)
+		default:
+			failed = append(failed, id)
+		}
+	}
+	return append(fresh, failed...)
+}
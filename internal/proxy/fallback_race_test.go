@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+)
+
+// raceProvider connects over net.Pipe, or never answers if blackhole is set.
+type raceProvider struct {
+	blackhole bool
+	dials     atomic.Int32
+}
+
+func (p *raceProvider) Connect(context.Context) error { return nil }
+func (p *raceProvider) Disconnect() error             { return nil }
+func (p *raceProvider) State() core.TunnelState       { return core.TunnelStateUp }
+func (p *raceProvider) GetAdapterIP() netip.Addr      { return netip.Addr{} }
+func (p *raceProvider) Name() string                  { return "race" }
+func (p *raceProvider) Protocol() string              { return "race" }
+
+func (p *raceProvider) DialTCP(ctx context.Context, _ string) (net.Conn, error) {
+	p.dials.Add(1)
+	if p.blackhole {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	c, _ := net.Pipe()
+	return c, nil
+}
+
+func (p *raceProvider) DialUDP(ctx context.Context, addr string) (net.Conn, error) {
+	return p.DialTCP(ctx, addr)
+}
+
+func TestRaceDialSkipsBlackholedTunnel(t *testing.T) {
+	providers := map[string]*raceProvider{
+		"slow": {blackhole: true},
+		"fast": {},
+	}
+	rules := core.NewRuleEngine([]core.Rule{
+		{Pattern: "app.exe", TunnelID: "slow", Fallback: core.PolicyFailover},
+		{Pattern: "app.exe", TunnelID: "fast", Fallback: core.PolicyBlock},
+	}, nil, nil)
+	fd := NewFallbackDialer(func(id string) (provider.TunnelProvider, bool) {
+		p, ok := providers[id]
+		return p, ok
+	}, rules)
+	fd.Configure(&core.FailoverConfig{Race: true, RaceDelay: "20ms"})
+
+	info := core.NATInfo{
+		OriginalDst: "192.0.2.1:443",
+		TunnelID:    "slow",
+		Fallback:    core.PolicyFailover,
+		ExeLower:    `c:\app.exe`,
+		BaseLower:   "app.exe",
+	}
+	start := time.Now()
+	conn, tunnel, err := fd.DialTCPWithFallback(context.Background(), info)
+	if err != nil || tunnel != "fast" {
+		t.Fatalf("DialTCPWithFallback = %q, %v; want fast", tunnel, err)
+	}
+	conn.Close()
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("race took %v", d)
+	}
+
+	// The blackholed tunnel lost, so it goes last and is not dialed while
+	// the other one connects within the delay.
+	fd.Configure(&core.FailoverConfig{Race: true, RaceDelay: "5s"})
+	conn, tunnel, err = fd.DialTCPWithFallback(context.Background(), info)
+	if err != nil || tunnel != "fast" {
+		t.Fatalf("second dial = %q, %v; want fast", tunnel, err)
+	}
+	conn.Close()
+	if n := providers["slow"].dials.Load(); n != 1 {
+		t.Errorf("blackholed tunnel dialed %d times, want 1", n)
+	}
+}
+
+func TestFailoverCandidates(t *testing.T) {
+	up := &raceProvider{}
+	lookup := func(id string) (provider.TunnelProvider, bool) { return up, id != "gone" }
+	tests := []struct {
+		name  string
+		rules []core.Rule
+		want  []string
+	}{
+		{
+			name: "ends at block",
+			rules: []core.Rule{
+				{Pattern: "app.exe", TunnelID: "a", Fallback: core.PolicyFailover},
+				{Pattern: "app.exe", TunnelID: "b", Fallback: core.PolicyBlock},
+				{Pattern: "app.exe", TunnelID: "c", Fallback: core.PolicyFailover},
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "allow_direct appends direct",
+			rules: []core.Rule{
+				{Pattern: "app.exe", TunnelID: "a", Fallback: core.PolicyFailover},
+				{Pattern: "app.exe", TunnelID: "gone", Fallback: core.PolicyBlock},
+				{Pattern: "app.exe", TunnelID: "a", Fallback: core.PolicyFailover},
+				{Pattern: "app.exe", TunnelID: "b", Fallback: core.PolicyAllowDirect},
+			},
+			want: []string{"a", "b", directTunnelID},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fd := NewFallbackDialer(lookup, core.NewRuleEngine(tt.rules, nil, nil))
+			got := fd.failoverCandidates(core.NATInfo{
+				TunnelID: "a", ExeLower: "app.exe", BaseLower: "app.exe",
+			}, "")
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
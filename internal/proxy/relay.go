@@ -0,0 +1,292 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/shaping"
+)
+
+// ---------------------------------------------------------------------------
+// TCP relay — pooled buffers, kernel splice (Linux), half-close, per-rule limits
+// ---------------------------------------------------------------------------
+
+const (
+	// spliceChunk bounds one kernel copy (Linux only, see kernelPair), so
+	// rate limits added mid-connection are checked between chunks.
+	spliceChunk = 512 * 1024
+
+	// spliceChecks is how many times per idle timeout a kernel copy returns
+	// to report activity. A splice that trickles data does not return on
+	// its own, and the other direction would take the connection for idle.
+	spliceChecks = 4
+)
+
+// streamBufPool holds 16 KB relay buffers for stream tunnels (VLESS,
+// Hysteria2, TUIC, SSH): they return data in TLS/QUIC-record pieces of at
+// most 16 KB, so a larger buffer only costs memory per connection.
+var streamBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 16*1024)
+		return &b
+	},
+}
+
+// relayBufPool returns the buffer pool for relaying through a tunnel of the
+// given protocol. Sockets (direct, SOCKS5, HTTP proxy) and netstack tunnels
+// (WireGuard, SSL-VPN) fill large reads and use fwdBufPool.
+func relayBufPool(protocol string) *sync.Pool {
+	switch protocol {
+	case core.ProtocolVLESS, core.ProtocolHysteria2, core.ProtocolTUIC, core.ProtocolSSH:
+		return &streamBufPool
+	}
+	return &fwdBufPool
+}
+
+// ConnLimits holds the per-rule idle_timeout and max_lifetime of proxied
+// TCP connections. One instance is shared by every TunnelProxy; Configure
+// may be called at any time and applies to new connections.
+type ConnLimits struct {
+	rules atomic.Pointer[map[string]connLimit] // by lowercased rule pattern
+}
+
+type connLimit struct {
+	idle     time.Duration
+	lifetime time.Duration // 0 = unlimited
+}
+
+// NewConnLimits creates ConnLimits with the defaults for every rule.
+func NewConnLimits() *ConnLimits {
+	return &ConnLimits{}
+}
+
+// Configure applies the limits of rules.
+func (cl *ConnLimits) Configure(rules []core.Rule) {
+	m := make(map[string]connLimit)
+	for _, r := range rules {
+		if !r.IsEnabled() || (r.IdleTimeout == "" && r.MaxLifetime == "") {
+			continue
+		}
+		key := strings.ToLower(r.Pattern)
+		if _, dup := m[key]; dup {
+			continue
+		}
+		m[key] = connLimit{
+			idle:     parseDurationOr(r.IdleTimeout, proxyIdleTimeout),
+			lifetime: parseDurationOr(r.MaxLifetime, 0),
+		}
+	}
+	cl.rules.Store(&m)
+	if len(m) > 0 {
+		core.Log.Infof("Proxy", "%d per-rule connection limits active", len(m))
+	}
+}
+
+// lookup returns the limits of connections matched by rule.
+func (cl *ConnLimits) lookup(rule string) connLimit {
+	if cl != nil && rule != "" {
+		if m := cl.rules.Load(); m != nil {
+			if l, ok := (*m)[strings.ToLower(rule)]; ok {
+				return l
+			}
+		}
+	}
+	return connLimit{idle: proxyIdleTimeout}
+}
+
+// relay copies one proxied connection in both directions.
+type relay struct {
+	client, remote net.Conn
+	target         string
+	limit          connLimit
+	pool           *sync.Pool
+
+	lastActive atomic.Int64 // unix nanoseconds of the last data moved
+	aborted    atomic.Bool
+}
+
+// run relays until both directions are done, the connection has been idle
+// (no data either way) for limit.idle or lived for limit.lifetime, or ctx
+// is done. A direction that ends with EOF is half-closed so the other side
+// sees it and may keep sending; an error in either direction closes both.
+func (r *relay) run(ctx context.Context, shape shaping.Flow) {
+	r.touch()
+	stop := context.AfterFunc(ctx, r.abort)
+	defer stop()
+	if r.limit.lifetime > 0 {
+		t := time.AfterFunc(r.limit.lifetime, func() {
+			core.Log.Debugf("Proxy", "relay %s: max lifetime %v reached", r.target, r.limit.lifetime)
+			r.abort()
+		})
+		defer t.Stop()
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go r.pipe(ctx, r.client, r.remote, "tunnel→client", shape.Down, &wg)
+	go r.pipe(ctx, r.remote, r.client, "client→tunnel", shape.Up, &wg)
+	wg.Wait()
+}
+
+// abort closes both connections, unblocking both directions.
+func (r *relay) abort() {
+	if r.aborted.CompareAndSwap(false, true) {
+		r.client.Close()
+		r.remote.Close()
+	}
+}
+
+func (r *relay) touch() {
+	r.lastActive.Store(time.Now().UnixNano())
+}
+
+// readDeadline is when the connection turns idle unless data moves.
+func (r *relay) readDeadline() time.Time {
+	return time.Unix(0, r.lastActive.Load()).Add(r.limit.idle)
+}
+
+// stillActive reports whether err is a read timeout while the other
+// direction kept the connection busy, so reading should go on.
+func (r *relay) stillActive(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout() && time.Now().Before(r.readDeadline())
+}
+
+// pipe copies src to dst and ends the direction.
+func (r *relay) pipe(ctx context.Context, dst, src net.Conn, direction string, lim shaping.Limiter, wg *sync.WaitGroup) {
+	defer wg.Done()
+	defer func() {
+		if v := recover(); v != nil {
+			core.Log.Errorf("Proxy", "panic in relay %s %s: %v", direction, r.target, v)
+			r.abort()
+		}
+	}()
+
+	total, err := r.copy(ctx, dst, src, lim)
+	var ne net.Error
+	switch {
+	case err == nil:
+		if total == 0 {
+			core.Log.Warnf("Proxy", "forward %s %s: 0 bytes (clean EOF — remote closed immediately)", direction, r.target)
+		} else {
+			core.Log.Debugf("Proxy", "forward %s %s: %d bytes", direction, r.target, total)
+		}
+		closeWrite(dst)
+		closeRead(src)
+		return
+	case r.aborted.Load():
+		core.Log.Debugf("Proxy", "forward %s %s: closed after %d bytes", direction, r.target, total)
+	case errors.As(err, &ne) && ne.Timeout():
+		core.Log.Debugf("Proxy", "forward %s %s: idle timeout (%v) after %d bytes", direction, r.target, r.limit.idle, total)
+	default:
+		core.Log.Warnf("Proxy", "forward %s %s: %d bytes, err=%v", direction, r.target, total, err)
+	}
+	r.abort()
+}
+
+// copy moves data from src to dst until EOF (nil error) or a failure.
+// On Linux, socket pairs go through the kernel while no rate limit applies;
+// anything else, and every connection on other platforms, is copied through
+// a pooled buffer, each chunk waiting for lim.
+func (r *relay) copy(ctx context.Context, dst, src net.Conn, lim shaping.Limiter) (int64, error) {
+	var total int64
+	var bp *[]byte
+	defer func() {
+		if bp != nil {
+			r.pool.Put(bp)
+		}
+	}()
+
+	for {
+		if d, s, ok := kernelPair(dst, src); ok && !lim.Limited() {
+			deadline := r.readDeadline()
+			if check := time.Now().Add(r.limit.idle / spliceChecks); check.Before(deadline) {
+				deadline = check
+			}
+			s.SetReadDeadline(deadline)
+			n, err := d.ReadFrom(&io.LimitedReader{R: s, N: spliceChunk})
+			total += n
+			if n > 0 {
+				r.touch()
+			}
+			if err != nil {
+				if r.stillActive(err) {
+					continue
+				}
+				return total, err
+			}
+			if n < spliceChunk {
+				return total, nil // EOF
+			}
+			continue
+		}
+
+		if bp == nil {
+			bp = r.pool.Get().(*[]byte)
+		}
+		src.SetReadDeadline(r.readDeadline())
+		nr, readErr := src.Read(*bp)
+		if nr > 0 {
+			r.touch()
+			if err := lim.Wait(ctx, nr); err != nil {
+				return total, err
+			}
+			nw, err := dst.Write((*bp)[:nr])
+			total += int64(nw)
+			if err != nil {
+				return total, err
+			}
+			if nw != nr {
+				return total, io.ErrShortWrite
+			}
+		}
+		if readErr != nil {
+			if readErr == io.EOF {
+				return total, nil
+			}
+			if r.stillActive(readErr) {
+				continue
+			}
+			return total, readErr
+		}
+	}
+}
+
+// rawTCP returns the TCP socket under c, once any replay prefix is drained.
+func rawTCP(c net.Conn) (*net.TCPConn, bool) {
+	if pc, ok := c.(*prefixConn); ok {
+		if pc.offset < len(pc.prefix) {
+			return nil, false
+		}
+		c = pc.Conn
+	}
+	tc, ok := c.(*net.TCPConn)
+	return tc, ok
+}
+
+// closeWrite half-closes c (TCP FIN, TLS close_notify) if it supports it.
+func closeWrite(c net.Conn) {
+	if pc, ok := c.(*prefixConn); ok {
+		c = pc.Conn
+	}
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
+}
+
+// closeRead shuts down the read side of c if it supports it.
+func closeRead(c net.Conn) {
+	if pc, ok := c.(*prefixConn); ok {
+		c = pc.Conn
+	}
+	if cr, ok := c.(interface{ CloseRead() error }); ok {
+		cr.CloseRead()
+	}
+}
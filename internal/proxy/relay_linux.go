@@ -0,0 +1,15 @@
+package proxy
+
+import "net"
+
+// kernelPair returns the TCP sockets under dst and src when the relay can
+// move bytes between them with splice(2) without copying through user
+// space: both ends are real sockets (direct, SOCKS5, HTTP proxy).
+func kernelPair(dst, src net.Conn) (*net.TCPConn, *net.TCPConn, bool) {
+	d, ok := rawTCP(dst)
+	if !ok {
+		return nil, nil, false
+	}
+	s, ok := rawTCP(src)
+	return d, s, ok
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package proxy
+
+import "net"
+
+// kernelPair reports no kernel copy path: splice(2) is Linux-only, and
+// elsewhere TCPConn.ReadFrom falls back to io.Copy with a fresh buffer, so
+// the pooled loop is faster. Windows and macOS always relay in user space.
+func kernelPair(dst, src net.Conn) (*net.TCPConn, *net.TCPConn, bool) {
+	return nil, nil, false
+}
@@ -3,7 +3,6 @@ package proxy
 import (
 	"context"
 	"fmt"
-	"io"
 	"net"
 	"sync"
 	"sync/atomic"
@@ -11,6 +10,7 @@ import (
 
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/shaping"
 )
 
 // sockBufSize is the socket buffer size for TCP proxy connections.
@@ -18,13 +18,13 @@ import (
 // actual window sizes. Avoids excessive RSS under many connections.
 const sockBufSize = 256 * 1024
 
-// proxyIdleTimeout is the maximum time a forwarding goroutine will wait
-// for data before considering the connection idle and terminating.
+// proxyIdleTimeout is the default idle_timeout: a connection with no data
+// moving in either direction for this long is closed.
 // Prevents goroutine leaks from abandoned/stalled connections.
 const proxyIdleTimeout = 5 * time.Minute
 
-// fwdBufPool reuses 128KB buffers for bidirectional TCP forwarding.
-// 64KB balances syscall overhead reduction with memory efficiency.
+// fwdBufPool reuses 64KB buffers for relaying through socket and netstack
+// tunnels. 64KB balances syscall overhead reduction with memory efficiency.
 var fwdBufPool = sync.Pool{
 	New: func() any {
 		b := make([]byte, 64*1024)
@@ -48,6 +48,11 @@ type NATLookup func(addrKey string) (core.NATInfo, bool)
 // ProviderLookup is a function that returns the TunnelProvider for a given tunnel ID.
 type ProviderLookup func(tunnelID string) (provider.TunnelProvider, bool)
 
+// TunnelReporter records the tunnel that actually carries a connection when
+// fallback or SNI routing moved it off the tunnel in its NAT entry.
+// addrKey is the same key passed to NATLookup.
+type TunnelReporter func(addrKey, tunnelID string)
+
 // TunnelProxy is a per-tunnel transparent TCP proxy.
 // It accepts redirected connections, looks up the original destination from the
 // NAT table, and forwards traffic through the VPN provider.
@@ -63,6 +68,18 @@ type TunnelProxy struct {
 	// potentially override the tunnel routing decision.
 	domainMatchFunc atomic.Pointer[core.DomainMatchFunc]
 
+	// shaper applies bandwidth limits to forwarded data (nil = unlimited).
+	shaper *shaping.Shaper
+
+	// connLimits holds per-rule idle/lifetime limits (nil = defaults).
+	connLimits *ConnLimits
+
+	// listen opens the proxy listener (nil = host stack).
+	listen func(addr string) (net.Listener, error)
+
+	// reportTunnel is told which tunnel won a rerouted connection (optional).
+	reportTunnel TunnelReporter
+
 	wg     sync.WaitGroup
 	cancel context.CancelFunc
 
@@ -81,6 +98,30 @@ func (tp *TunnelProxy) SetDomainMatchFunc(fn *core.DomainMatchFunc) {
 	tp.domainMatchFunc.Store(fn)
 }
 
+// SetShaper sets the rate limiter for forwarded connections. Must be called
+// before Start.
+func (tp *TunnelProxy) SetShaper(s *shaping.Shaper) {
+	tp.shaper = s
+}
+
+// SetConnLimits sets the per-rule connection limits. Must be called before
+// Start.
+func (tp *TunnelProxy) SetConnLimits(cl *ConnLimits) {
+	tp.connLimits = cl
+}
+
+// SetListen overrides how the proxy listener is opened. Must be called
+// before Start.
+func (tp *TunnelProxy) SetListen(fn func(addr string) (net.Listener, error)) {
+	tp.listen = fn
+}
+
+// SetTunnelReporter sets the callback told which tunnel carries a
+// connection that fallback or SNI routing moved. Must be called before Start.
+func (tp *TunnelProxy) SetTunnelReporter(fn TunnelReporter) {
+	tp.reportTunnel = fn
+}
+
 // NewTunnelProxy creates a proxy that listens on the given port.
 // If fallback is non-nil, connection-level fallback is enabled: failed dials
 // are retried through alternative tunnels according to the rule's fallback policy.
@@ -99,7 +140,13 @@ func (tp *TunnelProxy) Start(ctx context.Context) error {
 	ctx, tp.cancel = context.WithCancel(ctx)
 
 	addr := fmt.Sprintf("0.0.0.0:%d", tp.port)
-	ln, err := net.Listen("tcp4", addr)
+	var ln net.Listener
+	var err error
+	if tp.listen != nil {
+		ln, err = tp.listen(addr)
+	} else {
+		ln, err = net.Listen("tcp4", addr)
+	}
 	if err != nil {
 		return fmt.Errorf("[Proxy] failed to listen on %s: %w", addr, err)
 	}
@@ -120,7 +167,7 @@ func (tp *TunnelProxy) Stop() {
 	if tp.listener != nil {
 		tp.listener.Close()
 	}
-	// Close all active connections to unblock io.CopyBuffer in forwarders.
+	// Close all active connections to unblock relays.
 	tp.connsMu.Lock()
 	for c := range tp.conns {
 		c.Close()
@@ -184,11 +231,13 @@ func (tp *TunnelProxy) handleConnection(ctx context.Context, clientConn net.Conn
 	defer clientConn.Close()
 
 	// Look up original destination and fallback context from NAT table.
-	info, ok := tp.natLookup(clientConn.RemoteAddr().String())
+	addrKey := clientConn.RemoteAddr().String()
+	info, ok := tp.natLookup(addrKey)
 	if !ok {
 		core.Log.Warnf("Proxy", "No NAT entry for %s, closing", clientConn.RemoteAddr())
 		return
 	}
+	natTunnel := info.TunnelID
 
 	// SNI-based routing: if a domain match function is set, peek at the
 	// client's initial data to extract the TLS SNI hostname and potentially
@@ -227,9 +276,10 @@ func (tp *TunnelProxy) handleConnection(ctx context.Context, clientConn net.Conn
 	// Dial through the tunnel, with connection-level fallback if available.
 	var remoteConn net.Conn
 	var err error
+	usedTunnel := info.TunnelID
 
 	if tp.fallback != nil {
-		remoteConn, _, err = tp.fallback.DialTCPWithFallback(ctx, info)
+		remoteConn, usedTunnel, err = tp.fallback.DialTCPWithFallback(ctx, info)
 	} else {
 		prov, provOK := tp.providerLookup(info.TunnelID)
 		if !provOK {
@@ -248,6 +298,7 @@ func (tp *TunnelProxy) handleConnection(ctx context.Context, clientConn net.Conn
 	tp.trackConn(remoteConn)
 	defer tp.untrackConn(remoteConn)
 	defer remoteConn.Close()
+	info.TunnelID = usedTunnel
 
 	// Early EOF detection: after a successful dial, the server may still block
 	// the connection once it sees the destination (e.g. VLESS/Xray blackhole).
@@ -258,6 +309,7 @@ func (tp *TunnelProxy) handleConnection(ctx context.Context, clientConn net.Conn
 		if result.Failed {
 			return
 		}
+		usedTunnel = result.ActualTunnel
 		if result.RemoteConn != remoteConn {
 			// Fallback produced a new connection — swap it in.
 			// Old conn is already closed by DetectEarlyEOF; untrack it
@@ -279,64 +331,21 @@ func (tp *TunnelProxy) handleConnection(ctx context.Context, clientConn net.Conn
 		}
 	}
 
-	// Bidirectional forwarding.
-	var fwg sync.WaitGroup
-	fwg.Add(2)
-	go forward(clientConn, remoteConn, "tunnel→client", info.OriginalDst, &fwg)
-	go forward(remoteConn, clientConn, "client→tunnel", info.OriginalDst, &fwg)
-	fwg.Wait()
-}
-
-// forward copies data from src to dst with idle timeout protection.
-// The read deadline is reset on each successful read, so truly active
-// connections are never interrupted. Only idle/stalled connections timeout.
-func forward(dst, src net.Conn, direction, target string, wg *sync.WaitGroup) {
-	defer wg.Done()
-	defer func() {
-		if v := recover(); v != nil {
-			core.Log.Errorf("Proxy", "panic in forward %s %s: %v", direction, target, v)
-		}
-	}()
-
-	bp := fwdBufPool.Get().(*[]byte)
-	defer fwdBufPool.Put(bp)
-	buf := *bp
-
-	var total int64
-	for {
-		src.SetReadDeadline(time.Now().Add(proxyIdleTimeout))
-		nr, readErr := src.Read(buf)
-		if nr > 0 {
-			nw, writeErr := dst.Write(buf[:nr])
-			total += int64(nw)
-			if writeErr != nil {
-				core.Log.Warnf("Proxy", "forward %s %s: write err after %d bytes: %v", direction, target, total, writeErr)
-				break
-			}
-			if nw != nr {
-				core.Log.Warnf("Proxy", "forward %s %s: short write (%d/%d) after %d bytes", direction, target, nw, nr, total)
-				break
-			}
-		}
-		if readErr != nil {
-			if netErr, ok := readErr.(net.Error); ok && netErr.Timeout() {
-				core.Log.Debugf("Proxy", "forward %s %s: idle timeout (%v) after %d bytes", direction, target, proxyIdleTimeout, total)
-			} else if readErr != io.EOF && total > 0 {
-				core.Log.Warnf("Proxy", "forward %s %s: %d bytes, err=%v", direction, target, total, readErr)
-			} else if total == 0 {
-				core.Log.Warnf("Proxy", "forward %s %s: 0 bytes (clean EOF — remote closed immediately)", direction, target)
-			} else {
-				core.Log.Debugf("Proxy", "forward %s %s: %d bytes", direction, target, total)
-			}
-			break
-		}
+	if usedTunnel != natTunnel && tp.reportTunnel != nil {
+		tp.reportTunnel(addrKey, usedTunnel)
 	}
 
-	// Signal half-close.
-	if tc, ok := dst.(*net.TCPConn); ok {
-		tc.CloseWrite()
+	// Bidirectional forwarding.
+	var protocol string
+	if prov, ok := tp.providerLookup(usedTunnel); ok {
+		protocol = prov.Protocol()
 	}
-	if tc, ok := src.(*net.TCPConn); ok {
-		tc.CloseRead()
+	rl := &relay{
+		client: clientConn,
+		remote: remoteConn,
+		target: info.OriginalDst,
+		limit:  tp.connLimits.lookup(info.Rule),
+		pool:   relayBufPool(protocol),
 	}
+	rl.run(ctx, tp.shaper.Flow(usedTunnel, info.Rule))
 }
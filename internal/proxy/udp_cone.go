@@ -0,0 +1,264 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/shaping"
+)
+
+// maxConePeers bounds the remotes one full-cone socket accepts datagrams
+// from, so a flood of senders cannot grow the peer map without limit.
+const maxConePeers = 1024
+
+// UDPPeerRegistrar adds a UDP NAT entry for a datagram that reached a
+// full-cone socket from a remote the client has not sent to, so the router
+// hairpins it back with remote as its source. It returns false if the
+// remote cannot be mapped.
+type UDPPeerRegistrar func(remote netip.AddrPort, clientPort, proxyPort uint16, info core.NATInfo) bool
+
+// coneKey identifies a full-cone socket. Destinations of one client port
+// routed to different tunnels get separate sockets, so no datagram leaves
+// through a tunnel its rule did not pick.
+type coneKey struct {
+	clientPort uint16
+	tunnelID   string
+}
+
+// udpCone is the tunnel side of a full-cone (endpoint-independent) UDP
+// mapping: one unconnected socket shared by every destination of a client
+// port. Datagrams from any remote are delivered back to that port.
+type udpCone struct {
+	conn       net.PacketConn
+	clientPort uint16
+	info       core.NATInfo // NAT context of the first flow, for new remotes
+	shape      shaping.Flow
+	timeout    int64 // idle timeout once no session uses it, seconds
+	lastActive int64 // atomic; Unix seconds of the last datagram received
+
+	mu    sync.Mutex
+	peers map[netip.AddrPort]*net.UDPAddr // remote → hairpin address of the client
+
+	closeOnce sync.Once
+}
+
+// write sends a datagram from the client to dst.
+func (c *udpCone) write(data []byte, dst *net.UDPAddr) error {
+	_, err := c.conn.WriteTo(data, dst)
+	return err
+}
+
+// addPeer records where datagrams from remote are delivered to.
+func (c *udpCone) addPeer(remote netip.AddrPort, hairpin *net.UDPAddr) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.peers[remote]; !ok && len(c.peers) >= maxConePeers {
+		return false
+	}
+	c.peers[remote] = hairpin
+	return true
+}
+
+// peer returns the hairpin address for datagrams from remote.
+func (c *udpCone) peer(remote netip.AddrPort) *net.UDPAddr {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.peers[remote]
+}
+
+func (c *udpCone) close() {
+	c.closeOnce.Do(func() { c.conn.Close() })
+}
+
+// handleConeDatagram starts a full-cone session for the first datagram from
+// clientAddr and sends it. It returns false, with nothing sent, when the
+// tunnel cannot open an unconnected socket; the caller then uses a
+// connected one.
+func (up *UDPProxy) handleConeDatagram(ctx context.Context, sk netip.AddrPort, clientAddr *net.UDPAddr, info core.NATInfo, data []byte) bool {
+	dst, err := netip.ParseAddrPort(info.DialDst())
+	if err != nil || !dst.Addr().Is4() {
+		return false
+	}
+	cone, err := up.cone(ctx, uint16(clientAddr.Port), info, dst.Port())
+	if err != nil {
+		core.Log.Debugf("Proxy", "UDP full-cone unavailable via %s, using a connected socket: %v", info.TunnelID, err)
+		return false
+	}
+	// Replies from dst go back to the address the client sent to (the
+	// FakeIP, if any), which the router restores from its NAT entry.
+	cone.addPeer(dst, clientAddr)
+
+	sess := &UDPSession{
+		lastActive: up.nowSec.Load(),
+		clientAddr: clientAddr,
+		dstPort:    dst.Port(),
+		shape:      cone.shape,
+		cancel:     func() {},
+		cone:       cone,
+		dst:        net.UDPAddrFromAddrPort(dst),
+	}
+	up.sessionsMu.Lock()
+	up.sessions[sk] = sess
+	up.sessionsMu.Unlock()
+
+	if !allowDatagram(sess.shape.Up, len(data), sess.dstPort) {
+		return true
+	}
+	if err := sess.write(data); err != nil {
+		core.Log.Errorf("Proxy", "UDP write to tunnel failed for %s: %v", clientAddr, err)
+	}
+	return true
+}
+
+// cone returns the full-cone socket of clientPort in the flow's tunnel,
+// opening it on first use.
+func (up *UDPProxy) cone(ctx context.Context, clientPort uint16, info core.NATInfo, dstPort uint16) (*udpCone, error) {
+	ck := coneKey{clientPort: clientPort, tunnelID: info.TunnelID}
+	up.sessionsMu.RLock()
+	c, ok := up.cones[ck]
+	up.sessionsMu.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	prov, ok := up.providerLookup(info.TunnelID)
+	if !ok {
+		return nil, fmt.Errorf("no provider for tunnel %q", info.TunnelID)
+	}
+	l, ok := prov.(provider.UDPListener)
+	if !ok {
+		return nil, fmt.Errorf("provider %q has no unconnected UDP sockets", prov.Protocol())
+	}
+	conn, err := l.ListenUDP(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c = &udpCone{
+		conn:       conn,
+		clientPort: clientPort,
+		info:       info,
+		shape:      up.shaper.Flow(info.TunnelID, info.Rule),
+		timeout:    udpSessionTimeout(dstPort),
+		lastActive: up.nowSec.Load(),
+		peers:      make(map[netip.AddrPort]*net.UDPAddr),
+	}
+	up.sessionsMu.Lock()
+	up.cones[ck] = c
+	up.sessionsMu.Unlock()
+
+	core.Log.Debugf("Proxy", "UDP full-cone socket for port %d via %s (local %s)", clientPort, info.TunnelID, conn.LocalAddr())
+	up.wg.Add(1)
+	go up.readFromCone(ctx, c)
+	return c, nil
+}
+
+// readFromCone delivers datagrams arriving on a full-cone socket to the
+// client port through the hairpin path, whichever remote sent them. The
+// socket has no read deadline: it is closed by expireCones once idle, so the
+// public mapping stays stable while the client uses it.
+func (up *UDPProxy) readFromCone(ctx context.Context, c *udpCone) {
+	defer up.wg.Done()
+	defer up.removeCone(c)
+	defer func() {
+		if v := recover(); v != nil {
+			core.Log.Errorf("Proxy", "panic in UDP readFromCone for port %d: %v", c.clientPort, v)
+		}
+	}()
+
+	bp := udpBufPool.Get().(*[]byte)
+	defer udpBufPool.Put(bp)
+	buf := *bp
+
+	for {
+		n, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-ctx.Done():
+			default:
+				core.Log.Debugf("Proxy", "UDP full-cone socket for port %d closed: %v", c.clientPort, err)
+			}
+			return
+		}
+
+		ua, ok := from.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		remote := ua.AddrPort()
+		remote = netip.AddrPortFrom(remote.Addr().Unmap(), remote.Port())
+		if !remote.Addr().Is4() {
+			continue // the hairpin path is IPv4 only
+		}
+		atomic.StoreInt64(&c.lastActive, up.nowSec.Load())
+
+		hairpin := c.peer(remote)
+		if hairpin == nil {
+			// A new remote: map it so the router gives the reply remote as
+			// its source.
+			if up.peerRegistrar == nil || !up.peerRegistrar(remote, c.clientPort, up.port, c.info) {
+				continue
+			}
+			hairpin = &net.UDPAddr{IP: remote.Addr().AsSlice(), Port: int(c.clientPort)}
+			if !c.addPeer(remote, hairpin) {
+				continue
+			}
+			core.Log.Debugf("Proxy", "UDP full-cone: accepted %s → port %d", remote, c.clientPort)
+		}
+
+		if !allowDatagram(c.shape.Down, n, remote.Port()) {
+			continue
+		}
+		if _, err := up.conn.WriteTo(buf[:n], hairpin); err != nil {
+			core.Log.Errorf("Proxy", "UDP write to client %s failed: %v", hairpin, err)
+		}
+	}
+}
+
+// removeCone closes a full-cone socket and drops the sessions using it.
+func (up *UDPProxy) removeCone(c *udpCone) {
+	up.sessionsMu.Lock()
+	ck := coneKey{clientPort: c.clientPort, tunnelID: c.info.TunnelID}
+	if up.cones[ck] == c {
+		delete(up.cones, ck)
+	}
+	for sk, sess := range up.sessions {
+		if sess.cone == c {
+			delete(up.sessions, sk)
+		}
+	}
+	up.sessionsMu.Unlock()
+	c.close()
+}
+
+// expireCones closes full-cone sockets that no session uses any more and
+// that have received nothing for longer than their timeout.
+func (up *UDPProxy) expireCones(now int64) {
+	var stale []*udpCone
+	up.sessionsMu.RLock()
+	if len(up.cones) > 0 {
+		inUse := make(map[*udpCone]bool, len(up.cones))
+		for _, sess := range up.sessions {
+			if sess.cone != nil {
+				inUse[sess.cone] = true
+			}
+		}
+		for _, c := range up.cones {
+			if !inUse[c] && now-atomic.LoadInt64(&c.lastActive) > c.timeout {
+				stale = append(stale, c)
+			}
+		}
+	}
+	up.sessionsMu.RUnlock()
+
+	for _, c := range stale {
+		core.Log.Debugf("Proxy", "UDP full-cone socket for port %d timed out, closing", c.clientPort)
+		up.removeCone(c)
+	}
+}
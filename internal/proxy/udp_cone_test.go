@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider"
+)
+
+// loopProvider is a tunnel whose sockets are plain loopback sockets.
+type loopProvider struct {
+	listens atomic.Int32
+	dials   atomic.Int32
+}
+
+func (p *loopProvider) Connect(context.Context) error { return nil }
+func (p *loopProvider) Disconnect() error             { return nil }
+func (p *loopProvider) State() core.TunnelState       { return core.TunnelStateUp }
+func (p *loopProvider) GetAdapterIP() netip.Addr      { return netip.Addr{} }
+func (p *loopProvider) Name() string                  { return "loop" }
+func (p *loopProvider) Protocol() string              { return "loop" }
+
+func (p *loopProvider) DialTCP(context.Context, string) (net.Conn, error) {
+	return nil, errors.New("no TCP")
+}
+
+func (p *loopProvider) DialUDP(_ context.Context, addr string) (net.Conn, error) {
+	p.dials.Add(1)
+	return net.Dial("udp4", addr)
+}
+
+// coneProvider adds unconnected sockets to loopProvider.
+type coneProvider struct{ loopProvider }
+
+func (p *coneProvider) ListenUDP(context.Context) (net.PacketConn, error) {
+	p.listens.Add(1)
+	return net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+}
+
+var _ provider.UDPListener = (*coneProvider)(nil)
+
+func listenLoopback(t *testing.T) *net.UDPConn {
+	t.Helper()
+	c, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func readWithin(t *testing.T, c *net.UDPConn) (string, *net.UDPAddr) {
+	t.Helper()
+	buf := make([]byte, 1500)
+	c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	n, from, err := c.ReadFromUDP(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(buf[:n]), from
+}
+
+// startProxy runs a UDP proxy whose NAT table maps every client to dst.
+func startProxy(t *testing.T, prov provider.TunnelProvider, dst *net.UDPAddr, fullCone bool, reg UDPPeerRegistrar) *UDPProxy {
+	t.Helper()
+	free := listenLoopback(t)
+	port := uint16(free.LocalAddr().(*net.UDPAddr).Port)
+	free.Close()
+
+	natLookup := func(string) (core.NATInfo, bool) {
+		return core.NATInfo{OriginalDst: dst.String(), TunnelID: "loop", FullCone: fullCone}, true
+	}
+	providers := func(string) (provider.TunnelProvider, bool) { return prov, true }
+	up := NewUDPProxy(port, natLookup, providers, nil)
+	up.SetPeerRegistrar(reg)
+	if err := up.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(up.Stop)
+	return up
+}
+
+func TestFullConeAcceptsAnyRemote(t *testing.T) {
+	client := listenLoopback(t)
+	remoteA := listenLoopback(t)
+	remoteB := listenLoopback(t)
+	clientPort := uint16(client.LocalAddr().(*net.UDPAddr).Port)
+
+	var mu sync.Mutex
+	var registered []netip.AddrPort
+	reg := func(remote netip.AddrPort, cp, _ uint16, info core.NATInfo) bool {
+		mu.Lock()
+		defer mu.Unlock()
+		if cp != clientPort || !info.FullCone {
+			t.Errorf("registrar got port %d, info %+v", cp, info)
+		}
+		registered = append(registered, remote)
+		return true
+	}
+
+	prov := &coneProvider{}
+	up := startProxy(t, prov, remoteA.LocalAddr().(*net.UDPAddr), true, reg)
+	proxyAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: int(up.Port())}
+
+	if _, err := client.WriteToUDP([]byte("hello"), proxyAddr); err != nil {
+		t.Fatal(err)
+	}
+	msg, tunnelSock := readWithin(t, remoteA)
+	if msg != "hello" {
+		t.Fatalf("remote A got %q", msg)
+	}
+
+	// The remote the client sent to answers without a new NAT entry.
+	remoteA.WriteToUDP([]byte("from A"), tunnelSock)
+	if msg, _ := readWithin(t, client); msg != "from A" {
+		t.Fatalf("client got %q, want reply from A", msg)
+	}
+
+	// A remote the client never contacted reaches the same port.
+	remoteB.WriteToUDP([]byte("from B"), tunnelSock)
+	if msg, _ := readWithin(t, client); msg != "from B" {
+		t.Fatalf("client got %q, want datagram from B", msg)
+	}
+
+	mu.Lock()
+	want := remoteB.LocalAddr().(*net.UDPAddr).AddrPort()
+	if len(registered) != 1 || registered[0] != want {
+		t.Errorf("registered %v, want only %v", registered, want)
+	}
+	mu.Unlock()
+
+	// Later datagrams leave through the same tunnel socket.
+	client.WriteToUDP([]byte("again"), proxyAddr)
+	if _, from := readWithin(t, remoteA); from.Port != tunnelSock.Port {
+		t.Errorf("second datagram sent from port %d, want %d", from.Port, tunnelSock.Port)
+	}
+	if n := prov.listens.Load(); n != 1 || prov.dials.Load() != 0 {
+		t.Errorf("ListenUDP called %d times, DialUDP %d times", n, prov.dials.Load())
+	}
+}
+
+func TestFullConeFallsBackToConnectedSocket(t *testing.T) {
+	client := listenLoopback(t)
+	remote := listenLoopback(t)
+
+	prov := &loopProvider{}
+	up := startProxy(t, prov, remote.LocalAddr().(*net.UDPAddr), true, nil)
+	proxyAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: int(up.Port())}
+
+	client.WriteToUDP([]byte("hello"), proxyAddr)
+	if msg, _ := readWithin(t, remote); msg != "hello" {
+		t.Fatalf("remote got %q", msg)
+	}
+	if prov.dials.Load() != 1 {
+		t.Errorf("DialUDP called %d times, want 1", prov.dials.Load())
+	}
+}
+
+// TestFullConeSeparatesTunnels checks that two destinations of one client
+// port routed to different tunnels do not share a full-cone socket.
+func TestFullConeSeparatesTunnels(t *testing.T) {
+	clientA := listenLoopback(t)
+	clientPort := clientA.LocalAddr().(*net.UDPAddr).Port
+	// The router hairpins each destination with its own source IP.
+	clientB, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2), Port: clientPort})
+	if err != nil {
+		t.Skipf("cannot bind 127.0.0.2: %v", err)
+	}
+	t.Cleanup(func() { clientB.Close() })
+	remoteA := listenLoopback(t)
+	remoteB := listenLoopback(t)
+
+	free := listenLoopback(t)
+	port := uint16(free.LocalAddr().(*net.UDPAddr).Port)
+	free.Close()
+
+	routes := map[string]core.NATInfo{
+		clientA.LocalAddr().String(): {OriginalDst: remoteA.LocalAddr().String(), TunnelID: "a", FullCone: true},
+		clientB.LocalAddr().String(): {OriginalDst: remoteB.LocalAddr().String(), TunnelID: "b", FullCone: true},
+	}
+	natLookup := func(addr string) (core.NATInfo, bool) {
+		info, ok := routes[addr]
+		return info, ok
+	}
+	provA, provB := &coneProvider{}, &coneProvider{}
+	providers := func(id string) (provider.TunnelProvider, bool) {
+		if id == "a" {
+			return provA, true
+		}
+		return provB, true
+	}
+	up := NewUDPProxy(port, natLookup, providers, nil)
+	if err := up.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(up.Stop)
+	proxyAddr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: int(port)}
+
+	clientA.WriteToUDP([]byte("to A"), proxyAddr)
+	_, sockA := readWithin(t, remoteA)
+	clientB.WriteToUDP([]byte("to B"), proxyAddr)
+	msg, sockB := readWithin(t, remoteB)
+	if msg != "to B" {
+		t.Fatalf("remote B got %q", msg)
+	}
+	if sockA.Port == sockB.Port {
+		t.Errorf("both destinations sent from tunnel socket %d", sockA.Port)
+	}
+	if provA.listens.Load() != 1 || provB.listens.Load() != 1 {
+		t.Errorf("ListenUDP calls: tunnel a %d, tunnel b %d; want 1 each", provA.listens.Load(), provB.listens.Load())
+	}
+}
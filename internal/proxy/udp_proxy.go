@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/shaping"
 )
 
 // maxUDPSessions limits the number of concurrent UDP sessions to prevent
@@ -46,9 +47,35 @@ type UDPSession struct {
 	lastActive int64 // atomic; Unix seconds
 	tunnelConn net.Conn
 	clientAddr *net.UDPAddr
-	dstPort    uint16             // original destination port (for adaptive timeout)
+	dstPort    uint16       // original destination port (for adaptive timeout)
+	shape      shaping.Flow // rate limiters (global, tunnel, rule)
 	cancel     context.CancelFunc
 	closeOnce  sync.Once // prevents double-close of tunnelConn
+
+	// Full-cone sessions send through the shared socket of their client
+	// port instead of tunnelConn (nil).
+	cone *udpCone
+	dst  *net.UDPAddr
+}
+
+// write sends one datagram from the client to the session's destination.
+func (s *UDPSession) write(data []byte) error {
+	if s.cone != nil {
+		return s.cone.write(data, s.dst)
+	}
+	_, err := s.tunnelConn.Write(data)
+	return err
+}
+
+// close releases the session's tunnel connection. The shared socket of a
+// full-cone session is closed by its own cleanup.
+func (s *UDPSession) close() {
+	s.closeOnce.Do(func() {
+		if s.tunnelConn != nil {
+			s.tunnelConn.Close()
+		}
+	})
+	s.cancel()
 }
 
 // UDPProxy is a per-tunnel transparent UDP proxy.
@@ -56,13 +83,18 @@ type UDPSession struct {
 // NAT table, and forwards traffic through the VPN provider.
 type UDPProxy struct {
 	port           uint16
-	conn           *net.UDPConn
+	conn           net.PacketConn
 	natLookup      UDPNATLookup
 	providerLookup ProviderLookup
 	fallback       *FallbackDialer
+	shaper         *shaping.Shaper // nil = unlimited
+	peerRegistrar  UDPPeerRegistrar
+	listen         func(addr string) (net.PacketConn, error) // nil = host stack
+	reportTunnel   TunnelReporter                            // optional
 
 	sessionsMu sync.RWMutex
 	sessions   map[netip.AddrPort]*UDPSession
+	cones      map[coneKey]*udpCone // full-cone sockets by client port and tunnel
 
 	// Cached Unix timestamp (seconds), updated every 250ms.
 	// Eliminates time.Now() syscall from the per-datagram fast path.
@@ -77,6 +109,31 @@ func (up *UDPProxy) Port() uint16 {
 	return up.port
 }
 
+// SetShaper sets the rate limiter for UDP sessions. Must be called before
+// Start.
+func (up *UDPProxy) SetShaper(s *shaping.Shaper) {
+	up.shaper = s
+}
+
+// SetPeerRegistrar sets the callback that registers NAT entries for remotes
+// reaching a full-cone socket. Without it, full-cone sockets only accept
+// replies from remotes the client sent to. Must be called before Start.
+func (up *UDPProxy) SetPeerRegistrar(fn UDPPeerRegistrar) {
+	up.peerRegistrar = fn
+}
+
+// SetListen overrides how the proxy socket is opened. Must be called
+// before Start.
+func (up *UDPProxy) SetListen(fn func(addr string) (net.PacketConn, error)) {
+	up.listen = fn
+}
+
+// SetTunnelReporter sets the callback told which tunnel carries a session
+// that fallback moved. Must be called before Start.
+func (up *UDPProxy) SetTunnelReporter(fn TunnelReporter) {
+	up.reportTunnel = fn
+}
+
 // NewUDPProxy creates a UDP proxy that listens on the given port.
 // If fallback is non-nil, connection-level fallback is enabled for UDP sessions.
 func NewUDPProxy(port uint16, natLookup UDPNATLookup, providerLookup ProviderLookup, fallback *FallbackDialer) *UDPProxy {
@@ -86,6 +143,7 @@ func NewUDPProxy(port uint16, natLookup UDPNATLookup, providerLookup ProviderLoo
 		providerLookup: providerLookup,
 		fallback:       fallback,
 		sessions:       make(map[netip.AddrPort]*UDPSession),
+		cones:          make(map[coneKey]*udpCone),
 	}
 }
 
@@ -94,7 +152,13 @@ func (up *UDPProxy) Start(ctx context.Context) error {
 	ctx, up.cancel = context.WithCancel(ctx)
 
 	addr := &net.UDPAddr{IP: net.IPv4zero, Port: int(up.port)}
-	conn, err := net.ListenUDP("udp4", addr)
+	var conn net.PacketConn
+	var err error
+	if up.listen != nil {
+		conn, err = up.listen(addr.String())
+	} else {
+		conn, err = net.ListenUDP("udp4", addr)
+	}
 	if err != nil {
 		return fmt.Errorf("[Proxy] failed to listen UDP on :%d: %w", up.port, err)
 	}
@@ -123,10 +187,13 @@ func (up *UDPProxy) Stop() {
 	// Close all active sessions (closeOnce prevents double-close with readFromTunnel).
 	up.sessionsMu.Lock()
 	for _, sess := range up.sessions {
-		sess.closeOnce.Do(func() { sess.tunnelConn.Close() })
-		sess.cancel()
+		sess.close()
 	}
 	up.sessions = make(map[netip.AddrPort]*UDPSession)
+	for _, c := range up.cones {
+		c.close()
+	}
+	up.cones = make(map[coneKey]*udpCone)
 	up.sessionsMu.Unlock()
 
 	up.wg.Wait()
@@ -146,7 +213,7 @@ func (up *UDPProxy) readLoop(ctx context.Context) {
 		default:
 		}
 
-		n, clientAddr, err := up.conn.ReadFromUDP(buf)
+		n, from, err := up.conn.ReadFrom(buf)
 		if err != nil {
 			select {
 			case <-ctx.Done():
@@ -156,6 +223,10 @@ func (up *UDPProxy) readLoop(ctx context.Context) {
 				continue
 			}
 		}
+		clientAddr, ok := from.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
 
 		// Safe: handleDatagram writes buf[:n] synchronously before returning.
 		up.handleDatagram(ctx, buf[:n], clientAddr)
@@ -173,7 +244,10 @@ func (up *UDPProxy) handleDatagram(ctx context.Context, data []byte, clientAddr
 
 	if exists {
 		atomic.StoreInt64(&sess.lastActive, up.nowSec.Load())
-		if _, err := sess.tunnelConn.Write(data); err != nil {
+		if !allowDatagram(sess.shape.Up, len(data), sess.dstPort) {
+			return
+		}
+		if err := sess.write(data); err != nil {
 			core.Log.Errorf("Proxy", "UDP write to tunnel failed for %s: %v", clientAddr, err)
 		}
 		return
@@ -196,12 +270,19 @@ func (up *UDPProxy) handleDatagram(ctx context.Context, data []byte, clientAddr
 		return
 	}
 
+	// Full-cone rules share one tunnel socket per client port; providers
+	// without unconnected sockets fall back to a connected one below.
+	if info.FullCone && up.handleConeDatagram(ctx, sk, clientAddr, info, data) {
+		return
+	}
+
 	// Dial through the tunnel, with connection-level fallback if available.
 	var tunnelConn net.Conn
 	var err error
+	usedTunnel := info.TunnelID
 
 	if up.fallback != nil {
-		tunnelConn, _, err = up.fallback.DialUDPWithFallback(ctx, info)
+		tunnelConn, usedTunnel, err = up.fallback.DialUDPWithFallback(ctx, info)
 	} else {
 		prov, provOK := up.providerLookup(info.TunnelID)
 		if !provOK {
@@ -215,6 +296,9 @@ func (up *UDPProxy) handleDatagram(ctx context.Context, data []byte, clientAddr
 		core.Log.Errorf("Proxy", "UDP failed to dial %s via %s: %v", info.OriginalDst, info.TunnelID, err)
 		return
 	}
+	if usedTunnel != info.TunnelID && up.reportTunnel != nil {
+		up.reportTunnel(addrStr, usedTunnel)
+	}
 
 	// Parse the original destination port for adaptive timeout.
 	var dstPort uint16
@@ -230,6 +314,7 @@ func (up *UDPProxy) handleDatagram(ctx context.Context, data []byte, clientAddr
 		tunnelConn: tunnelConn,
 		clientAddr: clientAddr,
 		dstPort:    dstPort,
+		shape:      up.shaper.Flow(usedTunnel, info.Rule),
 		cancel:     sessCancel,
 	}
 
@@ -314,13 +399,30 @@ func (up *UDPProxy) readFromTunnel(ctx context.Context, sess *UDPSession, sk net
 
 		consecutiveErrors = 0
 		atomic.StoreInt64(&sess.lastActive, up.nowSec.Load())
-		if _, err := up.conn.WriteToUDP(buf[:n], sess.clientAddr); err != nil {
+		if !allowDatagram(sess.shape.Down, n, sess.dstPort) {
+			continue
+		}
+		if _, err := up.conn.WriteTo(buf[:n], sess.clientAddr); err != nil {
 			core.Log.Errorf("Proxy", "UDP write to client %s failed: %v", sess.clientAddr, err)
 			return
 		}
 	}
 }
 
+// allowDatagram applies a session's rate limit to one datagram. Datagrams
+// cannot be delayed without adding jitter, so those over the limit are
+// dropped; DNS and small datagrams (voice, games) may borrow ahead of the
+// limit, but are dropped too once a flood of them uses up the borrowing.
+func allowDatagram(lim shaping.Limiter, n int, dstPort uint16) bool {
+	if len(lim) == 0 {
+		return true
+	}
+	if dstPort == 53 || n < 300 {
+		return lim.AllowPriority(n)
+	}
+	return lim.Allow(n)
+}
+
 // HandleICMPUnreachable is called by the router when ICMP Destination
 // Unreachable is received. Finds and kills the matching UDP session.
 func (up *UDPProxy) HandleICMPUnreachable(dstIP [4]byte, srcPort, dstPort uint16) {
@@ -352,8 +454,7 @@ func (up *UDPProxy) removeSession(sk netip.AddrPort) {
 	}
 	up.sessionsMu.Unlock()
 	if ok {
-		sess.closeOnce.Do(func() { sess.tunnelConn.Close() })
-		sess.cancel()
+		sess.close()
 	}
 }
 
@@ -428,6 +529,7 @@ func (up *UDPProxy) cleanupLoop(ctx context.Context) {
 				core.Log.Debugf("Proxy", "UDP session timed out, closing")
 				up.removeSession(sk)
 			}
+			up.expireCones(now)
 		}
 	}
 }
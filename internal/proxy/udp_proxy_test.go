@@ -0,0 +1,30 @@
+package proxy
+
+import (
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/shaping"
+)
+
+func TestAllowDatagramThrottlesSmallFlood(t *testing.T) {
+	var cfg core.Config
+	cfg.Global.RateLimit = &core.RateLimit{DownKbps: 80, DownBurstKB: 2} // 10 KB/s
+
+	for _, port := range []uint16{53, 27015} {
+		s := shaping.New()
+		s.Configure(cfg)
+		lim := s.Flow("", "").Down
+
+		passed := 0
+		for i := 0; i < 1000; i++ {
+			if allowDatagram(lim, 100, port) {
+				passed++
+			}
+		}
+		// The burst and one burst of debt: ~40 datagrams.
+		if passed == 0 || passed > 100 {
+			t.Errorf("port %d: %d of 1000 small datagrams passed, want ~40", port, passed)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ruleimport
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// clash converts Clash / mihomo rules: a full config with a "rules:" list,
+// a bare YAML list, or one rule per line ("TYPE,PAYLOAD,TARGET[,OPTION]").
+func (im *importer) clash(data []byte) error {
+	rules, err := clashRules(data)
+	if err != nil {
+		return err
+	}
+	for _, line := range rules {
+		im.clashRule(strings.TrimSpace(line))
+	}
+	return nil
+}
+
+func clashRules(data []byte) ([]string, error) {
+	var cfg struct {
+		Rules []string `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err == nil && len(cfg.Rules) > 0 {
+		return cfg.Rules, nil
+	}
+	var list []string
+	if err := yaml.Unmarshal(data, &list); err == nil && len(list) > 0 {
+		return list, nil
+	}
+	var lines []string
+	for _, l := range strings.Split(string(data), "\n") {
+		l = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(l), "- "))
+		if l != "" && !strings.HasPrefix(l, "#") && strings.Contains(l, ",") {
+			lines = append(lines, l)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("clash: no rules found")
+	}
+	return lines, nil
+}
+
+func clashTarget(name string) target {
+	switch strings.ToUpper(name) {
+	case "DIRECT":
+		return target{act: actDirect}
+	case "REJECT", "REJECT-DROP", "REJECT-TINYGIF", "REJECT-NO-DROP":
+		return target{act: actBlock}
+	}
+	return target{act: actProxy, name: name}
+}
+
+func (im *importer) clashRule(line string) {
+	if line == "" || strings.HasPrefix(line, "#") {
+		return
+	}
+	parts := strings.Split(line, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	kind := strings.ToUpper(parts[0])
+	switch kind {
+	case "MATCH", "FINAL":
+		im.skip(line, "catch-all rule: unmatched traffic follows the tunnels' own routing")
+		return
+	case "RULE-SET":
+		im.skip(line, "rule providers are not imported; add the list as a rule set")
+		return
+	case "AND", "OR", "NOT", "SUB-RULE":
+		im.skip(line, "logical rules are not supported")
+		return
+	}
+	if len(parts) < 3 {
+		im.skip(line, "malformed rule")
+		return
+	}
+	payload, t := parts[1], clashTarget(parts[2])
+
+	switch kind {
+	case "DOMAIN":
+		im.addDomain("full:"+strings.ToLower(payload), t, true, line)
+	case "DOMAIN-SUFFIX":
+		im.addDomain("domain:"+strings.ToLower(strings.TrimPrefix(payload, ".")), t, true, line)
+	case "DOMAIN-KEYWORD":
+		im.addDomain("keyword:"+strings.ToLower(payload), t, true, line)
+	case "GEOSITE":
+		im.addDomain("geosite:"+strings.ToLower(payload), t, true, line)
+	case "GEOIP":
+		im.addDomain("geoip:"+strings.ToLower(payload), t, true, line)
+	case "IP-CIDR", "IP-CIDR6":
+		p, ok := parsePrefix(payload)
+		if !ok {
+			im.skip(line, "invalid IP range")
+			return
+		}
+		im.addCIDR(p, t, true, line)
+	case "PROCESS-NAME", "PROCESS-PATH":
+		im.addProcess(payload, t, true, line)
+	default:
+		im.skip(line, fmt.Sprintf("unsupported rule type %s", kind))
+	}
+}
@@ -0,0 +1,250 @@
+package ruleimport
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+// Proxifier profile (.ppx), the parts that matter for rules.
+type ppxProfile struct {
+	Proxies []ppxProxy `xml:"ProxyList>Proxy"`
+	Chains  []ppxChain `xml:"ChainList>Chain"`
+	Rules   []ppxRule  `xml:"RuleList>Rule"`
+}
+
+type ppxProxy struct {
+	ID      string `xml:"id,attr"`
+	Address string `xml:"Address"`
+	Port    string `xml:"Port"`
+	Label   string `xml:"Label"`
+}
+
+type ppxChain struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:"Name"`
+}
+
+type ppxRule struct {
+	Enabled      string    `xml:"enabled,attr"`
+	Name         string    `xml:"Name"`
+	Applications string    `xml:"Applications"`
+	Targets      string    `xml:"Targets"`
+	Ports        string    `xml:"Ports"`
+	Action       ppxAction `xml:"Action"`
+}
+
+type ppxAction struct {
+	Type  string `xml:"type,attr"` // Direct, Block, Proxy, Chain
+	Value string `xml:",chardata"` // proxy or chain id
+}
+
+// proxifier converts the rule list of a Proxifier profile. Proxies are
+// named by their label, else "address:port"; chains by their name. Options
+// may map either the name or the numeric id.
+func (im *importer) proxifier(data []byte) error {
+	var prof ppxProfile
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.CharsetReader = charset.NewReaderLabel
+	if err := dec.Decode(&prof); err != nil {
+		return fmt.Errorf("proxifier: %w", err)
+	}
+
+	names := make(map[string]string, len(prof.Proxies)+len(prof.Chains))
+	for _, p := range prof.Proxies {
+		name := p.Label
+		if name == "" {
+			name = net.JoinHostPort(p.Address, p.Port)
+		}
+		names[p.ID] = name
+	}
+	for _, c := range prof.Chains {
+		names[c.ID] = c.Name
+	}
+
+	for _, r := range prof.Rules {
+		im.proxifierRule(r, names)
+	}
+	return nil
+}
+
+func (im *importer) proxifierRule(r ppxRule, names map[string]string) {
+	source := fmt.Sprintf("rule %q", r.Name)
+	enabled := !strings.EqualFold(r.Enabled, "false")
+
+	var t target
+	var ids []string
+	switch strings.ToLower(r.Action.Type) {
+	case "direct":
+		t.act = actDirect
+	case "block":
+		t.act = actBlock
+	case "proxy", "chain":
+		id := strings.TrimSpace(r.Action.Value)
+		t = target{act: actProxy, name: names[id]}
+		if t.name == "" {
+			t.name = id
+		}
+		ids = []string{id}
+	default:
+		im.skip(source, fmt.Sprintf("unknown action %q", r.Action.Type))
+		return
+	}
+
+	apps := splitList(r.Applications)
+	targets := splitList(r.Targets)
+	switch {
+	case strings.TrimSpace(r.Ports) != "":
+		im.skip(source, "port conditions are not supported")
+	case len(apps) == 0 && len(targets) == 0:
+		im.skip(source, "catch-all rule: unmatched traffic follows the tunnels' own routing")
+	case len(apps) > 0 && len(targets) > 0:
+		im.skip(source, "rules combining applications and targets cannot be represented")
+	case len(apps) > 0:
+		for _, app := range apps {
+			im.addProcess(app, t, enabled, source, ids...)
+		}
+	default:
+		for _, host := range targets {
+			im.proxifierTarget(host, t, enabled, source, ids)
+		}
+	}
+}
+
+// proxifierTarget converts one entry of a Targets list: a host name with
+// optional "*" wildcards, an IPv4 address with "*" octets, a range
+// "a.b.c.d-e.f.g.h" or a CIDR.
+func (im *importer) proxifierTarget(host string, t target, enabled bool, source string, ids []string) {
+	entry := fmt.Sprintf("%s: %s", source, host)
+	if strings.Contains(host, "%") {
+		im.skip(entry, "Proxifier macros are not supported")
+		return
+	}
+	if prefixes, ok := ipv4Target(host); ok {
+		if prefixes == nil {
+			im.skip(entry, "invalid IP range")
+		}
+		for _, p := range prefixes {
+			im.addCIDR(p, t, enabled, entry)
+		}
+		return
+	}
+
+	host = strings.ToLower(host)
+	switch {
+	case !strings.Contains(host, "*"):
+		im.addDomain("full:"+host, t, enabled, entry, ids...)
+	case strings.HasPrefix(host, "*.") && !strings.Contains(host[2:], "*"):
+		im.addDomain("domain:"+host[2:], t, enabled, entry, ids...)
+	case strings.HasPrefix(host, "*") && strings.HasSuffix(host, "*") && !strings.Contains(host[1:len(host)-1], "*"):
+		im.addDomain("keyword:"+host[1:len(host)-1], t, enabled, entry, ids...)
+	case strings.HasPrefix(host, "*") && !strings.Contains(host[1:], "*"):
+		// "*example.com" matches example.com and anything ending in it; a
+		// suffix rule covers the subdomains.
+		im.addDomain("domain:"+strings.TrimPrefix(host[1:], "."), t, enabled, entry, ids...)
+	default:
+		im.skip(entry, "wildcard pattern cannot be represented")
+	}
+}
+
+// ipv4Target parses IP-like targets. ok is false for host names; a nil
+// result with ok means an invalid IP target.
+func ipv4Target(s string) (prefixes []netip.Prefix, ok bool) {
+	if p, isIP := parsePrefix(s); isIP {
+		return []netip.Prefix{p}, true
+	}
+	if lo, hi, found := strings.Cut(s, "-"); found {
+		a, err1 := netip.ParseAddr(strings.TrimSpace(lo))
+		b, err2 := netip.ParseAddr(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil {
+			return nil, err1 == nil || err2 == nil
+		}
+		if !a.Is4() || !b.Is4() || b.Less(a) {
+			return nil, true
+		}
+		return rangePrefixes(a, b), true
+	}
+	// "10.*.*.*", "192.168.1.*"
+	octets := strings.Split(s, ".")
+	if len(octets) != 4 || !strings.Contains(s, "*") {
+		return nil, false
+	}
+	var addr [4]byte
+	bits := 0
+	for i, o := range octets {
+		if o == "*" {
+			for _, rest := range octets[i:] {
+				if rest != "*" {
+					return nil, true
+				}
+			}
+			break
+		}
+		var v int
+		if _, err := fmt.Sscanf(o, "%d", &v); err != nil || v < 0 || v > 255 {
+			return nil, false
+		}
+		addr[i] = byte(v)
+		bits += 8
+	}
+	return []netip.Prefix{netip.PrefixFrom(netip.AddrFrom4(addr), bits)}, true
+}
+
+// rangePrefixes returns the smallest set of prefixes covering [a, b].
+func rangePrefixes(a, b netip.Addr) []netip.Prefix {
+	lo, hi := v4uint(a), v4uint(b)
+	var out []netip.Prefix
+	for lo <= hi {
+		bits := 32
+		for bits > 0 {
+			size := uint64(1) << (32 - (bits - 1))
+			if lo%size != 0 || lo+size-1 > hi {
+				break
+			}
+			bits--
+		}
+		out = append(out, netip.PrefixFrom(v4addr(lo), bits))
+		lo += uint64(1) << (32 - bits)
+	}
+	return out
+}
+
+func v4uint(a netip.Addr) uint64 {
+	b := a.As4()
+	return uint64(b[0])<<24 | uint64(b[1])<<16 | uint64(b[2])<<8 | uint64(b[3])
+}
+
+func v4addr(v uint64) netip.Addr {
+	return netip.AddrFrom4([4]byte{byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)})
+}
+
+// splitList splits a Proxifier list ("a.exe; b.exe" or "a.exe b.exe"),
+// keeping quoted entries with spaces together.
+func splitList(s string) []string {
+	var out []string
+	var cur strings.Builder
+	quoted := false
+	flush := func() {
+		if v := strings.TrimSpace(cur.String()); v != "" {
+			out = append(out, v)
+		}
+		cur.Reset()
+	}
+	for _, r := range s {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case !quoted && (r == ';' || r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,432 @@
+// Package ruleimport converts routing rules of other clients — Proxifier
+// profiles (.ppx), Clash "rules:", v2rayN routing JSON and WireSock
+// AllowedApps/DisallowedApps — into process rules, domain rules and global
+// filter entries.
+//
+// The source clients apply the first matching rule in file order. Here the
+// order is fixed by kind instead: the global filter (direct apps and IPs)
+// applies first, then domain rules (exact over suffix over keyword), then
+// process rules. Source order is kept within each kind; an imported rule that
+// takes precedence over an earlier source rule with a different target is
+// listed in Result.Warnings. Anything that has no equivalent here (port
+// conditions, regexes, proxied IP ranges, catch-all rules, ...) is listed in
+// Result.Skipped with the reason instead of being dropped silently.
+package ruleimport
+
+import (
+	"fmt"
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// Format names a source client.
+type Format string
+
+// Supported formats.
+const (
+	FormatProxifier Format = "proxifier"
+	FormatClash     Format = "clash"
+	FormatV2rayN    Format = "v2rayn"
+	FormatWireSock  Format = "wiresock"
+)
+
+// ParseFormat parses a format name; "" is returned as is, for Detect.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "", FormatProxifier, FormatClash, FormatV2rayN, FormatWireSock:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown rule import format %q (proxifier, clash, v2rayn, wiresock)", s)
+}
+
+// Detect guesses the format of data from the file name, then the content.
+func Detect(name string, data []byte) Format {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".ppx", ".xml":
+		return FormatProxifier
+	case ".yaml", ".yml":
+		return FormatClash
+	case ".json":
+		return FormatV2rayN
+	case ".conf":
+		return FormatWireSock
+	}
+	s := strings.TrimSpace(strings.TrimPrefix(string(data), "\ufeff"))
+	switch {
+	case strings.HasPrefix(s, "<"):
+		return FormatProxifier
+	case strings.HasPrefix(s, "{"), strings.HasPrefix(s, "["):
+		if strings.HasPrefix(s, "[Interface]") || strings.HasPrefix(s, "[Peer]") {
+			return FormatWireSock
+		}
+		return FormatV2rayN
+	}
+	return FormatClash
+}
+
+// Options control how the source's proxies map to tunnels.
+type Options struct {
+	// Tunnels maps proxy, policy group or outbound names of the source
+	// (Proxifier proxy label or "host:port", Clash target, v2rayN
+	// outboundTag) to tunnel IDs. Names match case-insensitively.
+	Tunnels map[string]string
+	// DefaultTunnel receives proxied rules whose target is not in Tunnels,
+	// and WireSock AllowedApps. Empty: such rules are skipped.
+	DefaultTunnel string
+}
+
+// Result is the outcome of an import.
+type Result struct {
+	Rules       []core.Rule
+	DomainRules []core.DomainRule
+	// Filter holds entries for the global filter: DisallowedApps and
+	// DisallowedIPs from rules that send traffic direct.
+	Filter  core.GlobalFilterConfig
+	Skipped []Skipped
+	// Warnings are imported rules that override an earlier source rule
+	// with a different target, so the traffic both match is routed
+	// differently than in the source.
+	Warnings []Skipped
+}
+
+// Skipped is a source rule that was not imported.
+type Skipped struct {
+	Source string // the rule as written in the source, or its name
+	Reason string
+}
+
+func (s Skipped) String() string {
+	return s.Source + ": " + s.Reason
+}
+
+// Parse converts data in the given format. It fails only if data cannot be
+// read as that format at all; rules that cannot be converted are reported
+// in Result.Skipped.
+func Parse(format Format, data []byte, opts Options) (*Result, error) {
+	im := newImporter(opts)
+	var err error
+	switch format {
+	case FormatProxifier:
+		err = im.proxifier(data)
+	case FormatClash:
+		err = im.clash(data)
+	case FormatV2rayN:
+		err = im.v2rayN(data)
+	case FormatWireSock:
+		err = im.wireSock(data)
+	default:
+		err = fmt.Errorf("unknown rule import format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &im.res, nil
+}
+
+// MergeInto appends the imported rules and filter entries to cfg. Rules
+// whose pattern cfg already has are kept as they are and returned as
+// skipped; the existing rule comes first and would win anyway.
+func (r *Result) MergeInto(cfg *core.Config) []Skipped {
+	var skipped []Skipped
+	// cfg is typically a shallow copy of the live config; clip so appends
+	// never write into a backing array it shares.
+	cfg.Rules = slices.Clip(cfg.Rules)
+	cfg.DomainRules = slices.Clip(cfg.DomainRules)
+	cfg.Global.DisallowedApps = slices.Clip(cfg.Global.DisallowedApps)
+	cfg.Global.DisallowedIPs = slices.Clip(cfg.Global.DisallowedIPs)
+
+	have := make(map[string]bool, len(cfg.Rules))
+	for _, x := range cfg.Rules {
+		have[strings.ToLower(x.Pattern)] = true
+	}
+	for _, x := range r.Rules {
+		if have[strings.ToLower(x.Pattern)] {
+			skipped = append(skipped, Skipped{x.Pattern, "a rule for this pattern already exists"})
+			continue
+		}
+		cfg.Rules = append(cfg.Rules, x)
+	}
+
+	have = make(map[string]bool, len(cfg.DomainRules))
+	for _, x := range cfg.DomainRules {
+		have[strings.ToLower(x.Pattern)] = true
+	}
+	for _, x := range r.DomainRules {
+		if have[strings.ToLower(x.Pattern)] {
+			skipped = append(skipped, Skipped{x.Pattern, "a domain rule for this pattern already exists"})
+			continue
+		}
+		cfg.DomainRules = append(cfg.DomainRules, x)
+	}
+
+	cfg.Global.DisallowedApps = appendUnique(cfg.Global.DisallowedApps, r.Filter.DisallowedApps)
+	cfg.Global.DisallowedIPs = appendUnique(cfg.Global.DisallowedIPs, r.Filter.DisallowedIPs)
+	return skipped
+}
+
+func appendUnique(dst, src []string) []string {
+	seen := make(map[string]bool, len(dst))
+	for _, s := range dst {
+		seen[strings.ToLower(s)] = true
+	}
+	for _, s := range src {
+		if !seen[strings.ToLower(s)] {
+			seen[strings.ToLower(s)] = true
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
+
+// ---------------------------------------------------------------------------
+// Shared conversion
+// ---------------------------------------------------------------------------
+
+type action int
+
+const (
+	actProxy action = iota
+	actDirect
+	actBlock
+)
+
+// target is where a source rule sends traffic.
+type target struct {
+	act  action
+	name string // proxy / outbound name for actProxy
+}
+
+type importer struct {
+	opts    Options
+	tunnels map[string]string // lowercased name → tunnel ID
+	res     Result
+	seen    map[string]bool // rule, domain rule and filter keys already added
+	placed  []placement     // enabled imported entries, in source order
+}
+
+func newImporter(opts Options) *importer {
+	im := &importer{
+		opts:    opts,
+		tunnels: make(map[string]string, len(opts.Tunnels)),
+		seen:    make(map[string]bool),
+	}
+	for name, id := range opts.Tunnels {
+		im.tunnels[strings.ToLower(name)] = id
+	}
+	return im
+}
+
+func (im *importer) skip(source, reason string) {
+	im.res.Skipped = append(im.res.Skipped, Skipped{Source: source, Reason: reason})
+}
+
+// tunnel returns the tunnel for a proxied rule; names are tried in order.
+func (im *importer) tunnel(source string, names ...string) (string, bool) {
+	for _, n := range names {
+		if id, ok := im.tunnels[strings.ToLower(n)]; ok && n != "" {
+			return id, true
+		}
+	}
+	if im.opts.DefaultTunnel != "" {
+		return im.opts.DefaultTunnel, true
+	}
+	im.skip(source, fmt.Sprintf("no tunnel for proxy %q: map it to a tunnel or set a default tunnel", names[0]))
+	return "", false
+}
+
+// once reports whether key is new, recording a skip for duplicates.
+func (im *importer) once(key, source string) bool {
+	key = strings.ToLower(key)
+	if im.seen[key] {
+		im.skip(source, "duplicate of an earlier rule")
+		return false
+	}
+	im.seen[key] = true
+	return true
+}
+
+func enabledPtr(enabled bool) *bool {
+	if enabled {
+		return nil
+	}
+	return &enabled
+}
+
+// addProcess converts a process condition: proxied processes become rules,
+// blocked ones drop rules, direct ones global disallowed_apps.
+func (im *importer) addProcess(pattern string, t target, enabled bool, source string, proxyNames ...string) {
+	pattern = strings.Trim(strings.TrimSpace(pattern), `"`)
+	if pattern == "" {
+		return
+	}
+	switch t.act {
+	case actDirect:
+		if !enabled {
+			im.skip(source, "disabled direct rules have no equivalent (disallowed_apps has no on/off switch)")
+			return
+		}
+		if im.once("app:"+pattern, source) {
+			im.res.Filter.DisallowedApps = append(im.res.Filter.DisallowedApps, pattern)
+			im.place(placement{source, rankFilter, "app", pattern, "direct"}, true)
+		}
+	case actBlock:
+		if im.once("rule:"+pattern, source) {
+			im.res.Rules = append(im.res.Rules, core.Rule{Pattern: pattern, Fallback: core.PolicyDrop, Enabled: enabledPtr(enabled)})
+			im.place(placement{source, rankProcess, "process", pattern, "block"}, enabled)
+		}
+	default:
+		id, ok := im.tunnel(source, append([]string{t.name}, proxyNames...)...)
+		if ok && im.once("rule:"+pattern, source) {
+			im.res.Rules = append(im.res.Rules, core.Rule{Pattern: pattern, TunnelID: id, Enabled: enabledPtr(enabled)})
+			im.place(placement{source, rankProcess, "process", pattern, "tunnel " + id}, enabled)
+		}
+	}
+}
+
+// addDomain converts a domain condition ("domain:x", "full:x", "keyword:x",
+// "geosite:x", "geoip:x").
+func (im *importer) addDomain(pattern string, t target, enabled bool, source string, proxyNames ...string) {
+	dr := core.DomainRule{Pattern: pattern, Enabled: enabledPtr(enabled)}
+	outcome := "direct"
+	switch t.act {
+	case actDirect:
+		dr.Action = core.DomainDirect
+	case actBlock:
+		dr.Action = core.DomainBlock
+		outcome = "block"
+	default:
+		id, ok := im.tunnel(source, append([]string{t.name}, proxyNames...)...)
+		if !ok {
+			return
+		}
+		dr.Action = core.DomainRoute
+		dr.TunnelID = id
+		outcome = "tunnel " + id
+	}
+	if im.once("domain:"+pattern, source) {
+		im.res.DomainRules = append(im.res.DomainRules, dr)
+		kind, value, _ := strings.Cut(pattern, ":")
+		im.place(placement{source, rankDomain, kind, value, outcome}, enabled)
+	}
+}
+
+// addCIDR converts an IP condition. Only direct has an equivalent
+// (disallowed_ips); IP ranges cannot be routed to a tunnel or blocked.
+func (im *importer) addCIDR(prefix netip.Prefix, t target, enabled bool, source string) {
+	switch {
+	case t.act == actProxy:
+		im.skip(source, "IP ranges cannot be routed to a tunnel by rule; use a domain or process rule, or the tunnel's allowed_ips")
+	case t.act == actBlock:
+		im.skip(source, "IP ranges cannot be blocked by rule")
+	case !enabled:
+		im.skip(source, "disabled direct rules have no equivalent (disallowed_ips has no on/off switch)")
+	default:
+		s := prefix.Masked().String()
+		if im.once("ip:"+s, source) {
+			im.res.Filter.DisallowedIPs = append(im.res.Filter.DisallowedIPs, s)
+			im.place(placement{source, rankFilter, "ip", s, "direct"}, true)
+		}
+	}
+}
+
+// ---------------------------------------------------------------------------
+// Precedence
+// ---------------------------------------------------------------------------
+
+// Precedence classes of imported entries, highest first.
+const (
+	rankFilter  = iota // global disallowed_apps / disallowed_ips
+	rankDomain         // domain rules
+	rankProcess        // process rules
+)
+
+var rankNames = [...]string{
+	rankFilter:  "direct apps and IPs",
+	rankDomain:  "domain rules",
+	rankProcess: "process rules",
+}
+
+// placement is an imported entry as seen by the precedence check.
+type placement struct {
+	source  string
+	rank    int
+	kind    string // "app", "ip", "process", or a domain pattern type ("domain", "full", ...)
+	value   string
+	outcome string // "direct", "block" or "tunnel <id>"
+}
+
+// place records an enabled entry and warns if it overrides an earlier source
+// rule that sends the same traffic elsewhere.
+func (im *importer) place(p placement, enabled bool) {
+	if !enabled {
+		return
+	}
+	for _, prev := range im.placed {
+		if prev.outcome == p.outcome {
+			continue
+		}
+		var why string
+		switch {
+		case p.rank < prev.rank && mayOverlap(p, prev):
+			why = fmt.Sprintf("%s are applied before %s", rankNames[p.rank], rankNames[prev.rank])
+		case p.rank == rankDomain && prev.rank == rankDomain && domainOverrides(p, prev):
+			why = "exact and more specific domain rules are applied before broader ones"
+		default:
+			continue
+		}
+		im.res.Warnings = append(im.res.Warnings, Skipped{
+			Source: p.source,
+			Reason: fmt.Sprintf("overrides earlier rule %q (%s -> %s): %s", prev.source, p.outcome, prev.outcome, why),
+		})
+		break
+	}
+	im.placed = append(im.placed, p)
+}
+
+// mayOverlap reports whether entries of different classes can match the same
+// traffic. Two process conditions overlap only for the same process; a
+// process and a destination always may.
+func mayOverlap(a, b placement) bool {
+	isProc := func(p placement) bool { return p.kind == "app" || p.kind == "process" }
+	if isProc(a) && isProc(b) {
+		return strings.EqualFold(a.value, b.value)
+	}
+	return true
+}
+
+// domainOverrides reports whether domain rule later wins over the earlier
+// one for some name both match, following DomainMatcher: exact names first,
+// then the most specific suffix, then keywords. Geosite and GeoIP contents
+// are unknown here and never reported.
+func domainOverrides(later, earlier placement) bool {
+	under := func(name, suffix string) bool {
+		return name == suffix || strings.HasSuffix(name, "."+suffix)
+	}
+	switch {
+	case later.kind == "full" && earlier.kind == "domain":
+		return under(later.value, earlier.value)
+	case later.kind == "full" && earlier.kind == "keyword":
+		return strings.Contains(later.value, earlier.value)
+	case later.kind == "domain" && earlier.kind == "domain":
+		return later.value != earlier.value && under(later.value, earlier.value)
+	case later.kind == "domain" && earlier.kind == "keyword":
+		// Any suffix has names containing the keyword (keyword.suffix).
+		return true
+	}
+	return false
+}
+
+// parsePrefix parses "1.2.3.0/24" or a bare address.
+func parsePrefix(s string) (netip.Prefix, bool) {
+	if p, err := netip.ParsePrefix(s); err == nil {
+		return p, true
+	}
+	if a, err := netip.ParseAddr(s); err == nil {
+		return netip.PrefixFrom(a, a.BitLen()), true
+	}
+	return netip.Prefix{}, false
+}
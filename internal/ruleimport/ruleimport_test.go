@@ -0,0 +1,315 @@
+package ruleimport
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func rulePatterns(r *Result) []string {
+	var out []string
+	for _, x := range r.Rules {
+		if x.Fallback == core.PolicyDrop {
+			out = append(out, x.Pattern+">drop")
+			continue
+		}
+		out = append(out, x.Pattern+">"+x.TunnelID)
+	}
+	return out
+}
+
+func domainPatterns(r *Result) []string {
+	var out []string
+	for _, x := range r.DomainRules {
+		out = append(out, x.Pattern+">"+x.Action.String()+x.TunnelID)
+	}
+	return out
+}
+
+func hasSkip(r *Result, source string) bool {
+	for _, s := range r.Skipped {
+		if strings.Contains(s.Source, source) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       Format
+	}{
+		{"profile.ppx", "", FormatProxifier},
+		{"config.yaml", "", FormatClash},
+		{"routing.json", "", FormatV2rayN},
+		{"wg0.conf", "", FormatWireSock},
+		{"", `<?xml version="1.0"?><ProxifierProfile/>`, FormatProxifier},
+		{"", `[{"outboundTag":"direct"}]`, FormatV2rayN},
+		{"", "[Interface]\nAllowedApps = a.exe", FormatWireSock},
+		{"", "DOMAIN-SUFFIX,example.com,DIRECT", FormatClash},
+	}
+	for _, tt := range tests {
+		if got := Detect(tt.name, []byte(tt.data)); got != tt.want {
+			t.Errorf("Detect(%q, %q) = %s, want %s", tt.name, tt.data, got, tt.want)
+		}
+	}
+}
+
+func TestProxifier(t *testing.T) {
+	ppx := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<ProxifierProfile version="102" platform="Windows">
+  <ProxyList>
+    <Proxy id="100" type="SOCKS5"><Address>10.0.0.1</Address><Port>1080</Port><Label>Office</Label></Proxy>
+    <Proxy id="101" type="HTTPS"><Address>proxy.example.com</Address><Port>3128</Port></Proxy>
+  </ProxyList>
+  <RuleList>
+    <Rule enabled="true"><Name>Localhost</Name><Targets>localhost; 127.0.0.1; %ComputerName%</Targets><Action type="Direct" /></Rule>
+    <Rule enabled="true"><Name>LAN</Name><Targets>192.168.*.*; 10.0.0.0-10.0.1.255</Targets><Action type="Direct" /></Rule>
+    <Rule enabled="true"><Name>Browsers</Name><Applications>chrome.exe; "Mozilla Firefox\firefox.exe"</Applications><Action type="Proxy">100</Action></Rule>
+    <Rule enabled="false"><Name>Telegram</Name><Applications>telegram.exe</Applications><Action type="Proxy">101</Action></Rule>
+    <Rule enabled="true"><Name>Ads</Name><Targets>*.doubleclick.net; ads*.example.*</Targets><Action type="Block" /></Rule>
+    <Rule enabled="true"><Name>Git</Name><Applications>git.exe</Applications><Ports>22</Ports><Action type="Proxy">100</Action></Rule>
+    <Rule enabled="true"><Name>Default</Name><Action type="Direct" /></Rule>
+  </RuleList>
+</ProxifierProfile>`
+
+	res, err := Parse(FormatProxifier, []byte(ppx), Options{Tunnels: map[string]string{"office": "awg1", "101": "socks1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"chrome.exe>awg1", `Mozilla Firefox\firefox.exe>awg1`, "telegram.exe>socks1"}; !slices.Equal(rulePatterns(res), want) {
+		t.Errorf("rules = %v, want %v", rulePatterns(res), want)
+	}
+	if res.Rules[2].Enabled == nil || *res.Rules[2].Enabled {
+		t.Error("disabled Proxifier rule imported as enabled")
+	}
+	if want := []string{"full:localhost>direct", "domain:doubleclick.net>block"}; !slices.Equal(domainPatterns(res), want) {
+		t.Errorf("domain rules = %v, want %v", domainPatterns(res), want)
+	}
+	if want := []string{"127.0.0.1/32", "192.168.0.0/16", "10.0.0.0/23"}; !slices.Equal(res.Filter.DisallowedIPs, want) {
+		t.Errorf("disallowed IPs = %v, want %v", res.Filter.DisallowedIPs, want)
+	}
+	for _, s := range []string{"%ComputerName%", "ads*.example.*", `"Git"`, `"Default"`} {
+		if !hasSkip(res, s) {
+			t.Errorf("%s not reported as skipped: %v", s, res.Skipped)
+		}
+	}
+}
+
+// TestOrderingConflicts checks that rules which win here over an earlier,
+// conflicting source rule are reported, and compatible orderings are not.
+func TestOrderingConflicts(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		warn  map[string]string // warned rule → substring of the reason
+	}{
+		{
+			name:  "domain after process",
+			rules: []string{"PROCESS-NAME,telegram.exe,Proxy", "DOMAIN-SUFFIX,example.com,DIRECT"},
+			warn:  map[string]string{"DOMAIN-SUFFIX,example.com,DIRECT": "domain rules are applied before process rules"},
+		},
+		{
+			name:  "direct process after proxied process",
+			rules: []string{"PROCESS-NAME,Telegram.exe,Proxy", "PROCESS-NAME,telegram.exe,DIRECT"},
+			warn:  map[string]string{"PROCESS-NAME,telegram.exe,DIRECT": "direct apps and IPs are applied before process rules"},
+		},
+		{
+			name:  "direct IP after blocked domain",
+			rules: []string{"DOMAIN,ads.example.com,REJECT", "IP-CIDR,203.0.113.0/24,DIRECT"},
+			warn:  map[string]string{"IP-CIDR,203.0.113.0/24,DIRECT": "direct apps and IPs are applied before domain rules"},
+		},
+		{
+			name:  "suffix after keyword",
+			rules: []string{"DOMAIN-KEYWORD,google,Proxy", "DOMAIN-SUFFIX,google.com,DIRECT"},
+			warn:  map[string]string{"DOMAIN-SUFFIX,google.com,DIRECT": "more specific domain rules"},
+		},
+		{
+			name:  "narrower suffix after broader",
+			rules: []string{"DOMAIN-SUFFIX,example.com,Proxy", "DOMAIN,www.example.com,DIRECT", "DOMAIN-SUFFIX,cdn.example.com,REJECT"},
+			warn: map[string]string{
+				"DOMAIN,www.example.com,DIRECT":        "more specific domain rules",
+				"DOMAIN-SUFFIX,cdn.example.com,REJECT": "more specific domain rules",
+			},
+		},
+		{
+			name: "compatible order",
+			rules: []string{
+				"IP-CIDR,192.168.0.0/16,DIRECT",
+				"PROCESS-NAME,steam.exe,DIRECT",
+				"DOMAIN-SUFFIX,google.com,DIRECT",
+				"DOMAIN-KEYWORD,google,Proxy",
+				"DOMAIN-SUFFIX,example.com,Proxy",
+				"DOMAIN,www.example.com,Proxy",
+				"PROCESS-NAME,discord.exe,Proxy",
+				"PROCESS-NAME,steam.exe,Proxy",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := "rules:\n  - " + strings.Join(tt.rules, "\n  - ") + "\n"
+			res, err := Parse(FormatClash, []byte(cfg), Options{DefaultTunnel: "awg1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(res.Warnings) != len(tt.warn) {
+				t.Errorf("warnings = %v, want %d", res.Warnings, len(tt.warn))
+			}
+			for _, w := range res.Warnings {
+				if reason, ok := tt.warn[w.Source]; !ok || !strings.Contains(w.Reason, reason) {
+					t.Errorf("unexpected warning %s", w)
+				}
+			}
+		})
+	}
+}
+
+func TestClash(t *testing.T) {
+	cfg := `
+proxies: []
+rules:
+  - DOMAIN-SUFFIX,google.com,Proxy
+  - DOMAIN,ads.example.com,REJECT
+  - DOMAIN-KEYWORD,youtube,Proxy
+  - IP-CIDR,192.168.0.0/16,DIRECT,no-resolve
+  - IP-CIDR,91.108.4.0/22,Proxy
+  - GEOIP,RU,DIRECT
+  - PROCESS-NAME,steam.exe,DIRECT
+  - PROCESS-NAME,discord.exe,Proxy
+  - DST-PORT,22,DIRECT
+  - MATCH,Proxy
+`
+	res, err := Parse(FormatClash, []byte(cfg), Options{DefaultTunnel: "awg1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"domain:google.com>routeawg1", "full:ads.example.com>block", "keyword:youtube>routeawg1", "geoip:ru>direct"}
+	if !slices.Equal(domainPatterns(res), want) {
+		t.Errorf("domain rules = %v, want %v", domainPatterns(res), want)
+	}
+	if want := []string{"discord.exe>awg1"}; !slices.Equal(rulePatterns(res), want) {
+		t.Errorf("rules = %v, want %v", rulePatterns(res), want)
+	}
+	if !slices.Equal(res.Filter.DisallowedApps, []string{"steam.exe"}) || !slices.Equal(res.Filter.DisallowedIPs, []string{"192.168.0.0/16"}) {
+		t.Errorf("filter = %+v", res.Filter)
+	}
+	for _, s := range []string{"91.108.4.0/22", "DST-PORT", "MATCH"} {
+		if !hasSkip(res, s) {
+			t.Errorf("%s not reported as skipped: %v", s, res.Skipped)
+		}
+	}
+
+	// Without a tunnel for "Proxy" the proxied rules are reported.
+	res, _ = Parse(FormatClash, []byte(cfg), Options{})
+	if len(res.Rules) != 0 || !hasSkip(res, "discord.exe") {
+		t.Errorf("unmapped proxy: rules %v, skipped %v", res.Rules, res.Skipped)
+	}
+}
+
+func TestV2rayN(t *testing.T) {
+	data := `[
+  {"remarks": "block ads", "outboundTag": "block", "domain": ["geosite:category-ads-all"]},
+  {"remarks": "ru direct", "outboundTag": "direct", "domain": ["domain:ru", "regexp:.*\\.su$"], "enabled": true},
+  {"outboundTag": "direct", "ip": ["geoip:private", "geoip:!ru", "10.0.0.0/8"]},
+  {"outboundTag": "proxy", "process": ["chrome.exe"]},
+  {"outboundTag": "proxy", "domain": ["openai"], "enabled": false},
+  {"outboundTag": "proxy", "port": "0-65535"}
+]`
+	res, err := Parse(FormatV2rayN, []byte(data), Options{Tunnels: map[string]string{"proxy": "vless1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"geosite:category-ads-all>block", "domain:ru>direct", "geoip:private>direct", "keyword:openai>routevless1"}
+	if !slices.Equal(domainPatterns(res), want) {
+		t.Errorf("domain rules = %v, want %v", domainPatterns(res), want)
+	}
+	if last := res.DomainRules[3]; last.Enabled == nil || *last.Enabled {
+		t.Error("disabled v2rayN rule imported as enabled")
+	}
+	if want := []string{"chrome.exe>vless1"}; !slices.Equal(rulePatterns(res), want) {
+		t.Errorf("rules = %v, want %v", rulePatterns(res), want)
+	}
+	for _, s := range []string{"regexp:", "geoip:!ru", "rule 6"} {
+		if !hasSkip(res, s) {
+			t.Errorf("%s not reported as skipped: %v", s, res.Skipped)
+		}
+	}
+
+	// Xray config layout.
+	res, err = Parse(FormatV2rayN, []byte(`{"routing": {"rules": [{"outboundTag": "direct", "domain": ["full:example.com"]}]}}`), Options{})
+	if err != nil || len(res.DomainRules) != 1 {
+		t.Errorf("routing.rules: %v, %+v", err, res)
+	}
+}
+
+func TestWireSock(t *testing.T) {
+	conf := `[Interface]
+PrivateKey = xxx
+Address = 10.8.0.2/32
+AllowedApps = chrome.exe, C:\Program Files\App\app.exe
+DisallowedApps = steam.exe
+DisallowedIPs = 192.168.0.0/16, 10.0.0.1
+
+[Peer]
+PublicKey = yyy
+AllowedIPs = 0.0.0.0/0, 172.16.0.0/12
+Endpoint = vpn.example.com:51820
+`
+	res, err := Parse(FormatWireSock, []byte(conf), Options{DefaultTunnel: "awg1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"chrome.exe>awg1", `C:\Program Files\App\app.exe>awg1`}; !slices.Equal(rulePatterns(res), want) {
+		t.Errorf("rules = %v, want %v", rulePatterns(res), want)
+	}
+	if !slices.Equal(res.Filter.DisallowedApps, []string{"steam.exe"}) ||
+		!slices.Equal(res.Filter.DisallowedIPs, []string{"192.168.0.0/16", "10.0.0.1/32"}) {
+		t.Errorf("filter = %+v", res.Filter)
+	}
+	if !hasSkip(res, "172.16.0.0/12") {
+		t.Errorf("partial AllowedIPs not reported: %v", res.Skipped)
+	}
+
+	if _, err := Parse(FormatWireSock, []byte("[Interface]\nPrivateKey = xxx\n"), Options{}); err == nil {
+		t.Error("config without split-tunnel keys accepted")
+	}
+}
+
+func TestMergeInto(t *testing.T) {
+	cfg := &core.Config{
+		Rules:       []core.Rule{{Pattern: "chrome.exe", TunnelID: "awg0"}},
+		DomainRules: []core.DomainRule{{Pattern: "domain:ru", Action: core.DomainDirect}},
+	}
+	cfg.Global.DisallowedApps = []string{"Steam.exe"}
+
+	res, err := Parse(FormatClash, []byte(`rules:
+  - PROCESS-NAME,chrome.exe,p
+  - PROCESS-NAME,discord.exe,p
+  - DOMAIN-SUFFIX,ru,DIRECT
+  - DOMAIN-SUFFIX,example.com,p
+  - PROCESS-NAME,steam.exe,DIRECT
+  - IP-CIDR,10.0.0.0/8,DIRECT
+`), Options{DefaultTunnel: "awg1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	skipped := res.MergeInto(cfg)
+
+	if len(skipped) != 2 {
+		t.Errorf("skipped = %v, want the two existing patterns", skipped)
+	}
+	if len(cfg.Rules) != 2 || cfg.Rules[0].TunnelID != "awg0" || cfg.Rules[1].Pattern != "discord.exe" {
+		t.Errorf("rules = %+v", cfg.Rules)
+	}
+	if len(cfg.DomainRules) != 2 || cfg.DomainRules[1].Pattern != "domain:example.com" {
+		t.Errorf("domain rules = %+v", cfg.DomainRules)
+	}
+	if !slices.Equal(cfg.Global.DisallowedApps, []string{"Steam.exe"}) || !slices.Equal(cfg.Global.DisallowedIPs, []string{"10.0.0.0/8"}) {
+		t.Errorf("global filter = %+v", cfg.Global)
+	}
+}
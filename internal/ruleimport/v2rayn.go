@@ -0,0 +1,153 @@
+package ruleimport
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// v2rayRule is one routing rule of v2rayN (or a plain Xray routing config).
+type v2rayRule struct {
+	Remarks     string   `json:"remarks"`
+	Enabled     *bool    `json:"enabled"`
+	OutboundTag string   `json:"outboundTag"`
+	Domain      []string `json:"domain"`
+	IP          []string `json:"ip"`
+	Process     []string `json:"process"`
+
+	Port        string          `json:"port"`
+	Network     string          `json:"network"`
+	Protocol    []string        `json:"protocol"`
+	Source      []string        `json:"source"`
+	InboundTag  json.RawMessage `json:"inboundTag"`
+	BalancerTag string          `json:"balancerTag"`
+}
+
+// v2rayN converts a v2rayN routing rule list (the "Export selected rules"
+// array), an object with "rules", or an Xray config with "routing.rules".
+func (im *importer) v2rayN(data []byte) error {
+	rules, err := v2rayRules(data)
+	if err != nil {
+		return err
+	}
+	for i, r := range rules {
+		im.v2rayRule(i, r)
+	}
+	return nil
+}
+
+func v2rayRules(data []byte) ([]v2rayRule, error) {
+	var list []v2rayRule
+	if err := json.Unmarshal(data, &list); err == nil {
+		return list, nil
+	}
+	var obj struct {
+		Rules   []v2rayRule `json:"rules"`
+		Routing struct {
+			Rules []v2rayRule `json:"rules"`
+		} `json:"routing"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, fmt.Errorf("v2rayn: %w", err)
+	}
+	if len(obj.Rules) > 0 {
+		return obj.Rules, nil
+	}
+	if len(obj.Routing.Rules) > 0 {
+		return obj.Routing.Rules, nil
+	}
+	return nil, fmt.Errorf("v2rayn: no routing rules found")
+}
+
+func (im *importer) v2rayRule(i int, r v2rayRule) {
+	source := fmt.Sprintf("rule %d", i+1)
+	if r.Remarks != "" {
+		source = fmt.Sprintf("rule %q", r.Remarks)
+	}
+	enabled := r.Enabled == nil || *r.Enabled
+
+	var t target
+	switch strings.ToLower(r.OutboundTag) {
+	case "direct":
+		t.act = actDirect
+	case "block":
+		t.act = actBlock
+	case "":
+		if r.BalancerTag != "" {
+			im.skip(source, "balancer targets are not supported")
+		} else {
+			im.skip(source, "rule has no outboundTag")
+		}
+		return
+	default:
+		t = target{act: actProxy, name: r.OutboundTag}
+	}
+
+	switch {
+	case r.Port != "" || r.Network != "" || len(r.Protocol) > 0 || len(r.Source) > 0 || len(r.InboundTag) > 0:
+		im.skip(source, "port, network, protocol, source and inbound conditions are not supported")
+		return
+	case nonEmpty(len(r.Domain), len(r.IP), len(r.Process)) > 1:
+		im.skip(source, "rules combining domain, IP and process conditions cannot be represented")
+		return
+	case nonEmpty(len(r.Domain), len(r.IP), len(r.Process)) == 0:
+		im.skip(source, "catch-all rule: unmatched traffic follows the tunnels' own routing")
+		return
+	}
+
+	for _, d := range r.Domain {
+		entry := fmt.Sprintf("%s: %s", source, d)
+		if pattern, ok := v2rayDomain(d); ok {
+			im.addDomain(pattern, t, enabled, entry)
+		} else {
+			im.skip(entry, "regexp and external domain lists are not supported")
+		}
+	}
+	for _, s := range r.IP {
+		entry := fmt.Sprintf("%s: %s", source, s)
+		lower := strings.ToLower(s)
+		switch {
+		case strings.HasPrefix(lower, "geoip:!"), strings.HasPrefix(s, "!"):
+			im.skip(entry, "negated IP conditions are not supported")
+		case strings.HasPrefix(lower, "geoip:"):
+			im.addDomain(lower, t, enabled, entry)
+		case strings.HasPrefix(lower, "ext:"):
+			im.skip(entry, "external IP lists are not supported")
+		default:
+			p, ok := parsePrefix(s)
+			if !ok {
+				im.skip(entry, "invalid IP range")
+				continue
+			}
+			im.addCIDR(p, t, enabled, entry)
+		}
+	}
+	for _, p := range r.Process {
+		im.addProcess(p, t, enabled, fmt.Sprintf("%s: %s", source, p))
+	}
+}
+
+// v2rayDomain maps a v2ray domain matcher to a domain rule pattern. A bare
+// value is a substring match in v2ray, i.e. keyword:.
+func v2rayDomain(d string) (string, bool) {
+	d = strings.ToLower(strings.TrimSpace(d))
+	kind, value, found := strings.Cut(d, ":")
+	if !found {
+		return "keyword:" + d, d != ""
+	}
+	switch kind {
+	case "domain", "full", "keyword", "geosite":
+		return d, value != ""
+	}
+	return "", false
+}
+
+func nonEmpty(n ...int) int {
+	c := 0
+	for _, v := range n {
+		if v > 0 {
+			c++
+		}
+	}
+	return c
+}
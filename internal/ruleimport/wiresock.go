@@ -0,0 +1,89 @@
+package ruleimport
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// wireSock converts the split-tunnel keys of a WireSock WireGuard config:
+// AllowedApps (only these apps use the tunnel) become process rules to
+// Options.DefaultTunnel; DisallowedApps and DisallowedIPs go to the global
+// filter.
+func (im *importer) wireSock(data []byte) error {
+	found := false
+	section := ""
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(strings.Trim(line, "[] "))
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		values := splitComma(value)
+
+		switch {
+		case section == "interface" && key == "allowedapps":
+			found = true
+			for _, app := range values {
+				im.wireSockApp(app)
+			}
+		case section == "interface" && key == "disallowedapps":
+			found = true
+			for _, app := range values {
+				im.addProcess(app, target{act: actDirect}, true, "DisallowedApps: "+app)
+			}
+		case section == "interface" && key == "disallowedips":
+			found = true
+			for _, s := range values {
+				p, ok := parsePrefix(s)
+				if !ok {
+					im.skip("DisallowedIPs: "+s, "invalid IP range")
+					continue
+				}
+				im.addCIDR(p, target{act: actDirect}, true, "DisallowedIPs: "+s)
+			}
+		case section == "peer" && key == "allowedips":
+			for _, s := range values {
+				if s != "0.0.0.0/0" && s != "::/0" {
+					im.skip("AllowedIPs: "+s, "partial AllowedIPs belong in the tunnel's allowed_ips, not in rules")
+				}
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("wiresock: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("wiresock: no AllowedApps, DisallowedApps or DisallowedIPs found")
+	}
+	return nil
+}
+
+func (im *importer) wireSockApp(app string) {
+	source := "AllowedApps: " + app
+	if im.opts.DefaultTunnel == "" {
+		im.skip(source, "no tunnel for AllowedApps: set a default tunnel")
+		return
+	}
+	im.addProcess(app, target{act: actProxy}, true, source)
+}
+
+func splitComma(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+// Package secret protects small secrets (TOTP seeds) stored in config.yaml.
+// Sealed values are self-describing strings ("dpapi:..." on Windows,
+// "aesgcm:..." elsewhere) so a config copied to another platform fails
+// clearly instead of producing garbage.
+package secret
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// ErrNotSealed is returned by Open for values without a known scheme prefix.
+var ErrNotSealed = errors.New("secret: value is not sealed")
+
+var (
+	keyMu   sync.Mutex
+	keyFile string
+)
+
+// SetKeyFile sets where the local encryption key is kept on platforms
+// without a system data-protection API. Call before the first Seal/Open.
+func SetKeyFile(path string) {
+	keyMu.Lock()
+	keyFile = path
+	keyMu.Unlock()
+}
+
+// IsSealed reports whether s looks like a value produced by Seal.
+func IsSealed(s string) bool {
+	scheme, _, ok := strings.Cut(s, ":")
+	return ok && (scheme == schemeDPAPI || scheme == schemeAESGCM)
+}
+
+// Seal encrypts plain and returns the printable sealed form.
+func Seal(plain []byte) (string, error) {
+	if len(plain) == 0 {
+		return "", errors.New("secret: nothing to seal")
+	}
+	return seal(plain)
+}
+
+// Open decrypts a value produced by Seal.
+func Open(sealed string) ([]byte, error) {
+	scheme, _, _ := strings.Cut(sealed, ":")
+	if scheme != schemeDPAPI && scheme != schemeAESGCM {
+		return nil, ErrNotSealed
+	}
+	if len(sealed) == len(scheme)+1 {
+		return nil, errors.New("secret: empty sealed value")
+	}
+	return open(sealed)
+}
+
+const (
+	schemeDPAPI  = "dpapi"
+	schemeAESGCM = "aesgcm"
+)
@@ -0,0 +1,84 @@
+//go:build !windows
+
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// seal encrypts with AES-256-GCM under a random key kept in a 0600 file
+// next to the config (the daemon runs as root).
+func seal(plain []byte) (string, error) {
+	aead, err := loadAEAD(true)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := aead.Seal(nonce, nonce, plain, []byte(schemeAESGCM))
+	return schemeAESGCM + ":" + base64.StdEncoding.EncodeToString(out), nil
+}
+
+func open(sealed string) ([]byte, error) {
+	if !strings.HasPrefix(sealed, schemeAESGCM+":") {
+		return nil, fmt.Errorf("secret: %q values can only be opened on the machine that sealed them", strings.SplitN(sealed, ":", 2)[0])
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, schemeAESGCM+":"))
+	if err != nil {
+		return nil, fmt.Errorf("secret: %w", err)
+	}
+	aead, err := loadAEAD(false)
+	if err != nil {
+		return nil, err
+	}
+	if len(blob) < aead.NonceSize() {
+		return nil, errors.New("secret: sealed value too short")
+	}
+	out, err := aead.Open(nil, blob[:aead.NonceSize()], blob[aead.NonceSize():], []byte(schemeAESGCM))
+	if err != nil {
+		return nil, errors.New("secret: decryption failed (key file changed?)")
+	}
+	return out, nil
+}
+
+// loadAEAD reads the key file, creating it when create is set.
+func loadAEAD(create bool) (cipher.AEAD, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if keyFile == "" {
+		return nil, errors.New("secret: key file not configured")
+	}
+	key, err := os.ReadFile(keyFile)
+	if errors.Is(err, os.ErrNotExist) && create {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(filepath.Dir(keyFile), 0700); err != nil {
+			return nil, fmt.Errorf("secret: %w", err)
+		}
+		if err := os.WriteFile(keyFile, key, 0600); err != nil {
+			return nil, fmt.Errorf("secret: write key file: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("secret: read key file: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("secret: key file %s is corrupt", keyFile)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,36 @@
+//go:build !windows
+
+package secret
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestSealOpenRoundTrip(t *testing.T) {
+	SetKeyFile(filepath.Join(t.TempDir(), "secret.key"))
+
+	sealed, err := Seal([]byte("GEZDGNBVGY3TQOJQ"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if !IsSealed(sealed) || IsSealed("GEZDGNBVGY3TQOJQ") {
+		t.Errorf("IsSealed mismatch for %q", sealed)
+	}
+	plain, err := Open(sealed)
+	if err != nil || string(plain) != "GEZDGNBVGY3TQOJQ" {
+		t.Fatalf("open = %q, %v", plain, err)
+	}
+
+	// A different key must not open the value.
+	SetKeyFile(filepath.Join(t.TempDir(), "other.key"))
+	if _, err := Seal([]byte("x")); err != nil {
+		t.Fatalf("seal with new key: %v", err)
+	}
+	if _, err := Open(sealed); err == nil {
+		t.Error("opened with the wrong key")
+	}
+	if _, err := Open("plain"); err != ErrNotSealed {
+		t.Errorf("Open(plain) err = %v", err)
+	}
+}
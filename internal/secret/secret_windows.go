@@ -0,0 +1,62 @@
+//go:build windows
+
+package secret
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// entropy is extra DPAPI input that keeps blobs of this application apart
+// from other ones. It is public, so it is not what protects them.
+var entropy = []byte("awg-split-tunnel/secret/v1")
+
+// seal uses user-scope DPAPI. The service seals and opens as LocalSystem,
+// so only processes running as SYSTEM can open the blob; local users who
+// can read config.yaml cannot. Blobs sealed by older versions with machine
+// scope still open.
+func seal(plain []byte) (string, error) {
+	out, err := dpapi(plain, true)
+	if err != nil {
+		return "", fmt.Errorf("secret: CryptProtectData: %w", err)
+	}
+	return schemeDPAPI + ":" + base64.StdEncoding.EncodeToString(out), nil
+}
+
+func open(sealed string) ([]byte, error) {
+	if !strings.HasPrefix(sealed, schemeDPAPI+":") {
+		return nil, fmt.Errorf("secret: %q values cannot be opened on Windows", strings.SplitN(sealed, ":", 2)[0])
+	}
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, schemeDPAPI+":"))
+	if err != nil {
+		return nil, fmt.Errorf("secret: %w", err)
+	}
+	out, err := dpapi(blob, false)
+	if err != nil {
+		return nil, fmt.Errorf("secret: CryptUnprotectData: %w", err)
+	}
+	return out, nil
+}
+
+func dpapi(in []byte, protect bool) ([]byte, error) {
+	inBlob := windows.DataBlob{Size: uint32(len(in)), Data: unsafe.SliceData(in)}
+	entBlob := windows.DataBlob{Size: uint32(len(entropy)), Data: unsafe.SliceData(entropy)}
+	var outBlob windows.DataBlob
+	var err error
+	if protect {
+		err = windows.CryptProtectData(&inBlob, nil, &entBlob, 0, nil,
+			windows.CRYPTPROTECT_UI_FORBIDDEN, &outBlob)
+	} else {
+		err = windows.CryptUnprotectData(&inBlob, nil, &entBlob, 0, nil,
+			windows.CRYPTPROTECT_UI_FORBIDDEN, &outBlob)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(outBlob.Data)))
+	return append([]byte(nil), unsafe.Slice(outBlob.Data, outBlob.Size)...), nil
+}
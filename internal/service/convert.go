@@ -23,6 +23,7 @@ func tunnelEntryToProto(e *core.TunnelEntry, ctrl TunnelController, geoResolver
 	}
 	if ctrl != nil {
 		ts.AdapterIp = ctrl.GetAdapterIP(e.ID)
+		ts.PathMtu = int32(ctrl.GetPathMTU(e.ID))
 		// Resolve external IP and country code from server endpoints.
 		endpoints := ctrl.GetServerEndpoints(e.ID)
 		if len(endpoints) > 0 {
@@ -47,6 +48,9 @@ func tunnelConfigToProto(c core.TunnelConfig) *vpnapi.TunnelConfig {
 		DisallowedIps:  c.DisallowedIPs,
 		DisallowedApps: c.DisallowedApps,
 		SortIndex:      int32(c.SortIndex),
+		RateLimit:      rateLimitToProto(c.RateLimit),
+		Mtu:            int32(c.MTU),
+		PmtuProbe:      c.PMTUProbe,
 	}
 }
 
@@ -61,6 +65,9 @@ func tunnelConfigFromProto(pc *vpnapi.TunnelConfig) core.TunnelConfig {
 		AllowedIPs:     pc.AllowedIps,
 		DisallowedIPs:  pc.DisallowedIps,
 		DisallowedApps: pc.DisallowedApps,
+		RateLimit:      rateLimitFromProto(pc.RateLimit),
+		MTU:            int(pc.Mtu),
+		PMTUProbe:      pc.PmtuProbe,
 	}
 }
 
@@ -129,20 +136,28 @@ func ruleToProto(r core.Rule) *vpnapi.Rule {
 		prio = r.Priority.String()
 	}
 	return &vpnapi.Rule{
-		Pattern:  r.Pattern,
-		TunnelId: r.TunnelID,
-		Fallback: vpnapi.FallbackPolicy(r.Fallback),
-		Priority: prio,
-		Enabled:  r.IsEnabled(),
+		Pattern:     r.Pattern,
+		TunnelId:    r.TunnelID,
+		Fallback:    vpnapi.FallbackPolicy(r.Fallback),
+		Priority:    prio,
+		Enabled:     r.IsEnabled(),
+		RateLimit:   rateLimitToProto(r.RateLimit),
+		UdpNat:      r.UDPNAT,
+		IdleTimeout: r.IdleTimeout,
+		MaxLifetime: r.MaxLifetime,
 	}
 }
 
 func ruleFromProto(pr *vpnapi.Rule) core.Rule {
 	r := core.Rule{
-		Pattern:  pr.Pattern,
-		TunnelID: pr.TunnelId,
-		Fallback: core.FallbackPolicy(pr.Fallback),
-		Priority: parsePriorityProto(pr.Priority),
+		Pattern:     pr.Pattern,
+		TunnelID:    pr.TunnelId,
+		Fallback:    core.FallbackPolicy(pr.Fallback),
+		Priority:    parsePriorityProto(pr.Priority),
+		RateLimit:   rateLimitFromProto(pr.RateLimit),
+		UDPNAT:      pr.UdpNat,
+		IdleTimeout: pr.IdleTimeout,
+		MaxLifetime: pr.MaxLifetime,
 	}
 	if !pr.Enabled {
 		enabled := false
@@ -156,6 +171,33 @@ func parsePriorityProto(s string) core.RulePriority {
 	return p
 }
 
+// ─── Rate limit conversions ─────────────────────────────────────────
+
+func rateLimitToProto(l *core.RateLimit) *vpnapi.RateLimit {
+	if l.IsZero() {
+		return nil
+	}
+	return &vpnapi.RateLimit{
+		UpKbps:      l.UpKbps,
+		DownKbps:    l.DownKbps,
+		UpBurstKb:   l.UpBurstKB,
+		DownBurstKb: l.DownBurstKB,
+	}
+}
+
+func rateLimitFromProto(pl *vpnapi.RateLimit) *core.RateLimit {
+	l := &core.RateLimit{
+		UpKbps:      pl.GetUpKbps(),
+		DownKbps:    pl.GetDownKbps(),
+		UpBurstKB:   pl.GetUpBurstKb(),
+		DownBurstKB: pl.GetDownBurstKb(),
+	}
+	if l.IsZero() {
+		return nil
+	}
+	return l
+}
+
 // ─── Domain rule conversions ────────────────────────────────────────
 
 func domainRuleToProto(r core.DomainRule) *vpnapi.DomainRule {
@@ -230,6 +272,7 @@ func configToProto(c core.Config) *vpnapi.AppConfig {
 			DisallowedIps:  c.Global.DisallowedIPs,
 			DisallowedApps: c.Global.DisallowedApps,
 			DisableLocal:   c.Global.DisableLocal,
+			RateLimit:      rateLimitToProto(c.Global.RateLimit),
 		},
 		Tunnels:       tunnels,
 		Rules:         rules,
@@ -271,6 +314,7 @@ func configFromProto(pc *vpnapi.AppConfig) core.Config {
 			DisallowedIPs:  pc.Global.DisallowedIps,
 			DisallowedApps: pc.Global.DisallowedApps,
 			DisableLocal:   pc.Global.DisableLocal,
+			RateLimit:      rateLimitFromProto(pc.Global.RateLimit),
 		}
 	}
 
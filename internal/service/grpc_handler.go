@@ -125,6 +125,7 @@ func (s *Service) UpdateTunnel(_ context.Context, req *vpnapi.UpdateTunnelReques
 	if err := s.ctrl.AddTunnel(context.Background(), cfg, nil); err != nil {
 		return &vpnapi.UpdateTunnelResponse{Success: false, Error: err.Error()}, nil
 	}
+	s.applyRateLimits()
 	return &vpnapi.UpdateTunnelResponse{Success: true}, nil
 }
 
@@ -241,6 +242,9 @@ func (s *Service) SaveRules(_ context.Context, req *vpnapi.SaveRulesRequest) (*v
 	}
 	s.rules.SetRules(rules)
 	s.cfg.SetRulesQuiet(rules)
+	if s.connLimits != nil {
+		s.connLimits.Configure(rules)
+	}
 	if err := s.cfg.Save(); err != nil {
 		return &vpnapi.SaveRulesResponse{Success: false, Error: err.Error()}, nil
 	}
@@ -389,6 +393,16 @@ func (s *Service) SaveConfig(ctx context.Context, req *vpnapi.SaveConfigRequest)
 	newCfg.GUI = oldCfg.GUI
 	newCfg.GUI.Reconnect = reconnectCfg // restore reconnect config from proto
 	newCfg.Update = oldCfg.Update
+	newCfg.DNS.Blocklists = oldCfg.DNS.Blocklists
+	newCfg.DNS.Allowlist = oldCfg.DNS.Allowlist
+	newCfg.DNS.Records = oldCfg.DNS.Records
+	newCfg.DNS.Split = oldCfg.DNS.Split
+	newCfg.DNS.SearchDomains = oldCfg.DNS.SearchDomains
+	newCfg.RuleSets = oldCfg.RuleSets
+	newCfg.WGServer = oldCfg.WGServer
+	newCfg.IPCAccess = oldCfg.IPCAccess
+	newCfg.RemoteAPI = oldCfg.RemoteAPI
+	newCfg.Global.Failover = oldCfg.Global.Failover
 	// Subscriptions are now part of AppConfig proto, but if the client sends
 	// an empty list we preserve the existing subscriptions (backward compat).
 	if len(newCfg.Subscriptions) == 0 && len(oldCfg.Subscriptions) > 0 {
@@ -703,8 +717,8 @@ func (s *Service) RestoreConnections(ctx context.Context, _ *emptypb.Empty) (*vp
 
 	var lastErr error
 	for _, tunnelID := range activeTunnels {
-		// Skip tunnels that require interactive auth (e.g. AnyConnect with OTP).
-		if entry, ok := s.registry.Get(tunnelID); ok && entry.Config.Protocol == "anyconnect" {
+		// Skip tunnels that require interactive auth (e.g. SSL-VPN with OTP).
+		if entry, ok := s.registry.Get(tunnelID); ok && core.IsSSLVPNProtocol(entry.Config.Protocol) {
 			core.Log.Infof("Core", "RestoreConnections: skipping %q (requires interactive auth)", tunnelID)
 			continue
 		}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/gateway"
+)
+
+// defaultDNSStatsLimit is the number of domains returned per aggregate list
+// when the request does not specify a limit.
+const defaultDNSStatsLimit = 50
+
+// StreamDNSQueries streams structured DNS query records to the client.
+func (s *Service) StreamDNSQueries(req *vpnapi.DNSQueryStreamRequest, stream vpnapi.VPNService_StreamDNSQueriesServer) error {
+	if s.dnsQueryLog == nil {
+		return fmt.Errorf("DNS query log not initialized")
+	}
+
+	sub := s.dnsQueryLog.Subscribe(int(req.GetTail()))
+	defer s.dnsQueryLog.Unsubscribe(sub)
+
+	domainFilter := strings.ToLower(req.GetDomainFilter())
+	processFilter := strings.ToLower(req.GetProcessFilter())
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			if req.GetBlockedOnly() && !e.Blocked() {
+				continue
+			}
+			if domainFilter != "" && !strings.Contains(strings.ToLower(e.Name), domainFilter) {
+				continue
+			}
+			if processFilter != "" && !strings.Contains(e.Process, processFilter) {
+				continue
+			}
+			if err := stream.Send(dnsQueryEntryToProto(e)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// GetDNSQueryStats returns the most queried and most blocked domains.
+func (s *Service) GetDNSQueryStats(_ context.Context, req *vpnapi.DNSQueryStatsRequest) (*vpnapi.DNSQueryStatsResponse, error) {
+	if s.dnsQueryLog == nil {
+		return nil, fmt.Errorf("DNS query log not initialized")
+	}
+
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultDNSStatsLimit
+	}
+
+	resp := &vpnapi.DNSQueryStatsResponse{}
+	for _, ds := range s.dnsQueryLog.TopDomains(limit, false) {
+		resp.TopDomains = append(resp.TopDomains, dnsDomainStatsToProto(ds))
+	}
+	for _, ds := range s.dnsQueryLog.TopDomains(limit, true) {
+		resp.BlockedDomains = append(resp.BlockedDomains, dnsDomainStatsToProto(ds))
+	}
+	if req.GetResetStats() {
+		s.dnsQueryLog.ResetStats()
+	}
+	return resp, nil
+}
+
+func dnsQueryEntryToProto(e gateway.DNSQueryEntry) *vpnapi.DNSQueryEntry {
+	pe := &vpnapi.DNSQueryEntry{
+		Timestamp:   timestamppb.New(e.Time),
+		ProcessName: e.Process,
+		Qname:       e.Name,
+		Qtype:       uint32(e.QType),
+		Rule:        e.Rule,
+		Action:      vpnapi.DomainAction(e.Action),
+		TunnelId:    e.TunnelID,
+		Upstream:    e.Upstream,
+		LatencyUs:   e.Latency.Microseconds(),
+		Rcode:       uint32(e.Rcode),
+		Transport:   e.Transport,
+	}
+	if e.FakeIP.IsValid() {
+		pe.FakeIp = e.FakeIP.String()
+	}
+	return pe
+}
+
+func dnsDomainStatsToProto(ds gateway.DNSDomainStats) *vpnapi.DNSDomainStats {
+	return &vpnapi.DNSDomainStats{
+		Domain:      ds.Name,
+		Queries:     ds.Queries,
+		Blocked:     ds.Blocked,
+		LastSeen:    timestamppb.New(ds.LastSeen),
+		LastProcess: ds.LastProcess,
+		LastTunnel:  ds.LastTunnel,
+		Rule:        ds.Rule,
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/ruleimport"
+)
+
+// ImportRules converts rules of another client (Proxifier, Clash, v2rayN,
+// WireSock) and, unless dry_run is set, appends them to the config. Source
+// rules that cannot be represented are returned in skipped, imported rules
+// whose precedence differs from the source in warnings.
+func (s *Service) ImportRules(_ context.Context, req *vpnapi.ImportRulesRequest) (*vpnapi.ImportRulesResponse, error) {
+	fail := func(err error) (*vpnapi.ImportRulesResponse, error) {
+		return &vpnapi.ImportRulesResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	format, err := ruleimport.ParseFormat(req.GetFormat())
+	if err != nil {
+		return fail(err)
+	}
+	if format == "" {
+		format = ruleimport.Detect(req.GetFileName(), req.GetContent())
+	}
+	opts := ruleimport.Options{Tunnels: req.GetTunnelMap(), DefaultTunnel: req.GetDefaultTunnel()}
+	for _, id := range append([]string{opts.DefaultTunnel}, mapValues(opts.Tunnels)...) {
+		if _, ok := s.registry.Get(id); id != "" && !ok {
+			return fail(fmt.Errorf("tunnel %q not found", id))
+		}
+	}
+
+	res, err := ruleimport.Parse(format, req.GetContent(), opts)
+	if err != nil {
+		return fail(err)
+	}
+	skipped := res.Skipped
+
+	if !req.GetDryRun() {
+		cfg := s.cfg.Get()
+		skipped = append(skipped, res.MergeInto(&cfg)...)
+		// Same order as SaveConfig: persist first, then let the reload
+		// handler apply rules, domain rules and the global filter.
+		s.cfg.SetQuiet(cfg)
+		if err := s.cfg.Save(); err != nil {
+			return fail(err)
+		}
+		s.bus.Publish(core.Event{Type: core.EventConfigReloaded})
+		if s.dnsFlush != nil {
+			_ = s.dnsFlush()
+		}
+		core.Log.Infof("Core", "Imported %s rules: %d rules, %d domain rules, %d filter entries, %d skipped, %d warnings",
+			format, len(res.Rules), len(res.DomainRules),
+			len(res.Filter.DisallowedApps)+len(res.Filter.DisallowedIPs), len(skipped), len(res.Warnings))
+	}
+
+	resp := &vpnapi.ImportRulesResponse{
+		Success:        true,
+		Format:         string(format),
+		DisallowedApps: res.Filter.DisallowedApps,
+		DisallowedIps:  res.Filter.DisallowedIPs,
+	}
+	for _, r := range res.Rules {
+		resp.Rules = append(resp.Rules, ruleToProto(r))
+	}
+	for _, r := range res.DomainRules {
+		resp.DomainRules = append(resp.DomainRules, domainRuleToProto(r))
+	}
+	for _, sk := range skipped {
+		resp.Skipped = append(resp.Skipped, &vpnapi.SkippedRule{Source: sk.Source, Reason: sk.Reason})
+	}
+	for _, w := range res.Warnings {
+		resp.Warnings = append(resp.Warnings, &vpnapi.SkippedRule{Source: w.Source, Reason: w.Reason})
+	}
+	return resp, nil
+}
+
+func mapValues(m map[string]string) []string {
+	out := make([]string, 0, len(m))
+	for _, v := range m {
+		out = append(out, v)
+	}
+	return out
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+)
+
+// GetRateLimits returns every configured bandwidth limit.
+func (s *Service) GetRateLimits(_ context.Context, _ *emptypb.Empty) (*vpnapi.RateLimitsResponse, error) {
+	cfg := s.cfg.Get()
+	resp := &vpnapi.RateLimitsResponse{}
+	add := func(scope vpnapi.RateLimitScope, target string, l *core.RateLimit) {
+		if !l.IsZero() {
+			resp.Limits = append(resp.Limits, &vpnapi.RateLimitEntry{
+				Scope:  scope,
+				Target: target,
+				Limit:  rateLimitToProto(l),
+			})
+		}
+	}
+	add(vpnapi.RateLimitScope_RATE_LIMIT_SCOPE_GLOBAL, "", cfg.Global.RateLimit)
+	for _, t := range cfg.Tunnels {
+		add(vpnapi.RateLimitScope_RATE_LIMIT_SCOPE_TUNNEL, t.ID, t.RateLimit)
+	}
+	for _, r := range cfg.Rules {
+		add(vpnapi.RateLimitScope_RATE_LIMIT_SCOPE_RULE, r.Pattern, r.RateLimit)
+	}
+	return resp, nil
+}
+
+// SetRateLimit sets or removes one bandwidth limit. The change is saved and
+// applied to running flows without a config reload.
+func (s *Service) SetRateLimit(_ context.Context, req *vpnapi.SetRateLimitRequest) (*vpnapi.SetRateLimitResponse, error) {
+	limit := rateLimitFromProto(req.GetLimit())
+	if err := limit.Validate(); err != nil {
+		return &vpnapi.SetRateLimitResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	cfg := s.cfg.Get()
+	found := false
+	switch req.GetScope() {
+	case vpnapi.RateLimitScope_RATE_LIMIT_SCOPE_GLOBAL:
+		cfg.Global.RateLimit = limit
+		found = true
+	case vpnapi.RateLimitScope_RATE_LIMIT_SCOPE_TUNNEL:
+		tunnels := make([]core.TunnelConfig, len(cfg.Tunnels))
+		copy(tunnels, cfg.Tunnels)
+		for i := range tunnels {
+			if tunnels[i].ID == req.GetTarget() {
+				tunnels[i].RateLimit = limit
+				found = true
+			}
+		}
+		cfg.Tunnels = tunnels
+	case vpnapi.RateLimitScope_RATE_LIMIT_SCOPE_RULE:
+		// Every rule with the pattern shares one limit.
+		rules := make([]core.Rule, len(cfg.Rules))
+		copy(rules, cfg.Rules)
+		for i := range rules {
+			if strings.EqualFold(rules[i].Pattern, req.GetTarget()) {
+				rules[i].RateLimit = limit
+				found = true
+			}
+		}
+		cfg.Rules = rules
+	default:
+		return &vpnapi.SetRateLimitResponse{Success: false, Error: fmt.Sprintf("unknown scope %v", req.GetScope())}, nil
+	}
+	if !found {
+		return &vpnapi.SetRateLimitResponse{Success: false, Error: fmt.Sprintf("%q not found", req.GetTarget())}, nil
+	}
+
+	s.cfg.SetQuiet(cfg)
+	if err := s.cfg.Save(); err != nil {
+		return &vpnapi.SetRateLimitResponse{Success: false, Error: err.Error()}, nil
+	}
+	s.applyRateLimits()
+	return &vpnapi.SetRateLimitResponse{Success: true}, nil
+}
+
+// applyRateLimits reapplies the saved rate limits to the shaper.
+func (s *Service) applyRateLimits() {
+	if s.shaper != nil {
+		s.shaper.Configure(s.cfg.Get())
+	}
+}
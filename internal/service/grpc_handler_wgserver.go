@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/wgserver"
+)
+
+// GetWGServerStatus returns the WireGuard server settings and its peers.
+func (s *Service) GetWGServerStatus(_ context.Context, _ *emptypb.Empty) (*vpnapi.WGServerStatusResponse, error) {
+	cfg := s.cfg.Get().WGServer
+	resp := &vpnapi.WGServerStatusResponse{
+		Success:    true,
+		Enabled:    cfg.Enabled,
+		ListenPort: int32(cfg.ListenPort),
+		Address:    cfg.Address,
+		Endpoint:   cfg.Endpoint,
+	}
+	if resp.ListenPort == 0 {
+		resp.ListenPort = wgserver.DefaultListenPort
+	}
+	if resp.Address == "" {
+		resp.Address = wgserver.DefaultAddress
+	}
+	if s.wgServer == nil {
+		return resp, nil
+	}
+	resp.Running = s.wgServer.Running()
+	resp.PublicKey = s.wgServer.PublicKey()
+	for _, p := range s.wgServer.Peers() {
+		resp.Peers = append(resp.Peers, wgServerPeerToProto(p))
+	}
+	return resp, nil
+}
+
+// AddWGServerPeer adds a device to the WireGuard server. Without a public
+// key a key pair is generated and the device's client config is returned.
+func (s *Service) AddWGServerPeer(_ context.Context, req *vpnapi.AddWGServerPeerRequest) (*vpnapi.AddWGServerPeerResponse, error) {
+	name := strings.TrimSpace(req.GetName())
+	if name == "" {
+		return &vpnapi.AddWGServerPeerResponse{Success: false, Error: "name is required"}, nil
+	}
+
+	cfg := s.cfg.Get()
+	var peer core.WGServerPeer
+	var err error
+	if req.GetPublicKey() == "" {
+		peer, err = wgserver.NewPeer(cfg.WGServer, name)
+	} else {
+		peer = core.WGServerPeer{Name: name, PublicKey: req.GetPublicKey(), PresharedKey: req.GetPresharedKey()}
+		peer.Address, err = wgserver.AllocateAddress(cfg.WGServer)
+	}
+	if err != nil {
+		return &vpnapi.AddWGServerPeerResponse{Success: false, Error: err.Error()}, nil
+	}
+	if req.GetAddress() != "" {
+		peer.Address = req.GetAddress()
+	}
+	cfg.WGServer.Peers = append(cfg.WGServer.Peers, peer)
+
+	if err := s.saveWGServer(cfg); err != nil {
+		return &vpnapi.AddWGServerPeerResponse{Success: false, Error: err.Error()}, nil
+	}
+
+	resp := &vpnapi.AddWGServerPeerResponse{Success: true}
+	if s.wgServer != nil {
+		for _, p := range s.wgServer.Peers() {
+			if p.Name == peer.Name {
+				resp.Peer = wgServerPeerToProto(p)
+			}
+		}
+	}
+	if peer.PrivateKey != "" {
+		// Best effort: the endpoint may not be configured yet.
+		resp.ClientConfig, _ = wgserver.ClientConfig(cfg.WGServer, peer.Name)
+	}
+	return resp, nil
+}
+
+// RemoveWGServerPeer removes a device from the WireGuard server.
+func (s *Service) RemoveWGServerPeer(_ context.Context, req *vpnapi.RemoveWGServerPeerRequest) (*vpnapi.RemoveWGServerPeerResponse, error) {
+	cfg := s.cfg.Get()
+	peers := cfg.WGServer.Peers
+	idx := -1
+	for i, p := range peers {
+		if strings.EqualFold(p.Name, req.GetName()) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return &vpnapi.RemoveWGServerPeerResponse{Success: false, Error: fmt.Sprintf("peer %q not found", req.GetName())}, nil
+	}
+	cfg.WGServer.Peers = append(peers[:idx:idx], peers[idx+1:]...)
+
+	if err := s.saveWGServer(cfg); err != nil {
+		return &vpnapi.RemoveWGServerPeerResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &vpnapi.RemoveWGServerPeerResponse{Success: true}, nil
+}
+
+// GetWGServerClientConfig renders the .conf of a peer whose keys were
+// generated by the server.
+func (s *Service) GetWGServerClientConfig(_ context.Context, req *vpnapi.WGServerClientConfigRequest) (*vpnapi.WGServerClientConfigResponse, error) {
+	conf, err := wgserver.ClientConfig(s.cfg.Get().WGServer, req.GetName())
+	if err != nil {
+		return &vpnapi.WGServerClientConfigResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &vpnapi.WGServerClientConfigResponse{Success: true, Conf: conf}, nil
+}
+
+// saveWGServer validates, persists and applies a config whose wg_server
+// section changed. The server is reconfigured directly, so running peers
+// keep their sessions instead of going through a full config reload.
+func (s *Service) saveWGServer(cfg core.Config) error {
+	if err := cfg.WGServer.Validate(); err != nil {
+		return err
+	}
+	s.cfg.SetQuiet(cfg)
+	if err := s.cfg.Save(); err != nil {
+		return err
+	}
+	if s.wgServer != nil {
+		if err := s.wgServer.Configure(cfg.WGServer); err != nil {
+			return fmt.Errorf("saved, but the server failed to apply it: %w", err)
+		}
+	}
+	return nil
+}
+
+func wgServerPeerToProto(p wgserver.PeerStatus) *vpnapi.WGServerPeer {
+	wp := &vpnapi.WGServerPeer{
+		Name:      p.Name,
+		PublicKey: p.PublicKey,
+		Enabled:   p.Enabled,
+		HasConfig: p.HasConfig,
+		Endpoint:  p.Endpoint,
+		RxBytes:   p.RxBytes,
+		TxBytes:   p.TxBytes,
+	}
+	if p.Address.IsValid() {
+		wp.Address = p.Address.String()
+	}
+	if !p.LastHandshake.IsZero() {
+		wp.LastHandshake = timestamppb.New(p.LastHandshake)
+	}
+	return wp
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	vpnapi "awg-split-tunnel/api/gen"
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider/wgconf"
+)
+
+// GenerateWireGuardKeys creates a new Curve25519 key pair, or derives the
+// public key when the request carries an existing private key.
+func (s *Service) GenerateWireGuardKeys(_ context.Context, req *vpnapi.WireGuardKeyRequest) (*vpnapi.WireGuardKeyResponse, error) {
+	priv := req.GetPrivateKey()
+	var pub string
+	var err error
+	if priv == "" {
+		priv, pub, err = wgconf.GenerateKeyPair()
+	} else {
+		pub, err = wgconf.PublicKey(priv)
+	}
+	if err != nil {
+		return &vpnapi.WireGuardKeyResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &vpnapi.WireGuardKeyResponse{Success: true, PrivateKey: priv, PublicKey: pub}, nil
+}
+
+// ImportWireGuardConfig decodes a .conf, share link or QR payload into a
+// tunnel that stores its config inline, and adds it unless dry_run is set.
+func (s *Service) ImportWireGuardConfig(ctx context.Context, req *vpnapi.ImportWireGuardRequest) (*vpnapi.ImportWireGuardResponse, error) {
+	wc, name, err := wgconf.Decode(req.GetPayload())
+	if err != nil {
+		return &vpnapi.ImportWireGuardResponse{Success: false, Error: err.Error()}, nil
+	}
+	if req.GetName() != "" {
+		name = req.GetName()
+	}
+	cfg := wgconf.TunnelConfig(wc, name)
+	cfg.ID = req.GetTunnelId()
+
+	if !req.GetDryRun() {
+		if err := s.ctrl.AddTunnel(ctx, cfg, nil); err != nil {
+			return &vpnapi.ImportWireGuardResponse{Success: false, Error: err.Error()}, nil
+		}
+	}
+	return &vpnapi.ImportWireGuardResponse{Success: true, Config: tunnelConfigToProto(cfg)}, nil
+}
+
+// ExportWireGuardConfig renders a WireGuard/AmneziaWG tunnel as standard
+// .conf text, whether it is defined inline, by structured settings or by
+// a config_file.
+func (s *Service) ExportWireGuardConfig(_ context.Context, req *vpnapi.ExportWireGuardRequest) (*vpnapi.ExportWireGuardResponse, error) {
+	entry, ok := s.registry.Get(req.GetTunnelId())
+	if !ok {
+		return &vpnapi.ExportWireGuardResponse{Success: false, Error: "tunnel not found"}, nil
+	}
+	conf, err := exportWireGuardConf(entry.Config)
+	if err != nil {
+		return &vpnapi.ExportWireGuardResponse{Success: false, Error: err.Error()}, nil
+	}
+	return &vpnapi.ExportWireGuardResponse{Success: true, Conf: conf}, nil
+}
+
+// GetTunnelPeers returns per-peer health and traffic of a WireGuard/AWG tunnel.
+func (s *Service) GetTunnelPeers(_ context.Context, req *vpnapi.TunnelPeersRequest) (*vpnapi.TunnelPeersResponse, error) {
+	peers, err := s.ctrl.GetTunnelPeers(req.GetTunnelId())
+	if err != nil {
+		return &vpnapi.TunnelPeersResponse{Success: false, Error: err.Error()}, nil
+	}
+	resp := &vpnapi.TunnelPeersResponse{Success: true}
+	for _, p := range peers {
+		tp := &vpnapi.TunnelPeer{
+			Id:        p.ID,
+			PublicKey: p.PublicKey,
+			Endpoint:  p.Endpoint,
+			RxBytes:   p.RxBytes,
+			TxBytes:   p.TxBytes,
+			Stale:     p.Stale,
+			Active:    p.Active,
+		}
+		for _, pfx := range p.AllowedIPs {
+			tp.AllowedIps = append(tp.AllowedIps, pfx.String())
+		}
+		if !p.LastHandshake.IsZero() {
+			tp.LastHandshake = timestamppb.New(p.LastHandshake)
+		}
+		resp.Peers = append(resp.Peers, tp)
+	}
+	return resp, nil
+}
+
+func exportWireGuardConf(cfg core.TunnelConfig) (string, error) {
+	if cfg.Protocol != core.ProtocolWireGuard && cfg.Protocol != core.ProtocolAmneziaWG {
+		return "", fmt.Errorf("tunnel %q is not a WireGuard/AmneziaWG tunnel", cfg.ID)
+	}
+	text, err := wireGuardConfigText(cfg.Settings)
+	if err != nil {
+		return "", err
+	}
+	if text == "" {
+		confFile := getStringSetting(cfg.Settings, "config_file", "")
+		if confFile == "" {
+			return "", fmt.Errorf("tunnel %q has no config", cfg.ID)
+		}
+		data, err := os.ReadFile(resolveRelativeToExe(confFile))
+		if err != nil {
+			return "", fmt.Errorf("read config file: %w", err)
+		}
+		text = string(data)
+	}
+	wc, err := wgconf.Parse(text)
+	if err != nil {
+		return "", err
+	}
+	return wc.String(), nil
+}
@@ -10,19 +10,25 @@ import (
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/gateway"
 	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/wgconf"
 )
 
 // HealthMonitor periodically checks WireGuard/AWG peer liveness by inspecting
 // last_handshake_time via IPC. When all peers of a tunnel are stale beyond the
 // threshold, the tunnel is transitioned to Error state, triggering ReconnectManager.
+// In multi-peer tunnels, routes of individual stale peers fail over to the
+// remaining healthy peers with overlapping AllowedIPs.
+// Before that, hostname endpoints are re-resolved: a server that moved to a new
+// IP is followed in place instead of reconnecting.
 type HealthMonitor struct {
-	registry       *core.TunnelRegistry
-	providerLookup func(string) (provider.TunnelProvider, bool)
-	markUnhealthy  func(string, error) bool
-	interval       time.Duration
-	staleThreshold time.Duration
-	ctx            context.Context
-	cancel         context.CancelFunc
+	registry         *core.TunnelRegistry
+	providerLookup   func(string) (provider.TunnelProvider, bool)
+	markUnhealthy    func(string, error) bool
+	refreshEndpoints func(string) bool
+	interval         time.Duration
+	staleThreshold   time.Duration
+	ctx              context.Context
+	cancel           context.CancelFunc
 }
 
 // NewHealthMonitor creates a health monitor with the given config and callbacks.
@@ -32,6 +38,7 @@ func NewHealthMonitor(
 	registry *core.TunnelRegistry,
 	providerLookup func(string) (provider.TunnelProvider, bool),
 	markUnhealthy func(string, error) bool,
+	refreshEndpoints func(string) bool,
 ) *HealthMonitor {
 	interval := 30 * time.Second
 	if cfg.Interval != "" {
@@ -48,11 +55,12 @@ func NewHealthMonitor(
 	}
 
 	return &HealthMonitor{
-		registry:       registry,
-		providerLookup: providerLookup,
-		markUnhealthy:  markUnhealthy,
-		interval:       interval,
-		staleThreshold: staleThreshold,
+		registry:         registry,
+		providerLookup:   providerLookup,
+		markUnhealthy:    markUnhealthy,
+		refreshEndpoints: refreshEndpoints,
+		interval:         interval,
+		staleThreshold:   staleThreshold,
 	}
 }
 
@@ -146,15 +154,40 @@ func (hm *HealthMonitor) checkOne(entry *core.TunnelEntry, now time.Time) {
 		return
 	}
 
+	if mp, ok := prov.(provider.MultiPeerProvider); ok {
+		hm.failoverPeers(entry.ID, mp, ipcData, now)
+	}
+
 	stale, reason := checkPeerHealth(ipcData, now, hm.staleThreshold)
 	if !stale {
 		return
 	}
 
+	// The server may have moved behind dynamic DNS: give the new endpoint
+	// one interval to handshake before reconnecting.
+	if hm.refreshEndpoints != nil && hm.refreshEndpoints(entry.ID) {
+		core.Log.Warnf("Core", "Health check: tunnel %q has stale peers (%s), endpoint updated", entry.ID, reason)
+		return
+	}
+
 	core.Log.Warnf("Core", "Health check: tunnel %q has stale peers (%s), triggering reconnect", entry.ID, reason)
 	hm.markUnhealthy(entry.ID, fmt.Errorf("stale peers: %s", reason))
 }
 
+// failoverPeers reports the individually stale peers of a tunnel to its
+// provider, which moves their routes to healthy peers (and back on recovery).
+func (hm *HealthMonitor) failoverPeers(tunnelID string, mp provider.MultiPeerProvider, ipcData string, now time.Time) {
+	stale := wgconf.StalePeers(ipcData, now, hm.staleThreshold)
+	moved, err := mp.SetStalePeers(stale)
+	if err != nil {
+		core.Log.Warnf("Core", "Health check: peer failover for %q failed: %v", tunnelID, err)
+		return
+	}
+	if moved {
+		core.Log.Warnf("Core", "Health check: tunnel %q re-routed peers (%d stale)", tunnelID, len(stale))
+	}
+}
+
 // checkPeerHealth parses WireGuard IPC output and determines if all peers are stale.
 // Returns (stale, reason). A tunnel with no peers is not considered stale.
 func checkPeerHealth(ipcData string, now time.Time, threshold time.Duration) (bool, string) {
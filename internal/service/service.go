@@ -13,7 +13,11 @@ import (
 	vpnapi "awg-split-tunnel/api/gen"
 	"awg-split-tunnel/internal/core"
 	"awg-split-tunnel/internal/gateway"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/proxy"
+	"awg-split-tunnel/internal/shaping"
 	"awg-split-tunnel/internal/update"
+	"awg-split-tunnel/internal/wgserver"
 )
 
 // TunnelController abstracts tunnel lifecycle operations
@@ -39,6 +43,10 @@ type TunnelController interface {
 	GetAdapterIP(tunnelID string) string
 	// GetServerEndpoints returns the remote server endpoint addresses for a tunnel.
 	GetServerEndpoints(tunnelID string) []netip.AddrPort
+	// GetPathMTU returns the path MTU of a raw-forwarding tunnel, 0 if unknown.
+	GetPathMTU(tunnelID string) int
+	// GetTunnelPeers returns per-peer state of a WireGuard/AWG tunnel.
+	GetTunnelPeers(tunnelID string) ([]provider.PeerInfo, error)
 }
 
 // Service is the central orchestrator that implements VPNServiceServer.
@@ -68,6 +76,10 @@ type Service struct {
 	reconnectMgr      *ReconnectManager
 	healthMon         *HealthMonitor
 	connMonitor       *ConnectionMonitor
+	dnsQueryLog       *gateway.DNSQueryLog
+	wgServer          *wgserver.Server
+	shaper            *shaping.Shaper
+	connLimits        *proxy.ConnLimits
 
 	// Cached geo category lists (parsed from geoip.dat / geosite.dat).
 	// Avoids re-reading and re-parsing 20-30 MB protobuf files on every UI request.
@@ -111,6 +123,15 @@ type Config struct {
 	HealthMonitor *HealthMonitor
 	// ConnMonitor tracks active connections for the Connections gRPC stream.
 	ConnMonitor *ConnectionMonitor
+	// DNSQueryLog records resolver decisions for the DNS query stream and stats.
+	DNSQueryLog *gateway.DNSQueryLog
+	// WGServer is the inbound WireGuard/AmneziaWG listener (wg_server).
+	WGServer *wgserver.Server
+	// Shaper applies rate_limit settings; SetRateLimit updates it live.
+	Shaper *shaping.Shaper
+	// ConnLimits applies per-rule idle_timeout/max_lifetime; SaveRules
+	// updates it live.
+	ConnLimits *proxy.ConnLimits
 }
 
 // New creates a new Service instance.
@@ -144,6 +165,10 @@ func New(c Config) *Service {
 	s.reconnectMgr = c.ReconnectManager
 	s.healthMon = c.HealthMonitor
 	s.connMonitor = c.ConnMonitor
+	s.dnsQueryLog = c.DNSQueryLog
+	s.wgServer = c.WGServer
+	s.shaper = c.Shaper
+	s.connLimits = c.ConnLimits
 
 	// Initialize GeoIP resolver for IP→country lookup (best-effort).
 	if c.GeoIPFilePath != "" {
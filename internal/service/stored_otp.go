@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/secret"
+	"awg-split-tunnel/internal/totp"
+)
+
+// totpSeedSetting holds the tunnel's TOTP seed. The GUI submits it in plain
+// text (base32 or otpauth:// URI); AddTunnel seals it before the config is
+// persisted, so config.yaml only ever contains the sealed form.
+const totpSeedSetting = "totp_seed"
+
+// sealTOTPSeed validates and seals a plain-text TOTP seed in cfg.Settings,
+// reporting whether it did. Already sealed values are kept as they are.
+func sealTOTPSeed(cfg *core.TunnelConfig) (bool, error) {
+	seed := getStringSetting(cfg.Settings, totpSeedSetting, "")
+	if seed == "" || secret.IsSealed(seed) {
+		return false, nil
+	}
+	if _, err := totp.Parse(seed); err != nil {
+		return false, fmt.Errorf("tunnel %q: %w", cfg.ID, err)
+	}
+	sealed, err := secret.Seal([]byte(seed))
+	if err != nil {
+		return false, fmt.Errorf("tunnel %q: seal TOTP seed: %w", cfg.ID, err)
+	}
+	settings := make(map[string]any, len(cfg.Settings))
+	for k, v := range cfg.Settings {
+		settings[k] = v
+	}
+	settings[totpSeedSetting] = sealed
+	cfg.Settings = settings
+	return true, nil
+}
+
+// hasStoredOTP reports whether the tunnel can generate its own OTP codes.
+func hasStoredOTP(cfg core.TunnelConfig) bool {
+	return getStringSetting(cfg.Settings, totpSeedSetting, "") != ""
+}
+
+// withStoredOTP returns params with "otp_code" filled from the tunnel's TOTP
+// seed. Codes are single-use on most servers, so if the previous connect
+// already used the current time step, it waits for the next one.
+// On any failure params are returned unchanged and the provider falls back
+// to asking the user.
+func (tc *TunnelControllerImpl) withStoredOTP(ctx context.Context, inst *tunnelInstance, params map[string]string) map[string]string {
+	if params["otp_code"] != "" || !hasStoredOTP(inst.config) {
+		return params
+	}
+	raw, err := secret.Open(getStringSetting(inst.config.Settings, totpSeedSetting, ""))
+	if err != nil {
+		core.Log.Warnf("Core", "Tunnel %q: cannot open stored TOTP seed: %v", inst.config.ID, err)
+		return params
+	}
+	key, err := totp.Parse(string(raw))
+	if err != nil {
+		core.Log.Warnf("Core", "Tunnel %q: stored TOTP seed: %v", inst.config.ID, err)
+		return params
+	}
+
+	now := time.Now()
+	step := key.Step(now)
+	if step <= inst.lastOTPStep.Load() {
+		wait := key.Remaining(now)
+		core.Log.Debugf("Core", "Tunnel %q: TOTP code already used, waiting %s for the next one", inst.config.ID, wait)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return params
+		}
+		step++
+	}
+	inst.lastOTPStep.Store(step)
+
+	out := make(map[string]string, len(params)+1)
+	for k, v := range params {
+		out[k] = v
+	}
+	out["otp_code"] = key.Code(step)
+	core.Log.Infof("Core", "Tunnel %q: using stored TOTP seed for the one-time code", inst.config.ID)
+	return out
+}
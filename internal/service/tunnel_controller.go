@@ -2,13 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/netip"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"awg-split-tunnel/internal/core"
@@ -18,15 +21,35 @@ import (
 	"awg-split-tunnel/internal/provider/amneziawg"
 	"awg-split-tunnel/internal/provider/direct"
 	"awg-split-tunnel/internal/provider/httpproxy"
+	"awg-split-tunnel/internal/provider/obfs"
 	"awg-split-tunnel/internal/provider/socks5"
 	"awg-split-tunnel/internal/provider/anyconnect"
+	"awg-split-tunnel/internal/provider/fortinet"
+	"awg-split-tunnel/internal/provider/globalprotect"
 	"awg-split-tunnel/internal/provider/hysteria2"
+	"awg-split-tunnel/internal/provider/openvpn"
 	sshprov "awg-split-tunnel/internal/provider/ssh"
+	"awg-split-tunnel/internal/provider/tuic"
 	"awg-split-tunnel/internal/provider/vless"
+	"awg-split-tunnel/internal/provider/wgconf"
 	"awg-split-tunnel/internal/provider/wireguard"
 	"awg-split-tunnel/internal/proxy"
+	"awg-split-tunnel/internal/shaping"
 )
 
+// sslVPNProvider is implemented by the cookie-session SSL-VPN providers
+// (AnyConnect, GlobalProtect, Fortinet) and by OpenVPN (auth-token), which
+// resume dropped sessions and resolve their server via the real NIC.
+type sslVPNProvider interface {
+	provider.TunnelProvider
+	SetRealNICIndex(index uint32)
+	SetInterfaceBinder(binder platform.InterfaceBinder)
+	SetEventBus(bus *core.EventBus)
+	SetOnSessionDrop(fn func(tunnelID string, err error))
+	HasSavedSession() bool
+	ClearSession()
+}
+
 // tunnelInstance tracks a running tunnel and its associated resources.
 type tunnelInstance struct {
 	opMu         sync.Mutex // serializes Connect/Disconnect/Remove on this tunnel
@@ -36,6 +59,7 @@ type tunnelInstance struct {
 	proxyPort    uint16
 	udpProxyPort uint16
 	config       core.TunnelConfig
+	lastOTPStep  atomic.Uint64 // TOTP time step of the last generated code
 }
 
 // ControllerDeps holds all dependencies needed by the TunnelControllerImpl.
@@ -66,6 +90,20 @@ type ControllerDeps struct {
 	Rules *core.RuleEngine
 	// ConfigManager for persisting active tunnels list.
 	Cfg *core.ConfigManager
+	// Shaper applies rate limits to proxied connections (optional).
+	Shaper *shaping.Shaper
+	// ConnLimits applies per-rule idle/lifetime limits to proxied TCP
+	// connections (optional).
+	ConnLimits *proxy.ConnLimits
+
+	// ListenTCP and ListenUDP open the per-tunnel proxy listeners
+	// (nil = host network stack).
+	ListenTCP func(addr string) (net.Listener, error)
+	ListenUDP func(addr string) (net.PacketConn, error)
+
+	// NewProvider, when set, replaces provider construction for every
+	// tunnel including direct (used by the simulated platform).
+	NewProvider func(cfg core.TunnelConfig) (provider.TunnelProvider, error)
 	Context   context.Context
 }
 
@@ -87,6 +125,10 @@ type TunnelControllerImpl struct {
 	// domainMatchFn is the current domain match function for SNI-based routing,
 	// stored here so new proxies get it automatically.
 	domainMatchFn *core.DomainMatchFunc
+
+	// endpointResolver is the NIC-bound resolver used to re-resolve hostname
+	// server endpoints (nil = endpoint refresh disabled).
+	endpointResolver *net.Resolver
 }
 
 // NewTunnelController creates a new TunnelControllerImpl.
@@ -192,8 +234,10 @@ func (tc *TunnelControllerImpl) ConnectTunnelWithAuth(ctx context.Context, tunne
 	}
 
 	// Pass ephemeral auth params (e.g. OTP code) to providers that support it.
-	if len(authParams) > 0 {
-		if ap, ok := inst.provider.(provider.AuthParamSetter); ok {
+	// Tunnels with a stored TOTP seed get their code generated here.
+	if ap, ok := inst.provider.(provider.AuthParamSetter); ok {
+		authParams = tc.withStoredOTP(tc.deps.Context, inst, authParams)
+		if len(authParams) > 0 {
 			ap.SetAuthParams(authParams)
 		}
 	}
@@ -205,7 +249,7 @@ func (tc *TunnelControllerImpl) ConnectTunnelWithAuth(ctx context.Context, tunne
 			vp.SetInterfaceBinder(tc.deps.InterfaceBinder)
 		}
 	}
-	if ap, ok := inst.provider.(*anyconnect.Provider); ok {
+	if ap, ok := inst.provider.(sslVPNProvider); ok {
 		if tc.deps.RealNICIndex > 0 {
 			ap.SetRealNICIndex(tc.deps.RealNICIndex)
 		}
@@ -214,18 +258,18 @@ func (tc *TunnelControllerImpl) ConnectTunnelWithAuth(ctx context.Context, tunne
 		}
 		// Pass EventBus for tunnel events (banner, timeout, reconnect).
 		ap.SetEventBus(tc.deps.Bus)
-		// Detect CSTP session drops and attempt session resumption.
+		// Detect session drops and attempt session resumption.
 		ap.SetOnSessionDrop(func(tunnelID string, err error) {
-			core.Log.Warnf("Core", "AnyConnect tunnel %q session dropped: %v", tunnelID, err)
+			core.Log.Warnf("Core", "%s tunnel %q session dropped: %v", ap.Protocol(), tunnelID, err)
 			// If provider has a saved session, attempt auto-reconnect.
 			if ap.HasSavedSession() {
-				core.Log.Infof("Core", "AnyConnect tunnel %q has saved session, attempting resume...", tunnelID)
+				core.Log.Infof("Core", "%s tunnel %q has saved session, attempting resume...", ap.Protocol(), tunnelID)
 				tc.deps.Registry.SetState(tunnelID, core.TunnelStateConnecting, nil)
-				core.SafeGo(fmt.Sprintf("anyconnect.reconnect-%s", tunnelID), func() {
+				core.SafeGo(fmt.Sprintf("%s.reconnect-%s", ap.Protocol(), tunnelID), func() {
 					ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 					defer cancel()
 					if reconnErr := ap.Connect(ctx); reconnErr != nil {
-						core.Log.Warnf("Core", "AnyConnect session resume failed for %q: %v", tunnelID, reconnErr)
+						core.Log.Warnf("Core", "%s session resume failed for %q: %v", ap.Protocol(), tunnelID, reconnErr)
 						ap.ClearSession()
 						tc.deps.Registry.SetState(tunnelID, core.TunnelStateError, fmt.Errorf("session expired: %v", err))
 						tc.deps.Bus.PublishAsync(core.Event{
@@ -236,7 +280,7 @@ func (tc *TunnelControllerImpl) ConnectTunnelWithAuth(ctx context.Context, tunne
 							},
 						})
 					} else {
-						core.Log.Infof("Core", "AnyConnect tunnel %q resumed successfully", tunnelID)
+						core.Log.Infof("Core", "%s tunnel %q resumed successfully", ap.Protocol(), tunnelID)
 						tc.deps.Registry.SetState(tunnelID, core.TunnelStateUp, nil)
 						tc.registerRawForwarder(tunnelID, ap)
 					}
@@ -261,6 +305,16 @@ func (tc *TunnelControllerImpl) ConnectTunnelWithAuth(ctx context.Context, tunne
 
 	if err := inst.provider.Connect(connectCtx); err != nil {
 		tc.deps.Registry.SetState(tunnelID, core.TunnelStateError, err)
+		if errors.Is(err, provider.ErrAuthRequired) {
+			// Ask the frontend for the OTP and retry via ConnectTunnelWithAuth.
+			tc.deps.Bus.PublishAsync(core.Event{
+				Type: core.EventAuthRequired,
+				Payload: core.AuthRequiredPayload{
+					TunnelID: tunnelID,
+					Reason:   err.Error(),
+				},
+			})
+		}
 		return fmt.Errorf("connect tunnel %q: %w", tunnelID, err)
 	}
 
@@ -389,15 +443,19 @@ func (tc *TunnelControllerImpl) AddTunnel(ctx context.Context, cfg core.TunnelCo
 	// If conf file data provided, handle per protocol.
 	if len(confFileData) > 0 {
 		if cfg.Protocol == core.ProtocolVLESS {
-			// Detect format: vless:// URI or JSON.
+			// Detect format: vless:// / vmess:// URI or JSON.
 			raw := strings.TrimSpace(string(confFileData))
 			var vlessCfg vless.Config
-			if strings.HasPrefix(raw, "vless://") {
+			if vless.IsURI(raw) {
 				var uriName string
 				var err error
-				vlessCfg, uriName, err = vless.ParseVLESSURI(raw)
+				if strings.HasPrefix(raw, "vmess://") {
+					vlessCfg, uriName, err = vless.ParseVMessURI(raw)
+				} else {
+					vlessCfg, uriName, err = vless.ParseVLESSURI(raw)
+				}
 				if err != nil {
-					return fmt.Errorf("parse VLESS URI: %w", err)
+					return fmt.Errorf("parse share link: %w", err)
 				}
 				if cfg.Name == "" && uriName != "" {
 					cfg.Name = uriName
@@ -410,9 +468,43 @@ func (tc *TunnelControllerImpl) AddTunnel(ctx context.Context, cfg core.TunnelCo
 				}
 			}
 			applyVLESSSettings(&cfg, vlessCfg)
+		} else if cfg.Protocol == core.ProtocolHysteria2 || cfg.Protocol == core.ProtocolTUIC {
+			// hysteria2:// / tuic:// share links become settings; nothing is written to disk.
+			raw := strings.TrimSpace(string(confFileData))
+			parse := hysteria2.ParseURIToTunnelConfig
+			if cfg.Protocol == core.ProtocolTUIC {
+				parse = tuic.ParseURIToTunnelConfig
+			}
+			parsed, err := parse(raw)
+			if err != nil {
+				return fmt.Errorf("parse share link: %w", err)
+			}
+			if cfg.Name == "" {
+				cfg.Name = parsed.Name
+			}
+			if cfg.Settings == nil {
+				cfg.Settings = make(map[string]any)
+			}
+			for k, v := range parsed.Settings {
+				cfg.Settings[k] = v
+			}
 		} else {
-			// AWG / WireGuard: write .conf file next to executable.
-			confFileName := getStringSetting(cfg.Settings, "config_file", cfg.ID+".conf")
+			ext := ".conf"
+			if cfg.Protocol == core.ProtocolOpenVPN {
+				ext = ".ovpn"
+			} else if raw := string(confFileData); wgconf.IsShareURI(raw) {
+				// AWG / WireGuard: share strings (wg://, vpn://) are decoded to .conf first.
+				wc, name, err := wgconf.Decode(raw)
+				if err != nil {
+					return fmt.Errorf("parse share link: %w", err)
+				}
+				if cfg.Name == "" {
+					cfg.Name = name
+				}
+				confFileData = []byte(wc.String())
+			}
+			// Write .conf file next to executable.
+			confFileName := getStringSetting(cfg.Settings, "config_file", cfg.ID+ext)
 			confPath := resolveRelativeToExe(confFileName)
 			if err := os.WriteFile(confPath, confFileData, 0600); err != nil {
 				return fmt.Errorf("write config file %q: %w", confPath, err)
@@ -424,10 +516,22 @@ func (tc *TunnelControllerImpl) AddTunnel(ctx context.Context, cfg core.TunnelCo
 		}
 	}
 
+	// Never persist a plain-text TOTP seed.
+	seedSealed, err := sealTOTPSeed(&cfg)
+	if err != nil {
+		return err
+	}
+
 	// Create provider.
 	var prov provider.TunnelProvider
-	switch cfg.Protocol {
-	case "direct":
+	switch {
+	case tc.deps.NewProvider != nil:
+		var err error
+		prov, err = tc.deps.NewProvider(cfg)
+		if err != nil {
+			return err
+		}
+	case cfg.Protocol == "direct":
 		var err error
 		prov, err = direct.New(tc.deps.RealNICIndex, tc.deps.RealNICLocalIP, tc.deps.InterfaceBinder)
 		if err != nil {
@@ -455,11 +559,19 @@ func (tc *TunnelControllerImpl) AddTunnel(ctx context.Context, cfg core.TunnelCo
 	if tc.domainMatchFn != nil {
 		tp.SetDomainMatchFunc(tc.domainMatchFn)
 	}
+	tp.SetShaper(tc.deps.Shaper)
+	tp.SetConnLimits(tc.deps.ConnLimits)
+	tp.SetTunnelReporter(tc.deps.Flows.SetTunnelTCP)
+	tp.SetListen(tc.deps.ListenTCP)
 	if err := tp.Start(tc.deps.Context); err != nil {
 		return fmt.Errorf("start TCP proxy for %q: %w", cfg.ID, err)
 	}
 
 	up := proxy.NewUDPProxy(udpProxyPort, tc.deps.Flows.LookupUDPNAT, tc.providerLookup, tc.fallbackDialer)
+	up.SetShaper(tc.deps.Shaper)
+	up.SetTunnelReporter(tc.deps.Flows.SetTunnelUDP)
+	up.SetPeerRegistrar(tc.deps.Flows.RegisterUDPPeer)
+	up.SetListen(tc.deps.ListenUDP)
 	if err := up.Start(tc.deps.Context); err != nil {
 		tp.Stop()
 		return fmt.Errorf("start UDP proxy for %q: %w", cfg.ID, err)
@@ -480,7 +592,7 @@ func (tc *TunnelControllerImpl) AddTunnel(ctx context.Context, cfg core.TunnelCo
 	// Skip ephemeral tunnels: subscription-sourced and direct.
 	_, isSub := cfg.Settings["_subscription"]
 	if !isSub && cfg.Protocol != "direct" {
-		tc.persistTunnelConfig(cfg)
+		tc.persistTunnelConfig(cfg, seedSealed)
 	}
 
 	return nil
@@ -573,8 +685,13 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 		if configFile != "" {
 			configFile = resolveRelativeToExe(configFile)
 		}
+		configText, err := wireGuardConfigText(cfg.Settings)
+		if err != nil {
+			return nil, err
+		}
 		awgCfg := amneziawg.Config{
 			ConfigFile: configFile,
+			ConfigText: configText,
 			AdapterIP:  getStringSetting(cfg.Settings, "adapter_ip", ""),
 		}
 		return amneziawg.New(cfg.Name, awgCfg)
@@ -583,9 +700,15 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 		if configFile != "" {
 			configFile = resolveRelativeToExe(configFile)
 		}
+		configText, err := wireGuardConfigText(cfg.Settings)
+		if err != nil {
+			return nil, err
+		}
 		wgCfg := wireguard.Config{
 			ConfigFile: configFile,
+			ConfigText: configText,
 			AdapterIP:  getStringSetting(cfg.Settings, "adapter_ip", ""),
+			Obfs:       obfsSettings(cfg.Settings),
 		}
 		return wireguard.New(cfg.Name, wgCfg)
 	case core.ProtocolSOCKS5:
@@ -595,6 +718,7 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 			Username:   getStringSetting(cfg.Settings, "username", ""),
 			Password:   getStringSetting(cfg.Settings, "password", ""),
 			UDPEnabled: getBoolSetting(cfg.Settings, "udp_enabled", true),
+			Obfs:       obfsSettings(cfg.Settings),
 		}
 		return socks5.New(cfg.Name, socksCfg)
 	case core.ProtocolHTTPProxy:
@@ -605,17 +729,33 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 			Password:      getStringSetting(cfg.Settings, "password", ""),
 			TLS:           getBoolSetting(cfg.Settings, "tls", false),
 			TLSSkipVerify: getBoolSetting(cfg.Settings, "tls_skip_verify", false),
+			Obfs:          obfsSettings(cfg.Settings),
 		}
 		return httpproxy.New(cfg.Name, httpCfg)
 	case core.ProtocolVLESS:
 		vlessCfg := vless.Config{
+			Outbound:   getStringSetting(cfg.Settings, "outbound", vless.OutboundVLESS),
 			Address:    getStringSetting(cfg.Settings, "address", ""),
 			Port:       getIntSetting(cfg.Settings, "port", 443),
 			UUID:       getStringSetting(cfg.Settings, "uuid", ""),
 			Flow:       getStringSetting(cfg.Settings, "flow", ""),
 			Encryption: getStringSetting(cfg.Settings, "encryption", "none"),
 			Network:    getStringSetting(cfg.Settings, "network", "tcp"),
-			Security:   getStringSetting(cfg.Settings, "security", "reality"),
+			// Empty lets the provider pick: reality for VLESS, none otherwise.
+			Security: getStringSetting(cfg.Settings, "security", ""),
+		}
+		// Parse nested VMess / Shadowsocks settings.
+		if vmessCfg := getMapSetting(cfg.Settings, "vmess"); vmessCfg != nil {
+			vlessCfg.VMess = vless.VMessConfig{
+				Security: getStringSetting(vmessCfg, "security", ""),
+			}
+		}
+		if ssCfg := getMapSetting(cfg.Settings, "shadowsocks"); ssCfg != nil {
+			vlessCfg.Shadowsocks = vless.ShadowsocksConfig{
+				Method:   getStringSetting(ssCfg, "method", ""),
+				Password: getStringSetting(ssCfg, "password", ""),
+				UoT:      getBoolSetting(ssCfg, "uot", false),
+			}
 		}
 		// Parse nested reality settings.
 		if reality := getMapSetting(cfg.Settings, "reality"); reality != nil {
@@ -666,6 +806,37 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 				vlessCfg.XHTTP.Extra = extra
 			}
 		}
+		// Parse nested HTTPUpgrade settings.
+		if huCfg := getMapSetting(cfg.Settings, "httpupgrade"); huCfg != nil {
+			vlessCfg.HTTPUpgrade = vless.HTTPUpgradeConfig{
+				Path: getStringSetting(huCfg, "path", ""),
+				Host: getStringSetting(huCfg, "host", ""),
+			}
+		}
+		// Parse mux / XUDP settings.
+		if muxCfg := getMapSetting(cfg.Settings, "mux"); muxCfg != nil {
+			vlessCfg.Mux = vless.MuxConfig{
+				Enabled:         getBoolSetting(muxCfg, "enabled", false),
+				Concurrency:     getIntSetting(muxCfg, "concurrency", 0),
+				XUDPConcurrency: getIntSetting(muxCfg, "xudp_concurrency", 0),
+				XUDPProxyUDP443: getStringSetting(muxCfg, "xudp_proxy_udp443", ""),
+			}
+		}
+		// Parse DPI evasion settings: TLS fragmentation and UDP noise.
+		if fragCfg := getMapSetting(cfg.Settings, "fragment"); fragCfg != nil {
+			vlessCfg.Fragment = vless.FragmentConfig{
+				Packets:  getStringSetting(fragCfg, "packets", ""),
+				Length:   getStringSetting(fragCfg, "length", ""),
+				Interval: getStringSetting(fragCfg, "interval", ""),
+			}
+		}
+		if noises := getStringSetting(cfg.Settings, "noises", ""); noises != "" {
+			parsed, err := vless.ParseNoises(noises)
+			if err != nil {
+				return nil, fmt.Errorf("[VLESS] %w", err)
+			}
+			vlessCfg.Noises = parsed
+		}
 		return vless.New(cfg.Name, vlessCfg)
 	case core.ProtocolAnyConnect:
 		acCfg := anyconnect.Config{
@@ -685,6 +856,52 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 			DTLS:          getBoolSetting(cfg.Settings, "dtls", false),
 		}
 		return anyconnect.New(cfg.Name, acCfg)
+	case core.ProtocolGlobalProtect:
+		gpCfg := globalprotect.Config{
+			Server:             getStringSetting(cfg.Settings, "server", ""),
+			Port:               getIntSetting(cfg.Settings, "port", 443),
+			Username:           getStringSetting(cfg.Settings, "username", ""),
+			Password:           getStringSetting(cfg.Settings, "password", ""),
+			Gateway:            getStringSetting(cfg.Settings, "gateway", ""),
+			GatewayOnly:        getBoolSetting(cfg.Settings, "gateway_only", false),
+			TLSSkipVerify:      getBoolSetting(cfg.Settings, "tls_skip_verify", false),
+			ClientOS:           getStringSetting(cfg.Settings, "client_os", ""),
+			ClientCert:         getStringSetting(cfg.Settings, "client_cert", ""),
+			ClientKey:          getStringSetting(cfg.Settings, "client_key", ""),
+			ClientCertPassword: getStringSetting(cfg.Settings, "client_cert_password", ""),
+			ProxyURL:           getStringSetting(cfg.Settings, "proxy_url", ""),
+			ProxyUsername:      getStringSetting(cfg.Settings, "proxy_username", ""),
+			ProxyPassword:      getStringSetting(cfg.Settings, "proxy_password", ""),
+			ESP:                getBoolSetting(cfg.Settings, "esp", true),
+		}
+		return globalprotect.New(cfg.Name, gpCfg)
+	case core.ProtocolFortinet:
+		ftCfg := fortinet.Config{
+			Server:             getStringSetting(cfg.Settings, "server", ""),
+			Port:               getIntSetting(cfg.Settings, "port", 443),
+			Username:           getStringSetting(cfg.Settings, "username", ""),
+			Password:           getStringSetting(cfg.Settings, "password", ""),
+			Realm:              getStringSetting(cfg.Settings, "realm", ""),
+			TLSSkipVerify:      getBoolSetting(cfg.Settings, "tls_skip_verify", false),
+			SAML:               getBoolSetting(cfg.Settings, "saml", false),
+			ClientCert:         getStringSetting(cfg.Settings, "client_cert", ""),
+			ClientKey:          getStringSetting(cfg.Settings, "client_key", ""),
+			ClientCertPassword: getStringSetting(cfg.Settings, "client_cert_password", ""),
+			ProxyURL:           getStringSetting(cfg.Settings, "proxy_url", ""),
+			ProxyUsername:      getStringSetting(cfg.Settings, "proxy_username", ""),
+			ProxyPassword:      getStringSetting(cfg.Settings, "proxy_password", ""),
+		}
+		return fortinet.New(cfg.Name, ftCfg)
+	case core.ProtocolOpenVPN:
+		ovpnCfg := openvpn.Config{
+			ConfigText: getStringSetting(cfg.Settings, "config", ""),
+			Username:   getStringSetting(cfg.Settings, "username", ""),
+			Password:   getStringSetting(cfg.Settings, "password", ""),
+		}
+		if f := getStringSetting(cfg.Settings, "config_file", ""); f != "" {
+			ovpnCfg.ConfigFile = resolveRelativeToExe(f)
+		}
+		return openvpn.New(cfg.Name, ovpnCfg)
 	case core.ProtocolHysteria2:
 		hy2Cfg := hysteria2.Config{
 			Server:       getStringSetting(cfg.Settings, "server", ""),
@@ -695,19 +912,49 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 			Insecure:     getBoolSetting(cfg.Settings, "insecure", false),
 			UpMbps:       getIntSetting(cfg.Settings, "up_mbps", 0),
 			DownMbps:     getIntSetting(cfg.Settings, "down_mbps", 0),
+			HopInterval:  getIntSetting(cfg.Settings, "hop_interval", 0),
 		}
 		return hysteria2.New(cfg.Name, hy2Cfg)
+	case core.ProtocolTUIC:
+		tuicCfg := tuic.Config{
+			Server:            getStringSetting(cfg.Settings, "server", ""),
+			UUID:              getStringSetting(cfg.Settings, "uuid", ""),
+			Password:          getStringSetting(cfg.Settings, "password", ""),
+			SNI:               getStringSetting(cfg.Settings, "sni", ""),
+			ALPN:              tuic.ParseALPN(getStringSetting(cfg.Settings, "alpn", "")),
+			Insecure:          getBoolSetting(cfg.Settings, "insecure", false),
+			UDPRelayMode:      getStringSetting(cfg.Settings, "udp_relay_mode", ""),
+			CongestionControl: getStringSetting(cfg.Settings, "congestion_control", ""),
+		}
+		return tuic.New(cfg.Name, tuicCfg)
 	case core.ProtocolSSH:
 		sshCfg := sshprov.Config{
 			Server:               getStringSetting(cfg.Settings, "server", ""),
-			Port:                 getIntSetting(cfg.Settings, "port", 22),
+			Port:                 getIntSetting(cfg.Settings, "port", 0),
 			Username:             getStringSetting(cfg.Settings, "username", ""),
 			Password:             getStringSetting(cfg.Settings, "password", ""),
 			PrivateKeyPath:       getStringSetting(cfg.Settings, "private_key_path", ""),
 			PrivateKeyPassphrase: getStringSetting(cfg.Settings, "private_key_passphrase", ""),
+			CertificatePath:      getStringSetting(cfg.Settings, "certificate_path", ""),
+			UseAgent:             getBoolSetting(cfg.Settings, "use_agent", false),
+			AgentSocket:          getStringSetting(cfg.Settings, "agent_socket", ""),
+			KeyboardInteractive:  getBoolSetting(cfg.Settings, "keyboard_interactive", false),
+			ProxyJump:            getStringSetting(cfg.Settings, "proxy_jump", ""),
+			SSHConfigPath:        getStringSetting(cfg.Settings, "ssh_config_path", ""),
 			HostKey:              getStringSetting(cfg.Settings, "host_key", ""),
 			InsecureSkipHostKey:  getBoolSetting(cfg.Settings, "insecure_skip_host_key", false),
 			KeepaliveInterval:    getIntSetting(cfg.Settings, "keepalive_interval", 30),
+			TunUDP:               getBoolSetting(cfg.Settings, "tun_udp", false),
+			TunAddress:           getStringSetting(cfg.Settings, "tun_address", ""),
+			TunUnit:              getIntSetting(cfg.Settings, "tun_unit", -1),
+		}
+		if keys := getMapSetting(cfg.Settings, "jump_host_keys"); keys != nil {
+			sshCfg.JumpHostKeys = make(map[string]string, len(keys))
+			for host, v := range keys {
+				if key, ok := v.(string); ok {
+					sshCfg.JumpHostKeys[host] = key
+				}
+			}
 		}
 		return sshprov.New(cfg.Name, sshCfg)
 	default:
@@ -715,6 +962,25 @@ func CreateProvider(cfg core.TunnelConfig) (provider.TunnelProvider, error) {
 	}
 }
 
+// obfsSettings parses the nested "obfs" settings of a WireGuard, SOCKS5 or
+// HTTP proxy tunnel. Missing settings disable obfuscation.
+func obfsSettings(settings map[string]any) obfs.Config {
+	m := getMapSetting(settings, "obfs")
+	if m == nil {
+		return obfs.Config{}
+	}
+	return obfs.Config{
+		Mode:       getStringSetting(m, "mode", obfs.ModeOff),
+		Server:     getStringSetting(m, "server", ""),
+		Host:       getStringSetting(m, "host", ""),
+		Path:       getStringSetting(m, "path", ""),
+		SkipVerify: getBoolSetting(m, "skip_verify", false),
+		Jc:         getIntSetting(m, "jc", 0),
+		Jmin:       getIntSetting(m, "jmin", 0),
+		Jmax:       getIntSetting(m, "jmax", 0),
+	}
+}
+
 // applySplitRoutes updates the IPFilter with dynamic AllowedIPs received from
 // providers that implement SplitRouteProvider (e.g. AnyConnect Split-Include).
 func (tc *TunnelControllerImpl) applySplitRoutes(tunnelID string, prov provider.TunnelProvider) {
@@ -766,6 +1032,7 @@ func (tc *TunnelControllerImpl) registerRawForwarder(tunnelID string, prov provi
 		vpnIP := prov.GetAdapterIP()
 		if vpnIP.IsValid() && vpnIP.Is4() {
 			tc.deps.TUNRouter.RegisterRawForwarder(tunnelID, rf, vpnIP.As4())
+			tc.probePathMTU(tunnelID)
 		}
 	}
 }
@@ -791,6 +1058,127 @@ func (tc *TunnelControllerImpl) addBypassRoutes(tunnelID string, prov provider.T
 	}
 }
 
+// SetEndpointResolver sets the resolver used by RefreshEndpoints. It must
+// bypass the TUN (NIC-bound): a tunnel whose server moved cannot resolve
+// the new address through itself.
+func (tc *TunnelControllerImpl) SetEndpointResolver(resolver *net.Resolver) {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	tc.endpointResolver = resolver
+}
+
+// SetFailover applies the failover racing settings to the shared fallback
+// dialer. Takes effect for new connections.
+func (tc *TunnelControllerImpl) SetFailover(cfg *core.FailoverConfig) {
+	tc.fallbackDialer.Configure(cfg)
+}
+
+// RefreshEndpoints re-resolves the hostname server endpoints of a connected
+// tunnel and, for each one that changed, moves the bypass route, WFP permit
+// and TUN router mapping to the new IP. Returns true if any endpoint changed.
+func (tc *TunnelControllerImpl) RefreshEndpoints(tunnelID string) bool {
+	tc.mu.Lock()
+	inst, ok := tc.instances[tunnelID]
+	resolver := tc.endpointResolver
+	tc.mu.Unlock()
+	if !ok || resolver == nil {
+		return false
+	}
+	er, ok := inst.provider.(provider.EndpointRefresher)
+	if !ok {
+		return false
+	}
+
+	// Per-tunnel lock to serialize with Connect/Disconnect.
+	inst.opMu.Lock()
+	defer inst.opMu.Unlock()
+	if tc.deps.Registry.GetState(tunnelID) != core.TunnelStateUp {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(tc.ctx, 10*time.Second)
+	defer cancel()
+	changes, err := er.RefreshEndpoints(ctx, resolver)
+	if err != nil {
+		core.Log.Warnf("Core", "Endpoint refresh for %q: %v", tunnelID, err)
+	}
+	for _, c := range changes {
+		tc.moveServerEndpoint(tunnelID, c.Old.Addr(), c.New.Addr())
+	}
+	return len(changes) > 0
+}
+
+// StartEndpointRefresh periodically re-resolves hostname endpoints of all
+// connected tunnels until the controller context is cancelled.
+func (tc *TunnelControllerImpl) StartEndpointRefresh(interval time.Duration) {
+	core.SafeGo("endpoint.refresh", func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-tc.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			tc.mu.Lock()
+			ids := make([]string, 0, len(tc.instances))
+			for id, inst := range tc.instances {
+				if _, ok := inst.provider.(provider.EndpointRefresher); ok {
+					ids = append(ids, id)
+				}
+			}
+			tc.mu.Unlock()
+			for _, id := range ids {
+				tc.RefreshEndpoints(id)
+			}
+		}
+	})
+	core.Log.Infof("Core", "Endpoint refresh started (interval=%s)", interval)
+}
+
+// moveServerEndpoint replaces the bypass state of a server IP after its
+// hostname resolved to a new address. The old WFP permit is left in place:
+// ProcessFilter has no per-prefix removal, and the permit only allows
+// traffic that would otherwise be blocked toward a former server IP.
+func (tc *TunnelControllerImpl) moveServerEndpoint(tunnelID string, oldIP, newIP netip.Addr) {
+	if oldIP.IsValid() && !tc.serverIPInUse(oldIP, tunnelID) {
+		if err := tc.deps.RouteMgr.RemoveBypassRoute(oldIP); err != nil {
+			core.Log.Warnf("Core", "Failed to remove bypass route for %s: %v", oldIP, err)
+		}
+		tc.deps.TUNRouter.RemoveServerEndpoint(oldIP)
+	}
+	if err := tc.deps.RouteMgr.AddBypassRoute(newIP); err != nil {
+		core.Log.Warnf("Core", "Failed to add bypass route for %s: %v", newIP, err)
+	}
+	prefix := netip.PrefixFrom(newIP, newIP.BitLen())
+	if err := tc.deps.WFPMgr.AddBypassPrefixes([]netip.Prefix{prefix}); err != nil {
+		core.Log.Warnf("Core", "Failed to add WFP permit for %s: %v", newIP, err)
+	}
+	tc.deps.TUNRouter.AddServerEndpoint(newIP, tunnelID)
+}
+
+// serverIPInUse reports whether another connected tunnel still uses ip as
+// a server endpoint (e.g. two configs for the same server).
+func (tc *TunnelControllerImpl) serverIPInUse(ip netip.Addr, exceptID string) bool {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+	for id, inst := range tc.instances {
+		if id == exceptID || tc.deps.Registry.GetState(id) != core.TunnelStateUp {
+			continue
+		}
+		ep, ok := inst.provider.(provider.EndpointProvider)
+		if !ok {
+			continue
+		}
+		for _, addr := range ep.GetServerEndpoints() {
+			if addr.Addr() == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // RegisterExistingTunnel registers a tunnel that was already created during startup.
 // Used to migrate existing tunnels from main.go's startup sequence to the controller.
 func (tc *TunnelControllerImpl) RegisterExistingTunnel(
@@ -818,6 +1206,36 @@ func (tc *TunnelControllerImpl) ProviderLookup() func(string) (provider.TunnelPr
 	return tc.providerLookup
 }
 
+// PinPeer routes dst through one peer of a multi-peer tunnel. Used by the
+// TUN router for rules targeting "tunnel_id/peer".
+func (tc *TunnelControllerImpl) PinPeer(tunnelID, peer string, dst netip.Addr) {
+	prov, ok := tc.providerLookup(tunnelID)
+	if !ok {
+		return
+	}
+	mp, ok := prov.(provider.MultiPeerProvider)
+	if !ok {
+		core.Log.Debugf("Core", "Rule target %s/%s: tunnel has no peers to select", tunnelID, peer)
+		return
+	}
+	if err := mp.PinPeer(peer, dst); err != nil {
+		core.Log.Warnf("Core", "Pin %s to %s/%s: %v", dst, tunnelID, peer, err)
+	}
+}
+
+// GetTunnelPeers returns per-peer state of a WireGuard/AWG tunnel.
+func (tc *TunnelControllerImpl) GetTunnelPeers(tunnelID string) ([]provider.PeerInfo, error) {
+	prov, ok := tc.providerLookup(tunnelID)
+	if !ok {
+		return nil, fmt.Errorf("tunnel %q not found", tunnelID)
+	}
+	mp, ok := prov.(provider.MultiPeerProvider)
+	if !ok {
+		return nil, fmt.Errorf("tunnel %q has no peers", tunnelID)
+	}
+	return mp.Peers()
+}
+
 // MarkTunnelUnhealthy transitions a tunnel from Up to Error state and disconnects it.
 // Uses CAS to prevent races with concurrent Connect/Disconnect. Returns true if the
 // transition was performed.
@@ -926,6 +1344,15 @@ func applyVLESSSettings(cfg *core.TunnelConfig, vc vless.Config) {
 			cfg.Settings["xhttp"] = xhttp
 		}
 	}
+
+	// Outbound-specific, transport, mux and DPI evasion keys are shared
+	// with subscription imports.
+	parsed := vless.ConfigToSettings(vc)
+	for _, key := range []string{"outbound", "vmess", "shadowsocks", "httpupgrade", "mux", "fragment", "noises"} {
+		if v, ok := parsed[key]; ok {
+			cfg.Settings[key] = v
+		}
+	}
 }
 
 // ─── ID generation & config persistence ─────────────────────────────
@@ -958,17 +1385,27 @@ func (tc *TunnelControllerImpl) generateTunnelID(name, protocol string) string {
 }
 
 // persistTunnelConfig adds the tunnel config to ConfigManager and saves.
-func (tc *TunnelControllerImpl) persistTunnelConfig(tunnelCfg core.TunnelConfig) {
+// An existing entry is only overwritten when replace is set (e.g. a TOTP
+// seed loaded in plain text was sealed).
+func (tc *TunnelControllerImpl) persistTunnelConfig(tunnelCfg core.TunnelConfig, replace bool) {
 	if tc.deps.Cfg == nil {
 		return
 	}
 	cfg := tc.deps.Cfg.Get()
-	for _, existing := range cfg.Tunnels {
+	found := false
+	for i, existing := range cfg.Tunnels {
 		if existing.ID == tunnelCfg.ID {
-			return // already in config
+			if !replace {
+				return // already in config
+			}
+			cfg.Tunnels[i] = tunnelCfg
+			found = true
+			break
 		}
 	}
-	cfg.Tunnels = append(cfg.Tunnels, tunnelCfg)
+	if !found {
+		cfg.Tunnels = append(cfg.Tunnels, tunnelCfg)
+	}
 	tc.deps.Cfg.SetFromGUI(cfg)
 	if err := tc.deps.Cfg.Save(); err != nil {
 		core.Log.Warnf("Core", "Failed to persist tunnel %q config: %v", tunnelCfg.ID, err)
@@ -1034,6 +1471,23 @@ func getBoolSetting(settings map[string]any, key string, defaultVal bool) bool {
 	return defaultVal
 }
 
+// wireGuardConfigText returns the inline .conf text of a WireGuard/AmneziaWG
+// tunnel: the "config" setting verbatim, or the structured interface/peers
+// settings rendered to .conf. Returns "" for tunnels that use config_file.
+func wireGuardConfigText(settings map[string]any) (string, error) {
+	if text := getStringSetting(settings, "config", ""); text != "" {
+		return text, nil
+	}
+	if !wgconf.HasSettings(settings) {
+		return "", nil
+	}
+	wc, err := wgconf.FromSettings(settings)
+	if err != nil {
+		return "", fmt.Errorf("inline config: %w", err)
+	}
+	return wc.String(), nil
+}
+
 func getMapSetting(settings map[string]any, key string) map[string]any {
 	if v, ok := settings[key]; ok {
 		if m, ok := v.(map[string]any); ok {
@@ -1073,8 +1527,8 @@ func (tc *TunnelControllerImpl) saveActiveTunnels() {
 		if tc.deps.Registry.GetState(id) != core.TunnelStateUp {
 			continue
 		}
-		// Don't persist tunnels that require interactive auth (e.g. AnyConnect with OTP).
-		if entry, ok := tc.deps.Registry.Get(id); ok && entry.Config.Protocol == "anyconnect" {
+		// Don't persist tunnels that require interactive auth (e.g. SSL-VPN with OTP).
+		if entry, ok := tc.deps.Registry.Get(id); ok && core.IsSSLVPNProtocol(entry.Config.Protocol) {
 			continue
 		}
 		active = append(active, id)
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/gateway"
+	"awg-split-tunnel/internal/provider"
+)
+
+const (
+	// defaultPMTUTarget is probed when the tunnel has no pmtu_probe and no
+	// server-pushed DNS.
+	defaultPMTUTarget = "1.1.1.1"
+
+	// pmtuProbeBudget bounds one tunnel's probe run.
+	pmtuProbeBudget = 30 * time.Second
+)
+
+// probePathMTU sets the path MTU of a raw-forwarding tunnel: the fixed mtu
+// from its config, or the value measured by probing through it. The
+// provider's own MTU is applied at once so MSS clamping is right before the
+// probe finishes. Stream tunnels (VLESS, Hysteria2) terminate TCP locally
+// and are not probed.
+func (tc *TunnelControllerImpl) probePathMTU(tunnelID string) {
+	tc.mu.Lock()
+	inst, ok := tc.instances[tunnelID]
+	tc.mu.Unlock()
+	if !ok || tc.deps.TUNRouter == nil {
+		return
+	}
+	if _, ok := inst.provider.(provider.RawForwarder); !ok {
+		return
+	}
+	cfg := inst.config
+
+	ceiling := 0
+	if mp, ok := inst.provider.(provider.MTUProvider); ok {
+		ceiling = mp.MTU()
+	}
+	if cfg.MTU > 0 {
+		if ceiling == 0 || cfg.MTU < ceiling {
+			ceiling = cfg.MTU
+		}
+		tc.deps.TUNRouter.SetTunnelMTU(tunnelID, ceiling)
+		return
+	}
+	tc.deps.TUNRouter.SetTunnelMTU(tunnelID, ceiling)
+	if cfg.PMTUProbe == core.PMTUProbeOff {
+		return
+	}
+	target := pmtuTarget(cfg, inst.provider)
+	if ceiling == 0 {
+		ceiling = 1500
+	}
+
+	core.SafeGo("pmtu-"+tunnelID, func() {
+		ctx, cancel := context.WithTimeout(tc.deps.Context, pmtuProbeBudget)
+		defer cancel()
+		mtu, err := tc.deps.TUNRouter.ProbePathMTU(ctx, tunnelID, target, ceiling)
+		if err != nil {
+			switch {
+			case errors.Is(err, context.Canceled):
+			case errors.Is(err, gateway.ErrPMTUNoReply):
+				core.Log.Infof("Core", "Path MTU probe for %q: %s does not answer ping, keeping MTU %d", tunnelID, target, tc.GetPathMTU(tunnelID))
+			default:
+				core.Log.Warnf("Core", "Path MTU probe for %q via %s failed: %v", tunnelID, target, err)
+			}
+			return
+		}
+		tc.deps.TUNRouter.SetTunnelMTU(tunnelID, mtu)
+		core.Log.Infof("Core", "Path MTU for %q: %d (probed via %s)", tunnelID, mtu, target)
+	})
+}
+
+// pmtuTarget picks the address probed through the tunnel.
+func pmtuTarget(cfg core.TunnelConfig, prov provider.TunnelProvider) netip.Addr {
+	if a, err := netip.ParseAddr(cfg.PMTUProbe); err == nil && a.Is4() {
+		return a
+	}
+	if dp, ok := prov.(provider.DNSProvider); ok {
+		for _, s := range dp.GetDNS() {
+			if a, err := netip.ParseAddr(s); err == nil && a.Is4() {
+				return a
+			}
+		}
+	}
+	return netip.MustParseAddr(defaultPMTUTarget)
+}
+
+// ReprobePathMTU probes the path MTU of every connected raw-forwarding
+// tunnel again. Called after a network change: the new uplink may carry
+// less than the old one.
+func (tc *TunnelControllerImpl) ReprobePathMTU() {
+	tc.mu.Lock()
+	ids := make([]string, 0, len(tc.instances))
+	for id, inst := range tc.instances {
+		if inst.provider.State() == core.TunnelStateUp {
+			ids = append(ids, id)
+		}
+	}
+	tc.mu.Unlock()
+	for _, id := range ids {
+		tc.probePathMTU(id)
+	}
+}
+
+// GetPathMTU returns the path MTU of a raw-forwarding tunnel, 0 if unknown.
+func (tc *TunnelControllerImpl) GetPathMTU(tunnelID string) int {
+	if tc.deps.TUNRouter == nil {
+		return 0
+	}
+	return tc.deps.TUNRouter.TunnelMTU(tunnelID)
+}
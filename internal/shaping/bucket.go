@@ -0,0 +1,192 @@
+// Package shaping implements the token-bucket bandwidth limits configured
+// globally, per tunnel and per process rule (rate_limit).
+//
+// A flow is charged against every bucket on its path. Stream copy loops
+// (TCP proxy) wait for tokens, so TCP slows down smoothly. Packet paths
+// (raw forwarding, UDP proxy) cannot block and drop packets over the limit
+// instead. High-priority packets may borrow up to one burst ahead of the
+// rate, so a call keeps working while bulk traffic sharing the limit backs
+// off, but a flood of them is still held to the rate.
+package shaping
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// burstWindow sizes the default burst: 250 ms worth of the rate.
+	burstWindow = 250 * time.Millisecond
+	// minBurst keeps low rates from dropping every full-size packet.
+	minBurst = 16 * 1024
+)
+
+// Bucket is a token bucket shared by every flow it limits. Its rate can be
+// changed at any time; flows holding the bucket see the change immediately.
+type Bucket struct {
+	limited atomic.Bool // fast path: false = unlimited
+
+	mu     sync.Mutex
+	rate   float64 // bytes per second
+	burst  float64 // bytes
+	tokens float64 // may go negative after Wait or AllowPriority
+	last   time.Time
+}
+
+// set changes the rate (bits per second, 0 = unlimited) and burst (bytes,
+// 0 = automatic). A full bucket stays full; debt is kept.
+func (b *Bucket) set(bitsPerSec, burstBytes int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasFull := !b.limited.Load() || b.tokens >= b.burst
+	b.rate = float64(bitsPerSec) / 8
+	b.burst = float64(burstBytes)
+	if b.burst == 0 {
+		b.burst = max(b.rate*burstWindow.Seconds(), minBurst)
+	}
+	if wasFull || b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = time.Now()
+	b.limited.Store(bitsPerSec > 0)
+}
+
+// refill adds the tokens accumulated since the last call. Caller holds mu.
+func (b *Bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed*b.rate, b.burst)
+		b.last = now
+	}
+}
+
+// reserve takes n tokens and returns how long the caller must wait until
+// the bucket is out of debt.
+func (b *Bucket) reserve(now time.Time, n int) time.Duration {
+	if !b.limited.Load() {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens -= float64(n)
+	if b.tokens >= 0 || b.rate == 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// take takes n tokens if available. Packets larger than the burst pass
+// whenever the bucket is full, so they are not dropped forever.
+func (b *Bucket) take(now time.Time, n int) bool {
+	if !b.limited.Load() {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens < min(float64(n), b.burst) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// borrow takes n tokens, going into debt by at most one burst. Priority
+// packets pass while the bucket is empty, but not for longer than the
+// burst would last at the rate.
+func (b *Bucket) borrow(now time.Time, n int) bool {
+	if !b.limited.Load() {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if b.tokens-float64(n) < -b.burst {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
+
+// refund returns n tokens taken by take or borrow.
+func (b *Bucket) refund(n int) {
+	if !b.limited.Load() {
+		return
+	}
+	b.mu.Lock()
+	b.tokens = min(b.tokens+float64(n), b.burst)
+	b.mu.Unlock()
+}
+
+// Limiter is the chain of buckets one direction of a flow is charged
+// against. A nil Limiter limits nothing.
+type Limiter []*Bucket
+
+// Wait charges n bytes and blocks until every bucket allows them, or ctx
+// is done.
+func (l Limiter) Wait(ctx context.Context, n int) error {
+	if len(l) == 0 {
+		return nil
+	}
+	now := time.Now()
+	var wait time.Duration
+	for _, b := range l {
+		wait = max(wait, b.reserve(now, n))
+	}
+	if wait <= 0 {
+		return nil
+	}
+	t := time.NewTimer(wait)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Allow charges n bytes if every bucket has room and reports whether the
+// packet may be sent. Nothing is charged when it returns false.
+func (l Limiter) Allow(n int) bool {
+	now := time.Now()
+	for i, b := range l {
+		if !b.take(now, n) {
+			for _, prev := range l[:i] {
+				prev.refund(n)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// AllowPriority is Allow for traffic that must not be delayed: it charges
+// n bytes even when the buckets are empty, and only refuses once a bucket
+// is a full burst in debt. Nothing is charged when it returns false.
+func (l Limiter) AllowPriority(n int) bool {
+	now := time.Now()
+	for i, b := range l {
+		if !b.borrow(now, n) {
+			for _, prev := range l[:i] {
+				prev.refund(n)
+			}
+			return false
+		}
+	}
+	return true
+}
+
+// Limited reports whether any bucket currently has a rate. Copy loops that
+// bypass Wait (kernel splice on Linux) check it before each chunk.
+func (l Limiter) Limited() bool {
+	for _, b := range l {
+		if b.limited.Load() {
+			return true
+		}
+	}
+	return false
+}
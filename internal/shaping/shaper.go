@@ -0,0 +1,135 @@
+package shaping
+
+import (
+	"strings"
+	"sync"
+
+	"awg-split-tunnel/internal/core"
+)
+
+// Flow holds the limiters of one flow: Up for client → server traffic,
+// Down for server → client traffic.
+type Flow struct {
+	Up   Limiter
+	Down Limiter
+}
+
+// pair is the upload and download bucket of one limit.
+type pair struct {
+	up, down Bucket
+}
+
+func (p *pair) set(l *core.RateLimit) {
+	if l == nil {
+		l = &core.RateLimit{}
+	}
+	p.up.set(l.UpKbps*1000, l.UpBurstKB*1024)
+	p.down.set(l.DownKbps*1000, l.DownBurstKB*1024)
+}
+
+// Shaper owns the buckets of every configured limit. It is safe for
+// concurrent use.
+//
+// Buckets are created on first use and never removed: a limit that is
+// removed from the config becomes unlimited, and one added later applies
+// to flows that are already running.
+type Shaper struct {
+	mu      sync.Mutex
+	global  pair
+	tunnels map[string]*pair // by tunnel ID
+	rules   map[string]*pair // by lowercased rule pattern
+}
+
+// New creates a Shaper without limits.
+func New() *Shaper {
+	return &Shaper{
+		tunnels: make(map[string]*pair),
+		rules:   make(map[string]*pair),
+	}
+}
+
+// Configure applies the rate limits of cfg. Running flows pick up the new
+// rates immediately.
+func (s *Shaper) Configure(cfg core.Config) {
+	tunnels := make(map[string]*core.RateLimit)
+	for _, t := range cfg.Tunnels {
+		if !t.RateLimit.IsZero() {
+			tunnels[t.ID] = t.RateLimit
+		}
+	}
+	rules := make(map[string]*core.RateLimit)
+	for _, r := range cfg.Rules {
+		key := strings.ToLower(r.Pattern)
+		if _, dup := rules[key]; !dup && r.IsEnabled() && !r.RateLimit.IsZero() {
+			rules[key] = r.RateLimit
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.global.set(cfg.Global.RateLimit)
+	apply(s.tunnels, tunnels)
+	apply(s.rules, rules)
+
+	n := len(tunnels) + len(rules)
+	if !cfg.Global.RateLimit.IsZero() {
+		n++
+	}
+	if n > 0 {
+		core.Log.Infof("Shaping", "%d rate limits active", n)
+	}
+}
+
+// apply sets every bucket in buckets to its limit in limits, creating the
+// missing ones and lifting the limits that are gone.
+func apply(buckets map[string]*pair, limits map[string]*core.RateLimit) {
+	for key, p := range buckets {
+		if _, ok := limits[key]; !ok {
+			p.set(nil)
+		}
+	}
+	for key, l := range limits {
+		p, ok := buckets[key]
+		if !ok {
+			p = &pair{}
+			buckets[key] = p
+		}
+		p.set(l)
+	}
+}
+
+// Flow returns the limiters for a flow through tunnelID matched by the
+// process rule with pattern rule ("" if none).
+func (s *Shaper) Flow(tunnelID, rule string) Flow {
+	if s == nil {
+		return Flow{}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f := Flow{
+		Up:   Limiter{&s.global.up},
+		Down: Limiter{&s.global.down},
+	}
+	if tunnelID != "" {
+		p := s.tunnels[tunnelID]
+		if p == nil {
+			p = &pair{}
+			s.tunnels[tunnelID] = p
+		}
+		f.Up = append(f.Up, &p.up)
+		f.Down = append(f.Down, &p.down)
+	}
+	if rule != "" {
+		key := strings.ToLower(rule)
+		p := s.rules[key]
+		if p == nil {
+			p = &pair{}
+			s.rules[key] = p
+		}
+		f.Up = append(f.Up, &p.up)
+		f.Down = append(f.Down, &p.down)
+	}
+	return f
+}
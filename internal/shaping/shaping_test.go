@@ -0,0 +1,148 @@
+package shaping
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+)
+
+func TestAllowRespectsBurstAndRefunds(t *testing.T) {
+	var a, b Bucket
+	a.set(8_000, 10_000) // 1000 B/s, 10 KB burst
+	b.set(8_000, 4_000)  // 1000 B/s, 4 KB burst
+	lim := Limiter{&a, &b}
+
+	if !lim.Allow(3000) {
+		t.Fatal("first packet within burst dropped")
+	}
+	// b has 1000 tokens left: the packet is dropped and a gets its
+	// tokens back.
+	if lim.Allow(3000) {
+		t.Fatal("packet over the burst of b allowed")
+	}
+	if got := a.tokens; got < 6999 || got > 7100 {
+		t.Errorf("a.tokens = %.0f after refund, want ~7000", got)
+	}
+}
+
+func TestAllowOversizedPacketWhenFull(t *testing.T) {
+	var b Bucket
+	b.set(8_000, 1_000)
+	lim := Limiter{&b}
+	if !lim.Allow(1500) {
+		t.Fatal("packet larger than the burst dropped on a full bucket")
+	}
+	if lim.Allow(1500) {
+		t.Fatal("packet allowed while the bucket is in debt")
+	}
+}
+
+func TestAllowPriorityBorrowsOneBurst(t *testing.T) {
+	var b Bucket
+	b.set(8_000, 2_000)
+	lim := Limiter{&b}
+	if !lim.AllowPriority(3500) {
+		t.Fatal("priority packet dropped within the debt limit")
+	}
+	if b.tokens > -1400 {
+		t.Errorf("tokens = %.0f, want debt after AllowPriority", b.tokens)
+	}
+	if lim.Allow(100) {
+		t.Error("bulk packet allowed while in debt")
+	}
+	if lim.AllowPriority(1000) {
+		t.Error("priority packet allowed past one burst of debt")
+	}
+}
+
+func TestAllowPriorityThrottlesFlood(t *testing.T) {
+	var b Bucket
+	b.set(80_000, 2_000) // 10 KB/s
+	lim := Limiter{&b}
+
+	// 1000 small packets at once: the burst plus one burst of debt
+	// pass, the rest are dropped.
+	passed := 0
+	for i := 0; i < 1000; i++ {
+		if lim.AllowPriority(100) {
+			passed++
+		}
+	}
+	if passed < 40 || passed > 45 {
+		t.Errorf("%d of 1000 priority packets passed, want ~40", passed)
+	}
+}
+
+func TestWaitThrottles(t *testing.T) {
+	var b Bucket
+	b.set(800_000, 10_000) // 100 KB/s
+	lim := Limiter{&b}
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := lim.Wait(context.Background(), 10_000); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first 10 KB is the burst; the other 20 KB take ~200 ms.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("30 KB at 100 KB/s took %v", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := lim.Wait(ctx, 100_000); err == nil {
+		t.Error("Wait ignored a cancelled context")
+	}
+}
+
+func TestConfigureUpdatesRunningFlows(t *testing.T) {
+	s := New()
+	cfg := core.Config{
+		Tunnels: []core.TunnelConfig{{ID: "awg", RateLimit: &core.RateLimit{UpKbps: 8, UpBurstKB: 1}}},
+		Rules:   []core.Rule{{Pattern: "Updater.exe", RateLimit: &core.RateLimit{DownKbps: 8, DownBurstKB: 1}}},
+	}
+	s.Configure(cfg)
+
+	f := s.Flow("awg", "updater.exe")
+	if len(f.Up) != 3 || len(f.Down) != 3 {
+		t.Fatalf("flow has %d/%d buckets, want global, tunnel and rule", len(f.Up), len(f.Down))
+	}
+	if !f.Up.Allow(1024) || f.Up.Allow(1024) {
+		t.Error("tunnel upload limit not applied")
+	}
+	if !f.Down.Allow(1024) || f.Down.Allow(1024) {
+		t.Error("rule download limit not applied")
+	}
+
+	// Removing the limits lifts them for flows that are already running.
+	s.Configure(core.Config{})
+	for i := 0; i < 10; i++ {
+		if !f.Up.Allow(1024) || !f.Down.Allow(1024) {
+			t.Fatal("removed limit still applied")
+		}
+	}
+
+	// A limit added later applies to the same flow.
+	cfg.Tunnels[0].RateLimit = &core.RateLimit{UpKbps: 8, UpBurstKB: 1}
+	s.Configure(cfg)
+	if !f.Up.Allow(1024) || f.Up.Allow(1024) {
+		t.Error("new limit not applied to running flow")
+	}
+}
+
+func TestNilShaper(t *testing.T) {
+	var s *Shaper
+	f := s.Flow("awg", "rule")
+	if f.Up != nil || f.Down != nil {
+		t.Fatal("nil shaper returned limiters")
+	}
+	if !f.Up.Allow(1 << 20) {
+		t.Error("empty limiter dropped a packet")
+	}
+	if err := f.Down.Wait(context.Background(), 1<<20); err != nil {
+		t.Error(err)
+	}
+}
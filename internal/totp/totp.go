@@ -0,0 +1,122 @@
+// Package totp generates RFC 6238 time-based one-time passwords from a
+// stored seed, so MFA tunnels can (re)connect without the user typing a code.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key is a TOTP seed with its generation parameters.
+type Key struct {
+	Secret    []byte
+	Digits    int           // 6..8
+	Period    time.Duration // time step, usually 30s
+	Algorithm string        // SHA1, SHA256 or SHA512
+}
+
+// Parse accepts either a bare base32 secret (as shown under the QR code,
+// spaces and lower case allowed) or an otpauth://totp/ URI.
+func Parse(s string) (*Key, error) {
+	s = strings.TrimSpace(s)
+	k := &Key{Digits: 6, Period: 30 * time.Second, Algorithm: "SHA1"}
+
+	secret := s
+	if strings.HasPrefix(strings.ToLower(s), "otpauth://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, fmt.Errorf("totp: invalid URI: %w", err)
+		}
+		if !strings.EqualFold(u.Host, "totp") {
+			return nil, fmt.Errorf("totp: unsupported OTP type %q", u.Host)
+		}
+		q := u.Query()
+		secret = q.Get("secret")
+		if v := q.Get("digits"); v != "" {
+			d, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("totp: invalid digits %q", v)
+			}
+			k.Digits = d
+		}
+		if v := q.Get("period"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil || p <= 0 {
+				return nil, fmt.Errorf("totp: invalid period %q", v)
+			}
+			k.Period = time.Duration(p) * time.Second
+		}
+		if v := q.Get("algorithm"); v != "" {
+			k.Algorithm = strings.ToUpper(v)
+		}
+	}
+
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	secret = strings.TrimRight(secret, "=")
+	raw, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return nil, fmt.Errorf("totp: secret is not valid base32")
+	}
+	if len(raw) < 10 {
+		return nil, fmt.Errorf("totp: secret too short (%d bytes)", len(raw))
+	}
+	k.Secret = raw
+
+	if k.Digits < 6 || k.Digits > 8 {
+		return nil, fmt.Errorf("totp: unsupported digits %d", k.Digits)
+	}
+	if k.hash() == nil {
+		return nil, fmt.Errorf("totp: unsupported algorithm %q", k.Algorithm)
+	}
+	return k, nil
+}
+
+func (k *Key) hash() func() hash.Hash {
+	switch k.Algorithm {
+	case "SHA1":
+		return sha1.New
+	case "SHA256":
+		return sha256.New
+	case "SHA512":
+		return sha512.New
+	}
+	return nil
+}
+
+// Step returns the time-step counter for t.
+func (k *Key) Step(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(k.Period/time.Second)
+}
+
+// Remaining returns how long the code for t stays valid.
+func (k *Key) Remaining(t time.Time) time.Duration {
+	period := int64(k.Period / time.Second)
+	return time.Duration(period-t.Unix()%period) * time.Second
+}
+
+// Code returns the one-time password for time-step counter step (RFC 4226 §5.3).
+func (k *Key) Code(step uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], step)
+	mac := hmac.New(k.hash(), k.Secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	mod := uint32(1)
+	for i := 0; i < k.Digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", k.Digits, bin%mod)
+}
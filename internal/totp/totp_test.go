@@ -0,0 +1,62 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// RFC 6238 Appendix B test vectors (8 digits).
+func TestRFC6238Vectors(t *testing.T) {
+	seeds := map[string]string{
+		"SHA1":   "12345678901234567890",
+		"SHA256": "12345678901234567890123456789012",
+		"SHA512": "1234567890123456789012345678901234567890123456789012345678901234",
+	}
+	vectors := []struct {
+		unix int64
+		algo string
+		code string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1234567890, "SHA256", "91819424"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+	}
+	for _, v := range vectors {
+		uri := "otpauth://totp/test?digits=8&algorithm=" + v.algo +
+			"&secret=" + base32.StdEncoding.EncodeToString([]byte(seeds[v.algo]))
+		k, err := Parse(uri)
+		if err != nil {
+			t.Fatalf("parse %s: %v", v.algo, err)
+		}
+		if got := k.Code(k.Step(time.Unix(v.unix, 0))); got != v.code {
+			t.Errorf("%s @%d = %s, want %s", v.algo, v.unix, got, v.code)
+		}
+	}
+}
+
+func TestParseBareSecret(t *testing.T) {
+	k, err := Parse("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if k.Digits != 6 || k.Period != 30*time.Second || string(k.Secret) != "12345678901234567890" {
+		t.Errorf("unexpected key: %+v", k)
+	}
+	if got := k.Code(k.Step(time.Unix(59, 0))); got != "287082" {
+		t.Errorf("code = %s, want 287082", got)
+	}
+	if k.Remaining(time.Unix(59, 0)) != time.Second {
+		t.Errorf("remaining = %s", k.Remaining(time.Unix(59, 0)))
+	}
+
+	for _, bad := range []string{"", "not base32!", "GEZDG", "otpauth://hotp/x?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,312 @@
+package wgserver
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider/wgconf"
+)
+
+// Defaults applied to an empty WGServerConfig.
+const (
+	DefaultListenPort = 51820
+	DefaultAddress    = "10.66.0.1/24"
+	defaultMTU        = 1420
+)
+
+// settings is a validated WGServerConfig with defaults applied and every
+// peer's tunnel address resolved.
+type settings struct {
+	listenPort  int
+	privateKey  string       // base64
+	publicKey   string       // base64
+	prefix      netip.Prefix // server IP with the peer subnet
+	mtu         int
+	obfuscation []wgconf.KeyValue // canonical .conf keys, sorted
+	peers       []peer
+}
+
+// peer is an enabled wg_server peer with its resolved tunnel address.
+type peer struct {
+	name         string
+	publicKey    string // base64
+	presharedKey string // base64, "" if none
+	addr         netip.Addr
+	allowLAN     bool
+}
+
+// parseSettings validates cfg and applies defaults. Peers without an
+// address get the lowest free host address of the subnet, in config order.
+func parseSettings(cfg core.WGServerConfig) (*settings, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	s := &settings{
+		listenPort: cfg.ListenPort,
+		privateKey: cfg.PrivateKey,
+		mtu:        cfg.MTU,
+	}
+	if s.listenPort == 0 {
+		s.listenPort = DefaultListenPort
+	}
+	if s.mtu == 0 {
+		s.mtu = defaultMTU
+	}
+	if s.mtu < 576 || s.mtu > 1500 {
+		return nil, fmt.Errorf("wg_server.mtu: %d out of range 576-1500", s.mtu)
+	}
+
+	var err error
+	if s.prefix, err = serverPrefix(cfg); err != nil {
+		return nil, err
+	}
+	if s.publicKey, err = wgconf.PublicKey(s.privateKey); err != nil {
+		return nil, fmt.Errorf("wg_server.private_key: %w", err)
+	}
+
+	for key, value := range cfg.Obfuscation {
+		canonical, ok := wgconf.AmneziaKey(key)
+		if !ok {
+			return nil, fmt.Errorf("wg_server.obfuscation: unknown parameter %q", key)
+		}
+		s.obfuscation = append(s.obfuscation, wgconf.KeyValue{Key: canonical, Value: value})
+	}
+	sort.Slice(s.obfuscation, func(i, j int) bool { return s.obfuscation[i].Key < s.obfuscation[j].Key })
+
+	addrs, err := peerAddresses(cfg, s.prefix)
+	if err != nil {
+		return nil, err
+	}
+	for i, p := range cfg.Peers {
+		if _, err := base64ToHex(p.PublicKey); err != nil {
+			return nil, fmt.Errorf("wg_server peer %q: public_key: %w", p.Name, err)
+		}
+		if p.PresharedKey != "" {
+			if _, err := base64ToHex(p.PresharedKey); err != nil {
+				return nil, fmt.Errorf("wg_server peer %q: preshared_key: %w", p.Name, err)
+			}
+		}
+		if !p.IsEnabled() {
+			continue
+		}
+		s.peers = append(s.peers, peer{
+			name:         p.Name,
+			publicKey:    p.PublicKey,
+			presharedKey: p.PresharedKey,
+			addr:         addrs[i],
+			allowLAN:     cfg.PeerAllowsLAN(p),
+		})
+	}
+	return s, nil
+}
+
+// serverPrefix returns the server's tunnel address with the peer subnet.
+func serverPrefix(cfg core.WGServerConfig) (netip.Prefix, error) {
+	addr := cfg.Address
+	if addr == "" {
+		addr = DefaultAddress
+	}
+	pfx, err := netip.ParsePrefix(addr)
+	if err != nil || !pfx.Addr().Is4() {
+		return netip.Prefix{}, fmt.Errorf("wg_server.address: invalid IPv4 prefix %q", addr)
+	}
+	if pfx.Bits() > 30 {
+		return netip.Prefix{}, fmt.Errorf("wg_server.address: subnet %s has no room for peers", pfx)
+	}
+	return pfx, nil
+}
+
+// peerAddresses returns the tunnel address of every peer in cfg, parallel
+// to cfg.Peers. Configured addresses are kept; the rest are allocated.
+func peerAddresses(cfg core.WGServerConfig, pfx netip.Prefix) ([]netip.Addr, error) {
+	used := map[netip.Addr]bool{pfx.Addr(): true}
+	addrs := make([]netip.Addr, len(cfg.Peers))
+	for i, p := range cfg.Peers {
+		if p.Address != "" {
+			addrs[i] = netip.MustParseAddr(p.Address) // checked by Validate
+			used[addrs[i]] = true
+		}
+	}
+	for i := range addrs {
+		if addrs[i].IsValid() {
+			continue
+		}
+		ip, err := freeAddress(pfx, used)
+		if err != nil {
+			return nil, fmt.Errorf("wg_server peer %q: %w", cfg.Peers[i].Name, err)
+		}
+		addrs[i] = ip
+		used[ip] = true
+	}
+	return addrs, nil
+}
+
+// freeAddress returns the lowest host address in pfx not in used,
+// skipping the network and broadcast addresses.
+func freeAddress(pfx netip.Prefix, used map[netip.Addr]bool) (netip.Addr, error) {
+	network := pfx.Masked().Addr()
+	for ip := network.Next(); pfx.Contains(ip); ip = ip.Next() {
+		if !pfx.Contains(ip.Next()) {
+			break // broadcast
+		}
+		if !used[ip] {
+			return ip, nil
+		}
+	}
+	return netip.Addr{}, fmt.Errorf("no free address left in %s", pfx.Masked())
+}
+
+// NewPeer creates a peer named name with a fresh key pair, a preshared key
+// and the next free address of the server subnet. The private key is kept
+// so the client config can be exported later.
+func NewPeer(cfg core.WGServerConfig, name string) (core.WGServerPeer, error) {
+	priv, pub, err := wgconf.GenerateKeyPair()
+	if err != nil {
+		return core.WGServerPeer{}, err
+	}
+	psk, _, err := wgconf.GenerateKeyPair()
+	if err != nil {
+		return core.WGServerPeer{}, err
+	}
+	p := core.WGServerPeer{Name: name, PublicKey: pub, PresharedKey: psk, PrivateKey: priv}
+	if p.Address, err = AllocateAddress(cfg); err != nil {
+		return core.WGServerPeer{}, err
+	}
+	return p, nil
+}
+
+// AllocateAddress returns the next free peer address of the server subnet.
+func AllocateAddress(cfg core.WGServerConfig) (string, error) {
+	pfx, err := serverPrefix(cfg)
+	if err != nil {
+		return "", err
+	}
+	addrs, err := peerAddresses(cfg, pfx)
+	if err != nil {
+		return "", err
+	}
+	used := map[netip.Addr]bool{pfx.Addr(): true}
+	for _, a := range addrs {
+		used[a] = true
+	}
+	ip, err := freeAddress(pfx, used)
+	if err != nil {
+		return "", err
+	}
+	return ip.String(), nil
+}
+
+// ClientConfig renders the .conf a device imports to connect as peer name.
+// All IPv4 traffic is sent through the server, and DNS goes to the server
+// address so domain rules apply to the device.
+func ClientConfig(cfg core.WGServerConfig, name string) (string, error) {
+	s, err := parseSettings(cfg)
+	if err != nil {
+		return "", err
+	}
+	if cfg.Endpoint == "" {
+		return "", fmt.Errorf("wg_server.endpoint is not set")
+	}
+	idx := -1
+	for i, p := range cfg.Peers {
+		if strings.EqualFold(p.Name, name) {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return "", fmt.Errorf("wg_server peer %q not found", name)
+	}
+	p := cfg.Peers[idx]
+	if p.PrivateKey == "" {
+		return "", fmt.Errorf("wg_server peer %q: private key is not stored, add it to the client manually", p.Name)
+	}
+	addrs, err := peerAddresses(cfg, s.prefix)
+	if err != nil {
+		return "", err
+	}
+
+	var wc wgconf.Config
+	wc.Interface.Set("PrivateKey", p.PrivateKey)
+	wc.Interface.Set("Address", netip.PrefixFrom(addrs[idx], 32).String())
+	wc.Interface.Set("DNS", s.prefix.Addr().String())
+	wc.Interface.Set("MTU", strconv.Itoa(s.mtu))
+	wc.Interface = append(wc.Interface, s.obfuscation...)
+
+	var sp wgconf.Section
+	sp.Set("PublicKey", s.publicKey)
+	sp.Set("PresharedKey", p.PresharedKey)
+	sp.Set("Endpoint", cfg.Endpoint)
+	sp.Set("AllowedIPs", "0.0.0.0/0")
+	sp.Set("PersistentKeepalive", "25")
+	wc.Peers = []wgconf.Section{sp}
+	return wc.String(), nil
+}
+
+// ---------------------------------------------------------------------------
+// UAPI
+// ---------------------------------------------------------------------------
+
+// interfaceUAPI renders the device-level UAPI configuration.
+func (s *settings) interfaceUAPI() string {
+	var b strings.Builder
+	privHex, _ := base64ToHex(s.privateKey) // checked by parseSettings
+	fmt.Fprintf(&b, "private_key=%s\n", privHex)
+	fmt.Fprintf(&b, "listen_port=%d\n", s.listenPort)
+	for _, kv := range s.obfuscation {
+		fmt.Fprintf(&b, "%s=%s\n", strings.ToLower(kv.Key), kv.Value)
+	}
+	b.WriteString("replace_peers=true\n")
+	return b.String()
+}
+
+// peersUAPI renders a UAPI update that moves the device from the old peer
+// list to the new one without touching unchanged peers' sessions.
+func peersUAPI(old, cur []peer) string {
+	var b strings.Builder
+	keep := make(map[string]peer, len(cur))
+	for _, p := range cur {
+		keep[p.publicKey] = p
+	}
+	prev := make(map[string]peer, len(old))
+	for _, p := range old {
+		prev[p.publicKey] = p
+		if _, ok := keep[p.publicKey]; !ok {
+			pubHex, _ := base64ToHex(p.publicKey)
+			fmt.Fprintf(&b, "public_key=%s\nremove=true\n", pubHex)
+		}
+	}
+	for _, p := range cur {
+		if o, ok := prev[p.publicKey]; ok && o == p {
+			continue
+		}
+		pubHex, _ := base64ToHex(p.publicKey)
+		fmt.Fprintf(&b, "public_key=%s\n", pubHex)
+		pskHex := strings.Repeat("0", 64) // all-zero key clears the PSK
+		if p.presharedKey != "" {
+			pskHex, _ = base64ToHex(p.presharedKey)
+		}
+		fmt.Fprintf(&b, "preshared_key=%s\n", pskHex)
+		b.WriteString("replace_allowed_ips=true\n")
+		fmt.Fprintf(&b, "allowed_ip=%s\n", netip.PrefixFrom(p.addr, 32))
+	}
+	return b.String()
+}
+
+// base64ToHex converts a base64 WireGuard key to the hex form UAPI expects.
+func base64ToHex(b64 string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(b64)
+	if err != nil {
+		return "", fmt.Errorf("invalid base64: %w", err)
+	}
+	if len(raw) != 32 {
+		return "", fmt.Errorf("key must be 32 bytes, got %d", len(raw))
+	}
+	return hex.EncodeToString(raw), nil
+}
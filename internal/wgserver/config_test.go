@@ -0,0 +1,180 @@
+package wgserver
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/provider/wgconf"
+)
+
+// Fake keys (valid base64-encoded 32-byte values for testing only).
+const (
+	testServerKey = "YWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWFhYWE="
+	testPeerKeyA  = "YmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmJiYmI="
+	testPeerKeyB  = "Y2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2NjY2M="
+	testPeerKeyC  = "ZGRkZGRkZGRkZGRkZGRkZGRkZGRkZGRkZGRkZGRkZGQ="
+)
+
+func testConfig() core.WGServerConfig {
+	off := false
+	return core.WGServerConfig{
+		Enabled:    true,
+		PrivateKey: testServerKey,
+		Endpoint:   "vpn.example.com:51820",
+		Peers: []core.WGServerPeer{
+			{Name: "phone", PublicKey: testPeerKeyA},
+			{Name: "laptop", PublicKey: testPeerKeyB, Address: "10.66.0.2"},
+			{Name: "tablet", PublicKey: testPeerKeyC, Enabled: &off},
+		},
+	}
+}
+
+func TestParseSettingsAllocatesAddresses(t *testing.T) {
+	st, err := parseSettings(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.listenPort != DefaultListenPort || st.mtu != defaultMTU {
+		t.Errorf("defaults not applied: port=%d mtu=%d", st.listenPort, st.mtu)
+	}
+	if st.prefix.String() != DefaultAddress {
+		t.Errorf("prefix = %s, want %s", st.prefix, DefaultAddress)
+	}
+	// The disabled peer is skipped; "phone" takes the first address not
+	// claimed by the server or by "laptop".
+	if len(st.peers) != 2 {
+		t.Fatalf("got %d peers, want 2", len(st.peers))
+	}
+	want := map[string]string{"phone": "10.66.0.3", "laptop": "10.66.0.2"}
+	for _, p := range st.peers {
+		if p.addr.String() != want[p.name] {
+			t.Errorf("peer %s: addr = %s, want %s", p.name, p.addr, want[p.name])
+		}
+	}
+}
+
+func TestParseSettingsAllowLAN(t *testing.T) {
+	cfg := testConfig()
+	on := true
+	cfg.Peers[1].AllowLAN = &on
+	st, err := parseSettings(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range st.peers {
+		if want := p.name == "laptop"; p.allowLAN != want {
+			t.Errorf("peer %s: allowLAN = %v, want %v", p.name, p.allowLAN, want)
+		}
+	}
+
+	off := false
+	cfg.AllowLAN = true
+	cfg.Peers[1].AllowLAN = &off
+	if st, err = parseSettings(cfg); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range st.peers {
+		if want := p.name == "phone"; p.allowLAN != want {
+			t.Errorf("server allow_lan: peer %s: allowLAN = %v, want %v", p.name, p.allowLAN, want)
+		}
+	}
+}
+
+func TestParseSettingsRejectsInvalid(t *testing.T) {
+	cfg := testConfig()
+	cfg.Obfuscation = map[string]string{"Bogus": "1"}
+	if _, err := parseSettings(cfg); err == nil {
+		t.Error("unknown obfuscation parameter accepted")
+	}
+
+	cfg = testConfig()
+	cfg.Peers[1].PublicKey = testPeerKeyA
+	if _, err := parseSettings(cfg); err == nil {
+		t.Error("duplicate public key accepted")
+	}
+
+	cfg = testConfig()
+	cfg.Address = "10.66.0.1/31"
+	if _, err := parseSettings(cfg); err == nil {
+		t.Error("subnet without room for peers accepted")
+	}
+}
+
+func TestPeersUAPIDiff(t *testing.T) {
+	a := peer{name: "a", publicKey: testPeerKeyA, addr: netip.MustParseAddr("10.66.0.2")}
+	b := peer{name: "b", publicKey: testPeerKeyB, addr: netip.MustParseAddr("10.66.0.3")}
+	c := peer{name: "c", publicKey: testPeerKeyC, addr: netip.MustParseAddr("10.66.0.4")}
+	hexA, _ := base64ToHex(testPeerKeyA)
+	hexB, _ := base64ToHex(testPeerKeyB)
+	hexC, _ := base64ToHex(testPeerKeyC)
+
+	moved := b
+	moved.addr = netip.MustParseAddr("10.66.0.9")
+	uapi := peersUAPI([]peer{a, b}, []peer{moved, c})
+
+	if !strings.Contains(uapi, "public_key="+hexA+"\nremove=true\n") {
+		t.Errorf("removed peer not removed:\n%s", uapi)
+	}
+	if !strings.Contains(uapi, "public_key="+hexB+"\n") || !strings.Contains(uapi, "allowed_ip=10.66.0.9/32\n") {
+		t.Errorf("changed peer not updated:\n%s", uapi)
+	}
+	if !strings.Contains(uapi, "public_key="+hexC+"\n") || !strings.Contains(uapi, "allowed_ip=10.66.0.4/32\n") {
+		t.Errorf("added peer missing:\n%s", uapi)
+	}
+
+	if uapi := peersUAPI([]peer{a, b}, []peer{a, b}); uapi != "" {
+		t.Errorf("unchanged peers produced an update:\n%s", uapi)
+	}
+}
+
+func TestNewPeerAndClientConfig(t *testing.T) {
+	cfg := testConfig()
+	cfg.Obfuscation = map[string]string{"jc": "4"}
+	p, err := NewPeer(cfg, "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10.66.0.2 is taken by "laptop", 10.66.0.3 is allocated to "phone"
+	// and 10.66.0.4 to the disabled "tablet".
+	if p.Address != "10.66.0.5" {
+		t.Errorf("address = %s, want 10.66.0.5", p.Address)
+	}
+	if p.PrivateKey == "" || p.PresharedKey == "" {
+		t.Fatal("keys not generated")
+	}
+	cfg.Peers = append(cfg.Peers, p)
+
+	conf, err := ClientConfig(cfg, "Watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc, err := wgconf.Parse(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverPub, _ := wgconf.PublicKey(testServerKey)
+	checks := []struct {
+		sec       wgconf.Section
+		key, want string
+	}{
+		{wc.Interface, "PrivateKey", p.PrivateKey},
+		{wc.Interface, "Address", "10.66.0.5/32"},
+		{wc.Interface, "DNS", "10.66.0.1"},
+		{wc.Interface, "Jc", "4"},
+		{wc.Peers[0], "PublicKey", serverPub},
+		{wc.Peers[0], "PresharedKey", p.PresharedKey},
+		{wc.Peers[0], "Endpoint", "vpn.example.com:51820"},
+		{wc.Peers[0], "AllowedIPs", "0.0.0.0/0"},
+	}
+	for _, c := range checks {
+		if got := c.sec.Get(c.key); got != c.want {
+			t.Errorf("%s = %q, want %q", c.key, got, c.want)
+		}
+	}
+
+	if _, err := ClientConfig(cfg, "phone"); err == nil {
+		t.Error("client config exported for a peer without a stored private key")
+	}
+}
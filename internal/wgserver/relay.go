@@ -0,0 +1,243 @@
+package wgserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/adapters/gonet"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	dialTimeout    = 10 * time.Second
+	tcpIdleTimeout = 5 * time.Minute
+	udpIdleTimeout = 2 * time.Minute
+	dnsIdleTimeout = 10 * time.Second
+	dnsTimeout     = 3 * time.Second
+	relayBufSize   = 64 * 1024
+)
+
+var relayBufPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, relayBufSize)
+		return &b
+	},
+}
+
+// flowID extracts the peer source address and the destination of a flow.
+// In forwarder requests "local" is the destination the peer connected to.
+func flowID(local, remote tcpip.Address, localPort uint16) (src netip.Addr, dst netip.AddrPort) {
+	src = netip.AddrFrom4(remote.As4())
+	dst = netip.AddrPortFrom(netip.AddrFrom4(local.As4()), localPort)
+	return src, dst
+}
+
+// handleTCP dials the destination before completing the peer's handshake,
+// so an unreachable or dropped destination is refused with a RST.
+func (s *Server) handleTCP(r *tcp.ForwarderRequest) {
+	id := r.ID()
+	src, dst := flowID(id.LocalAddress, id.RemoteAddress, id.LocalPort)
+	p, ok := s.peerFor(src)
+	if !ok || s.isServerAddr(dst.Addr()) {
+		r.Complete(true)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	upstream, tunnelID, err := s.dial(ctx, p, dst, false)
+	cancel()
+	if err != nil {
+		core.Log.Debugf("WGServer", "TCP %s → %s (peer %s): %v", src, dst, p.name, err)
+		r.Complete(true)
+		return
+	}
+
+	var wq waiter.Queue
+	ep, tErr := r.CreateEndpoint(&wq)
+	if tErr != nil {
+		upstream.Close()
+		r.Complete(true)
+		return
+	}
+	r.Complete(false)
+	ep.SocketOptions().SetKeepAlive(true)
+
+	s.relay(gonet.NewTCPConn(&wq, ep), upstream, tunnelID, tcpIdleTimeout)
+}
+
+// handleUDP runs in the stack's receive path, so it only creates the
+// endpoint and leaves dialing to a goroutine. Later datagrams of the same
+// flow go to that endpoint directly.
+func (s *Server) handleUDP(r *udp.ForwarderRequest) bool {
+	id := r.ID()
+	src, dst := flowID(id.LocalAddress, id.RemoteAddress, id.LocalPort)
+	p, ok := s.peerFor(src)
+	if !ok {
+		return false
+	}
+	isDNS := dst.Port() == 53
+	if !isDNS && s.isServerAddr(dst.Addr()) {
+		return false
+	}
+
+	var wq waiter.Queue
+	ep, err := r.CreateEndpoint(&wq)
+	if err != nil {
+		return false
+	}
+	client := gonet.NewUDPConn(&wq, ep)
+
+	// DNS from peers is answered by the local resolver whatever server
+	// they query, as the TUN router does for local apps.
+	if isDNS {
+		core.SafeGo("wgserver.dns", func() { s.serveDNS(client, p) })
+		return true
+	}
+
+	core.SafeGo("wgserver.udp", func() {
+		ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+		upstream, tunnelID, err := s.dial(ctx, p, dst, true)
+		cancel()
+		if err != nil {
+			core.Log.Debugf("WGServer", "UDP %s → %s (peer %s): %v", src, dst, p.name, err)
+			client.Close()
+			return
+		}
+		s.relay(client, upstream, tunnelID, udpIdleTimeout)
+	})
+	return true
+}
+
+// isServerAddr reports whether ip is the server's own tunnel address.
+func (s *Server) isServerAddr(ip netip.Addr) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings != nil && s.settings.prefix.Addr() == ip
+}
+
+// dial routes a peer flow and connects to its destination through the
+// chosen tunnel. Returns the connection and the tunnel ID.
+func (s *Server) dial(ctx context.Context, p peer, dst netip.AddrPort, isUDP bool) (net.Conn, string, error) {
+	route, ok := s.opts.Router.RoutePeer(p.name, p.publicKey, dst.Addr(), isUDP, p.allowLAN)
+	if !ok {
+		return nil, "", errDropped
+	}
+	prov, ok := s.opts.Providers(route.TunnelID)
+	if !ok {
+		return nil, "", fmt.Errorf("tunnel %q is not available", route.TunnelID)
+	}
+	addr := netip.AddrPortFrom(route.Dst, dst.Port()).String()
+	var c net.Conn
+	var err error
+	if isUDP {
+		c, err = prov.DialUDP(ctx, addr)
+	} else {
+		c, err = prov.DialTCP(ctx, addr)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("via %s: %w", route.TunnelID, err)
+	}
+	return c, route.TunnelID, nil
+}
+
+// serveDNS answers the queries of one peer DNS flow until it goes idle.
+func (s *Server) serveDNS(c net.Conn, p peer) {
+	defer c.Close()
+	bp := relayBufPool.Get().(*[]byte)
+	defer relayBufPool.Put(bp)
+	buf := *bp
+
+	for {
+		c.SetReadDeadline(time.Now().Add(dnsIdleTimeout))
+		n, err := c.Read(buf)
+		if err != nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), dnsTimeout)
+		resp := s.opts.Router.ResolvePeerDNS(ctx, p.name, buf[:n])
+		cancel()
+		if resp != nil {
+			c.Write(resp)
+		}
+	}
+}
+
+// relay copies data both ways until both directions finish or the flow is
+// idle for longer than idle in both directions.
+func (s *Server) relay(client, upstream net.Conn, tunnelID string, idle time.Duration) {
+	defer client.Close()
+	defer upstream.Close()
+
+	var lastActive atomic.Int64
+	lastActive.Store(time.Now().UnixNano())
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go s.copyFlow(upstream, client, tunnelID, true, idle, &lastActive, &wg)
+	go s.copyFlow(client, upstream, tunnelID, false, idle, &lastActive, &wg)
+	wg.Wait()
+}
+
+// copyFlow copies src to dst. A read timeout only ends the flow if the other
+// direction was idle too, so one-way streams stay open. On EOF the write
+// side of dst is closed when supported (TCP half-close); any other error
+// closes both connections to stop the opposite direction.
+func (s *Server) copyFlow(dst, src net.Conn, tunnelID string, tx bool, idle time.Duration,
+	lastActive *atomic.Int64, wg *sync.WaitGroup) {
+	defer wg.Done()
+	bp := relayBufPool.Get().(*[]byte)
+	defer relayBufPool.Put(bp)
+	buf := *bp
+
+	for {
+		src.SetReadDeadline(time.Now().Add(idle))
+		n, err := src.Read(buf)
+		if n > 0 {
+			lastActive.Store(time.Now().UnixNano())
+			if _, werr := dst.Write(buf[:n]); werr != nil {
+				break
+			}
+			s.reportBytes(tunnelID, n, tx)
+		}
+		if err == nil {
+			continue
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() &&
+			time.Since(time.Unix(0, lastActive.Load())) < idle {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+				cw.CloseWrite()
+				return
+			}
+		}
+		break
+	}
+	dst.Close()
+	src.Close()
+}
+
+func (s *Server) reportBytes(tunnelID string, n int, tx bool) {
+	if s.opts.BytesReporter == nil {
+		return
+	}
+	if tx {
+		s.opts.BytesReporter(tunnelID, int64(n), 0)
+	} else {
+		s.opts.BytesReporter(tunnelID, 0, int64(n))
+	}
+}
@@ -0,0 +1,323 @@
+// Package wgserver runs the inbound WireGuard/AmneziaWG listener (wg_server).
+// Phones and laptops connect as peers; their traffic is terminated in a
+// userspace stack and routed by the TUN router's domain, GeoIP and "peer:"
+// rules through the configured tunnels, like traffic of local processes.
+package wgserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"awg-split-tunnel/internal/core"
+	"awg-split-tunnel/internal/gateway"
+	"awg-split-tunnel/internal/provider"
+	"awg-split-tunnel/internal/provider/wgconf"
+
+	"github.com/amnezia-vpn/amneziawg-go/conn"
+	"github.com/amnezia-vpn/amneziawg-go/device"
+	"github.com/amnezia-vpn/amneziawg-go/tun/netstack"
+)
+
+// endpointPollInterval is how often peer endpoints are checked for new
+// addresses. A peer's first handshake response may be lost until its
+// address is routed around the TUN adapter; the peer retries after 5s.
+const endpointPollInterval = 2 * time.Second
+
+// Router decides where peer flows go. Implemented by *gateway.TUNRouter.
+type Router interface {
+	RoutePeer(name, publicKey string, dst netip.Addr, isUDP, allowLAN bool) (gateway.PeerRoute, bool)
+	ResolvePeerDNS(ctx context.Context, peer string, query []byte) []byte
+}
+
+var _ Router = (*gateway.TUNRouter)(nil)
+
+// Options wires the server to the rest of the application.
+type Options struct {
+	Router Router
+	// Providers returns the provider of a connected tunnel, including
+	// gateway.DirectTunnelID.
+	Providers func(tunnelID string) (provider.TunnelProvider, bool)
+	// BytesReporter receives per-tunnel traffic of peer flows (optional).
+	BytesReporter func(tunnelID string, tx, rx int64)
+	// OnPeerEndpoint is called once for every new peer endpoint address so
+	// replies to it can be routed around the TUN adapter (optional).
+	OnPeerEndpoint func(ip netip.Addr)
+}
+
+// PeerStatus is the configuration and runtime state of one peer.
+type PeerStatus struct {
+	Name          string
+	PublicKey     string
+	Address       netip.Addr
+	Enabled       bool
+	HasConfig     bool // the private key is stored, so a client config can be exported
+	Endpoint      string
+	LastHandshake time.Time
+	RxBytes       uint64
+	TxBytes       uint64
+}
+
+// Server is the WireGuard/AmneziaWG listener. It is safe for concurrent use.
+type Server struct {
+	opts Options
+
+	mu        sync.Mutex
+	cfg       core.WGServerConfig
+	settings  *settings // nil while stopped
+	dev       *device.Device
+	tnet      *netstack.Net
+	stack     *netStack
+	cancel    context.CancelFunc
+	endpoints map[netip.Addr]bool // endpoint addresses passed to OnPeerEndpoint
+
+	// peers maps a peer's tunnel address to the peer, for flow attribution.
+	peers atomic.Pointer[map[netip.Addr]peer]
+}
+
+// New creates a stopped server; call Configure to start it.
+func New(opts Options) *Server {
+	return &Server{opts: opts, endpoints: make(map[netip.Addr]bool)}
+}
+
+// EnsureKey generates the server private key if cfg has none.
+// Returns true if cfg was changed and should be saved.
+func EnsureKey(cfg *core.WGServerConfig) (bool, error) {
+	if cfg.PrivateKey != "" {
+		return false, nil
+	}
+	priv, _, err := wgconf.GenerateKeyPair()
+	if err != nil {
+		return false, err
+	}
+	cfg.PrivateKey = priv
+	return true, nil
+}
+
+// Configure applies cfg. The listener is started, stopped or restarted as
+// needed; when only the peer list changed, peers are updated in place and
+// connected devices keep their sessions.
+func (s *Server) Configure(cfg core.WGServerConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cfg = cfg
+	if !cfg.Enabled {
+		s.stopLocked()
+		return nil
+	}
+	st, err := parseSettings(cfg)
+	if err != nil {
+		s.stopLocked()
+		return err
+	}
+
+	if old := s.settings; old != nil && sameInterface(old, st) {
+		if uapi := peersUAPI(old.peers, st.peers); uapi != "" {
+			if err := s.dev.IpcSet(uapi); err != nil {
+				return fmt.Errorf("[WGServer] update peers: %w", err)
+			}
+		}
+		s.settings = st
+		s.setPeers(st.peers)
+		core.Log.Infof("WGServer", "Peers updated (%d enabled)", len(st.peers))
+		return nil
+	}
+
+	s.stopLocked()
+	return s.startLocked(st)
+}
+
+// sameInterface reports whether a and b differ only in their peers.
+func sameInterface(a, b *settings) bool {
+	return a.listenPort == b.listenPort && a.privateKey == b.privateKey &&
+		a.prefix == b.prefix && a.mtu == b.mtu && slices.Equal(a.obfuscation, b.obfuscation)
+}
+
+func (s *Server) startLocked(st *settings) error {
+	tunDev, tnet, err := netstack.CreateNetTUN([]netip.Addr{st.prefix.Addr()}, nil, st.mtu)
+	if err != nil {
+		return fmt.Errorf("[WGServer] create netstack TUN: %w", err)
+	}
+	logger := device.NewLogger(device.LogLevelError, "[WGServer] ")
+	dev := device.NewDevice(tunDev, conn.NewDefaultBind(), logger)
+
+	if err := dev.IpcSet(st.interfaceUAPI() + peersUAPI(nil, st.peers)); err != nil {
+		dev.Close()
+		return fmt.Errorf("[WGServer] apply config: %w", err)
+	}
+
+	ns, err := newNetStack(st.mtu, tnet.InjectOutbound, s.handleTCP, s.handleUDP)
+	if err != nil {
+		dev.Close()
+		return fmt.Errorf("[WGServer] %w", err)
+	}
+	tnet.SetInboundHandler(ns.deliver)
+
+	if err := dev.Up(); err != nil {
+		tnet.SetInboundHandler(nil)
+		ns.close()
+		dev.Close()
+		return fmt.Errorf("[WGServer] device up: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.settings = st
+	s.dev = dev
+	s.tnet = tnet
+	s.stack = ns
+	s.cancel = cancel
+	s.setPeers(st.peers)
+	if s.opts.OnPeerEndpoint != nil {
+		core.SafeGo("wgserver.endpoints", func() { s.watchEndpoints(ctx, dev) })
+	}
+
+	mode := "WireGuard"
+	if len(st.obfuscation) > 0 {
+		mode = "AmneziaWG"
+	}
+	core.Log.Infof("WGServer", "%s server listening on UDP %d (ip=%s, %d peers)",
+		mode, st.listenPort, st.prefix, len(st.peers))
+	return nil
+}
+
+// Stop shuts the listener down. Configure starts it again.
+func (s *Server) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stopLocked()
+}
+
+func (s *Server) stopLocked() {
+	if s.settings == nil {
+		return
+	}
+	s.cancel()
+	s.tnet.SetInboundHandler(nil)
+	s.stack.close()
+	s.dev.Close()
+	s.settings, s.dev, s.tnet, s.stack, s.cancel = nil, nil, nil, nil, nil
+	s.setPeers(nil)
+	core.Log.Infof("WGServer", "Server stopped")
+}
+
+// Running reports whether the listener is up.
+func (s *Server) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.settings != nil
+}
+
+// PublicKey returns the server public key, or "" if the server is stopped.
+func (s *Server) PublicKey() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.settings == nil {
+		return ""
+	}
+	return s.settings.publicKey
+}
+
+// Peers returns every configured peer, with runtime state for connected ones.
+func (s *Server) Peers() []PeerStatus {
+	s.mu.Lock()
+	cfg := s.cfg
+	dev := s.dev
+	s.mu.Unlock()
+
+	var addrs []netip.Addr
+	if pfx, err := serverPrefix(cfg); err == nil {
+		addrs, _ = peerAddresses(cfg, pfx)
+	}
+	var runtime map[string]wgconf.PeerStatus
+	if dev != nil {
+		if ipc, err := dev.IpcGet(); err == nil {
+			runtime = make(map[string]wgconf.PeerStatus)
+			for _, ps := range wgconf.ParsePeerStatus(ipc) {
+				runtime[ps.PublicKey] = ps
+			}
+		}
+	}
+
+	out := make([]PeerStatus, 0, len(cfg.Peers))
+	for i, p := range cfg.Peers {
+		st := PeerStatus{
+			Name:      p.Name,
+			PublicKey: p.PublicKey,
+			Enabled:   p.IsEnabled(),
+			HasConfig: p.PrivateKey != "",
+		}
+		if i < len(addrs) {
+			st.Address = addrs[i]
+		}
+		if pubHex, err := base64ToHex(p.PublicKey); err == nil {
+			if ps, ok := runtime[pubHex]; ok {
+				st.Endpoint = ps.Endpoint
+				st.LastHandshake = ps.LastHandshake
+				st.RxBytes = ps.RxBytes
+				st.TxBytes = ps.TxBytes
+			}
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+func (s *Server) setPeers(peers []peer) {
+	m := make(map[netip.Addr]peer, len(peers))
+	for _, p := range peers {
+		m[p.addr] = p
+	}
+	s.peers.Store(&m)
+}
+
+// peerFor returns the peer owning a tunnel address. WireGuard only accepts
+// packets whose source is within the sending peer's allowed IPs, so the
+// source address reliably identifies the peer.
+func (s *Server) peerFor(addr netip.Addr) (peer, bool) {
+	m := s.peers.Load()
+	if m == nil {
+		return peer{}, false
+	}
+	p, ok := (*m)[addr]
+	return p, ok
+}
+
+// watchEndpoints reports new peer endpoint addresses to OnPeerEndpoint.
+func (s *Server) watchEndpoints(ctx context.Context, dev *device.Device) {
+	ticker := time.NewTicker(endpointPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		ipc, err := dev.IpcGet()
+		if err != nil {
+			continue
+		}
+		for _, ps := range wgconf.ParsePeerStatus(ipc) {
+			ap, err := netip.ParseAddrPort(ps.Endpoint)
+			if err != nil {
+				continue
+			}
+			s.mu.Lock()
+			seen := s.endpoints[ap.Addr()]
+			s.endpoints[ap.Addr()] = true
+			s.mu.Unlock()
+			if !seen {
+				core.Log.Debugf("WGServer", "New peer endpoint %s", ap.Addr())
+				s.opts.OnPeerEndpoint(ap.Addr())
+			}
+		}
+	}
+}
+
+// errDropped is returned for flows the router decided to drop.
+var errDropped = errors.New("dropped by routing rules")
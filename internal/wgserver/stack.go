@@ -0,0 +1,104 @@
+package wgserver
+
+import (
+	"context"
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/channel"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+
+	"awg-split-tunnel/internal/core"
+)
+
+const (
+	nicID = 1
+	// channelSize is the outbound queue of the link endpoint, in packets.
+	channelSize = 1024
+	// tcpMaxInFlight caps TCP handshakes being dialed upstream at once.
+	tcpMaxInFlight = 1024
+)
+
+// netStack terminates peer TCP and UDP flows in a userspace gVisor stack.
+// The NIC is promiscuous and may spoof, so it accepts connections to any
+// destination and answers from that destination's address, like a
+// transparent proxy.
+type netStack struct {
+	stack  *stack.Stack
+	ep     *channel.Endpoint
+	cancel context.CancelFunc
+}
+
+// newNetStack creates the stack. Packets it emits are passed to output;
+// new flows are handed to the TCP and UDP forwarder callbacks.
+func newNetStack(mtu int, output func(pkt []byte) bool,
+	onTCP func(*tcp.ForwarderRequest), onUDP func(*udp.ForwarderRequest) bool) (*netStack, error) {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{tcp.NewProtocol, udp.NewProtocol},
+	})
+	sack := tcpip.TCPSACKEnabled(true)
+	s.SetTransportProtocolOption(tcp.ProtocolNumber, &sack)
+
+	ep := channel.New(channelSize, uint32(mtu), "")
+	if err := s.CreateNIC(nicID, ep); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("create NIC: %s", err)
+	}
+	if err := s.SetPromiscuousMode(nicID, true); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("promiscuous mode: %s", err)
+	}
+	if err := s.SetSpoofing(nicID, true); err != nil {
+		s.Close()
+		return nil, fmt.Errorf("spoofing: %s", err)
+	}
+	s.SetRouteTable([]tcpip.Route{{Destination: header.IPv4EmptySubnet, NIC: nicID}})
+
+	s.SetTransportProtocolHandler(tcp.ProtocolNumber, tcp.NewForwarder(s, 0, tcpMaxInFlight, onTCP).HandlePacket)
+	s.SetTransportProtocolHandler(udp.ProtocolNumber, udp.NewForwarder(s, onUDP).HandlePacket)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n := &netStack{stack: s, ep: ep, cancel: cancel}
+	core.SafeGo("wgserver.stack-output", func() { n.outputLoop(ctx, output) })
+	return n, nil
+}
+
+// deliver injects a decrypted IPv4 packet from a peer into the stack.
+// It always consumes the packet: peers have nothing else to talk to.
+func (n *netStack) deliver(pkt []byte) bool {
+	if len(pkt) == 0 || pkt[0]>>4 != 4 {
+		return true
+	}
+	pb := stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buffer.MakeWithData(pkt)})
+	n.ep.InjectInbound(ipv4.ProtocolNumber, pb)
+	pb.DecRef()
+	return true
+}
+
+// outputLoop moves packets emitted by the stack to the WireGuard device.
+func (n *netStack) outputLoop(ctx context.Context, output func(pkt []byte) bool) {
+	for {
+		pb := n.ep.ReadContext(ctx)
+		if pb == nil {
+			return
+		}
+		view := pb.ToView()
+		output(view.AsSlice())
+		view.Release()
+		pb.DecRef()
+	}
+}
+
+// close stops the output loop and tears down every flow.
+func (n *netStack) close() {
+	n.cancel()
+	n.ep.Close()
+	n.stack.Close()
+	n.stack.Wait()
+}
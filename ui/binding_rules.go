@@ -20,6 +20,7 @@ type RuleInfo struct {
 	Priority string `json:"priority"` // "auto", "realtime", "normal", "low"
 	Active   bool   `json:"active"`   // tunnel is connected, rule is active
 	Enabled  bool   `json:"enabled"`  // user can disable rule without deleting it
+	UDPNAT   string `json:"udpNat"`   // "" (symmetric) or "full_cone"
 }
 
 func fallbackStr(f vpnapi.FallbackPolicy) string {
@@ -68,6 +69,7 @@ func (b *BindingService) ListRules() ([]RuleInfo, error) {
 			Priority: prio,
 			Active:   r.Active,
 			Enabled:  r.Enabled,
+			UDPNAT:   r.UdpNat,
 		})
 	}
 	return rules, nil
@@ -86,6 +88,7 @@ func (b *BindingService) SaveRules(rules []RuleInfo) error {
 			Fallback: fallbackFromStr(r.Fallback),
 			Priority: prio,
 			Enabled:  r.Enabled,
+			UdpNat:   r.UDPNAT,
 		})
 	}
 	resp, err := b.client.Service.SaveRules(context.Background(), &vpnapi.SaveRulesRequest{Rules: protoRules})
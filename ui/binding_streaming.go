@@ -124,7 +124,7 @@ func (b *BindingService) runStatsStream() {
 			prev, hasPrev := prevStates[t.TunnelId]
 			if hasPrev {
 				if prev == vpnapi.TunnelState_TUNNEL_STATE_UP && t.State == vpnapi.TunnelState_TUNNEL_STATE_ERROR {
-					if tunnelProtocols[t.TunnelId] == "anyconnect" {
+					if isSSLVPNProtocol(tunnelProtocols[t.TunnelId]) {
 						b.notifMgr.NotifyAuthRequired(t.TunnelId)
 						if visible {
 							app.Event.Emit("auth-required", map[string]interface{}{
@@ -140,7 +140,7 @@ func (b *BindingService) runStatsStream() {
 				}
 				// Session resume detection: UP → CONNECTING means auto-reconnect in progress.
 				if prev == vpnapi.TunnelState_TUNNEL_STATE_UP && t.State == vpnapi.TunnelState_TUNNEL_STATE_CONNECTING {
-					if tunnelProtocols[t.TunnelId] == "anyconnect" {
+					if isSSLVPNProtocol(tunnelProtocols[t.TunnelId]) {
 						b.notifMgr.NotifySessionResuming(t.TunnelId)
 						if visible {
 							app.Event.Emit("tunnel-resuming", map[string]interface{}{
@@ -196,6 +196,16 @@ func (b *BindingService) loadTunnelProtocols() map[string]string {
 	return result
 }
 
+// isSSLVPNProtocol reports whether a protocol uses cookie sessions with
+// interactive (OTP/SAML) re-authentication.
+func isSSLVPNProtocol(protocol string) bool {
+	switch protocol {
+	case "anyconnect", "globalprotect", "fortinet":
+		return true
+	}
+	return false
+}
+
 func logLevelStr(l vpnapi.LogLevel) string {
 	switch l {
 	case vpnapi.LogLevel_LOG_LEVEL_DEBUG:
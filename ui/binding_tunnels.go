@@ -29,6 +29,7 @@ type TunnelInfo struct {
 	AdapterIP   string            `json:"adapterIp"`
 	ExternalIP  string            `json:"externalIp"`
 	CountryCode string            `json:"countryCode"`
+	PathMTU     int               `json:"pathMtu"` // 0 = unknown
 	SortIndex   int               `json:"sortIndex"`
 	Settings    map[string]string `json:"settings"`
 }
@@ -62,6 +63,7 @@ func (b *BindingService) ListTunnels() ([]TunnelInfo, error) {
 			AdapterIP:   t.AdapterIp,
 			ExternalIP:  t.ExternalIp,
 			CountryCode: t.CountryCode,
+			PathMTU:     int(t.PathMtu),
 			SortIndex:   int(t.SortIndex),
 		}
 		if t.Config != nil {